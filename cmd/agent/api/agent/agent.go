@@ -12,8 +12,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"io"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -22,10 +24,13 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
 	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -36,6 +41,9 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
+// streamLogsBufferSize is the number of messages buffered for each stream-logs client
+const streamLogsBufferSize = 1000
+
 // SetupHandlers adds the specific handlers for /agent endpoints
 func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/version", common.GetVersion).Methods("GET")
@@ -57,6 +65,7 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/config/{setting}", setRuntimeConfig).Methods("POST")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
+	r.HandleFunc("/stream-logs", streamLogs).Methods("POST")
 }
 
 func stopAgent(w http.ResponseWriter, r *http.Request) {
@@ -234,6 +243,63 @@ func getHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonHealth)
 }
 
+// streamLogs streams the processed log messages matching the filters of the
+// request body as NDJSON, until the client closes the connection
+func streamLogs(w http.ResponseWriter, r *http.Request) {
+	var filters diagnostic.Filters
+	if err := json.NewDecoder(r.Body).Decode(&filters); err != nil && err != io.EOF {
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid filters: %v", err)})
+		http.Error(w, string(body), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		body, _ := json.Marshal(map[string]string{"error": "streaming is not supported"})
+		http.Error(w, string(body), http.StatusInternalServerError)
+		return
+	}
+	if !logsStatus.Get().IsRunning {
+		body, _ := json.Marshal(map[string]string{"error": "the logs agent is not running"})
+		http.Error(w, string(body), http.StatusServiceUnavailable)
+		return
+	}
+
+	messages, unsubscribe, err := diagnostic.Default.Subscribe(filters, streamLogsBufferSize)
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if dropped := unsubscribe(); dropped > 0 {
+			log.Infof("%d log messages were dropped while streaming them to a slow client", dropped)
+		}
+	}()
+
+	// The stream lasts longer than the server write timeout
+	if conn := apiutil.GetConnection(r); conn != nil {
+		conn.SetWriteDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-messages:
+			if err := encoder.Encode(m); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 func getCSRFToken(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(gui.CsrfToken))
 }
@@ -260,6 +326,7 @@ func getConfigCheck(w http.ResponseWriter, r *http.Request) {
 	response.ResolveWarnings = autodiscovery.GetResolveWarnings()
 	response.ConfigErrors = autodiscovery.GetConfigErrors()
 	response.Unresolved = common.AC.GetUnresolvedTemplates()
+	response.ScheduleHistory = common.AC.GetScheduleHistory()
 
 	jsonConfig, err := json.Marshal(response)
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
 	"github.com/DataDog/datadog-agent/pkg/status"
@@ -45,7 +46,8 @@ func getJMXConfigs(w http.ResponseWriter, r *http.Request) {
 	j := map[string]interface{}{}
 	configs := map[string]integration.JSONMap{}
 
-	for name, config := range jmx.GetScheduledConfigs() {
+	// each JMXFetch process authenticates with a token scoped to its process group
+	for name, config := range jmx.GetScheduledConfigsForGroup(apiutil.GetTokenScope(r)) {
 		var rawInitConfig integration.RawMap
 		err := yaml.Unmarshal(config.InitConfig, &rawInitConfig)
 		if err != nil {
@@ -94,5 +96,5 @@ func setJMXStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), 500)
 	}
 
-	status.SetJMXStatus(jmxStatus)
+	status.SetJMXGroupStatus(apiutil.GetTokenScope(r), jmxStatus)
 }
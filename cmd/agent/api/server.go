@@ -22,6 +22,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/agent"
 	"github.com/DataDog/datadog-agent/cmd/agent/api/check"
+	"github.com/DataDog/datadog-agent/pkg/api/audit"
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -43,6 +44,8 @@ func StartServer() error {
 
 	// Validate token for every request
 	r.Use(validateToken)
+	// Audit the commands of the authenticated requests
+	r.Use(audit.Middleware)
 
 	// get the transport we're going to use under HTTP
 	var err error
@@ -86,6 +89,8 @@ func StartServer() error {
 		}, "Error from the agent http API server: ", 0), // log errors to seelog,
 		TLSConfig:    &tlsConfig,
 		WriteTimeout: config.Datadog.GetDuration("server_timeout") * time.Second,
+		// Give the streaming endpoints access to the connection to lift the write timeout
+		ConnContext: util.ConnContext,
 	}
 	tlsListener := tls.NewListener(listener, &tlsConfig)
 
@@ -10,6 +10,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"io/ioutil"
+	"os"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
@@ -23,6 +25,9 @@ func init() {
 	configCommand.AddCommand(listRuntimeCommand)
 	configCommand.AddCommand(setCommand)
 	configCommand.AddCommand(getCommand)
+	configCommand.AddCommand(migrateCommand)
+
+	migrateCommand.Flags().BoolVarP(&migrateDryRun, "dry-run", "n", false, "print the changes without writing the configuration file")
 }
 
 var (
@@ -50,6 +55,13 @@ var (
 		Long:  ``,
 		RunE:  getConfigValue,
 	}
+	migrateCommand = &cobra.Command{
+		Use:   "migrate",
+		Short: "Replace the deprecated settings of the configuration file",
+		Long:  `Rename the deprecated settings of the configuration file to their replacement, keeping a backup of the original file.`,
+		RunE:  migrateConfig,
+	}
+	migrateDryRun      bool
 	agentConfigURLPath = "/agent/config"
 	listRuntimeURLPath = agentConfigURLPath + "/list-runtime"
 )
@@ -209,3 +221,46 @@ func getConfigValue(cmd *cobra.Command, args []string) error {
 	}
 	return fmt.Errorf("unable to get value for this setting: %v", args[0])
 }
+
+func migrateConfig(cmd *cobra.Command, args []string) error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfigWithoutSecrets(confFilePath, "")
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	path := config.Datadog.ConfigFileUsed()
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("unable to read the configuration file: %v", err)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read the configuration file: %v", err)
+	}
+
+	migrated, notes := config.MigrateDeprecatedSettings(content)
+	if len(notes) == 0 {
+		fmt.Printf("No deprecated setting found in %s\n", path)
+		return nil
+	}
+	for _, note := range notes {
+		fmt.Printf("%s: %s\n", path, note)
+	}
+	if migrateDryRun || bytes.Equal(content, migrated) {
+		return nil
+	}
+
+	backup := path + ".bak"
+	if err := ioutil.WriteFile(backup, content, info.Mode()); err != nil {
+		return fmt.Errorf("unable to back up the configuration file: %v", err)
+	}
+	if err := ioutil.WriteFile(path, migrated, info.Mode()); err != nil {
+		return fmt.Errorf("unable to write the configuration file: %v", err)
+	}
+	fmt.Println(color.GreenString(fmt.Sprintf("%s migrated, the original file is saved as %s. Restart the Agent to apply the changes.", path, backup)))
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 
 func init() {
 	AgentCmd.AddCommand(diagnoseCommand)
+	diagnoseCommand.AddCommand(diagnoseProxyCommand)
 }
 
 var diagnoseCommand = &cobra.Command{
@@ -27,6 +28,26 @@ var diagnoseCommand = &cobra.Command{
 	RunE:  doDiagnose,
 }
 
+var diagnoseProxyCommand = &cobra.Command{
+	Use:   "proxy",
+	Short: "Show the proxy used to reach each intake URL",
+	Long:  ``,
+	RunE:  doDiagnoseProxy,
+}
+
+func doDiagnoseProxy(cmd *cobra.Command, args []string) error {
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	return diagnose.RunProxy(color.Output)
+}
+
 func doDiagnose(cmd *cobra.Command, args []string) error {
 	// Global config setup
 	err := common.SetupConfig(confFilePath)
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"runtime"
 	"syscall"
+	"time"
 
 	_ "expvar" // Blank import used because this isn't directly used in this file
 	"net/http"
@@ -25,20 +26,29 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/api/audit"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
+	"github.com/DataDog/datadog-agent/pkg/api/localapi"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/remote"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
+	"github.com/DataDog/datadog-agent/pkg/epforwarder"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
-	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/updater"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/autotune"
+	"github.com/DataDog/datadog-agent/pkg/util/crashreport"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/memorypressure"
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	"github.com/spf13/cobra"
 
@@ -47,7 +57,9 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/ebpf"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/iis"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/net"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/sqlserver"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/systemd"
 
@@ -68,6 +80,10 @@ var (
 var (
 	// flags variables
 	pidfilePath string
+
+	remoteConfig          *remote.Client
+	agentUpdater          *updater.Updater
+	memoryPressureMonitor *memorypressure.Monitor
 )
 
 func init() {
@@ -84,6 +100,8 @@ func run(cmd *cobra.Command, args []string) error {
 	defer func() {
 		StopAgent()
 	}()
+	// Persist the panics of the main goroutine before StopAgent runs
+	defer crashreport.Recover()
 
 	// Setup a channel to catch OS signals
 	signalCh := make(chan os.Signal, 1)
@@ -179,6 +197,21 @@ func StartAgent() error {
 
 	log.Infof("Starting Datadog Agent v%v", version.AgentVersion)
 
+	// tune to the cgroup limits before the forwarder and dogstatsd read their settings
+	autotune.Apply()
+
+	if memoryPressureMonitor = memorypressure.NewMonitorFromConfig(); memoryPressureMonitor != nil {
+		memoryPressureMonitor.Start()
+	}
+
+	if err := payloadaudit.StartFromConfig(); err != nil {
+		log.Errorf("Could not start the payload audit: %v", err)
+	}
+
+	if err := crashreport.Init("agent"); err != nil {
+		log.Errorf("Could not set up the crash reports: %v", err)
+	}
+
 	// init settings that can be changed at runtime
 	if err := settings.InitRuntimeSettings(); err != nil {
 		log.Warnf("Can't initiliaze the runtime settings: %v", err)
@@ -201,6 +234,14 @@ func StartAgent() error {
 		log.Debugf("Health check listening on port %d", healthPort)
 	}
 
+	// Setup the local API socket
+	if socketPath := config.Datadog.GetString("local_api_socket"); socketPath != "" {
+		if err := localapi.Serve(common.MainCtx, socketPath); err != nil {
+			return log.Errorf("Error starting the local API, exiting: %v", err)
+		}
+		log.Debugf("Local API listening on %s", socketPath)
+	}
+
 	if pidfilePath != "" {
 		err = pidfile.WritePID(pidfilePath)
 		if err != nil {
@@ -224,6 +265,15 @@ func StartAgent() error {
 
 	// start the cmd HTTP server
 	if runtime.GOOS != "android" {
+		if config.Datadog.GetBool("ipc_audit.enabled") {
+			logFile := config.Datadog.GetString("log_file")
+			if logFile == "" {
+				logFile = common.DefaultLogFile
+			}
+			if err := audit.Init(audit.FilePath(logFile), int64(config.Datadog.GetSizeInBytes("ipc_audit.max_size"))); err != nil {
+				log.Errorf("Could not set up the IPC audit log: %v", err)
+			}
+		}
 		if err = api.StartServer(); err != nil {
 			return log.Errorf("Error while starting api server, exiting: %v", err)
 		}
@@ -266,7 +316,11 @@ func StartAgent() error {
 	// setup the aggregator
 	s := serializer.NewSerializer(common.Forwarder)
 	agg := aggregator.InitAggregator(s, hostname, agentName)
+	epforwarder.InitEventPlatformForwarder(common.Forwarder)
 	agg.AddAgentStartupTelemetry(version.AgentVersion)
+	if sender, err := aggregator.GetDefaultSender(); err == nil {
+		crashreport.SubmitTelemetry(sender)
+	}
 
 	// start dogstatsd
 	if config.Datadog.GetBool("use_dogstatsd") {
@@ -278,18 +332,9 @@ func StartAgent() error {
 	}
 	log.Debugf("statsd started")
 
-	// start logs-agent
-	if config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled") {
-		if config.Datadog.GetBool("log_enabled") {
-			log.Warn(`"log_enabled" is deprecated, use "logs_enabled" instead`)
-		}
-		err := logs.Start()
-		if err != nil {
-			log.Error("Could not start logs-agent: ", err)
-		}
-	} else {
-		log.Info("logs-agent disabled")
-	}
+	// start the optional subsystems part of this build, like logs-agent
+	log.Debugf("Subsystems part of this build: %v", subsystem.Names())
+	subsystem.StartAll()
 
 	if err = common.SetupSystemProbeConfig(sysProbeConfFilePath); err != nil {
 		log.Infof("System probe config not found, disabling pulling system probe info in the status page: %v", err)
@@ -309,17 +354,55 @@ func StartAgent() error {
 		return err
 	}
 
+	if tagsFile := config.Datadog.GetString("tags_file"); tagsFile != "" && common.MetadataScheduler.IsScheduled("host") {
+		host.StartTagsFileWatcher(common.MetadataScheduler, tagsFile, config.Datadog.GetDuration("tags_file_check_interval")*time.Second)
+	}
+
 	if config.Datadog.GetBool("inventories_enabled") {
 		if err := metadata.SetupInventories(common.MetadataScheduler, common.AC, common.Coll); err != nil {
 			return err
 		}
 	}
 
+	if config.Datadog.GetBool("container_images_enabled") {
+		if err := metadata.SetupContainerImages(common.MetadataScheduler); err != nil {
+			return err
+		}
+	}
+
+	startRemoteConfiguration()
+
 	// start dependent services
 	startDependentServices()
 	return nil
 }
 
+// startRemoteConfiguration starts the remote configuration client and the
+// subsystems subscribing to it
+func startRemoteConfiguration() {
+	var err error
+	if remoteConfig, err = remote.NewClientFromConfig(); err != nil {
+		log.Errorf("Could not start the remote configuration client: %v", err)
+	}
+
+	if agentUpdater, err = updater.NewUpdaterFromConfig(); err != nil {
+		log.Errorf("Could not start the agent updater: %v", err)
+	} else if agentUpdater != nil {
+		// the updater checks the last upgrade even without remote configuration
+		agentUpdater.Start()
+		if remoteConfig != nil {
+			remoteConfig.Subscribe(remote.ProductAgentUpdates, agentUpdater.OnCatalog)
+		} else {
+			log.Warn("The agent updater requires the remote configuration, the agent won't be upgraded")
+		}
+	}
+
+	if remoteConfig != nil {
+		remoteConfig.Subscribe(remote.ProductNetworkPath, settings.OnNetworkPathTriggers)
+		remoteConfig.Start()
+	}
+}
+
 // StopAgent Tears down the agent process
 func StopAgent() {
 	// retrieve the agent health before stopping the components
@@ -343,16 +426,28 @@ func StopAgent() {
 	if common.MetadataScheduler != nil {
 		common.MetadataScheduler.Stop()
 	}
+	if remoteConfig != nil {
+		remoteConfig.Stop()
+	}
+	if agentUpdater != nil {
+		agentUpdater.Stop()
+	}
+	if memoryPressureMonitor != nil {
+		memoryPressureMonitor.Stop()
+	}
+	payloadaudit.Stop()
 	api.StopServer()
 	clcrunnerapi.StopCLCRunnerServer()
 	jmx.StopJmxfetch()
 	aggregator.StopDefaultAggregator()
+	epforwarder.StopEventPlatformForwarder()
 	if common.Forwarder != nil {
 		common.Forwarder.Stop()
 	}
-	logs.Stop()
+	subsystem.StopAll()
 	gui.StopGUIServer()
 	os.Remove(pidfilePath)
+	crashreport.Stop()
 	log.Info("See ya!")
 	log.Flush()
 }
@@ -35,10 +35,16 @@ func InitRuntimeSettings() error {
 	if err := registerRuntimeSetting(logLevelRuntimeSetting("log_level")); err != nil {
 		return err
 	}
+	if err := registerRuntimeSetting(logLevelOverridesRuntimeSetting("log_level_overrides")); err != nil {
+		return err
+	}
 	if err := registerRuntimeSetting(dsdStatsRuntimeSetting("dogstatsd_stats")); err != nil {
 		return err
 	}
-	return nil
+	if err := registerRuntimeSetting(payloadAuditRuntimeSetting("payload_audit")); err != nil {
+		return err
+	}
+	return registerSystemProbeRuntimeSettings()
 }
 
 // RegisterRuntimeSettings keeps track of configurable settings
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// logLevelOverridesRuntimeSetting wraps operations to change the log levels of components at runtime.
+type logLevelOverridesRuntimeSetting string
+
+func (l logLevelOverridesRuntimeSetting) Description() string {
+	return "Set/get the log levels overriding log_level for some components, e.g. forwarder:debug,collector:trace. Set to an empty value to remove them"
+}
+
+func (l logLevelOverridesRuntimeSetting) Name() string {
+	return string(l)
+}
+
+func (l logLevelOverridesRuntimeSetting) Get() (interface{}, error) {
+	overrides, err := log.GetLogLevelOverrides()
+	if err != nil {
+		return "", err
+	}
+	return formatLogLevelOverrides(overrides), nil
+}
+
+func (l logLevelOverridesRuntimeSetting) Set(v interface{}) error {
+	var overrides map[string]string
+	switch value := v.(type) {
+	case string:
+		var err error
+		if overrides, err = parseLogLevelOverrides(value); err != nil {
+			return fmt.Errorf("logLevelOverridesRuntimeSetting: %v", err)
+		}
+	case map[string]string:
+		overrides = value
+	default:
+		return fmt.Errorf("logLevelOverridesRuntimeSetting: unexpected value type %T", v)
+	}
+
+	if err := config.ChangeLogLevelOverrides(overrides); err != nil {
+		return err
+	}
+	config.Datadog.Set("log_level_overrides", overrides)
+	return nil
+}
+
+// parseLogLevelOverrides parses log levels by component in the component:level,... format
+func parseLogLevelOverrides(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, override := range strings.Split(s, ",") {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+		parts := strings.SplitN(override, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid log level override %q, expected component:level", override)
+		}
+		overrides[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return overrides, nil
+}
+
+// formatLogLevelOverrides formats log levels by component in the component:level,... format
+func formatLogLevelOverrides(overrides map[string]string) string {
+	parts := make([]string, 0, len(overrides))
+	for component, level := range overrides {
+		parts = append(parts, component+":"+level)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
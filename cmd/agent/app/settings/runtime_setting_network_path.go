@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build process,!windows
+
+package settings
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// networkPathRuntimeSetting wraps operations to trace the network paths with system-probe on demand.
+type networkPathRuntimeSetting string
+
+func (s networkPathRuntimeSetting) Description() string {
+	return "Set to true to trace the network paths toward the system_probe_config.network_path.targets now, reads whether a trace is running. Possible values: true, false"
+}
+
+func (s networkPathRuntimeSetting) Name() string {
+	return string(s)
+}
+
+func (s networkPathRuntimeSetting) Get() (interface{}, error) {
+	probeUtil, err := getSystemProbeUtil()
+	if err != nil {
+		return nil, err
+	}
+	return probeUtil.IsNetworkPathRunning()
+}
+
+func (s networkPathRuntimeSetting) Set(v interface{}) error {
+	var newValue bool
+	var err error
+
+	if newValue, err = getBool(v); err != nil {
+		return fmt.Errorf("networkPathRuntimeSetting: %v", err)
+	}
+	if !newValue {
+		// A running trace stops by itself
+		return nil
+	}
+
+	probeUtil, err := getSystemProbeUtil()
+	if err != nil {
+		return err
+	}
+	triggered, err := probeUtil.TriggerNetworkPath()
+	if err != nil {
+		return err
+	}
+	if !triggered {
+		log.Info("The network paths are already being traced")
+	}
+	return nil
+}
+
+// networkPathTriggers maps the trigger files of the remote configuration to
+// their content, the remote configuration callbacks being called sequentially
+var networkPathTriggers = make(map[string]string)
+
+// OnNetworkPathTriggers traces the network paths toward the system-probe
+// targets when the remote configuration adds or changes a trigger file
+func OnNetworkPathTriggers(files map[string][]byte) {
+	triggered := false
+	triggers := make(map[string]string, len(files))
+	for path, content := range files {
+		if networkPathTriggers[path] != string(content) {
+			triggered = true
+		}
+		triggers[path] = string(content)
+	}
+	networkPathTriggers = triggers
+
+	if !triggered {
+		return
+	}
+	if err := networkPathRuntimeSetting("network_path_trace").Set(true); err != nil {
+		log.Errorf("Could not trace the network paths requested by the remote configuration: %v", err)
+	}
+}
+
+func getSystemProbeUtil() (*net.RemoteSysProbeUtil, error) {
+	net.SetSystemProbePath(config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+	return net.GetRemoteSystemProbeUtil()
+}
+
+func registerSystemProbeRuntimeSettings() error {
+	return registerRuntimeSetting(networkPathRuntimeSetting("network_path_trace"))
+}
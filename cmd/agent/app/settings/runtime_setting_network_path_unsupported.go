@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !process windows
+
+package settings
+
+// registerSystemProbeRuntimeSettings registers nothing on systems that do not
+// at least build the process agent
+func registerSystemProbeRuntimeSettings() error {
+	return nil
+}
+
+// OnNetworkPathTriggers does nothing on systems that do not at least build
+// the process agent
+func OnNetworkPathTriggers(files map[string][]byte) {}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package settings
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
+)
+
+// payloadAuditRuntimeSetting wraps operations to start and stop the payload audit at runtime.
+type payloadAuditRuntimeSetting string
+
+func (s payloadAuditRuntimeSetting) Description() string {
+	return "Start/stop recording scrubbed copies of the outgoing payloads with the payload_audit settings. Possible values: true, false"
+}
+
+func (s payloadAuditRuntimeSetting) Name() string {
+	return string(s)
+}
+
+func (s payloadAuditRuntimeSetting) Get() (interface{}, error) {
+	return payloadaudit.Enabled(), nil
+}
+
+func (s payloadAuditRuntimeSetting) Set(v interface{}) error {
+	var newValue bool
+	var err error
+
+	if newValue, err = getBool(v); err != nil {
+		return fmt.Errorf("payloadAuditRuntimeSetting: %v", err)
+	}
+
+	if !newValue {
+		payloadaudit.Stop()
+		return nil
+	}
+	return payloadaudit.StartWithConfig()
+}
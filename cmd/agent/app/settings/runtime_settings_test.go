@@ -97,6 +97,35 @@ func TestLogLevel(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestLogLevelOverrides(t *testing.T) {
+	cleanRuntimeSetting()
+	config.SetupLogger("TEST", "info", "", "", true, true, true)
+
+	ll := logLevelOverridesRuntimeSetting("log_level_overrides")
+	assert.Equal(t, "log_level_overrides", ll.Name())
+
+	err := ll.Set("forwarder:debug, collector/corechecks:WARNING")
+	assert.Nil(t, err)
+
+	v, err := ll.Get()
+	assert.Equal(t, "collector/corechecks:warn,forwarder:debug", v)
+	assert.Nil(t, err)
+
+	err = ll.Set("forwarder")
+	assert.NotNil(t, err)
+
+	err = ll.Set("forwarder:invalid")
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown log level: invalid for component forwarder", err.Error())
+
+	err = ll.Set("")
+	assert.Nil(t, err)
+
+	v, err = ll.Get()
+	assert.Equal(t, "", v)
+	assert.Nil(t, err)
+}
+
 func TestDogstatsdMetricsStats(t *testing.T) {
 	assert := assert.New(t)
 	var err error
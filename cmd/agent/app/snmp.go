@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/snmp/profiles"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	AgentCmd.AddCommand(snmpCommand)
+	snmpCommand.AddCommand(validateProfilesCommand)
+}
+
+var (
+	snmpCommand = &cobra.Command{
+		Use:   "snmp",
+		Short: "SNMP related commands",
+		Long:  ``,
+	}
+	validateProfilesCommand = &cobra.Command{
+		Use:   "validate-profiles",
+		Short: "Validate the SNMP device profiles",
+		Long: `Load the SNMP device profiles of the SNMP integration and of the snmp_profiles.user_directories,
+resolve their extends, and report the invalid and conflicting sysobjectids and the symbols and tags without OID.`,
+		RunE: validateProfiles,
+	}
+)
+
+func validateProfiles(cmd *cobra.Command, args []string) error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	err := common.SetupConfigWithoutSecrets(confFilePath, "")
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+
+	dirs := profiles.Directories()
+	loaded, err := profiles.Load(dirs)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(loaded))
+	for name := range loaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("Loaded %d profiles from %v\n", len(loaded), dirs)
+	for _, name := range names {
+		if p := loaded[name]; p.Overrides != "" {
+			fmt.Printf("  %s: %s overrides %s\n", name, p.Path, p.Overrides)
+		}
+	}
+
+	issues := profiles.Validate(loaded)
+	if len(issues) == 0 {
+		fmt.Println(color.GreenString("No issues found"))
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", color.RedString(issue.String()))
+	}
+	return fmt.Errorf("found %d issues in the SNMP profiles", len(issues))
+}
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	streamLogsFilters  diagnostic.Filters
+	streamLogsDuration time.Duration
+	streamLogsCount    int
+	streamLogsOutput   string
+)
+
+func init() {
+	AgentCmd.AddCommand(streamLogsCmd)
+
+	streamLogsCmd.Flags().StringVar(&streamLogsFilters.Name, "name", "", "Only show the logs of the sources with this name, e.g. an integration name")
+	streamLogsCmd.Flags().StringVar(&streamLogsFilters.Type, "type", "", "Only show the logs of the sources of this type, e.g. file, docker, tcp")
+	streamLogsCmd.Flags().StringVar(&streamLogsFilters.Source, "source", "", "Only show the logs with this source")
+	streamLogsCmd.Flags().StringVar(&streamLogsFilters.Service, "service", "", "Only show the logs with this service")
+	streamLogsCmd.Flags().StringVar(&streamLogsFilters.Pattern, "pattern", "", "Only show the logs matching this regular expression, after the processing rules")
+	streamLogsCmd.Flags().DurationVarP(&streamLogsDuration, "duration", "d", 0, "Stop streaming after this duration, e.g. 30s")
+	streamLogsCmd.Flags().IntVar(&streamLogsCount, "count", 0, "Stop streaming after this number of logs")
+	streamLogsCmd.Flags().StringVarP(&streamLogsOutput, "output", "o", "text", "Output format: text or json (one JSON object per line)")
+}
+
+var streamLogsCmd = &cobra.Command{
+	Use:   "stream-logs",
+	Short: "Stream the logs processed by a running agent",
+	Long: `Stream the logs processed by a running agent, after the processing rules were
+applied, to check the logs collected and the effect of the processing rules live.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+		if streamLogsOutput != "text" && streamLogsOutput != "json" {
+			return fmt.Errorf("unknown output format %q, expected text or json", streamLogsOutput)
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return streamLogs(os.Stdout)
+	},
+}
+
+func streamLogs(w io.Writer) error {
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/stream-logs", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	if err = util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if streamLogsDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, streamLogsDuration)
+		defer cancel()
+	}
+	// Stop cleanly on Ctrl-C
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt)
+	defer signal.Stop(signalCh)
+	go func() {
+		select {
+		case <-signalCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	filters, err := json.Marshal(streamLogsFilters)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", urlstr, bytes.NewReader(filters))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+util.GetAuthToken())
+
+	resp, err := util.GetClient(false).Do(req) // FIX: get certificates right then make this true
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		fmt.Printf("Could not reach agent: %v \nMake sure the agent is running before streaming the logs and contact support if you continue having issues. \n", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		var errMap = make(map[string]string)
+		json.Unmarshal(body, &errMap) //nolint:errcheck
+		if e, found := errMap["error"]; found {
+			return fmt.Errorf("could not stream the logs: %s", e)
+		}
+		return fmt.Errorf("could not stream the logs: %s", strings.TrimSpace(string(body)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for count := 0; streamLogsCount <= 0 || count < streamLogsCount; count++ {
+		if !scanner.Scan() {
+			break
+		}
+		if err := printStreamedLog(w, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func printStreamedLog(w io.Writer, line []byte) error {
+	if streamLogsOutput == "json" {
+		_, err := fmt.Fprintln(w, string(line))
+		return err
+	}
+
+	var m diagnostic.Message
+	if err := json.Unmarshal(line, &m); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s | %s | Name: %s | Type: %s | Source: %s | Service: %s | Tags: %s | %s\n",
+		m.Timestamp.Format(time.RFC3339),
+		strings.ToUpper(m.Status),
+		m.Name,
+		m.Type,
+		m.Source,
+		m.Service,
+		strings.Join(m.Tags, ","),
+		m.Content,
+	)
+	return err
+}
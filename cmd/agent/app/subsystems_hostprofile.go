@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/hostprofile"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
+)
+
+var hostProfiler *hostprofile.Profiler
+
+func init() {
+	subsystem.Register(subsystem.Subsystem{
+		Name: "host_profile",
+		Enabled: func() bool {
+			return config.Datadog.GetBool("host_profile.enabled")
+		},
+		Start: func() error {
+			p, err := hostprofile.NewProfilerFromConfig(serializer.NewSerializer(common.Forwarder))
+			if err != nil {
+				return err
+			}
+			hostProfiler = p
+			hostProfiler.Start()
+			return nil
+		},
+		Stop: func() {
+			hostProfiler.Stop()
+		},
+	})
+}
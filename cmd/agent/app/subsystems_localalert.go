@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/localalert"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
+)
+
+var localAlertEngine *localalert.Engine
+
+func init() {
+	subsystem.Register(subsystem.Subsystem{
+		Name: "local_alerting",
+		Enabled: func() bool {
+			return config.Datadog.GetBool("local_alerting.enabled")
+		},
+		Start: func() error {
+			e, err := localalert.NewEngineFromConfig(forwarder.LastIntakeResponse)
+			if err != nil {
+				return err
+			}
+			localAlertEngine = e
+			aggregator.AddSeriesObserver(localAlertEngine.Evaluate)
+			return nil
+		},
+		Stop: func() {
+			localAlertEngine.Stop()
+		},
+	})
+}
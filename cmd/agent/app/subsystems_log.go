@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build log
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
+)
+
+func init() {
+	subsystem.Register(subsystem.Subsystem{
+		Name: "logs",
+		Enabled: func() bool {
+			return config.IsFeatureEnabled(config.LogsFeature)
+		},
+		Start: logs.Start,
+		Stop:  logs.Stop,
+		Scheduler: func() scheduler.Scheduler {
+			// avoid returning a non-nil interface holding a nil scheduler
+			if s := logs.GetScheduler(); s != nil {
+				return s
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/epforwarder"
+	"github.com/DataDog/datadog-agent/pkg/netflow"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
+)
+
+// netflowTrack is the event platform track of the flows
+const netflowTrack = "network-devices-netflow"
+
+var netflowServer *netflow.Server
+
+func init() {
+	subsystem.Register(subsystem.Subsystem{
+		Name: "netflow",
+		Enabled: func() bool {
+			return config.Datadog.GetBool("netflow.enabled")
+		},
+		Start: func() error {
+			c, err := netflow.ReadConfig()
+			if err != nil {
+				return err
+			}
+			s := netflow.NewServer(c, func(rawFlow []byte) error {
+				return epforwarder.SendEventPlatformEvent(rawFlow, netflowTrack)
+			})
+			if err := s.Start(); err != nil {
+				return err
+			}
+			netflowServer = s
+			return nil
+		},
+		Stop: func() {
+			netflowServer.Stop()
+		},
+	})
+}
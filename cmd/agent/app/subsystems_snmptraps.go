@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/epforwarder"
+	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
+)
+
+// snmpTrapsTrack is the event platform track of the SNMP traps
+const snmpTrapsTrack = "snmp-traps"
+
+var snmpTrapsServer *traps.Server
+
+func init() {
+	subsystem.Register(subsystem.Subsystem{
+		Name: "snmp_traps",
+		Enabled: func() bool {
+			return config.Datadog.GetBool("snmp_traps.enabled")
+		},
+		Start: func() error {
+			c, err := traps.ReadConfig()
+			if err != nil {
+				return err
+			}
+			s, err := traps.NewServer(c, func(rawEvent []byte) error {
+				return epforwarder.SendEventPlatformEvent(rawEvent, snmpTrapsTrack)
+			})
+			if err != nil {
+				return err
+			}
+			if err := s.Start(); err != nil {
+				return err
+			}
+			snmpTrapsServer = s
+			return nil
+		},
+		Stop: func() {
+			snmpTrapsServer.Stop()
+		},
+	})
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/collector"
 	"github.com/DataDog/datadog-agent/pkg/config"
-	"github.com/DataDog/datadog-agent/pkg/logs"
+	"github.com/DataDog/datadog-agent/pkg/subsystem"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -35,9 +35,9 @@ func SetupAutoConfig(confdPath string) {
 	// registering the check scheduler
 	metaScheduler.Register("check", collector.InitCheckScheduler(Coll))
 
-	// registering the logs scheduler
-	if logs.IsAgentRunning() {
-		metaScheduler.Register("logs", logs.GetScheduler())
+	// registering the schedulers of the running subsystems, like logs
+	for name, s := range subsystem.Schedulers() {
+		metaScheduler.Register(name, s)
 	}
 
 	// create the Autoconfig instance
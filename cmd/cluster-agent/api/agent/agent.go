@@ -142,6 +142,7 @@ func getConfigCheck(w http.ResponseWriter, r *http.Request) {
 	response.ResolveWarnings = autodiscovery.GetResolveWarnings()
 	response.ConfigErrors = autodiscovery.GetConfigErrors()
 	response.Unresolved = common.AC.GetUnresolvedTemplates()
+	response.ScheduleHistory = common.AC.GetScheduleHistory()
 
 	jsonConfig, err := json.Marshal(response)
 	if err != nil {
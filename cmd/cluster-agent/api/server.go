@@ -80,6 +80,19 @@ func StartServer(sc clusteragent.ServerContext) error {
 		Certificates: []tls.Certificate{rootTLSCert},
 	}
 
+	// Serve the cluster issued certificate to verify the node agents ones
+	mtlsMode, err := security.GetClusterAgentMTLSMode()
+	if err != nil {
+		return err
+	}
+	if mtlsMode != security.MTLSDisabled {
+		certReloader, err := security.NewClusterAgentCertReloader()
+		if err != nil {
+			return fmt.Errorf("unable to set up mutual TLS: %v", err)
+		}
+		tlsConfig = *certReloader.ServerTLSConfig(mtlsMode)
+	}
+
 	srv := &http.Server{
 		Handler: r,
 		ErrorLog: stdLog.New(&config.ErrorLogWriter{
@@ -104,6 +117,8 @@ func StopServer() {
 
 // We only want to maintain 1 API and expose an external route to serve the cluster level metadata.
 // As we have 2 different tokens for the validation, we need to validate accordingly.
+// Node agents presenting a certificate verified during the TLS handshake don't need the DCA token,
+// which isn't accepted anymore once mutual TLS is required.
 func validateToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.String()
@@ -112,8 +127,14 @@ func validateToken(next http.Handler) http.Handler {
 			if err := util.Validate(w, r); err == nil {
 				isValid = true
 			}
+		} else if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			isValid = true
 		}
 		if !isValid {
+			if mode, _ := security.GetClusterAgentMTLSMode(); mode == security.MTLSRequired {
+				http.Error(w, "a client certificate is required", http.StatusUnauthorized)
+				return
+			}
 			if err := util.ValidateDCARequest(w, r); err != nil {
 				return
 			}
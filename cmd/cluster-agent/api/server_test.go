@@ -6,11 +6,14 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/api/security"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
@@ -74,3 +77,44 @@ func TestValidateTokenMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTokenMiddlewareMTLS(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("cluster_agent.auth_token", "abc123")
+	util.InitDCAAuthToken()
+
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	tests := []struct {
+		mode               string
+		authToken          string
+		tlsState           *tls.ConnectionState
+		expectedStatusCode int
+	}{
+		{security.MTLSMigration, "abc123", nil, http.StatusOK},
+		{security.MTLSMigration, "", verified, http.StatusOK},
+		{security.MTLSMigration, "imposter", nil, http.StatusForbidden},
+		{security.MTLSRequired, "", verified, http.StatusOK},
+		{security.MTLSRequired, "abc123", nil, http.StatusUnauthorized},
+		{security.MTLSRequired, "abc123", &tls.ConnectionState{}, http.StatusUnauthorized},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			mockConfig.Set("cluster_agent.mtls.mode", tt.mode)
+			req, err := http.NewRequest("GET", "/version", nil)
+			require.NoError(t, err)
+			if tt.authToken != "" {
+				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", tt.authToken))
+			}
+			req.TLS = tt.tlsState
+
+			rr := httptest.NewRecorder()
+			handler := validateToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rr.Code)
+		})
+	}
+}
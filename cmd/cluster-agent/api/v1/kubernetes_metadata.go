@@ -16,6 +16,7 @@ func installKubernetesMetadataEndpoints(r *mux.Router) {
 	r.HandleFunc("/tags/pod/{nodeName}", getPodMetadataForNode).Methods("GET")
 	r.HandleFunc("/tags/pod", getAllMetadata).Methods("GET")
 	r.HandleFunc("/tags/node/{nodeName}", getNodeMetadata).Methods("GET")
+	r.HandleFunc("/tags/namespace/{ns}", getNamespaceMetadata).Methods("GET")
 }
 
 // getNodeMetadata is only used when the node agent hits the DCA for the list of labels
@@ -77,6 +78,55 @@ func getNodeMetadata(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(fmt.Sprintf("Could not find labels on the node: %s", nodeName)))
 }
 
+// getNamespaceMetadata is only used when the node agent hits the DCA for the labels and annotations of a namespace
+func getNamespaceMetadata(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/tags/namespace/default
+		Outputs
+			Status: 200
+			Returns: apiv1.NamespaceMetadata
+			Example: {"labels":{"team":"containers"},"annotations":{"cost-center":"1234"}}
+
+			Status: 404
+			Returns: string
+			Example: 404 page not found
+
+			Status: 500
+			Returns: string
+			Example: "namespace \"default\" not found"
+	*/
+
+	vars := mux.Vars(r)
+	ns := vars["ns"]
+	metadata, err := as.GetNamespaceMetadata(ns)
+	if err != nil {
+		log.Errorf("Could not retrieve the metadata of the namespace %s: %v", ns, err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getNamespaceMetadata",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		log.Errorf("Could not process the metadata of the namespace %s: %v", ns, err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getNamespaceMetadata",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(metadataBytes)
+	apiRequests.Inc(
+		"getNamespaceMetadata",
+		strconv.Itoa(http.StatusOK),
+	)
+}
+
 // getPodMetadata is only used when the node agent hits the DCA for the tags list.
 // It returns a list of all the tags that can be directly used in the tagger of the agent.
 func getPodMetadata(w http.ResponseWriter, r *http.Request) {
@@ -36,6 +36,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/orchestrator"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
@@ -288,6 +289,13 @@ func start(cmd *cobra.Command, args []string) error {
 		return log.Errorf("Error while starting agent API, exiting: %v", err)
 	}
 
+	// Compliance rules scoped to the cluster
+	stopper := restart.NewSerialStopper()
+	defer stopper.Stop()
+	if err = startCompliance(stopper); err != nil {
+		log.Errorf("Error while starting compliance checks: %v", err)
+	}
+
 	wg := sync.WaitGroup{}
 
 	// Autoscaler Controller Goroutine
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/collector/runner"
+	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/agent"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// leaderReporter only reports the events of the leader cluster agent, so
+// that cluster rules are reported once when several replicas are running
+type leaderReporter struct {
+	compliance.Reporter
+	leaderEngine *leaderelection.LeaderEngine
+}
+
+func (r *leaderReporter) Report(event *compliance.RuleEvent) {
+	if r.leaderEngine != nil && !r.leaderEngine.IsLeader() {
+		log.Tracef("Not leader, skipping the report of rule %s", event.RuleID)
+		return
+	}
+	r.Reporter.Report(event)
+}
+
+func newComplianceReporter(stopper restart.Stopper) (compliance.Reporter, error) {
+	httpConnectivity := logsconfig.HTTPConnectivityFailure
+	if endpoints, err := logsconfig.BuildHTTPEndpoints(); err == nil {
+		httpConnectivity = http.CheckConnectivity(endpoints.Main)
+	}
+
+	endpoints, err := logsconfig.BuildEndpoints(httpConnectivity)
+	if err != nil {
+		return nil, log.Errorf("Invalid endpoints: %v", err)
+	}
+
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	stopper.Add(destinationsCtx)
+
+	health := health.RegisterLiveness("compliance")
+
+	// setup the auditor
+	auditor := auditor.New(config.Datadog.GetString("compliance_config.run_path"), health)
+	auditor.Start()
+	stopper.Add(auditor)
+
+	// setup the pipeline provider that provides pairs of processor and sender
+	pipelineProvider := pipeline.NewProvider(logsconfig.NumberOfPipelines, auditor, nil, endpoints, destinationsCtx)
+	pipelineProvider.Start()
+	stopper.Add(pipelineProvider)
+
+	// Same source as the security agent, results being merged into the same report
+	logSource := logsconfig.NewLogSource(
+		"compliance-agent",
+		&logsconfig.LogsConfig{
+			Type:    "compliance",
+			Service: "compliance-agent",
+			Source:  "compliance-agent",
+		},
+	)
+	return compliance.NewReporter(logSource, pipelineProvider.NextPipelineChan()), nil
+}
+
+// startCompliance evaluates the compliance rules scoped to the Kubernetes
+// cluster against the API server, so that they don't require agents running
+// on the control plane nodes
+func startCompliance(stopper restart.Stopper) error {
+	if !config.Datadog.GetBool("compliance_config.kubernetes_cluster.enabled") {
+		return nil
+	}
+
+	reporter, err := newComplianceReporter(stopper)
+	if err != nil {
+		return err
+	}
+
+	var leaderEngine *leaderelection.LeaderEngine
+	if config.Datadog.GetBool("leader_election") {
+		leaderEngine, err = leaderelection.GetLeaderEngine()
+		if err != nil {
+			return err
+		}
+		if err = leaderEngine.EnsureLeaderElectionRuns(); err != nil {
+			return err
+		}
+	}
+
+	runner := runner.NewRunner()
+	stopper.Add(runner)
+
+	scheduler := scheduler.NewScheduler(runner.GetChan())
+	runner.SetScheduler(scheduler)
+
+	checkInterval := config.Datadog.GetDuration("compliance_config.check_interval")
+	configDir := config.Datadog.GetString("compliance_config.dir")
+
+	agent, err := agent.New(
+		&leaderReporter{Reporter: reporter, leaderEngine: leaderEngine},
+		scheduler,
+		configDir,
+		checks.WithInterval(checkInterval),
+		checks.WithKubernetesCluster(clustername.GetClusterName()),
+		checks.WithKubernetes(),
+	)
+	if err != nil {
+		log.Errorf("Compliance agent failed to initialize: %v", err)
+		return err
+	}
+	err = agent.Run()
+	if err != nil {
+		log.Errorf("Error starting compliance agent, exiting: %v", err)
+		return err
+	}
+	stopper.Add(agent)
+
+	log.Infof("Running cluster compliance checks every %s", checkInterval.String())
+	return nil
+}
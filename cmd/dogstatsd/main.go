@@ -16,6 +16,7 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -68,6 +69,9 @@ extensions for special Datadog features.`,
 
 	metaScheduler *metadata.Scheduler
 	statsd        *dogstatsd.Server
+
+	hostname  string
+	startTime = time.Now()
 )
 
 const (
@@ -153,6 +157,9 @@ func runAgent() (mainCtx context.Context, mainCtxCancel context.CancelFunc, err
 		return
 	}
 
+	// Serve the expvars, telemetry and status once the config is loaded
+	startStatsServer()
+
 	if !config.Datadog.IsSet("api_key") {
 		log.Critical("no API key configured, exiting")
 		return
@@ -178,12 +185,12 @@ func runAgent() (mainCtx context.Context, mainCtxCancel context.CancelFunc, err
 	f.Start() //nolint:errcheck
 	s := serializer.NewSerializer(f)
 
-	hname, err := util.GetHostname()
+	hostname, err = util.GetHostname()
 	if err != nil {
 		log.Warnf("Error getting hostname: %s", err)
-		hname = ""
+		hostname = ""
 	}
-	log.Debugf("Using hostname: %s", hname)
+	log.Debugf("Using hostname: %s", hostname)
 
 	// setup the metadata collector
 	metaScheduler = metadata.NewScheduler(s)
@@ -203,7 +210,7 @@ func runAgent() (mainCtx context.Context, mainCtxCancel context.CancelFunc, err
 		tagger.Init()
 	}
 
-	aggregatorInstance := aggregator.InitAggregator(s, hname, aggregator.DogStatsDStandAloneName)
+	aggregatorInstance := aggregator.InitAggregator(s, hostname, aggregator.DogStatsDStandAloneName)
 
 	statsd, err = dogstatsd.NewServer(aggregatorInstance)
 	if err != nil {
@@ -238,6 +245,10 @@ func stopAgent(ctx context.Context, cancel context.CancelFunc) {
 		statsd.Stop()
 	}
 
+	if config.Datadog.GetBool("dogstatsd_origin_detection") {
+		tagger.Stop() //nolint:errcheck
+	}
+
 	log.Info("See ya!")
 	log.Flush()
 	return
@@ -8,7 +8,6 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	_ "net/http/pprof"
 	"net/url"
 	"os"
@@ -64,11 +63,6 @@ const ServiceName = "dogstatsd"
 func main() {
 	config.Datadog.AddConfigPath(DefaultConfPath)
 
-	// go_expvar server
-	go http.ListenAndServe(
-		fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_stats_port")),
-		http.DefaultServeMux)
-
 	isIntSess, err := svc.IsAnInteractiveSession()
 	if err != nil {
 		fmt.Printf("failed to determine if we are running in an interactive session: %v\n", err)
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// statusExpvars are the expvars reported by the status endpoint
+var statusExpvars = []string{"dogstatsd", "dogstatsd-udp", "dogstatsd-uds", "aggregator", "forwarder"}
+
+// startStatsServer serves the expvars, pprof, the telemetry and the status
+// of DogStatsD on localhost, on dogstatsd_stats_port
+func startStatsServer() {
+	if config.Datadog.GetBool("telemetry.enabled") {
+		http.Handle("/telemetry", telemetry.Handler())
+	}
+	http.HandleFunc("/status", statusHandler)
+
+	go http.ListenAndServe( //nolint:errcheck
+		fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_stats_port")),
+		http.DefaultServeMux)
+}
+
+// statusHandler reports the state of DogStatsD in JSON, a minimal equivalent
+// of the agent status for the standalone binary
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]interface{}{
+		"version":          version.AgentVersion,
+		"hostname":         hostname,
+		"pid":              os.Getpid(),
+		"uptime":           int64(time.Since(startTime).Seconds()),
+		"origin_detection": config.Datadog.GetBool("dogstatsd_origin_detection"),
+	}
+	if profiles, err := config.GetDogstatsdMappingProfiles(); err == nil {
+		stats["mapper_profiles"] = len(profiles)
+	}
+	if status, err := health.GetReadyNonBlocking(); err == nil {
+		stats["health"] = status
+	}
+	for _, name := range statusExpvars {
+		if v := expvar.Get(name); v != nil {
+			stats[name] = json.RawMessage(v.String())
+		}
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		log.Errorf("Error marshalling the status: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body) //nolint:errcheck
+}
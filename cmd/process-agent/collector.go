@@ -21,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
+	"github.com/DataDog/datadog-agent/pkg/util/memorypressure"
 )
 
 type checkResult struct {
@@ -102,6 +103,12 @@ func (l *Collector) runCheck(c checks.Check, results *api.WeightedQueue) {
 	}
 	l.runCounters.Store(c.Name(), runCounter)
 
+	if memorypressure.CurrentLevel() >= memorypressure.Hard {
+		// pause the payloads until the memory pressure goes down
+		memorypressure.Shed(memorypressure.ActionProcessPayloads, 1)
+		return
+	}
+
 	start := time.Now()
 	// update the last collected timestamp for info
 	updateLastCollectTime(start)
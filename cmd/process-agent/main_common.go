@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/memorypressure"
 )
 
 const loggerName ddconfig.LoggerName = "PROCESS"
@@ -168,6 +169,11 @@ func runAgent(exit chan struct{}) {
 		http.ListenAndServe(fmt.Sprintf("localhost:%d", cfg.ProcessExpVarPort), nil) //nolint:errcheck
 	}()
 
+	if monitor := memorypressure.NewMonitorFromConfig(); monitor != nil {
+		monitor.Start()
+		defer monitor.Stop()
+	}
+
 	cl, err := NewCollector(cfg)
 	if err != nil {
 		log.Criticalf("Error creating collector: %s", err)
@@ -179,6 +179,14 @@ func start(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err = startFileIntegrityMonitoring(stopper); err != nil {
+		return err
+	}
+
+	if err = startSBOMGeneration(stopper); err != nil {
+		return err
+	}
+
 	srv, err := api.NewServer()
 	if err != nil {
 		return log.Errorf("Error while creating api server, exiting: %v", err)
@@ -210,6 +218,15 @@ func start(cmd *cobra.Command, args []string) error {
 }
 
 func newComplianceReporter(stopper restart.Stopper, sourceName, sourceType string) (compliance.Reporter, error) {
+	pipelineProvider, err := newLogPipelineProvider(stopper)
+	if err != nil {
+		return nil, err
+	}
+	return compliance.NewReporter(newLogSource(sourceName, sourceType), pipelineProvider.NextPipelineChan()), nil
+}
+
+// newLogPipelineProvider returns a started logs pipeline provider sending to the logs intake
+func newLogPipelineProvider(stopper restart.Stopper) (pipeline.Provider, error) {
 	httpConnectivity := config.HTTPConnectivityFailure
 	if endpoints, err := config.BuildHTTPEndpoints(); err == nil {
 		httpConnectivity = http.CheckConnectivity(endpoints.Main)
@@ -236,7 +253,11 @@ func newComplianceReporter(stopper restart.Stopper, sourceName, sourceType strin
 	pipelineProvider.Start()
 	stopper.Add(pipelineProvider)
 
-	logSource := config.NewLogSource(
+	return pipelineProvider, nil
+}
+
+func newLogSource(sourceName, sourceType string) *config.LogSource {
+	return config.NewLogSource(
 		sourceName,
 		&config.LogsConfig{
 			Type:    sourceType,
@@ -244,12 +265,10 @@ func newComplianceReporter(stopper restart.Stopper, sourceName, sourceType strin
 			Source:  sourceName,
 		},
 	)
-	return compliance.NewReporter(logSource, pipelineProvider.NextPipelineChan()), nil
 }
 
 func startCompliance(stopper restart.Stopper) error {
-	enabled := coreconfig.Datadog.GetBool("compliance_config.enabled")
-	if !enabled {
+	if !coreconfig.IsFeatureEnabled(coreconfig.CSPMFeature) {
 		return nil
 	}
 
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/fim"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// startFileIntegrityMonitoring reports the changes of the files matching the
+// runtime security policy, monitored with eBPF
+func startFileIntegrityMonitoring(stopper restart.Stopper) error {
+	if !coreconfig.Datadog.GetBool("runtime_security_config.fim.enabled") {
+		return nil
+	}
+
+	procRoot := "/proc"
+	if hostRoot := os.Getenv("HOST_ROOT"); hostRoot != "" {
+		procRoot = filepath.Join(hostRoot, "proc")
+	}
+	policy := fim.Policy{Paths: coreconfig.Datadog.GetStringSlice("runtime_security_config.fim.paths")}
+	monitor, err := ebpf.NewFileIntegrityMonitor(policy, procRoot, coreconfig.Datadog.GetInt64("runtime_security_config.fim.max_hash_size"))
+	if err != nil {
+		return log.Errorf("Error starting file integrity monitoring: %v", err)
+	}
+
+	reporter, err := newComplianceReporter(stopper, "runtime-security-agent", "runtime_security")
+	if err != nil {
+		monitor.Stop()
+		return err
+	}
+
+	monitor.Start()
+	stopper.Add(monitor)
+	go func() {
+		for event := range monitor.Events() {
+			reporter.Report(fimRuleEvent(event))
+		}
+	}()
+
+	log.Infof("Monitoring the integrity of %d paths", len(policy.Paths))
+	return nil
+}
+
+// fimRuleEvent converts a file change to a rule event
+func fimRuleEvent(event *fim.Event) *compliance.RuleEvent {
+	ancestry := make([]string, 0, len(event.Ancestry))
+	for _, p := range event.Ancestry {
+		ancestry = append(ancestry, fmt.Sprintf("%s(%d)", p.Name, p.Pid))
+	}
+
+	data := compliance.KVMap{
+		"event":    event.Type,
+		"inode":    fmt.Sprint(event.Inode),
+		"ancestry": strings.Join(ancestry, " < "),
+	}
+	if event.SHA256 != "" {
+		data["sha256"] = event.SHA256
+	}
+
+	return &compliance.RuleEvent{
+		RuleID:       "file_integrity",
+		ResourceID:   event.Path,
+		ResourceType: "file",
+		Tags:         []string{"security:runtime"},
+		Data:         data,
+	}
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// sbomCache stores the images whose SBOM was sent in the run path
+type sbomCache struct{}
+
+func (sbomCache) Read(key string) (string, error) { return persistentcache.Read(key) }
+
+func (sbomCache) Write(key, value string) error { return persistentcache.Write(key, value) }
+
+// sbomScanner periodically generates the SBOM of the images of the running containers
+type sbomScanner struct {
+	generator *sbom.Generator
+	interval  time.Duration
+	stop      chan struct{}
+}
+
+func (s *sbomScanner) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		containers, err := listSBOMContainers()
+		if err != nil {
+			log.Debugf("Could not list the containers to generate their SBOM: %v", err)
+		} else {
+			s.generator.Process(containers)
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the scans
+func (s *sbomScanner) Stop() {
+	close(s.stop)
+}
+
+func startSBOMGeneration(stopper restart.Stopper) error {
+	if !coreconfig.Datadog.GetBool("sbom_config.enabled") {
+		return nil
+	}
+
+	pipelineProvider, err := newLogPipelineProvider(stopper)
+	if err != nil {
+		return err
+	}
+	sender := sbom.NewLogSender(newLogSource("sbom-agent", "sbom"), pipelineProvider.NextPipelineChan())
+
+	procRoot := "/proc"
+	if hostRoot := os.Getenv("HOST_ROOT"); hostRoot != "" {
+		procRoot = filepath.Join(hostRoot, "proc")
+	}
+	scanner := &sbomScanner{
+		generator: sbom.NewGenerator(
+			procRoot,
+			coreconfig.Datadog.GetDuration("sbom_config.cache_ttl"),
+			coreconfig.Datadog.GetInt("sbom_config.max_per_minute"),
+			sbomCache{},
+			sender,
+		),
+		interval: coreconfig.Datadog.GetDuration("sbom_config.scan_interval"),
+		stop:     make(chan struct{}),
+	}
+	go scanner.run()
+	stopper.Add(scanner)
+
+	log.Infof("Generating the SBOM of the container images every %s", scanner.interval)
+	return nil
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package app
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+// listSBOMContainers returns the running docker containers
+func listSBOMContainers() ([]sbom.Container, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+	ctrs, err := du.ListContainers(&docker.ContainerListConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []sbom.Container
+	for _, c := range ctrs {
+		if c.State != containers.ContainerRunningState || len(c.Pids) == 0 {
+			continue
+		}
+		result = append(result, sbom.Container{
+			ID:        c.ID,
+			ImageID:   c.ImageID,
+			ImageName: c.Image,
+			Pid:       c.Pids[0],
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !docker
+
+package app
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/sbom"
+)
+
+// listSBOMContainers isn't supported without docker
+func listSBOMContainers() ([]sbom.Container, error) {
+	return nil, errors.New("docker support not compiled in")
+}
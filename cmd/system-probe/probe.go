@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/network/tracepath"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
 	"github.com/DataDog/datadog-agent/pkg/process/net"
 )
@@ -34,6 +35,7 @@ type SystemProbe struct {
 	conn   net.Conn
 
 	tcpQueueLengthTracer *ebpf.TCPQueueLengthTracer
+	networkPathRunner    *tracepath.Runner
 }
 
 // CreateSystemProbe creates a SystemProbe as well as it's UDS socket after confirming that the OS supports BPF-based
@@ -62,6 +64,13 @@ func CreateSystemProbe(cfg *config.AgentConfig) (*SystemProbe, error) {
 		log.Infof("TCP queue length tracer disabled")
 	}
 
+	var npr *tracepath.Runner
+	if len(cfg.NetworkPath.Targets) > 0 {
+		log.Infof("Starting the network path tracing toward %d targets", len(cfg.NetworkPath.Targets))
+		npr = tracepath.NewRunner(cfg.NetworkPath)
+		npr.Start()
+	}
+
 	// Setting up the unix socket
 	conn, err := net.NewListener(cfg)
 	if err != nil {
@@ -71,6 +80,7 @@ func CreateSystemProbe(cfg *config.AgentConfig) (*SystemProbe, error) {
 	return &SystemProbe{
 		tracer:               t,
 		tcpQueueLengthTracer: tqlt,
+		networkPathRunner:    npr,
 		cfg:                  cfg,
 		conn:                 conn,
 	}, nil
@@ -154,6 +164,37 @@ func (nt *SystemProbe) Run() {
 		writeAsJSON(w, stats)
 	})
 
+	httpMux.HandleFunc("/network_path", func(w http.ResponseWriter, req *http.Request) {
+		if nt.networkPathRunner == nil {
+			log.Errorf("Network path tracing is not configured")
+			w.WriteHeader(404)
+			return
+		}
+
+		writeAsJSON(w, map[string]interface{}{
+			"running": nt.networkPathRunner.Running(),
+			"paths":   nt.networkPathRunner.Results(),
+		})
+	})
+
+	// Traces the network paths on demand, the results being available on /network_path once done
+	httpMux.HandleFunc("/network_path/trigger", func(w http.ResponseWriter, req *http.Request) {
+		if nt.networkPathRunner == nil {
+			log.Errorf("Network path tracing is not configured")
+			w.WriteHeader(404)
+			return
+		}
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !nt.networkPathRunner.Trigger() {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
 	go func() {
 		tags := []string{
 			fmt.Sprintf("version:%s", Version),
@@ -222,4 +263,7 @@ func writeAsJSON(w http.ResponseWriter, data interface{}) {
 func (nt *SystemProbe) Close() {
 	nt.conn.Stop()
 	nt.tracer.Stop()
+	if nt.networkPathRunner != nil {
+		nt.networkPathRunner.Stop()
+	}
 }
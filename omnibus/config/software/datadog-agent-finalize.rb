@@ -116,6 +116,7 @@ build do
             delete "/etc/datadog-agent/conf.d/process_agent.yaml.default"
 
             # remove windows specific configs
+            delete "/etc/datadog-agent/conf.d/windows_counters.d"
             delete "/etc/datadog-agent/conf.d/winproc.d"
 
             # cleanup clutter
@@ -184,6 +185,7 @@ build do
             delete "#{install_dir}/etc/conf.d/file_handle.d"
 
             # remove windows specific configs
+            delete "#{install_dir}/etc/conf.d/windows_counters.d"
             delete "#{install_dir}/etc/conf.d/winproc.d"
 
             if ENV['HARDENED_RUNTIME_MAC'] == 'true'
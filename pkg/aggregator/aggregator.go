@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
 )
 
 // DefaultFlushInterval aggregator default flush interval
@@ -100,32 +101,35 @@ var (
 	flushTimeStats    = make(map[string]*Stats)
 	flushCountStats   = make(map[string]*Stats)
 
-	aggregatorSeriesFlushed                    = expvar.Int{}
-	aggregatorSeriesFlushErrors                = expvar.Int{}
-	aggregatorServiceCheckFlushErrors          = expvar.Int{}
-	aggregatorServiceCheckFlushed              = expvar.Int{}
-	aggregatorSketchesFlushErrors              = expvar.Int{}
-	aggregatorSketchesFlushed                  = expvar.Int{}
-	aggregatorEventsFlushErrors                = expvar.Int{}
-	aggregatorEventsFlushed                    = expvar.Int{}
-	aggregatorNumberOfFlush                    = expvar.Int{}
-	aggregatorDogstatsdMetricSample            = expvar.Int{}
-	aggregatorChecksMetricSample               = expvar.Int{}
-	aggregatorCheckHistogramBucketMetricSample = expvar.Int{}
-	aggregatorServiceCheck                     = expvar.Int{}
-	aggregatorEvent                            = expvar.Int{}
-	aggregatorHostnameUpdate                   = expvar.Int{}
-
-	tlmFlush = telemetry.NewCounter("aggregator", "flush",
+	aggregatorSeriesFlushErrors       = expvar.Int{}
+	aggregatorServiceCheckFlushErrors = expvar.Int{}
+	aggregatorSketchesFlushErrors     = expvar.Int{}
+	aggregatorEventsFlushErrors       = expvar.Int{}
+	aggregatorNumberOfFlush           = expvar.Int{}
+
+	tlmFlush = telemetry.NewStatCounter("aggregator", "flush",
 		[]string{"data_type", "state"}, "Count of flush")
-	tlmProcessed = telemetry.NewCounter("aggregator", "processed",
+	tlmProcessed = telemetry.NewStatCounter("aggregator", "processed",
 		[]string{"data_type"}, "Amount of metrics/services_checks/events processed by the aggregator")
-	tlmHostnameUpdate = telemetry.NewCounter("aggregator", "hostname_update",
+	tlmHostnameUpdate = telemetry.NewStatCounter("aggregator", "hostname_update",
 		nil, "Count of hostname update")
+	tlmSeriesByOrigin = telemetry.NewCounter("aggregator", "series_by_origin",
+		[]string{"product", "service"}, "Count of series flushed by origin")
+
+	aggregatorDogstatsdMetricSample            = tlmProcessed.WithValues("dogstatsd_metrics")
+	aggregatorChecksMetricSample               = tlmProcessed.WithValues("metrics")
+	aggregatorCheckHistogramBucketMetricSample = tlmProcessed.WithValues("histogram_bucket")
+	aggregatorServiceCheck                     = tlmProcessed.WithValues("service_checks")
+	aggregatorEvent                            = tlmProcessed.WithValues("events")
+	aggregatorHostnameUpdate                   = tlmHostnameUpdate.WithValues()
 
 	// Hold series to be added to aggregated series on each flush
 	recurrentSeries     metrics.Series
 	recurrentSeriesLock sync.Mutex
+
+	// Called with the series of each flush
+	seriesObservers     []func(metrics.Series)
+	seriesObserversLock sync.Mutex
 )
 
 func init() {
@@ -142,21 +146,29 @@ func init() {
 	newFlushCountStats("Sketches")
 	aggregatorExpvars.Set("FlushCount", expvar.Func(expStatsMap(flushCountStats)))
 
-	aggregatorExpvars.Set("SeriesFlushed", &aggregatorSeriesFlushed)
+	aggregatorExpvars.Set("SeriesFlushed", expvar.Func(flushedCount("series")))
 	aggregatorExpvars.Set("SeriesFlushErrors", &aggregatorSeriesFlushErrors)
 	aggregatorExpvars.Set("ServiceCheckFlushErrors", &aggregatorServiceCheckFlushErrors)
-	aggregatorExpvars.Set("ServiceCheckFlushed", &aggregatorServiceCheckFlushed)
+	aggregatorExpvars.Set("ServiceCheckFlushed", expvar.Func(flushedCount("service_checks")))
 	aggregatorExpvars.Set("SketchesFlushErrors", &aggregatorSketchesFlushErrors)
-	aggregatorExpvars.Set("SketchesFlushed", &aggregatorSketchesFlushed)
+	aggregatorExpvars.Set("SketchesFlushed", expvar.Func(flushedCount("sketches")))
 	aggregatorExpvars.Set("EventsFlushErrors", &aggregatorEventsFlushErrors)
-	aggregatorExpvars.Set("EventsFlushed", &aggregatorEventsFlushed)
+	aggregatorExpvars.Set("EventsFlushed", expvar.Func(flushedCount("events")))
 	aggregatorExpvars.Set("NumberOfFlush", &aggregatorNumberOfFlush)
-	aggregatorExpvars.Set("DogstatsdMetricSample", &aggregatorDogstatsdMetricSample)
-	aggregatorExpvars.Set("ChecksMetricSample", &aggregatorChecksMetricSample)
-	aggregatorExpvars.Set("ChecksHistogramBucketMetricSample", &aggregatorCheckHistogramBucketMetricSample)
-	aggregatorExpvars.Set("ServiceCheck", &aggregatorServiceCheck)
-	aggregatorExpvars.Set("Event", &aggregatorEvent)
-	aggregatorExpvars.Set("HostnameUpdate", &aggregatorHostnameUpdate)
+	aggregatorExpvars.Set("DogstatsdMetricSample", aggregatorDogstatsdMetricSample.Expvar())
+	aggregatorExpvars.Set("ChecksMetricSample", aggregatorChecksMetricSample.Expvar())
+	aggregatorExpvars.Set("ChecksHistogramBucketMetricSample", aggregatorCheckHistogramBucketMetricSample.Expvar())
+	aggregatorExpvars.Set("ServiceCheck", aggregatorServiceCheck.Expvar())
+	aggregatorExpvars.Set("Event", aggregatorEvent.Expvar())
+	aggregatorExpvars.Set("HostnameUpdate", aggregatorHostnameUpdate.Expvar())
+}
+
+// flushedCount returns the number of items of a data type flushed, whether
+// the flushes succeeded or not
+func flushedCount(dataType string) func() interface{} {
+	return func() interface{} {
+		return tlmFlush.Get(dataType, stateOk) + tlmFlush.Get(dataType, stateError)
+	}
 }
 
 // InitAggregator returns the Singleton instance
@@ -168,6 +180,7 @@ func InitAggregator(s serializer.MetricSerializer, hostname, agentName string) *
 func InitAggregatorWithFlushInterval(s serializer.MetricSerializer, hostname, agentName string, flushInterval time.Duration) *BufferedAggregator {
 	aggregatorInit.Do(func() {
 		aggregatorInstance = NewBufferedAggregator(s, hostname, agentName, flushInterval)
+		tagger.AddDeletionListener(aggregatorInstance.DeleteOrigins)
 		go aggregatorInstance.run()
 	})
 
@@ -203,6 +216,8 @@ type BufferedAggregator struct {
 	checkMetricIn          chan senderMetricSample
 	checkHistogramBucketIn chan senderHistogramBucket
 
+	deletedOriginsIn chan []string
+
 	// metricSamplePool is a pool of slices of metric sample to avoid allocations.
 	// Used by the Dogstatsd Batcher.
 	MetricSamplePool *metrics.MetricSamplePool
@@ -239,6 +254,8 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname, agentName st
 		checkMetricIn:          make(chan senderMetricSample, bufferSize),
 		checkHistogramBucketIn: make(chan senderHistogramBucket, bufferSize),
 
+		deletedOriginsIn: make(chan []string, bufferSize),
+
 		MetricSamplePool: metrics.NewMetricSamplePool(MetricSamplePoolBatchSize),
 
 		statsdSampler:      *NewTimeSampler(bucketSize),
@@ -263,6 +280,15 @@ func AddRecurrentSeries(newSerie *metrics.Serie) {
 	recurrentSeries = append(recurrentSeries, newSerie)
 }
 
+// AddSeriesObserver adds a function called with the series of every flush,
+// before they are sent to the serializer. It must not modify the series nor
+// block the flush.
+func AddSeriesObserver(observer func(metrics.Series)) {
+	seriesObserversLock.Lock()
+	defer seriesObserversLock.Unlock()
+	seriesObservers = append(seriesObservers, observer)
+}
+
 // IsInputQueueEmpty returns true if every input channel for the aggregator are
 // empty. This is mainly useful for tests and benchmark
 func (agg *BufferedAggregator) IsInputQueueEmpty() bool {
@@ -282,6 +308,19 @@ func (agg *BufferedAggregator) GetBufferedChannels() (chan []metrics.MetricSampl
 	return agg.bufferedMetricIn, agg.bufferedEventIn, agg.bufferedServiceCheckIn
 }
 
+// DeleteOrigins stops tracking the dogstatsd contexts of the given origins,
+// e.g. deleted containers: their counters are not zero-filled anymore and
+// their contexts are expired at the next flush. It doesn't block and drops
+// the notification if the aggregator is lagging behind, in which case the
+// contexts expire normally.
+func (agg *BufferedAggregator) DeleteOrigins(origins []string) {
+	select {
+	case agg.deletedOriginsIn <- origins:
+	default:
+		log.Debugf("Aggregator input queue full, %d deleted origins will expire normally", len(origins))
+	}
+}
+
 // SetHostname sets the hostname that the aggregator uses by default on all the data it sends
 // Blocks until the main aggregator goroutine has finished handling the update
 func (agg *BufferedAggregator) SetHostname(hostname string) {
@@ -318,7 +357,7 @@ func (agg *BufferedAggregator) registerSender(id check.ID) error {
 	if _, ok := agg.checkSamplers[id]; ok {
 		return fmt.Errorf("Sender with ID '%s' has already been registered, will use existing sampler", id)
 	}
-	agg.checkSamplers[id] = newCheckSampler()
+	agg.checkSamplers[id] = newCheckSampler(metrics.CheckOrigin(check.IDToCheckName(id)))
 	return nil
 }
 
@@ -384,8 +423,14 @@ func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, tim
 
 // GetSeriesAndSketches grabs all the series & sketches from the queue and clears the queue
 func (agg *BufferedAggregator) GetSeriesAndSketches() (metrics.Series, metrics.SketchSeriesList) {
+	return agg.getSeriesAndSketches(timeNowNano())
+}
+
+// getSeriesAndSketches grabs the series & sketches of the dogstatsd buckets
+// closed at the given timestamp and of the check samplers
+func (agg *BufferedAggregator) getSeriesAndSketches(timestamp float64) (metrics.Series, metrics.SketchSeriesList) {
 	agg.mu.Lock()
-	series, sketches := agg.statsdSampler.flush(timeNowNano())
+	series, sketches := agg.statsdSampler.flush(timestamp)
 
 	for _, checkSampler := range agg.checkSamplers {
 		s, sk := checkSampler.flush()
@@ -406,8 +451,7 @@ func (agg *BufferedAggregator) pushSketches(start time.Time, sketches metrics.Sk
 		state = stateError
 	}
 	addFlushTime("MetricSketchFlushTime", int64(time.Since(start)))
-	aggregatorSketchesFlushed.Add(int64(len(sketches)))
-	tlmFlush.Add(float64(len(sketches)), "sketches", state)
+	tlmFlush.Add(int64(len(sketches)), "sketches", state)
 }
 
 func (agg *BufferedAggregator) pushSeries(start time.Time, series metrics.Series) {
@@ -420,8 +464,7 @@ func (agg *BufferedAggregator) pushSeries(start time.Time, series metrics.Series
 		state = stateError
 	}
 	addFlushTime("ChecksMetricSampleFlushTime", int64(time.Since(start)))
-	aggregatorSeriesFlushed.Add(int64(len(series)))
-	tlmFlush.Add(float64(len(series)), "series", state)
+	tlmFlush.Add(int64(len(series)), "series", state)
 }
 
 func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series, waitForSerializer bool) {
@@ -441,6 +484,7 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 			Host:           extra.Host,
 			MType:          extra.MType,
 			SourceTypeName: extra.SourceTypeName,
+			Origin:         metrics.AgentOrigin,
 		}
 
 		// Updating Ts for every points
@@ -466,6 +510,7 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	})
 
 	// Send along a metric that counts the number of times we dropped some payloads because we couldn't split them.
@@ -475,9 +520,17 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	})
 
 	addFlushCount("Series", int64(len(series)))
+	countSeriesByOrigin(series)
+
+	seriesObserversLock.Lock()
+	for _, observer := range seriesObservers {
+		observer(series)
+	}
+	seriesObserversLock.Unlock()
 
 	// For debug purposes print out all metrics/tag combinations
 	if config.Datadog.GetBool("log_payloads") {
@@ -494,6 +547,20 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 	}
 }
 
+// countSeriesByOrigin counts the flushed series of each origin, to attribute
+// them to their producers
+func countSeriesByOrigin(series metrics.Series) {
+	counts := make(map[metrics.Origin]int)
+	for _, serie := range series {
+		if serie.Origin != nil {
+			counts[*serie.Origin]++
+		}
+	}
+	for origin, count := range counts {
+		tlmSeriesByOrigin.Add(float64(count), string(origin.Product), origin.Service)
+	}
+}
+
 func (agg *BufferedAggregator) sendSketches(start time.Time, sketches metrics.SketchSeriesList, waitForSerializer bool) {
 	// Serialize and forward sketches in a separate goroutine
 	addFlushCount("Sketches", int64(len(sketches)))
@@ -532,8 +599,7 @@ func (agg *BufferedAggregator) sendServiceChecks(start time.Time, serviceChecks
 		state = stateError
 	}
 	addFlushTime("ServiceCheckFlushTime", int64(time.Since(start)))
-	aggregatorServiceCheckFlushed.Add(int64(len(serviceChecks)))
-	tlmFlush.Add(float64(len(serviceChecks)), "service_checks", state)
+	tlmFlush.Add(int64(len(serviceChecks)), "service_checks", state)
 }
 
 func (agg *BufferedAggregator) flushServiceChecks(start time.Time, waitForSerializer bool) {
@@ -581,8 +647,7 @@ func (agg *BufferedAggregator) sendEvents(start time.Time, events metrics.Events
 		state = stateError
 	}
 	addFlushTime("EventFlushTime", int64(time.Since(start)))
-	aggregatorEventsFlushed.Add(int64(len(events)))
-	tlmFlush.Add(float64(len(events)), "events", state)
+	tlmFlush.Add(int64(len(events)), "events", state)
 }
 
 // flushEvents serializes and forwards events in a separate goroutine
@@ -615,16 +680,81 @@ func (agg *BufferedAggregator) flush(start time.Time, waitForSerializer bool) {
 	agg.flushEvents(start, waitForSerializer)
 }
 
+// drainAndFlush processes the samples still queued and flushes everything to
+// the serializer, the open dogstatsd buckets included
+func (agg *BufferedAggregator) drainAndFlush(start time.Time) {
+	agg.processQueued()
+
+	// the current bucket is closed at the start of the next one
+	series, sketches := agg.getSeriesAndSketches(timeNowNano() + float64(agg.statsdSampler.interval))
+	agg.sendSketches(start, sketches, true)
+	agg.sendSeries(start, series, true)
+	agg.flushServiceChecks(start, true)
+	agg.flushEvents(start, true)
+}
+
+// processQueued handles the samples, service checks and events waiting in
+// the input channels, once the run loop is stopped
+func (agg *BufferedAggregator) processQueued() {
+	for {
+		select {
+		case checkMetric := <-agg.checkMetricIn:
+			aggregatorChecksMetricSample.Inc()
+			agg.handleSenderSample(checkMetric)
+		case checkHistogramBucket := <-agg.checkHistogramBucketIn:
+			aggregatorCheckHistogramBucketMetricSample.Inc()
+			agg.handleSenderBucket(checkHistogramBucket)
+		case metric := <-agg.metricIn:
+			aggregatorDogstatsdMetricSample.Inc()
+			agg.addSample(metric, timeNowNano())
+		case event := <-agg.eventIn:
+			aggregatorEvent.Inc()
+			agg.addEvent(event)
+		case serviceCheck := <-agg.serviceCheckIn:
+			aggregatorServiceCheck.Inc()
+			agg.addServiceCheck(serviceCheck)
+		case ms := <-agg.bufferedMetricIn:
+			aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
+			for i := 0; i < len(ms); i++ {
+				agg.addSample(&ms[i], timeNowNano())
+			}
+			agg.MetricSamplePool.PutBatch(ms)
+		case serviceChecks := <-agg.bufferedServiceCheckIn:
+			aggregatorServiceCheck.Add(int64(len(serviceChecks)))
+			for _, serviceCheck := range serviceChecks {
+				agg.addServiceCheck(*serviceCheck)
+			}
+		case events := <-agg.bufferedEventIn:
+			aggregatorEvent.Add(int64(len(events)))
+			for _, event := range events {
+				agg.addEvent(*event)
+			}
+		default:
+			return
+		}
+	}
+}
+
 // Stop stops the aggregator. Based on 'flushData' waiting metrics (from checks
 // or closed dogstatsd buckets) will be sent to the serializer before stopping.
+// When shutdown_drain_timeout is set, the queued samples and the open dogstatsd
+// buckets are flushed too, within that deadline.
 func (agg *BufferedAggregator) Stop() {
 	agg.stopChan <- struct{}{}
 
 	timeout := config.Datadog.GetDuration("aggregator_stop_timeout") * time.Second
+	drainTimeout := config.Datadog.GetDuration("shutdown_drain_timeout") * time.Second
+	if drainTimeout > 0 {
+		timeout = drainTimeout
+	}
 	if timeout > 0 {
 		done := make(chan struct{})
 		go func() {
-			agg.flush(time.Now(), true)
+			if drainTimeout > 0 {
+				agg.drainAndFlush(time.Now())
+			} else {
+				agg.flush(time.Now(), true)
+			}
 			done <- struct{}{}
 		}()
 
@@ -658,47 +788,40 @@ func (agg *BufferedAggregator) run() {
 			addFlushTime("MainFlushTime", int64(time.Since(start)))
 			aggregatorNumberOfFlush.Add(1)
 		case checkMetric := <-agg.checkMetricIn:
-			aggregatorChecksMetricSample.Add(1)
-			tlmProcessed.Inc("metrics")
+			aggregatorChecksMetricSample.Inc()
 			agg.handleSenderSample(checkMetric)
 		case checkHistogramBucket := <-agg.checkHistogramBucketIn:
-			aggregatorCheckHistogramBucketMetricSample.Add(1)
-			tlmProcessed.Inc("histogram_bucket")
+			aggregatorCheckHistogramBucketMetricSample.Inc()
 			agg.handleSenderBucket(checkHistogramBucket)
 		case metric := <-agg.metricIn:
-			aggregatorDogstatsdMetricSample.Add(1)
-			tlmProcessed.Inc("dogstatsd_metrics")
+			aggregatorDogstatsdMetricSample.Inc()
 			agg.addSample(metric, timeNowNano())
 		case event := <-agg.eventIn:
-			aggregatorEvent.Add(1)
-			tlmProcessed.Inc("events")
+			aggregatorEvent.Inc()
 			agg.addEvent(event)
 		case serviceCheck := <-agg.serviceCheckIn:
-			aggregatorServiceCheck.Add(1)
-			tlmProcessed.Inc("service_checks")
+			aggregatorServiceCheck.Inc()
 			agg.addServiceCheck(serviceCheck)
 		case ms := <-agg.bufferedMetricIn:
 			aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
-			tlmProcessed.Add(float64(len(ms)), "dogstatsd_metrics")
 			for i := 0; i < len(ms); i++ {
 				agg.addSample(&ms[i], timeNowNano())
 			}
 			agg.MetricSamplePool.PutBatch(ms)
 		case serviceChecks := <-agg.bufferedServiceCheckIn:
 			aggregatorServiceCheck.Add(int64(len(serviceChecks)))
-			tlmProcessed.Add(float64(len(serviceChecks)), "service_checks")
 			for _, serviceCheck := range serviceChecks {
 				agg.addServiceCheck(*serviceCheck)
 			}
 		case events := <-agg.bufferedEventIn:
 			aggregatorEvent.Add(int64(len(events)))
-			tlmProcessed.Add(float64(len(events)), "events")
 			for _, event := range events {
 				agg.addEvent(*event)
 			}
+		case origins := <-agg.deletedOriginsIn:
+			agg.statsdSampler.deleteOrigins(origins)
 		case h := <-agg.hostnameUpdate:
-			aggregatorHostnameUpdate.Add(1)
-			tlmHostnameUpdate.Inc()
+			aggregatorHostnameUpdate.Inc()
 			agg.hostname = h
 			changeAllSendersDefaultHostname(h)
 			agg.hostnameUpdateDone <- struct{}{}
@@ -167,12 +167,14 @@ func TestDefaultData(t *testing.T) {
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	}, &metrics.Serie{
 		Name:           fmt.Sprintf("n_o_i_n_d_e_x.datadog.%s.payload.dropped", agg.agentName),
 		Points:         []metrics.Point{{Value: 0, Ts: float64(start.Unix())}},
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	}}
 
 	s.On("SendSeries", series).Return(nil).Times(1)
@@ -219,6 +221,7 @@ func TestRecurentSeries(t *testing.T) {
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	}, &metrics.Serie{
 		Name:           "some.metric.2",
 		Points:         []metrics.Point{{Value: 22, Ts: float64(start.Unix())}},
@@ -226,6 +229,7 @@ func TestRecurentSeries(t *testing.T) {
 		Host:           "non default host",
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "non default SourceTypeName",
+		Origin:         metrics.AgentOrigin,
 	}, &metrics.Serie{
 		Name:           fmt.Sprintf("datadog.%s.running", agg.agentName),
 		Points:         []metrics.Point{{Value: 1, Ts: float64(start.Unix())}},
@@ -233,12 +237,14 @@ func TestRecurentSeries(t *testing.T) {
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	}, &metrics.Serie{
 		Name:           fmt.Sprintf("n_o_i_n_d_e_x.datadog.%s.payload.dropped", agg.agentName),
 		Points:         []metrics.Point{{Value: 0, Ts: float64(start.Unix())}},
 		Host:           agg.hostname,
 		MType:          metrics.APIGaugeType,
 		SourceTypeName: "System",
+		Origin:         metrics.AgentOrigin,
 	}}
 
 	s.On("SendServiceChecks", agentUp).Return(nil).Times(1)
@@ -256,3 +262,26 @@ func TestRecurentSeries(t *testing.T) {
 	s.AssertNotCalled(t, "SendSketch")
 
 }
+
+func TestDrainOpenBuckets(t *testing.T) {
+	resetAggregator()
+	agg := NewBufferedAggregator(nil, "hostname", AgentName, DefaultFlushInterval)
+
+	agg.metricIn <- &metrics.MetricSample{
+		Name:       "my.gauge",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		SampleRate: 1,
+		Timestamp:  timeNowNano(),
+	}
+	agg.processQueued()
+	assert.Len(t, agg.metricIn, 0)
+
+	// the bucket of the sample is still open
+	series, _ := agg.getSeriesAndSketches(timeNowNano())
+	assert.Len(t, series, 0)
+
+	series, _ = agg.getSeriesAndSketches(timeNowNano() + float64(agg.statsdSampler.interval))
+	require.Len(t, series, 1)
+	assert.Equal(t, "my.gauge", series[0].Name)
+}
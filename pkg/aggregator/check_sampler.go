@@ -26,10 +26,12 @@ type CheckSampler struct {
 	lastBucketValue map[ckey.ContextKey]int64
 	lastSeenBucket  map[ckey.ContextKey]time.Time
 	bucketExpiry    time.Duration
+	origin          *metrics.Origin
 }
 
-// newCheckSampler returns a newly initialized CheckSampler
-func newCheckSampler() *CheckSampler {
+// newCheckSampler returns a newly initialized CheckSampler, its series are
+// attributed to the given origin
+func newCheckSampler(origin *metrics.Origin) *CheckSampler {
 	return &CheckSampler{
 		series:          make([]*metrics.Serie, 0),
 		sketches:        make([]metrics.SketchSeries, 0),
@@ -39,6 +41,7 @@ func newCheckSampler() *CheckSampler {
 		lastBucketValue: make(map[ckey.ContextKey]int64),
 		lastSeenBucket:  make(map[ckey.ContextKey]time.Time),
 		bucketExpiry:    1 * time.Minute,
+		origin:          origin,
 	}
 }
 
@@ -140,6 +143,7 @@ func (cs *CheckSampler) commitSeries(timestamp float64) {
 		serie.Tags = context.Tags
 		serie.Host = context.Host
 		serie.SourceTypeName = checksSourceTypeName // this source type is required for metrics coming from the checks
+		serie.Origin = cs.origin
 
 		cs.series = append(cs.series, serie)
 	}
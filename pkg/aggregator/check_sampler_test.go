@@ -26,7 +26,7 @@ func generateContextKey(sample metrics.MetricSampleContext) ckey.ContextKey {
 }
 
 func TestCheckGaugeSampling(t *testing.T) {
-	checkSampler := newCheckSampler()
+	checkSampler := newCheckSampler(metrics.CheckOrigin("my_check"))
 
 	mSample1 := metrics.MetricSample{
 		Name:       "my.metric.name",
@@ -68,6 +68,7 @@ func TestCheckGaugeSampling(t *testing.T) {
 		SourceTypeName: checksSourceTypeName,
 		ContextKey:     generateContextKey(&mSample2),
 		NameSuffix:     "",
+		Origin:         &metrics.Origin{Product: metrics.OriginProductCheck, Service: "my_check"},
 	}
 
 	expectedSerie2 := &metrics.Serie{
@@ -85,7 +86,7 @@ func TestCheckGaugeSampling(t *testing.T) {
 }
 
 func TestCheckRateSampling(t *testing.T) {
-	checkSampler := newCheckSampler()
+	checkSampler := newCheckSampler(nil)
 
 	mSample1 := metrics.MetricSample{
 		Name:       "my.metric.name",
@@ -133,8 +134,42 @@ func TestCheckRateSampling(t *testing.T) {
 	}
 }
 
+func TestCheckIntervalCountSampling(t *testing.T) {
+	checkSampler := newCheckSampler(nil)
+
+	for _, ts := range []float64{12000.0, 12300.0} {
+		checkSampler.addSample(&metrics.MetricSample{
+			Name:       "my.metric.name",
+			Value:      ts / 1000,
+			Mtype:      metrics.IntervalCountType,
+			Tags:       []string{"foo", "bar"},
+			SampleRate: 1,
+			Timestamp:  ts,
+			Interval:   300,
+		})
+	}
+
+	checkSampler.commit(12349.0)
+	series, _ := checkSampler.flush()
+
+	// the pre-aggregated counts are neither summed nor moved to the commit timestamp
+	expectedSerie := &metrics.Serie{
+		Name:           "my.metric.name",
+		Tags:           []string{"foo", "bar"},
+		Points:         []metrics.Point{{Ts: 12000.0, Value: 12}, {Ts: 12300.0, Value: 12.3}},
+		MType:          metrics.APICountType,
+		Interval:       300,
+		SourceTypeName: checksSourceTypeName,
+		NameSuffix:     "",
+	}
+
+	if assert.Equal(t, 1, len(series)) {
+		metrics.AssertSerieEqual(t, expectedSerie, series[0])
+	}
+}
+
 func TestHistogramIntervalSampling(t *testing.T) {
-	checkSampler := newCheckSampler()
+	checkSampler := newCheckSampler(nil)
 
 	mSample1 := metrics.MetricSample{
 		Name:       "my.metric.name",
@@ -192,7 +227,7 @@ func TestHistogramIntervalSampling(t *testing.T) {
 }
 
 func TestCheckHistogramBucketSampling(t *testing.T) {
-	checkSampler := newCheckSampler()
+	checkSampler := newCheckSampler(nil)
 	checkSampler.bucketExpiry = 10 * time.Millisecond
 
 	bucket1 := &metrics.HistogramBucket{
@@ -265,7 +300,7 @@ func TestCheckHistogramBucketSampling(t *testing.T) {
 }
 
 func TestCheckHistogramBucketInfinityBucket(t *testing.T) {
-	checkSampler := newCheckSampler()
+	checkSampler := newCheckSampler(nil)
 	checkSampler.bucketExpiry = 10 * time.Millisecond
 
 	bucket1 := &metrics.HistogramBucket{
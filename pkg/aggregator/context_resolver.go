@@ -17,6 +17,8 @@ type Context struct {
 	Name string
 	Tags []string
 	Host string
+	// Origin is the tagger entity that emitted the metric, if known
+	Origin string
 }
 
 // ContextResolver allows tracking and expiring contexts
@@ -85,3 +87,27 @@ func (cr *ContextResolver) expireContexts(expireTimestamp float64) []ckey.Contex
 
 	return expiredContextKeys
 }
+
+// setOrigin records the origin of a tracked context
+func (cr *ContextResolver) setOrigin(contextKey ckey.ContextKey, origin string) {
+	if context, ok := cr.contextsByKey[contextKey]; ok {
+		context.Origin = origin
+	}
+}
+
+// contextsByOrigins returns the keys of the contexts emitted by the given origins
+func (cr *ContextResolver) contextsByOrigins(origins map[string]struct{}) []ckey.ContextKey {
+	var contextKeys []ckey.ContextKey
+	for contextKey, context := range cr.contextsByKey {
+		if _, ok := origins[context.Origin]; ok && context.Origin != "" {
+			contextKeys = append(contextKeys, contextKey)
+		}
+	}
+	return contextKeys
+}
+
+// removeContext stops tracking a context
+func (cr *ContextResolver) removeContext(contextKey ckey.ContextKey) {
+	delete(cr.contextsByKey, contextKey)
+	delete(cr.lastSeenByKey, contextKey)
+}
@@ -49,6 +49,11 @@ func (m *MockSender) ServiceCheck(checkName string, status metrics.ServiceCheckS
 	m.Called(checkName, status, hostname, tags, message)
 }
 
+//ServiceCheckWithCorrelation enables the service check with correlation mock call.
+func (m *MockSender) ServiceCheckWithCorrelation(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string, correlation metrics.Correlation) {
+	m.Called(checkName, status, hostname, tags, message, correlation)
+}
+
 //DisableDefaultHostname enables the hostname mock call.
 func (m *MockSender) DisableDefaultHostname(d bool) {
 	m.Called(d)
@@ -59,6 +64,21 @@ func (m *MockSender) Event(e metrics.Event) {
 	m.Called(e)
 }
 
+//EventPlatformEvent enables the event platform event mock call.
+func (m *MockSender) EventPlatformEvent(rawEvent string, track string) {
+	m.Called(rawEvent, track)
+}
+
+//CountWithInterval adds a count with interval type to the mock calls.
+func (m *MockSender) CountWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string) {
+	m.Called(metric, value, timestamp, interval, hostname, tags)
+}
+
+//RateWithInterval adds a rate with interval type to the mock calls.
+func (m *MockSender) RateWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string) {
+	m.Called(metric, value, timestamp, interval, hostname, tags)
+}
+
 //HistogramBucket enables the histogram bucket mock call.
 func (m *MockSender) HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
 	m.Called(metric, value, lowerBound, upperBound, monotonic, hostname, tags)
@@ -79,6 +99,11 @@ func (m *MockSender) SetCheckService(service string) {
 	m.Called(service)
 }
 
+//SetMaxMetricsPerRun enables the setting of the max metrics per run mock call.
+func (m *MockSender) SetMaxMetricsPerRun(max int) {
+	m.Called(max)
+}
+
 //FinalizeCheckServiceTag enables the sending of check service tag mock call.
 func (m *MockSender) FinalizeCheckServiceTag() {
 	m.Called()
@@ -46,6 +46,16 @@ func (m *MockSender) SetupAcceptAll() {
 			mock.AnythingOfType("[]string"), // Tags
 		).Return()
 	}
+	for _, call := range []string{"CountWithInterval", "RateWithInterval"} {
+		m.On(call,
+			mock.AnythingOfType("string"),   // Metric
+			mock.AnythingOfType("float64"),  // Value
+			mock.AnythingOfType("float64"),  // Timestamp
+			mock.AnythingOfType("int64"),    // Interval
+			mock.AnythingOfType("string"),   // Hostname
+			mock.AnythingOfType("[]string"), // Tags
+		).Return()
+	}
 	m.On("ServiceCheck",
 		mock.AnythingOfType("string"),                     // checkName (e.g: docker.exit)
 		mock.AnythingOfType("metrics.ServiceCheckStatus"), // (e.g: metrics.ServiceCheckOK)
@@ -53,7 +63,19 @@ func (m *MockSender) SetupAcceptAll() {
 		mock.AnythingOfType("[]string"),                   // Tags
 		mock.AnythingOfType("string"),                     // message
 	).Return()
+	m.On("ServiceCheckWithCorrelation",
+		mock.AnythingOfType("string"),                     // checkName (e.g: docker.exit)
+		mock.AnythingOfType("metrics.ServiceCheckStatus"), // (e.g: metrics.ServiceCheckOK)
+		mock.AnythingOfType("string"),                     // Hostname
+		mock.AnythingOfType("[]string"),                   // Tags
+		mock.AnythingOfType("string"),                     // message
+		mock.AnythingOfType("metrics.Correlation"),        // correlation
+	).Return()
 	m.On("Event", mock.AnythingOfType("metrics.Event")).Return()
+	m.On("EventPlatformEvent",
+		mock.AnythingOfType("string"), // raw event
+		mock.AnythingOfType("string"), // track
+	).Return()
 	m.On("HistogramBucket",
 		mock.AnythingOfType("string"),   // metric name
 		mock.AnythingOfType("int64"),    // value
@@ -67,6 +89,7 @@ func (m *MockSender) SetupAcceptAll() {
 	m.On("DisableDefaultHostname", mock.AnythingOfType("bool")).Return()
 	m.On("SetCheckCustomTags", mock.AnythingOfType("[]string")).Return()
 	m.On("SetCheckService", mock.AnythingOfType("string")).Return()
+	m.On("SetMaxMetricsPerRun", mock.AnythingOfType("int")).Return()
 	m.On("FinalizeCheckServiceTag").Return()
 	m.On("Commit").Return()
 }
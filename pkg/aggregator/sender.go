@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/epforwarder"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
@@ -31,13 +32,18 @@ type Sender interface {
 	Counter(metric string, value float64, hostname string, tags []string)
 	Histogram(metric string, value float64, hostname string, tags []string)
 	Historate(metric string, value float64, hostname string, tags []string)
+	CountWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string)
+	RateWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string)
 	ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string)
+	ServiceCheckWithCorrelation(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string, correlation metrics.Correlation)
 	HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string)
 	Event(e metrics.Event)
+	EventPlatformEvent(rawEvent string, track string)
 	GetMetricStats() map[string]int64
 	DisableDefaultHostname(disable bool)
 	SetCheckCustomTags(tags []string)
 	SetCheckService(service string)
+	SetMaxMetricsPerRun(max int)
 	FinalizeCheckServiceTag()
 }
 
@@ -46,9 +52,14 @@ type metricStats struct {
 	Events           int64
 	ServiceChecks    int64
 	HistogramBuckets int64
+	DroppedMetrics   int64
 	Lock             sync.RWMutex
 }
 
+// maxMetricsServiceCheck reports whether a check submitted more metrics than
+// its max_metrics_per_run limit during its last run
+const maxMetricsServiceCheck = "datadog.agent.check_metrics_limit"
+
 // RawSender interface to submit samples to aggregator directly
 type RawSender interface {
 	SendRawMetricSample(sample *metrics.MetricSample)
@@ -69,6 +80,7 @@ type checkSender struct {
 	histogramBucketOut      chan<- senderHistogramBucket
 	checkTags               []string
 	service                 string
+	maxMetricsPerRun        int
 }
 
 type senderMetricSample struct {
@@ -178,6 +190,13 @@ func (s *checkSender) SetCheckService(service string) {
 	s.service = service
 }
 
+// SetMaxMetricsPerRun limits the number of metric samples and histogram
+// buckets submitted during a check run, the others are dropped. No limit
+// when max isn't positive.
+func (s *checkSender) SetMaxMetricsPerRun(max int) {
+	s.maxMetricsPerRun = max
+}
+
 // FinalizeCheckServiceTag appends the service as a tag for metrics, events, and service checks
 func (s *checkSender) FinalizeCheckServiceTag() {
 	if s.service != "" {
@@ -188,11 +207,53 @@ func (s *checkSender) FinalizeCheckServiceTag() {
 // Commit commits the metric samples & histogram buckets that were added during a check run
 // Should be called at the end of every check run
 func (s *checkSender) Commit() {
+	if s.maxMetricsPerRun > 0 {
+		s.reportDroppedMetrics()
+	}
 	// we use a metric sample to commit both for metrics & sketches
 	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
 	s.cyclemetricStats()
 }
 
+// acceptMetric returns whether a metric sample or histogram bucket is within
+// the max_metrics_per_run limit of the current run, and counts it otherwise
+func (s *checkSender) acceptMetric() bool {
+	if s.maxMetricsPerRun <= 0 {
+		return true
+	}
+	s.metricStats.Lock.Lock()
+	defer s.metricStats.Lock.Unlock()
+	if s.metricStats.MetricSamples+s.metricStats.HistogramBuckets < int64(s.maxMetricsPerRun) {
+		return true
+	}
+	s.metricStats.DroppedMetrics++
+	return false
+}
+
+// reportDroppedMetrics sends the service check of the max_metrics_per_run
+// limit for the current run
+func (s *checkSender) reportDroppedMetrics() {
+	s.metricStats.Lock.RLock()
+	dropped := s.metricStats.DroppedMetrics
+	s.metricStats.Lock.RUnlock()
+
+	serviceCheck := metrics.ServiceCheck{
+		CheckName: maxMetricsServiceCheck,
+		Status:    metrics.ServiceCheckOK,
+		Ts:        time.Now().Unix(),
+		Tags:      append([]string{fmt.Sprintf("check:%s", check.IDToCheckName(s.id))}, s.checkTags...),
+	}
+	if !s.defaultHostnameDisabled {
+		serviceCheck.Host = s.defaultHostname
+	}
+	if dropped > 0 {
+		log.Warnf("Check %s submitted more than %d metrics during its run, %d were dropped", string(s.id), s.maxMetricsPerRun, dropped)
+		serviceCheck.Status = metrics.ServiceCheckWarning
+		serviceCheck.Message = fmt.Sprintf("%d metrics dropped, the check is limited to %d metrics per run by max_metrics_per_run", dropped, s.maxMetricsPerRun)
+	}
+	s.serviceCheckOut <- serviceCheck
+}
+
 func (s *checkSender) GetMetricStats() map[string]int64 {
 	s.priormetricStats.Lock.RLock()
 	defer s.priormetricStats.Lock.RUnlock()
@@ -202,6 +263,7 @@ func (s *checkSender) GetMetricStats() map[string]int64 {
 	metricStats["Events"] = s.priormetricStats.Events
 	metricStats["ServiceChecks"] = s.priormetricStats.ServiceChecks
 	metricStats["HistogramBuckets"] = s.priormetricStats.HistogramBuckets
+	metricStats["DroppedMetrics"] = s.priormetricStats.DroppedMetrics
 
 	return metricStats
 }
@@ -213,10 +275,12 @@ func (s *checkSender) cyclemetricStats() {
 	s.priormetricStats.Events = s.metricStats.Events
 	s.priormetricStats.ServiceChecks = s.metricStats.ServiceChecks
 	s.priormetricStats.HistogramBuckets = s.metricStats.HistogramBuckets
+	s.priormetricStats.DroppedMetrics = s.metricStats.DroppedMetrics
 	s.metricStats.MetricSamples = 0
 	s.metricStats.Events = 0
 	s.metricStats.ServiceChecks = 0
 	s.metricStats.HistogramBuckets = 0
+	s.metricStats.DroppedMetrics = 0
 	s.metricStats.Lock.Unlock()
 	s.priormetricStats.Lock.Unlock()
 }
@@ -228,6 +292,13 @@ func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
 }
 
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) {
+	s.sendSample(metric, value, timeNowNano(), 0, hostname, tags, mType)
+}
+
+func (s *checkSender) sendSample(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string, mType metrics.MetricType) {
+	if !s.acceptMetric() {
+		return
+	}
 	tags = append(tags, s.checkTags...)
 
 	log.Trace(mType.String(), " sample: ", metric, ": ", value, " for hostname: ", hostname, " tags: ", tags)
@@ -239,7 +310,8 @@ func (s *checkSender) sendMetricSample(metric string, value float64, hostname st
 		Tags:       tags,
 		Host:       hostname,
 		SampleRate: 1,
-		Timestamp:  timeNowNano(),
+		Timestamp:  timestamp,
+		Interval:   interval,
 	}
 
 	if hostname == "" && !s.defaultHostnameDisabled {
@@ -286,8 +358,33 @@ func (s *checkSender) Histogram(metric string, value float64, hostname string, t
 	s.sendMetricSample(metric, value, hostname, tags, metrics.HistogramType)
 }
 
+// CountWithInterval should be used to send a count the check already aggregated
+// over the interval, in seconds, starting at the timestamp. It's submitted as
+// is instead of being aggregated over the check run. A zero timestamp stands
+// for the current time.
+func (s *checkSender) CountWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string) {
+	if timestamp == 0 {
+		timestamp = timeNowNano()
+	}
+	s.sendSample(metric, value, timestamp, interval, hostname, tags, metrics.IntervalCountType)
+}
+
+// RateWithInterval should be used to send a per second rate the check already
+// computed over the interval, in seconds, starting at the timestamp. It's
+// submitted as is instead of being derived from the previous check run. A
+// zero timestamp stands for the current time.
+func (s *checkSender) RateWithInterval(metric string, value float64, timestamp float64, interval int64, hostname string, tags []string) {
+	if timestamp == 0 {
+		timestamp = timeNowNano()
+	}
+	s.sendSample(metric, value, timestamp, interval, hostname, tags, metrics.IntervalRateType)
+}
+
 // HistogramBucket should be called to directly send raw buckets to be submitted as distribution metrics
 func (s *checkSender) HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
+	if !s.acceptMetric() {
+		return
+	}
 	tags = append(tags, s.checkTags...)
 
 	log.Tracef(
@@ -337,6 +434,12 @@ func (s *checkSender) SendRawServiceCheck(sc *metrics.ServiceCheck) {
 
 // ServiceCheck submits a service check
 func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string) {
+	s.ServiceCheckWithCorrelation(checkName, status, hostname, tags, message, metrics.Correlation{})
+}
+
+// ServiceCheckWithCorrelation submits a service check linked to an event and
+// a log snippet. The event is submitted with the same ID as CorrelationID.
+func (s *checkSender) ServiceCheckWithCorrelation(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string, correlation metrics.Correlation) {
 	log.Trace("Service check submitted: ", checkName, ": ", status.String(), " for hostname: ", hostname, " tags: ", tags)
 	serviceCheck := metrics.ServiceCheck{
 		CheckName: checkName,
@@ -346,6 +449,7 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 		Tags:      append(tags, s.checkTags...),
 		Message:   message,
 	}
+	serviceCheck.SetCorrelation(correlation)
 
 	if hostname == "" && !s.defaultHostnameDisabled {
 		serviceCheck.Host = s.defaultHostname
@@ -375,6 +479,19 @@ func (s *checkSender) Event(e metrics.Event) {
 	s.metricStats.Lock.Unlock()
 }
 
+// EventPlatformEvent submits a JSON encoded event to an event platform track.
+// Events are dropped when they are submitted faster than they can be sent.
+func (s *checkSender) EventPlatformEvent(rawEvent string, track string) {
+	log.Tracef("Event platform event submitted to track %s: %s", track, rawEvent)
+
+	err := epforwarder.SendEventPlatformEvent([]byte(rawEvent), track)
+	if err == epforwarder.ErrQueueFull {
+		log.Debugf("Event platform event of check %s dropped: %s", string(s.id), err)
+	} else if err != nil {
+		log.Errorf("Could not submit the event platform event of check %s: %s", string(s.id), err)
+	}
+}
+
 // changeAllSendersDefaultHostname u
 func (sp *checkSenderPool) changeAllSendersDefaultHostname(hostname string) {
 	sp.m.Lock()
@@ -470,6 +470,88 @@ func TestCheckSenderInterface(t *testing.T) {
 	assert.Equal(t, []string{"foo", "bar"}, histogramBucket.bucket.Tags)
 }
 
+func TestCheckSenderServiceCheckWithCorrelation(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+
+	correlation := metrics.Correlation{ID: metrics.NewCorrelationID(), LogSnippet: "connection refused"}
+	checkSender.ServiceCheckWithCorrelation("my_service.can_connect", metrics.ServiceCheckCritical, "", []string{"foo"}, "message", correlation)
+	checkSender.Event(metrics.Event{Title: "my_service is down", CorrelationID: correlation.ID})
+
+	serviceCheck := <-serviceCheckChan
+	assert.Equal(t, metrics.ServiceCheckCritical, serviceCheck.Status)
+	assert.Equal(t, "default-hostname", serviceCheck.Host)
+	assert.Equal(t, correlation.ID, serviceCheck.CorrelationID)
+	assert.Equal(t, "connection refused", serviceCheck.LogSnippet)
+	event := <-eventChan
+	assert.Equal(t, correlation.ID, event.CorrelationID)
+	assert.EqualValues(t, 1, checkSender.metricStats.ServiceChecks)
+}
+
+func TestCheckSenderWithInterval(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+	checkSender.CountWithInterval("my.count_metric", 12.0, 1600000000.0, 300, "", []string{"foo"})
+	checkSender.RateWithInterval("my.rate_metric", 0.5, 0, 60, "my-hostname", []string{"foo"})
+
+	countSenderSample := <-senderMetricSampleChan
+	assert.Equal(t, metrics.IntervalCountType, countSenderSample.metricSample.Mtype)
+	assert.Equal(t, 1600000000.0, countSenderSample.metricSample.Timestamp)
+	assert.Equal(t, int64(300), countSenderSample.metricSample.Interval)
+	assert.Equal(t, "default-hostname", countSenderSample.metricSample.Host)
+
+	rateSenderSample := <-senderMetricSampleChan
+	assert.Equal(t, metrics.IntervalRateType, rateSenderSample.metricSample.Mtype)
+	assert.NotZero(t, rateSenderSample.metricSample.Timestamp, "the current time is used")
+	assert.Equal(t, int64(60), rateSenderSample.metricSample.Interval)
+	assert.Equal(t, 2, int(checkSender.metricStats.MetricSamples))
+}
+
+func TestCheckSenderMaxMetricsPerRun(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+	checkSender.SetCheckCustomTags([]string{"custom:tag"})
+	checkSender.SetMaxMetricsPerRun(2)
+
+	checkSender.Gauge("my.metric", 1.0, "", nil)
+	checkSender.HistogramBucket("my.histogram_bucket", 42, 1.0, 2.0, true, "", nil)
+	checkSender.Gauge("my.metric", 2.0, "", nil)
+	checkSender.HistogramBucket("my.histogram_bucket", 43, 1.0, 2.0, true, "", nil)
+	checkSender.Commit()
+
+	assert.Len(t, senderMetricSampleChan, 2, "one sample and the commit")
+	assert.Len(t, bucketChan, 1)
+	stats := checkSender.GetMetricStats()
+	assert.EqualValues(t, 1, stats["MetricSamples"])
+	assert.EqualValues(t, 1, stats["HistogramBuckets"])
+	assert.EqualValues(t, 2, stats["DroppedMetrics"])
+	assert.EqualValues(t, 0, stats["ServiceChecks"])
+
+	serviceCheck := <-serviceCheckChan
+	assert.Equal(t, maxMetricsServiceCheck, serviceCheck.CheckName)
+	assert.Equal(t, metrics.ServiceCheckWarning, serviceCheck.Status)
+	assert.Equal(t, "default-hostname", serviceCheck.Host)
+	assert.Equal(t, []string{"check:1", "custom:tag"}, serviceCheck.Tags)
+	assert.Equal(t, "2 metrics dropped, the check is limited to 2 metrics per run by max_metrics_per_run", serviceCheck.Message)
+
+	// the limit applies to each run
+	checkSender.Gauge("my.metric", 1.0, "", nil)
+	checkSender.Commit()
+	assert.EqualValues(t, 0, checkSender.GetMetricStats()["DroppedMetrics"])
+	serviceCheck = <-serviceCheckChan
+	assert.Equal(t, metrics.ServiceCheckOK, serviceCheck.Status)
+	assert.Empty(t, serviceCheck.Message)
+}
+
 func TestCheckSenderHostname(t *testing.T) {
 	defaultHostname := "default-host"
 
@@ -30,6 +30,7 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	deletedOrigins              map[string]struct{}
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
@@ -43,6 +44,7 @@ func NewTimeSampler(interval int64) *TimeSampler {
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		deletedOrigins:              map[string]struct{}{},
 	}
 }
 
@@ -58,6 +60,9 @@ func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) b
 func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
 	// Keep track of the context
 	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	if metricSample.OriginID != "" {
+		s.contextResolver.setOrigin(contextKey, metricSample.OriginID)
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
@@ -151,6 +156,7 @@ func (s *TimeSampler) flushSeries(cutoffTime int64) metrics.Series {
 			serie.Tags = context.Tags
 			serie.Host = context.Host
 			serie.Interval = s.interval
+			serie.Origin = metrics.DogStatsDOriginFromTags(context.Tags)
 
 			serieBySignature[serieSignature] = serie
 			series = append(series, serie)
@@ -181,16 +187,54 @@ func (s *TimeSampler) flush(timestamp float64) (metrics.Series, metrics.SketchSe
 	// Compute a limit timestamp
 	cutoffTime := s.calculateBucketStart(timestamp)
 
+	// Stop sending zeros for the counters of the deleted origins
+	var deletedContexts []ckey.ContextKey
+	if len(s.deletedOrigins) > 0 {
+		deletedContexts = s.contextResolver.contextsByOrigins(s.deletedOrigins)
+		for _, contextKey := range deletedContexts {
+			delete(s.counterLastSampledByContext, contextKey)
+		}
+		s.deletedOrigins = map[string]struct{}{}
+	}
+
 	series := s.flushSeries(cutoffTime)
 	sketches := s.flushSketches(cutoffTime)
 
 	// expiring contexts
 	s.contextResolver.expireContexts(timestamp - defaultExpiry)
+	s.expireDeletedContexts(deletedContexts)
 	s.lastCutOffTime = cutoffTime
 
 	return series, sketches
 }
 
+// deleteOrigins registers origins that were deleted, e.g. stopped containers,
+// so that their contexts are expired at the next flush
+func (s *TimeSampler) deleteOrigins(origins []string) {
+	for _, origin := range origins {
+		s.deletedOrigins[origin] = struct{}{}
+	}
+}
+
+// expireDeletedContexts stops tracking the given contexts, unless they still
+// have samples in an open bucket, in which case they will expire normally
+func (s *TimeSampler) expireDeletedContexts(contextKeys []ckey.ContextKey) {
+ContextLoop:
+	for _, contextKey := range contextKeys {
+		for _, contextMetrics := range s.metricsByTimestamp {
+			if _, ok := contextMetrics[contextKey]; ok {
+				continue ContextLoop
+			}
+		}
+		for _, sketches := range s.sketchMap {
+			if _, ok := sketches[contextKey]; ok {
+				continue ContextLoop
+			}
+		}
+		s.contextResolver.removeContext(contextKey)
+	}
+}
+
 // flushContextMetrics flushes the passed contextMetrics, handles its errors, and returns its series
 func (s *TimeSampler) flushContextMetrics(timestamp int64, contextMetrics metrics.ContextMetrics) []*metrics.Serie {
 	series, errors := contextMetrics.Flush(float64(timestamp))
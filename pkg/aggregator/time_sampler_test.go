@@ -54,6 +54,7 @@ func TestBucketSampling(t *testing.T) {
 		MType:      metrics.APIGaugeType,
 		Interval:   10,
 		NameSuffix: "",
+		Origin:     metrics.DogStatsDOrigin,
 	}
 
 	assert.Equal(t, 1, len(sampler.metricsByTimestamp))
@@ -62,6 +63,35 @@ func TestBucketSampling(t *testing.T) {
 	}
 }
 
+func TestBucketSamplingJMXOrigin(t *testing.T) {
+	sampler := NewTimeSampler(10)
+
+	sampler.addSample(&metrics.MetricSample{
+		Name:       "jvm.heap_memory",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		Tags:       []string{"instance:tomcat", "jmx_domain:java.lang"},
+		SampleRate: 1,
+	}, 12345.0)
+	sampler.addSample(&metrics.MetricSample{
+		Name:       "my.metric.name",
+		Value:      1,
+		Mtype:      metrics.GaugeType,
+		SampleRate: 1,
+	}, 12345.0)
+
+	series, _ := sampler.flush(12360.0)
+	if assert.Equal(t, 2, len(series)) {
+		for _, serie := range series {
+			if serie.Name == "jvm.heap_memory" {
+				assert.Equal(t, metrics.JMXOrigin, serie.Origin)
+			} else {
+				assert.Equal(t, metrics.DogStatsDOrigin, serie.Origin)
+			}
+		}
+	}
+}
+
 func TestContextSampling(t *testing.T) {
 	sampler := NewTimeSampler(10)
 
@@ -258,6 +288,59 @@ func TestCounterExpirySeconds(t *testing.T) {
 	assert.Equal(t, 0, len(sampler.contextResolver.contextsByKey))
 }
 
+func TestDeletedOriginContexts(t *testing.T) {
+	sampler := NewTimeSampler(10)
+
+	sampleCounter1 := &metrics.MetricSample{
+		Name:       "my.counter1",
+		Value:      1,
+		Mtype:      metrics.CounterType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+		OriginID:   "container_id://deleted",
+	}
+	sampleCounter2 := &metrics.MetricSample{
+		Name:       "my.counter2",
+		Value:      1,
+		Mtype:      metrics.CounterType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+	}
+	sampleGauge := &metrics.MetricSample{
+		Name:       "my.gauge",
+		Value:      2,
+		Mtype:      metrics.GaugeType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+		OriginID:   "container_id://deleted",
+	}
+
+	sampler.addSample(sampleCounter1, 1002.0)
+	sampler.addSample(sampleCounter2, 1002.0)
+	series, _ := sampler.flush(1010.0)
+	assert.Equal(t, 2, len(series))
+	assert.Equal(t, "container_id://deleted", sampler.contextResolver.contextsByKey[generateContextKey(sampleCounter1)].Origin)
+
+	// The gauge is still in an open bucket when the origin is deleted
+	sampler.addSample(sampleCounter2, 1012.0)
+	sampler.addSample(sampleGauge, 1025.0)
+	sampler.deleteOrigins([]string{"container_id://deleted"})
+
+	// Only the counter without origin is reported
+	series, _ = sampler.flush(1020.0)
+	require.Equal(t, 1, len(series))
+	assert.Equal(t, "my.counter2", series[0].Name)
+	assert.Equal(t, 1, len(sampler.counterLastSampledByContext))
+	assert.Equal(t, 0, len(sampler.deletedOrigins))
+
+	// The context of the deleted counter is expired, the gauge is kept until it is flushed
+	assert.Equal(t, 2, len(sampler.contextResolver.contextsByKey))
+	assert.NotContains(t, sampler.contextResolver.contextsByKey, generateContextKey(sampleCounter1))
+
+	series, _ = sampler.flush(1030.0)
+	assert.Equal(t, 2, len(series))
+}
+
 func TestSketch(t *testing.T) {
 	const (
 		defaultBucketSize = 10
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package audit records the commands received by the agent IPC API in a
+// dedicated log file, to know who changed the state of the agent and when
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const redacted = "********"
+
+// sensitiveArgs are the argument names whose values are never logged
+var sensitiveArgs = []string{"key", "token", "password", "secret", "pass"}
+
+var (
+	mu     sync.Mutex
+	logger *Logger
+)
+
+// Entry is an audited IPC command
+type Entry struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Args       map[string]string `json:"args,omitempty"`
+	RemoteAddr string            `json:"remote_addr"`
+	// UID and User identify the caller when its connection could be matched to a local user
+	UID       string `json:"uid,omitempty"`
+	User      string `json:"user,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Status    int    `json:"status"`
+}
+
+// Init sets up the audit log used by Middleware
+func Init(path string, maxSize int64) error {
+	l, err := NewLogger(path, maxSize)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if logger != nil {
+		logger.Close()
+	}
+	logger = l
+	return nil
+}
+
+// Middleware audits the commands of the authenticated requests, every request
+// but the GET ones, once they are handled. It's a no-op until Init is called.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		l := logger
+		mu.Unlock()
+		if l == nil || r.Method == http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		entry := Entry{
+			Timestamp:  time.Now(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Args:       requestArgs(r),
+			RemoteAddr: r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+			Status:     recorder.status,
+		}
+		if uid, err := peerUID(r.RemoteAddr); err == nil {
+			entry.UID = uid
+			if u, err := user.LookupId(uid); err == nil {
+				entry.User = u.Username
+			}
+		}
+		if err := l.Log(entry); err != nil {
+			log.Warnf("Could not write to the IPC audit log: %v", err)
+		}
+	})
+}
+
+// requestArgs returns the scrubbed arguments of a request: its route
+// variables, query parameters and form values
+func requestArgs(r *http.Request) map[string]string {
+	args := make(map[string]string)
+	for k, v := range mux.Vars(r) {
+		args[k] = v
+	}
+	// The handlers parse the form themselves, parsing it here would consume the body
+	form := r.Form
+	if form == nil {
+		form = r.URL.Query()
+	}
+	for k, values := range form {
+		sort.Strings(values)
+		args[k] = strings.Join(values, ",")
+	}
+
+	// A setting name like api_key makes its value sensitive
+	sensitive := false
+	for _, v := range mux.Vars(r) {
+		sensitive = sensitive || isSensitive(v)
+	}
+	for k, v := range args {
+		if isSensitive(k) || (sensitive && k == "value") {
+			args[k] = redacted
+			continue
+		}
+		if scrubbed, err := log.CredentialsCleanerBytes([]byte(v)); err == nil {
+			args[k] = string(scrubbed)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveArgs {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher for the streaming endpoints
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Logger writes audit entries as JSON lines to a file, rotated once it
+// reaches its maximum size, the previous file being kept with a .1 suffix
+type Logger struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+// NewLogger opens an audit log file
+func NewLogger(path string, maxSize int64) (*Logger, error) {
+	l := &Logger{path: path, maxSize: maxSize}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Log writes an entry
+func (l *Logger) Log(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxSize > 0 && l.size+int64(len(line)) > l.maxSize && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *Logger) rotate() error {
+	l.file.Close()
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the audit log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestLoggerRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ipc_audit.log")
+
+	l, err := NewLogger(path, 300)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Log(Entry{Method: "POST", Path: "/agent/flare", RemoteAddr: "127.0.0.1:4242"}))
+	}
+	require.NoError(t, l.Close())
+
+	current := readEntries(t, path)
+	rotated := readEntries(t, path+".1")
+	assert.NotEmpty(t, current)
+	assert.NotEmpty(t, rotated)
+	assert.Len(t, append(current, rotated...), 4)
+}
+
+func TestRequestArgs(t *testing.T) {
+	r := httptest.NewRequest("POST", "/agent/config/api_key", strings.NewReader(url.Values{"value": {"abcdef"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"setting": "api_key"})
+	require.NoError(t, r.ParseForm())
+	assert.Equal(t, map[string]string{"setting": "api_key", "value": redacted}, requestArgs(r))
+
+	r = httptest.NewRequest("POST", "/agent/config/log_level?token=foo", strings.NewReader(url.Values{"value": {"debug"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"setting": "log_level"})
+	require.NoError(t, r.ParseForm())
+	assert.Equal(t, map[string]string{"setting": "log_level", "value": "debug", "token": redacted}, requestArgs(r))
+
+	r = httptest.NewRequest("POST", "/agent/flare", nil)
+	assert.Nil(t, requestArgs(r))
+}
+
+func TestMiddleware(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipc-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ipc_audit.log")
+
+	require.NoError(t, Init(path, 0))
+	defer func() {
+		logger.Close()
+		logger = nil
+	}()
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/agent/stop" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/agent/status", nil),
+		httptest.NewRequest("POST", "/agent/stop", nil),
+		httptest.NewRequest("POST", "/agent/flare?profile=30", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/agent/stop", entries[0].Path)
+	assert.Equal(t, http.StatusForbidden, entries[0].Status)
+	assert.Equal(t, "POST", entries[1].Method)
+	assert.Equal(t, http.StatusOK, entries[1].Status)
+	assert.Equal(t, map[string]string{"profile": "30"}, entries[1].Args)
+	assert.False(t, entries[1].Timestamp.IsZero())
+}
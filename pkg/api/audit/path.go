@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package audit
+
+import (
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// defaultFileName is the name of the audit log, next to the agent log file by default
+const defaultFileName = "ipc_audit.log"
+
+// FilePath returns the path of the audit log, ipc_audit.file or a file
+// next to the agent log file
+func FilePath(logFile string) string {
+	if path := config.Datadog.GetString("ipc_audit.file"); path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(logFile), defaultFileName)
+}
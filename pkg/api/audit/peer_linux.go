@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package audit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+var procNetTCPFiles = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+
+// peerUID returns the uid owning the local socket at the other end of a
+// connection to the IPC API, found in the procfs TCP tables
+func peerUID(remoteAddr string) (string, error) {
+	host, rawPort, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(host)
+	port, err := strconv.Atoi(rawPort)
+	if ip == nil || err != nil {
+		return "", fmt.Errorf("invalid address %s", remoteAddr)
+	}
+	wanted := procNetAddress(ip, port)
+
+	for _, path := range procNetTCPFiles {
+		uid, err := findUID(path, wanted)
+		if err == nil && uid != "" {
+			return uid, nil
+		}
+	}
+	return "", fmt.Errorf("no socket found for %s", remoteAddr)
+}
+
+// findUID returns the uid of the socket whose local address is wanted
+func findUID(path string, wanted []string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		for _, w := range wanted {
+			if fields[1] == w {
+				return fields[7], nil
+			}
+		}
+	}
+	return "", scanner.Err()
+}
+
+// procNetAddress formats an address as in the procfs TCP tables: the words
+// of the address in host byte order, then the port. An IPv4 address can be
+// listed in tcp6 as an IPv4-mapped address.
+func procNetAddress(ip net.IP, port int) []string {
+	var addresses []string
+	if v4 := ip.To4(); v4 != nil {
+		addresses = append(addresses, fmt.Sprintf("%s:%04X", hostOrderHex(v4), port))
+	}
+	addresses = append(addresses, fmt.Sprintf("%s:%04X", hostOrderHex(ip.To16()), port))
+	return addresses
+}
+
+// hostOrderHex formats the words of an address as the kernel prints them,
+// as numbers read in host byte order
+func hostOrderHex(ip []byte) string {
+	var sb strings.Builder
+	for i := 0; i+4 <= len(ip); i += 4 {
+		fmt.Fprintf(&sb, "%08X", nativeEndian.Uint32(ip[i:i+4]))
+	}
+	return sb.String()
+}
+
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
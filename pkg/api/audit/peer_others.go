@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package audit
+
+import "errors"
+
+// peerUID is only implemented on linux
+func peerUID(remoteAddr string) (string, error) {
+	return "", errors.New("caller identity is only resolved on linux")
+}
@@ -65,6 +65,7 @@ func healthHandler(getStatusNonBlocking func() (health.Status, error), w http.Re
 	health, err := getStatusNonBlocking()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	if len(health.Unhealthy) > 0 {
@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package localapi implements a read-only HTTP API, served on a unix socket,
+that local applications query to enrich their own telemetry with the host
+tags, entity tags and configuration used by the Agent.
+*/
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+	"github.com/gorilla/mux"
+)
+
+const defaultTimeout = 5 * time.Second
+
+var (
+	// For testing purposes
+	tagEntity   = tagger.Tag
+	getHostTags = hostTags
+	getHostname = util.GetHostname
+)
+
+// Serve configures and starts the http server on the given unix socket.
+// It returns an error if the setup failed, or runs the server in a goroutine.
+// Stop the server by cancelling the passed context.
+func Serve(ctx context.Context, socketPath string) error {
+	if socketPath == "" {
+		return errors.New("socket path should be non-empty")
+	}
+	ln, err := listenUnix(socketPath)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           newRouter(),
+		ReadTimeout:       defaultTimeout,
+		ReadHeaderTimeout: defaultTimeout,
+		WriteTimeout:      defaultTimeout,
+	}
+
+	go srv.Serve(ln) //nolint:errcheck
+	go closeOnContext(ctx, srv)
+	return nil
+}
+
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/host/tags", hostTagsHandler).Methods("GET")
+	r.HandleFunc("/tags", entityTagsHandler).Methods("GET")
+	r.HandleFunc("/config", configHandler).Methods("GET")
+	return r
+}
+
+// listenUnix listens on the socket, removing a stale one left by a previous run
+func listenUnix(path string) (net.Listener, error) {
+	fi, err := os.Stat(path)
+	if err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("cannot reuse %q; not a unix socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unable to remove stale socket: %v", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0722); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("error setting socket permissions: %v", err)
+	}
+	return ln, nil
+}
+
+func closeOnContext(ctx context.Context, srv *http.Server) {
+	// Wait for the context to be canceled
+	<-ctx.Done()
+
+	// Shutdown the server, it will close the listener
+	timeout, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Shutdown(timeout) //nolint:errcheck
+}
+
+// hostTags returns the host tags sent in the last host metadata payload
+func hostTags() []string {
+	hostnameData, err := util.GetHostnameData()
+	if err != nil {
+		log.Debugf("Could not get the hostname: %s", err)
+	}
+	payload := host.GetPayloadFromCache(hostnameData)
+	if payload.HostTags == nil {
+		return []string{}
+	}
+	tags := make([]string, 0, len(payload.HostTags.System)+len(payload.HostTags.GoogleCloudPlatform))
+	tags = append(tags, payload.HostTags.System...)
+	return append(tags, payload.HostTags.GoogleCloudPlatform...)
+}
+
+func hostTagsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string][]string{"tags": getHostTags()})
+}
+
+// entityTagsHandler returns the tags of the entity given by the container_id
+// or entity_id query parameter, at the cardinality given by the cardinality
+// query parameter, low by default.
+func entityTagsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	entity := query.Get("entity_id")
+	if containerID := query.Get("container_id"); containerID != "" {
+		entity = containers.BuildTaggerEntityName(containerID)
+	}
+	if entity == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing container_id or entity_id parameter"))
+		return
+	}
+
+	cardinality := collectors.LowCardinality
+	if c := query.Get("cardinality"); c != "" {
+		var err error
+		if cardinality, err = tagger.StringToTagCardinality(c); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	tags, err := tagEntity(entity, cardinality)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"entity": entity,
+		"tags":   tags,
+	})
+}
+
+// configHandler returns the settings applications need to be consistent with
+// the Agent. It never returns secrets like the API key.
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	hostname, err := getHostname()
+	if err != nil {
+		log.Debugf("Could not get the hostname: %s", err)
+	}
+	site := config.Datadog.GetString("site")
+	if site == "" {
+		site = config.DefaultSite
+	}
+	writeJSON(w, map[string]string{
+		"hostname": hostname,
+		"site":     site,
+		"env":      config.Datadog.GetString("env"),
+		"version":  version.AgentVersion,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body) //nolint:errcheck
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	http.Error(w, string(body), code)
+}
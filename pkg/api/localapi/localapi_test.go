@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+func query(t *testing.T, method, url string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, url, nil)
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if rec.Code != http.StatusMethodNotAllowed {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+	return rec.Code, body
+}
+
+func TestHostTags(t *testing.T) {
+	defer func(f func() []string) { getHostTags = f }(getHostTags)
+	getHostTags = func() []string { return []string{"role:database", "env:prod"} }
+
+	code, body := query(t, "GET", "/host/tags")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"role:database", "env:prod"}, body["tags"])
+
+	code, _ = query(t, "POST", "/host/tags")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestEntityTags(t *testing.T) {
+	defer func(f func(string, collectors.TagCardinality) ([]string, error)) { tagEntity = f }(tagEntity)
+	tagEntity = func(entity string, cardinality collectors.TagCardinality) ([]string, error) {
+		if cardinality == collectors.HighCardinality {
+			return []string{"image_name:redis", "container_id:abc"}, nil
+		}
+		return []string{"image_name:redis"}, nil
+	}
+
+	code, body := query(t, "GET", "/tags?container_id=abc")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "container_id://abc", body["entity"])
+	assert.Equal(t, []interface{}{"image_name:redis"}, body["tags"])
+
+	code, body = query(t, "GET", "/tags?entity_id=container_id://abc&cardinality=high")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"image_name:redis", "container_id:abc"}, body["tags"])
+
+	code, _ = query(t, "GET", "/tags")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = query(t, "GET", "/tags?container_id=abc&cardinality=invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestConfig(t *testing.T) {
+	defer func(f func() (string, error)) { getHostname = f }(getHostname)
+	getHostname = func() (string, error) { return "myhost", nil }
+
+	mockConfig := config.Mock()
+	mockConfig.Set("env", "prod")
+	mockConfig.Set("api_key", "secret")
+	defer mockConfig.Set("env", "")
+
+	code, body := query(t, "GET", "/config")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "myhost", body["hostname"])
+	assert.Equal(t, config.DefaultSite, body["site"])
+	assert.Equal(t, "prod", body["env"])
+	assert.NotContains(t, body, "api_key")
+}
+
+func TestServe(t *testing.T) {
+	defer func(f func() []string) { getHostTags = f }(getHostTags)
+	getHostTags = func() []string { return []string{"role:database"} }
+
+	dir, err := ioutil.TempDir("", "localapi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "agent.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, Serve(ctx, socketPath))
+
+	client := http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	resp, err := client.Get("http://localhost/host/tags")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tags":["role:database"]}`, string(body))
+}
+
+func TestServeNotASocket(t *testing.T) {
+	f, err := ioutil.TempFile("", "localapi")
+	require.NoError(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	assert.Error(t, Serve(context.Background(), f.Name()))
+}
@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Mutual TLS modes between the node agents and the cluster agent
+const (
+	// MTLSDisabled only authenticates the node agents with the cluster agent auth token
+	MTLSDisabled = "disabled"
+	// MTLSMigration accepts both client certificates and the auth token, to
+	// migrate the node agents one at a time
+	MTLSMigration = "migration"
+	// MTLSRequired only accepts node agents presenting a valid client certificate
+	MTLSRequired = "required"
+)
+
+// minReloadInterval is the minimum interval between two checks of the certificate files
+const minReloadInterval = 10 * time.Second
+
+// GetClusterAgentMTLSMode returns the configured mutual TLS mode between the
+// node agents and the cluster agent
+func GetClusterAgentMTLSMode() (string, error) {
+	mode := config.Datadog.GetString("cluster_agent.mtls.mode")
+	switch mode {
+	case "", MTLSDisabled:
+		return MTLSDisabled, nil
+	case MTLSMigration, MTLSRequired:
+		return mode, nil
+	}
+	return MTLSDisabled, fmt.Errorf("invalid cluster_agent.mtls.mode %q, expected one of %s, %s or %s", mode, MTLSDisabled, MTLSMigration, MTLSRequired)
+}
+
+// NewClusterAgentCertReloader returns a CertReloader for the certificate
+// files configured in cluster_agent.mtls
+func NewClusterAgentCertReloader() (*CertReloader, error) {
+	return NewCertReloader(
+		config.Datadog.GetString("cluster_agent.mtls.cert_file"),
+		config.Datadog.GetString("cluster_agent.mtls.key_file"),
+		config.Datadog.GetString("cluster_agent.mtls.ca_file"),
+	)
+}
+
+// CertReloader serves a certificate and a CA issued by the cluster, reloading
+// them when their files change so that rotated certificates are picked up
+// without restarting the agents
+type CertReloader struct {
+	certFile, keyFile, caFile string
+
+	mu         sync.Mutex
+	cert       *tls.Certificate
+	pool       *x509.CertPool
+	modTimes   [3]time.Time
+	lastReload time.Time
+}
+
+// NewCertReloader loads a certificate, its key and a CA bundle
+func NewCertReloader(certFile, keyFile, caFile string) (*CertReloader, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("the certificate, key and CA files are required for mutual TLS")
+	}
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) load() error {
+	var modTimes [3]time.Time
+	for i, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	if r.cert != nil && modTimes == r.modTimes {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load the certificate: %v", err)
+	}
+	caPEM, err := ioutil.ReadFile(r.caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no certificate found in %s", r.caFile)
+	}
+
+	r.cert = &cert
+	r.pool = pool
+	r.modTimes = modTimes
+	return nil
+}
+
+// current returns the certificate and CA pool, reloading them if their files changed
+func (r *CertReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastReload) >= minReloadInterval {
+		r.lastReload = time.Now()
+		// Keep serving the previous certificate while the new files are partially written
+		if err := r.load(); err != nil {
+			log.Warnf("Could not reload the mutual TLS certificates, using the previous ones: %v", err)
+		}
+	}
+	return r.cert, r.pool
+}
+
+// ServerTLSConfig returns the TLS configuration of the cluster agent server.
+// Client certificates are verified against the cluster CA when presented,
+// and required in the MTLSRequired mode.
+func (r *CertReloader) ServerTLSConfig(mode string) *tls.Config {
+	clientAuth := tls.VerifyClientCertIfGiven
+	if mode == MTLSRequired {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			tlsConfig := &tls.Config{
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    pool,
+			}
+			config.ConfigureFIPSTLS(tlsConfig)
+			return tlsConfig, nil
+		},
+	}
+}
+
+// ClientTLSConfig returns the TLS configuration of the node agents, presenting
+// their certificate and verifying the cluster agent one against the cluster CA.
+// The cluster agent certificate must be valid for serverName, the host of the
+// configured cluster agent URL.
+func (r *CertReloader) ClientTLSConfig(serverName string) *tls.Config {
+	tlsConfig := &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// The CA can be rotated, the chain is verified against the current one below
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return r.verifyServer(rawCerts, serverName)
+		},
+	}
+	config.ConfigureFIPSTLS(tlsConfig)
+	return tlsConfig
+}
+
+func (r *CertReloader) verifyServer(rawCerts [][]byte, serverName string) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no certificate presented by the cluster agent")
+	}
+	if serverName == "" {
+		return errors.New("no server name to verify the cluster agent certificate against")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	_, pool := r.current()
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package security
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type testCA struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     interface{}
+}
+
+func newTestCA(t *testing.T) testCA {
+	cert, certPEM, key, err := GenerateRootCert(nil, 2048)
+	require.NoError(t, err)
+	return testCA{cert: cert, certPEM: certPEM, key: key}
+}
+
+// issue returns the PEM encoded certificate and key of a leaf signed by the CA,
+// valid for 127.0.0.1
+func (ca testCA) issue(t *testing.T, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	return ca.issueFor(t, usage, "127.0.0.1")
+}
+
+// issueFor returns the PEM encoded certificate and key of a leaf signed by the
+// CA, valid for the given IP
+func (ca testCA) issueFor(t *testing.T, usage x509.ExtKeyUsage, ip string) ([]byte, []byte) {
+	tmpl, err := CertTemplate()
+	require.NoError(t, err)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	tmpl.IPAddresses = []net.IP{net.ParseIP(ip)}
+
+	key, err := GenerateKeyPair(2048)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func writeCerts(t *testing.T, dir, name string, certPEM, keyPEM, caPEM []byte) (string, string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	caFile := filepath.Join(dir, name+"-ca.crt")
+	require.NoError(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(caFile, caPEM, 0600))
+	return certFile, keyFile, caFile
+}
+
+func TestGetClusterAgentMTLSMode(t *testing.T) {
+	mockConfig := config.Mock()
+
+	mode, err := GetClusterAgentMTLSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, MTLSDisabled, mode)
+
+	mockConfig.Set("cluster_agent.mtls.mode", MTLSRequired)
+	mode, err = GetClusterAgentMTLSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, MTLSRequired, mode)
+
+	mockConfig.Set("cluster_agent.mtls.mode", "strict")
+	_, err = GetClusterAgentMTLSMode()
+	assert.Error(t, err)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, x509.ExtKeyUsageClientAuth)
+
+	serverReloader, err := NewCertReloader(writeCerts(t, dir, "server", serverCert, serverKey, ca.certPEM))
+	require.NoError(t, err)
+	clientReloader, err := NewCertReloader(writeCerts(t, dir, "client", clientCert, clientKey, ca.certPEM))
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = serverReloader.ServerTLSConfig(MTLSRequired)
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientReloader.ClientTLSConfig("127.0.0.1")}}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// A client with a certificate issued by another CA is rejected
+	otherCA := newTestCA(t)
+	otherCert, otherKey := otherCA.issue(t, x509.ExtKeyUsageClientAuth)
+	otherReloader, err := NewCertReloader(writeCerts(t, dir, "other", otherCert, otherKey, ca.certPEM))
+	require.NoError(t, err)
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: otherReloader.ClientTLSConfig("127.0.0.1")}}
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	// The client doesn't trust a server whose certificate is issued by another CA
+	otherServerCert, otherServerKey := otherCA.issue(t, x509.ExtKeyUsageServerAuth)
+	otherServerReloader, err := NewCertReloader(writeCerts(t, dir, "otherserver", otherServerCert, otherServerKey, ca.certPEM))
+	require.NoError(t, err)
+	otherServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	otherServer.TLS = otherServerReloader.ServerTLSConfig(MTLSMigration)
+	otherServer.StartTLS()
+	defer otherServer.Close()
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: clientReloader.ClientTLSConfig("127.0.0.1")}}
+	_, err = client.Get(otherServer.URL)
+	assert.Error(t, err)
+
+	// The client doesn't trust a certificate issued by the cluster CA for another host
+	anotherHostCert, anotherHostKey := ca.issueFor(t, x509.ExtKeyUsageServerAuth, "10.0.0.1")
+	anotherHostReloader, err := NewCertReloader(writeCerts(t, dir, "anotherhost", anotherHostCert, anotherHostKey, ca.certPEM))
+	require.NoError(t, err)
+	anotherHostServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	anotherHostServer.TLS = anotherHostReloader.ServerTLSConfig(MTLSMigration)
+	anotherHostServer.StartTLS()
+	defer anotherHostServer.Close()
+	_, err = client.Get(anotherHostServer.URL)
+	assert.Error(t, err)
+}
+
+func TestCertReloaderRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, x509.ExtKeyUsageClientAuth)
+	certFile, keyFile, caFile := writeCerts(t, dir, "client", certPEM, keyPEM, ca.certPEM)
+	r, err := NewCertReloader(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	first, _ := r.current()
+
+	// Rotate the certificate
+	newCertPEM, newKeyPEM := ca.issue(t, x509.ExtKeyUsageClientAuth)
+	writeCerts(t, dir, "client", newCertPEM, newKeyPEM, ca.certPEM)
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile, caFile} {
+		require.NoError(t, os.Chtimes(f, future, future))
+	}
+
+	// The files are only checked again after minReloadInterval
+	unchanged, _ := r.current()
+	assert.Equal(t, first, unchanged)
+
+	r.lastReload = time.Time{}
+	rotated, _ := r.current()
+	assert.NotEqual(t, first.Certificate[0], rotated.Certificate[0])
+
+	// A partially written rotation keeps the previous certificate
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("garbage"), 0600))
+	later := future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	r.lastReload = time.Time{}
+	current, _ := r.current()
+	assert.Equal(t, rotated, current)
+}
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+type connContextKey struct{}
+
+// ConnContext stores the connection of the requests in their context, it's
+// meant to be set as the ConnContext of http.Server
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// GetConnection returns the connection of a request served by a server using
+// ConnContext, so that streaming endpoints can lift the server write timeout
+func GetConnection(r *http.Request) net.Conn {
+	conn, _ := r.Context().Value(connContextKey{}).(net.Conn)
+	return conn
+}
@@ -6,9 +6,12 @@
 package util
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 )
@@ -16,6 +19,10 @@ import (
 var (
 	token    string
 	dcaToken string
+
+	// scopedTokens maps the scoped session tokens to their scope
+	scopedTokens      = map[string]string{}
+	scopedTokensMutex sync.RWMutex
 )
 
 // SetAuthToken sets the session token
@@ -70,6 +77,48 @@ func GetDCAAuthToken() string {
 	return dcaToken
 }
 
+// CreateScopedToken creates a session token granting the same access as the
+// auth token, that identifies the scope of the requests authenticated with it,
+// e.g. the subprocess they come from
+func CreateScopedToken(scope string) (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("can't create scoped session token: %s", err)
+	}
+	scopedToken := hex.EncodeToString(key)
+
+	scopedTokensMutex.Lock()
+	defer scopedTokensMutex.Unlock()
+	scopedTokens[scopedToken] = scope
+	return scopedToken, nil
+}
+
+// RevokeScopedToken revokes a token created by CreateScopedToken
+func RevokeScopedToken(scopedToken string) {
+	scopedTokensMutex.Lock()
+	defer scopedTokensMutex.Unlock()
+	delete(scopedTokens, scopedToken)
+}
+
+// GetTokenScope returns the scope of the session token of a request, or an
+// empty string for the auth token
+func GetTokenScope(r *http.Request) string {
+	tok := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(tok) < 2 {
+		return ""
+	}
+	scopedTokensMutex.RLock()
+	defer scopedTokensMutex.RUnlock()
+	return scopedTokens[tok[1]]
+}
+
+func isScopedToken(t string) bool {
+	scopedTokensMutex.RLock()
+	defer scopedTokensMutex.RUnlock()
+	_, ok := scopedTokens[t]
+	return ok
+}
+
 // Validate validates an http request
 func Validate(w http.ResponseWriter, r *http.Request) error {
 	var err error
@@ -89,7 +138,7 @@ func Validate(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	if len(tok) < 2 || tok[1] != GetAuthToken() {
+	if len(tok) < 2 || (tok[1] != GetAuthToken() && !isScopedToken(tok[1])) {
 		err = fmt.Errorf("invalid session token")
 		http.Error(w, err.Error(), 403)
 	}
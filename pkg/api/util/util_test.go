@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedToken(t *testing.T) {
+	token = "authtoken"
+	defer func() { token = "" }()
+
+	scoped, err := CreateScopedToken("kafka")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, scoped)
+
+	for _, tc := range []struct {
+		auth          string
+		expectedCode  int
+		expectedScope string
+	}{
+		{"Bearer authtoken", 200, ""},
+		{"Bearer " + scoped, 200, "kafka"},
+		{"Bearer invalid", 403, ""},
+	} {
+		r := httptest.NewRequest("GET", "/agent/jmx/configs", nil)
+		r.Header.Set("Authorization", tc.auth)
+		w := httptest.NewRecorder()
+		Validate(w, r) //nolint:errcheck
+		assert.Equal(t, tc.expectedCode, w.Code, tc.auth)
+		assert.Equal(t, tc.expectedScope, GetTokenScope(r), tc.auth)
+	}
+
+	RevokeScopedToken(scoped)
+	r := httptest.NewRequest("GET", "/agent/jmx/configs", nil)
+	r.Header.Set("Authorization", "Bearer "+scoped)
+	w := httptest.NewRecorder()
+	assert.Error(t, Validate(w, r))
+	assert.Equal(t, "", GetTokenScope(r))
+}
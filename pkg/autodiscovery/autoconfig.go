@@ -64,6 +64,7 @@ type AutoConfig struct {
 	newService         chan listeners.Service
 	delService         chan listeners.Service
 	store              *store
+	history            *scheduleHistory
 	m                  sync.RWMutex
 }
 
@@ -78,6 +79,7 @@ func NewAutoConfig(scheduler *scheduler.MetaScheduler) *AutoConfig {
 		newService:         make(chan listeners.Service),
 		delService:         make(chan listeners.Service),
 		store:              newStore(),
+		history:            newScheduleHistory(),
 		scheduler:          scheduler,
 	}
 	// We need to listen to the service channels before anything is sent to them
@@ -189,7 +191,7 @@ func (ac *AutoConfig) AddConfigProvider(provider providers.ConfigProvider, shoul
 // that don't need polling will be queried at least once
 func (ac *AutoConfig) LoadAndRun() {
 	resolvedConfigs := ac.GetAllConfigs()
-	ac.schedule(resolvedConfigs)
+	ac.schedule(resolvedConfigs, triggerInitialLoad)
 }
 
 // GetAllConfigs queries all the providers and returns all the integration
@@ -242,13 +244,16 @@ func (ac *AutoConfig) GetAllConfigs() []integration.Config {
 	return resolvedConfigs
 }
 
-// schedule takes a slice of configs and schedule them
-func (ac *AutoConfig) schedule(configs []integration.Config) {
+// schedule takes a slice of configs and schedule them, recording the
+// trigger in the schedule history
+func (ac *AutoConfig) schedule(configs []integration.Config, trigger string) {
+	ac.history.scheduled(configs, trigger, time.Now())
 	ac.scheduler.Schedule(configs)
 }
 
 // unschedule takes a slice of configs and unschedule them
 func (ac *AutoConfig) unschedule(configs []integration.Config) {
+	ac.history.unscheduled(configs, time.Now())
 	ac.scheduler.Unschedule(configs)
 }
 
@@ -558,6 +563,12 @@ func GetConfigErrors() map[string]string {
 	return errorStats.getConfigErrors()
 }
 
+// GetScheduleHistory returns, for each check instance ID, when and why
+// it was last (re)scheduled
+func (ac *AutoConfig) GetScheduleHistory() map[string]integration.ScheduleRecord {
+	return ac.history.get()
+}
+
 // GetResolveWarnings get the resolve warnings/errors
 func GetResolveWarnings() map[string][]string {
 	return errorStats.getResolveWarnings()
@@ -598,7 +609,7 @@ func (ac *AutoConfig) processNewService(svc listeners.Service) {
 		}
 
 		// ask the Collector to schedule the checks
-		ac.schedule([]integration.Config{resolvedConfig})
+		ac.schedule([]integration.Config{resolvedConfig}, fmt.Sprintf("%s %s", triggerService, svc.GetEntity()))
 	}
 	// FIXME: schedule new services as well
 	ac.schedule([]integration.Config{
@@ -610,7 +621,7 @@ func (ac *AutoConfig) processNewService(svc listeners.Service) {
 			MetricsExcluded: svc.HasFilter(containers.MetricsFilter),
 			LogsExcluded:    svc.HasFilter(containers.LogsFilter),
 		},
-	})
+	}, triggerService)
 
 }
 
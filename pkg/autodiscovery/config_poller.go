@@ -105,7 +105,7 @@ func (pd *configPoller) poll(ac *AutoConfig) {
 			for _, config := range newConfigs {
 				config.Provider = pd.provider.String()
 				resolvedConfigs := ac.processNewConfig(config)
-				ac.schedule(resolvedConfigs)
+				ac.schedule(resolvedConfigs, triggerProviderPoll)
 			}
 		}
 	}
@@ -65,11 +65,14 @@ type CommonInstanceConfig struct {
 	Service               string   `yaml:"service"`
 	Name                  string   `yaml:"name"`
 	Namespace             string   `yaml:"namespace"`
+	Loader                string   `yaml:"loader"`
+	MaxMetricsPerRun      int      `yaml:"max_metrics_per_run"`
 }
 
 // CommonGlobalConfig holds the reserved fields for the yaml init_config data
 type CommonGlobalConfig struct {
 	Service string `yaml:"service"`
+	Loader  string `yaml:"loader"`
 }
 
 // Equal determines whether the passed config is the same
@@ -213,6 +216,27 @@ func (c *Data) GetNameForInstance() string {
 	return commonOptions.Namespace
 }
 
+// GetLoaderForInstance returns the name of the loader the instance should be
+// loaded with first, set by the `loader` option of the instance or else of the
+// init_config. It returns an empty string when none is set.
+func (c *Config) GetLoaderForInstance(instance Data) string {
+	commonOptions := CommonInstanceConfig{}
+	if err := yaml.Unmarshal(instance, &commonOptions); err != nil {
+		log.Errorf("invalid instance section: %s", err)
+		return ""
+	}
+	if commonOptions.Loader != "" {
+		return commonOptions.Loader
+	}
+
+	globalOptions := CommonGlobalConfig{}
+	if err := yaml.Unmarshal(c.InitConfig, &globalOptions); err != nil {
+		log.Errorf("invalid init_config section: %s", err)
+		return ""
+	}
+	return globalOptions.Loader
+}
+
 // MergeAdditionalTags merges additional tags to possible existing config tags
 func (c *Data) MergeAdditionalTags(tags []string) error {
 	rawConfig := RawMap{}
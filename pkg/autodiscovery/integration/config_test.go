@@ -252,6 +252,19 @@ func TestGetNameForInstance(t *testing.T) {
 	assert.Equal(t, config.Instances[0].GetNameForInstance(), "")
 }
 
+func TestGetLoaderForInstance(t *testing.T) {
+	config := &Config{Name: "foo"}
+	assert.Equal(t, "", config.GetLoaderForInstance(Data("foo: bar")))
+
+	config.InitConfig = Data("loader: python")
+	assert.Equal(t, "python", config.GetLoaderForInstance(Data("foo: bar")))
+	assert.Equal(t, "core", config.GetLoaderForInstance(Data("loader: core")))
+
+	config.InitConfig = Data("fooBarBaz")
+	assert.Equal(t, "core", config.GetLoaderForInstance(Data("loader: core")))
+	assert.Equal(t, "", config.GetLoaderForInstance(Data("foo: bar")))
+}
+
 // this is here to prevent compiler optimization on the benchmarking code
 var result string
 
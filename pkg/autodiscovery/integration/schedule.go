@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package integration
+
+import "time"
+
+// ScheduleRecord describes the last time a check instance was (re)scheduled
+// by autodiscovery and what caused it
+type ScheduleRecord struct {
+	CheckName     string    `json:"check_name"`
+	Provider      string    `json:"provider"`
+	Source        string    `json:"source"`
+	Trigger       string    `json:"trigger"`
+	LastScheduled time.Time `json:"last_scheduled"`
+	Unscheduled   time.Time `json:"unscheduled"`
+	ScheduleCount int       `json:"schedule_count"`
+}
@@ -277,6 +277,11 @@ func (l *SNMPListener) createService(entityID string, subnet *snmpSubnet, device
 	l.services[entityID] = svc
 	subnet.devices[entityID] = deviceIP
 	subnet.deviceFailures[entityID] = 0
+	snmp.AddDevice(snmp.Device{
+		IP:           deviceIP,
+		Network:      subnet.config.Network,
+		ADIdentifier: subnet.adIdentifier,
+	})
 	if writeCache {
 		l.writeCache(subnet)
 	}
@@ -299,6 +304,7 @@ func (l *SNMPListener) deleteService(entityID string, subnet *snmpSubnet) {
 		if l.config.AllowedFailures != -1 && failure >= l.config.AllowedFailures {
 			l.delService <- svc
 			delete(l.services, entityID)
+			snmp.RemoveDevice(subnet.devices[entityID])
 			delete(subnet.devices, entityID)
 			l.writeCache(subnet)
 		}
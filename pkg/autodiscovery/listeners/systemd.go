@@ -0,0 +1,345 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build systemd
+
+package listeners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/coreos/go-systemd/dbus"
+	godbus "github.com/godbus/dbus"
+)
+
+const (
+	systemdEntityPrefix = "systemd://"
+	systemdActiveState  = "active"
+
+	// Keys of the [Unit] section of a unit file read by the listener, systemd
+	// ignoring keys prefixed with X-
+	unitFileADIdentifiersKey = "X-Datadog-AD-Identifiers"
+	unitFileCheckNamesKey    = "X-Datadog-Check-Names"
+	unitFileTagsKey          = "X-Datadog-Tags"
+)
+
+func init() {
+	Register("systemd", NewSystemdListener)
+}
+
+// systemdConn is the subset of the go-systemd dbus connection used by the listener
+type systemdConn interface {
+	SubscribeUnitsCustom(interval time.Duration, buffer int, isChanged func(*dbus.UnitStatus, *dbus.UnitStatus) bool, filterUnit func(string) bool) (<-chan map[string]*dbus.UnitStatus, <-chan error)
+	GetUnitProperty(unit string, propertyName string) (*dbus.Property, error)
+	GetUnitTypeProperty(unit string, unitType string, propertyName string) (*dbus.Property, error)
+	Close()
+}
+
+// SystemdListener watches the systemd units and exposes the active ones as
+// services, so that templates can be scheduled against them on hosts
+// without containers
+type SystemdListener struct {
+	sync.RWMutex
+	conn         systemdConn
+	includeUnits []*regexp.Regexp
+	interval     time.Duration
+	services     map[string]*SystemdService // maps unit names to services
+	newService   chan<- Service
+	delService   chan<- Service
+	stop         chan struct{}
+}
+
+// SystemdService implements and store results from the Service interface for the systemd listener
+type SystemdService struct {
+	unit          string
+	adIdentifiers []string
+	checkNames    []string
+	tags          []string
+	pid           int
+	creationTime  integration.CreationTime
+}
+
+// Make sure SystemdService implements the Service interface
+var _ Service = &SystemdService{}
+
+// NewSystemdListener creates a SystemdListener
+func NewSystemdListener() (ServiceListener, error) {
+	var includeUnits []*regexp.Regexp
+	for _, pattern := range config.Datadog.GetStringSlice("systemd_listener.include_units") {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid systemd_listener.include_units pattern %q: %v", pattern, err)
+		}
+		includeUnits = append(includeUnits, re)
+	}
+
+	conn, err := newSystemdConn(config.Datadog.GetString("systemd_listener.private_socket"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to systemd: %v", err)
+	}
+
+	return &SystemdListener{
+		conn:         conn,
+		includeUnits: includeUnits,
+		interval:     config.Datadog.GetDuration("systemd_listener.polling_interval") * time.Second,
+		services:     make(map[string]*SystemdService),
+		stop:         make(chan struct{}),
+	}, nil
+}
+
+// newSystemdConn connects to systemd through the system bus, or through its
+// private socket if one is configured
+func newSystemdConn(privateSocket string) (*dbus.Conn, error) {
+	if privateSocket == "" {
+		return dbus.New()
+	}
+	return dbus.NewConnection(func() (*godbus.Conn, error) {
+		conn, err := godbus.Dial(fmt.Sprintf("unix:path=%s", privateSocket))
+		if err != nil {
+			return nil, err
+		}
+		methods := []godbus.Auth{godbus.AuthExternal(fmt.Sprintf("%d", os.Getuid()))}
+		if err = conn.Auth(methods); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	})
+}
+
+// Listen subscribes to the unit state changes and reports the active units as services
+func (l *SystemdListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	l.newService = newSvc
+	l.delService = delSvc
+
+	changes, errs := l.conn.SubscribeUnitsCustom(l.interval, 0, unitStateChanged, l.isFiltered)
+	go func() {
+		firstRun := true
+		for {
+			select {
+			case <-l.stop:
+				l.conn.Close()
+				return
+			case err := <-errs:
+				log.Warnf("Error while watching systemd units: %v", err)
+			case units := <-changes:
+				l.processUnits(units, firstRun)
+				firstRun = false
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of SystemdListener
+func (l *SystemdListener) Stop() {
+	l.stop <- struct{}{}
+}
+
+// isFiltered returns true for the units the listener should ignore: only
+// services are watched by default, include_units selects other units
+func (l *SystemdListener) isFiltered(unit string) bool {
+	if len(l.includeUnits) == 0 {
+		return !strings.HasSuffix(unit, ".service")
+	}
+	for _, re := range l.includeUnits {
+		if re.MatchString(unit) {
+			return false
+		}
+	}
+	return true
+}
+
+// unitStateChanged only reports changes of the active state, sub state
+// changes like reloading don't affect the services
+func unitStateChanged(u1, u2 *dbus.UnitStatus) bool {
+	return u1.ActiveState != u2.ActiveState
+}
+
+// processUnits creates services for the units becoming active and removes
+// the ones of units stopped or unloaded, which are reported as nil
+func (l *SystemdListener) processUnits(units map[string]*dbus.UnitStatus, firstRun bool) {
+	crTime := integration.After
+	if firstRun {
+		crTime = integration.Before
+	}
+
+	for name, status := range units {
+		l.RLock()
+		svc, found := l.services[name]
+		l.RUnlock()
+
+		active := status != nil && status.ActiveState == systemdActiveState
+		switch {
+		case active && !found:
+			svc = l.createService(name, crTime)
+			l.Lock()
+			l.services[name] = svc
+			l.Unlock()
+			log.Debugf("Systemd unit %s is active, adding service", name)
+			l.newService <- svc
+		case !active && found:
+			l.Lock()
+			delete(l.services, name)
+			l.Unlock()
+			log.Debugf("Systemd unit %s is not active anymore, removing service", name)
+			l.delService <- svc
+		}
+	}
+}
+
+func (l *SystemdListener) createService(unit string, crTime integration.CreationTime) *SystemdService {
+	svc := &SystemdService{
+		unit:          unit,
+		adIdentifiers: []string{unit, strings.TrimSuffix(unit, ".service")},
+		pid:           -1,
+		creationTime:  crTime,
+	}
+	if strings.HasSuffix(unit, ".service") {
+		if prop, err := l.conn.GetUnitTypeProperty(unit, "Service", "MainPID"); err == nil {
+			if pid, ok := prop.Value.Value().(uint32); ok && pid > 0 {
+				svc.pid = int(pid)
+			}
+		}
+	}
+
+	prop, err := l.conn.GetUnitProperty(unit, "FragmentPath")
+	if err != nil {
+		log.Debugf("Cannot get the unit file of %s: %v", unit, err)
+		return svc
+	}
+	path, _ := prop.Value.Value().(string)
+	if path == "" {
+		return svc
+	}
+	keys, err := readUnitFileKeys(path)
+	if err != nil {
+		log.Debugf("Cannot read the unit file of %s: %v", unit, err)
+		return svc
+	}
+	if ids := splitUnitFileList(keys[unitFileADIdentifiersKey]); len(ids) > 0 {
+		svc.adIdentifiers = ids
+	}
+	svc.checkNames = splitUnitFileList(keys[unitFileCheckNamesKey])
+	svc.tags = splitUnitFileList(keys[unitFileTagsKey])
+	return svc
+}
+
+// readUnitFileKeys returns the Datadog keys of the [Unit] section of a unit file
+func readUnitFileKeys(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys := make(map[string]string)
+	inUnitSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inUnitSection = line == "[Unit]"
+			continue
+		}
+		if !inUnitSection || !strings.HasPrefix(line, "X-Datadog-") {
+			continue
+		}
+		if idx := strings.Index(line, "="); idx > 0 {
+			keys[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// splitUnitFileList splits a comma separated value of a unit file key
+func splitUnitFileList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetEntity returns the unique entity name linked to that service
+func (s *SystemdService) GetEntity() string {
+	return systemdEntityPrefix + s.unit
+}
+
+// GetTaggerEntity returns the unique entity name linked to that service
+func (s *SystemdService) GetTaggerEntity() string {
+	return s.GetEntity()
+}
+
+// GetADIdentifiers returns the unit name, with and without the .service
+// suffix, or the identifiers set in the unit file
+func (s *SystemdService) GetADIdentifiers() ([]string, error) {
+	return s.adIdentifiers, nil
+}
+
+// GetHosts returns the loopback address, units running on the host
+func (s *SystemdService) GetHosts() (map[string]string, error) {
+	return map[string]string{"host": "127.0.0.1"}, nil
+}
+
+// GetPorts returns nil and an error because ports are not known to systemd
+func (s *SystemdService) GetPorts() ([]ContainerPort, error) {
+	return nil, ErrNotSupported
+}
+
+// GetTags returns the tags set in the unit file and the unit name
+func (s *SystemdService) GetTags() ([]string, error) {
+	return append([]string{"unit:" + s.unit}, s.tags...), nil
+}
+
+// GetPid returns the main pid of a service unit
+func (s *SystemdService) GetPid() (int, error) {
+	if s.pid <= 0 {
+		return -1, ErrNotSupported
+	}
+	return s.pid, nil
+}
+
+// GetHostname returns nil and an error because hostname is not supported
+func (s *SystemdService) GetHostname() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetCreationTime returns the creation time of the service compare to the agent start.
+func (s *SystemdService) GetCreationTime() integration.CreationTime {
+	return s.creationTime
+}
+
+// IsReady returns true, the service being created once the unit is active
+func (s *SystemdService) IsReady() bool {
+	return true
+}
+
+// GetCheckNames returns the check names set in the unit file
+func (s *SystemdService) GetCheckNames() []string {
+	return s.checkNames
+}
+
+// HasFilter returns false, container filters don't apply to units
+func (s *SystemdService) HasFilter(filter containers.FilterType) bool {
+	return false
+}
+
+// GetExtraConfig isn't supported
+func (s *SystemdService) GetExtraConfig(key []byte) ([]byte, error) {
+	return []byte{}, ErrNotSupported
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build systemd
+
+package listeners
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/dbus"
+	godbus "github.com/godbus/dbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+type fakeSystemdConn struct {
+	properties map[string]interface{} // unit/property -> value
+}
+
+func (c *fakeSystemdConn) SubscribeUnitsCustom(time.Duration, int, func(*dbus.UnitStatus, *dbus.UnitStatus) bool, func(string) bool) (<-chan map[string]*dbus.UnitStatus, <-chan error) {
+	return nil, nil
+}
+
+func (c *fakeSystemdConn) GetUnitProperty(unit string, propertyName string) (*dbus.Property, error) {
+	return c.property(unit, propertyName)
+}
+
+func (c *fakeSystemdConn) GetUnitTypeProperty(unit string, unitType string, propertyName string) (*dbus.Property, error) {
+	return c.property(unit, propertyName)
+}
+
+func (c *fakeSystemdConn) property(unit string, propertyName string) (*dbus.Property, error) {
+	value, found := c.properties[unit+"/"+propertyName]
+	if !found {
+		return nil, errors.New("property not found")
+	}
+	return &dbus.Property{Name: propertyName, Value: godbus.MakeVariant(value)}, nil
+}
+
+func (c *fakeSystemdConn) Close() {}
+
+func TestSystemdListenerProcessUnits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "systemd-listener")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	unitFile := filepath.Join(dir, "redis.service")
+	err = ioutil.WriteFile(unitFile, []byte(`[Unit]
+Description=Redis
+X-Datadog-AD-Identifiers=redis, redisdb
+X-Datadog-Check-Names=redisdb
+X-Datadog-Tags=team:cache,env:prod
+
+[Service]
+X-Datadog-Tags=ignored
+ExecStart=/usr/bin/redis-server
+`), 0644)
+	require.NoError(t, err)
+
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	l := &SystemdListener{
+		conn: &fakeSystemdConn{properties: map[string]interface{}{
+			"redis.service/FragmentPath": unitFile,
+			"redis.service/MainPID":      uint32(1234),
+			"nginx.service/MainPID":      uint32(0),
+		}},
+		services:   make(map[string]*SystemdService),
+		newService: newSvc,
+		delService: delSvc,
+	}
+
+	l.processUnits(map[string]*dbus.UnitStatus{
+		"redis.service": {Name: "redis.service", ActiveState: "active"},
+		"nginx.service": {Name: "nginx.service", ActiveState: "active"},
+		"cron.service":  {Name: "cron.service", ActiveState: "failed"},
+	}, true)
+	require.Len(t, newSvc, 2)
+	assert.Len(t, delSvc, 0)
+
+	redis := l.services["redis.service"]
+	require.NotNil(t, redis)
+	assert.Equal(t, "systemd://redis.service", redis.GetEntity())
+	ids, _ := redis.GetADIdentifiers()
+	assert.Equal(t, []string{"redis", "redisdb"}, ids)
+	assert.Equal(t, []string{"redisdb"}, redis.GetCheckNames())
+	tags, _ := redis.GetTags()
+	assert.Equal(t, []string{"unit:redis.service", "team:cache", "env:prod"}, tags)
+	pid, err := redis.GetPid()
+	assert.NoError(t, err)
+	assert.Equal(t, 1234, pid)
+	assert.Equal(t, integration.Before, redis.GetCreationTime())
+
+	nginx := l.services["nginx.service"]
+	require.NotNil(t, nginx)
+	ids, _ = nginx.GetADIdentifiers()
+	assert.Equal(t, []string{"nginx.service", "nginx"}, ids)
+	_, err = nginx.GetPid()
+	assert.Error(t, err)
+
+	// Sub state changes of active units are no-ops, stopped and removed units are deleted
+	l.processUnits(map[string]*dbus.UnitStatus{
+		"redis.service": nil,
+		"nginx.service": {Name: "nginx.service", ActiveState: "active", SubState: "reloading"},
+		"cron.service":  {Name: "cron.service", ActiveState: "active"},
+	}, false)
+	require.Len(t, delSvc, 1)
+	assert.Equal(t, "systemd://redis.service", (<-delSvc).GetEntity())
+	assert.Len(t, newSvc, 3)
+	assert.Equal(t, integration.After, l.services["cron.service"].GetCreationTime())
+	assert.NotContains(t, l.services, "redis.service")
+}
+
+func TestSystemdListenerIsFiltered(t *testing.T) {
+	l := &SystemdListener{}
+	assert.False(t, l.isFiltered("redis.service"))
+	assert.True(t, l.isFiltered("docker.socket"))
+
+	l.includeUnits = []*regexp.Regexp{regexp.MustCompile(`^docker\.`)}
+	assert.False(t, l.isFiltered("docker.socket"))
+	assert.True(t, l.isFiltered("redis.service"))
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// Triggers of a schedule, reported in the schedule history
+const (
+	triggerInitialLoad  = "initial load"
+	triggerProviderPoll = "provider poll"
+	triggerService      = "service"
+)
+
+// scheduleHistoryRetention is how long the history of an unscheduled
+// instance is kept, so that flapping instances remain visible
+var scheduleHistoryRetention = time.Hour
+
+// scheduleHistory records, for each check instance, when and why it was
+// last (re)scheduled
+type scheduleHistory struct {
+	records map[string]integration.ScheduleRecord // check ID -> record
+	m       sync.RWMutex
+}
+
+func newScheduleHistory() *scheduleHistory {
+	return &scheduleHistory{
+		records: make(map[string]integration.ScheduleRecord),
+	}
+}
+
+// scheduled records the scheduling of every instance of the given configs
+func (sh *scheduleHistory) scheduled(configs []integration.Config, trigger string, now time.Time) {
+	sh.m.Lock()
+	defer sh.m.Unlock()
+
+	for _, c := range configs {
+		for _, inst := range c.Instances {
+			id := string(check.BuildID(c.Name, inst, c.InitConfig))
+			record := sh.records[id]
+			record.CheckName = c.Name
+			record.Provider = c.Provider
+			record.Source = c.Source
+			record.Trigger = trigger
+			record.LastScheduled = now
+			record.Unscheduled = time.Time{}
+			record.ScheduleCount++
+			sh.records[id] = record
+		}
+	}
+	sh.prune(now)
+}
+
+// unscheduled marks the instances of the given configs as unscheduled
+func (sh *scheduleHistory) unscheduled(configs []integration.Config, now time.Time) {
+	sh.m.Lock()
+	defer sh.m.Unlock()
+
+	for _, c := range configs {
+		for _, inst := range c.Instances {
+			id := string(check.BuildID(c.Name, inst, c.InitConfig))
+			if record, found := sh.records[id]; found {
+				record.Unscheduled = now
+				sh.records[id] = record
+			}
+		}
+	}
+	sh.prune(now)
+}
+
+// prune forgets the instances unscheduled for longer than the retention,
+// it must be called with the lock held
+func (sh *scheduleHistory) prune(now time.Time) {
+	for id, record := range sh.records {
+		if !record.Unscheduled.IsZero() && now.Sub(record.Unscheduled) > scheduleHistoryRetention {
+			delete(sh.records, id)
+		}
+	}
+}
+
+// get returns a copy of the schedule history
+func (sh *scheduleHistory) get() map[string]integration.ScheduleRecord {
+	sh.m.RLock()
+	defer sh.m.RUnlock()
+
+	recordsCopy := make(map[string]integration.ScheduleRecord, len(sh.records))
+	for k, v := range sh.records {
+		recordsCopy[k] = v
+	}
+	return recordsCopy
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func TestScheduleHistory(t *testing.T) {
+	sh := newScheduleHistory()
+	now := time.Now()
+	config := integration.Config{
+		Name:      "redisdb",
+		Instances: []integration.Data{integration.Data("host: 10.0.0.1"), integration.Data("host: 10.0.0.2")},
+		Provider:  "kubelet",
+		Source:    "kubelet:docker://abc",
+	}
+	ID := string(check.BuildID(config.Name, config.Instances[0], config.InitConfig))
+
+	sh.scheduled([]integration.Config{config}, triggerInitialLoad, now)
+	history := sh.get()
+	require.Len(t, history, 2)
+	assert.Equal(t, integration.ScheduleRecord{
+		CheckName:     "redisdb",
+		Provider:      "kubelet",
+		Source:        "kubelet:docker://abc",
+		Trigger:       triggerInitialLoad,
+		LastScheduled: now,
+		ScheduleCount: 1,
+	}, history[ID])
+
+	// a flapping instance keeps its count
+	sh.unscheduled([]integration.Config{config}, now.Add(time.Second))
+	assert.Equal(t, now.Add(time.Second), sh.get()[ID].Unscheduled)
+	sh.scheduled([]integration.Config{config}, triggerProviderPoll, now.Add(2*time.Second))
+	record := sh.get()[ID]
+	assert.Equal(t, 2, record.ScheduleCount)
+	assert.Equal(t, triggerProviderPoll, record.Trigger)
+	assert.Equal(t, now.Add(2*time.Second), record.LastScheduled)
+	assert.True(t, record.Unscheduled.IsZero())
+
+	// unscheduled instances are forgotten after the retention
+	sh.unscheduled([]integration.Config{config}, now.Add(3*time.Second))
+	sh.scheduled(nil, triggerProviderPoll, now.Add(3*time.Second+scheduleHistoryRetention))
+	assert.Len(t, sh.get(), 2)
+	sh.scheduled(nil, triggerProviderPoll, now.Add(4*time.Second+scheduleHistoryRetention))
+	assert.Len(t, sh.get(), 0)
+}
@@ -134,3 +134,10 @@ func NewMetadataResponse() *MetadataResponse {
 		Nodes: make(map[string]*MetadataResponseBundle),
 	}
 }
+
+// NamespaceMetadata holds the labels and annotations of a namespace, used by
+// the node agents to tag the pods running in it
+type NamespaceMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
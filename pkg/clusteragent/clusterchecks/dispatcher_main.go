@@ -31,6 +31,10 @@ type dispatcher struct {
 	extraTags             []string
 	clcRunnersClient      clusteragent.CLCRunnerClientInterface
 	advancedDispatching   bool
+	namespaceTenant       namespaceTenantCallback
+	runnerNamespace       runnerNamespaceCallback
+	stateStore            dispatchStateStore
+	lastSavedState        map[string]string // guarded by store's lock
 }
 
 func newDispatcher() *dispatcher {
@@ -50,6 +54,27 @@ func newDispatcher() *dispatcher {
 		d.extraTags = append(d.extraTags, fmt.Sprintf("kube_cluster_name:%s", clusterTagValue))
 	}
 
+	if tenantLabel := config.Datadog.GetString("cluster_checks.tenant_label"); tenantLabel != "" {
+		namespaceTenant, err := getNamespaceTenantCallback(tenantLabel)
+		if err == nil {
+			d.runnerNamespace, err = getRunnerNamespaceCallback()
+		}
+		if err != nil {
+			log.Warnf("Cannot get namespace tenants, cluster checks will be dispatched regardless of their namespace: %v", err)
+		} else {
+			d.namespaceTenant = namespaceTenant
+		}
+	}
+
+	if config.Datadog.GetBool("cluster_checks.persist_dispatch_state") {
+		stateStore, err := getDispatchStateStore()
+		if err != nil {
+			log.Warnf("Cannot persist the dispatching state, a new leader will dispatch all the checks again: %v", err)
+		} else {
+			d.stateStore = stateStore
+		}
+	}
+
 	d.advancedDispatching = config.Datadog.GetBool("cluster_checks.advanced_dispatching_enabled")
 	if !d.advancedDispatching {
 		return d
@@ -128,7 +153,15 @@ func (d *dispatcher) reschedule(configs []integration.Config) {
 
 // add stores and delegates a given configuration
 func (d *dispatcher) add(config integration.Config) {
-	target := d.getLeastBusyNode()
+	tenant := d.getConfigTenant(config)
+	d.store.Lock()
+	d.store.digestToTenant[config.Digest()] = tenant
+	d.store.Unlock()
+
+	target := d.getPreferredNode(config.Digest(), tenant)
+	if target == "" {
+		target = d.getLeastBusyNode(tenant)
+	}
 	if target == "" {
 		// If no node is found, store it in the danglingConfigs map for retrying later.
 		log.Warnf("No available node to dispatch %s:%s on, will retry later", config.Name, config.Digest())
@@ -144,6 +177,10 @@ func (d *dispatcher) remove(config integration.Config) {
 	digest := config.Digest()
 	log.Debugf("Removing configuration %s:%s", config.Name, digest)
 	d.removeConfig(digest)
+
+	d.store.Lock()
+	delete(d.store.digestToTenant, digest)
+	d.store.Unlock()
 }
 
 // reset empties the store and resets all states
@@ -184,6 +221,9 @@ func (d *dispatcher) run(ctx context.Context) {
 				danglingConfs := d.retrieveAndClearDangling()
 				d.reschedule(danglingConfs)
 			}
+
+			// Share the dispatching with the next leader
+			d.saveDispatchState()
 		case <-runnerStatsTicker.C:
 			// Collect stats with an exponential backoff 2 - 5 - 10 minutes
 			if runnerStatsMinutes == firstRunnerStatsMinutes {
@@ -31,7 +31,20 @@ func (d *dispatcher) getNodeConfigs(nodeName string) ([]integration.Config, int6
 
 	node.RLock()
 	defer node.RUnlock()
-	return makeConfigArray(node.digestToConfig), node.lastConfigChange, nil
+	if !d.tenancyEnabled() {
+		return makeConfigArray(node.digestToConfig), node.lastConfigChange, nil
+	}
+
+	// Never serve a node checks targeting namespaces of another tenant
+	configs := make([]integration.Config, 0, len(node.digestToConfig))
+	for digest, config := range node.digestToConfig {
+		if tenant := d.store.digestToTenant[digest]; tenant != node.tenant {
+			log.Warnf("Not serving configuration %s:%s of tenant %q to node %s of tenant %q", config.Name, digest, tenant, nodeName, node.tenant)
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs, node.lastConfigChange, nil
 }
 
 // processNodeStatus keeps the node's status in the store, and returns true
@@ -39,11 +52,15 @@ func (d *dispatcher) getNodeConfigs(nodeName string) ([]integration.Config, int6
 func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.NodeStatus) (bool, error) {
 	var warmingUp bool
 
+	// Looked up before locking the store, as it can query the API server
+	tenant := d.getRunnerTenant(nodeName, clientIP)
+
 	d.store.Lock()
 	if !d.store.active {
 		warmingUp = true
 	}
 	node := d.store.getOrCreateNodeStore(nodeName, clientIP)
+	d.handleTenantChange(node, tenant)
 	d.store.Unlock()
 
 	node.Lock()
@@ -67,10 +84,10 @@ func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.N
 	return false, nil
 }
 
-// getLeastBusyNode returns the name of the node that is assigned
-// the lowest number of checks. In case of equality, one is chosen
+// getLeastBusyNode returns the name of the node of the given tenant that is
+// assigned the lowest number of checks. In case of equality, one is chosen
 // randomly, based on map iterations being randomized.
-func (d *dispatcher) getLeastBusyNode() string {
+func (d *dispatcher) getLeastBusyNode(tenant string) string {
 	var leastBusyNode string
 	minCheckCount := int(-1)
 	minBusyness := int(-1)
@@ -82,6 +99,9 @@ func (d *dispatcher) getLeastBusyNode() string {
 		if name == "" {
 			continue
 		}
+		if d.tenancyEnabled() && store.tenant != tenant {
+			continue
+		}
 		if d.advancedDispatching && store.busyness > defaultBusynessValue {
 			// dispatching based on clc runners stats
 			// only when advancedDispatching is true and
@@ -186,7 +186,11 @@ func (d *dispatcher) rebalance() {
 				break
 			}
 
-			pickedNodeName := pickNode(diffMap, sourceNodeName)
+			pickedNodeName := pickNode(d.filterDiffByTenant(diffMap, d.getNodeTenant(sourceNodeName)), sourceNodeName)
+			if pickedNodeName == "" {
+				log.Debugf("No node to move check %s from node %s to", checkID, sourceNodeName)
+				break
+			}
 			if diffMap[pickedNodeName]+checkWeight < int(float64(diffMap[sourceNodeName])*tolerationMargin) {
 				// move a check to a new node only if it keeps the busyness of the new node
 				// lower than the original node's busyness multiplied by the tolerationMargin value
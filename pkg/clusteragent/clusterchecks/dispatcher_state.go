@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"reflect"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// dispatchStateStore persists the node each configuration is dispatched to,
+// shared between the cluster-agent replicas. It allows a new leader to keep
+// the checks on the node running them instead of dispatching them again.
+type dispatchStateStore interface {
+	load() (map[string]string, error)
+	save(digestToNode map[string]string) error
+}
+
+// loadDispatchState reads the dispatching of the previous leader, used to
+// dispatch the configurations to the same nodes once they report
+func (d *dispatcher) loadDispatchState() {
+	if d.stateStore == nil {
+		return
+	}
+
+	digestToNode, err := d.stateStore.load()
+	if err != nil {
+		log.Warnf("Cannot load the dispatching state of the previous leader, all the configurations will be dispatched again: %v", err)
+		return
+	}
+	log.Infof("Loaded the dispatching state of %d configurations from the previous leader", len(digestToNode))
+
+	// getPreferredNode consumes preferredNodes while saveDispatchState
+	// compares with lastSavedState, they must not share the same map
+	preferredNodes := make(map[string]string, len(digestToNode))
+	for digest, node := range digestToNode {
+		preferredNodes[digest] = node
+	}
+
+	d.store.Lock()
+	defer d.store.Unlock()
+	d.store.preferredNodes = preferredNodes
+	d.lastSavedState = digestToNode
+}
+
+// saveDispatchState persists the current dispatching if it changed since the
+// last save
+func (d *dispatcher) saveDispatchState() {
+	if d.stateStore == nil {
+		return
+	}
+
+	d.store.RLock()
+	digestToNode := make(map[string]string, len(d.store.digestToNode))
+	for digest, node := range d.store.digestToNode {
+		digestToNode[digest] = node
+	}
+	unchanged := reflect.DeepEqual(digestToNode, d.lastSavedState)
+	d.store.RUnlock()
+
+	if unchanged {
+		return
+	}
+	if err := d.stateStore.save(digestToNode); err != nil {
+		log.Warnf("Cannot save the dispatching state: %v", err)
+		return
+	}
+
+	d.store.Lock()
+	d.lastSavedState = digestToNode
+	d.store.Unlock()
+}
+
+// getPreferredNode returns the node a configuration was dispatched to by the
+// previous leader, if this node reported to us and belongs to the tenant.
+// A preferred node is only used once, the next dispatching are balanced.
+func (d *dispatcher) getPreferredNode(digest, tenant string) string {
+	d.store.Lock()
+	defer d.store.Unlock()
+
+	name, found := d.store.preferredNodes[digest]
+	if !found {
+		return ""
+	}
+	delete(d.store.preferredNodes, digest)
+
+	node, found := d.store.getNodeStore(name)
+	if !found || name == "" {
+		return ""
+	}
+	if d.tenancyEnabled() && node.tenant != tenant {
+		return ""
+	}
+	return name
+}
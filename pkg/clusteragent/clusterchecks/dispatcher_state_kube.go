@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package clusterchecks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// dispatchStateTokenKey is the key of the dispatching state in the ConfigMap
+// also holding the event collection token
+const dispatchStateTokenKey = "clusterchecks"
+
+// configMapDispatchStateStore stores the dispatching state in the ConfigMap
+// of the cluster-agent tokens
+type configMapDispatchStateStore struct {
+	cl *apiserver.APIClient
+}
+
+func getDispatchStateStore() (dispatchStateStore, error) {
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return &configMapDispatchStateStore{cl: cl}, nil
+}
+
+func (s *configMapDispatchStateStore) load() (map[string]string, error) {
+	value, _, err := s.cl.GetTokenFromConfigmap(dispatchStateTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	digestToNode := make(map[string]string)
+	if value == "" {
+		return digestToNode, nil
+	}
+	err = json.Unmarshal([]byte(value), &digestToNode)
+	return digestToNode, err
+}
+
+func (s *configMapDispatchStateStore) save(digestToNode map[string]string) error {
+	value, err := json.Marshal(digestToNode)
+	if err != nil {
+		return err
+	}
+	return s.cl.UpdateTokenInConfigmap(dispatchStateTokenKey, string(value), time.Now())
+}
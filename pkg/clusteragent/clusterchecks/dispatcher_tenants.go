@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const kubeNamespaceTagPrefix = "kube_namespace:"
+
+// namespaceTenantCallback returns the tenant owning a namespace, read from the
+// value of the configured tenant label, and allows to inject a custom one for tests
+type namespaceTenantCallback func(namespace string) (string, error)
+
+// runnerNamespaceCallback returns the namespace of the runner pod with the
+// given IP, and allows to inject a custom one for tests
+type runnerNamespaceCallback func(podIP string) (string, error)
+
+// tenancyEnabled returns whether dispatching is partitioned by tenant
+func (d *dispatcher) tenancyEnabled() bool {
+	return d.namespaceTenant != nil && d.runnerNamespace != nil
+}
+
+// getRunnerTenant returns the tenant of the namespace a node is deployed in.
+// It is looked up through the API server from the IP of the node, rather than
+// trusted from the node's status. Nodes that cannot be found, such as the
+// ones running in the host network, belong to the default "" tenant.
+func (d *dispatcher) getRunnerTenant(nodeName, clientIP string) string {
+	if !d.tenancyEnabled() || clientIP == "" {
+		return ""
+	}
+	namespace, err := d.runnerNamespace(clientIP)
+	if err != nil {
+		log.Debugf("Cannot get the namespace of node %s with IP %s, it will only run checks without tenant: %v", nodeName, clientIP, err)
+		return ""
+	}
+	tenant, err := d.namespaceTenant(namespace)
+	if err != nil {
+		log.Debugf("Cannot get the tenant of namespace %s for node %s, it will only run checks without tenant: %v", namespace, nodeName, err)
+		return ""
+	}
+	return tenant
+}
+
+// getConfigTenant returns the tenant of the namespace targeted by a config.
+// Configs that don't target a namespace belong to the default "" tenant.
+func (d *dispatcher) getConfigTenant(config integration.Config) string {
+	if !d.tenancyEnabled() {
+		return ""
+	}
+	namespace := getConfigNamespace(config)
+	if namespace == "" {
+		return ""
+	}
+	tenant, err := d.namespaceTenant(namespace)
+	if err != nil {
+		log.Warnf("Cannot get the tenant of namespace %s for config %s:%s, it will be dispatched to runners without tenant: %v", namespace, config.Name, config.Digest(), err)
+		return ""
+	}
+	return tenant
+}
+
+// getConfigNamespace returns the namespace targeted by a config, based on the
+// kube_namespace tag added to the instances by the kube_service listener
+func getConfigNamespace(config integration.Config) string {
+	for _, instance := range config.Instances {
+		var common integration.CommonInstanceConfig
+		if err := yaml.Unmarshal(instance, &common); err != nil {
+			continue
+		}
+		for _, tag := range common.Tags {
+			if strings.HasPrefix(tag, kubeNamespaceTagPrefix) {
+				return strings.TrimPrefix(tag, kubeNamespaceTagPrefix)
+			}
+		}
+	}
+	return ""
+}
+
+// filterDiffByTenant restricts a busyness diff map to the nodes of a tenant,
+// so that rebalancing never moves a check to another tenant's runner
+func (d *dispatcher) filterDiffByTenant(diffMap map[string]int, tenant string) map[string]int {
+	if !d.tenancyEnabled() {
+		return diffMap
+	}
+
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	filtered := make(map[string]int)
+	for name, diff := range diffMap {
+		node, found := d.store.getNodeStore(name)
+		if !found {
+			continue
+		}
+		node.RLock()
+		if node.tenant == tenant {
+			filtered[name] = diff
+		}
+		node.RUnlock()
+	}
+	return filtered
+}
+
+// handleTenantChange moves the configurations of a node that changed tenant
+// to the dangling configs, so that they get dispatched again to runners of
+// the right tenant. The store lock must be held by the caller.
+func (d *dispatcher) handleTenantChange(node *nodeStore, tenant string) {
+	node.Lock()
+	defer node.Unlock()
+
+	if node.tenant == tenant {
+		return
+	}
+	if len(node.digestToConfig) > 0 {
+		log.Infof("Node %s changed tenant from %q to %q, its configurations will be dispatched again", node.name, node.tenant, tenant)
+	}
+	for digest, config := range node.digestToConfig {
+		delete(d.store.digestToNode, digest)
+		d.store.danglingConfigs[digest] = config
+		danglingConfigs.Inc()
+		node.removeConfig(digest)
+	}
+	node.tenant = tenant
+}
+
+// getNodeTenant returns the tenant of the namespace a node is deployed in
+func (d *dispatcher) getNodeTenant(nodeName string) string {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	node, found := d.store.getNodeStore(nodeName)
+	if !found {
+		return ""
+	}
+	node.RLock()
+	defer node.RUnlock()
+	return node.tenant
+}
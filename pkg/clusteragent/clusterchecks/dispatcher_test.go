@@ -241,22 +241,22 @@ func TestGetLeastBusyNode(t *testing.T) {
 	dispatcher := newDispatcher()
 
 	// No node registered -> empty string
-	assert.Equal(t, "", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "", dispatcher.getLeastBusyNode(""))
 
 	// 1 config on node1, 2 on node2
 	dispatcher.addConfig(generateIntegration("A"), "node1")
 	dispatcher.addConfig(generateIntegration("B"), "node2")
 	dispatcher.addConfig(generateIntegration("C"), "node2")
-	assert.Equal(t, "node1", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node1", dispatcher.getLeastBusyNode(""))
 
 	// 3 configs on node1, 2 on node2
 	dispatcher.addConfig(generateIntegration("D"), "node1")
 	dispatcher.addConfig(generateIntegration("E"), "node1")
-	assert.Equal(t, "node2", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node2", dispatcher.getLeastBusyNode(""))
 
 	// Add an empty node3
 	dispatcher.processNodeStatus("node3", "10.0.0.3", types.NodeStatus{})
-	assert.Equal(t, "node3", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node3", dispatcher.getLeastBusyNode(""))
 
 	requireNotLocked(t, dispatcher.store)
 }
@@ -637,3 +637,185 @@ func TestUpdateRunnersStats(t *testing.T) {
 
 	requireNotLocked(t, dispatcher.store)
 }
+
+func TestTenantDispatching(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.namespaceTenant = func(namespace string) (string, error) {
+		switch namespace {
+		case "team-a-ns":
+			return "team-a", nil
+		case "team-b-ns":
+			return "team-b", nil
+		}
+		return "", nil
+	}
+	dispatcher.runnerNamespace = func(podIP string) (string, error) {
+		switch podIP {
+		case "10.0.0.1", "10.0.0.4":
+			return "team-a-ns", nil
+		case "10.0.0.2":
+			return "team-b-ns", nil
+		case "10.0.0.3":
+			return "default", nil
+		}
+		return "", fmt.Errorf("no running pod has the IP %s", podIP)
+	}
+
+	dispatcher.processNodeStatus("node-a", "10.0.0.1", types.NodeStatus{})
+	dispatcher.processNodeStatus("node-b", "10.0.0.2", types.NodeStatus{})
+	dispatcher.processNodeStatus("node-default", "10.0.0.3", types.NodeStatus{})
+
+	configA := integration.Config{
+		Name:         "check-a",
+		ClusterCheck: true,
+		Instances:    []integration.Data{integration.Data("tags: [\"kube_namespace:team-a-ns\"]")},
+	}
+	configB := integration.Config{
+		Name:         "check-b",
+		ClusterCheck: true,
+		Instances:    []integration.Data{integration.Data("tags: [\"kube_namespace:team-b-ns\"]")},
+	}
+	configStatic := generateIntegration("check-static")
+
+	assert.Equal(t, "team-a-ns", getConfigNamespace(configA))
+	assert.Equal(t, "", getConfigNamespace(configStatic))
+
+	dispatcher.Schedule([]integration.Config{configA, configB, configStatic})
+
+	configs, _, err := dispatcher.getNodeConfigs("node-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"check-a"}, extractCheckNames(configs))
+
+	configs, _, err = dispatcher.getNodeConfigs("node-b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"check-b"}, extractCheckNames(configs))
+
+	configs, _, err = dispatcher.getNodeConfigs("node-default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"check-static"}, extractCheckNames(configs))
+
+	// A node which is not found belongs to the default tenant
+	dispatcher.processNodeStatus("node-unknown", "10.0.0.5", types.NodeStatus{})
+	assert.Equal(t, "", dispatcher.getNodeTenant("node-unknown"))
+	assert.Equal(t, "team-a", dispatcher.getNodeTenant("node-a"))
+
+	// A node moved to the namespace of another tenant loses its configurations
+	dispatcher.processNodeStatus("node-b", "10.0.0.4", types.NodeStatus{})
+	configs, _, err = dispatcher.getNodeConfigs("node-b")
+	assert.NoError(t, err)
+	assert.Len(t, configs, 0)
+	assert.Equal(t, []string{"check-b"}, extractCheckNames(makeConfigArray(dispatcher.store.danglingConfigs)))
+
+	// No runner left for team-b, the config stays dangling
+	dispatcher.reschedule(dispatcher.retrieveAndClearDangling())
+	assert.Equal(t, []string{"check-b"}, extractCheckNames(makeConfigArray(dispatcher.store.danglingConfigs)))
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+type fakeDispatchStateStore struct {
+	digestToNode map[string]string
+	saves        int
+}
+
+func (s *fakeDispatchStateStore) load() (map[string]string, error) {
+	return s.digestToNode, nil
+}
+
+func (s *fakeDispatchStateStore) save(digestToNode map[string]string) error {
+	s.digestToNode = digestToNode
+	s.saves++
+	return nil
+}
+
+func TestDispatchStatePersistence(t *testing.T) {
+	stateStore := &fakeDispatchStateStore{}
+
+	// First leader dispatches and saves its state
+	leader := newDispatcher()
+	leader.stateStore = stateStore
+	leader.processNodeStatus("node1", "10.0.0.1", types.NodeStatus{})
+	leader.processNodeStatus("node2", "10.0.0.2", types.NodeStatus{})
+	config1 := generateIntegration("A")
+	config2 := generateIntegration("B")
+	leader.add(config1)
+	leader.add(config2)
+	node1 := leader.store.digestToNode[config1.Digest()]
+	node2 := leader.store.digestToNode[config2.Digest()]
+	assert.NotEqual(t, node1, node2)
+
+	leader.saveDispatchState()
+	assert.Equal(t, 1, stateStore.saves)
+	assert.Len(t, stateStore.digestToNode, 2)
+
+	// Nothing changed, not saved again
+	leader.saveDispatchState()
+	assert.Equal(t, 1, stateStore.saves)
+
+	// New leader keeps the checks on their node, whatever the dispatching order
+	newLeader := newDispatcher()
+	newLeader.stateStore = stateStore
+	newLeader.loadDispatchState()
+	newLeader.processNodeStatus("node1", "10.0.0.1", types.NodeStatus{})
+	newLeader.processNodeStatus("node2", "10.0.0.2", types.NodeStatus{})
+	newLeader.add(config2)
+	newLeader.add(config1)
+	assert.Equal(t, node1, newLeader.store.digestToNode[config1.Digest()])
+	assert.Equal(t, node2, newLeader.store.digestToNode[config2.Digest()])
+	assert.Len(t, newLeader.store.preferredNodes, 0)
+
+	// The state matches the loaded one, not saved again
+	newLeader.saveDispatchState()
+	assert.Equal(t, 1, stateStore.saves)
+
+	// A node that did not report is not used
+	stateStore.digestToNode = map[string]string{config1.Digest(): "node3"}
+	thirdLeader := newDispatcher()
+	thirdLeader.stateStore = stateStore
+	thirdLeader.loadDispatchState()
+	thirdLeader.processNodeStatus("node1", "10.0.0.1", types.NodeStatus{})
+	thirdLeader.add(config1)
+	assert.Equal(t, "node1", thirdLeader.store.digestToNode[config1.Digest()])
+
+	requireNotLocked(t, leader.store)
+	requireNotLocked(t, newLeader.store)
+	requireNotLocked(t, thirdLeader.store)
+}
+
+func TestDispatchStateConcurrentSave(t *testing.T) {
+	var configs []integration.Config
+	loaded := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		config := generateIntegration(fmt.Sprintf("check-%d", i))
+		configs = append(configs, config)
+		loaded[config.Digest()] = "node1"
+	}
+	stateStore := &fakeDispatchStateStore{digestToNode: loaded}
+
+	// Run with -race: the scheduling consumes the loaded state while the
+	// dispatcher loop compares the dispatching with it
+	dispatcher := newDispatcher()
+	dispatcher.stateStore = stateStore
+	dispatcher.loadDispatchState()
+	dispatcher.processNodeStatus("node1", "10.0.0.1", types.NodeStatus{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, config := range configs {
+			dispatcher.add(config)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		dispatcher.saveDispatchState()
+	}
+
+	assert.Len(t, dispatcher.store.preferredNodes, 0)
+	assert.Len(t, loaded, 50)
+	requireNotLocked(t, dispatcher.store)
+}
@@ -150,6 +150,9 @@ func (h *Handler) Run(ctx context.Context) {
 
 // runDispatch hooks in the Autodiscovery and runs the dispatch's run method
 func (h *Handler) runDispatch(ctx context.Context) {
+	// Keep the checks on the nodes they were dispatched to by the previous leader
+	h.dispatcher.loadDispatchState()
+
 	// Register our scheduler and ask for a config replay
 	h.autoconfig.AddScheduler(schedulerName, h.dispatcher, true)
 
@@ -29,6 +29,8 @@ type clusterStore struct {
 	danglingConfigs  map[string]integration.Config            // Configs we could not dispatch to any node
 	endpointsConfigs map[string]map[string]integration.Config // Endpoints configs to be consumed by node agents
 	idToDigest       map[check.ID]string                      // link check IDs to check configs
+	digestToTenant   map[string]string                        // Tenant of the namespace targeted by a config
+	preferredNodes   map[string]string                        // Node running a config under the previous leader
 }
 
 func newClusterStore() *clusterStore {
@@ -46,6 +48,8 @@ func (s *clusterStore) reset() {
 	s.danglingConfigs = make(map[string]integration.Config)
 	s.endpointsConfigs = make(map[string]map[string]integration.Config)
 	s.idToDigest = make(map[check.ID]string)
+	s.digestToTenant = make(map[string]string)
+	s.preferredNodes = make(map[string]string)
 }
 
 // getNodeStore retrieves the store struct for a given node name, if it exists
@@ -88,6 +92,7 @@ type nodeStore struct {
 	clientIP         string
 	clcRunnerStats   types.CLCRunnersStats
 	busyness         int
+	tenant           string // Tenant of the namespace the node is deployed in
 }
 
 func newNodeStore(name, clientIP string) *nodeStore {
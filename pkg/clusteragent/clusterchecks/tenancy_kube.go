@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package clusterchecks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	namespaceTenantCacheExpiration = 5 * time.Minute
+	runnerNamespaceCacheExpiration = 5 * time.Minute
+)
+
+func getNamespaceTenantCallback(tenantLabel string) (namespaceTenantCallback, error) {
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(namespace string) (string, error) {
+		cacheKey := cache.BuildAgentKey("clusterchecks", "namespace_tenant", namespace)
+		if tenant, found := cache.Cache.Get(cacheKey); found {
+			return tenant.(string), nil
+		}
+
+		ns, err := cl.Cl.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		tenant := ns.Labels[tenantLabel]
+		cache.Cache.Set(cacheKey, tenant, namespaceTenantCacheExpiration)
+		return tenant, nil
+	}, nil
+}
+
+func getRunnerNamespaceCallback() (runnerNamespaceCallback, error) {
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(podIP string) (string, error) {
+		cacheKey := cache.BuildAgentKey("clusterchecks", "runner_namespace", podIP)
+		if namespace, found := cache.Cache.Get(cacheKey); found {
+			return namespace.(string), nil
+		}
+
+		pods, err := cl.Cl.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("status.podIP", podIP).String(),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		// Pods in the host network share the IP of their node, they cannot be told apart
+		var namespace string
+		for _, pod := range pods.Items {
+			if pod.Spec.HostNetwork || pod.Status.Phase != v1.PodRunning {
+				continue
+			}
+			if namespace != "" && namespace != pod.Namespace {
+				return "", fmt.Errorf("pods of several namespaces have the IP %s", podIP)
+			}
+			namespace = pod.Namespace
+		}
+		if namespace == "" {
+			return "", fmt.Errorf("no running pod has the IP %s", podIP)
+		}
+		cache.Cache.Set(cacheKey, namespace, runnerNamespaceCacheExpiration)
+		return namespace, nil
+	}, nil
+}
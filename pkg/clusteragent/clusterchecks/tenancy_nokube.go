@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build !kubeapiserver
+
+package clusterchecks
+
+import (
+	"errors"
+)
+
+func getNamespaceTenantCallback(tenantLabel string) (namespaceTenantCallback, error) {
+	return nil, errors.New("No namespace tenancy support compiled in")
+}
+
+func getRunnerNamespaceCallback() (runnerNamespaceCallback, error) {
+	return nil, errors.New("No namespace tenancy support compiled in")
+}
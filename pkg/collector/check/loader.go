@@ -16,3 +16,10 @@ import (
 type Loader interface {
 	Load(config integration.Config, instance integration.Data) (Check, error)
 }
+
+// NamedLoader is a Loader that can be selected with the `loader` option of a
+// check instance or init_config, to be tried before the other loaders.
+type NamedLoader interface {
+	Loader
+	Name() string
+}
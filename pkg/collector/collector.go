@@ -41,6 +41,7 @@ func NewCollector(paths ...string) *Collector {
 
 	// let the runner some visibility into the scheduler
 	run.SetScheduler(sched)
+	sched.SetPressurePolicy(scheduler.NewPressurePolicyFromConfig())
 	sched.Run()
 
 	c := &Collector{
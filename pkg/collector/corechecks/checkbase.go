@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -37,6 +38,9 @@ import (
 //
 // If custom tags are set in the instance configuration, they will
 // be automatically appended to each send done by this check.
+//
+// Checks that need to resume from a cursor after a restart can save
+// it in the persistent store returned by State().
 type CheckBase struct {
 	checkName      string
 	checkID        check.ID
@@ -44,6 +48,7 @@ type CheckBase struct {
 	checkInterval  time.Duration
 	source         string
 	telemetry      bool
+	state          *persistentcache.Store
 }
 
 // NewCheckBase returns a check base struct with a given check name
@@ -148,6 +153,16 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 		s.SetCheckService(commonOptions.Service)
 	}
 
+	// Limit the number of metrics submitted per run if specified
+	if commonOptions.MaxMetricsPerRun > 0 {
+		s, err := aggregator.GetSender(c.checkID)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
+			return err
+		}
+		s.SetMaxMetricsPerRun(commonOptions.MaxMetricsPerRun)
+	}
+
 	c.source = source
 	return nil
 }
@@ -219,6 +234,19 @@ func (c *CheckBase) GetWarnings() []error {
 	return w
 }
 
+// State returns the persistent key-value store of the check instance,
+// opening it on first use. It must be called after BuildID().
+func (c *CheckBase) State() (*persistentcache.Store, error) {
+	if c.state == nil {
+		state, err := persistentcache.OpenStore(string(c.checkID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the state of check %s: %v", string(c.checkID), err)
+		}
+		c.state = state
+	}
+	return c.state, nil
+}
+
 // GetMetricStats returns the stats from the last run of the check.
 func (c *CheckBase) GetMetricStats() (map[string]int64, error) {
 	sender, err := aggregator.GetSender(c.ID())
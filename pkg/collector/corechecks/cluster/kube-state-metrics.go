@@ -21,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kube-state-metrics/pkg/allowdenylist"
 	"k8s.io/kube-state-metrics/pkg/options"
@@ -34,12 +35,15 @@ const (
 type KSMConfig struct {
 	// TODO fill in all the configurations.
 	Collectors []string `yaml:"collectors"`
+	// CustomResources defines metrics generated from custom resources objects
+	CustomResources []CustomResourceConfig `yaml:"custom_resources"`
 }
 
 type KSMCheck struct {
 	core.CheckBase
-	instance *KSMConfig
-	store    []cache.Store
+	instance      *KSMConfig
+	store         []cache.Store
+	dynamicClient dynamic.Interface
 }
 
 func init() {
@@ -56,6 +60,12 @@ func (k *KSMCheck) Configure(config, initConfig integration.Data, source string)
 		return err
 	}
 
+	for i := range k.instance.CustomResources {
+		if err := k.instance.CustomResources[i].validate(); err != nil {
+			return err
+		}
+	}
+
 	builder := kubestatemetrics.New()
 
 	// Prepare the collectors for the resources specified in the configuration file.
@@ -83,6 +93,7 @@ func (k *KSMCheck) Configure(config, initConfig integration.Data, source string)
 	}
 
 	builder.WithKubeClient(c.Cl)
+	k.dynamicClient = c.DynamicCl
 	builder.WithContext(context.Background())
 	builder.WithResync(30 * time.Second) // TODO resync period should be configurable
 	builder.WithGenerateStoreFunc(builder.GenerateStore)
@@ -110,6 +121,10 @@ func (k *KSMCheck) Run() error {
 		metrics := store.(*ksmstore.MetricsStore).Push()
 		processMetrics(sender, metrics)
 	}
+
+	if len(k.instance.CustomResources) > 0 && k.dynamicClient != nil {
+		processCustomResources(sender, k.dynamicClient, k.instance.CustomResources)
+	}
 	return nil
 }
 
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const customResourceMetricPrefix = "kubernetes_state.custom_resource."
+
+// CustomResourceConfig describes the metrics generated from the objects of a
+// custom resource, configured in the `custom_resources` list of the check
+// instance. Paths are lists of keys in the object, list elements being
+// selected by their index.
+type CustomResourceConfig struct {
+	Group     string `yaml:"group"`
+	Version   string `yaml:"version"`
+	Resource  string `yaml:"resource"`
+	Namespace string `yaml:"namespace"`
+	// LabelsFromPath are added as tags to all the metrics of the resource
+	LabelsFromPath map[string][]string    `yaml:"labels_from_path"`
+	Metrics        []CustomResourceMetric `yaml:"metrics"`
+}
+
+// CustomResourceMetric describes a gauge generated from a path of a custom
+// resource object
+type CustomResourceMetric struct {
+	Name           string              `yaml:"name"`
+	Path           []string            `yaml:"path"`
+	LabelsFromPath map[string][]string `yaml:"labels_from_path"`
+	// ValueMapping converts string values, like a status phase, to numbers
+	ValueMapping map[string]float64 `yaml:"value_mapping"`
+}
+
+func (c *CustomResourceConfig) validate() error {
+	if c.Version == "" || c.Resource == "" {
+		return fmt.Errorf("custom resource %q: version and resource are required", c.Resource)
+	}
+	if len(c.Metrics) == 0 {
+		return fmt.Errorf("custom resource %q: at least one metric is required", c.Resource)
+	}
+	for _, m := range c.Metrics {
+		if m.Name == "" || len(m.Path) == 0 {
+			return fmt.Errorf("custom resource %q: metrics require a name and a path", c.Resource)
+		}
+	}
+	return nil
+}
+
+func (c *CustomResourceConfig) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: c.Group, Version: c.Version, Resource: c.Resource}
+}
+
+// processCustomResources lists the objects of every configured custom resource
+// and submits the metrics extracted from them
+func processCustomResources(sender aggregator.Sender, client dynamic.Interface, customResources []CustomResourceConfig) {
+	for i := range customResources {
+		cr := &customResources[i]
+		list, err := client.Resource(cr.groupVersionResource()).Namespace(cr.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			log.Warnf("Unable to list custom resource %s: %v", cr.groupVersionResource(), err)
+			continue
+		}
+		for _, obj := range list.Items {
+			processCustomResourceObject(sender, cr, obj.Object)
+		}
+	}
+}
+
+func processCustomResourceObject(sender aggregator.Sender, cr *CustomResourceConfig, obj map[string]interface{}) {
+	commonTags := tagsFromPaths(obj, cr.LabelsFromPath)
+	if ns, found, _ := unstructured.NestedString(obj, "metadata", "namespace"); found && ns != "" {
+		commonTags = append(commonTags, "namespace:"+ns)
+	}
+
+	for _, m := range cr.Metrics {
+		raw, found := getPath(obj, m.Path)
+		if !found {
+			continue
+		}
+		value, err := toFloat(raw, m.ValueMapping)
+		if err != nil {
+			log.Debugf("Cannot convert %s of custom resource %s to a metric value: %v", strings.Join(m.Path, "."), cr.Resource, err)
+			continue
+		}
+		tags := append(tagsFromPaths(obj, m.LabelsFromPath), commonTags...)
+		sender.Gauge(customResourceMetricPrefix+m.Name, value, "", tags)
+	}
+}
+
+// getPath returns the value found at path in the object, list elements can be
+// selected by their index
+func getPath(obj interface{}, path []string) (interface{}, bool) {
+	current := obj
+	for _, key := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, found := node[key]
+			if !found {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func tagsFromPaths(obj map[string]interface{}, labelsFromPath map[string][]string) []string {
+	var tags []string
+	for label, path := range labelsFromPath {
+		raw, found := getPath(obj, path)
+		if !found {
+			continue
+		}
+		switch raw.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("%s:%v", label, raw))
+	}
+	return tags
+}
+
+func toFloat(raw interface{}, valueMapping map[string]float64) (float64, error) {
+	switch v := raw.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if mapped, found := valueMapping[v]; found {
+			return mapped, nil
+		}
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", raw)
+	}
+}
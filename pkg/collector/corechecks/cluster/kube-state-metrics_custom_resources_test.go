@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+)
+
+func TestProcessCustomResourceObject(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "my-foo",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   true,
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "lastProbe": "1.5"},
+			},
+		},
+	}
+	cr := &CustomResourceConfig{
+		Group:          "example.com",
+		Version:        "v1",
+		Resource:       "foos",
+		LabelsFromPath: map[string][]string{"foo": {"metadata", "name"}},
+		Metrics: []CustomResourceMetric{
+			{Name: "foo.replicas", Path: []string{"spec", "replicas"}},
+			{Name: "foo.paused", Path: []string{"spec", "paused"}},
+			{
+				Name:         "foo.phase",
+				Path:         []string{"status", "phase"},
+				ValueMapping: map[string]float64{"Pending": 0, "Running": 1},
+			},
+			{
+				Name:           "foo.last_probe",
+				Path:           []string{"status", "conditions", "0", "lastProbe"},
+				LabelsFromPath: map[string][]string{"condition": {"status", "conditions", "0", "type"}},
+			},
+			{Name: "foo.missing", Path: []string{"status", "missing"}},
+		},
+	}
+	assert.NoError(t, cr.validate())
+
+	check := newKSMCheck(core.NewCheckBase(kubeStateMetricsCheckName), &KSMConfig{})
+	mocked := mocksender.NewMockSender(check.ID())
+	mocked.SetupAcceptAll()
+
+	processCustomResourceObject(mocked, cr, obj)
+
+	commonTags := []string{"foo:my-foo", "namespace:default"}
+	mocked.AssertMetric(t, "Gauge", "kubernetes_state.custom_resource.foo.replicas", 3, "", commonTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes_state.custom_resource.foo.paused", 1, "", commonTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes_state.custom_resource.foo.phase", 1, "", commonTags)
+	mocked.AssertMetric(t, "Gauge", "kubernetes_state.custom_resource.foo.last_probe", 1.5, "", append([]string{"condition:Ready"}, commonTags...))
+	mocked.AssertNumberOfCalls(t, "Gauge", 4)
+}
+
+func TestCustomResourceConfigValidate(t *testing.T) {
+	assert.Error(t, (&CustomResourceConfig{Resource: "foos"}).validate())
+	assert.Error(t, (&CustomResourceConfig{Version: "v1", Resource: "foos"}).validate())
+	assert.Error(t, (&CustomResourceConfig{
+		Version:  "v1",
+		Resource: "foos",
+		Metrics:  []CustomResourceMetric{{Name: "foo.replicas"}},
+	}).validate())
+}
@@ -31,12 +31,10 @@ import (
 
 // Covers the Control Plane service check and the in memory pod metadata.
 const (
-	KubeControlPaneCheck          = "kube_apiserver_controlplane.up"
-	kubernetesAPIServerCheckName  = "kubernetes_apiserver"
-	eventTokenKey                 = "event"
-	maxEventCardinality           = 300
-	defaultResyncPeriodInSecond   = 300
-	defaultTimeoutEventCollection = 2000
+	KubeControlPaneCheck         = "kube_apiserver_controlplane.up"
+	kubernetesAPIServerCheckName = "kubernetes_apiserver"
+	eventTokenKey                = "event"
+	maxEventCardinality          = 300
 
 	defaultCacheExpire = 2 * time.Minute
 	defaultCachePurge  = 10 * time.Minute
@@ -47,23 +45,20 @@ type KubeASConfig struct {
 	CollectEvent             bool     `yaml:"collect_events"`
 	CollectOShiftQuotas      bool     `yaml:"collect_openshift_clusterquotas"`
 	FilteredEventTypes       []string `yaml:"filtered_event_types"`
-	EventCollectionTimeoutMs int      `yaml:"kubernetes_event_read_timeout_ms"`
+	CollectedEventKinds      []string `yaml:"collected_event_kinds"`
+	CollectedEventNamespaces []string `yaml:"collected_event_namespaces"`
+	CollectedEventReasons    []string `yaml:"collected_event_reasons"`
+	EventDedupWindow         int      `yaml:"kubernetes_event_dedup_window_s"`
 	MaxEventCollection       int      `yaml:"max_events_per_run"`
 	LeaderSkip               bool     `yaml:"skip_leader_election"`
-	ResyncPeriodEvents       int      `yaml:"kubernetes_event_resync_period_s"`
-}
-
-// EventC holds the information pertaining to which event we collected last and when we last re-synced.
-type EventC struct {
-	LastResVer string
-	LastTime   time.Time
 }
 
 // KubeASCheck grabs metrics and events from the API server.
 type KubeASCheck struct {
 	core.CheckBase
 	instance        *KubeASConfig
-	eventCollection EventC
+	eventCollector  *eventCollector
+	eventBookmark   string
 	ignoredEvents   string
 	ac              *apiserver.APIClient
 	oshiftAPILevel  apiserver.OpenShiftAPILevel
@@ -74,7 +69,6 @@ func (c *KubeASConfig) parse(data []byte) error {
 	// default values
 	c.CollectEvent = config.Datadog.GetBool("collect_kubernetes_events")
 	c.CollectOShiftQuotas = true
-	c.ResyncPeriodEvents = defaultResyncPeriodInSecond
 
 	return yaml.Unmarshal(data, c)
 }
@@ -105,14 +99,15 @@ func (k *KubeASCheck) Configure(config, initConfig integration.Data, source stri
 		log.Error("could not parse the config for the API server")
 		return err
 	}
-	if k.instance.EventCollectionTimeoutMs == 0 {
-		k.instance.EventCollectionTimeoutMs = defaultTimeoutEventCollection
-	}
-
 	if k.instance.MaxEventCollection == 0 {
 		k.instance.MaxEventCollection = maxEventCardinality
 	}
 	k.ignoredEvents = convertFilter(k.instance.FilteredEventTypes)
+	k.eventCollector = newEventCollector(
+		newEventFilter(k.instance.CollectedEventKinds, k.instance.CollectedEventNamespaces, k.instance.CollectedEventReasons),
+		time.Duration(k.instance.EventDedupWindow)*time.Second,
+		k.instance.MaxEventCollection,
+	)
 
 	return nil
 }
@@ -153,6 +148,8 @@ func (k *KubeASCheck) Run() error {
 		if errLeader != nil {
 			if errLeader == apiserver.ErrNotLeader {
 				// Only the leader can instantiate the apiserver client.
+				// The new leader resumes the event collection from the bookmark.
+				k.eventCollector.stopCollection()
 				return nil
 			}
 			return err
@@ -235,32 +232,25 @@ func (k *KubeASCheck) runLeaderElection() error {
 	return nil
 }
 
-func (k *KubeASCheck) eventCollectionCheck() (newEvents []*v1.Event, err error) {
-	resVer, lastTime, err := k.ac.GetTokenFromConfigmap(eventTokenKey)
-	if err != nil {
-		return nil, err
-	}
-
-	// This is to avoid getting in a situation where we list all the events for multiple runs in a row.
-	if resVer == "" && k.eventCollection.LastResVer != "" {
-		log.Errorf("Resource Version stored in the ConfigMap is incorrect. Will resume collecting from %s", k.eventCollection.LastResVer)
-		resVer = k.eventCollection.LastResVer
+func (k *KubeASCheck) eventCollectionCheck() ([]*v1.Event, error) {
+	if !k.eventCollector.running() {
+		if err := k.eventCollector.start(k.ac, k.ignoredEvents); err != nil {
+			k.Warnf("Could not start collecting events from the api server: %s", err.Error()) //nolint:errcheck
+			return nil, err
+		}
 	}
 
-	timeout := int64(k.instance.EventCollectionTimeoutMs / 1000)
-	limit := int64(k.instance.MaxEventCollection)
-	resync := int64(k.instance.ResyncPeriodEvents)
-	newEvents, k.eventCollection.LastResVer, k.eventCollection.LastTime, err = k.ac.RunEventCollection(resVer, lastTime, timeout, limit, resync, k.ignoredEvents)
-
-	if err != nil {
-		k.Warnf("Could not collect events from the api server: %s", err.Error()) //nolint:errcheck
-		return nil, err
+	newEvents, bookmark := k.eventCollector.collect(time.Now())
+	if bookmark == k.eventBookmark {
+		return newEvents, nil
 	}
 
-	configMapErr := k.ac.UpdateTokenInConfigmap(eventTokenKey, k.eventCollection.LastResVer, k.eventCollection.LastTime)
+	configMapErr := k.ac.UpdateTokenInConfigmap(eventTokenKey, bookmark, time.Now())
 	if configMapErr != nil {
 		k.Warnf("Could not store the LastEventToken in the ConfigMap: %s", configMapErr.Error()) //nolint:errcheck
+		return newEvents, nil
 	}
+	k.eventBookmark = bookmark
 	return newEvents, nil
 }
 
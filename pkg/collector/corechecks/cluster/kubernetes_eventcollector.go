@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// eventCollectorIdleTimeout is the time after which the informer is stopped
+// if the check doesn't collect its events anymore, e.g. once unscheduled
+const eventCollectorIdleTimeout = 10 * time.Minute
+
+// eventFilter restricts the collected events to the given involved object
+// kinds, namespaces and reasons. An empty list matches everything.
+type eventFilter struct {
+	kinds      map[string]struct{}
+	namespaces map[string]struct{}
+	reasons    map[string]struct{}
+}
+
+func newEventFilter(kinds, namespaces, reasons []string) eventFilter {
+	return eventFilter{
+		kinds:      toSet(kinds),
+		namespaces: toSet(namespaces),
+		reasons:    toSet(reasons),
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func (f eventFilter) match(ev *v1.Event) bool {
+	return matchSet(f.kinds, ev.InvolvedObject.Kind) &&
+		matchSet(f.namespaces, ev.InvolvedObject.Namespace) &&
+		matchSet(f.reasons, ev.Reason)
+}
+
+func matchSet(set map[string]struct{}, value string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	_, found := set[value]
+	return found
+}
+
+// eventCollector buffers the events received by an informer between two
+// check runs. The highest resource version collected is the bookmark: it is
+// persisted in the ConfigMap so that a new leader doesn't submit again the
+// events collected by the previous one.
+type eventCollector struct {
+	filter      eventFilter
+	dedupWindow time.Duration
+	maxEvents   int
+
+	m           sync.Mutex
+	stop        chan struct{}
+	pending     []*v1.Event
+	bookmark    int
+	dropped     int
+	lastCollect time.Time
+	seen        map[string]time.Time // deduplication key -> last collection
+}
+
+func newEventCollector(filter eventFilter, dedupWindow time.Duration, maxEvents int) *eventCollector {
+	return &eventCollector{
+		filter:      filter,
+		dedupWindow: dedupWindow,
+		maxEvents:   maxEvents,
+		seen:        make(map[string]time.Time),
+	}
+}
+
+// running returns whether the informer of the collector is started
+func (c *eventCollector) running() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.stop != nil
+}
+
+// start resumes the collection from the bookmark stored in the ConfigMap and
+// runs an informer on the events matching the field selector
+func (c *eventCollector) start(ac *apiserver.APIClient, fieldSelector string) error {
+	resVer, _, err := ac.GetTokenFromConfigmap(eventTokenKey)
+	if err != nil {
+		return err
+	}
+	bookmark, err := strconv.Atoi(resVer)
+	if err != nil {
+		// resVer is "" when the events were never collected
+		bookmark = 0
+	}
+
+	informer, err := ac.NewEventInformer(fieldSelector)
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.add,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldEv, ok := oldObj.(*v1.Event)
+			if ok && newObj.(*v1.Event).ResourceVersion == oldEv.ResourceVersion {
+				// periodic resync of the informer, nothing changed
+				return
+			}
+			c.add(newObj)
+		},
+	})
+
+	c.m.Lock()
+	c.bookmark = bookmark
+	c.pending = nil
+	c.lastCollect = time.Now()
+	c.stop = make(chan struct{})
+	go informer.Run(c.stop)
+	c.m.Unlock()
+
+	log.Infof("Started the kubernetes event informer, resuming from resource version %d", bookmark)
+	return nil
+}
+
+// stopCollection stops the informer, the events not collected yet are left
+// to the next leader
+func (c *eventCollector) stopCollection() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.stopLocked()
+}
+
+// stopLocked stops the informer, it must be called with the lock held
+func (c *eventCollector) stopLocked() {
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	c.stop = nil
+	c.pending = nil
+	log.Info("Stopped the kubernetes event informer")
+}
+
+// add buffers a new or updated event if it is more recent than the bookmark
+// and matches the filter
+func (c *eventCollector) add(obj interface{}) {
+	ev, ok := obj.(*v1.Event)
+	if !ok {
+		log.Errorf("The event object for %v cannot be safely converted, skipping it.", obj)
+		return
+	}
+	resVer, err := strconv.Atoi(ev.ResourceVersion)
+	if err != nil {
+		log.Errorf("Could not parse resource version of an event, will skip: %s", err)
+		return
+	}
+	if !c.filter.match(ev) {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.stop == nil {
+		// late notification of a stopped informer
+		return
+	}
+	if time.Since(c.lastCollect) > eventCollectorIdleTimeout {
+		log.Warnf("Kubernetes events were not collected for %s, stopping the event informer", eventCollectorIdleTimeout)
+		c.stopLocked()
+		return
+	}
+	if resVer <= c.bookmark {
+		// already collected, by this leader or a previous one
+		return
+	}
+	if len(c.pending) >= c.maxEvents {
+		c.dropped++
+		return
+	}
+	c.pending = append(c.pending, ev)
+}
+
+// collect returns the events buffered since the last call, without the ones
+// already collected in the deduplication window, and the new bookmark
+func (c *eventCollector) collect(now time.Time) ([]*v1.Event, string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.lastCollect = now
+	if c.dropped > 0 {
+		log.Warnf("Dropped %d kubernetes events above the limit of %d events per run", c.dropped, c.maxEvents)
+		c.dropped = 0
+	}
+
+	for key, last := range c.seen {
+		if now.Sub(last) >= c.dedupWindow {
+			delete(c.seen, key)
+		}
+	}
+
+	var events []*v1.Event
+	for _, ev := range c.pending {
+		if resVer, _ := strconv.Atoi(ev.ResourceVersion); resVer > c.bookmark {
+			c.bookmark = resVer
+		}
+		if c.dedupWindow > 0 {
+			key := dedupKey(ev)
+			if _, found := c.seen[key]; found {
+				continue
+			}
+			c.seen[key] = now
+		}
+		events = append(events, ev)
+	}
+	c.pending = nil
+
+	return events, strconv.Itoa(c.bookmark)
+}
+
+// dedupKey identifies the occurrences of the same event on the same object
+func dedupKey(ev *v1.Event) string {
+	return fmt.Sprintf("%s/%s/%s/%s", ev.InvolvedObject.UID, ev.Type, ev.Reason, ev.Message)
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build kubeapiserver
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	obj "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newCollectedEvent(resVer, namespace, kind, uid, reason, message string) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: obj.ObjectMeta{
+			ResourceVersion: resVer,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      kind,
+			UID:       types.UID(uid),
+			Namespace: namespace,
+		},
+		Reason:  reason,
+		Message: message,
+		Type:    "Warning",
+	}
+}
+
+// runningCollector returns a collector started from the given bookmark, without informer
+func runningCollector(filter eventFilter, dedupWindow time.Duration, maxEvents, bookmark int) *eventCollector {
+	c := newEventCollector(filter, dedupWindow, maxEvents)
+	c.stop = make(chan struct{})
+	c.bookmark = bookmark
+	c.lastCollect = time.Now()
+	return c
+}
+
+func TestEventFilter(t *testing.T) {
+	ev := newCollectedEvent("1", "default", "Pod", "uid", "BackOff", "Back-off restarting failed container")
+
+	assert.True(t, newEventFilter(nil, nil, nil).match(ev))
+	assert.True(t, newEventFilter([]string{"Node", "Pod"}, []string{"default"}, []string{"BackOff"}).match(ev))
+	assert.False(t, newEventFilter([]string{"Node"}, nil, nil).match(ev))
+	assert.False(t, newEventFilter(nil, []string{"kube-system"}, nil).match(ev))
+	assert.False(t, newEventFilter(nil, nil, []string{"Scheduled"}).match(ev))
+}
+
+func TestEventCollectorBookmark(t *testing.T) {
+	c := runningCollector(newEventFilter([]string{"Pod"}, nil, nil), 0, 10, 100)
+
+	c.add(newCollectedEvent("99", "default", "Pod", "a", "Scheduled", "already collected by the previous leader"))
+	c.add(newCollectedEvent("102", "default", "Pod", "a", "Started", "Started container"))
+	c.add(newCollectedEvent("101", "default", "Pod", "a", "Pulled", "Container image pulled"))
+	c.add(newCollectedEvent("103", "default", "Node", "b", "NodeReady", "filtered out"))
+	c.add("not an event")
+
+	events, bookmark := c.collect(time.Now())
+	require.Len(t, events, 2)
+	assert.Equal(t, "Started", events[0].Reason)
+	assert.Equal(t, "Pulled", events[1].Reason)
+	assert.Equal(t, "102", bookmark)
+
+	// nothing new
+	events, bookmark = c.collect(time.Now())
+	assert.Len(t, events, 0)
+	assert.Equal(t, "102", bookmark)
+
+	// events are ignored once the collector is stopped
+	c.stopCollection()
+	assert.False(t, c.running())
+	c.add(newCollectedEvent("104", "default", "Pod", "a", "Killing", "Stopping container"))
+	events, _ = c.collect(time.Now())
+	assert.Len(t, events, 0)
+}
+
+func TestEventCollectorDedupWindow(t *testing.T) {
+	now := time.Now()
+	c := runningCollector(newEventFilter(nil, nil, nil), time.Minute, 10, 0)
+
+	c.add(newCollectedEvent("1", "default", "Pod", "a", "BackOff", "Back-off restarting failed container"))
+	c.add(newCollectedEvent("2", "default", "Pod", "a", "BackOff", "Back-off restarting failed container"))
+	c.add(newCollectedEvent("3", "default", "Pod", "b", "BackOff", "Back-off restarting failed container"))
+	events, bookmark := c.collect(now)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "3", bookmark)
+
+	// still in the window
+	c.add(newCollectedEvent("4", "default", "Pod", "a", "BackOff", "Back-off restarting failed container"))
+	events, bookmark = c.collect(now.Add(30 * time.Second))
+	assert.Len(t, events, 0)
+	assert.Equal(t, "4", bookmark)
+
+	// the window is over
+	c.add(newCollectedEvent("5", "default", "Pod", "a", "BackOff", "Back-off restarting failed container"))
+	events, _ = c.collect(now.Add(time.Minute))
+	assert.Len(t, events, 1)
+}
+
+func TestEventCollectorMaxEvents(t *testing.T) {
+	c := runningCollector(newEventFilter(nil, nil, nil), 0, 2, 0)
+
+	c.add(newCollectedEvent("1", "default", "Pod", "a", "Pulled", "Container image pulled"))
+	c.add(newCollectedEvent("2", "default", "Pod", "a", "Created", "Created container"))
+	c.add(newCollectedEvent("3", "default", "Pod", "a", "Started", "Started container"))
+	events, _ := c.collect(time.Now())
+	assert.Len(t, events, 2)
+	assert.Equal(t, 0, c.dropped)
+}
+
+func TestEventCollectorIdle(t *testing.T) {
+	c := runningCollector(newEventFilter(nil, nil, nil), 0, 10, 0)
+	c.lastCollect = time.Now().Add(-2 * eventCollectorIdleTimeout)
+
+	c.add(newCollectedEvent("1", "default", "Pod", "a", "Pulled", "Container image pulled"))
+	assert.False(t, c.running())
+}
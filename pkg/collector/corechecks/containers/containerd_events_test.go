@@ -30,6 +30,8 @@ type mockItf struct {
 	mockContainer   func() ([]containerd.Container, error)
 	mockMetadata    func() (containerd.Version, error)
 	mockImageSize   func(ctn containerd.Container) (int64, error)
+	mockImages      func() ([]containerd.Image, error)
+	mockImageLayers func(img containerd.Image) ([]string, error)
 	mockTaskMetrics func(ctn containerd.Container) (*types.Metric, error)
 	mockTaskPids    func(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 	mockInfo        func(ctn containerd.Container) (containers.Container, error)
@@ -40,6 +42,14 @@ func (m *mockItf) ImageSize(ctn containerd.Container) (int64, error) {
 	return m.mockImageSize(ctn)
 }
 
+func (m *mockItf) Images() ([]containerd.Image, error) {
+	return m.mockImages()
+}
+
+func (m *mockItf) ImageLayers(img containerd.Image) ([]string, error) {
+	return m.mockImageLayers(img)
+}
+
 func (m *mockItf) Info(ctn containerd.Container) (containers.Container, error) {
 	return m.mockInfo(ctn)
 }
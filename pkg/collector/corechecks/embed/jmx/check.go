@@ -23,12 +23,14 @@ type JMXCheck struct {
 	config    integration.Config
 	stop      chan struct{}
 	source    string
+	group     string
 	telemetry bool
 }
 
-func newJMXCheck(config integration.Config, source string) *JMXCheck {
+func newJMXCheck(config integration.Config, source string, group string) *JMXCheck {
 	check := &JMXCheck{
 		config:    config,
+		group:     group,
 		stop:      make(chan struct{}),
 		name:      config.Name,
 		id:        check.ID(fmt.Sprintf("%v_%v", config.Name, config.Digest())),
@@ -13,7 +13,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 // JMXCheckLoader is a specific loader for checks living in this package
@@ -33,7 +36,13 @@ func (jl *JMXCheckLoader) Load(config integration.Config, instance integration.D
 		return c, errors.New("check is not a jmx check, or unable to determine if it's so")
 	}
 
-	if err := state.runner.configureRunner(instance, config.InitConfig); err != nil {
+	group, err := processGroup(config.Name, config.InitConfig)
+	if err != nil {
+		log.Errorf("jmx.loader: could not parse the init_config of check %s: %s", config.Name, err)
+		return c, err
+	}
+
+	if err := state.configureRunner(group, instance, config.InitConfig); err != nil {
 		log.Errorf("jmx.loader: could not configure check: %s", err)
 		return c, err
 	}
@@ -47,11 +56,30 @@ func (jl *JMXCheckLoader) Load(config integration.Config, instance integration.D
 		Name:          config.Name,
 		Provider:      config.Provider,
 	}
-	c = newJMXCheck(cf, config.Source)
+	c = newJMXCheck(cf, config.Source, group)
 
 	return c, nil
 }
 
+// processGroup returns the process group of a check, whose checks share a
+// JMXFetch process: the jmx_process_group of its init_config, its name if
+// jmx_isolated_processes is enabled, or the default group otherwise.
+func processGroup(name string, initConfig integration.Data) (string, error) {
+	var initConf struct {
+		ProcessGroup string `yaml:"jmx_process_group"`
+	}
+	if err := yaml.Unmarshal(initConfig, &initConf); err != nil {
+		return "", err
+	}
+	if initConf.ProcessGroup != "" {
+		return initConf.ProcessGroup, nil
+	}
+	if config.Datadog.GetBool("jmx_isolated_processes") {
+		return name, nil
+	}
+	return "", nil
+}
+
 func (jl *JMXCheckLoader) String() string {
 	return "JMX Check Loader"
 }
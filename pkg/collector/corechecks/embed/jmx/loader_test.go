@@ -15,8 +15,10 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +86,29 @@ func TestLoadCheckConfig(t *testing.T) {
 		assert.True(t, found)
 	}
 }
+
+func TestProcessGroup(t *testing.T) {
+	mockConfig := config.Mock()
+
+	group, err := processGroup("kafka", integration.Data("is_jmx: true"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", group)
+
+	group, err = processGroup("kafka", integration.Data("is_jmx: true\njmx_process_group: brokers"))
+	assert.Nil(t, err)
+	assert.Equal(t, "brokers", group)
+
+	mockConfig.Set("jmx_isolated_processes", true)
+	defer mockConfig.Set("jmx_isolated_processes", false)
+
+	group, err = processGroup("kafka", integration.Data("is_jmx: true"))
+	assert.Nil(t, err)
+	assert.Equal(t, "kafka", group)
+
+	group, err = processGroup("kafka", integration.Data("jmx_process_group: brokers"))
+	assert.Nil(t, err)
+	assert.Equal(t, "brokers", group)
+
+	_, err = processGroup("kafka", integration.Data("- invalid"))
+	assert.NotNil(t, err)
+}
@@ -10,14 +10,18 @@ package jmx
 import (
 	"time"
 
+	api "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/jmxfetch"
 	"github.com/DataDog/datadog-agent/pkg/status"
 )
 
+// runner manages a JMXFetch process, running the checks of a process group.
+// The checks of the default group, an empty string, share a single process.
 type runner struct {
 	jmxfetch *jmxfetch.JMXFetch
+	group    string
 	started  bool
 }
 
@@ -26,6 +30,20 @@ func (r *runner) initRunner() {
 	r.jmxfetch.LogLevel = config.Datadog.GetString("log_level")
 }
 
+// newGroupRunner returns a runner for the checks of a process group. Its
+// JMXFetch process authenticates with a token scoped to the group, so that it
+// only pulls the configurations of the group.
+func newGroupRunner(group string) (*runner, error) {
+	r := &runner{group: group}
+	r.initRunner()
+	token, err := api.CreateScopedToken(group)
+	if err != nil {
+		return nil, err
+	}
+	r.jmxfetch.SessionToken = token
+	return r, nil
+}
+
 func (r *runner) startRunner() error {
 
 	lifecycleMgmt := true
@@ -50,6 +68,9 @@ func (r *runner) configureRunner(instance, initConfig integration.Data) error {
 }
 
 func (r *runner) stopRunner() error {
+	if r.jmxfetch != nil && r.jmxfetch.SessionToken != "" {
+		api.RevokeScopedToken(r.jmxfetch.SessionToken)
+	}
 	if r.jmxfetch != nil && r.started {
 		return r.jmxfetch.Stop()
 	}
@@ -14,34 +14,71 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 type jmxState struct {
-	configs     *cache.BasicCache
-	runnerError chan struct{}
-	runner      *runner
-	lock        *sync.Mutex
+	configs      *cache.BasicCache
+	groups       map[string]string // process group of the scheduled configs
+	runnerError  chan struct{}
+	runner       *runner            // runs the checks of the default group
+	groupRunners map[string]*runner // runs the checks of the other groups
+	lock         *sync.Mutex
 }
 
 var state jmxState = jmxState{
-	configs:     cache.NewBasicCache(),
-	runnerError: make(chan struct{}),
-	runner:      &runner{},
-	lock:        &sync.Mutex{},
+	configs:      cache.NewBasicCache(),
+	groups:       map[string]string{},
+	runnerError:  make(chan struct{}),
+	runner:       &runner{},
+	groupRunners: map[string]*runner{},
+	lock:         &sync.Mutex{},
+}
+
+// getRunner returns the runner of a process group, creating it for a new
+// group. It must be called with the lock held.
+func (s *jmxState) getRunner(group string) (*runner, error) {
+	if group == "" {
+		return s.runner, nil
+	}
+	if r, ok := s.groupRunners[group]; ok {
+		return r, nil
+	}
+	r, err := newGroupRunner(group)
+	if err != nil {
+		return nil, err
+	}
+	s.groupRunners[group] = r
+	return r, nil
+}
+
+func (s *jmxState) configureRunner(group string, instance, initConfig integration.Data) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, err := s.getRunner(group)
+	if err != nil {
+		return err
+	}
+	return r.configureRunner(instance, initConfig)
 }
 
 func (s *jmxState) scheduleCheck(c *JMXCheck) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	if !s.runner.started {
-		err := check.Retry(5*time.Second, 3, s.runner.startRunner, "jmxfetch")
+	r, err := s.getRunner(c.group)
+	if err != nil {
+		return err
+	}
+	if !r.started {
+		err := check.Retry(5*time.Second, 3, r.startRunner, "jmxfetch")
 		if err != nil {
 			return err
 		}
 	}
 	s.configs.Add(string(c.id), c.config)
+	s.groups[string(c.id)] = c.group
 	return nil
 }
 
@@ -49,6 +86,24 @@ func (s *jmxState) unscheduleCheck(c *JMXCheck) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.configs.Remove(string(c.id))
+	delete(s.groups, string(c.id))
+
+	if c.group == "" {
+		return
+	}
+	for _, group := range s.groups {
+		if group == c.group {
+			return
+		}
+	}
+	// last check of the group, its JMXFetch process has nothing left to run
+	if r, ok := s.groupRunners[c.group]; ok {
+		if err := r.stopRunner(); err != nil {
+			log.Errorf("failure to kill jmxfetch process of group %s: %s", c.group, err)
+		}
+		delete(s.groupRunners, c.group)
+		status.RemoveJMXGroupStatus(c.group)
+	}
 }
 
 func (s *jmxState) addScheduledConfig(c integration.Config) {
@@ -63,6 +118,19 @@ func (s *jmxState) getScheduledConfigs() map[string]integration.Config {
 	return configs
 }
 
+func (s *jmxState) getScheduledConfigsForGroup(group string) map[string]integration.Config {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	configs := map[string]integration.Config{}
+	for name, config := range s.configs.Items() {
+		// configs added with addScheduledConfig belong to the default group
+		if s.groups[name] == group {
+			configs[name] = config.(integration.Config)
+		}
+	}
+	return configs
+}
+
 func (s *jmxState) getScheduledConfigsModificationTimestamp() int64 {
 	return s.configs.GetModified()
 }
@@ -78,16 +146,30 @@ func GetScheduledConfigs() map[string]integration.Config {
 	return state.getScheduledConfigs()
 }
 
+// GetScheduledConfigsForGroup returns the list of scheduled jmx configs run
+// by the JMXFetch process of a process group, the default one being empty.
+func GetScheduledConfigsForGroup(group string) map[string]integration.Config {
+	return state.getScheduledConfigsForGroup(group)
+}
+
 // GetScheduledConfigsModificationTimestamp returns the last timestamp at which
 // the list of scheduled configuration got updated.
 func GetScheduledConfigsModificationTimestamp() int64 {
 	return state.getScheduledConfigsModificationTimestamp()
 }
 
-// StopJmxfetch stops the jmxfetch process if it is running
+// StopJmxfetch stops the jmxfetch processes if they are running
 func StopJmxfetch() {
 	err := state.runner.stopRunner()
 	if err != nil {
 		log.Errorf("failure to kill jmxfetch process: %s", err)
 	}
+
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	for group, r := range state.groupRunners {
+		if err := r.stopRunner(); err != nil {
+			log.Errorf("failure to kill jmxfetch process of group %s: %s", group, err)
+		}
+	}
 }
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package iis
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+const (
+	iisCheckName = "iis"
+
+	siteClass       = "Web Service"
+	siteTag         = "site"
+	appPoolClass    = "HTTP Service Request Queues"
+	appPoolTag      = "app_pool"
+	totalInstance   = "_Total"
+	siteServiceName = "iis.site_up"
+)
+
+// siteCounters maps the per-site counters to the metrics of the Python check
+var siteCounters = []struct {
+	counter string
+	metric  string
+}{
+	{"Service Uptime", "iis.uptime"},
+	{"Bytes Sent/sec", "iis.net.bytes_sent"},
+	{"Bytes Received/sec", "iis.net.bytes_rcvd"},
+	{"Bytes Total/sec", "iis.net.bytes_total"},
+	{"Current Connections", "iis.net.num_connections"},
+	{"Files Sent/sec", "iis.net.files_sent"},
+	{"Files Received/sec", "iis.net.files_rcvd"},
+	{"Connection Attempts/sec", "iis.net.connection_attempts"},
+	{"Get Requests/sec", "iis.httpd_request_method.get"},
+	{"Post Requests/sec", "iis.httpd_request_method.post"},
+	{"Head Requests/sec", "iis.httpd_request_method.head"},
+	{"Put Requests/sec", "iis.httpd_request_method.put"},
+	{"Delete Requests/sec", "iis.httpd_request_method.delete"},
+	{"Options Requests/sec", "iis.httpd_request_method.options"},
+	{"Trace Requests/sec", "iis.httpd_request_method.trace"},
+	{"Not Found Errors/sec", "iis.errors.not_found"},
+	{"Locked Errors/sec", "iis.errors.locked"},
+	{"Current Anonymous Users", "iis.users.anon"},
+	{"Current NonAnonymous Users", "iis.users.nonanon"},
+	{"CGI Requests/sec", "iis.requests.cgi"},
+	{"ISAPI Extension Requests/sec", "iis.requests.isapi"},
+}
+
+// appPoolCounters maps the per-application pool request queue counters
+var appPoolCounters = []struct {
+	counter    string
+	metric     string
+	metricType string
+}{
+	{"CurrentQueueSize", "iis.request_queue.length", pdhutil.MetricTypeGauge},
+	{"MaxQueueItemAge", "iis.request_queue.max_item_age", pdhutil.MetricTypeGauge},
+	{"RejectedRequests", "iis.request_queue.rejected", pdhutil.MetricTypeMonotonicCount},
+}
+
+type iisInstanceConfig struct {
+	// Sites lists the sites to collect, with wildcards, all of them by default
+	Sites []string `yaml:"sites"`
+	// AppPools lists the application pools whose request queue is collected,
+	// with wildcards, all of them by default
+	AppPools []string `yaml:"app_pools"`
+}
+
+type iisCheck struct {
+	core.CheckBase
+	sites    []string
+	counters []*pdhutil.ConfiguredCounter
+}
+
+// Run executes the check
+func (c *iisCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	seenSites := make(map[string]bool)
+	for _, counter := range c.counters {
+		values, err := counter.GetValues()
+		if err != nil {
+			c.Warnf("Error getting the values of %s: %v", counter.Config.Metric, err)
+			continue
+		}
+		for instance, val := range values {
+			tags := []string{fmt.Sprintf("%s:%s", counter.Config.InstanceTag, instance)}
+			if counter.Config.Type == pdhutil.MetricTypeMonotonicCount {
+				sender.MonotonicCount(counter.Config.Metric, val, "", tags)
+			} else {
+				sender.Gauge(counter.Config.Metric, val, "", tags)
+			}
+			if counter.Config.Class == siteClass {
+				seenSites[strings.ToLower(instance)] = true
+			}
+		}
+	}
+
+	for _, site := range c.sites {
+		tags := []string{fmt.Sprintf("%s:%s", siteTag, site)}
+		if seenSites[strings.ToLower(site)] {
+			sender.ServiceCheck(siteServiceName, metrics.ServiceCheckOK, "", tags, "")
+		} else {
+			sender.ServiceCheck(siteServiceName, metrics.ServiceCheckCritical, "", tags, fmt.Sprintf("site %s is not running", site))
+		}
+	}
+	sender.Commit()
+
+	return nil
+}
+
+// Configure opens the counters, it fails if IIS is not installed
+func (c *iisCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	conf := iisInstanceConfig{}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	sites := conf.Sites
+	var excludedSites []string
+	if len(sites) == 0 {
+		sites = []string{"*"}
+		excludedSites = []string{totalInstance}
+	}
+	// the service check only covers the sites explicitly listed
+	for _, site := range conf.Sites {
+		if !strings.ContainsAny(site, "*?[") {
+			c.sites = append(c.sites, site)
+		}
+	}
+	appPools := conf.AppPools
+	if len(appPools) == 0 {
+		appPools = []string{"*"}
+	}
+
+	for _, sc := range siteCounters {
+		if err := c.addCounter(pdhutil.CounterConfig{
+			Class:            siteClass,
+			Counter:          sc.counter,
+			Instances:        sites,
+			ExcludeInstances: excludedSites,
+			Metric:           sc.metric,
+			InstanceTag:      siteTag,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, ac := range appPoolCounters {
+		if err := c.addCounter(pdhutil.CounterConfig{
+			Class:       appPoolClass,
+			Counter:     ac.counter,
+			Instances:   appPools,
+			Metric:      ac.metric,
+			Type:        ac.metricType,
+			InstanceTag: appPoolTag,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *iisCheck) addCounter(conf pdhutil.CounterConfig) error {
+	counter, err := pdhutil.NewConfiguredCounter(conf)
+	if err != nil {
+		c.Stop()
+		return err
+	}
+	c.counters = append(c.counters, counter)
+	return nil
+}
+
+// Stop closes the counters
+func (c *iisCheck) Stop() {
+	for _, counter := range c.counters {
+		counter.Close()
+	}
+	c.counters = nil
+}
+
+func iisFactory() check.Check {
+	return &iisCheck{
+		CheckBase: core.NewCheckBase(iisCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(iisCheckName, iisFactory)
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package iis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	pdhtest "github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+func setupTesting() {
+	pdhtest.SetupTesting("..\\system\\testfiles\\counter_indexes_en-us.txt", "..\\system\\testfiles\\allcounters_en-us.txt")
+}
+
+func TestIISCheck(t *testing.T) {
+	setupTesting()
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Default Web Site)\\Current Connections", 12)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Default Web Site)\\Get Requests/sec", 3.5)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Exchange Back End)\\Current Connections", 7)
+	pdhtest.SetQueryReturnValue("\\\\.\\HTTP Service Request Queues(DefaultAppPool)\\CurrentQueueSize", 4)
+	pdhtest.SetQueryReturnValue("\\\\.\\HTTP Service Request Queues(DefaultAppPool)\\RejectedRequests", 21)
+
+	iis := iisFactory().(*iisCheck)
+	err := iis.Configure([]byte("sites: [Default Web Site, Missing Site]\napp_pools: [Default*]"), nil, "test")
+	require.NoError(t, err)
+	require.Len(t, iis.counters, len(siteCounters)+len(appPoolCounters))
+
+	mock := mocksender.NewMockSender(iis.ID())
+	mock.On("Gauge", "iis.net.num_connections", 12.0, "", []string{"site:Default Web Site"}).Return().Times(1)
+	mock.On("Gauge", "iis.httpd_request_method.get", 3.5, "", []string{"site:Default Web Site"}).Return().Times(1)
+	mock.On("Gauge", "iis.request_queue.length", 4.0, "", []string{"app_pool:DefaultAppPool"}).Return().Times(1)
+	mock.On("MonotonicCount", "iis.request_queue.rejected", 21.0, "", []string{"app_pool:DefaultAppPool"}).Return().Times(1)
+	mock.On("ServiceCheck", "iis.site_up", metrics.ServiceCheckOK, "", []string{"site:Default Web Site"}, "").Return().Times(1)
+	mock.On("ServiceCheck", "iis.site_up", metrics.ServiceCheckCritical, "", []string{"site:Missing Site"}, "site Missing Site is not running").Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+
+	err = iis.Run()
+	require.NoError(t, err)
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 3)
+	mock.AssertNumberOfCalls(t, "MonotonicCount", 1)
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 2)
+}
+
+func TestIISCheckAllSites(t *testing.T) {
+	setupTesting()
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Default Web Site)\\Current Connections", 12)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(Exchange Back End)\\Current Connections", 7)
+	pdhtest.SetQueryReturnValue("\\\\.\\Web Service(_Total)\\Current Connections", 19)
+
+	iis := iisFactory().(*iisCheck)
+	err := iis.Configure(nil, nil, "test")
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(iis.ID())
+	mock.On("Gauge", "iis.net.num_connections", 12.0, "", []string{"site:Default Web Site"}).Return().Times(1)
+	mock.On("Gauge", "iis.net.num_connections", 7.0, "", []string{"site:Exchange Back End"}).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+
+	err = iis.Run()
+	require.NoError(t, err)
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 2)
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 0)
+}
@@ -72,6 +72,11 @@ func (gl *GoCheckLoader) Load(config integration.Config, instance integration.Da
 	return c, nil
 }
 
+// Name returns the name selecting this loader in a check configuration
+func (gl *GoCheckLoader) Name() string {
+	return "core"
+}
+
 func (gl *GoCheckLoader) String() string {
 	return "Core Check Loader"
 }
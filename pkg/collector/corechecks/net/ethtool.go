@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// from linux/sockios.h and linux/ethtool.h
+const (
+	siocEthtool     = 0x8946
+	ethtoolGDrvInfo = 0x00000003
+	ethtoolGStrings = 0x0000001b
+	ethtoolGStats   = 0x0000001d
+	ethSSStats      = 1
+	ethGStringLen   = 32
+	ifNameSize      = 16
+
+	// upper bound of the number of statistics, as drivers expose at most a
+	// few hundreds of them
+	maxEthtoolStats = 4096
+)
+
+var (
+	// ethtoolMetrics maps the statistics of the interface reported as is
+	ethtoolMetrics = map[string]string{
+		"rx_missed_errors": "system.net.ethtool.rx_missed",
+		"rx_missed":        "system.net.ethtool.rx_missed",
+		"rx_dropped":       "system.net.ethtool.rx_dropped",
+		"tx_dropped":       "system.net.ethtool.tx_dropped",
+		"rx_fifo_errors":   "system.net.ethtool.rx_fifo_errors",
+		"tx_fifo_errors":   "system.net.ethtool.tx_fifo_errors",
+	}
+
+	// ethtoolQueueStatRe matches the drops of a queue, whose naming depends on
+	// the driver, e.g. rx_queue_0_drops (ixgbe), tx-0.tx_dropped (i40e) or
+	// rx3_dropped (mlx5)
+	ethtoolQueueStatRe = regexp.MustCompile(`^(rx|tx)[-_]?(?:queue_)?([0-9]+)[._](?:rx_|tx_)?(drops|dropped)$`)
+)
+
+// ethtoolDrvInfo is struct ethtool_drvinfo
+type ethtoolDrvInfo struct {
+	cmd         uint32
+	driver      [32]byte
+	version     [32]byte
+	fwVersion   [32]byte
+	busInfo     [32]byte
+	eromVersion [32]byte
+	reserved2   [12]byte
+	nPrivFlags  uint32
+	nStats      uint32
+	testInfoLen uint32
+	eedumpLen   uint32
+	regdumpLen  uint32
+}
+
+// ifreq is struct ifreq, with ifr_data
+type ifreq struct {
+	name [ifNameSize]byte
+	data uintptr
+	_    [24 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// ethtoolStats returns the NIC statistics of an interface, as printed by
+// `ethtool -S <iface>`
+func ethtoolStats(iface string) (map[string]uint64, error) {
+	if len(iface) >= ifNameSize {
+		return nil, fmt.Errorf("invalid interface name %q", iface)
+	}
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_IP)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	drvInfo := ethtoolDrvInfo{cmd: ethtoolGDrvInfo}
+	if err := ethtoolIoctl(fd, iface, unsafe.Pointer(&drvInfo)); err != nil {
+		return nil, err
+	}
+	n := int(drvInfo.nStats)
+	if n == 0 {
+		return nil, nil
+	}
+	if n > maxEthtoolStats {
+		return nil, fmt.Errorf("too many ethtool statistics for %s: %d", iface, n)
+	}
+
+	// struct ethtool_gstrings: cmd, string_set, len, then the strings
+	names := make([]byte, 12+n*ethGStringLen)
+	*(*uint32)(unsafe.Pointer(&names[0])) = ethtoolGStrings
+	*(*uint32)(unsafe.Pointer(&names[4])) = ethSSStats
+	*(*uint32)(unsafe.Pointer(&names[8])) = uint32(n)
+	if err := ethtoolIoctl(fd, iface, unsafe.Pointer(&names[0])); err != nil {
+		return nil, err
+	}
+
+	// struct ethtool_stats: cmd, n_stats, then the values
+	values := make([]uint64, 1+n)
+	*(*uint32)(unsafe.Pointer(&values[0])) = ethtoolGStats
+	*(*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(&values[0])) + 4)) = uint32(n)
+	if err := ethtoolIoctl(fd, iface, unsafe.Pointer(&values[0])); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]uint64, n)
+	for i := 0; i < n; i++ {
+		name := names[12+i*ethGStringLen : 12+(i+1)*ethGStringLen]
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		stats[string(name)] = values[1+i]
+	}
+	return stats, nil
+}
+
+func ethtoolIoctl(fd int, iface string, data unsafe.Pointer) error {
+	var req ifreq
+	copy(req.name[:], iface)
+	req.data = uintptr(data)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// softnetStat is a line of /proc/net/softnet_stat, the statistics of the
+// packets processing of an online CPU
+type softnetStat struct {
+	cpu          int
+	processed    uint64
+	dropped      uint64
+	timeSqueezed uint64
+}
+
+func readSoftnetStats(path string) ([]softnetStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stats []softnetStat
+	scanner := bufio.NewScanner(f)
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s is not formatted correctly, expected at least 3 columns", path)
+		}
+		// offline CPUs have no line, the index of the CPU is given by the
+		// 13th column since Linux 5.10
+		cpu := line
+		if len(fields) >= 13 {
+			index, err := strconv.ParseUint(fields[12], 16, 32)
+			if err != nil {
+				return nil, err
+			}
+			cpu = int(index)
+		}
+		var values [3]uint64
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i], 16, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		stats = append(stats, softnetStat{
+			cpu:          cpu,
+			processed:    values[0],
+			dropped:      values[1],
+			timeSqueezed: values[2],
+		})
+	}
+	return stats, scanner.Err()
+}
@@ -27,6 +27,7 @@ import (
 
 const (
 	networkCheckName = "network"
+	softnetStatPath  = "/proc/net/softnet_stat"
 )
 
 var (
@@ -81,6 +82,10 @@ type networkInstanceConfig struct {
 	ExcludedInterfaces       []string `yaml:"excluded_interfaces"`
 	ExcludedInterfaceRe      string   `yaml:"excluded_interface_re"`
 	ExcludedInterfacePattern *regexp.Regexp
+	CollectEthtoolStats      bool   `yaml:"collect_ethtool_stats"`
+	EthtoolInterfaceRe       string `yaml:"ethtool_interface_re"`
+	EthtoolInterfacePattern  *regexp.Regexp
+	CollectSoftnetStats      bool `yaml:"collect_softnet_stats"`
 }
 
 type networkInitConfig struct{}
@@ -95,6 +100,8 @@ type networkStats interface {
 	ProtoCounters(protocols []string) ([]net.ProtoCountersStat, error)
 	Connections(kind string) ([]net.ConnectionStat, error)
 	NetstatTCPExtCounters() (map[string]int64, error)
+	EthtoolStats(iface string) (map[string]uint64, error)
+	SoftnetStats() ([]softnetStat, error)
 }
 
 type defaultNetworkStats struct{}
@@ -115,6 +122,14 @@ func (n defaultNetworkStats) NetstatTCPExtCounters() (map[string]int64, error) {
 	return netstatTCPExtCounters()
 }
 
+func (n defaultNetworkStats) EthtoolStats(iface string) (map[string]uint64, error) {
+	return ethtoolStats(iface)
+}
+
+func (n defaultNetworkStats) SoftnetStats() ([]softnetStat, error) {
+	return readSoftnetStats(softnetStatPath)
+}
+
 // Run executes the check
 func (c *NetworkCheck) Run() error {
 	sender, err := aggregator.GetSender(c.ID())
@@ -129,6 +144,24 @@ func (c *NetworkCheck) Run() error {
 	for _, interfaceIO := range ioByInterface {
 		if !c.isDeviceExcluded(interfaceIO.Name) {
 			submitInterfaceMetrics(sender, interfaceIO)
+			if c.collectEthtoolStats(interfaceIO.Name) {
+				stats, err := c.net.EthtoolStats(interfaceIO.Name)
+				if err != nil {
+					// virtual interfaces, like lo or veths, don't support ethtool
+					log.Debugf("Unable to get the ethtool statistics of %s: %s", interfaceIO.Name, err)
+				} else {
+					submitEthtoolMetrics(sender, interfaceIO.Name, stats)
+				}
+			}
+		}
+	}
+
+	if c.config.instance.CollectSoftnetStats {
+		stats, err := c.net.SoftnetStats()
+		if err != nil {
+			log.Warnf("Unable to get the softnet statistics: %s", err)
+		} else {
+			submitSoftnetMetrics(sender, stats)
 		}
 	}
 
@@ -194,6 +227,16 @@ func (c *NetworkCheck) isDeviceExcluded(deviceName string) bool {
 	return false
 }
 
+func (c *NetworkCheck) collectEthtoolStats(deviceName string) bool {
+	if !c.config.instance.CollectEthtoolStats {
+		return false
+	}
+	if c.config.instance.EthtoolInterfacePattern != nil {
+		return c.config.instance.EthtoolInterfacePattern.MatchString(deviceName)
+	}
+	return true
+}
+
 func submitInterfaceMetrics(sender aggregator.Sender, interfaceIO net.IOCountersStat) {
 	tags := []string{fmt.Sprintf("device:%s", interfaceIO.Name), fmt.Sprintf("device_name:%s", interfaceIO.Name)}
 	sender.Rate("system.net.bytes_rcvd", float64(interfaceIO.BytesRecv), "", tags)
@@ -204,6 +247,29 @@ func submitInterfaceMetrics(sender aggregator.Sender, interfaceIO net.IOCounters
 	sender.Rate("system.net.packets_out.error", float64(interfaceIO.Errout), "", tags)
 }
 
+func submitEthtoolMetrics(sender aggregator.Sender, deviceName string, stats map[string]uint64) {
+	tags := []string{fmt.Sprintf("device:%s", deviceName), fmt.Sprintf("device_name:%s", deviceName)}
+	for stat, value := range stats {
+		if metricName, ok := ethtoolMetrics[stat]; ok {
+			sender.Rate(metricName, float64(value), "", tags)
+			continue
+		}
+		if match := ethtoolQueueStatRe.FindStringSubmatch(stat); match != nil {
+			queueTags := append([]string{fmt.Sprintf("queue:%s", match[2])}, tags...)
+			sender.Rate(fmt.Sprintf("system.net.ethtool.%s_queue_dropped", match[1]), float64(value), "", queueTags)
+		}
+	}
+}
+
+func submitSoftnetMetrics(sender aggregator.Sender, stats []softnetStat) {
+	for _, stat := range stats {
+		tags := []string{fmt.Sprintf("cpu:%d", stat.cpu)}
+		sender.Rate("system.net.softnet.processed", float64(stat.processed), "", tags)
+		sender.Rate("system.net.softnet.dropped", float64(stat.dropped), "", tags)
+		sender.Rate("system.net.softnet.times_squeezed", float64(stat.timeSqueezed), "", tags)
+	}
+}
+
 func submitProtocolMetrics(sender aggregator.Sender, protocolStats net.ProtoCountersStat) {
 	if protocolMapping, ok := protocolsMetricsMapping[protocolStats.Protocol]; ok {
 		for rawMetricName, metricName := range protocolMapping {
@@ -299,6 +365,14 @@ func (c *NetworkCheck) Configure(rawInstance integration.Data, rawInitConfig int
 		}
 	}
 
+	if c.config.instance.EthtoolInterfaceRe != "" {
+		pattern, err := regexp.Compile(c.config.instance.EthtoolInterfaceRe)
+		if err != nil {
+			return fmt.Errorf("failed to parse network check option ethtool_interface_re: %s", err)
+		}
+		c.config.instance.EthtoolInterfacePattern = pattern
+	}
+
 	return nil
 }
 
@@ -8,6 +8,10 @@
 package net
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
@@ -31,6 +35,9 @@ type fakeNetworkStats struct {
 	connectionStatsTCP6Error    error
 	netstatTCPExtCountersValues map[string]int64
 	netstatTCPExtCountersError  error
+	ethtoolStats                map[string]map[string]uint64
+	softnetStats                []softnetStat
+	softnetStatsError           error
 }
 
 // IOCounters returns the inner values of counterStats and counterStatsError
@@ -62,6 +69,20 @@ func (n *fakeNetworkStats) NetstatTCPExtCounters() (map[string]int64, error) {
 	return n.netstatTCPExtCountersValues, n.netstatTCPExtCountersError
 }
 
+// EthtoolStats returns the inner values of ethtoolStats for the interface
+func (n *fakeNetworkStats) EthtoolStats(iface string) (map[string]uint64, error) {
+	stats, ok := n.ethtoolStats[iface]
+	if !ok {
+		return nil, errors.New("operation not supported")
+	}
+	return stats, nil
+}
+
+// SoftnetStats returns the inner values of softnetStats and softnetStatsError
+func (n *fakeNetworkStats) SoftnetStats() ([]softnetStat, error) {
+	return n.softnetStats, n.softnetStatsError
+}
+
 func TestDefaultConfiguration(t *testing.T) {
 	check := NetworkCheck{}
 	check.Configure([]byte(``), []byte(``), "test")
@@ -485,3 +506,83 @@ excluded_interface_re: "eth[0-9]"
 	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.count", float64(26), "", lo0Tags)
 	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.error", float64(27), "", lo0Tags)
 }
+
+func TestEthtoolAndSoftnetMetrics(t *testing.T) {
+	net := &fakeNetworkStats{
+		counterStats: []net.IOCountersStat{
+			{Name: "eth0"},
+			{Name: "eth1"},
+			{Name: "lo"},
+		},
+		ethtoolStats: map[string]map[string]uint64{
+			"eth0": {
+				"rx_missed_errors":   1,
+				"tx_dropped":         2,
+				"rx_queue_0_drops":   3,
+				"tx-1.tx_dropped":    4,
+				"rx_queue_0_packets": 5,
+			},
+			"eth1": {
+				"tx_dropped": 6,
+			},
+		},
+		softnetStats: []softnetStat{
+			{cpu: 0, processed: 7, dropped: 8, timeSqueezed: 9},
+		},
+	}
+
+	networkCheck := NetworkCheck{
+		net: net,
+	}
+
+	rawInstanceConfig := []byte(`
+collect_ethtool_stats: true
+ethtool_interface_re: "eth0|lo"
+collect_softnet_stats: true
+`)
+
+	err := networkCheck.Configure(rawInstanceConfig, []byte(``), "test")
+	assert.Nil(t, err)
+
+	mockSender := mocksender.NewMockSender(networkCheck.ID())
+	mockSender.SetupAcceptAll()
+
+	err = networkCheck.Run()
+	assert.Nil(t, err)
+
+	eth0Tags := []string{"device:eth0", "device_name:eth0"}
+	mockSender.AssertCalled(t, "Rate", "system.net.ethtool.rx_missed", float64(1), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.ethtool.tx_dropped", float64(2), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.ethtool.rx_queue_dropped", float64(3), "", append([]string{"queue:0"}, eth0Tags...))
+	mockSender.AssertCalled(t, "Rate", "system.net.ethtool.tx_queue_dropped", float64(4), "", append([]string{"queue:1"}, eth0Tags...))
+	mockSender.AssertNotCalled(t, "Rate", "system.net.ethtool.tx_dropped", float64(6), "", []string{"device:eth1", "device_name:eth1"})
+
+	cpuTags := []string{"cpu:0"}
+	mockSender.AssertCalled(t, "Rate", "system.net.softnet.processed", float64(7), "", cpuTags)
+	mockSender.AssertCalled(t, "Rate", "system.net.softnet.dropped", float64(8), "", cpuTags)
+	mockSender.AssertCalled(t, "Rate", "system.net.softnet.times_squeezed", float64(9), "", cpuTags)
+}
+
+func TestEthtoolInterfaceReInvalid(t *testing.T) {
+	check := NetworkCheck{}
+	err := check.Configure([]byte(`ethtool_interface_re: "eth("`), []byte(``), "test")
+	assert.Error(t, err)
+}
+
+func TestReadSoftnetStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "softnet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "softnet_stat")
+	content := "0000a2f3 00000001 0000000c 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000\n" +
+		"00001b40 00000000 00000002 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000002\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	stats, err := readSoftnetStats(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []softnetStat{
+		{cpu: 0, processed: 0xa2f3, dropped: 1, timeSqueezed: 0xc},
+		{cpu: 2, processed: 0x1b40, dropped: 0, timeSqueezed: 2},
+	}, stats)
+}
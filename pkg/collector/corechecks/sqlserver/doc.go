@@ -0,0 +1,10 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package sqlserver provides a core check for Microsoft SQL Server, querying its
+performance counters through ODBC on Windows
+*/
+package sqlserver
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package sqlserver
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Minimal binding of the ODBC driver manager, enough to run a query and read
+// its results as strings.
+// https://docs.microsoft.com/en-us/sql/odbc/reference/syntax/odbc-api-reference
+
+var (
+	mododbc32 = windows.NewLazySystemDLL("odbc32.dll")
+
+	procSQLAllocHandle    = mododbc32.NewProc("SQLAllocHandle")
+	procSQLFreeHandle     = mododbc32.NewProc("SQLFreeHandle")
+	procSQLSetEnvAttr     = mododbc32.NewProc("SQLSetEnvAttr")
+	procSQLDriverConnectW = mododbc32.NewProc("SQLDriverConnectW")
+	procSQLDisconnect     = mododbc32.NewProc("SQLDisconnect")
+	procSQLExecDirectW    = mododbc32.NewProc("SQLExecDirectW")
+	procSQLNumResultCols  = mododbc32.NewProc("SQLNumResultCols")
+	procSQLFetch          = mododbc32.NewProc("SQLFetch")
+	procSQLGetData        = mododbc32.NewProc("SQLGetData")
+	procSQLGetDiagRecW    = mododbc32.NewProc("SQLGetDiagRecW")
+)
+
+const (
+	sqlHandleEnv  = 1
+	sqlHandleDbc  = 2
+	sqlHandleStmt = 3
+
+	sqlAttrODBCVersion = 200
+	sqlOVODBC3         = 3
+
+	sqlSuccess         = 0
+	sqlSuccessWithInfo = 1
+	sqlNoData          = 100
+
+	sqlNullData       = -1
+	sqlDriverNoPrompt = 0
+
+	// negative arguments, as two's complement of the register size
+	sqlNTS    = ^uintptr(2) // -3
+	sqlCWChar = ^uintptr(7) // -8
+
+	// columns longer than this are truncated
+	maxColumnChars = 1024
+)
+
+// odbcAvailable returns an error if the ODBC driver manager can't be loaded
+func odbcAvailable() error {
+	return procSQLAllocHandle.Find()
+}
+
+func succeeded(ret uintptr) bool {
+	r := int16(ret)
+	return r == sqlSuccess || r == sqlSuccessWithInfo
+}
+
+// diagError builds an error from the first diagnostic record of the handle
+func diagError(op string, handleType int16, handle uintptr) error {
+	state := make([]uint16, 6)
+	message := make([]uint16, 512)
+	var nativeError int32
+	var length int16
+	ret, _, _ := procSQLGetDiagRecW.Call(uintptr(handleType), handle, 1,
+		uintptr(unsafe.Pointer(&state[0])), uintptr(unsafe.Pointer(&nativeError)),
+		uintptr(unsafe.Pointer(&message[0])), uintptr(len(message)), uintptr(unsafe.Pointer(&length)))
+	if !succeeded(ret) {
+		return fmt.Errorf("%s failed", op)
+	}
+	return fmt.Errorf("%s failed: [%s] %s", op, windows.UTF16ToString(state), windows.UTF16ToString(message))
+}
+
+type odbcConn struct {
+	env uintptr
+	dbc uintptr
+}
+
+// odbcConnect opens a connection with the given ODBC connection string
+func odbcConnect(connStr string) (*odbcConn, error) {
+	c := &odbcConn{}
+	ret, _, _ := procSQLAllocHandle.Call(sqlHandleEnv, 0, uintptr(unsafe.Pointer(&c.env)))
+	if !succeeded(ret) {
+		return nil, fmt.Errorf("could not allocate an ODBC environment handle")
+	}
+	ret, _, _ = procSQLSetEnvAttr.Call(c.env, sqlAttrODBCVersion, sqlOVODBC3, 0)
+	if !succeeded(ret) {
+		err := diagError("SQLSetEnvAttr", sqlHandleEnv, c.env)
+		c.close()
+		return nil, err
+	}
+	ret, _, _ = procSQLAllocHandle.Call(sqlHandleDbc, c.env, uintptr(unsafe.Pointer(&c.dbc)))
+	if !succeeded(ret) {
+		err := diagError("SQLAllocHandle", sqlHandleEnv, c.env)
+		c.close()
+		return nil, err
+	}
+
+	connStrW, err := windows.UTF16PtrFromString(connStr)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	ret, _, _ = procSQLDriverConnectW.Call(c.dbc, 0, uintptr(unsafe.Pointer(connStrW)), sqlNTS,
+		0, 0, 0, sqlDriverNoPrompt)
+	if !succeeded(ret) {
+		err := diagError("SQLDriverConnect", sqlHandleDbc, c.dbc)
+		procSQLFreeHandle.Call(sqlHandleDbc, c.dbc)
+		c.dbc = 0
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// query runs the statement and returns its rows, every column as a string;
+// NULL values are returned as empty strings
+func (c *odbcConn) query(statement string) ([][]string, error) {
+	var stmt uintptr
+	ret, _, _ := procSQLAllocHandle.Call(sqlHandleStmt, c.dbc, uintptr(unsafe.Pointer(&stmt)))
+	if !succeeded(ret) {
+		return nil, diagError("SQLAllocHandle", sqlHandleDbc, c.dbc)
+	}
+	defer procSQLFreeHandle.Call(sqlHandleStmt, stmt)
+
+	statementW, err := windows.UTF16PtrFromString(statement)
+	if err != nil {
+		return nil, err
+	}
+	ret, _, _ = procSQLExecDirectW.Call(stmt, uintptr(unsafe.Pointer(statementW)), sqlNTS)
+	if !succeeded(ret) {
+		return nil, diagError("SQLExecDirect", sqlHandleStmt, stmt)
+	}
+
+	var numCols int16
+	ret, _, _ = procSQLNumResultCols.Call(stmt, uintptr(unsafe.Pointer(&numCols)))
+	if !succeeded(ret) {
+		return nil, diagError("SQLNumResultCols", sqlHandleStmt, stmt)
+	}
+
+	var rows [][]string
+	buf := make([]uint16, maxColumnChars+1)
+	for {
+		ret, _, _ = procSQLFetch.Call(stmt)
+		if int16(ret) == sqlNoData {
+			return rows, nil
+		}
+		if !succeeded(ret) {
+			return nil, diagError("SQLFetch", sqlHandleStmt, stmt)
+		}
+		row := make([]string, numCols)
+		for col := range row {
+			// SQLLEN is pointer sized
+			var indicator int
+			ret, _, _ = procSQLGetData.Call(stmt, uintptr(col+1), sqlCWChar,
+				uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)*2), uintptr(unsafe.Pointer(&indicator)))
+			if !succeeded(ret) {
+				return nil, diagError("SQLGetData", sqlHandleStmt, stmt)
+			}
+			if indicator != sqlNullData {
+				row[col] = windows.UTF16ToString(buf)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// close disconnects and frees the handles
+func (c *odbcConn) close() {
+	if c.dbc != 0 {
+		procSQLDisconnect.Call(c.dbc)
+		procSQLFreeHandle.Call(sqlHandleDbc, c.dbc)
+		c.dbc = 0
+	}
+	if c.env != 0 {
+		procSQLFreeHandle.Call(sqlHandleEnv, c.env)
+		c.env = 0
+	}
+}
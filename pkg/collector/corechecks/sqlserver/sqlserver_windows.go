@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package sqlserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	sqlserverCheckName = "sqlserver"
+	canConnectService  = "sqlserver.can_connect"
+
+	defaultHost     = "localhost"
+	defaultDatabase = "master"
+	defaultDriver   = "SQL Server"
+
+	// cntr_type values of sys.dm_os_performance_counters, the other types
+	// being submitted as gauges
+	perfBulkCount      = 272696576 // PERF_COUNTER_BULK_COUNT, a cumulative count
+	perfLargeRawFrac   = 537003264 // PERF_LARGE_RAW_FRACTION, divided by its base
+	fractionBaseSuffix = " base"
+)
+
+// perfCounters maps the collected counters to the metrics of the Python check.
+// The instance is empty for the counters without instances.
+var perfCounters = []struct {
+	counter  string
+	instance string
+	metric   string
+}{
+	{"Buffer cache hit ratio", "", "sqlserver.buffer.cache_hit_ratio"},
+	{"Page life expectancy", "", "sqlserver.buffer.page_life_expectancy"},
+	{"Checkpoint pages/sec", "", "sqlserver.buffer.checkpoint_pages"},
+	{"Page splits/sec", "", "sqlserver.access.page_splits"},
+	{"Batch Requests/sec", "", "sqlserver.stats.batch_requests"},
+	{"SQL Compilations/sec", "", "sqlserver.stats.sql_compilations"},
+	{"SQL Re-Compilations/sec", "", "sqlserver.stats.sql_recompilations"},
+	{"User Connections", "", "sqlserver.stats.connections"},
+	{"Lock Waits/sec", "_Total", "sqlserver.stats.lock_waits"},
+	{"Processes blocked", "", "sqlserver.stats.procs_blocked"},
+}
+
+type sqlserverInstanceConfig struct {
+	Host             string `yaml:"host"`
+	Username         string `yaml:"username"`
+	Password         string `yaml:"password"`
+	Database         string `yaml:"database"`
+	Driver           string `yaml:"driver"`
+	ConnectionString string `yaml:"connection_string"`
+}
+
+// connection is the part of odbcConn used by the check, mocked in the tests
+type connection interface {
+	query(statement string) ([][]string, error)
+	close()
+}
+
+var connect = func(connStr string) (connection, error) {
+	conn, err := odbcConnect(connStr)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+type sqlserverCheck struct {
+	core.CheckBase
+	connStr     string
+	serviceTags []string
+	query       string
+	conn        connection
+}
+
+// Run executes the check
+func (c *sqlserverCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	rows, err := c.queryCounters()
+	if err != nil {
+		sender.ServiceCheck(canConnectService, metrics.ServiceCheckCritical, "", c.serviceTags, err.Error())
+		sender.Commit()
+		return err
+	}
+	sender.ServiceCheck(canConnectService, metrics.ServiceCheckOK, "", c.serviceTags, "")
+
+	// index the counters by name and instance, the bases included
+	values := make(map[string]float64)
+	types := make(map[string]int)
+	for _, row := range rows {
+		if len(row) != 4 {
+			continue
+		}
+		val, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			log.Debugf("Invalid value %q for counter %s: %v", row[2], row[0], err)
+			continue
+		}
+		cntrType, _ := strconv.Atoi(row[3])
+		key := counterKey(row[0], row[1])
+		values[key] = val
+		types[key] = cntrType
+	}
+
+	for _, pc := range perfCounters {
+		key := counterKey(pc.counter, pc.instance)
+		val, found := values[key]
+		if !found {
+			continue
+		}
+		switch types[key] {
+		case perfBulkCount:
+			sender.Rate(pc.metric, val, "", nil)
+		case perfLargeRawFrac:
+			base, found := values[counterKey(pc.counter+fractionBaseSuffix, pc.instance)]
+			if !found || base == 0 {
+				continue
+			}
+			sender.Gauge(pc.metric, val/base, "", nil)
+		default:
+			sender.Gauge(pc.metric, val, "", nil)
+		}
+	}
+	sender.Commit()
+
+	return nil
+}
+
+// queryCounters reads the performance counters, connecting first if needed.
+// The connection is reopened on the next run after an error.
+func (c *sqlserverCheck) queryCounters() ([][]string, error) {
+	if c.conn == nil {
+		conn, err := connect(c.connStr)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	rows, err := c.conn.query(c.query)
+	if err != nil {
+		c.conn.close()
+		c.conn = nil
+	}
+	return rows, err
+}
+
+func counterKey(counter, instance string) string {
+	return strings.ToLower(counter) + "|" + strings.ToLower(instance)
+}
+
+// Configure builds the connection string, it fails if ODBC is not available
+func (c *sqlserverCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+	if err := odbcAvailable(); err != nil {
+		return fmt.Errorf("ODBC is not available: %v", err)
+	}
+
+	conf := sqlserverInstanceConfig{
+		Host:     defaultHost,
+		Database: defaultDatabase,
+		Driver:   defaultDriver,
+	}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	c.connStr = buildConnectionString(conf)
+	c.serviceTags = []string{"host:" + conf.Host, "db:" + conf.Database}
+	c.query = buildQuery()
+	return nil
+}
+
+func buildConnectionString(conf sqlserverInstanceConfig) string {
+	parts := []string{
+		fmt.Sprintf("DRIVER={%s}", conf.Driver),
+		fmt.Sprintf("Server=%s", conf.Host),
+		fmt.Sprintf("Database=%s", conf.Database),
+	}
+	if conf.Username != "" {
+		parts = append(parts, fmt.Sprintf("UID=%s", conf.Username), fmt.Sprintf("PWD=%s", conf.Password))
+	} else {
+		parts = append(parts, "Trusted_Connection=yes")
+	}
+	if conf.ConnectionString != "" {
+		parts = append(parts, conf.ConnectionString)
+	}
+	return strings.Join(parts, ";")
+}
+
+// buildQuery returns the query of the collected counters and of their bases
+func buildQuery() string {
+	var names []string
+	for _, pc := range perfCounters {
+		names = append(names, fmt.Sprintf("'%s'", pc.counter), fmt.Sprintf("'%s%s'", pc.counter, fractionBaseSuffix))
+	}
+	return fmt.Sprintf("SELECT RTRIM(counter_name), RTRIM(instance_name), cntr_value, cntr_type "+
+		"FROM sys.dm_os_performance_counters WHERE counter_name IN (%s)", strings.Join(names, ", "))
+}
+
+// Stop closes the connection
+func (c *sqlserverCheck) Stop() {
+	if c.conn != nil {
+		c.conn.close()
+		c.conn = nil
+	}
+}
+
+func sqlserverFactory() check.Check {
+	return &sqlserverCheck{
+		CheckBase: core.NewCheckBase(sqlserverCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(sqlserverCheckName, sqlserverFactory)
+}
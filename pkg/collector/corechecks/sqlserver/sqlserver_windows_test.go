@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package sqlserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type fakeConnection struct {
+	rows   [][]string
+	err    error
+	closed bool
+}
+
+func (f *fakeConnection) query(statement string) ([][]string, error) {
+	return f.rows, f.err
+}
+
+func (f *fakeConnection) close() {
+	f.closed = true
+}
+
+// mockConnect makes the check use conn, it returns a function restoring connect
+func mockConnect(conn *fakeConnection) func() {
+	orig := connect
+	connect = func(connStr string) (connection, error) {
+		return conn, nil
+	}
+	return func() { connect = orig }
+}
+
+func TestBuildConnectionString(t *testing.T) {
+	conf := sqlserverInstanceConfig{Host: "localhost", Database: "master", Driver: "SQL Server"}
+	assert.Equal(t, "DRIVER={SQL Server};Server=localhost;Database=master;Trusted_Connection=yes", buildConnectionString(conf))
+
+	conf.Username = "datadog"
+	conf.Password = "secret"
+	conf.ConnectionString = "ApplicationIntent=ReadOnly"
+	assert.Equal(t, "DRIVER={SQL Server};Server=localhost;Database=master;UID=datadog;PWD=secret;ApplicationIntent=ReadOnly", buildConnectionString(conf))
+}
+
+func TestSQLServerCheck(t *testing.T) {
+	conn := &fakeConnection{
+		rows: [][]string{
+			{"Buffer cache hit ratio", "", "90", "537003264"},
+			{"Buffer cache hit ratio base", "", "100", "1073939712"},
+			{"Page life expectancy", "", "1200", "65792"},
+			{"Batch Requests/sec", "", "4242", "272696576"},
+			{"Lock Waits/sec", "_Total", "12", "272696576"},
+			{"Lock Waits/sec", "Page", "3", "272696576"},
+			{"User Connections", "", "not a number", "65792"},
+		},
+	}
+	defer mockConnect(conn)()
+
+	sqlserver := sqlserverFactory().(*sqlserverCheck)
+	err := sqlserver.Configure([]byte("host: db.local,1433"), nil, "test")
+	require.NoError(t, err)
+
+	serviceTags := []string{"host:db.local,1433", "db:master"}
+	mock := mocksender.NewMockSender(sqlserver.ID())
+	mock.On("ServiceCheck", "sqlserver.can_connect", metrics.ServiceCheckOK, "", serviceTags, "").Return().Times(1)
+	mock.On("Gauge", "sqlserver.buffer.cache_hit_ratio", 0.9, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "sqlserver.buffer.page_life_expectancy", 1200.0, "", []string(nil)).Return().Times(1)
+	mock.On("Rate", "sqlserver.stats.batch_requests", 4242.0, "", []string(nil)).Return().Times(1)
+	mock.On("Rate", "sqlserver.stats.lock_waits", 12.0, "", []string(nil)).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+
+	err = sqlserver.Run()
+	require.NoError(t, err)
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 2)
+	mock.AssertNumberOfCalls(t, "Rate", 2)
+}
+
+func TestSQLServerCheckReconnects(t *testing.T) {
+	conn := &fakeConnection{err: fmt.Errorf("connection lost")}
+	defer mockConnect(conn)()
+
+	sqlserver := sqlserverFactory().(*sqlserverCheck)
+	err := sqlserver.Configure(nil, nil, "test")
+	require.NoError(t, err)
+
+	serviceTags := []string{"host:localhost", "db:master"}
+	mock := mocksender.NewMockSender(sqlserver.ID())
+	mock.On("ServiceCheck", "sqlserver.can_connect", metrics.ServiceCheckCritical, "", serviceTags, "connection lost").Return().Times(1)
+	mock.On("ServiceCheck", "sqlserver.can_connect", metrics.ServiceCheckOK, "", serviceTags, "").Return().Times(1)
+	mock.On("Commit").Return().Times(2)
+
+	require.Error(t, sqlserver.Run())
+	assert.True(t, conn.closed)
+	assert.Nil(t, sqlserver.conn)
+
+	conn.err = nil
+	require.NoError(t, sqlserver.Run())
+	assert.NotNil(t, sqlserver.conn)
+
+	mock.AssertExpectations(t)
+}
@@ -65,10 +65,14 @@ func (c *CPUCheck) Run() error {
 
 		sender.Gauge("system.cpu.user", user*toPercent, "", nil)
 		sender.Gauge("system.cpu.system", system*toPercent, "", nil)
-		sender.Gauge("system.cpu.iowait", iowait*toPercent, "", nil)
 		sender.Gauge("system.cpu.idle", idle*toPercent, "", nil)
-		sender.Gauge("system.cpu.stolen", stolen*toPercent, "", nil)
-		sender.Gauge("system.cpu.guest", guest*toPercent, "", nil)
+		// the macOS kernel doesn't account for these times, don't report
+		// them as always zero
+		if runtimeOS != "darwin" {
+			sender.Gauge("system.cpu.iowait", iowait*toPercent, "", nil)
+			sender.Gauge("system.cpu.stolen", stolen*toPercent, "", nil)
+			sender.Gauge("system.cpu.guest", guest*toPercent, "", nil)
+		}
 		sender.Commit()
 	}
 
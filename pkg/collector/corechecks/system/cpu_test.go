@@ -72,6 +72,7 @@ func CPUInfo() ([]cpu.InfoStat, error) {
 }
 
 func TestCPUCheckLinux(t *testing.T) {
+	runtimeOS = "linux"
 	times = CPUTimes
 	cpuInfo = CPUInfo
 	cpuCheck := new(CPUCheck)
@@ -100,3 +101,27 @@ func TestCPUCheckLinux(t *testing.T) {
 	mock.AssertNumberOfCalls(t, "Gauge", 6)
 	mock.AssertNumberOfCalls(t, "Commit", 1)
 }
+
+func TestCPUCheckDarwin(t *testing.T) {
+	runtimeOS = "darwin"
+	times = CPUTimes
+	cpuInfo = CPUInfo
+	cpuCheck := new(CPUCheck)
+	cpuCheck.Configure(nil, nil, "test")
+
+	mock := mocksender.NewMockSender(cpuCheck.ID())
+
+	sample = firstSample
+	cpuCheck.Run()
+
+	sample = secondSample
+	mock.On("Gauge", "system.cpu.user", 0.1913803067769472, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "system.cpu.system", 5.026101621048045, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "system.cpu.idle", 94.74272612720159, "", []string(nil)).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+	cpuCheck.Run()
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 3)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
@@ -7,6 +7,8 @@
 package system
 
 import (
+	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -32,9 +34,45 @@ type diskConfig struct {
 	excludedMountpointRe *regexp.Regexp
 	allPartitions        bool
 	deviceTagRe          map[*regexp.Regexp][]string
+	excludeAPFSSnapshots bool
+	includedMountOptions []string
+	excludedMountOptions []string
+	tagByMountOptions    bool
+	tagByDeviceType      bool
+	tagByMountpoint      bool
 }
 
-func (c *DiskCheck) excludeDisk(mountpoint, device, fstype string) bool {
+// APFS volumes are named after their container, e.g. /dev/disk1s5 in the
+// disk1 container, and the snapshots after their volume, e.g. /dev/disk1s5s1
+// for the sealed system snapshot mounted on / since macOS 11.
+var apfsDeviceRe = regexp.MustCompile(`^/dev/(disk[0-9]+)s[0-9]+(s[0-9]+)?$`)
+
+// Time Machine local snapshots are mounted from a device named after the
+// snapshot and the volume, e.g. com.apple.TimeMachine.2020-09-01-101112.local@/dev/disk1s1
+const timeMachineSnapshotPrefix = "com.apple.TimeMachine."
+
+// filesystems reported with the `device_type:network` tag
+var networkFilesystems = map[string]bool{
+	"nfs":        true,
+	"nfs4":       true,
+	"cifs":       true,
+	"smbfs":      true,
+	"smb3":       true,
+	"afpfs":      true,
+	"ceph":       true,
+	"glusterfs":  true,
+	"fuse.sshfs": true,
+	"webdav":     true,
+}
+
+// filesystems reported with the `device_type:overlay` tag
+var overlayFilesystems = map[string]bool{
+	"overlay":  true,
+	"overlay2": true,
+	"aufs":     true,
+}
+
+func (c *DiskCheck) excludeDisk(mountpoint, device, fstype, opts string) bool {
 
 	// Hack for NFS secure mounts
 	// Secure mounts might look like this: '/mypath (deleted)', we should
@@ -74,6 +112,29 @@ func (c *DiskCheck) excludeDisk(mountpoint, device, fstype string) bool {
 		return true
 	}
 
+	mountOptions := splitMountOptions(opts)
+
+	// one of the mount options is listed in `excluded_mount_options`
+	for _, opt := range mountOptions {
+		if stringSliceContain(c.cfg.excludedMountOptions, opt) {
+			return true
+		}
+	}
+
+	// none of the mount options is listed in `included_mount_options`
+	if len(c.cfg.includedMountOptions) > 0 {
+		included := false
+		for _, opt := range mountOptions {
+			if stringSliceContain(c.cfg.includedMountOptions, opt) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+
 	// all good, don't exclude the disk
 	return false
 }
@@ -125,6 +186,36 @@ func (c *DiskCheck) instanceConfigure(data integration.Data) error {
 		}
 	}
 
+	excludeAPFSSnapshots, found := conf["exclude_apfs_snapshots"]
+	if excludeAPFSSnapshots, ok := excludeAPFSSnapshots.(bool); found && ok {
+		c.cfg.excludeAPFSSnapshots = excludeAPFSSnapshots
+	}
+
+	includedMountOptions, found := conf["included_mount_options"]
+	if includedMountOptions, ok := toStringSlice(includedMountOptions); found && ok {
+		c.cfg.includedMountOptions = includedMountOptions
+	}
+
+	excludedMountOptions, found := conf["excluded_mount_options"]
+	if excludedMountOptions, ok := toStringSlice(excludedMountOptions); found && ok {
+		c.cfg.excludedMountOptions = excludedMountOptions
+	}
+
+	tagByMountOptions, found := conf["tag_by_mount_options"]
+	if tagByMountOptions, ok := tagByMountOptions.(bool); found && ok {
+		c.cfg.tagByMountOptions = tagByMountOptions
+	}
+
+	tagByDeviceType, found := conf["tag_by_device_type"]
+	if tagByDeviceType, ok := tagByDeviceType.(bool); found && ok {
+		c.cfg.tagByDeviceType = tagByDeviceType
+	}
+
+	tagByMountpoint, found := conf["tag_by_mountpoint"]
+	if tagByMountpoint, ok := tagByMountpoint.(bool); found && ok {
+		c.cfg.tagByMountpoint = tagByMountpoint
+	}
+
 	allPartitions, found := conf["all_partitions"]
 	if allPartitions, ok := allPartitions.(bool); found && ok {
 		c.cfg.allPartitions = allPartitions
@@ -158,6 +249,60 @@ func stringSliceContain(slice []string, x string) bool {
 	return false
 }
 
+// toStringSlice converts a YAML list to a slice of strings
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch value := value.(type) {
+	case []string:
+		return value, true
+	case []interface{}:
+		slice := make([]string, 0, len(value))
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, false
+			}
+			slice = append(slice, s)
+		}
+		return slice, true
+	}
+	return nil, false
+}
+
+// splitMountOptions splits the comma-separated options of a mount
+func splitMountOptions(opts string) []string {
+	if opts == "" {
+		return nil
+	}
+	return strings.Split(opts, ",")
+}
+
+// mountOptionTags returns a `mount_option` tag for each flag of a mount, the
+// options with a value, e.g. `errors=remount-ro`, are not tagged.
+func mountOptionTags(opts string) []string {
+	var tags []string
+	for _, opt := range splitMountOptions(opts) {
+		if opt == "" || strings.Contains(opt, "=") {
+			continue
+		}
+		tags = append(tags, fmt.Sprintf("mount_option:%s", opt))
+	}
+	return tags
+}
+
+// deviceType returns the type of device backing a partition: nvme, network
+// or overlay, or an empty string if it is none of them.
+func deviceType(device, fstype string) string {
+	switch {
+	case networkFilesystems[fstype]:
+		return "network"
+	case overlayFilesystems[fstype]:
+		return "overlay"
+	case strings.HasPrefix(filepath.Base(device), "nvme"):
+		return "nvme"
+	}
+	return ""
+}
+
 func (c *DiskCheck) applyDeviceTags(device, mountpoint string, tags []string) []string {
 	// apply device/mountpoint specific tags
 	for re, deviceTags := range c.cfg.deviceTagRe {
@@ -173,6 +318,25 @@ func (c *DiskCheck) applyDeviceTags(device, mountpoint string, tags []string) []
 	return tags
 }
 
+// apfsTags returns the tags of an APFS partition: its container, whose free
+// space is shared by all its volumes, and whether it is a snapshot.
+func apfsTags(device string) (tags []string, snapshot bool) {
+	if strings.HasPrefix(device, timeMachineSnapshotPrefix) {
+		snapshot = true
+		if i := strings.LastIndex(device, "@"); i >= 0 {
+			device = device[i+1:]
+		}
+	}
+	if match := apfsDeviceRe.FindStringSubmatch(device); match != nil {
+		tags = append(tags, fmt.Sprintf("apfs_container:%s", match[1]))
+		snapshot = snapshot || match[2] != ""
+	}
+	if snapshot {
+		tags = append(tags, "apfs_snapshot:true")
+	}
+	return tags, snapshot
+}
+
 func diskFactory() check.Check {
 	return &DiskCheck{
 		CheckBase: core.NewCheckBase(diskCheckName),
@@ -58,10 +58,20 @@ func (c *DiskCheck) collectPartitionMetrics(sender aggregator.Sender) error {
 	}
 
 	for _, partition := range partitions {
-		if c.excludeDisk(partition.Mountpoint, partition.Device, partition.Fstype) {
+		if c.excludeDisk(partition.Mountpoint, partition.Device, partition.Fstype, partition.Opts) {
 			continue
 		}
 
+		var apfs []string
+		if partition.Fstype == "apfs" {
+			var snapshot bool
+			apfs, snapshot = apfsTags(partition.Device)
+			// the sealed system snapshot mounted on / is never excluded
+			if snapshot && c.cfg.excludeAPFSSnapshots && partition.Mountpoint != "/" {
+				continue
+			}
+		}
+
 		// Get disk metrics here to be able to exclude on total usage
 		usage, err := diskUsage(partition.Mountpoint)
 		if err != nil {
@@ -87,6 +97,18 @@ func (c *DiskCheck) collectPartitionMetrics(sender aggregator.Sender) error {
 		}
 		tags = append(tags, fmt.Sprintf("device:%s", deviceName))
 		tags = append(tags, fmt.Sprintf("device_name:%s", filepath.Base(partition.Device)))
+		tags = append(tags, apfs...)
+		if c.cfg.tagByMountpoint {
+			tags = append(tags, fmt.Sprintf("mountpoint:%s", partition.Mountpoint))
+		}
+		if c.cfg.tagByDeviceType {
+			if devType := deviceType(partition.Device, partition.Fstype); devType != "" {
+				tags = append(tags, fmt.Sprintf("device_type:%s", devType))
+			}
+		}
+		if c.cfg.tagByMountOptions {
+			tags = append(tags, mountOptionTags(partition.Opts)...)
+		}
 
 		tags = c.applyDeviceTags(partition.Device, partition.Mountpoint, tags)
 
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -228,3 +229,91 @@ func TestDiskCheckTags(t *testing.T) {
 	mock.AssertNumberOfCalls(t, "Rate", expectedRates)
 	mock.AssertNumberOfCalls(t, "Commit", 1)
 }
+
+func TestAPFSTags(t *testing.T) {
+	for _, tc := range []struct {
+		device   string
+		tags     []string
+		snapshot bool
+	}{
+		{"/dev/disk1s1", []string{"apfs_container:disk1"}, false},
+		{"/dev/disk1s5s1", []string{"apfs_container:disk1", "apfs_snapshot:true"}, true},
+		{"com.apple.TimeMachine.2020-09-01-101112.local@/dev/disk1s1", []string{"apfs_container:disk1", "apfs_snapshot:true"}, true},
+		{"map auto_home", nil, false},
+	} {
+		tags, snapshot := apfsTags(tc.device)
+		assert.Equal(t, tc.tags, tags, tc.device)
+		assert.Equal(t, tc.snapshot, snapshot, tc.device)
+	}
+}
+
+func TestDiskCheckAPFSSnapshots(t *testing.T) {
+	diskPartitions = func(all bool) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{
+			{Device: "/dev/disk1s5s1", Mountpoint: "/", Fstype: "apfs"},
+			{Device: "/dev/disk1s1", Mountpoint: "/System/Volumes/Data", Fstype: "apfs"},
+			{Device: "com.apple.TimeMachine.2020-09-01-101112.local@/dev/disk1s1", Mountpoint: "/Volumes/com.apple.TimeMachine.localsnapshots/2020-09-01-101112", Fstype: "apfs"},
+		}, nil
+	}
+	diskUsage = func(mountpoint string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Path: mountpoint, Total: 1024, Used: 512, Free: 512, UsedPercent: 50}, nil
+	}
+	ioCounters = func(names ...string) (map[string]disk.IOCountersStat, error) {
+		return nil, nil
+	}
+	diskCheck := new(DiskCheck)
+	diskCheck.Configure(integration.Data("exclude_apfs_snapshots: true"), nil, "test")
+
+	mock := mocksender.NewMockSender(diskCheck.ID())
+	mock.SetupAcceptAll()
+
+	diskCheck.Run()
+	mock.AssertCalled(t, "Gauge", "system.disk.total", 1.0, "", []string{"device:/dev/disk1s5s1", "device_name:disk1s5s1", "apfs_container:disk1", "apfs_snapshot:true"})
+	mock.AssertCalled(t, "Gauge", "system.disk.total", 1.0, "", []string{"device:/dev/disk1s1", "device_name:disk1s1", "apfs_container:disk1"})
+	// 8 metrics for the two partitions, the Time Machine snapshot is excluded
+	mock.AssertNumberOfCalls(t, "Gauge", 16)
+}
+
+func TestDeviceType(t *testing.T) {
+	assert.Equal(t, "nvme", deviceType("/dev/nvme0n1p1", "ext4"))
+	assert.Equal(t, "network", deviceType("fileserver:/export", "nfs4"))
+	assert.Equal(t, "overlay", deviceType("overlay", "overlay"))
+	assert.Equal(t, "", deviceType("/dev/sda1", "ext4"))
+}
+
+func TestDiskCheckMountOptions(t *testing.T) {
+	diskPartitions = func(all bool) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{
+			{Device: "/dev/nvme0n1p1", Mountpoint: "/", Fstype: "ext4", Opts: "rw,relatime,errors=remount-ro"},
+			{Device: "/dev/loop0", Mountpoint: "/snap/core/9993", Fstype: "squashfs", Opts: "ro,nodev,relatime"},
+		}, nil
+	}
+	diskUsage = func(mountpoint string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Path: mountpoint, Total: 1024, Used: 512, Free: 512, UsedPercent: 50, InodesTotal: 10, InodesUsed: 5, InodesFree: 5, InodesUsedPercent: 50}, nil
+	}
+	ioCounters = func(names ...string) (map[string]disk.IOCountersStat, error) {
+		return nil, nil
+	}
+	diskCheck := new(DiskCheck)
+	config := integration.Data([]byte("excluded_mount_options:\n  - ro\ntag_by_mount_options: true\ntag_by_device_type: true\ntag_by_mountpoint: true"))
+	diskCheck.Configure(config, nil, "test")
+
+	mock := mocksender.NewMockSender(diskCheck.ID())
+	mock.SetupAcceptAll()
+
+	diskCheck.Run()
+	tags := []string{"device:/dev/nvme0n1p1", "device_name:nvme0n1p1", "mountpoint:/", "device_type:nvme", "mount_option:rw", "mount_option:relatime"}
+	mock.AssertCalled(t, "Gauge", "system.disk.total", 1.0, "", tags)
+	mock.AssertCalled(t, "Gauge", "system.fs.inodes.used", 5.0, "", tags)
+	// the read-only snap is excluded
+	mock.AssertNumberOfCalls(t, "Gauge", 8)
+}
+
+func TestDiskCheckIncludedMountOptions(t *testing.T) {
+	diskCheck := new(DiskCheck)
+	diskCheck.Configure(integration.Data([]byte("included_mount_options:\n  - rw")), nil, "test")
+
+	assert.False(t, diskCheck.excludeDisk("/", "/dev/sda1", "ext4", "rw,relatime"))
+	assert.True(t, diskCheck.excludeDisk("/snap/core/9993", "/dev/loop0", "squashfs", "ro,nodev"))
+	assert.True(t, diskCheck.excludeDisk("/mnt", "/dev/sdb1", "ext4", ""))
+}
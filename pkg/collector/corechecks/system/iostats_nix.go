@@ -85,6 +85,10 @@ func (c *IOCheck) nixIO() error {
 	delta := float64(now - c.ts)
 	deltaSecond := delta / 1000
 
+	// IOKit doesn't expose merged requests, the queue size nor the busy time
+	// of the disks on macOS, skip the metrics computed from them
+	darwin := runtimeOS == "darwin"
+
 	for device, ioStats := range iomap {
 		if c.blacklist != nil && c.blacklist.MatchString(device) {
 			continue
@@ -100,8 +104,10 @@ func (c *IOCheck) nixIO() error {
 
 		sender.Rate("system.io.r_s", float64(ioStats.ReadCount), "", tags)
 		sender.Rate("system.io.w_s", float64(ioStats.WriteCount), "", tags)
-		sender.Rate("system.io.rrqm_s", float64(ioStats.MergedReadCount), "", tags)
-		sender.Rate("system.io.wrqm_s", float64(ioStats.MergedWriteCount), "", tags)
+		if !darwin {
+			sender.Rate("system.io.rrqm_s", float64(ioStats.MergedReadCount), "", tags)
+			sender.Rate("system.io.wrqm_s", float64(ioStats.MergedWriteCount), "", tags)
+		}
 
 		if c.ts == 0 {
 			continue
@@ -163,6 +169,9 @@ func (c *IOCheck) nixIO() error {
 		sender.Gauge("system.io.await", roundFloat(aWait), "", tags)
 		sender.Gauge("system.io.r_await", roundFloat(rAwait), "", tags)
 		sender.Gauge("system.io.w_await", roundFloat(wAwait), "", tags)
+		if darwin {
+			continue
+		}
 		sender.Gauge("system.io.avg_q_sz", roundFloat(avgqusz), "", tags)
 		sender.Gauge("system.io.svctm", roundFloat(svctime), "", tags)
 
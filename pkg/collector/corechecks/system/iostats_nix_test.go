@@ -9,6 +9,7 @@ package system
 
 import (
 	"math"
+	"runtime"
 	"testing"
 	"time"
 
@@ -81,7 +82,7 @@ func TestIncrementWithOverflow(t *testing.T) {
 }
 
 func TestIoStatsOverflow(t *testing.T) {
-
+	runtimeOS = "linux"
 	ioCheck := new(IOCheck)
 	ioCheck.Configure(nil, nil, "test")
 	ioCheck.stats = lastStats
@@ -115,3 +116,37 @@ func TestIoStatsOverflow(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func TestIoStatsDarwin(t *testing.T) {
+	runtimeOS = "darwin"
+	defer func() { runtimeOS = runtime.GOOS }()
+
+	ioCheck := new(IOCheck)
+	ioCheck.Configure(nil, nil, "test")
+	ioCheck.stats = lastStats
+	ioCheck.ts = 1000
+	ioCounters = func(names ...string) (map[string]disk.IOCountersStat, error) {
+		return currentStats, nil
+	}
+
+	mock := mocksender.NewMockSender(ioCheck.ID())
+
+	mock.On("Rate", "system.io.r_s", 41.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Rate", "system.io.w_s", 41.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.rkb_s", 42.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.wkb_s", 42.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.avg_rq_sz", 2.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.await", 1.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.r_await", 1.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Gauge", "system.io.w_await", 1.0, "", []string{"device:sda", "device_name:sda"}).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+
+	nowNano = func() int64 { return 2000 * 1000000 }
+	defer func() { nowNano = time.Now().UnixNano }()
+
+	ioCheck.Run()
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Rate", 2)
+	mock.AssertNumberOfCalls(t, "Gauge", 6)
+}
@@ -56,6 +56,11 @@ func (c *MemoryCheck) Run() error {
 			if e != nil {
 				return e
 			}
+		case "darwin":
+			e := c.darwinSpecificVirtualMemoryCheck(v)
+			if e != nil {
+				return e
+			}
 		}
 	} else {
 		log.Errorf("system.MemoryCheck: could not retrieve virtual memory stats: %s", errVirt)
@@ -105,3 +110,15 @@ func (c *MemoryCheck) freebsdSpecificVirtualMemoryCheck(v *mem.VirtualMemoryStat
 	sender.Gauge("system.mem.cached", float64(v.Cached)/mbSize, "", nil)
 	return nil
 }
+
+func (c *MemoryCheck) darwinSpecificVirtualMemoryCheck(v *mem.VirtualMemoryStat) error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	sender.Gauge("system.mem.active", float64(v.Active)/mbSize, "", nil)
+	sender.Gauge("system.mem.inactive", float64(v.Inactive)/mbSize, "", nil)
+	sender.Gauge("system.mem.wired", float64(v.Wired)/mbSize, "", nil)
+	return nil
+}
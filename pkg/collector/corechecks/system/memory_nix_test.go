@@ -130,6 +130,9 @@ func TestMemoryCheckDarwin(t *testing.T) {
 	mock.On("Gauge", "system.mem.used", 791363890/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.mem.usable", 234567890.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.mem.pct_usable", 0.019000016207304602, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "system.mem.active", 2506516070400.0/mbSize, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "system.mem.inactive", 970587111424.0/mbSize, "", []string(nil)).Return().Times(1)
+	mock.On("Gauge", "system.mem.wired", 0.0, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.swap.total", 100000.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.swap.free", 60000.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.swap.used", 40000.0/mbSize, "", []string(nil)).Return().Times(1)
@@ -139,7 +142,7 @@ func TestMemoryCheckDarwin(t *testing.T) {
 	require.Nil(t, err)
 
 	mock.AssertExpectations(t)
-	mock.AssertNumberOfCalls(t, "Gauge", 9)
+	mock.AssertNumberOfCalls(t, "Gauge", 12)
 	mock.AssertNumberOfCalls(t, "Commit", 1)
 }
 
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+const windowsCountersCheckName = "windows_counters"
+
+type windowsCountersInstanceConfig struct {
+	Counters []pdhutil.CounterConfig `yaml:"counters"`
+}
+
+// windowsCountersCheck collects the performance counters declared in its
+// instance configuration
+type windowsCountersCheck struct {
+	core.CheckBase
+	counters []*pdhutil.ConfiguredCounter
+}
+
+// Run executes the check
+func (c *windowsCountersCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	for _, counter := range c.counters {
+		values, err := counter.GetValues()
+		if err != nil {
+			c.Warnf("Error getting the values of %s: %v", counter.Config.Metric, err)
+			continue
+		}
+		for instance, val := range values {
+			var tags []string
+			if instance != "" {
+				tags = []string{fmt.Sprintf("%s:%s", counter.Config.InstanceTag, instance)}
+			}
+			switch counter.Config.Type {
+			case pdhutil.MetricTypeRate:
+				sender.Rate(counter.Config.Metric, val, "", tags)
+			case pdhutil.MetricTypeCount:
+				sender.Count(counter.Config.Metric, val, "", tags)
+			case pdhutil.MetricTypeMonotonicCount:
+				sender.MonotonicCount(counter.Config.Metric, val, "", tags)
+			default:
+				sender.Gauge(counter.Config.Metric, val, "", tags)
+			}
+		}
+	}
+	sender.Commit()
+
+	return nil
+}
+
+// Configure opens the counters of the instance. A counter that can't be
+// opened is skipped with a warning, the check failing if none can be.
+func (c *windowsCountersCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	var conf windowsCountersInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	if len(conf.Counters) == 0 {
+		return fmt.Errorf("no counters configured")
+	}
+
+	for _, counterConf := range conf.Counters {
+		counter, err := pdhutil.NewConfiguredCounter(counterConf)
+		if err != nil {
+			log.Warnf("Skipping counter: %v", err)
+			continue
+		}
+		c.counters = append(c.counters, counter)
+	}
+	if len(c.counters) == 0 {
+		return fmt.Errorf("none of the configured counters could be opened")
+	}
+	return nil
+}
+
+// Stop closes the counters
+func (c *windowsCountersCheck) Stop() {
+	for _, counter := range c.counters {
+		counter.Close()
+	}
+}
+
+func windowsCountersFactory() check.Check {
+	return &windowsCountersCheck{
+		CheckBase: core.NewCheckBase(windowsCountersCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(windowsCountersCheckName, windowsCountersFactory)
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	pdhtest "github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+var windowsCountersConfig = []byte(`
+counters:
+  - class: System
+    counter: Processes
+    metric: test.processes
+  - class: LogicalDisk
+    counter: Disk Read Bytes/sec
+    instances: ["*"]
+    exclude_instances: ["_total"]
+    metric: test.disk.read_bytes
+    type: rate
+    instance_tag: device
+    scale: 0.5
+  - class: LogicalDisk
+    counter: Current Disk Queue Length
+    instances: ["Harddisk*"]
+    metric: test.disk.queue_length
+    inverse: 10
+`)
+
+func TestWindowsCountersCheck(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.SetQueryReturnValue("\\\\.\\System\\Processes", 42)
+	addDefaultQueryReturnValues()
+
+	countersCheck := windowsCountersFactory().(*windowsCountersCheck)
+	err := countersCheck.Configure(windowsCountersConfig, nil, "test")
+	require.NoError(t, err)
+	require.Len(t, countersCheck.counters, 3)
+
+	queueLength := 5.333
+	mock := mocksender.NewMockSender(countersCheck.ID())
+	mock.On("Gauge", "test.processes", 42.0, "", []string(nil)).Return().Times(1)
+	mock.On("Rate", "test.disk.read_bytes", 3.222*0.5, "", []string{"device:C:"}).Return().Times(1)
+	mock.On("Rate", "test.disk.read_bytes", 3.333*0.5, "", []string{"device:HarddiskVolume1"}).Return().Times(1)
+	mock.On("Gauge", "test.disk.queue_length", 10-queueLength, "", []string{"instance:HarddiskVolume1"}).Return().Times(1)
+	mock.On("Commit").Return().Times(1)
+
+	err = countersCheck.Run()
+	require.NoError(t, err)
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Gauge", 2)
+	mock.AssertNumberOfCalls(t, "Rate", 2)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestWindowsCountersCheckInvalidConfig(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+
+	for name, conf := range map[string]string{
+		"no counters":   `counters: []`,
+		"no metric":     "counters:\n  - class: System\n    counter: Processes\n",
+		"unknown type":  "counters:\n  - class: System\n    counter: Processes\n    metric: test.processes\n    type: histogram\n",
+		"bad wildcard":  "counters:\n  - class: LogicalDisk\n    counter: Disk Reads/sec\n    metric: test.reads\n    instances: [\"[\"]\n",
+		"unknown class": "counters:\n  - class: NotAClass\n    counter: Processes\n    metric: test.processes\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			countersCheck := windowsCountersFactory()
+			require.Error(t, countersCheck.Configure([]byte(conf), nil, "test"))
+		})
+	}
+}
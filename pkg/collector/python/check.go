@@ -220,6 +220,16 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 		}
 	}
 
+	// Limit the number of metrics submitted per run if specified
+	if commonOptions.MaxMetricsPerRun > 0 {
+		s, err := aggregator.GetSender(c.id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
+		} else {
+			s.SetMaxMetricsPerRun(commonOptions.MaxMetricsPerRun)
+		}
+	}
+
 	cInitConfig := TrackedCString(string(initConfig))
 	cInstance := TrackedCString(string(data))
 	cCheckID := TrackedCString(string(c.id))
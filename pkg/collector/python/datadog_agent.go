@@ -8,8 +8,10 @@
 package python
 
 import (
+	"sync"
 	"unsafe"
 
+	traceconfig "github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/obfuscate"
 	yaml "gopkg.in/yaml.v2"
 
@@ -187,14 +189,33 @@ func ReadPersistentCache(key *C.char) *C.char {
 	return TrackedCString(data)
 }
 
-var obfuscator = obfuscate.NewObfuscator(nil)
+var (
+	obfuscator     *obfuscate.Obfuscator
+	obfuscatorOnce sync.Once
+)
+
+// lazyInitObfuscator creates the SQL obfuscator, using the obfuscation file shared
+// with the trace-agent when set.
+func lazyInitObfuscator() *obfuscate.Obfuscator {
+	obfuscatorOnce.Do(func() {
+		var cfg *traceconfig.ObfuscationConfig
+		if path := config.Datadog.GetString("apm_config.obfuscation_file"); path != "" {
+			var err error
+			if cfg, err = traceconfig.LoadObfuscationConfig(path); err != nil {
+				log.Errorf("Error reading obfuscation file: %v", err)
+			}
+		}
+		obfuscator = obfuscate.NewObfuscator(cfg)
+	})
+	return obfuscator
+}
 
 // ObfuscateSQL obfuscates & normalizes the provided SQL query, writing the error into errResult if the operation
 // fails
 //export ObfuscateSQL
 func ObfuscateSQL(rawQuery *C.char, errResult **C.char) *C.char {
 	s := C.GoString(rawQuery)
-	obfuscatedQuery, err := obfuscator.ObfuscateSQLString(s)
+	obfuscatedQuery, err := lazyInitObfuscator().ObfuscateSQLString(s)
 	if err != nil {
 		// memory will be freed by caller
 		*errResult = TrackedCString(err.Error())
@@ -110,12 +110,14 @@ void SubmitMetric(char *, metric_type_t, char *, float, char **, int, char *);
 void SubmitServiceCheck(char *, char *, int, char **, int, char *, char *);
 void SubmitEvent(char *, event_t *, int);
 void SubmitHistogramBucket(char *, char *, long long, float, float, int, char *, char **);
+void SubmitEventPlatformEvent(char *, char *, char *);
 
 void initAggregatorModule(rtloader_t *rtloader) {
 	set_submit_metric_cb(rtloader, SubmitMetric);
 	set_submit_service_check_cb(rtloader, SubmitServiceCheck);
 	set_submit_event_cb(rtloader, SubmitEvent);
 	set_submit_histogram_bucket_cb(rtloader, SubmitHistogramBucket);
+	set_submit_event_platform_event_cb(rtloader, SubmitEventPlatformEvent);
 }
 
 //
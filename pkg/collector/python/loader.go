@@ -204,6 +204,11 @@ func (cl *PythonCheckLoader) Load(config integration.Config, instance integratio
 	return c, nil
 }
 
+// Name returns the name selecting this loader in a check configuration
+func (cl *PythonCheckLoader) Name() string {
+	return "python"
+}
+
 func (cl *PythonCheckLoader) String() string {
 	return "Python Check Loader"
 }
@@ -137,7 +137,7 @@ func (s *CheckScheduler) getChecks(config integration.Config) ([]check.Check, er
 	for _, instance := range config.Instances {
 		errors := []string{}
 
-		for _, loader := range s.loaders {
+		for _, loader := range s.loadersForInstance(config, instance) {
 			c, err := loader.Load(config, instance)
 			if err == nil {
 				log.Debugf("%v: successfully loaded check '%s'", loader, config.Name)
@@ -162,6 +162,33 @@ func (s *CheckScheduler) getChecks(config integration.Config) ([]check.Check, er
 	return checks, nil
 }
 
+// loadersForInstance returns the loaders in the order they should be tried
+// for the instance: the loader selected by its `loader` option first, if any,
+// then the others so that the check falls back on them if it fails to load.
+func (s *CheckScheduler) loadersForInstance(config integration.Config, instance integration.Data) []check.Loader {
+	name := config.GetLoaderForInstance(instance)
+	if name == "" {
+		return s.loaders
+	}
+
+	ordered := make([]check.Loader, 0, len(s.loaders))
+	for _, loader := range s.loaders {
+		if named, ok := loader.(check.NamedLoader); ok && named.Name() == name {
+			ordered = append(ordered, loader)
+		}
+	}
+	if len(ordered) == 0 {
+		log.Warnf("Unknown loader %q selected for check %s, using the default loaders", name, config.Name)
+		return s.loaders
+	}
+	for _, loader := range s.loaders {
+		if named, ok := loader.(check.NamedLoader); !ok || named.Name() != name {
+			ordered = append(ordered, loader)
+		}
+	}
+	return ordered
+}
+
 // GetChecksByNameForConfigs returns checks matching name for passed in configs
 func GetChecksByNameForConfigs(checkName string, configs []integration.Config) []check.Check {
 	var checks []check.Check
@@ -199,6 +199,9 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 			if !s.IsCheckScheduled(check.ID()) {
 				continue
 			}
+			if s.pressure != nil && s.pressure.shouldDefer(check) {
+				continue
+			}
 
 			select {
 			// blocking, we'll be here as long as it takes
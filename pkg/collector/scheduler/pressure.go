@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package scheduler
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// probeInterval is the minimum time between two probes of the host pressure,
+// the job queues asking for it every second
+const probeInterval = time.Second
+
+var (
+	schedulerChecksDeferred = expvar.Int{}
+
+	tlmChecksDeferred = telemetry.NewCounter("scheduler", "checks_deferred",
+		[]string{"check_name"}, "How many check runs were deferred because of the host pressure")
+)
+
+func init() {
+	schedulerExpvars.Set("ChecksDeferred", &schedulerChecksDeferred)
+}
+
+// hostPressure holds the CPU usage and the highest pressure stall
+// information of the host, in percent
+type hostPressure struct {
+	cpu float64
+	psi float64
+}
+
+// PressurePolicy defers the runs of the low priority checks while the host
+// is under pressure, so the agent backs off when the node itself is in
+// trouble. A check isn't deferred more than maxDeferrals times in a row.
+type PressurePolicy struct {
+	cpuThreshold float64
+	psiThreshold float64
+	lowPriority  map[string]bool
+	maxDeferrals int
+	probe        func() (hostPressure, error)
+
+	mu        sync.Mutex
+	pressure  hostPressure
+	lastProbe time.Time
+	deferrals map[check.ID]int
+}
+
+// NewPressurePolicyFromConfig returns the policy configured with the
+// check_pressure_backoff settings, or nil when it's disabled
+func NewPressurePolicyFromConfig() *PressurePolicy {
+	if !config.Datadog.GetBool("check_pressure_backoff.enabled") {
+		return nil
+	}
+	lowPriority := make(map[string]bool)
+	for _, name := range config.Datadog.GetStringSlice("check_pressure_backoff.low_priority_checks") {
+		lowPriority[name] = true
+	}
+	return newPressurePolicy(
+		config.Datadog.GetFloat64("check_pressure_backoff.cpu_threshold"),
+		config.Datadog.GetFloat64("check_pressure_backoff.psi_threshold"),
+		lowPriority,
+		config.Datadog.GetInt("check_pressure_backoff.max_deferrals"),
+		newHostProbe(),
+	)
+}
+
+func newPressurePolicy(cpuThreshold, psiThreshold float64, lowPriority map[string]bool, maxDeferrals int, probe func() (hostPressure, error)) *PressurePolicy {
+	return &PressurePolicy{
+		cpuThreshold: cpuThreshold,
+		psiThreshold: psiThreshold,
+		lowPriority:  lowPriority,
+		maxDeferrals: maxDeferrals,
+		probe:        probe,
+		deferrals:    make(map[check.ID]int),
+	}
+}
+
+// shouldDefer returns whether the run of the check must be skipped
+func (p *PressurePolicy) shouldDefer(c check.Check) bool {
+	if !p.lowPriority[c.String()] {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.underPressure(time.Now()) {
+		delete(p.deferrals, c.ID())
+		return false
+	}
+	if p.deferrals[c.ID()] >= p.maxDeferrals {
+		log.Debugf("Running check %s despite the host pressure, it was deferred %d times in a row", c.ID(), p.deferrals[c.ID()])
+		delete(p.deferrals, c.ID())
+		return false
+	}
+
+	p.deferrals[c.ID()]++
+	log.Debugf("Deferring check %s, the host is under pressure: %.1f%% CPU used, %.1f%% stalled", c.ID(), p.pressure.cpu, p.pressure.psi)
+	schedulerChecksDeferred.Add(1)
+	if c.IsTelemetryEnabled() {
+		tlmChecksDeferred.Inc(c.String())
+	}
+	return true
+}
+
+// underPressure returns whether the host pressure exceeds the thresholds,
+// probing it at most every probeInterval. Called with the lock held.
+func (p *PressurePolicy) underPressure(now time.Time) bool {
+	if now.Sub(p.lastProbe) >= probeInterval {
+		pressure, err := p.probe()
+		if err != nil {
+			log.Debugf("Could not probe the host pressure: %v", err)
+		}
+		p.pressure = pressure
+		p.lastProbe = now
+	}
+	return (p.cpuThreshold > 0 && p.pressure.cpu >= p.cpuThreshold) ||
+		(p.psiThreshold > 0 && p.pressure.psi >= p.psiThreshold)
+}
+
+// newHostProbe returns a probe of the host pressure. The CPU usage is
+// computed since the previous probe, so the first one reports none.
+func newHostProbe() func() (hostPressure, error) {
+	var last *cpu.TimesStat
+	return func() (hostPressure, error) {
+		var pressure hostPressure
+		psi, err := readPSI()
+		if err != nil {
+			return pressure, err
+		}
+		pressure.psi = psi
+
+		times, err := cpu.Times(false)
+		if err != nil || len(times) == 0 {
+			return pressure, err
+		}
+		current := times[0]
+		if last != nil {
+			total := current.Total() - last.Total()
+			idle := (current.Idle + current.Iowait) - (last.Idle + last.Iowait)
+			if total > 0 {
+				pressure.cpu = (total - idle) / total * 100
+			}
+		}
+		last = &current
+		return pressure, nil
+	}
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// readPSI returns the highest share of time some tasks were stalled on the
+// CPU, the memory or the IO over the last 10 seconds, in percent. It's 0 on
+// kernels older than 4.20, without the pressure stall information.
+func readPSI() (float64, error) {
+	var highest float64
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		raw, err := ioutil.ReadFile(filepath.Join(config.Datadog.GetString("procfs_path"), "pressure", resource))
+		if os.IsNotExist(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if avg10 := parsePSIAvg10(string(raw)); avg10 > highest {
+			highest = avg10
+		}
+	}
+	return highest, nil
+}
+
+// parsePSIAvg10 returns the avg10 value of the "some" line of a pressure file
+func parsePSIAvg10(content string) float64 {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" || !strings.HasPrefix(fields[1], "avg10=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimPrefix(fields[1], "avg10="), 64)
+		if err == nil {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePSIAvg10(t *testing.T) {
+	assert.Equal(t, 12.5, parsePSIAvg10("some avg10=12.50 avg60=3.00 avg300=1.00 total=1000\nfull avg10=50.00 avg60=0.00 avg300=0.00 total=500\n"))
+	assert.Equal(t, 0.0, parsePSIAvg10(""))
+}
@@ -0,0 +1,13 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package scheduler
+
+// readPSI returns 0, the pressure stall information is Linux only
+func readPSI() (float64, error) {
+	return 0, nil
+}
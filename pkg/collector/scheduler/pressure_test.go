@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressurePolicy(t *testing.T) {
+	pressure := hostPressure{}
+	probes := 0
+	p := newPressurePolicy(90, 40, map[string]bool{"TestCheck": true}, 2, func() (hostPressure, error) {
+		probes++
+		return pressure, nil
+	})
+	c := &TestCheck{}
+
+	// no pressure
+	assert.False(t, p.shouldDefer(c))
+
+	// the host pressure is probed at most every second
+	pressure = hostPressure{cpu: 95}
+	assert.False(t, p.shouldDefer(c))
+	assert.Equal(t, 1, probes)
+
+	p.lastProbe = time.Time{}
+	assert.True(t, p.shouldDefer(c))
+	assert.True(t, p.shouldDefer(c))
+	// the check runs after max_deferrals deferred runs in a row
+	assert.False(t, p.shouldDefer(c))
+	assert.True(t, p.shouldDefer(c))
+
+	// pressure stall
+	p.lastProbe = time.Time{}
+	pressure = hostPressure{cpu: 10, psi: 50}
+	assert.True(t, p.shouldDefer(c))
+
+	// the other checks aren't deferred
+	p.lowPriority = map[string]bool{}
+	assert.False(t, p.shouldDefer(c))
+}
+
+func TestPressurePolicyDisabledThresholds(t *testing.T) {
+	p := newPressurePolicy(0, 0, map[string]bool{"TestCheck": true}, 2, func() (hostPressure, error) {
+		return hostPressure{cpu: 100, psi: 100}, nil
+	})
+	assert.False(t, p.shouldDefer(&TestCheck{}))
+}
@@ -46,6 +46,7 @@ type Scheduler struct {
 	started      chan bool                   // Used to internally communicate the queues are up
 	jobQueues    map[time.Duration]*jobQueue // We have one scheduling queue for every interval
 	checkToQueue map[check.ID]*jobQueue      // Keep track of what is the queue for any Check
+	pressure     *PressurePolicy             // Defers the low priority checks under host pressure, nil when disabled
 	mu           sync.Mutex                  // To protect critical sections in struct's fields
 
 	cancelOneTime chan bool      // Used to internally communicate a cancel signal to one-time schedule goroutines
@@ -67,6 +68,12 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 	}
 }
 
+// SetPressurePolicy sets the policy deferring the low priority checks while
+// the host is under pressure. Must be called before Run.
+func (s *Scheduler) SetPressurePolicy(p *PressurePolicy) {
+	s.pressure = p
+}
+
 // Enter schedules a `Check`s for execution accordingly to the `Check.Interval()` value.
 // If the interval is 0, the check is supposed to run only once.
 func (s *Scheduler) Enter(check check.Check) error {
@@ -19,6 +19,18 @@ func (l *MockLoader) Load(config integration.Config, instance integration.Data)
 	return nil, nil
 }
 
+type MockNamedLoader struct {
+	name string
+}
+
+func (l *MockNamedLoader) Load(config integration.Config, instance integration.Data) (check.Check, error) {
+	return nil, nil
+}
+
+func (l *MockNamedLoader) Name() string {
+	return l.name
+}
+
 func TestAddLoader(t *testing.T) {
 	s := CheckScheduler{}
 	assert.Len(t, s.loaders, 0)
@@ -26,3 +38,22 @@ func TestAddLoader(t *testing.T) {
 	s.AddLoader(&MockLoader{}) // noop
 	assert.Len(t, s.loaders, 1)
 }
+
+func TestLoadersForInstance(t *testing.T) {
+	mock := &MockLoader{}
+	python := &MockNamedLoader{name: "python"}
+	core := &MockNamedLoader{name: "core"}
+	s := CheckScheduler{}
+	s.AddLoader(mock)
+	s.AddLoader(python)
+	s.AddLoader(core)
+
+	config := integration.Config{Name: "foo"}
+	assert.Equal(t, []check.Loader{mock, python, core}, s.loadersForInstance(config, integration.Data("foo: bar")))
+	assert.Equal(t, []check.Loader{core, mock, python}, s.loadersForInstance(config, integration.Data("loader: core")))
+	assert.Equal(t, []check.Loader{mock, python, core}, s.loadersForInstance(config, integration.Data("loader: unknown")))
+
+	config.InitConfig = integration.Data("loader: core")
+	assert.Equal(t, []check.Loader{core, mock, python}, s.loadersForInstance(config, integration.Data("foo: bar")))
+	assert.Equal(t, []check.Loader{python, mock, core}, s.loadersForInstance(config, integration.Data("loader: python")))
+}
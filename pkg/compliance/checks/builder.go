@@ -98,6 +98,36 @@ func WithAuditClient(cli AuditClient) BuilderOption {
 	}
 }
 
+// WithKubernetes configures using the Kubernetes API server client
+func WithKubernetes() BuilderOption {
+	return func(b *builder) error {
+		cli, err := newKubeClient()
+		if err == nil {
+			b.kubeClient = cli
+		}
+		return err
+	}
+}
+
+// WithKubernetesClient configures using a specific Kubernetes client
+func WithKubernetesClient(cli KubeClient) BuilderOption {
+	return func(b *builder) error {
+		b.kubeClient = cli
+		return nil
+	}
+}
+
+// WithKubernetesCluster configures the builder to only build checks of the rules
+// evaluated once per cluster, as done by the cluster agent, reporting them for
+// the given cluster
+func WithKubernetesCluster(clusterName string) BuilderOption {
+	return func(b *builder) error {
+		b.kubernetesCluster = true
+		b.clusterName = clusterName
+		return nil
+	}
+}
+
 // SuiteMatcher checks if a compliance suite is included
 type SuiteMatcher func(*compliance.SuiteMeta) bool
 
@@ -167,9 +197,13 @@ type builder struct {
 	reporter     compliance.Reporter
 	dockerClient DockerClient
 	auditClient  AuditClient
+	kubeClient   KubeClient
 	hostname     string
 	pathMapper   pathMapper
 
+	kubernetesCluster bool
+	clusterName       string
+
 	etcGroupPath string
 
 	suiteMatcher SuiteMatcher
@@ -183,8 +217,11 @@ const (
 	checkKindDocker  = checkKind("docker")
 	checkKindAudit   = checkKind("audit")
 	checkKindGroup   = checkKind("group")
+	checkKindKube    = checkKind("kubeapiserver")
 )
 
+const scopeKubernetesCluster = "kubernetesCluster"
+
 func (b *builder) Close() error {
 	if b.dockerClient != nil {
 		if err := b.dockerClient.Close(); err != nil {
@@ -219,6 +256,12 @@ func (b *builder) ChecksFromFile(file string, onCheck compliance.CheckVisitor) e
 			continue
 		}
 
+		// Cluster rules are only evaluated by the cluster agent, which only evaluates them
+		if r.Scope.KubernetesCluster != b.kubernetesCluster {
+			log.Tracef("%s/%s: skipped rule %s from %s - out of scope", suite.Meta.Name, suite.Meta.Version, r.ID, file)
+			continue
+		}
+
 		log.Debugf("%s/%s: loading rule %s", suite.Meta.Name, suite.Meta.Version, r.ID)
 		checks, err := b.ChecksFromRule(&suite.Meta, &r)
 		if err != nil {
@@ -257,6 +300,10 @@ func (b *builder) ChecksFromRule(meta *compliance.SuiteMeta, rule *compliance.Ru
 }
 
 func (b *builder) getRuleScope(meta *compliance.SuiteMeta, rule *compliance.Rule) (string, error) {
+	if rule.Scope.KubernetesCluster {
+		return scopeKubernetesCluster, nil
+	}
+
 	if rule.Scope.Docker {
 		return "docker", nil
 	}
@@ -288,6 +335,11 @@ func (b *builder) checkFromRule(meta *compliance.SuiteMeta, ruleID string, ruleS
 		return newAuditCheck(b.baseCheck(ruleID, checkKindAudit, ruleScope, meta), b.auditClient, resource.Audit)
 	case resource.Group != nil:
 		return newGroupCheck(b.baseCheck(ruleID, checkKindGroup, ruleScope, meta), b.etcGroupPath, resource.Group)
+	case resource.KubeApiserver != nil:
+		if b.kubeClient == nil {
+			return nil, log.Errorf("%s: skipped - kubernetes client not initialized", ruleID)
+		}
+		return newKubeApiserverCheck(b.baseCheck(ruleID, checkKindKube, ruleScope, meta), b.kubeClient, resource.KubeApiserver)
 	default:
 		log.Errorf("%s: resource not supported", ruleID)
 		return nil, ErrResourceNotSupported
@@ -295,6 +347,12 @@ func (b *builder) checkFromRule(meta *compliance.SuiteMeta, ruleID string, ruleS
 }
 
 func (b *builder) baseCheck(ruleID string, kind checkKind, ruleScope string, meta *compliance.SuiteMeta) baseCheck {
+	// Cluster rules are reported for the cluster, whichever node the cluster agent runs on
+	resourceID := b.hostname
+	if ruleScope == scopeKubernetesCluster {
+		resourceID = b.clusterName
+	}
+
 	return baseCheck{
 		name:      ruleID,
 		id:        newCheckID(ruleID, kind),
@@ -307,7 +365,7 @@ func (b *builder) baseCheck(ruleID string, kind checkKind, ruleScope string, met
 
 		ruleID:       ruleID,
 		resourceType: ruleScope,
-		resourceID:   b.hostname,
+		resourceID:   resourceID,
 	}
 }
 
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ErrKubeResourceKindMissing is returned when a Kubernetes resource has no kind
+var ErrKubeResourceKindMissing = errors.New("missing kubernetes resource kind")
+
+// KubeClient abstracts Kubernetes API client
+type KubeClient interface {
+	dynamic.Interface
+}
+
+type kubeApiserverCheck struct {
+	baseCheck
+
+	client       KubeClient
+	kubeResource *compliance.KubernetesResource
+}
+
+func newKubeApiserverCheck(baseCheck baseCheck, client KubeClient, kubeResource *compliance.KubernetesResource) (*kubeApiserverCheck, error) {
+	if kubeResource.Kind == "" {
+		return nil, ErrKubeResourceKindMissing
+	}
+
+	return &kubeApiserverCheck{
+		baseCheck:    baseCheck,
+		client:       client,
+		kubeResource: kubeResource,
+	}, nil
+}
+
+func (c *kubeApiserverCheck) Run() error {
+	log.Debugf("%s: running kubeapiserver check", c.id)
+
+	version := c.kubeResource.Version
+	if version == "" {
+		version = "v1"
+	}
+	resourceDef := c.client.Resource(schema.GroupVersionResource{
+		Group:    c.kubeResource.Group,
+		Version:  version,
+		Resource: c.kubeResource.Kind,
+	})
+
+	var resourceAPI dynamic.ResourceInterface = resourceDef
+	if c.kubeResource.Namespace != "" {
+		resourceAPI = resourceDef.Namespace(c.kubeResource.Namespace)
+	}
+
+	list, err := resourceAPI.List(metav1.ListOptions{LabelSelector: c.kubeResource.LabelSelector})
+	if err != nil {
+		return log.Errorf("%s: unable to list %s: %v", c.id, c.kubeResource.Kind, err)
+	}
+
+	for _, item := range list.Items {
+		c.inspect(item)
+	}
+	return nil
+}
+
+func (c *kubeApiserverCheck) inspect(item unstructured.Unstructured) {
+	log.Debugf("%s: iterating %s[name=%s]", c.id, c.kubeResource.Kind, item.GetName())
+
+	for _, f := range c.kubeResource.Filter {
+		if f.Include != nil {
+			prop := evalTemplate(f.Include.Property, item.Object)
+			if !evalCondition(prop, f.Include) {
+				return
+			}
+		} else if f.Exclude != nil {
+			prop := evalTemplate(f.Exclude.Property, item.Object)
+			if evalCondition(prop, f.Exclude) {
+				return
+			}
+		}
+	}
+
+	kv := compliance.KVMap{}
+	for _, field := range c.kubeResource.Report {
+		if c.setStaticKV(field, kv) {
+			continue
+		}
+
+		key := field.As
+		if field.Kind == compliance.PropertyKindTemplate {
+			if key == "" {
+				log.Errorf("%s: template field without an alias key - %s", c.id, field.Property)
+				continue
+			}
+			kv[key] = evalTemplate(field.Property, item.Object)
+			continue
+		}
+
+		switch field.Property {
+		case "name":
+			if key == "" {
+				key = "name"
+			}
+			kv[key] = item.GetName()
+		case "namespace":
+			if key == "" {
+				key = "namespace"
+			}
+			kv[key] = item.GetNamespace()
+		}
+	}
+
+	c.report(nil, kv, "%s[name=%s]", c.kubeResource.Kind, item.GetName())
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+)
+
+// fakeKubeClient lists objects by resource, only implementing the List calls
+type fakeKubeClient struct {
+	dynamic.Interface
+	objects map[schema.GroupVersionResource][]unstructured.Unstructured
+}
+
+func (c *fakeKubeClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeKubeResource{client: c, resource: resource}
+}
+
+type fakeKubeResource struct {
+	dynamic.NamespaceableResourceInterface
+	client    *fakeKubeClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+func (r *fakeKubeResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &fakeKubeResource{client: r.client, resource: r.resource, namespace: namespace}
+}
+
+func (r *fakeKubeResource) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	for _, obj := range r.client.objects[r.resource] {
+		if r.namespace == "" || obj.GetNamespace() == r.namespace {
+			list.Items = append(list.Items, obj)
+		}
+	}
+	return list, nil
+}
+
+func TestKubeApiserverCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	clusterRoleBindings := schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	client := &fakeKubeClient{
+		objects: map[schema.GroupVersionResource][]unstructured.Unstructured{
+			clusterRoleBindings: {
+				{Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "cluster-admin"},
+					"roleRef":  map[string]interface{}{"name": "cluster-admin"},
+					"subjects": []interface{}{map[string]interface{}{"kind": "Group", "name": "system:masters"}},
+				}},
+				{Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "ci-admin"},
+					"roleRef":  map[string]interface{}{"name": "cluster-admin"},
+					"subjects": []interface{}{map[string]interface{}{"kind": "ServiceAccount", "name": "ci"}},
+				}},
+				{Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "view"},
+					"roleRef":  map[string]interface{}{"name": "view"},
+				}},
+			},
+			pods: {
+				{Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "kube-apiserver-master", "namespace": "kube-system"},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{
+							"command": []interface{}{"kube-apiserver", "--anonymous-auth=false", "--profiling=false"},
+						}},
+					},
+				}},
+				{Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "kube-apiserver-test", "namespace": "test"},
+				}},
+			},
+		},
+	}
+
+	reporter := &mocks.Reporter{}
+	defer reporter.AssertExpectations(t)
+
+	// Reports the bindings granting cluster-admin to other subjects than system:masters
+	rbacCheck, err := newKubeApiserverCheck(newTestBaseCheck(reporter, checkKindKube), client, &compliance.KubernetesResource{
+		Kind:    "clusterrolebindings",
+		Group:   "rbac.authorization.k8s.io",
+		Version: "v1",
+		Filter: []compliance.Filter{
+			{
+				Include: &compliance.Condition{
+					Property:  `{{- $.roleRef.name -}}`,
+					Operation: compliance.OpEqual,
+					Value:     "cluster-admin",
+				},
+			},
+			{
+				Exclude: &compliance.Condition{
+					Property:  `{{- (index $.subjects 0).name -}}`,
+					Operation: compliance.OpEqual,
+					Value:     "system:masters",
+				},
+			},
+		},
+		Report: compliance.Report{
+			{
+				Property: "name",
+				As:       "binding",
+			},
+			{
+				Property: `{{- (index $.subjects 0).kind -}}`,
+				Kind:     compliance.PropertyKindTemplate,
+				As:       "subject_kind",
+			},
+		},
+	})
+	assert.NoError(err)
+
+	reporter.On(
+		"Report",
+		newTestRuleEvent(
+			[]string{"check_kind:kubeapiserver"},
+			compliance.KVMap{
+				"binding":      "ci-admin",
+				"subject_kind": "ServiceAccount",
+			},
+		),
+	).Once()
+	assert.NoError(rbacCheck.Run())
+
+	// Reports the flags of the API server
+	flagsCheck, err := newKubeApiserverCheck(newTestBaseCheck(reporter, checkKindKube), client, &compliance.KubernetesResource{
+		Kind:      "pods",
+		Namespace: "kube-system",
+		Report: compliance.Report{
+			{
+				Property: "name",
+			},
+			{
+				Property: `{{- has "--anonymous-auth=false" (index $.spec.containers 0).command -}}`,
+				Kind:     compliance.PropertyKindTemplate,
+				As:       "anonymous_auth_disabled",
+			},
+		},
+	})
+	assert.NoError(err)
+
+	reporter.On(
+		"Report",
+		newTestRuleEvent(
+			[]string{"check_kind:kubeapiserver"},
+			compliance.KVMap{
+				"name":                    "kube-apiserver-master",
+				"anonymous_auth_disabled": "true",
+			},
+		),
+	).Once()
+	assert.NoError(flagsCheck.Run())
+
+	_, err = newKubeApiserverCheck(newTestBaseCheck(reporter, checkKindKube), client, &compliance.KubernetesResource{})
+	assert.Equal(ErrKubeResourceKindMissing, err)
+}
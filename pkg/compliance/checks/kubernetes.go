@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package checks
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+func newKubeClient() (KubeClient, error) {
+	apiCl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return apiCl.DynamicCl, nil
+}
@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !kubeapiserver
+
+package checks
+
+import "errors"
+
+func newKubeClient() (KubeClient, error) {
+	return nil, errors.New("kubernetes client requires kubeapiserver build flag")
+}
@@ -15,6 +15,8 @@ type Resource struct {
 	Command *Command        `yaml:"command,omitempty"`
 	Audit   *Audit          `yaml:"audit,omitempty"`
 	Docker  *DockerResource `yaml:"docker,omitempty"`
+
+	KubeApiserver *KubernetesResource `yaml:"kubeApiserver,omitempty"`
 }
 
 // File describes a file resource
@@ -100,6 +102,20 @@ type DockerResource struct {
 	Report Report `yaml:"report,omitempty"`
 }
 
+// KubernetesResource describes objects queried from the Kubernetes API server
+type KubernetesResource struct {
+	// Kind is the plural name of the resource, e.g. clusterrolebindings
+	Kind          string `yaml:"kind"`
+	Group         string `yaml:"group,omitempty"`
+	Version       string `yaml:"version,omitempty"`
+	Namespace     string `yaml:"namespace,omitempty"`
+	LabelSelector string `yaml:"labelSelector,omitempty"`
+
+	Filter []Filter `yaml:"filter,omitempty"`
+
+	Report Report `yaml:"report,omitempty"`
+}
+
 // ValueFrom provides a lookup list for substitution of a value in a Resource
 type ValueFrom []ValueSource
 
@@ -17,6 +17,8 @@ type Rule struct {
 type Scope struct {
 	Docker     bool               `yaml:"docker"`
 	Kubernetes []KubeNodeSelector `yaml:"kubernetes,omitempty"`
+	// KubernetesCluster rules are evaluated once per cluster by the cluster agent
+	KubernetesCluster bool `yaml:"kubernetesCluster"`
 }
 
 // KubeNodeSelector defines selector for a Kubernetes node
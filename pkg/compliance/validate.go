@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compliance
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ValidateSuite parses a compliance suite strictly and returns the problems
+// found: syntax errors, unknown fields and rules without an id
+func ValidateSuite(config string) []error {
+	f, err := ioutil.ReadFile(config)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	s := &Suite{}
+	if err := yaml.UnmarshalStrict(f, s); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return []error{err}
+		}
+		// The rest of the document is still decoded, report each unknown
+		// field or mismatched type on its own and keep checking
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	for i, r := range s.Rules {
+		if r.ID == "" {
+			errs = append(errs, fmt.Errorf("rule #%d has no id", i+1))
+		}
+	}
+	return errs
+}
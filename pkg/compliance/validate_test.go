@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compliance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSuite(t *testing.T) {
+	assert.Empty(t, ValidateSuite("./testdata/cis-docker.yaml"))
+
+	errs := ValidateSuite("./testdata/invalid.yaml")
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "field dockr not found")
+	assert.EqualError(t, errs[1], "rule #2 has no id")
+
+	assert.Len(t, ValidateSuite("./testdata/missing.yaml"), 1)
+}
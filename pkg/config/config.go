@@ -7,6 +7,7 @@ package config
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/url"
@@ -110,21 +111,30 @@ type Proxy struct {
 	HTTP    string   `mapstructure:"http"`
 	HTTPS   string   `mapstructure:"https"`
 	NoProxy []string `mapstructure:"no_proxy"`
+	// Endpoints override the proxy of the matching hosts
+	Endpoints []ProxyEndpoint `mapstructure:"endpoints"`
+}
+
+// ProxyEndpoint sets the proxy used for the hosts matching Host, which
+// supports the same notations as no_proxy. An empty URL means no proxy.
+type ProxyEndpoint struct {
+	Host string `mapstructure:"host"`
+	URL  string `mapstructure:"url"`
 }
 
 // MappingProfile represent a group of mappings
 type MappingProfile struct {
-	Name     string          `mapstructure:"name"`
-	Prefix   string          `mapstructure:"prefix"`
-	Mappings []MetricMapping `mapstructure:"mappings"`
+	Name     string          `mapstructure:"name" json:"name"`
+	Prefix   string          `mapstructure:"prefix" json:"prefix"`
+	Mappings []MetricMapping `mapstructure:"mappings" json:"mappings"`
 }
 
 // MetricMapping represent one mapping rule
 type MetricMapping struct {
-	Match     string            `mapstructure:"match"`
-	MatchType string            `mapstructure:"match_type"`
-	Name      string            `mapstructure:"name"`
-	Tags      map[string]string `mapstructure:"tags"`
+	Match     string            `mapstructure:"match" json:"match"`
+	MatchType string            `mapstructure:"match_type" json:"match_type"`
+	Name      string            `mapstructure:"name" json:"name"`
+	Tags      map[string]string `mapstructure:"tags" json:"tags"`
 }
 
 func init() {
@@ -147,6 +157,8 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("skip_ssl_validation", false)
 	config.BindEnvAndSetDefault("hostname", "")
 	config.BindEnvAndSetDefault("tags", []string{})
+	config.BindEnvAndSetDefault("tags_file", "")
+	config.BindEnvAndSetDefault("tags_file_check_interval", 60)
 	config.BindEnv("env") //nolint:errcheck
 	config.BindEnvAndSetDefault("tag_value_split_separator", map[string]string{})
 	config.BindEnvAndSetDefault("conf_path", ".")
@@ -156,13 +168,24 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("log_file", "")
 	config.BindEnvAndSetDefault("log_file_max_size", "10Mb")
 	config.BindEnvAndSetDefault("log_file_max_rolls", 1)
+	config.BindEnvAndSetDefault("log_file_max_age", 0)
+	config.BindEnvAndSetDefault("log_file_compress", false)
 	config.BindEnvAndSetDefault("log_level", "info")
+	config.BindEnvAndSetDefault("log_level_overrides", map[string]string{})
 	config.BindEnvAndSetDefault("log_to_syslog", false)
 	config.BindEnvAndSetDefault("log_to_console", true)
 	config.BindEnvAndSetDefault("logging_frequency", int64(500))
 	config.BindEnvAndSetDefault("disable_file_logging", false)
 	config.BindEnvAndSetDefault("syslog_uri", "")
 	config.BindEnvAndSetDefault("syslog_rfc", false)
+	config.BindEnvAndSetDefault("fips.enabled", false)
+	config.BindEnvAndSetDefault("ipc_audit.enabled", true)
+	config.BindEnvAndSetDefault("ipc_audit.file", "")
+	config.BindEnvAndSetDefault("ipc_audit.max_size", "10Mb")
+	config.BindEnvAndSetDefault("crash_reporting.enabled", true)
+	config.BindEnvAndSetDefault("crash_reporting.spool_dir", "")
+	config.BindEnvAndSetDefault("crash_reporting.max_reports", 10)
+	config.BindEnvAndSetDefault("crash_reporting.submit_telemetry", false)
 	config.BindEnvAndSetDefault("syslog_pem", "")
 	config.BindEnvAndSetDefault("syslog_key", "")
 	config.BindEnvAndSetDefault("syslog_tls_verify", true)
@@ -173,10 +196,17 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
+	config.BindEnvAndSetDefault("check_state_max_size", 65536)
+	config.BindEnvAndSetDefault("check_pressure_backoff.enabled", false)
+	config.BindEnvAndSetDefault("check_pressure_backoff.cpu_threshold", 90.0)
+	config.BindEnvAndSetDefault("check_pressure_backoff.psi_threshold", 40.0)
+	config.BindEnvAndSetDefault("check_pressure_backoff.low_priority_checks", []string{})
+	config.BindEnvAndSetDefault("check_pressure_backoff.max_deferrals", 10)
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	config.BindEnvAndSetDefault("bind_host", "localhost")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
 	config.BindEnvAndSetDefault("health_port", int64(0))
+	config.BindEnvAndSetDefault("local_api_socket", "")
 	config.BindEnvAndSetDefault("disable_py3_validation", false)
 	config.BindEnvAndSetDefault("python_version", DefaultPython)
 	config.BindEnvAndSetDefault("iot_host", AgentFlavor == IotAgentFlavor)
@@ -275,6 +305,7 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
+	config.BindEnvAndSetDefault("shutdown_drain_timeout", 0)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
 	// Serializer
 	config.BindEnvAndSetDefault("enable_stream_payload_serialization", true)
@@ -302,6 +333,30 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_apikey_validation_interval", DefaultAPIKeyValidationInterval) // in minutes
 	config.BindEnvAndSetDefault("forwarder_num_workers", 1)
 	config.BindEnvAndSetDefault("forwarder_stop_timeout", 2)
+
+	// Autotune: adjust GOMAXPROCS and the buffer sizes to the cgroup limits
+	config.BindEnvAndSetDefault("autotune.enabled", false)
+	config.BindEnvAndSetDefault("autotune.memory_ballast_ratio", 0.1)
+
+	// Memory pressure: shed load when the RSS approaches a limit
+	config.BindEnvAndSetDefault("memory_pressure.enabled", false)
+	config.BindEnvAndSetDefault("memory_pressure.rss_limit", 0) // in bytes, 0 uses the cgroup memory limit
+	config.BindEnvAndSetDefault("memory_pressure.soft_threshold", 0.8)
+	config.BindEnvAndSetDefault("memory_pressure.hard_threshold", 0.9)
+	config.BindEnvAndSetDefault("memory_pressure.check_interval", 5) // in seconds
+
+	// Payload audit
+	config.BindEnvAndSetDefault("payload_audit.enabled", false)
+	config.BindEnvAndSetDefault("payload_audit.directory", "") // empty uses <run_path>/payload_audit
+	config.BindEnvAndSetDefault("payload_audit.duration", 600) // in seconds
+	config.BindEnvAndSetDefault("payload_audit.max_size", 100*1024*1024)
+
+	// Event platform
+	config.BindEnvAndSetDefault("event_platform.batch_wait", 5)
+	config.BindEnvAndSetDefault("event_platform.batch_max_size", 100)
+	config.BindEnvAndSetDefault("event_platform.batch_max_content_size", 1000000)
+	config.BindEnvAndSetDefault("event_platform.input_chan_size", 1000)
+
 	// Forwarder retry settings
 	config.BindEnvAndSetDefault("forwarder_backoff_factor", 2)
 	config.BindEnvAndSetDefault("forwarder_backoff_base", 2)
@@ -339,7 +394,9 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_entity_id_precedence", false)
 	// Sends Dogstatsd parse errors to the Debug level instead of the Error level
 	config.BindEnvAndSetDefault("dogstatsd_disable_verbose_logs", false)
+	// Set as a JSON list through DD_DOGSTATSD_MAPPER_PROFILES, e.g. in a dogstatsd sidecar
 	config.SetKnown("dogstatsd_mapper_profiles")
+	config.BindEnv("dogstatsd_mapper_profiles") //nolint:errcheck
 
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
@@ -367,6 +424,8 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("kubernetes_pod_labels_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("kubernetes_pod_annotations_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("kubernetes_node_labels_as_tags", map[string]string{})
+	config.BindEnvAndSetDefault("kubernetes_namespace_labels_as_tags", map[string]string{})
+	config.BindEnvAndSetDefault("kubernetes_namespace_annotations_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("container_cgroup_prefix", "")
 
 	// CRI
@@ -408,6 +467,26 @@ func initConfig(config Config) {
 	config.SetKnown("snmp_listener.allowed_failures")
 	config.SetKnown("snmp_listener.workers")
 	config.SetKnown("snmp_listener.configs")
+	config.BindEnvAndSetDefault("snmp_profiles.user_directories", []string{})
+	config.BindEnvAndSetDefault("snmp_traps.enabled", false)
+	config.BindEnvAndSetDefault("snmp_traps.port", 162)
+	config.BindEnvAndSetDefault("snmp_traps.bind_host", "0.0.0.0")
+	config.BindEnvAndSetDefault("snmp_traps.community_strings", []string{})
+	config.BindEnvAndSetDefault("snmp_traps.stop_timeout", 5) // in seconds
+	config.BindEnvAndSetDefault("snmp_traps.mib_files", []string{})
+	config.SetKnown("snmp_traps.users")
+	config.SetKnown("snmp_traps.device_profiles")
+
+	// NetFlow
+	config.BindEnvAndSetDefault("netflow.enabled", false)
+	config.BindEnvAndSetDefault("netflow.aggregator_flush_interval", 10) // in seconds
+	config.BindEnvAndSetDefault("netflow.aggregator_max_flows", 10000)
+	config.SetKnown("netflow.listeners")
+
+	// systemd listener
+	config.BindEnvAndSetDefault("systemd_listener.include_units", []string{})
+	config.BindEnvAndSetDefault("systemd_listener.polling_interval", 10) // in seconds
+	config.BindEnvAndSetDefault("systemd_listener.private_socket", "")
 
 	// Kube ApiServer
 	config.BindEnvAndSetDefault("kubernetes_kubeconfig_path", "")
@@ -419,6 +498,10 @@ func initConfig(config Config) {
 	// Datadog cluster agent
 	config.BindEnvAndSetDefault("cluster_agent.enabled", false)
 	config.BindEnvAndSetDefault("cluster_agent.auth_token", "")
+	config.BindEnvAndSetDefault("cluster_agent.mtls.mode", "disabled")
+	config.BindEnvAndSetDefault("cluster_agent.mtls.cert_file", "")
+	config.BindEnvAndSetDefault("cluster_agent.mtls.key_file", "")
+	config.BindEnvAndSetDefault("cluster_agent.mtls.ca_file", "")
 	config.BindEnvAndSetDefault("cluster_agent.url", "")
 	config.BindEnvAndSetDefault("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
 	config.BindEnvAndSetDefault("cluster_agent.tagging_fallback", false)
@@ -443,6 +526,12 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("collect_gce_tags", true)
 	config.BindEnvAndSetDefault("exclude_gce_tags", []string{"kube-env", "kubelet-config", "containerd-configure-sh", "startup-script", "shutdown-script", "configure-sh", "sshKeys", "ssh-keys", "user-data", "cli-cert", "ipsec-cert", "ssl-cert", "google-container-manifest", "bosh_settings", "windows-startup-script-ps1", "common-psm1", "k8s-node-setup-psm1", "serial-port-logging-enable", "enable-oslogin", "disable-address-manager", "disable-legacy-endpoints", "windows-keys"})
 	config.BindEnvAndSetDefault("gce_metadata_timeout", 1000) // value in milliseconds
+	config.BindEnvAndSetDefault("collect_gce_labels", false)
+
+	// Azure
+	config.BindEnvAndSetDefault("collect_azure_tags", false)
+
+	config.BindEnvAndSetDefault("cloud_provider_tags_refresh_interval", 1800) // in seconds
 
 	// Cloud Foundry
 	config.BindEnvAndSetDefault("cloud_foundry", false)
@@ -471,6 +560,7 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("jmx_collection_timeout", 60)
 	config.BindEnvAndSetDefault("jmx_check_period", int(defaults.DefaultCheckInterval/time.Millisecond))
 	config.BindEnvAndSetDefault("jmx_reconnection_timeout", 10)
+	config.BindEnvAndSetDefault("jmx_isolated_processes", false)
 
 	// Go_expvar server port
 	config.BindEnvAndSetDefault("expvar_port", "5000")
@@ -504,7 +594,7 @@ func initConfig(config Config) {
 	// External Use: modify those parameters to configure the logs-agent.
 	// enable the logs-agent:
 	config.BindEnvAndSetDefault("logs_enabled", false)
-	config.BindEnvAndSetDefault("log_enabled", false) // deprecated, use logs_enabled instead
+	bindDeprecated(config, "log_enabled", "logs_enabled", "6.0.0")
 	// collect all logs from all containers:
 	config.BindEnvAndSetDefault("logs_config.container_collect_all", false)
 	// add a socks5 proxy:
@@ -585,6 +675,8 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
+	config.BindEnvAndSetDefault("cluster_checks.tenant_label", "") // namespace label partitioning the dispatching between runners of different tenants
+	config.BindEnvAndSetDefault("cluster_checks.persist_dispatch_state", false)
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
 	config.BindEnvAndSetDefault("clc_runner_host", "") // must be set using the Kubernetes downward API
@@ -620,6 +712,7 @@ func initConfig(config Config) {
 	config.SetKnown("proxy.http")
 	config.SetKnown("proxy.https")
 	config.SetKnown("proxy.no_proxy")
+	config.SetKnown("proxy.endpoints")
 
 	// Ochestrator explorer
 	config.BindEnvAndSetDefault("orchestrator_explorer.enabled", false)
@@ -630,6 +723,7 @@ func initConfig(config Config) {
 	config.SetKnown("process_config.intervals.process_realtime")
 	config.SetKnown("process_config.queue_size")
 	config.SetKnown("process_config.max_per_message")
+	config.SetKnown("process_config.max_io_stats_processes")
 	config.SetKnown("process_config.intervals.process")
 	config.SetKnown("process_config.blacklist_patterns")
 	config.SetKnown("process_config.intervals.container")
@@ -658,6 +752,7 @@ func initConfig(config Config) {
 	config.SetKnown("system_probe_config.collect_local_dns")
 	config.SetKnown("system_probe_config.use_local_system_probe")
 	config.SetKnown("system_probe_config.enable_conntrack")
+	config.SetKnown("system_probe_config.conntrack_mode")
 	config.SetKnown("system_probe_config.sysprobe_socket")
 	config.SetKnown("system_probe_config.conntrack_rate_limit")
 	config.SetKnown("system_probe_config.max_conns_per_message")
@@ -670,6 +765,11 @@ func initConfig(config Config) {
 	config.SetKnown("system_probe_config.closed_channel_size")
 	config.SetKnown("system_probe_config.dns_timeout_in_s")
 	config.SetKnown("system_probe_config.collect_dns_stats")
+	config.SetKnown("system_probe_config.network_path.targets")
+	config.SetKnown("system_probe_config.network_path.protocol")
+	config.SetKnown("system_probe_config.network_path.max_hops")
+	config.SetKnown("system_probe_config.network_path.timeout_ms")
+	config.SetKnown("system_probe_config.network_path.interval")
 
 	// Network
 	config.BindEnv("network.id") //nolint:errcheck
@@ -680,24 +780,51 @@ func initConfig(config Config) {
 	config.SetKnown("apm_config.additional_endpoints.*")
 	config.SetKnown("apm_config.apm_non_local_traffic")
 	config.SetKnown("apm_config.max_traces_per_second")
+	config.SetKnown("apm_config.target_traces_per_second_by_service.*")
+	config.SetKnown("apm_config.errors_sampler.max_traces_per_second")
+	config.SetKnown("apm_config.rare_sampler.enabled")
+	config.SetKnown("apm_config.rare_sampler.max_traces_per_second")
+	config.SetKnown("apm_config.rare_sampler.burst")
+	config.SetKnown("apm_config.rare_sampler.cooldown")
+	config.SetKnown("apm_config.rare_sampler.priority_cooldown")
+	config.SetKnown("apm_config.rare_sampler.cardinality_limit")
+	config.SetKnown("apm_config.rare_sampler.tags")
 	config.SetKnown("apm_config.max_memory")
 	config.SetKnown("apm_config.log_file")
 	config.SetKnown("apm_config.apm_dd_url")
 	config.SetKnown("apm_config.max_cpu_percent")
 	config.SetKnown("apm_config.receiver_port")
 	config.SetKnown("apm_config.receiver_socket")
+	config.SetKnown("apm_config.receiver_socket_mode")
+	config.SetKnown("apm_config.receiver_socket_user")
+	config.SetKnown("apm_config.receiver_socket_group")
 	config.SetKnown("apm_config.connection_limit")
 	config.SetKnown("apm_config.ignore_resources")
 	config.SetKnown("apm_config.replace_tags")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.enabled")
 	config.SetKnown("apm_config.obfuscation.elasticsearch.keep_values")
+	config.SetKnown("apm_config.obfuscation.elasticsearch.use_defaults")
 	config.SetKnown("apm_config.obfuscation.mongodb.enabled")
 	config.SetKnown("apm_config.obfuscation.mongodb.keep_values")
+	config.SetKnown("apm_config.obfuscation.mongodb.use_defaults")
 	config.SetKnown("apm_config.obfuscation.http.remove_query_string")
 	config.SetKnown("apm_config.obfuscation.http.remove_paths_with_digits")
+	config.SetKnown("apm_config.obfuscation.sql.normalize_quoted_identifiers")
+	config.SetKnown("apm_config.obfuscation.sql.max_bytes")
+	config.SetKnown("apm_config.obfuscation.sql.max_duration_ms")
+	config.SetKnown("apm_config.obfuscation.sql.cache_size")
+	config.SetKnown("apm_config.obfuscation.sql.dialect")
+	config.SetKnown("apm_config.obfuscation.sql.extract_comments")
+	config.SetKnown("apm_config.obfuscation.sql.keep_values_tuples")
+	config.SetKnown("apm_config.obfuscation.sql.table_names")
+	config.SetKnown("apm_config.obfuscation.sql.obfuscate_db_statement")
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.replacement_token")
+	config.SetKnown("apm_config.obfuscation_file")
+	config.SetKnown("apm_config.obfuscation_diagnostics_rate")
+	config.SetKnown("apm_config.otlp_stats_endpoint")
 	config.SetKnown("apm_config.extra_sample_rate")
 	config.SetKnown("apm_config.dd_agent_bin")
 	config.SetKnown("apm_config.max_events_per_second")
@@ -715,17 +842,56 @@ func initConfig(config Config) {
 	config.SetKnown("apm_config.receiver_timeout")
 	config.SetKnown("apm_config.watchdog_check_delay")
 	config.SetKnown("apm_config.max_payload_size")
+	config.SetKnown("apm_config.max_payload_spans")
+	config.SetKnown("apm_config.max_resource_length")
 
 	// inventories
 	config.BindEnvAndSetDefault("inventories_enabled", true)
 	config.BindEnvAndSetDefault("inventories_max_interval", 600) // 10min
 	config.BindEnvAndSetDefault("inventories_min_interval", 300) // 5min
 
+	// container images inventory
+	config.BindEnvAndSetDefault("container_images_enabled", false)
+	config.BindEnvAndSetDefault("container_images_interval", 3600) // 1h
+
+	// host profile baseline
+	config.BindEnvAndSetDefault("host_profile.enabled", false)
+	config.BindEnvAndSetDefault("host_profile.sample_interval", 15*time.Second)
+	config.BindEnvAndSetDefault("host_profile.flush_interval", time.Hour)
+
+	// local alerting while the intake is unreachable
+	config.BindEnvAndSetDefault("local_alerting.enabled", false)
+	config.BindEnvAndSetDefault("local_alerting.offline_after", 5*time.Minute)
+	config.SetKnown("local_alerting.rules")
+
+	// Remote configuration
+	config.BindEnvAndSetDefault("remote_configuration.enabled", false)
+	config.BindEnvAndSetDefault("remote_configuration.trusted_root_file", "")
+	config.BindEnvAndSetDefault("remote_configuration.endpoint", "")
+	config.BindEnvAndSetDefault("remote_configuration.refresh_interval", time.Minute)
+
+	// Agent updater
+	config.BindEnvAndSetDefault("updater.enabled", false)
+	config.BindEnvAndSetDefault("updater.maintenance_window", "")
+	config.BindEnvAndSetDefault("updater.health_check_timeout", 5*time.Minute)
+
 	// Datadog security agent (compliance)
 	config.BindEnvAndSetDefault("compliance_config.enabled", true)
 	config.BindEnvAndSetDefault("compliance_config.check_interval", 20*time.Minute)
 	config.BindEnvAndSetDefault("compliance_config.dir", "/etc/datadog-agent/compliance.d")
 	config.BindEnvAndSetDefault("compliance_config.cmd_port", 5010)
+	config.BindEnvAndSetDefault("compliance_config.kubernetes_cluster.enabled", false)
+
+	// Datadog security agent (runtime)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.paths", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.fim.max_hash_size", 10*1024*1024)
+
+	// Datadog security agent (SBOM)
+	config.BindEnvAndSetDefault("sbom_config.enabled", false)
+	config.BindEnvAndSetDefault("sbom_config.scan_interval", time.Minute)
+	config.BindEnvAndSetDefault("sbom_config.cache_ttl", 24*time.Hour)
+	config.BindEnvAndSetDefault("sbom_config.max_per_minute", 5)
 
 	// command line options
 	config.SetKnown("cmd.check.fullsketches")
@@ -867,6 +1033,8 @@ func load(config Config, origin string, loadSecret bool) error {
 		}
 	}
 
+	applyDeprecatedSettings(config)
+
 	// If this variable is set to true, we'll use DefaultPython for the Python version,
 	// ignoring the python_version configuration value.
 	if ForceDefaultPython == "true" {
@@ -1195,7 +1363,13 @@ func GetDogstatsdMappingProfiles() ([]MappingProfile, error) {
 func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error) {
 	var mappings []MappingProfile
 	if config.IsSet("dogstatsd_mapper_profiles") {
-		err := config.UnmarshalKey("dogstatsd_mapper_profiles", &mappings)
+		var err error
+		// Environment variables can only hold the profiles as a JSON string
+		if profiles, ok := config.Get("dogstatsd_mapper_profiles").(string); ok {
+			err = json.Unmarshal([]byte(profiles), &mappings)
+		} else {
+			err = config.UnmarshalKey("dogstatsd_mapper_profiles", &mappings)
+		}
 		if err != nil {
 			return []MappingProfile{}, log.Errorf("Could not parse dogstatsd_mapper_profiles: %v", err)
 		}
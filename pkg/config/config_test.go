@@ -813,6 +813,31 @@ dogstatsd_mapper_profiles:
 	assert.EqualValues(t, expectedProfiles, profiles)
 }
 
+func TestDogstatsdMappingProfilesEnv(t *testing.T) {
+	os.Setenv("DD_DOGSTATSD_MAPPER_PROFILES", `[{"name":"airflow","prefix":"airflow.","mappings":[{"match":"airflow.job.duration_sec.*.*","name":"airflow.job.duration","tags":{"job_type":"$1","job_name":"$2"}}]}]`)
+	defer os.Unsetenv("DD_DOGSTATSD_MAPPER_PROFILES")
+	testConfig := setupConf()
+
+	profiles, err := getDogstatsdMappingProfilesConfig(testConfig)
+
+	expectedProfiles := []MappingProfile{
+		{
+			Name:   "airflow",
+			Prefix: "airflow.",
+			Mappings: []MetricMapping{
+				{
+					Match: "airflow.job.duration_sec.*.*",
+					Name:  "airflow.job.duration",
+					Tags:  map[string]string{"job_type": "$1", "job_name": "$2"},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedProfiles, profiles)
+}
+
 func TestDogstatsdMappingProfilesEmpty(t *testing.T) {
 	datadogYaml := `
 dogstatsd_mapper_profiles:
@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// DeprecatedSetting is a setting replaced by another one
+type DeprecatedSetting struct {
+	Key         string `json:"key"`
+	Replacement string `json:"replacement"`
+	// Since is the Agent version that deprecated the setting
+	Since string `json:"since"`
+}
+
+// DeprecationWarning describes a deprecated setting found in the configuration
+type DeprecationWarning struct {
+	DeprecatedSetting
+	// Ignored is true when the replacement is set too, and takes precedence
+	Ignored bool `json:"ignored"`
+}
+
+var (
+	deprecatedSettings      = map[string]DeprecatedSetting{}
+	deprecationWarnings     []DeprecationWarning
+	deprecatedSettingsMutex sync.RWMutex
+
+	yamlKeyRegexp = regexp.MustCompile(`^(\s*)([^\s#'"\-][^:#]*?)\s*:(\s+(.*))?$`)
+)
+
+// bindDeprecated registers a deprecated setting. It only binds its environment
+// variable, without a default, so that IsSet tells whether it's set by the user.
+func bindDeprecated(config Config, key, replacement, since string) {
+	config.BindEnv(key) //nolint:errcheck
+	deprecatedSettingsMutex.Lock()
+	defer deprecatedSettingsMutex.Unlock()
+	deprecatedSettings[key] = DeprecatedSetting{Key: key, Replacement: replacement, Since: since}
+}
+
+// GetDeprecatedSettings returns the registered deprecated settings, sorted by key
+func GetDeprecatedSettings() []DeprecatedSetting {
+	deprecatedSettingsMutex.RLock()
+	defer deprecatedSettingsMutex.RUnlock()
+	settings := make([]DeprecatedSetting, 0, len(deprecatedSettings))
+	for _, s := range deprecatedSettings {
+		settings = append(settings, s)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// GetDeprecationWarnings returns the deprecated settings found when loading
+// the configuration
+func GetDeprecationWarnings() []DeprecationWarning {
+	deprecatedSettingsMutex.RLock()
+	defer deprecatedSettingsMutex.RUnlock()
+	return append([]DeprecationWarning{}, deprecationWarnings...)
+}
+
+// applyDeprecatedSettings sets the replacement of the deprecated settings set
+// in the configuration, unless the replacement is set too.
+func applyDeprecatedSettings(config Config) {
+	fileSettings := readSettingsFile(config.ConfigFileUsed())
+
+	var warnings []DeprecationWarning
+	for _, s := range GetDeprecatedSettings() {
+		if !config.IsSet(s.Key) {
+			continue
+		}
+		w := DeprecationWarning{DeprecatedSetting: s}
+		// IsSet is always true for the replacement as it has a default
+		if hasSetting(fileSettings, s.Replacement) || hasEnvVar(s.Replacement) {
+			w.Ignored = true
+			log.Warnf("%q is deprecated since %s and ignored as %q is set, remove it", s.Key, s.Since, s.Replacement)
+		} else {
+			config.Set(s.Replacement, config.Get(s.Key))
+			log.Warnf("%q is deprecated since %s, use %q instead", s.Key, s.Since, s.Replacement)
+		}
+		warnings = append(warnings, w)
+	}
+
+	deprecatedSettingsMutex.Lock()
+	defer deprecatedSettingsMutex.Unlock()
+	deprecationWarnings = warnings
+}
+
+func readSettingsFile(path string) map[interface{}]interface{} {
+	settings := map[interface{}]interface{}{}
+	if path == "" {
+		return settings
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return settings
+	}
+	yaml.Unmarshal(content, &settings) //nolint:errcheck
+	return settings
+}
+
+// hasSetting returns whether the dotted key is set in the settings
+func hasSetting(settings map[interface{}]interface{}, key string) bool {
+	var current interface{} = settings
+	for _, name := range strings.Split(key, ".") {
+		m, ok := current.(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		if current, ok = m[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func hasEnvVar(key string) bool {
+	_, found := os.LookupEnv("DD_" + strings.ToUpper(strings.Replace(key, ".", "_", -1)))
+	return found
+}
+
+// MigrateDeprecatedSettings renames the deprecated settings of the content of
+// a configuration file, keeping its comments and layout. It returns the
+// migrated content, and a note for each deprecated setting found, telling
+// whether it was migrated or must be migrated manually.
+func MigrateDeprecatedSettings(content []byte) ([]byte, []string) {
+	lines := strings.Split(string(content), "\n")
+
+	paths := map[string]bool{}
+	walkYAMLKeys(lines, func(_ int, path string) {
+		paths[path] = true
+	})
+
+	deprecated := map[string]DeprecatedSetting{}
+	for _, s := range GetDeprecatedSettings() {
+		deprecated[s.Key] = s
+	}
+
+	var notes []string
+	walkYAMLKeys(lines, func(i int, path string) {
+		s, found := deprecated[path]
+		if !found {
+			return
+		}
+		parent, name := splitKey(s.Key)
+		replacementParent, replacementName := splitKey(s.Replacement)
+		switch {
+		case paths[s.Replacement]:
+			notes = append(notes, fmt.Sprintf("line %d: %q is deprecated and ignored as %q is set, remove it", i+1, s.Key, s.Replacement))
+		case parent != replacementParent:
+			notes = append(notes, fmt.Sprintf("line %d: %q is deprecated, move its value to %q", i+1, s.Key, s.Replacement))
+		default:
+			lines[i] = strings.Replace(lines[i], name, replacementName, 1)
+			paths[s.Replacement] = true
+			notes = append(notes, fmt.Sprintf("line %d: renamed %q to %q", i+1, s.Key, s.Replacement))
+		}
+	})
+	return []byte(strings.Join(lines, "\n")), notes
+}
+
+// walkYAMLKeys calls fn with the index and the dotted path of every line of a
+// YAML document defining a map key. Keys in lists are ignored.
+func walkYAMLKeys(lines []string, fn func(i int, path string)) {
+	type key struct {
+		indent int
+		name   string
+	}
+	var stack []key
+	blockIndent := -1 // indentation of the key of the block scalar being skipped
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		match := yamlKeyRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, key{indent: indent, name: match[2]})
+
+		names := make([]string, len(stack))
+		for j, k := range stack {
+			names[j] = k.name
+		}
+		fn(i, strings.Join(names, "."))
+
+		if value := strings.TrimSpace(match[4]); strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+	}
+}
+
+func splitKey(key string) (string, string) {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
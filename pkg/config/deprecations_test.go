@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupConfFromFile(t *testing.T, yamlConfig string) (Config, func()) {
+	dir, err := ioutil.TempDir("", "deprecations")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "datadog.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(yamlConfig), 0600))
+
+	conf := setupConf()
+	conf.SetConfigFile(path)
+	require.NoError(t, conf.ReadInConfig())
+	return conf, func() { os.RemoveAll(dir) }
+}
+
+func TestApplyDeprecatedSettings(t *testing.T) {
+	config, cleanup := setupConfFromFile(t, "log_enabled: true\n")
+	defer cleanup()
+
+	applyDeprecatedSettings(config)
+	assert.True(t, config.GetBool("logs_enabled"))
+	assert.Equal(t, []DeprecationWarning{
+		{DeprecatedSetting: DeprecatedSetting{Key: "log_enabled", Replacement: "logs_enabled", Since: "6.0.0"}},
+	}, GetDeprecationWarnings())
+}
+
+func TestApplyDeprecatedSettingsReplacementSet(t *testing.T) {
+	config, cleanup := setupConfFromFile(t, "log_enabled: true\nlogs_enabled: false\n")
+	defer cleanup()
+
+	applyDeprecatedSettings(config)
+	assert.False(t, config.GetBool("logs_enabled"))
+	warnings := GetDeprecationWarnings()
+	require.Len(t, warnings, 1)
+	assert.True(t, warnings[0].Ignored)
+}
+
+func TestApplyDeprecatedSettingsNone(t *testing.T) {
+	config, cleanup := setupConfFromFile(t, "logs_enabled: true\n")
+	defer cleanup()
+
+	applyDeprecatedSettings(config)
+	assert.True(t, config.GetBool("logs_enabled"))
+	assert.Empty(t, GetDeprecationWarnings())
+}
+
+func TestMigrateDeprecatedSettings(t *testing.T) {
+	defer func(s map[string]DeprecatedSetting) { deprecatedSettings = s }(deprecatedSettings)
+	deprecatedSettings = map[string]DeprecatedSetting{
+		"log_enabled":            {Key: "log_enabled", Replacement: "logs_enabled", Since: "6.0.0"},
+		"apm_config.old_port":    {Key: "apm_config.old_port", Replacement: "apm_config.receiver_port", Since: "7.0.0"},
+		"apm_config.max_traces":  {Key: "apm_config.max_traces", Replacement: "max_traces", Since: "7.0.0"},
+		"process_config.enabled": {Key: "process_config.enabled", Replacement: "process_config.on", Since: "7.0.0"},
+	}
+
+	content := `# Agent configuration
+api_key: abcdef
+## log_enabled: true
+log_enabled: true  # collect logs
+
+apm_config:
+  # the receiver port
+  old_port: 8126
+  max_traces: 10
+
+process_config:
+  enabled: true
+  on: false
+
+extra_config: |
+  log_enabled: true
+`
+	expected := `# Agent configuration
+api_key: abcdef
+## log_enabled: true
+logs_enabled: true  # collect logs
+
+apm_config:
+  # the receiver port
+  receiver_port: 8126
+  max_traces: 10
+
+process_config:
+  enabled: true
+  on: false
+
+extra_config: |
+  log_enabled: true
+`
+	migrated, notes := MigrateDeprecatedSettings([]byte(content))
+	assert.Equal(t, expected, string(migrated))
+	assert.Equal(t, []string{
+		`line 4: renamed "log_enabled" to "logs_enabled"`,
+		`line 8: renamed "apm_config.old_port" to "apm_config.receiver_port"`,
+		`line 9: "apm_config.max_traces" is deprecated, move its value to "max_traces"`,
+		`line 12: "process_config.enabled" is deprecated and ignored as "process_config.on" is set, remove it`,
+	}, notes)
+
+	migrated, notes = MigrateDeprecatedSettings([]byte("api_key: abcdef\n"))
+	assert.Equal(t, "api_key: abcdef\n", string(migrated))
+	assert.Empty(t, notes)
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+// Feature is an optional subsystem of the Agent, enabled in its configuration
+type Feature string
+
+const (
+	// LogsFeature is the logs collection, run by the logs-agent
+	LogsFeature Feature = "logs"
+	// APMFeature is the traces collection, run by the trace-agent
+	APMFeature Feature = "apm"
+	// ProcessFeature is the live processes collection, run by the process-agent
+	ProcessFeature Feature = "process"
+	// NPMFeature is the network performance monitoring, run by the system-probe
+	NPMFeature Feature = "npm"
+	// CSPMFeature is the compliance monitoring, run by the security-agent
+	CSPMFeature Feature = "cspm"
+)
+
+// featureSetting is the setting enabling a feature
+type featureSetting struct {
+	key     string
+	enabled func(c Config, key string) bool
+}
+
+// features maps each feature to the setting enabling it
+var features = map[Feature]featureSetting{
+	LogsFeature: {"logs_enabled", Config.GetBool},
+	APMFeature:  {"apm_config.enabled", Config.GetBool},
+	// process_config.enabled is "false" when only the containers are collected
+	ProcessFeature: {"process_config.enabled", func(c Config, key string) bool { return c.GetString(key) == "true" }},
+	NPMFeature:     {"system_probe_config.enabled", Config.GetBool},
+	CSPMFeature:    {"compliance_config.enabled", Config.GetBool},
+}
+
+// IsFeatureEnabled returns whether a feature is enabled in the Agent configuration
+func IsFeatureEnabled(f Feature) bool {
+	return isFeatureEnabled(Datadog, f)
+}
+
+func isFeatureEnabled(config Config, f Feature) bool {
+	setting, found := features[f]
+	return found && setting.enabled(config, setting.key)
+}
+
+// GetFeatures returns whether each feature is enabled, by feature name. It is
+// the source of the features reported in the metadata, status and flare.
+func GetFeatures() map[string]bool {
+	return getFeatures(Datadog)
+}
+
+func getFeatures(config Config) map[string]bool {
+	states := make(map[string]bool, len(features))
+	for f, setting := range features {
+		states[string(f)] = setting.enabled(config, setting.key)
+	}
+	return states
+}
+
+// GetFeatureSettings returns the setting enabling each feature, by feature name
+func GetFeatureSettings() map[string]string {
+	settings := make(map[string]string, len(features))
+	for f, setting := range features {
+		settings[string(f)] = setting.key
+	}
+	return settings
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatures(t *testing.T) {
+	config := setupConfFromYAML(`
+logs_enabled: true
+apm_config:
+  enabled: false
+process_config:
+  enabled: "true"
+system_probe_config:
+  enabled: true
+compliance_config:
+  enabled: false
+`)
+
+	assert.True(t, isFeatureEnabled(config, LogsFeature))
+	assert.False(t, isFeatureEnabled(config, APMFeature))
+	assert.False(t, isFeatureEnabled(config, Feature("unknown")))
+	assert.Equal(t, map[string]bool{
+		"logs":    true,
+		"apm":     false,
+		"process": true,
+		"npm":     true,
+		"cspm":    false,
+	}, getFeatures(config))
+}
+
+func TestProcessFeatureContainersOnly(t *testing.T) {
+	config := setupConfFromYAML(`
+process_config:
+  enabled: "false"
+`)
+	assert.False(t, isFeatureEnabled(config, ProcessFeature))
+}
+
+func TestFeatureSettings(t *testing.T) {
+	settings := GetFeatureSettings()
+	assert.Len(t, settings, len(features))
+	assert.Equal(t, "logs_enabled", settings["logs"])
+	assert.Equal(t, "process_config.enabled", settings["process"])
+}
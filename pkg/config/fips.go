@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"crypto/tls"
+)
+
+// FIPSCipherSuites are the FIPS 140-2 approved TLS 1.2 cipher suites the
+// agent negotiates in FIPS mode
+var FIPSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the FIPS approved elliptic curves
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// FIPSEnabled returns whether the agent runs in FIPS mode, either enabled
+// with fips.enabled or enforced by a FIPS build
+func FIPSEnabled() bool {
+	return fipsEnabledWithConfig(Datadog)
+}
+
+func fipsEnabledWithConfig(config Config) bool {
+	return fipsBuild || config.GetBool("fips.enabled")
+}
+
+// ConfigureFIPSTLS restricts a TLS configuration to TLS 1.2 and above, and
+// to the approved cipher suites and curves, when the agent runs in FIPS mode
+func ConfigureFIPSTLS(tlsConfig *tls.Config) {
+	if FIPSEnabled() {
+		restrictTLS(tlsConfig)
+	}
+}
+
+func restrictTLS(tlsConfig *tls.Config) {
+	if tlsConfig.MinVersion < tls.VersionTLS12 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+	tlsConfig.CipherSuites = FIPSCipherSuites
+	tlsConfig.CurvePreferences = fipsCurves
+}
+
+// GetFIPSStatus returns the FIPS mode state displayed in the agent status
+func GetFIPSStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":  FIPSEnabled(),
+		"enforced": fipsBuild,
+	}
+}
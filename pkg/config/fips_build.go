@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build fips
+
+package config
+
+import (
+	// Restricts crypto/tls to the FIPS approved settings, this build requires
+	// a Go toolchain with BoringCrypto
+	_ "crypto/tls/fipsonly"
+)
+
+// fipsBuild enforces the FIPS mode whatever the configuration
+const fipsBuild = true
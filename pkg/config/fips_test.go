@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !fips
+
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFIPSEnabled(t *testing.T) {
+	assert.False(t, fipsEnabledWithConfig(setupConf()))
+	assert.True(t, fipsEnabledWithConfig(setupConfFromYAML(`
+fips:
+  enabled: true
+`)))
+}
+
+func TestRestrictTLS(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS10}
+	restrictTLS(tlsConfig)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, FIPSCipherSuites, tlsConfig.CipherSuites)
+	assert.Equal(t, fipsCurves, tlsConfig.CurvePreferences)
+
+	tlsConfig = &tls.Config{MinVersion: tls.VersionTLS13}
+	restrictTLS(tlsConfig)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+}
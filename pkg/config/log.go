@@ -52,7 +52,7 @@ func createQuoteMsgFormatter(params string) seelog.FormatterFunc {
 // buildJSONFormat returns the log JSON format seelog string
 func buildJSONFormat(loggerName LoggerName) string {
 	seelog.RegisterCustomFormatter("QuoteMsg", createQuoteMsgFormatter) //nolint:errcheck
-	return fmt.Sprintf(`{"agent":"%s","time":"%%Date(%s)","level":"%%LEVEL","file":"%%ShortFilePath","line":"%%Line","func":"%%FuncShort","msg":%%QuoteMsg}%%n`, strings.ToLower(string(loggerName)), getLogDateFormat())
+	return fmt.Sprintf(`{"agent":"%s","time":"%%Date(%s)","level":"%%LEVEL","component":"%%Component","file":"%%ShortFilePath","line":"%%Line","func":"%%FuncShort","msg":%%QuoteMsg}%%n`, strings.ToLower(string(loggerName)), getLogDateFormat())
 }
 
 func getSyslogTLSKeyPair() (*tls.Certificate, error) {
@@ -93,9 +93,20 @@ func SetupLogger(loggerName LoggerName, logLevel, logFile, syslogURI string, sys
 		formatID = "json"
 	}
 
-	seelogConfig = seelogCfg.NewSeelogConfig(string(loggerName), seelogLogLevel, formatID, buildJSONFormat(loggerName), buildCommonFormat(loggerName), syslogRFC)
+	overrides, err := validateLogLevelOverrides(Datadog.GetStringMapString("log_level_overrides"))
+	if err != nil {
+		return err
+	}
+
+	seelogConfig = seelogCfg.NewSeelogConfig(string(loggerName), lowestLogLevel(seelogLogLevel, overrides), formatID, buildJSONFormat(loggerName), buildCommonFormat(loggerName), syslogRFC)
 	seelogConfig.EnableConsoleLog(logToConsole)
-	seelogConfig.EnableFileLogging(logFile, Datadog.GetSizeInBytes("log_file_max_size"), uint(Datadog.GetInt("log_file_max_rolls")))
+	seelogConfig.EnableFileLogging(
+		logFile,
+		Datadog.GetSizeInBytes("log_file_max_size"),
+		uint(Datadog.GetInt("log_file_max_rolls")),
+		uint(Datadog.GetInt("log_file_max_age")),
+		Datadog.GetBool("log_file_compress"),
+	)
 
 	if syslogURI != "" { // non-blank uri enables syslog
 		syslogTLSKeyPair, err := getSyslogTLSKeyPair()
@@ -124,6 +135,9 @@ func SetupLogger(loggerName LoggerName, logLevel, logFile, syslogURI string, sys
 	}
 	seelog.ReplaceLogger(logger) //nolint:errcheck
 	log.SetupDatadogLogger(logger, seelogLogLevel)
+	if err := log.SetLogLevelOverrides(overrides); err != nil {
+		return err
+	}
 	log.AddStrippedKeys(Datadog.GetStringSlice("flare_stripped_keys"))
 	return nil
 }
@@ -310,6 +324,62 @@ func (s *SyslogReceiver) Close() error {
 	return nil
 }
 
+// RotatingFileReceiver implements seelog.CustomReceiver, writing the logs to a
+// file rotated and cleaned up by the agent itself, as logrotate isn't available
+// on every platform the agent runs on
+type RotatingFileReceiver struct {
+	file *log.RotatingFile
+}
+
+// ReceiveMessage writes the current log message to the file
+func (r *RotatingFileReceiver) ReceiveMessage(message string, level seelog.LogLevel, context seelog.LogContextInterface) error {
+	_, err := r.file.Write([]byte(message))
+	return err
+}
+
+// AfterParse parses the receiver configuration and opens the log file
+func (r *RotatingFileReceiver) AfterParse(initArgs seelog.CustomReceiverInitArgs) error {
+	attrs := initArgs.XmlCustomAttrs
+	path := attrs["path"]
+	if path == "" {
+		return errors.New("bad rotating file receiver configuration: missing path")
+	}
+	maxSize, err := strconv.ParseInt(attrs["maxsize"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad rotating file receiver configuration: invalid maxsize: %v", err)
+	}
+	maxRolls, err := strconv.Atoi(attrs["maxrolls"])
+	if err != nil {
+		return fmt.Errorf("bad rotating file receiver configuration: invalid maxrolls: %v", err)
+	}
+	maxAgeDays, err := strconv.Atoi(attrs["maxage"])
+	if err != nil {
+		return fmt.Errorf("bad rotating file receiver configuration: invalid maxage: %v", err)
+	}
+
+	r.file, err = log.NewRotatingFile(path, maxSize, maxRolls, time.Duration(maxAgeDays)*24*time.Hour, attrs["compress"] == "true")
+	return err
+}
+
+// Flush is a NOP, the messages are written to the file as they're received
+func (r *RotatingFileReceiver) Flush() {
+	// Nothing to do here...
+}
+
+// Close closes the log file
+func (r *RotatingFileReceiver) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+func parseComponent(params string) seelog.FormatterFunc {
+	return func(message string, level seelog.LogLevel, context seelog.LogContextInterface) interface{} {
+		return log.ComponentFromPath(context.FullPath())
+	}
+}
+
 func parseShortFilePath(params string) seelog.FormatterFunc {
 	return func(message string, level seelog.LogLevel, context seelog.LogContextInterface) interface{} {
 		return extractShortPathFromFullPath(context.FullPath())
@@ -329,8 +399,35 @@ func ChangeLogLevel(level string) error {
 	if err != nil {
 		return err
 	}
+	overrides, err := log.GetLogLevelOverrides()
+	if err != nil {
+		return err
+	}
+	return reloadLogger(seelogLogLevel, overrides)
+}
+
+// ChangeLogLevelOverrides immediately changes the log levels overriding the
+// global one for some components, by component.
+func ChangeLogLevelOverrides(overrides map[string]string) error {
+	overrides, err := validateLogLevelOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	level, err := log.GetLogLevel()
+	if err != nil {
+		return err
+	}
+	if err := reloadLogger(level.String(), overrides); err != nil {
+		return err
+	}
+	return log.SetLogLevelOverrides(overrides)
+}
+
+// reloadLogger replaces the seelog logger with one accepting the messages of
+// the given levels
+func reloadLogger(seelogLogLevel string, overrides map[string]string) error {
 	// We create a new logger to propagate the new log level everywhere seelog is used (including dependencies)
-	seelogConfig.SetLogLevel(seelogLogLevel)
+	seelogConfig.SetLogLevel(lowestLogLevel(seelogLogLevel, overrides))
 	configTemplate, err := seelogConfig.Render()
 	if err != nil {
 		return err
@@ -346,6 +443,32 @@ func ChangeLogLevel(level string) error {
 	return log.ChangeLogLevel(logger, seelogLogLevel)
 }
 
+// validateLogLevelOverrides validates and normalizes the log levels of the components
+func validateLogLevelOverrides(overrides map[string]string) (map[string]string, error) {
+	validated := make(map[string]string, len(overrides))
+	for component, level := range overrides {
+		seelogLogLevel, err := validateLogLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("%v for component %s", err, component)
+		}
+		validated[component] = seelogLogLevel
+	}
+	return validated, nil
+}
+
+// lowestLogLevel returns the most verbose of the global log level and the log
+// levels of the components, which the seelog logger must accept. The messages
+// are then filtered by component by the Datadog logger.
+func lowestLogLevel(seelogLogLevel string, overrides map[string]string) string {
+	lowest, _ := seelog.LogLevelFromString(seelogLogLevel)
+	for _, level := range overrides {
+		if lvl, _ := seelog.LogLevelFromString(level); lvl < lowest {
+			lowest = lvl
+		}
+	}
+	return lowest.String()
+}
+
 func validateLogLevel(logLevel string) (string, error) {
 	seelogLogLevel := strings.ToLower(logLevel)
 	if seelogLogLevel == "warning" { // Common gotcha when used to agent5
@@ -361,5 +484,7 @@ func validateLogLevel(logLevel string) (string, error) {
 func init() {
 	seelog.RegisterCustomFormatter("CustomSyslogHeader", createSyslogHeaderFormatter) //nolint:errcheck
 	seelog.RegisterCustomFormatter("ShortFilePath", parseShortFilePath)               //nolint:errcheck
+	seelog.RegisterCustomFormatter("Component", parseComponent)                       //nolint:errcheck
 	seelog.RegisterReceiver("syslog", &SyslogReceiver{})
+	seelog.RegisterReceiver("rotatingfile", &RotatingFileReceiver{})
 }
@@ -31,7 +31,7 @@ func TestExtractShortPathFromFullPath(t *testing.T) {
 func TestSeelogConfig(t *testing.T) {
 	cfg := seelogCfg.NewSeelogConfig("TEST", "off", "common", "", "", false)
 	cfg.EnableConsoleLog(true)
-	cfg.EnableFileLogging("/dev/null", 123, 456)
+	cfg.EnableFileLogging("/dev/null", 123, 456, 7, true)
 
 	seelogConfigStr, err := cfg.Render()
 	assert.Nil(t, err)
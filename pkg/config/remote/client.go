@@ -0,0 +1,388 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package remote implements the client of the remote configuration service.
+// The subsystems subscribe to the configurations of a product, which are
+// distributed as files listed in targets metadata signed with keys trusted
+// through a chain of root metadata, like in The Update Framework.
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Products distributed by the remote configuration service
+const (
+	ProductAPMSampling    = "APM_SAMPLING"
+	ProductAPMObfuscation = "APM_OBFUSCATION"
+	ProductCWSPolicies    = "CWS_POLICIES"
+	ProductLogsRules      = "LOGS_RULES"
+	ProductAgentUpdates   = "AGENT_UPDATES"
+	ProductNetworkPath    = "NETWORK_PATH"
+)
+
+const (
+	cacheFileName          = "remote-config.json"
+	maxResponseBodyBytes   = 10 * 1024 * 1024
+	defaultRefreshInterval = time.Minute
+)
+
+// Callback is called with the files of a product, by path, every time they change
+type Callback func(files map[string][]byte)
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// Endpoint is the URL of the remote configuration service
+	Endpoint string
+	APIKey   string
+	// TrustedRoot is the root metadata the chain of trust starts from
+	TrustedRoot []byte
+	// CacheDir holds the last verified configurations, served until the
+	// service can be reached. Nothing is cached when empty.
+	CacheDir        string
+	RefreshInterval time.Duration
+	HTTPClient      *http.Client
+}
+
+// updateRequest is sent to the service to get the configurations more recent
+// than the versions of the client
+type updateRequest struct {
+	Products       []string `json:"products"`
+	RootVersion    int64    `json:"root_version"`
+	TargetsVersion int64    `json:"targets_version"`
+}
+
+// update is the answer of the service, and the content of the cache. Roots is
+// the chain of root metadata following the version of the client, Targets is
+// nil when nothing changed.
+type update struct {
+	Roots       []*Signed    `json:"roots"`
+	Targets     *Signed      `json:"targets"`
+	TargetFiles []targetFile `json:"target_files"`
+}
+
+type targetFile struct {
+	Path string `json:"path"`
+	Raw  []byte `json:"raw"`
+}
+
+// Client fetches the configurations of the subscribed products from the
+// remote configuration service, verifies and caches them
+type Client struct {
+	opts ClientOptions
+	now  func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[string][]Callback
+	// state is only changed as a whole, after an update is verified
+	roots         []*Signed
+	root          *Root
+	targets       *Targets
+	targetsSigned *Signed
+	files         map[string][]byte
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewClient returns a client trusting the given root metadata
+func NewClient(opts ClientOptions) (*Client, error) {
+	var trustedRoot Signed
+	if err := json.Unmarshal(opts.TrustedRoot, &trustedRoot); err != nil {
+		return nil, fmt.Errorf("invalid trusted root: %v", err)
+	}
+	root, err := parseRoot(&trustedRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := root.verifySignatures(roleRoot, &trustedRoot); err != nil {
+		return nil, fmt.Errorf("trusted root: %v", err)
+	}
+
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultRefreshInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		opts:        opts,
+		now:         time.Now,
+		subscribers: make(map[string][]Callback),
+		root:        root,
+		files:       make(map[string][]byte),
+		stop:        make(chan struct{}),
+	}, nil
+}
+
+// Subscribe registers a callback for the configurations of a product. It must
+// be called before Start.
+func (c *Client) Subscribe(product string, cb Callback) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers[product] = append(c.subscribers[product], cb)
+}
+
+// Start serves the cached configurations then polls the service for updates
+func (c *Client) Start() {
+	if err := c.loadCache(); err != nil {
+		log.Warnf("Ignoring the remote configuration cache: %v", err)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.opts.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := c.poll(); err != nil {
+				log.Errorf("Could not update the remote configuration: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling the service
+func (c *Client) Stop() {
+	close(c.stop)
+	c.wg.Wait()
+}
+
+// products returns the sorted list of subscribed products
+func (c *Client) products() []string {
+	products := make([]string, 0, len(c.subscribers))
+	for product := range c.subscribers {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	return products
+}
+
+// poll fetches and applies the updates from the service
+func (c *Client) poll() error {
+	c.mu.Lock()
+	req := updateRequest{Products: c.products(), RootVersion: c.root.Version}
+	if c.targets != nil {
+		req.TargetsVersion = c.targets.Version
+	}
+	c.mu.Unlock()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest("POST", c.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("DD-API-KEY", c.opts.APIKey)
+
+	resp, err := c.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	var u update
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBodyBytes)).Decode(&u); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	return c.apply(&u, true)
+}
+
+// loadCache replays the cached update from the trusted root, so it goes
+// through the same verifications as the updates from the service
+func (c *Client) loadCache() error {
+	if c.opts.CacheDir == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(c.opts.CacheDir, cacheFileName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var u update
+	if err := json.Unmarshal(content, &u); err != nil {
+		return err
+	}
+	return c.apply(&u, false)
+}
+
+// writeCache stores the chain of roots, the targets and the files of the
+// current state
+func (c *Client) writeCache() error {
+	if c.opts.CacheDir == "" {
+		return nil
+	}
+	u := update{Roots: c.roots, Targets: c.targetsSigned}
+	for path, raw := range c.files {
+		u.TargetFiles = append(u.TargetFiles, targetFile{Path: path, Raw: raw})
+	}
+	content, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.opts.CacheDir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(c.opts.CacheDir, cacheFileName), content, 0600)
+}
+
+// apply verifies an update and switches to it, then notifies the subscribers
+// of the products whose files changed. Nothing changes when the update is
+// invalid.
+func (c *Client) apply(u *update, cache bool) error {
+	notify, err := c.verifyAndSwitch(u, cache)
+	if err != nil {
+		return err
+	}
+	// the callbacks are called without holding the lock
+	notify()
+	return nil
+}
+
+func (c *Client) verifyAndSwitch(u *update, cache bool) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	noop := func() {}
+
+	root, roots := c.root, c.roots
+	for _, s := range u.Roots {
+		next, err := updateRoot(root, s)
+		if err != nil {
+			return nil, err
+		}
+		root, roots = next, append(roots[:len(roots):len(roots)], s)
+	}
+	now := c.now()
+	if now.After(root.Expires) {
+		return nil, fmt.Errorf("root version %d expired on %s", root.Version, root.Expires)
+	}
+
+	targetsSigned := u.Targets
+	if targetsSigned == nil {
+		if len(u.Roots) == 0 {
+			return noop, nil
+		}
+		if c.targetsSigned == nil {
+			c.root, c.roots = root, roots
+			return noop, nil
+		}
+		// the current targets must still be signed with the keys of the new root
+		targetsSigned = c.targetsSigned
+	}
+
+	targets, err := verifyTargets(root, targetsSigned, now)
+	if err != nil {
+		return nil, err
+	}
+	if c.targets != nil && targets.Version < c.targets.Version {
+		return nil, fmt.Errorf("targets version %d is older than the current version %d", targets.Version, c.targets.Version)
+	}
+
+	received := make(map[string][]byte, len(u.TargetFiles))
+	for _, f := range u.TargetFiles {
+		received[f.Path] = f.Raw
+	}
+
+	files := make(map[string][]byte)
+	for path, target := range targets.Targets {
+		if _, subscribed := c.subscribers[productOf(path)]; !subscribed {
+			continue
+		}
+		raw, found := received[path]
+		if !found {
+			// unchanged files aren't sent again
+			raw, found = c.files[path]
+		}
+		if !found {
+			return nil, fmt.Errorf("target %s is missing", path)
+		}
+		if err := verifyTargetFile(path, target, raw); err != nil {
+			return nil, err
+		}
+		files[path] = raw
+	}
+
+	changed := changedProducts(c.files, files)
+	c.root, c.roots = root, roots
+	c.targets, c.targetsSigned = targets, targetsSigned
+	c.files = files
+
+	if cache {
+		if err := c.writeCache(); err != nil {
+			log.Warnf("Could not cache the remote configuration: %v", err)
+		}
+	}
+
+	var notifications []func()
+	for _, product := range changed {
+		productFiles := make(map[string][]byte)
+		for path, raw := range files {
+			if productOf(path) == product {
+				productFiles[path] = raw
+			}
+		}
+		for _, cb := range c.subscribers[product] {
+			cb := cb
+			notifications = append(notifications, func() { cb(productFiles) })
+		}
+	}
+	return func() {
+		for _, n := range notifications {
+			n()
+		}
+	}, nil
+}
+
+// changedProducts returns the sorted products with added, removed or modified files
+func changedProducts(old, new map[string][]byte) []string {
+	changed := make(map[string]bool)
+	for path, raw := range new {
+		if oldRaw, found := old[path]; !found || !bytes.Equal(oldRaw, raw) {
+			changed[productOf(path)] = true
+		}
+	}
+	for path := range old {
+		if _, found := new[path]; !found {
+			changed[productOf(path)] = true
+		}
+	}
+
+	products := make([]string, 0, len(changed))
+	for product := range changed {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	return products
+}
@@ -0,0 +1,269 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepo signs metadata like the remote configuration service
+type testRepo struct {
+	t           *testing.T
+	rootKeys    map[string]ed25519.PrivateKey
+	targetsKeys map[string]ed25519.PrivateKey
+	rootVersion int64
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	r := &testRepo{t: t, rootKeys: map[string]ed25519.PrivateKey{}, targetsKeys: map[string]ed25519.PrivateKey{}}
+	r.rootKeys["root-1"] = r.newKey()
+	r.targetsKeys["targets-1"] = r.newKey()
+	return r
+}
+
+func (r *testRepo) newKey() ed25519.PrivateKey {
+	_, private, err := ed25519.GenerateKey(nil)
+	require.NoError(r.t, err)
+	return private
+}
+
+func (r *testRepo) sign(v interface{}, keys map[string]ed25519.PrivateKey) *Signed {
+	signed, err := json.Marshal(v)
+	require.NoError(r.t, err)
+	s := &Signed{Signed: signed}
+	for id, key := range keys {
+		s.Signatures = append(s.Signatures, Signature{KeyID: id, Sig: hex.EncodeToString(ed25519.Sign(key, signed))})
+	}
+	return s
+}
+
+func roleOf(keys map[string]ed25519.PrivateKey) Role {
+	role := Role{Threshold: len(keys)}
+	for id := range keys {
+		role.KeyIDs = append(role.KeyIDs, id)
+	}
+	return role
+}
+
+// root returns the next root version, signed by the previous and new root keys
+func (r *testRepo) root(previousKeys map[string]ed25519.PrivateKey) *Signed {
+	r.rootVersion++
+	root := Root{
+		Type:    roleRoot,
+		Version: r.rootVersion,
+		Expires: time.Now().Add(24 * time.Hour),
+		Keys:    map[string]Key{},
+		Roles:   map[string]Role{roleRoot: roleOf(r.rootKeys), roleTargets: roleOf(r.targetsKeys)},
+	}
+	signers := map[string]ed25519.PrivateKey{}
+	for _, keys := range []map[string]ed25519.PrivateKey{r.rootKeys, r.targetsKeys, previousKeys} {
+		for id, key := range keys {
+			root.Keys[id] = Key{KeyType: "ed25519", Public: hex.EncodeToString(key.Public().(ed25519.PublicKey))}
+		}
+	}
+	for _, keys := range []map[string]ed25519.PrivateKey{r.rootKeys, previousKeys} {
+		for id, key := range keys {
+			signers[id] = key
+		}
+	}
+	return r.sign(root, signers)
+}
+
+func (r *testRepo) targets(version int64, files map[string]string) *Signed {
+	targets := Targets{
+		Type:    roleTargets,
+		Version: version,
+		Expires: time.Now().Add(24 * time.Hour),
+		Targets: map[string]TargetFile{},
+	}
+	for path, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		targets.Targets[path] = TargetFile{Length: int64(len(content)), Hashes: map[string]string{"sha256": hex.EncodeToString(sum[:])}}
+	}
+	return r.sign(targets, r.targetsKeys)
+}
+
+func (r *testRepo) update(version int64, files map[string]string) *update {
+	u := &update{Targets: r.targets(version, files)}
+	for path, content := range files {
+		u.TargetFiles = append(u.TargetFiles, targetFile{Path: path, Raw: []byte(content)})
+	}
+	return u
+}
+
+func newTestClient(t *testing.T, r *testRepo, trustedRoot *Signed, cacheDir string) *Client {
+	raw, err := json.Marshal(trustedRoot)
+	require.NoError(t, err)
+	c, err := NewClient(ClientOptions{TrustedRoot: raw, CacheDir: cacheDir})
+	require.NoError(t, err)
+	return c
+}
+
+type received struct {
+	sync.Mutex
+	files []map[string][]byte
+}
+
+func (r *received) callback(files map[string][]byte) {
+	r.Lock()
+	defer r.Unlock()
+	r.files = append(r.files, files)
+}
+
+func TestClientApply(t *testing.T) {
+	r := newTestRepo(t)
+	c := newTestClient(t, r, r.root(nil), "")
+
+	var sampling received
+	c.Subscribe(ProductAPMSampling, sampling.callback)
+
+	files := map[string]string{
+		"APM_SAMPLING/default/config": `{"rate":0.5}`,
+		"CWS_POLICIES/default/config": `rules: []`,
+	}
+	require.NoError(t, c.apply(r.update(1, files), false))
+	require.Len(t, sampling.files, 1)
+	// the files of the products without subscribers are left out
+	assert.Equal(t, map[string][]byte{"APM_SAMPLING/default/config": []byte(`{"rate":0.5}`)}, sampling.files[0])
+
+	// unchanged files aren't sent again, and don't notify the subscribers
+	u := r.update(2, files)
+	u.TargetFiles = nil
+	require.NoError(t, c.apply(u, false))
+	assert.Len(t, sampling.files, 1)
+
+	files["APM_SAMPLING/default/config"] = `{"rate":0.1}`
+	require.NoError(t, c.apply(r.update(3, files), false))
+	require.Len(t, sampling.files, 2)
+	assert.Equal(t, []byte(`{"rate":0.1}`), sampling.files[1]["APM_SAMPLING/default/config"])
+
+	// rollbacks are refused
+	assert.Error(t, c.apply(r.update(2, files), false))
+	assert.Equal(t, int64(3), c.targets.Version)
+}
+
+func TestClientRejectsInvalidUpdates(t *testing.T) {
+	r := newTestRepo(t)
+	c := newTestClient(t, r, r.root(nil), "")
+	var sampling received
+	c.Subscribe(ProductAPMSampling, sampling.callback)
+	files := map[string]string{"APM_SAMPLING/default/config": `{"rate":0.5}`}
+
+	// tampered file
+	u := r.update(1, files)
+	u.TargetFiles[0].Raw = []byte(`{"rate":1.0}`)
+	assert.EqualError(t, c.apply(u, false), "target APM_SAMPLING/default/config doesn't match its sha256 hash")
+
+	// missing file
+	u = r.update(1, files)
+	u.TargetFiles = nil
+	assert.EqualError(t, c.apply(u, false), "target APM_SAMPLING/default/config is missing")
+
+	// targets signed by an unknown key
+	u = r.update(1, files)
+	u.Targets = r.sign(json.RawMessage(u.Targets.Signed), map[string]ed25519.PrivateKey{"targets-1": r.newKey()})
+	assert.EqualError(t, c.apply(u, false), "targets metadata has 0 valid signatures, 1 required")
+
+	// root not signed by the current root keys
+	previous := r.rootKeys
+	r.rootKeys = map[string]ed25519.PrivateKey{"root-2": r.newKey()}
+	u = r.update(1, files)
+	u.Roots = []*Signed{r.root(nil)}
+	assert.Error(t, c.apply(u, false))
+
+	assert.Nil(t, c.targets)
+	assert.Empty(t, sampling.files)
+
+	// the same root signed by the previous keys is accepted
+	r.rootVersion--
+	u.Roots = []*Signed{r.root(previous)}
+	require.NoError(t, c.apply(u, false))
+	assert.Equal(t, int64(2), c.root.Version)
+	assert.Len(t, sampling.files, 1)
+}
+
+func TestClientRootRotationResignsTargets(t *testing.T) {
+	r := newTestRepo(t)
+	c := newTestClient(t, r, r.root(nil), "")
+	c.Subscribe(ProductAPMSampling, func(map[string][]byte) {})
+	require.NoError(t, c.apply(r.update(1, map[string]string{"APM_SAMPLING/a/b": "x"}), false))
+
+	// the new root revokes the targets key, the current targets aren't trusted anymore
+	previous := r.rootKeys
+	r.targetsKeys = map[string]ed25519.PrivateKey{"targets-2": r.newKey()}
+	assert.Error(t, c.apply(&update{Roots: []*Signed{r.root(previous)}}, false))
+	assert.Equal(t, int64(1), c.root.Version)
+}
+
+func TestClientCache(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "remote-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	r := newTestRepo(t)
+	trustedRoot := r.root(nil)
+	c := newTestClient(t, r, trustedRoot, cacheDir)
+	c.Subscribe(ProductAPMSampling, func(map[string][]byte) {})
+
+	previous := r.rootKeys
+	r.rootKeys = map[string]ed25519.PrivateKey{"root-2": r.newKey()}
+	u := r.update(1, map[string]string{"APM_SAMPLING/default/config": `{"rate":0.5}`})
+	u.Roots = []*Signed{r.root(previous)}
+	require.NoError(t, c.apply(u, true))
+
+	// a new client replays the cache from the trusted root
+	c = newTestClient(t, r, trustedRoot, cacheDir)
+	var sampling received
+	c.Subscribe(ProductAPMSampling, sampling.callback)
+	require.NoError(t, c.loadCache())
+	assert.Equal(t, int64(2), c.root.Version)
+	require.Len(t, sampling.files, 1)
+	assert.Equal(t, []byte(`{"rate":0.5}`), sampling.files[0]["APM_SAMPLING/default/config"])
+}
+
+func TestClientPoll(t *testing.T) {
+	r := newTestRepo(t)
+	c := newTestClient(t, r, r.root(nil), "")
+	var sampling received
+	c.Subscribe(ProductAPMSampling, sampling.callback)
+
+	var requests []updateRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "secret", req.Header.Get("DD-API-KEY"))
+		var ur updateRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&ur))
+		requests = append(requests, ur)
+		if ur.TargetsVersion == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(r.update(1, map[string]string{"APM_SAMPLING/default/config": `{"rate":0.5}`}))
+	}))
+	defer ts.Close()
+	c.opts.Endpoint = ts.URL
+	c.opts.APIKey = "secret"
+
+	require.NoError(t, c.poll())
+	require.NoError(t, c.poll())
+	assert.Equal(t, []updateRequest{
+		{Products: []string{ProductAPMSampling}, RootVersion: 1},
+		{Products: []string{ProductAPMSampling}, RootVersion: 1, TargetsVersion: 1},
+	}, requests)
+	assert.Len(t, sampling.files, 1)
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+const (
+	endpointPrefix = "https://config."
+	endpointPath   = "/api/v0.1/configurations"
+)
+
+// NewClientFromConfig returns a client configured with the
+// remote_configuration settings, or nil when it's disabled
+func NewClientFromConfig() (*Client, error) {
+	if !config.Datadog.GetBool("remote_configuration.enabled") {
+		return nil, nil
+	}
+
+	rootFile := config.Datadog.GetString("remote_configuration.trusted_root_file")
+	if rootFile == "" {
+		return nil, errors.New("remote_configuration.trusted_root_file must be set to enable the remote configuration")
+	}
+	trustedRoot, err := ioutil.ReadFile(rootFile)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := config.GetMainEndpoint(endpointPrefix, "remote_configuration.endpoint")
+	return NewClient(ClientOptions{
+		Endpoint:        strings.TrimSuffix(endpoint, "/") + endpointPath,
+		APIKey:          config.SanitizeAPIKey(config.Datadog.GetString("api_key")),
+		TrustedRoot:     trustedRoot,
+		CacheDir:        filepath.Join(config.Datadog.GetString("run_path"), "remote-config"),
+		RefreshInterval: config.Datadog.GetDuration("remote_configuration.refresh_interval"),
+		HTTPClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: httputils.CreateHTTPTransport(),
+		},
+	})
+}
@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metadata roles
+const (
+	roleRoot    = "root"
+	roleTargets = "targets"
+)
+
+// Signed is a metadata document along with its signatures, computed over the
+// exact bytes of the signed document
+type Signed struct {
+	Signed     json.RawMessage `json:"signed"`
+	Signatures []Signature     `json:"signatures"`
+}
+
+// Signature is the hex encoded ed25519 signature of a key
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Key is a hex encoded ed25519 public key
+type Key struct {
+	KeyType string `json:"keytype"`
+	Public  string `json:"public"`
+}
+
+// Role lists the keys allowed to sign the metadata of a role, and how many of
+// them must sign it
+type Role struct {
+	KeyIDs    []string `json:"keyids"`
+	Threshold int      `json:"threshold"`
+}
+
+// Root holds the keys trusted for each role. A new version must be signed by
+// the root keys of both the current and the new version.
+type Root struct {
+	Type    string          `json:"_type"`
+	Version int64           `json:"version"`
+	Expires time.Time       `json:"expires"`
+	Keys    map[string]Key  `json:"keys"`
+	Roles   map[string]Role `json:"roles"`
+}
+
+// Targets lists the configuration files, by path, with their hashes
+type Targets struct {
+	Type    string                `json:"_type"`
+	Version int64                 `json:"version"`
+	Expires time.Time             `json:"expires"`
+	Targets map[string]TargetFile `json:"targets"`
+}
+
+// TargetFile describes a configuration file, its path is "<product>/<id>/<name>"
+type TargetFile struct {
+	Length int64             `json:"length"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// productOf returns the product a target path belongs to
+func productOf(path string) string {
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+// verifySignatures checks that enough distinct keys of the role signed the
+// document
+func (r *Root) verifySignatures(role string, s *Signed) error {
+	roleKeys, found := r.Roles[role]
+	if !found {
+		return fmt.Errorf("role %s is not defined in root version %d", role, r.Version)
+	}
+	if roleKeys.Threshold < 1 {
+		return fmt.Errorf("invalid threshold %d for role %s in root version %d", roleKeys.Threshold, role, r.Version)
+	}
+
+	allowed := make(map[string]bool, len(roleKeys.KeyIDs))
+	for _, id := range roleKeys.KeyIDs {
+		allowed[id] = true
+	}
+
+	valid := make(map[string]bool)
+	for _, sig := range s.Signatures {
+		if !allowed[sig.KeyID] || valid[sig.KeyID] {
+			continue
+		}
+		key, found := r.Keys[sig.KeyID]
+		if !found || key.KeyType != "ed25519" {
+			continue
+		}
+		public, err := hex.DecodeString(key.Public)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			continue
+		}
+		signature, err := hex.DecodeString(sig.Sig)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(ed25519.PublicKey(public), s.Signed, signature) {
+			valid[sig.KeyID] = true
+		}
+	}
+
+	if len(valid) < roleKeys.Threshold {
+		return fmt.Errorf("%s metadata has %d valid signatures, %d required", role, len(valid), roleKeys.Threshold)
+	}
+	return nil
+}
+
+// parseRoot decodes a root metadata without verifying it
+func parseRoot(s *Signed) (*Root, error) {
+	var root Root
+	if err := json.Unmarshal(s.Signed, &root); err != nil {
+		return nil, fmt.Errorf("invalid root metadata: %v", err)
+	}
+	if root.Type != roleRoot {
+		return nil, fmt.Errorf("invalid root metadata type %q", root.Type)
+	}
+	return &root, nil
+}
+
+// updateRoot verifies the next version of the root metadata, which must be
+// signed by the keys of the current version and by its own keys
+func updateRoot(current *Root, s *Signed) (*Root, error) {
+	next, err := parseRoot(s)
+	if err != nil {
+		return nil, err
+	}
+	if next.Version != current.Version+1 {
+		return nil, fmt.Errorf("root version %d can't follow version %d", next.Version, current.Version)
+	}
+	if err := current.verifySignatures(roleRoot, s); err != nil {
+		return nil, fmt.Errorf("root version %d: %v", next.Version, err)
+	}
+	if err := next.verifySignatures(roleRoot, s); err != nil {
+		return nil, fmt.Errorf("root version %d: %v", next.Version, err)
+	}
+	return next, nil
+}
+
+// verifyTargets verifies the targets metadata signed with the keys of root
+func verifyTargets(root *Root, s *Signed, now time.Time) (*Targets, error) {
+	if err := root.verifySignatures(roleTargets, s); err != nil {
+		return nil, err
+	}
+	var targets Targets
+	if err := json.Unmarshal(s.Signed, &targets); err != nil {
+		return nil, fmt.Errorf("invalid targets metadata: %v", err)
+	}
+	if targets.Type != roleTargets {
+		return nil, fmt.Errorf("invalid targets metadata type %q", targets.Type)
+	}
+	if now.After(targets.Expires) {
+		return nil, fmt.Errorf("targets version %d expired on %s", targets.Version, targets.Expires)
+	}
+	return &targets, nil
+}
+
+// verifyTargetFile checks the length and the sha256 hash of a file
+func verifyTargetFile(path string, target TargetFile, raw []byte) error {
+	if int64(len(raw)) != target.Length {
+		return fmt.Errorf("target %s has a length of %d, %d expected", path, len(raw), target.Length)
+	}
+	expected, found := target.Hashes["sha256"]
+	if !found {
+		return fmt.Errorf("target %s has no sha256 hash", path)
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) != expected {
+		return fmt.Errorf("target %s doesn't match its sha256 hash", path)
+	}
+	return nil
+}
@@ -22,7 +22,7 @@ const seelogConfigurationTemplate = `
 <seelog minlevel="{{.logLevel}}">
 	<outputs formatid="{{.format}}">
 		{{if .consoleLoggingEnabled}}<console />{{end}}
-		{{if .logfile              }}<rollingfile type="size" filename="{{.logfile}}" maxsize="{{.maxsize}}" maxrolls="{{.maxrolls}}" />{{end}}
+		{{if .logfile              }}<custom name="rotatingfile" data-path="{{.logfile}}" data-maxsize="{{.maxsize}}" data-maxrolls="{{.maxrolls}}" data-maxage="{{.maxage}}" data-compress="{{.compress}}" />{{end}}
 		{{if .syslogURI            }}<custom name="syslog" formatid="syslog-{{.format}}" data-uri="{{.syslogURI}}" data-tls="{{.syslogUseTLS}}" />{{end}}
 	</outputs>
 	<formats>
@@ -68,13 +68,18 @@ func (c *Config) SetLogLevel(l string) {
 	c.setValue("logLevel", l)
 }
 
-// EnableFileLogging enables and configures file logging if the filename is not empty
-func (c *Config) EnableFileLogging(f string, maxsize, maxrolls uint) {
+// EnableFileLogging enables and configures file logging if the filename is not empty.
+// The file is rotated once it reaches maxsize bytes, and the rotated files are
+// removed when there are more than maxrolls of them or when they are older than
+// maxage days. A zero maxrolls or maxage disables the matching retention.
+func (c *Config) EnableFileLogging(f string, maxsize, maxrolls, maxage uint, compress bool) {
 	c.Lock()
 	defer c.Unlock()
 	c.settings["logfile"] = f
 	c.settings["maxsize"] = maxsize
 	c.settings["maxrolls"] = maxrolls
+	c.settings["maxage"] = maxage
+	c.settings["compress"] = compress
 }
 
 // ConfigureSyslog enables and configures syslog if the syslogURI it not an empty string
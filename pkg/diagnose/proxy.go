@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+// intakeURLs returns the intake URLs the agent sends its payloads to, by product
+func intakeURLs() (map[string][]string, error) {
+	endpoints, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	var metrics []string
+	for domain := range endpoints {
+		metrics = append(metrics, domain)
+	}
+	sort.Strings(metrics)
+
+	return map[string][]string{
+		"metrics":   metrics,
+		"logs":      {withScheme(config.GetMainEndpoint("agent-http-intake.logs.", "logs_config.logs_dd_url"))},
+		"apm":       {withScheme(config.GetMainEndpoint("https://trace.agent.", "apm_config.apm_dd_url"))},
+		"processes": {withScheme(config.GetMainEndpoint("https://process.", "process_config.process_dd_url"))},
+	}, nil
+}
+
+func withScheme(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		return "https://" + rawURL
+	}
+	return rawURL
+}
+
+// RunProxy outputs the proxy used to reach each intake URL in writer
+func RunProxy(w io.Writer) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	urls, err := intakeURLs()
+	if err != nil {
+		return err
+	}
+	proxies := config.GetProxies()
+	if proxies == nil {
+		proxies = &config.Proxy{}
+	}
+	printProxies(w, proxies, urls)
+	return nil
+}
+
+func printProxies(w io.Writer, proxies *config.Proxy, urls map[string][]string) {
+	products := make([]string, 0, len(urls))
+	for product := range urls {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+
+	for _, product := range products {
+		fmt.Fprintln(w, fmt.Sprintf("=== %s ===", color.BlueString(product)))
+		for _, rawURL := range urls[product] {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				fmt.Fprintln(w, fmt.Sprintf("  %s: %s", rawURL, color.RedString("invalid URL: %v", err)))
+				continue
+			}
+			proxyURL, err := httputils.GetProxyForURL(proxies, u)
+			if err != nil {
+				fmt.Fprintln(w, fmt.Sprintf("  %s: %s", rawURL, color.RedString(err.Error())))
+				continue
+			}
+			fmt.Fprintln(w, fmt.Sprintf("  %s: %s", rawURL, describeProxy(proxyURL)))
+		}
+	}
+}
+
+// describeProxy returns the proxy URL without its credentials
+func describeProxy(proxyURL *url.URL) string {
+	if proxyURL == nil {
+		return "no proxy"
+	}
+	userInfo := ""
+	if proxyURL.User != nil {
+		if _, isSet := proxyURL.User.Password(); isSet {
+			userInfo = "*****:*****@"
+		} else {
+			userInfo = "*****@"
+		}
+	}
+	return fmt.Sprintf("proxy %s://%s%s", proxyURL.Scheme, userInfo, proxyURL.Host)
+}
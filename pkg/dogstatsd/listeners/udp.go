@@ -18,21 +18,21 @@ import (
 )
 
 var (
-	udpExpvars             = expvar.NewMap("dogstatsd-udp")
-	udpPacketReadingErrors = expvar.Int{}
-	udpPackets             = expvar.Int{}
-	udpBytes               = expvar.Int{}
+	udpExpvars = expvar.NewMap("dogstatsd-udp")
 
-	tlmUDPPackets = telemetry.NewCounter("dogstatsd", "udp_packets",
+	tlmUDPPackets = telemetry.NewStatCounter("dogstatsd", "udp_packets",
 		[]string{"state"}, "Dogstatsd UDP packets count")
-	tlmUDPPacketsBytes = telemetry.NewCounter("dogstatsd", "udp_packets_bytes",
-		nil, "Dogstatsd UDP packets bytes count")
+	udpPacketReadingErrors = tlmUDPPackets.WithValues("error")
+	udpPackets             = tlmUDPPackets.WithValues("ok")
+	udpBytes               = telemetry.NewStatCounter("dogstatsd", "udp_packets_bytes",
+		nil, "Dogstatsd UDP packets bytes count").WithValues()
 )
 
 func init() {
-	udpExpvars.Set("PacketReadingErrors", &udpPacketReadingErrors)
-	udpExpvars.Set("Packets", &udpPackets)
-	udpExpvars.Set("Bytes", &udpBytes)
+	udpExpvars.Set("PacketReadingErrors", udpPacketReadingErrors.Expvar())
+	// Packets counts the read attempts, including the errors
+	udpExpvars.Set("Packets", tlmUDPPackets.Expvar())
+	udpExpvars.Set("Bytes", udpBytes.Expvar())
 }
 
 // UDPListener implements the StatsdListener interface for UDP protocol.
@@ -96,7 +96,6 @@ func NewUDPListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDPL
 func (l *UDPListener) Listen() {
 	log.Infof("dogstatsd-udp: starting to listen on %s", l.conn.LocalAddr())
 	for {
-		udpPackets.Add(1)
 		n, _, err := l.conn.ReadFrom(l.buffer)
 		if err != nil {
 			// connection has been closed
@@ -105,14 +104,11 @@ func (l *UDPListener) Listen() {
 			}
 
 			log.Errorf("dogstatsd-udp: error reading packet: %v", err)
-			udpPacketReadingErrors.Add(1)
-			tlmUDPPackets.Inc("error")
+			udpPacketReadingErrors.Inc()
 			continue
 		}
-		tlmUDPPackets.Inc("ok")
-
+		udpPackets.Inc()
 		udpBytes.Add(int64(n))
-		tlmUDPPacketsBytes.Add(float64(n))
 
 		// packetAssembler merges multiple packets together and sends them when its buffer is full
 		l.packetAssembler.addMessage(l.buffer[:n])
@@ -20,27 +20,26 @@ import (
 )
 
 var (
-	udsExpvars               = expvar.NewMap("dogstatsd-uds")
-	udsOriginDetectionErrors = expvar.Int{}
-	udsPacketReadingErrors   = expvar.Int{}
-	udsPackets               = expvar.Int{}
-	udsBytes                 = expvar.Int{}
+	udsExpvars = expvar.NewMap("dogstatsd-uds")
 
-	tlmUDSPackets = telemetry.NewCounter("dogstatsd", "uds_packets",
+	tlmUDSPackets = telemetry.NewStatCounter("dogstatsd", "uds_packets",
 		[]string{"state"}, "Dogstatsd UDS packets count")
+	udsPacketReadingErrors = tlmUDSPackets.WithValues("error")
+	udsPackets             = tlmUDSPackets.WithValues("ok")
 
-	tlmUDSOriginDetectionError = telemetry.NewCounter("dogstatsd", "uds_origin_detection_error",
-		nil, "Dogstatsd UDS origin detection error count")
+	udsOriginDetectionErrors = telemetry.NewStatCounter("dogstatsd", "uds_origin_detection_error",
+		nil, "Dogstatsd UDS origin detection error count").WithValues()
 
-	tlmUDSPacketsBytes = telemetry.NewCounter("dogstatsd", "uds_packets_bytes",
-		nil, "Dogstatsd UDS packets bytes")
+	udsBytes = telemetry.NewStatCounter("dogstatsd", "uds_packets_bytes",
+		nil, "Dogstatsd UDS packets bytes").WithValues()
 )
 
 func init() {
-	udsExpvars.Set("OriginDetectionErrors", &udsOriginDetectionErrors)
-	udsExpvars.Set("PacketReadingErrors", &udsPacketReadingErrors)
-	udsExpvars.Set("Packets", &udsPackets)
-	udsExpvars.Set("Bytes", &udsBytes)
+	udsExpvars.Set("OriginDetectionErrors", udsOriginDetectionErrors.Expvar())
+	udsExpvars.Set("PacketReadingErrors", udsPacketReadingErrors.Expvar())
+	// Packets counts the read attempts, including the errors
+	udsExpvars.Set("Packets", tlmUDSPackets.Expvar())
+	udsExpvars.Set("Bytes", udsBytes.Expvar())
 }
 
 // UDSListener implements the StatsdListener interface for Unix Domain
@@ -133,7 +132,6 @@ func (l *UDSListener) Listen() {
 		// retrieve an available packet from the packet pool,
 		// which will be pushed back by the server when processed.
 		packet := l.sharedPacketPool.Get()
-		udsPackets.Add(1)
 		if l.OriginDetection {
 			// Read datagram + credentials in ancilary data
 			oob := l.oobPool.Get().([]byte)
@@ -143,8 +141,7 @@ func (l *UDSListener) Listen() {
 			container, taggingErr := processUDSOrigin(oob[:oobn])
 			if taggingErr != nil {
 				log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", taggingErr)
-				udsOriginDetectionErrors.Add(1)
-				tlmUDSOriginDetectionError.Inc()
+				udsOriginDetectionErrors.Inc()
 			} else {
 				packet.Origin = container
 			}
@@ -162,14 +159,11 @@ func (l *UDSListener) Listen() {
 			}
 
 			log.Errorf("dogstatsd-uds: error reading packet: %v", err)
-			udsPacketReadingErrors.Add(1)
-			tlmUDSPackets.Inc("error")
+			udsPacketReadingErrors.Inc()
 			continue
 		}
-		tlmUDSPackets.Inc("ok")
-
+		udsPackets.Inc()
 		udsBytes.Add(int64(n))
-		tlmUDSPacketsBytes.Add(float64(n))
 		packet.Contents = packet.buffer[:n]
 
 		// packetsBuffer handles the forwarding of the packets to the dogstatsd server intake channel
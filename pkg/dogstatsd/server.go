@@ -31,31 +31,30 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/memorypressure"
 )
 
 var (
-	dogstatsdExpvars                 = expvar.NewMap("dogstatsd")
-	dogstatsdServiceCheckParseErrors = expvar.Int{}
-	dogstatsdServiceCheckPackets     = expvar.Int{}
-	dogstatsdEventParseErrors        = expvar.Int{}
-	dogstatsdEventPackets            = expvar.Int{}
-	dogstatsdMetricParseErrors       = expvar.Int{}
-	dogstatsdMetricPackets           = expvar.Int{}
-	dogstatsdPacketsLastSec          = expvar.Int{}
-
-	tlmProcessed = telemetry.NewCounter("dogstatsd", "processed",
+	dogstatsdExpvars        = expvar.NewMap("dogstatsd")
+	dogstatsdPacketsLastSec = expvar.Int{}
+
+	tlmProcessed = telemetry.NewStatCounter("dogstatsd", "processed",
 		[]string{"message_type", "state"}, "Count of service checks/events/metrics processed by dogstatsd")
-	tlmProcessedErrorTags = map[string]string{"message_type": "metrics", "state": "error"}
-	tlmProcessedOkTags    = map[string]string{"message_type": "metrics", "state": "ok"}
+	dogstatsdServiceCheckParseErrors = tlmProcessed.WithValues("service_checks", "error")
+	dogstatsdServiceCheckPackets     = tlmProcessed.WithValues("service_checks", "ok")
+	dogstatsdEventParseErrors        = tlmProcessed.WithValues("events", "error")
+	dogstatsdEventPackets            = tlmProcessed.WithValues("events", "ok")
+	dogstatsdMetricParseErrors       = tlmProcessed.WithValues("metrics", "error")
+	dogstatsdMetricPackets           = tlmProcessed.WithValues("metrics", "ok")
 )
 
 func init() {
-	dogstatsdExpvars.Set("ServiceCheckParseErrors", &dogstatsdServiceCheckParseErrors)
-	dogstatsdExpvars.Set("ServiceCheckPackets", &dogstatsdServiceCheckPackets)
-	dogstatsdExpvars.Set("EventParseErrors", &dogstatsdEventParseErrors)
-	dogstatsdExpvars.Set("EventPackets", &dogstatsdEventPackets)
-	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
-	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
+	dogstatsdExpvars.Set("ServiceCheckParseErrors", dogstatsdServiceCheckParseErrors.Expvar())
+	dogstatsdExpvars.Set("ServiceCheckPackets", dogstatsdServiceCheckPackets.Expvar())
+	dogstatsdExpvars.Set("EventParseErrors", dogstatsdEventParseErrors.Expvar())
+	dogstatsdExpvars.Set("EventPackets", dogstatsdEventPackets.Expvar())
+	dogstatsdExpvars.Set("MetricParseErrors", dogstatsdMetricParseErrors.Expvar())
+	dogstatsdExpvars.Set("MetricPackets", dogstatsdMetricPackets.Expvar())
 }
 
 // Server represent a Dogstatsd server
@@ -316,11 +315,28 @@ func (s *Server) worker() {
 			return
 		case <-s.health.C:
 		case packets := <-s.packetsIn:
+			if s.shedPackets(packets) {
+				continue
+			}
 			s.parsePackets(batcher, parser, packets)
 		}
 	}
 }
 
+// shedPackets drops the packets while the memory pressure is hard and the
+// queue is more than a quarter full, reducing the memory the queue holds.
+// It returns whether the packets were dropped.
+func (s *Server) shedPackets(packets listeners.Packets) bool {
+	if memorypressure.CurrentLevel() < memorypressure.Hard || len(s.packetsIn) <= cap(s.packetsIn)/4 {
+		return false
+	}
+	for _, packet := range packets {
+		s.sharedPacketPool.Put(packet)
+	}
+	memorypressure.Shed(memorypressure.ActionDogstatsdPackets, len(packets))
+	return true
+}
+
 func nextMessage(packet *[]byte) (message []byte) {
 	if len(*packet) == 0 {
 		return nil
@@ -388,6 +404,9 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 					}
 					continue
 				}
+				if packet.Origin != listeners.NoOrigin {
+					sample.OriginID = packet.Origin
+				}
 				if atomic.LoadUint64(&s.Debug.Enabled) == 1 {
 					s.storeMetricStats(sample)
 				}
@@ -416,8 +435,7 @@ func (s *Server) errLog(format string, params ...interface{}) {
 func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFunc func() []string) (metrics.MetricSample, error) {
 	sample, err := parser.parseMetricSample(message)
 	if err != nil {
-		dogstatsdMetricParseErrors.Add(1)
-		tlmProcessed.IncWithTags(tlmProcessedErrorTags)
+		dogstatsdMetricParseErrors.Inc()
 		return metrics.MetricSample{}, err
 	}
 	if s.mapper != nil && len(sample.tags) == 0 {
@@ -429,36 +447,31 @@ func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFu
 	}
 	metricSample := enrichMetricSample(sample, s.metricPrefix, s.metricPrefixBlacklist, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	metricSample.Tags = append(metricSample.Tags, s.extraTags...)
-	dogstatsdMetricPackets.Add(1)
-	tlmProcessed.IncWithTags(tlmProcessedOkTags)
+	dogstatsdMetricPackets.Inc()
 	return metricSample, nil
 }
 
 func (s *Server) parseEventMessage(parser *parser, message []byte, originTagsFunc func() []string) (*metrics.Event, error) {
 	sample, err := parser.parseEvent(message)
 	if err != nil {
-		dogstatsdEventParseErrors.Add(1)
-		tlmProcessed.Inc("events", "error")
+		dogstatsdEventParseErrors.Inc()
 		return nil, err
 	}
 	event := enrichEvent(sample, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	event.Tags = append(event.Tags, s.extraTags...)
-	tlmProcessed.Inc("events", "ok")
-	dogstatsdEventPackets.Add(1)
+	dogstatsdEventPackets.Inc()
 	return event, nil
 }
 
 func (s *Server) parseServiceCheckMessage(parser *parser, message []byte, originTagsFunc func() []string) (*metrics.ServiceCheck, error) {
 	sample, err := parser.parseServiceCheck(message)
 	if err != nil {
-		dogstatsdServiceCheckParseErrors.Add(1)
-		tlmProcessed.Inc("service_checks", "error")
+		dogstatsdServiceCheckParseErrors.Inc()
 		return nil, err
 	}
 	serviceCheck := enrichServiceCheck(sample, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	serviceCheck.Tags = append(serviceCheck.Tags, s.extraTags...)
-	dogstatsdServiceCheckPackets.Add(1)
-	tlmProcessed.Inc("service_checks", "ok")
+	dogstatsdServiceCheckPackets.Inc()
 	return serviceCheck, nil
 }
 
@@ -0,0 +1,38 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
+)
+
+// loadBCCSource returns an embedded eBPF source, with the embedded headers it
+// includes inlined
+func loadBCCSource(name string) (string, error) {
+	raw, err := bytecode.Asset(name)
+	if err != nil {
+		return "", fmt.Errorf("couldn't find asset %s: %v", name, err)
+	}
+
+	includeRegexp := regexp.MustCompile(`^\s*#\s*include\s+"(.*)"$`)
+	var source bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewBuffer(raw))
+	for scanner.Scan() {
+		match := includeRegexp.FindSubmatch(scanner.Bytes())
+		if len(match) == 2 {
+			header, err := bytecode.Asset(string(match[1]))
+			if err == nil {
+				source.Write(header)
+				continue
+			}
+		}
+		source.Write(scanner.Bytes())
+		source.WriteByte('\n')
+	}
+	return source.String(), nil
+}
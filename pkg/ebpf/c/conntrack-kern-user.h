@@ -0,0 +1,17 @@
+#ifndef CONNTRACK_KERN_USER_H
+#define CONNTRACK_KERN_USER_H
+
+#include <linux/types.h>
+
+// conntrack_tuple is an IPv4 conntrack tuple, addresses and ports being kept
+// in network byte order as in struct nf_conntrack_tuple
+struct conntrack_tuple {
+  __u32 saddr;
+  __u32 daddr;
+  __u16 sport;
+  __u16 dport;
+  __u8 proto;
+  __u8 _pad[3];
+};
+
+#endif /* defined(CONNTRACK_KERN_USER_H) */
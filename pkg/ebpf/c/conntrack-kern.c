@@ -0,0 +1,73 @@
+#include <linux/types.h>
+#include <linux/socket.h>
+#include <net/netfilter/nf_conntrack.h>
+
+#include "conntrack-kern-user.h"
+
+// MAX_STATE_SIZE is set from system_probe_config.conntrack_max_state_size at compile time
+#ifndef MAX_STATE_SIZE
+#define MAX_STATE_SIZE 65536
+#endif
+
+// conntrack maps each tuple of a NATed connection to the tuple of the other direction
+BPF_HASH(conntrack, struct conntrack_tuple, struct conntrack_tuple, MAX_STATE_SIZE);
+
+// telemetry counts the registered (0) and dropped (1) connections
+BPF_ARRAY(telemetry, __u64, 2);
+
+static inline int read_tuple(struct nf_conn* ct, int dir, struct conntrack_tuple* t) {
+  struct nf_conntrack_tuple tuple = ct->tuplehash[dir].tuple;
+
+  if (tuple.src.l3num != AF_INET) {
+    return -1;
+  }
+
+  t->saddr = tuple.src.u3.ip;
+  t->daddr = tuple.dst.u3.ip;
+  t->sport = tuple.src.u.all;
+  t->dport = tuple.dst.u.all;
+  t->proto = tuple.dst.protonum;
+  return 0;
+}
+
+static inline int is_nat(struct conntrack_tuple* orig, struct conntrack_tuple* reply) {
+  return orig->saddr != reply->daddr || orig->daddr != reply->saddr || orig->sport != reply->dport || orig->dport != reply->sport;
+}
+
+// kprobe__nf_conntrack_hash_insert is attached to __nf_conntrack_hash_insert, called when a
+// connection is confirmed, after the NAT rules have been applied to its reply tuple
+int kprobe__nf_conntrack_hash_insert(struct pt_regs* ctx, struct nf_conn* ct) {
+  struct conntrack_tuple orig = {};
+  struct conntrack_tuple reply = {};
+
+  if (read_tuple(ct, IP_CT_DIR_ORIGINAL, &orig) || read_tuple(ct, IP_CT_DIR_REPLY, &reply)) {
+    return 0;
+  }
+  if (!is_nat(&orig, &reply)) {
+    return 0;
+  }
+
+  int idx = 0;
+  if (conntrack.update(&orig, &reply) || conntrack.update(&reply, &orig)) {
+    idx = 1;
+  }
+  __u64* count = telemetry.lookup(&idx);
+  if (count) {
+    __sync_fetch_and_add(count, 1);
+  }
+  return 0;
+}
+
+// kprobe__nf_ct_delete removes the tuples of a connection leaving the conntrack table
+int kprobe__nf_ct_delete(struct pt_regs* ctx, struct nf_conn* ct) {
+  struct conntrack_tuple orig = {};
+  struct conntrack_tuple reply = {};
+
+  if (read_tuple(ct, IP_CT_DIR_ORIGINAL, &orig) || read_tuple(ct, IP_CT_DIR_REPLY, &reply)) {
+    return 0;
+  }
+
+  conntrack.delete(&orig);
+  conntrack.delete(&reply);
+  return 0;
+}
@@ -0,0 +1,28 @@
+#ifndef FILE_INTEGRITY_KERN_USER_H
+#define FILE_INTEGRITY_KERN_USER_H
+
+#include <linux/types.h>
+
+#define FIM_MAX_DEPTH 16
+#define FIM_NAME_LEN 64
+
+enum fim_event_type {
+  FIM_EVENT_WRITE = 1,
+  FIM_EVENT_UNLINK,
+  FIM_EVENT_RENAME,
+};
+
+// fim_event describes a change of a file, its path being made of the names
+// of the dentries from the file up to the root of its mount namespace, empty
+// names marking the mount points
+struct fim_event {
+  __u32 pid;
+  __u32 type;
+  __u64 inode;
+  __u32 depth;
+  __u32 truncated;
+  char comm[16];
+  char names[FIM_MAX_DEPTH][FIM_NAME_LEN];
+};
+
+#endif /* defined(FILE_INTEGRITY_KERN_USER_H) */
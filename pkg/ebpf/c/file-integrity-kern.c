@@ -0,0 +1,116 @@
+#include <linux/kconfig.h>
+#include <linux/ptrace.h>
+#include <linux/fs.h>
+#include <linux/dcache.h>
+
+#include "file-integrity-kern-user.h"
+
+BPF_PERF_OUTPUT(fim_events);
+
+// fim_scratch holds the event being built, too large for the stack
+BPF_PERCPU_ARRAY(fim_scratch, struct fim_event, 1);
+
+// fim_mount mirrors the beginning of the kernel private struct mount, stable
+// since 3.3, to cross the mount points while walking up a path
+struct fim_mount {
+  struct hlist_node mnt_hash;
+  struct fim_mount* mnt_parent;
+  struct dentry* mnt_mountpoint;
+  struct vfsmount mnt;
+};
+
+// TODO: replace all `bpf_probe_read` by `bpf_probe_read_kernel` once we can assume that we have at least kernel 5.5
+static inline int send_event(struct pt_regs* ctx, struct vfsmount* vfsmnt, struct dentry* dentry, __u32 type) {
+  int zero = 0;
+  struct fim_event* e = fim_scratch.lookup(&zero);
+  if (e == NULL) {
+    return 0;
+  }
+
+  e->pid = bpf_get_current_pid_tgid() >> 32;
+  e->type = type;
+  e->inode = 0;
+  e->depth = 0;
+  e->truncated = 1;
+  bpf_get_current_comm(&e->comm, sizeof(e->comm));
+
+  struct inode* inode;
+  if (!bpf_probe_read(&inode, sizeof(inode), &dentry->d_inode) && inode != NULL) {
+    bpf_probe_read(&e->inode, sizeof(e->inode), &inode->i_ino);
+  }
+
+  // Walk up to the root of the mount namespace, which is the root filesystem
+  // of the container for container workloads. At the root of a mount, the
+  // walk continues from its mount point in the parent mount, leaving an
+  // empty name: the root dentry of a bind mounted file is named after its
+  // source instead of its mount point.
+  struct fim_mount* mnt = container_of(vfsmnt, struct fim_mount, mnt);
+#pragma unroll
+  for (int i = 0; i < FIM_MAX_DEPTH; i++) {
+    struct dentry* mnt_root;
+    struct dentry* parent;
+    bpf_probe_read(&mnt_root, sizeof(mnt_root), &mnt->mnt.mnt_root);
+    bpf_probe_read(&parent, sizeof(parent), &dentry->d_parent);
+    e->depth = i + 1;
+
+    if (dentry == mnt_root || dentry == parent) {
+      struct fim_mount* mnt_parent;
+      bpf_probe_read(&mnt_parent, sizeof(mnt_parent), &mnt->mnt_parent);
+      if (mnt_parent == mnt) {
+        e->names[i][0] = 0;
+        e->truncated = 0;
+        break;
+      }
+      bpf_probe_read(&dentry, sizeof(dentry), &mnt->mnt_mountpoint);
+      mnt = mnt_parent;
+      e->names[i][0] = 0;
+      continue;
+    }
+
+    struct qstr d_name;
+    bpf_probe_read(&d_name, sizeof(d_name), &dentry->d_name);
+    bpf_probe_read_str(&e->names[i], FIM_NAME_LEN, d_name.name);
+    dentry = parent;
+  }
+
+  fim_events.perf_submit(ctx, e, sizeof(*e));
+  return 0;
+}
+
+// kprobe____fput reports the regular files opened for writing when they are
+// closed, their content being hashed by the userland program
+int kprobe____fput(struct pt_regs* ctx, struct file* file) {
+  fmode_t mode;
+  bpf_probe_read(&mode, sizeof(mode), &file->f_mode);
+  if (!(mode & FMODE_WRITE)) {
+    return 0;
+  }
+
+  struct inode* inode;
+  umode_t i_mode;
+  bpf_probe_read(&inode, sizeof(inode), &file->f_inode);
+  bpf_probe_read(&i_mode, sizeof(i_mode), &inode->i_mode);
+  if (!S_ISREG(i_mode)) {
+    return 0;
+  }
+
+  struct path f_path;
+  bpf_probe_read(&f_path, sizeof(f_path), &file->f_path);
+  return send_event(ctx, f_path.mnt, f_path.dentry, FIM_EVENT_WRITE);
+}
+
+// The security_path hooks are used rather than vfs_unlink and vfs_rename as
+// they also get the mount of the directory, they require CONFIG_SECURITY_PATH
+int kprobe__security_path_unlink(struct pt_regs* ctx, const struct path* dir, struct dentry* dentry) {
+  struct vfsmount* mnt;
+  bpf_probe_read(&mnt, sizeof(mnt), &dir->mnt);
+  return send_event(ctx, mnt, dentry, FIM_EVENT_UNLINK);
+}
+
+// kprobe__security_path_rename reports the destination of a rename, replacing
+// a watched file by another one being the common way to update it atomically
+int kprobe__security_path_rename(struct pt_regs* ctx, const struct path* old_dir, struct dentry* old_dentry, const struct path* new_dir, struct dentry* new_dentry) {
+  struct vfsmount* mnt;
+  bpf_probe_read(&mnt, sizeof(mnt), &new_dir->mnt);
+  return send_event(ctx, mnt, new_dentry, FIM_EVENT_RENAME);
+}
@@ -4,6 +4,16 @@ import (
 	"time"
 )
 
+// Conntrack modes
+const (
+	// ConntrackModeNetlink tracks NAT translations from the netlink conntrack events
+	ConntrackModeNetlink = "netlink"
+	// ConntrackModeEBPF tracks NAT translations with kprobes on the conntrack table
+	ConntrackModeEBPF = "ebpf"
+	// ConntrackModeAuto uses netlink, falling back to eBPF if netlink is unavailable
+	ConntrackModeAuto = "auto"
+)
+
 // Config stores all flags used by the eBPF tracer
 type Config struct {
 	// CollectTCPConns specifies whether the tracer should collect traffic statistics for TCP connections
@@ -68,9 +78,13 @@ type Config struct {
 	// BPFDebug enables bpf debug logs
 	BPFDebug bool
 
-	// EnableConntrack enables probing conntrack for network address translation via netlink
+	// EnableConntrack enables probing conntrack for network address translation
 	EnableConntrack bool
 
+	// ConntrackMode selects how conntrack is probed: through netlink, through eBPF probes on the
+	// conntrack table, or through netlink with a fallback to eBPF when netlink is unavailable
+	ConntrackMode string
+
 	// ConntrackMaxStateSize specifies the maximum number of connections with NAT we can track
 	ConntrackMaxStateSize int
 
@@ -108,6 +122,7 @@ func NewDefaultConfig() *Config {
 		ProcRoot:              "/proc",
 		BPFDebug:              false,
 		EnableConntrack:       true,
+		ConntrackMode:         ConntrackModeNetlink,
 		// With clients checking connection stats roughly every 30s, this gives us roughly ~1.6k + ~2.5k objects a second respectively.
 		MaxClosedConnectionsBuffered: 50000,
 		MaxConnectionsStateBuffered:  75000,
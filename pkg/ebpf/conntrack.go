@@ -0,0 +1,121 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+// ebpfConntracker resolves NAT translations from the conntrack entries
+// recorded by kprobes on the netfilter conntrack table, for hosts where
+// the netlink conntrack events are unavailable or too costly
+type ebpfConntracker struct {
+	m         *bpflib.Module
+	table     *bpflib.Table
+	telemetry *bpflib.Table
+	stats     struct {
+		gets         int64
+		getTimeTotal int64
+		unregisters  int64
+	}
+}
+
+// NewEBPFConntracker compiles and attaches the conntrack eBPF program
+func NewEBPFConntracker(maxStateSize int) (netlink.Conntracker, error) {
+	source, err := loadBCCSource("conntrack-kern.c")
+	if err != nil {
+		return nil, err
+	}
+
+	m := bpflib.NewModule(source, []string{fmt.Sprintf("-DMAX_STATE_SIZE=%d", maxStateSize)})
+	if m == nil {
+		return nil, fmt.Errorf("failed to compile conntrack-kern.c")
+	}
+
+	for fn, probe := range map[string]string{
+		"__nf_conntrack_hash_insert": "kprobe__nf_conntrack_hash_insert",
+		"nf_ct_delete":               "kprobe__nf_ct_delete",
+	} {
+		fd, err := m.LoadKprobe(probe)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to load %s: %s", probe, err)
+		}
+		if err := m.AttachKprobe(fn, fd, -1); err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to attach %s: %s", fn, err)
+		}
+	}
+
+	log.Infof("initialized eBPF conntrack with max_state_size=%d", maxStateSize)
+	return &ebpfConntracker{
+		m:         m,
+		table:     bpflib.NewTable(m.TableId("conntrack"), m),
+		telemetry: bpflib.NewTable(m.TableId("telemetry"), m),
+	}, nil
+}
+
+func (e *ebpfConntracker) GetTranslationForConn(c network.ConnectionStats) *network.IPTranslation {
+	then := time.Now().UnixNano()
+	defer func() {
+		atomic.AddInt64(&e.stats.gets, 1)
+		atomic.AddInt64(&e.stats.getTimeTotal, time.Now().UnixNano()-then)
+	}()
+
+	key, ok := newConntrackTuple(c)
+	if !ok {
+		return nil
+	}
+	value, err := e.table.Get(key.MarshalBinary())
+	if err != nil {
+		return nil
+	}
+	reply, ok := unmarshalConntrackTuple(value)
+	if !ok {
+		return nil
+	}
+	return reply.ipTranslation()
+}
+
+func (e *ebpfConntracker) DeleteTranslation(c network.ConnectionStats) {
+	key, ok := newConntrackTuple(c)
+	if !ok {
+		return
+	}
+	// The entries are removed when conntrack deletes the connection, this
+	// only covers the ones whose deletion was missed
+	_ = e.table.Delete(key.MarshalBinary())
+	_ = e.table.Delete(key.reversed().MarshalBinary())
+	atomic.AddInt64(&e.stats.unregisters, 1)
+}
+
+func (e *ebpfConntracker) GetStats() map[string]int64 {
+	m := map[string]int64{
+		"ebpf_conntracker":  1,
+		"gets_total":        atomic.LoadInt64(&e.stats.gets),
+		"unregisters_total": atomic.LoadInt64(&e.stats.unregisters),
+	}
+	if gets := m["gets_total"]; gets != 0 {
+		m["nanoseconds_per_get"] = atomic.LoadInt64(&e.stats.getTimeTotal) / gets
+	}
+	for idx, name := range []string{"registers_total", "registers_dropped"} {
+		key := make([]byte, 4)
+		bpflib.GetHostByteOrder().PutUint32(key, uint32(idx))
+		if value, err := e.telemetry.Get(key); err == nil && len(value) >= 8 {
+			m[name] = int64(bpflib.GetHostByteOrder().Uint64(value))
+		}
+	}
+	return m
+}
+
+func (e *ebpfConntracker) Close() {
+	e.m.Close()
+}
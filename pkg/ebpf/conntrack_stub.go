@@ -0,0 +1,10 @@
+// +build linux_bpf,!bcc
+
+package ebpf
+
+import "github.com/DataDog/datadog-agent/pkg/network/netlink"
+
+// NewEBPFConntracker is not implemented without bcc support
+func NewEBPFConntracker(maxStateSize int) (netlink.Conntracker, error) {
+	return nil, ErrNotImplemented
+}
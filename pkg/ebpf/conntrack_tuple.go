@@ -0,0 +1,87 @@
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// conntrackTupleSize is the size of struct conntrack_tuple in c/conntrack-kern-user.h
+	conntrackTupleSize = 16
+
+	protoTCP = 6
+	protoUDP = 17
+)
+
+// conntrackTuple mirrors struct conntrack_tuple, addresses and ports being
+// in network byte order
+type conntrackTuple struct {
+	saddr [4]byte
+	daddr [4]byte
+	sport uint16
+	dport uint16
+	proto uint8
+}
+
+// newConntrackTuple returns the tuple of a connection, false if the
+// connection cannot be tracked by the eBPF conntracker
+func newConntrackTuple(c network.ConnectionStats) (conntrackTuple, bool) {
+	var t conntrackTuple
+	if c.Family != network.AFINET || c.Source == nil || c.Dest == nil {
+		return t, false
+	}
+	copy(t.saddr[:], c.Source.Bytes())
+	copy(t.daddr[:], c.Dest.Bytes())
+	t.sport = c.SPort
+	t.dport = c.DPort
+	t.proto = protoTCP
+	if c.Type == network.UDP {
+		t.proto = protoUDP
+	}
+	return t, true
+}
+
+// reversed returns the tuple of the other direction of the connection
+func (t conntrackTuple) reversed() conntrackTuple {
+	return conntrackTuple{saddr: t.daddr, daddr: t.saddr, sport: t.dport, dport: t.sport, proto: t.proto}
+}
+
+// MarshalBinary encodes the tuple as the eBPF map key
+func (t conntrackTuple) MarshalBinary() []byte {
+	b := make([]byte, conntrackTupleSize)
+	copy(b[0:4], t.saddr[:])
+	copy(b[4:8], t.daddr[:])
+	binary.BigEndian.PutUint16(b[8:10], t.sport)
+	binary.BigEndian.PutUint16(b[10:12], t.dport)
+	b[12] = t.proto
+	return b
+}
+
+// unmarshalConntrackTuple decodes a tuple read from the eBPF map
+func unmarshalConntrackTuple(b []byte) (conntrackTuple, bool) {
+	var t conntrackTuple
+	if len(b) < conntrackTupleSize {
+		return t, false
+	}
+	copy(t.saddr[:], b[0:4])
+	copy(t.daddr[:], b[4:8])
+	t.sport = binary.BigEndian.Uint16(b[8:10])
+	t.dport = binary.BigEndian.Uint16(b[10:12])
+	t.proto = b[12]
+	return t, true
+}
+
+// ipTranslation returns the translation described by the tuple of the
+// reply direction
+func (t conntrackTuple) ipTranslation() *network.IPTranslation {
+	return &network.IPTranslation{
+		ReplSrcIP:   util.V4AddressFromBytes(t.saddr[:]),
+		ReplDstIP:   util.V4AddressFromBytes(t.daddr[:]),
+		ReplSrcPort: t.sport,
+		ReplDstPort: t.dport,
+	}
+}
@@ -0,0 +1,50 @@
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestConntrackTuple(t *testing.T) {
+	conn := network.ConnectionStats{
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("10.96.0.10"),
+		SPort:  34567,
+		DPort:  53,
+		Type:   network.UDP,
+		Family: network.AFINET,
+	}
+
+	tuple, ok := newConntrackTuple(conn)
+	require.True(t, ok)
+	b := tuple.MarshalBinary()
+	assert.Equal(t, []byte{10, 0, 0, 1, 10, 96, 0, 10, 0x87, 0x07, 0, 53, protoUDP, 0, 0, 0}, b)
+
+	decoded, ok := unmarshalConntrackTuple(b)
+	require.True(t, ok)
+	assert.Equal(t, tuple, decoded)
+	assert.Equal(t, tuple, tuple.reversed().reversed())
+
+	// the reply tuple of a DNAT to 10.0.1.5:5353
+	reply := conntrackTuple{saddr: [4]byte{10, 0, 1, 5}, daddr: [4]byte{10, 0, 0, 1}, sport: 5353, dport: 34567, proto: protoUDP}
+	assert.Equal(t, &network.IPTranslation{
+		ReplSrcIP:   util.AddressFromString("10.0.1.5"),
+		ReplDstIP:   util.AddressFromString("10.0.0.1"),
+		ReplSrcPort: 5353,
+		ReplDstPort: 34567,
+	}, reply.ipTranslation())
+
+	conn.Family = network.AFINET6
+	_, ok = newConntrackTuple(conn)
+	assert.False(t, ok)
+
+	_, ok = unmarshalConntrackTuple(b[:8])
+	assert.False(t, ok)
+}
@@ -0,0 +1,147 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/fim"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+/*
+#include <string.h>
+#include "c/file-integrity-kern-user.h"
+*/
+import "C"
+
+var fimEventTypes = map[C.__u32]string{
+	C.FIM_EVENT_WRITE:  fim.EventWrite,
+	C.FIM_EVENT_UNLINK: fim.EventUnlink,
+	C.FIM_EVENT_RENAME: fim.EventRename,
+}
+
+// FileIntegrityMonitor reports the changes of the files matching a policy,
+// with the hash of their content and the ancestry of the process changing them
+type FileIntegrityMonitor struct {
+	m         *bpflib.Module
+	perfMap   *bpflib.PerfMap
+	processor *fim.Processor
+	events    chan []byte
+	lost      chan uint64
+	out       chan *fim.Event
+	stop      chan struct{}
+}
+
+// NewFileIntegrityMonitor compiles and attaches the file integrity probes
+func NewFileIntegrityMonitor(policy fim.Policy, procRoot string, maxHashSize int64) (*FileIntegrityMonitor, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	source, err := loadBCCSource("file-integrity-kern.c")
+	if err != nil {
+		return nil, err
+	}
+
+	m := bpflib.NewModule(source, []string{})
+	if m == nil {
+		return nil, fmt.Errorf("failed to compile file-integrity-kern.c")
+	}
+
+	for _, fn := range []string{"__fput", "security_path_unlink", "security_path_rename"} {
+		probe, err := m.LoadKprobe("kprobe__" + fn)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to load kprobe__%s: %s", fn, err)
+		}
+		if err := m.AttachKprobe(fn, probe, -1); err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to attach %s: %s", fn, err)
+		}
+	}
+
+	events := make(chan []byte, 1024)
+	lost := make(chan uint64, 10)
+	perfMap, err := bpflib.InitPerfMap(bpflib.NewTable(m.TableId("fim_events"), m), events, lost)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("failed to init the fim_events perf map: %s", err)
+	}
+
+	return &FileIntegrityMonitor{
+		m:         m,
+		perfMap:   perfMap,
+		processor: fim.NewProcessor(policy, procRoot, maxHashSize),
+		events:    events,
+		lost:      lost,
+		out:       make(chan *fim.Event, 100),
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+// Start starts reading the events, the changes of the watched files being
+// sent to the Events channel
+func (f *FileIntegrityMonitor) Start() {
+	f.perfMap.Start()
+	go func() {
+		defer close(f.out)
+		for {
+			select {
+			case <-f.stop:
+				return
+			case count := <-f.lost:
+				log.Warnf("Lost %d file integrity events", count)
+			case data := <-f.events:
+				if len(data) < C.sizeof_struct_fim_event {
+					continue
+				}
+				var raw C.struct_fim_event
+				C.memcpy(unsafe.Pointer(&raw), unsafe.Pointer(&data[0]), C.sizeof_struct_fim_event)
+				event, ok := f.processor.Process(convertFIMEvent(raw))
+				if !ok {
+					continue
+				}
+				select {
+				case f.out <- event:
+				case <-f.stop:
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Events returns the channel of the changes of the watched files, closed
+// once the monitor is stopped
+func (f *FileIntegrityMonitor) Events() <-chan *fim.Event {
+	return f.out
+}
+
+// Stop detaches the probes
+func (f *FileIntegrityMonitor) Stop() {
+	close(f.stop)
+	f.perfMap.Stop()
+	f.m.Close()
+}
+
+func convertFIMEvent(in C.struct_fim_event) fim.RawEvent {
+	depth := int(in.depth)
+	if depth > C.FIM_MAX_DEPTH {
+		depth = C.FIM_MAX_DEPTH
+	}
+	names := make([]string, 0, depth)
+	for i := 0; i < depth; i++ {
+		names = append(names, C.GoString(&in.names[i][0]))
+	}
+	return fim.RawEvent{
+		Type:  fimEventTypes[in._type],
+		Pid:   uint32(in.pid),
+		Comm:  C.GoString(&in.comm[0]),
+		Inode: uint64(in.inode),
+		Path:  fim.PathFromNames(names, in.truncated != 0),
+	}
+}
@@ -0,0 +1,24 @@
+// +build !linux_bpf linux_bpf,!bcc
+
+package ebpf
+
+import "github.com/DataDog/datadog-agent/pkg/ebpf/fim"
+
+// FileIntegrityMonitor is not implemented on non-linux systems
+type FileIntegrityMonitor struct{}
+
+// NewFileIntegrityMonitor is not implemented on non-linux systems
+func NewFileIntegrityMonitor(policy fim.Policy, procRoot string, maxHashSize int64) (*FileIntegrityMonitor, error) {
+	return nil, ErrNotImplemented
+}
+
+// Start is not implemented on non-linux systems
+func (f *FileIntegrityMonitor) Start() {}
+
+// Events is not implemented on non-linux systems
+func (f *FileIntegrityMonitor) Events() <-chan *fim.Event {
+	return nil
+}
+
+// Stop is not implemented on non-linux systems
+func (f *FileIntegrityMonitor) Stop() {}
@@ -0,0 +1,85 @@
+package fim
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathFromNames(t *testing.T) {
+	assert.Equal(t, "/etc/passwd", PathFromNames([]string{"passwd", "etc", "/"}, false))
+	// /var mounted on its own partition
+	assert.Equal(t, "/var/log/syslog", PathFromNames([]string{"syslog", "log", "", "var", ""}, false))
+	assert.Equal(t, ".../lib/app/config.yaml", PathFromNames([]string{"config.yaml", "app", "lib"}, true))
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{Paths: []string{"/etc/passwd", "/etc/ssh/*_config", "/usr/bin/**"}}
+	require.NoError(t, policy.Validate())
+
+	assert.True(t, policy.Matches("/etc/passwd"))
+	assert.True(t, policy.Matches("/etc/ssh/sshd_config"))
+	assert.True(t, policy.Matches("/usr/bin/ls"))
+	assert.True(t, policy.Matches("/usr/bin/x86_64/ld"))
+	assert.False(t, policy.Matches("/etc/shadow"))
+	assert.False(t, policy.Matches("/etc/ssh/keys/sshd_config"))
+	assert.False(t, policy.Matches("/usr/bin"))
+
+	assert.Error(t, Policy{Paths: []string{"etc/passwd"}}.Validate())
+	assert.Error(t, Policy{Paths: []string{"/etc/[passwd"}}.Validate())
+}
+
+func TestProcessor(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "fim")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeFile := func(path, content string) {
+		path = filepath.Join(procRoot, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	writeFile("1/stat", "1 (systemd) S 0 1 1 0 -1")
+	writeFile("42/stat", "42 (vim editor) S 1 42 42 0 -1")
+	writeFile("42/root/etc/passwd", "root:x:0:0::/root:/bin/bash\n")
+	writeFile("43/root/etc/passwd", "root:x:0:0::/root:/bin/bash\n")
+	for _, pid := range []string{"42", "43"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(procRoot, pid, "ns"), 0755))
+		require.NoError(t, os.Symlink("mnt:[4026531840]", filepath.Join(procRoot, pid, "ns", "mnt")))
+	}
+
+	p := NewProcessor(Policy{Paths: []string{"/etc/passwd"}}, procRoot, 1024)
+
+	_, reported := p.Process(RawEvent{Type: EventWrite, Pid: 42, Path: "/etc/hosts"})
+	assert.False(t, reported)
+
+	event, reported := p.Process(RawEvent{Type: EventWrite, Pid: 42, Inode: 12, Path: "/etc/passwd"})
+	require.True(t, reported)
+	assert.Equal(t, "/etc/passwd", event.Path)
+	assert.Equal(t, uint64(12), event.Inode)
+	assert.Equal(t, "5e06477834f51abf42ea4e8dc199632afc6afbfd8c44354685a271e9a48d2c0a", event.SHA256)
+	assert.Equal(t, []Process{{Pid: 42, Name: "vim editor"}, {Pid: 1, Name: "systemd"}}, event.Ancestry)
+
+	// Closing the file without changing its content isn't reported
+	_, reported = p.Process(RawEvent{Type: EventWrite, Pid: 42, Path: "/etc/passwd"})
+	assert.False(t, reported)
+
+	// Nor by another process of the same mount namespace
+	_, reported = p.Process(RawEvent{Type: EventWrite, Pid: 43, Path: "/etc/passwd"})
+	assert.False(t, reported)
+
+	writeFile("42/root/etc/passwd", "root:x:0:0::/root:/bin/sh\n")
+	event, reported = p.Process(RawEvent{Type: EventWrite, Pid: 42, Path: "/etc/passwd"})
+	require.True(t, reported)
+	assert.Len(t, event.SHA256, 64)
+
+	// The ancestry falls back to the command of exited processes
+	event, reported = p.Process(RawEvent{Type: EventUnlink, Pid: 50, Comm: "rm", Path: "/etc/passwd"})
+	require.True(t, reported)
+	assert.Empty(t, event.SHA256)
+	assert.Equal(t, []Process{{Pid: 50, Name: "rm"}}, event.Ancestry)
+}
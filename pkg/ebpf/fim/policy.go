@@ -0,0 +1,45 @@
+package fim
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Policy lists the paths to watch, as glob patterns. Patterns ending with
+// "/**" match the files of a directory and of all its subdirectories.
+type Policy struct {
+	Paths []string
+}
+
+// Validate checks the syntax of the patterns
+func (p Policy) Validate() error {
+	for _, pattern := range p.Paths {
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("pattern %q is not an absolute path", pattern)
+		}
+		if _, err := filepath.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Matches returns whether a path is watched
+func (p Policy) Matches(path string) bool {
+	for _, pattern := range p.Paths {
+		if dir := strings.TrimSuffix(pattern, "/**"); dir != pattern {
+			// Match the directory against the ancestors of the path
+			for parent := filepath.Dir(path); parent != "/" && parent != "."; parent = filepath.Dir(parent) {
+				if matched, _ := filepath.Match(dir, parent); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package fim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxAncestryDepth = 16
+	// maxTrackedFiles bounds the hashes kept to detect content changes
+	maxTrackedFiles = 10000
+)
+
+// Processor filters the raw events against the policy and enriches them with
+// the hash of the files and the ancestry of the processes changing them
+type Processor struct {
+	policy      Policy
+	procRoot    string
+	maxHashSize int64
+	hashes      map[string]string // maps the files to the last hash seen
+}
+
+// NewProcessor returns a Processor. Files larger than maxHashSize aren't hashed.
+func NewProcessor(policy Policy, procRoot string, maxHashSize int64) *Processor {
+	return &Processor{
+		policy:      policy,
+		procRoot:    procRoot,
+		maxHashSize: maxHashSize,
+		hashes:      make(map[string]string),
+	}
+}
+
+// Process returns the event to report for a raw event, or false if the file
+// isn't watched or its content didn't change
+func (p *Processor) Process(raw RawEvent) (*Event, bool) {
+	if !p.policy.Matches(raw.Path) {
+		return nil, false
+	}
+
+	event := &Event{
+		Type:      raw.Type,
+		Path:      raw.Path,
+		Inode:     raw.Inode,
+		Timestamp: time.Now(),
+	}
+
+	// The path is resolved in the mount namespace of the process
+	key := p.fileKey(raw.Pid, raw.Path)
+	if raw.Type == EventUnlink {
+		delete(p.hashes, key)
+	} else {
+		hash, err := p.hash(filepath.Join(p.procRoot, strconv.Itoa(int(raw.Pid)), "root", raw.Path))
+		if err == nil {
+			if raw.Type == EventWrite && p.hashes[key] == hash {
+				return nil, false
+			}
+			if len(p.hashes) >= maxTrackedFiles {
+				p.hashes = make(map[string]string)
+			}
+			p.hashes[key] = hash
+			event.SHA256 = hash
+		}
+	}
+
+	event.Ancestry = p.ancestry(int32(raw.Pid))
+	if len(event.Ancestry) == 0 {
+		// The process already exited
+		event.Ancestry = []Process{{Pid: int32(raw.Pid), Name: raw.Comm}}
+	}
+	return event, true
+}
+
+// fileKey identifies a file by its path in the mount namespace of a process,
+// so that the processes sharing it see the same hash
+func (p *Processor) fileKey(pid uint32, path string) string {
+	ns, err := os.Readlink(filepath.Join(p.procRoot, strconv.Itoa(int(pid)), "ns", "mnt"))
+	if err != nil {
+		return fmt.Sprintf("pid:%d:%s", pid, path)
+	}
+	return ns + ":" + path
+}
+
+func (p *Processor) hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() || info.Size() > p.maxHashSize {
+		return "", fmt.Errorf("%s is not hashed", path)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ancestry returns a process followed by its parents, read from procfs
+func (p *Processor) ancestry(pid int32) []Process {
+	var ancestry []Process
+	for i := 0; i < maxAncestryDepth && pid > 0; i++ {
+		name, ppid, err := readStat(filepath.Join(p.procRoot, strconv.Itoa(int(pid)), "stat"))
+		if err != nil {
+			break
+		}
+		ancestry = append(ancestry, Process{Pid: pid, Name: name})
+		pid = ppid
+	}
+	return ancestry
+}
+
+// readStat returns the name and the parent pid of a process from its stat
+// file, the name being enclosed in parentheses and possibly containing spaces
+func readStat(path string) (string, int32, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	stat := string(content)
+	start, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("invalid stat file %s", path)
+	}
+	// state ppid ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("invalid stat file %s", path)
+	}
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return "", 0, err
+	}
+	return stat[start+1 : end], int32(ppid), nil
+}
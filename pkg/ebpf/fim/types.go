@@ -0,0 +1,59 @@
+package fim
+
+import (
+	"strings"
+	"time"
+)
+
+// Event types
+const (
+	EventWrite  = "write"
+	EventUnlink = "unlink"
+	EventRename = "rename"
+)
+
+// RawEvent is a file change reported by the kernel
+type RawEvent struct {
+	Type  string
+	Pid   uint32
+	Comm  string
+	Inode uint64
+	// Path is relative to the root of the mount namespace of the process, which
+	// is the root filesystem of the container for container workloads
+	Path string
+}
+
+// Process is a process of the ancestry of the one changing a file
+type Process struct {
+	Pid  int32  `json:"pid"`
+	Name string `json:"name"`
+}
+
+// Event is a change of a watched file
+type Event struct {
+	Type      string    `json:"type"`
+	Path      string    `json:"path"`
+	Inode     uint64    `json:"inode"`
+	SHA256    string    `json:"sha256,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Ancestry starts with the process changing the file, followed by its parents
+	Ancestry []Process `json:"ancestry"`
+}
+
+// PathFromNames builds a path from the names of the dentries walked from a
+// file up to the root of its mount namespace, skipping the empty names left
+// at the mount points. Paths too deep to be fully walked are
+// prefixed by "...".
+func PathFromNames(names []string, truncated bool) string {
+	var components []string
+	for i := len(names) - 1; i >= 0; i-- {
+		if names[i] == "" || names[i] == "/" {
+			continue
+		}
+		components = append(components, names[i])
+	}
+	if truncated {
+		return ".../" + strings.Join(components, "/")
+	}
+	return "/" + strings.Join(components, "/")
+}
@@ -207,14 +207,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 		return nil, fmt.Errorf("failed to read initial UDP pid->port mapping: %s", err)
 	}
 
-	conntracker := netlink.NewNoOpConntracker()
-	if config.EnableConntrack {
-		if c, err := netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit); err != nil {
-			log.Warnf("could not initialize conntrack, tracer will continue without NAT tracking: %s", err)
-		} else {
-			conntracker = c
-		}
-	}
+	conntracker := newConntracker(config)
 
 	state := network.NewState(
 		config.ClientStateExpiry,
@@ -254,6 +247,33 @@ func NewTracer(config *Config) (*Tracer, error) {
 	return tr, nil
 }
 
+// newConntracker returns the conntracker selected by the configured mode,
+// or a no-op one if NAT tracking is disabled or cannot be initialized
+func newConntracker(config *Config) netlink.Conntracker {
+	if !config.EnableConntrack {
+		return netlink.NewNoOpConntracker()
+	}
+
+	if config.ConntrackMode != ConntrackModeEBPF {
+		c, err := netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit)
+		if err == nil {
+			return c
+		}
+		if config.ConntrackMode != ConntrackModeAuto {
+			log.Warnf("could not initialize conntrack, tracer will continue without NAT tracking: %s", err)
+			return netlink.NewNoOpConntracker()
+		}
+		log.Warnf("could not initialize netlink conntrack, falling back to eBPF conntrack: %s", err)
+	}
+
+	c, err := NewEBPFConntracker(config.ConntrackMaxStateSize)
+	if err != nil {
+		log.Warnf("could not initialize eBPF conntrack, tracer will continue without NAT tracking: %s", err)
+		return netlink.NewNoOpConntracker()
+	}
+	return c
+}
+
 func (t *Tracer) expvarStats() {
 	ticker := time.NewTicker(5 * time.Second)
 	// starts running the body immediately instead waiting for the first tick
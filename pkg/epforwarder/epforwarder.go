@@ -0,0 +1,246 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package epforwarder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const defaultBatchWait = 5 * time.Second
+
+var (
+	// ErrQueueFull is returned when the events are submitted faster than they
+	// can be sent, the event is dropped.
+	ErrQueueFull = errors.New("event platform queue is full")
+	// ErrInvalidTrack is returned for an empty track or a track with other
+	// characters than lower case letters, digits, '-' and '_'.
+	ErrInvalidTrack = errors.New("invalid event platform track")
+	// ErrInvalidEvent is returned when the event is not valid JSON.
+	ErrInvalidEvent = errors.New("event platform event is not valid JSON")
+	// ErrEventTooLarge is returned when the event is larger than a batch.
+	ErrEventTooLarge = errors.New("event platform event is too large")
+
+	errNotInitialized = errors.New("event platform forwarder is not initialized")
+
+	validTrack = regexp.MustCompile("^[a-z0-9_-]+$")
+
+	epExpvars      = expvar.NewMap("event_platform")
+	eventsSent     = expvar.Map{}
+	eventsDropped  = expvar.Map{}
+	batchesSent    = expvar.Map{}
+	batchesErrored = expvar.Map{}
+)
+
+func init() {
+	epExpvars.Set("EventsSent", &eventsSent)
+	epExpvars.Set("EventsDropped", &eventsDropped)
+	epExpvars.Set("BatchesSent", &batchesSent)
+	epExpvars.Set("BatchesErrored", &batchesErrored)
+}
+
+type message struct {
+	track   string
+	content []byte
+}
+
+type batch struct {
+	events [][]byte
+	size   int
+}
+
+// EventPlatformForwarder batches the events submitted per event platform
+// track and sends them through the forwarder. Submitting never blocks: the
+// events are dropped when the queue is full.
+type EventPlatformForwarder struct {
+	fwd            forwarder.Forwarder
+	in             chan message
+	stop           chan struct{}
+	done           chan struct{}
+	batchWait      time.Duration
+	maxBatchSize   int
+	maxContentSize int
+}
+
+// NewEventPlatformForwarder returns an event platform forwarder sending its
+// batches with fwd, configured by the event_platform settings.
+func NewEventPlatformForwarder(fwd forwarder.Forwarder) *EventPlatformForwarder {
+	batchWait := config.Datadog.GetDuration("event_platform.batch_wait") * time.Second
+	if batchWait <= 0 {
+		log.Warnf("Invalid event_platform.batch_wait, using the default of %v", defaultBatchWait)
+		batchWait = defaultBatchWait
+	}
+	return &EventPlatformForwarder{
+		fwd:            fwd,
+		in:             make(chan message, config.Datadog.GetInt("event_platform.input_chan_size")),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		batchWait:      batchWait,
+		maxBatchSize:   config.Datadog.GetInt("event_platform.batch_max_size"),
+		maxContentSize: config.Datadog.GetInt("event_platform.batch_max_content_size"),
+	}
+}
+
+// Start starts sending the batches.
+func (f *EventPlatformForwarder) Start() {
+	go f.run()
+}
+
+// Stop sends the queued events and stops the forwarder.
+func (f *EventPlatformForwarder) Stop() {
+	close(f.stop)
+	<-f.done
+}
+
+// SendEventPlatformEvent queues a JSON encoded event of the given track.
+func (f *EventPlatformForwarder) SendEventPlatformEvent(rawEvent []byte, track string) error {
+	if !validTrack.MatchString(track) {
+		return ErrInvalidTrack
+	}
+	if !json.Valid(rawEvent) {
+		return ErrInvalidEvent
+	}
+	// the batch holds the event plus the enclosing brackets
+	if len(rawEvent)+2 > f.maxContentSize {
+		eventsDropped.Add(track, 1)
+		return ErrEventTooLarge
+	}
+	select {
+	case f.in <- message{track: track, content: rawEvent}:
+		return nil
+	default:
+		eventsDropped.Add(track, 1)
+		return ErrQueueFull
+	}
+}
+
+func (f *EventPlatformForwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.batchWait)
+	defer ticker.Stop()
+
+	batches := make(map[string]*batch)
+	for {
+		select {
+		case m := <-f.in:
+			f.add(batches, m)
+		case <-ticker.C:
+			f.flushAll(batches)
+		case <-f.stop:
+		drain:
+			for {
+				select {
+				case m := <-f.in:
+					f.add(batches, m)
+				default:
+					break drain
+				}
+			}
+			f.flushAll(batches)
+			return
+		}
+	}
+}
+
+// add appends the event to the batch of its track, sending the batch first
+// if the event doesn't fit in it, and after if it is full.
+func (f *EventPlatformForwarder) add(batches map[string]*batch, m message) {
+	b, ok := batches[m.track]
+	if !ok {
+		b = &batch{}
+		batches[m.track] = b
+	}
+	// events are separated by a comma and enclosed in brackets
+	if len(b.events) > 0 && b.size+len(m.content)+1 > f.maxContentSize {
+		f.flush(m.track, b)
+	}
+	if len(b.events) == 0 {
+		b.size = 2
+	} else {
+		b.size++
+	}
+	b.events = append(b.events, m.content)
+	b.size += len(m.content)
+	if len(b.events) >= f.maxBatchSize {
+		f.flush(m.track, b)
+	}
+}
+
+func (f *EventPlatformForwarder) flushAll(batches map[string]*batch) {
+	for track, b := range batches {
+		if len(b.events) > 0 {
+			f.flush(track, b)
+		}
+	}
+}
+
+func (f *EventPlatformForwarder) flush(track string, b *batch) {
+	payload := make([]byte, 0, b.size)
+	payload = append(payload, '[')
+	payload = append(payload, bytes.Join(b.events, []byte{','})...)
+	payload = append(payload, ']')
+
+	if err := f.fwd.SubmitEventPlatformEvents(forwarder.Payloads{&payload}, make(http.Header), track); err != nil {
+		log.Errorf("Could not send the %s event platform events: %s", track, err)
+		eventsDropped.Add(track, int64(len(b.events)))
+		batchesErrored.Add(track, 1)
+	} else {
+		eventsSent.Add(track, int64(len(b.events)))
+		batchesSent.Add(track, 1)
+	}
+	b.events = nil
+	b.size = 0
+}
+
+var (
+	defaultForwarder *EventPlatformForwarder
+	defaultMu        sync.RWMutex
+)
+
+// InitEventPlatformForwarder creates and starts the event platform forwarder
+// used by SendEventPlatformEvent.
+func InitEventPlatformForwarder(fwd forwarder.Forwarder) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultForwarder != nil {
+		return
+	}
+	defaultForwarder = NewEventPlatformForwarder(fwd)
+	defaultForwarder.Start()
+}
+
+// StopEventPlatformForwarder sends the queued events and stops the event
+// platform forwarder.
+func StopEventPlatformForwarder() {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultForwarder != nil {
+		defaultForwarder.Stop()
+		defaultForwarder = nil
+	}
+}
+
+// SendEventPlatformEvent queues a JSON encoded event of the given track with
+// the event platform forwarder.
+func SendEventPlatformEvent(rawEvent []byte, track string) error {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultForwarder == nil {
+		return errNotInitialized
+	}
+	return defaultForwarder.SendEventPlatformEvent(rawEvent, track)
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package epforwarder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+)
+
+func payload(s string) forwarder.Payloads {
+	p := []byte(s)
+	return forwarder.Payloads{&p}
+}
+
+func TestSendEventPlatformEventInvalid(t *testing.T) {
+	config.Mock()
+	f := NewEventPlatformForwarder(&forwarder.MockedForwarder{})
+
+	assert.Equal(t, ErrInvalidTrack, f.SendEventPlatformEvent([]byte(`{}`), ""))
+	assert.Equal(t, ErrInvalidTrack, f.SendEventPlatformEvent([]byte(`{}`), "../track"))
+	assert.Equal(t, ErrInvalidEvent, f.SendEventPlatformEvent([]byte(`{"a":`), "track"))
+}
+
+func TestSendEventPlatformEventBatches(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("event_platform.batch_max_size", 2)
+	mockConfig.Set("event_platform.batch_wait", 3600)
+
+	fwd := &forwarder.MockedForwarder{}
+	fwd.On("SubmitEventPlatformEvents", payload(`[{"a":1},{"a":2}]`), make(http.Header), "track1").Return(nil).Times(1)
+	fwd.On("SubmitEventPlatformEvents", payload(`[{"a":3}]`), make(http.Header), "track1").Return(nil).Times(1)
+	fwd.On("SubmitEventPlatformEvents", payload(`[{"b":1}]`), make(http.Header), "track2").Return(nil).Times(1)
+
+	f := NewEventPlatformForwarder(fwd)
+	f.Start()
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":1}`), "track1"))
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"b":1}`), "track2"))
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":2}`), "track1"))
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":3}`), "track1"))
+	// the incomplete batches are sent on stop
+	f.Stop()
+
+	fwd.AssertExpectations(t)
+}
+
+func TestSendEventPlatformEventMaxContentSize(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("event_platform.batch_max_content_size", 20)
+	mockConfig.Set("event_platform.batch_wait", 3600)
+
+	fwd := &forwarder.MockedForwarder{}
+	fwd.On("SubmitEventPlatformEvents", payload(`[{"a":1},{"a":2}]`), make(http.Header), "track").Return(nil).Times(1)
+	fwd.On("SubmitEventPlatformEvents", payload(`[{"a":3}]`), make(http.Header), "track").Return(nil).Times(1)
+
+	f := NewEventPlatformForwarder(fwd)
+	assert.Equal(t, ErrEventTooLarge, f.SendEventPlatformEvent([]byte(`{"a":"too large event"}`), "track"))
+
+	f.Start()
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":1}`), "track"))
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":2}`), "track"))
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{"a":3}`), "track"))
+	f.Stop()
+
+	fwd.AssertExpectations(t)
+}
+
+func TestSendEventPlatformEventQueueFull(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("event_platform.input_chan_size", 1)
+
+	// not started, nothing consumes the queue
+	f := NewEventPlatformForwarder(&forwarder.MockedForwarder{})
+	require.NoError(t, f.SendEventPlatformEvent([]byte(`{}`), "track"))
+	assert.Equal(t, ErrQueueFull, f.SendEventPlatformEvent([]byte(`{}`), "track"))
+}
+
+func TestSendEventPlatformEventNotInitialized(t *testing.T) {
+	assert.Error(t, SendEventPlatformEvent([]byte(`{}`), "track"))
+}
@@ -9,8 +9,6 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"expvar"
 	"fmt"
@@ -24,16 +22,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/api/audit"
 	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/diagnose"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/fim"
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
 	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/crashreport"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
-	"github.com/mholt/archiver"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -81,6 +83,10 @@ type filePermsInfo struct {
 	mode  os.FileMode
 	owner string
 	group string
+	// acl is the POSIX ACL on Linux and the DACL on Windows
+	acl          string
+	capabilities string
+	secContext   string
 }
 
 // CreateArchive packages up the files
@@ -95,170 +101,83 @@ func CreateArchive(local bool, distPath, pyChecksPath, logFilePath string) (stri
 }
 
 func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string) (string, error) {
-	b := make([]byte, 10)
-	_, err := rand.Read(b)
-	if err != nil {
-		return "", err
-	}
-
-	dirName := hex.EncodeToString(b)
-	tempDir, err := ioutil.TempDir("", dirName)
-	if err != nil {
-		return "", err
-	}
-
-	defer os.RemoveAll(tempDir)
-
-	// Get hostname, if there's an error in getting the hostname,
-	// set the hostname to unknown
-	hostname, err := util.GetHostname()
-	if err != nil {
-		hostname = "unknown"
-	}
-
-	hostname = cleanDirectoryName(hostname)
-
-	permsInfos := make(permissionsInfos)
-
-	if local {
-		f := filepath.Join(tempDir, hostname, "local")
-
-		err := ensureParentDirsExist(f)
-		if err != nil {
-			return "", err
-		}
-
-		w, err := newRedactingWriter(f, os.ModePerm, true)
-		if err != nil {
-			return "", err
-		}
-		defer w.Close()
-
-		_, err = w.Write([]byte{})
-		if err != nil {
-			return "", err
-		}
-
-		// Can't reach the agent, mention it in those two files
-		err = writeStatusFile(tempDir, hostname, []byte("unable to get the status of the agent, is it running?"))
-		if err != nil {
-			return "", err
-		}
-		err = writeConfigCheck(tempDir, hostname, []byte("unable to get loaded checks config, is the agent running?"))
-		if err != nil {
-			return "", err
-		}
-	} else {
+	b := &archiveBuilder{
+		localSections: []archiveSection{
+			// Can't reach the agent, mention it in those two files
+			requiredSection("status", func(tempDir, hostname string) error {
+				return writeStatusFile(tempDir, hostname, []byte("unable to get the status of the agent, is it running?"))
+			}),
+			requiredSection("config check", func(tempDir, hostname string) error {
+				return writeConfigCheck(tempDir, hostname, []byte("unable to get loaded checks config, is the agent running?"))
+			}),
+		},
 		// Status informations are available, zip them up as the agent is running.
-		err = zipStatusFile(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip status: %s", err)
-		}
-
-		err = zipConfigCheck(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip config check: %s", err)
-		}
-	}
-
-	// auth token permissions info (only if existing)
-	if _, err = os.Stat(security.GetAuthTokenFilepath()); err == nil && !os.IsNotExist(err) {
-		permsInfos.add(security.GetAuthTokenFilepath())
-	}
-
-	err = zipConfigFiles(tempDir, hostname, confSearchPaths, permsInfos)
-	if err != nil {
-		log.Errorf("Could not zip config: %s", err)
-	}
-
-	err = zipExpVar(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip exp var: %s", err)
-	}
-
-	if config.Datadog.GetBool("system_probe_config.enabled") {
-		err = zipSystemProbeStats(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip system probe exp var stats: %s", err)
-		}
+		remoteSections: []archiveSection{
+			section("status", zipStatusFile),
+			section("config check", zipConfigCheck),
+		},
+		sections: agentSections(confSearchPaths, logFilePath),
 	}
+	return b.build(zipFilePath, local)
+}
 
-	err = zipDiagnose(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip diagnose: %s", err)
+// agentSections lists the sections of the agent flare
+func agentSections(confSearchPaths SearchPaths, logFilePath string) []archiveSection {
+	sections := []archiveSection{
+		// auth token permissions info (only if existing)
+		permsSection("auth token permissions", func(_, _ string, permsInfos permissionsInfos) error {
+			if _, err := os.Stat(security.GetAuthTokenFilepath()); err == nil {
+				permsInfos.add(security.GetAuthTokenFilepath())
+			}
+			return nil
+		}),
+		permsSection("config", func(tempDir, hostname string, permsInfos permissionsInfos) error {
+			return zipConfigFiles(tempDir, hostname, confSearchPaths, permsInfos)
+		}),
+		section("exp var", zipExpVar),
 	}
 
-	err = zipSecrets(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip secrets: %s", err)
+	if config.IsFeatureEnabled(config.NPMFeature) {
+		sections = append(sections, section("system probe exp var stats", zipSystemProbeStats))
 	}
 
-	err = zipEnvvars(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip env vars: %s", err)
-	}
-
-	err = zipHealth(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip health check: %s", err)
-	}
+	sections = append(sections,
+		section("diagnose", zipDiagnose),
+		section("secrets", zipSecrets),
+		section("env vars", zipEnvvars),
+		section("check state keys", zipCheckState),
+		section("health check", zipHealth),
+		section("features", zipFeatures),
+		section("environment detection", zipEnvironmentDetection),
+		section("policy validation report", zipPolicyValidation),
+	)
 
 	if config.Datadog.GetBool("telemetry.enabled") {
-		err = zipTelemetry(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not collect telemetry metrics: %s", err)
-		}
+		sections = append(sections, section("telemetry metrics", zipTelemetry))
 	}
 
-	err = zipStackTraces(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not collect go routine stack traces: %s", err)
-	}
+	sections = append(sections, section("go routine stack traces", zipStackTraces))
 
 	if config.IsContainerized() {
-		err = zipDockerSelfInspect(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip docker inspect: %s", err)
-		}
-	}
-
-	err = zipDockerPs(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip docker ps: %s", err)
-	}
-
-	err = zipTypeperfData(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write typeperf data: %s", err)
-	}
-	err = zipCounterStrings(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write counter strings: %s", err)
-	}
-
-	// force a log flush before zipping them
-	log.Flush()
-	err = zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
-	if err != nil {
-		log.Errorf("Could not zip logs: %s", err)
-	}
-
-	err = zipInstallInfo(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip install_info: %s", err)
-	}
-
-	// gets files infos and write the permissions.log file
-	if err := permsInfos.commit(tempDir, hostname, os.ModePerm); err != nil {
-		log.Errorf("Could not write permissions.log file: %s", err)
-	}
-
-	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
-	if err != nil {
-		return "", err
-	}
-
-	return zipFilePath, nil
+		sections = append(sections, section("docker inspect", zipDockerSelfInspect))
+	}
+
+	return append(sections,
+		section("docker ps", zipDockerPs),
+		section("kubelet connection", zipKubeletConnection),
+		section("typeperf data", zipTypeperfData),
+		section("counter strings", zipCounterStrings),
+		permsSection("logs", func(tempDir, hostname string, permsInfos permissionsInfos) error {
+			// force a log flush before zipping them
+			log.Flush()
+			return zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
+		}),
+		permsSection("IPC audit log", func(tempDir, hostname string, permsInfos permissionsInfos) error {
+			return zipIPCAuditLog(tempDir, hostname, logFilePath, permsInfos)
+		}),
+		section("crash reports", zipCrashReports),
+		section("install_info", zipInstallInfo),
+	)
 }
 
 func zipStatusFile(tempDir, hostname string) error {
@@ -314,7 +233,7 @@ func zipLogFiles(tempDir, hostname, logFilePath string, permsInfos permissionsIn
 			return nil
 		}
 
-		if filepath.Ext(f.Name()) == ".log" || getFirstSuffix(f.Name()) == ".log" {
+		if filepath.Ext(f.Name()) == ".log" || getFirstSuffix(f.Name()) == ".log" || isCompressedLogRoll(f.Name()) {
 			dst := filepath.Join(tempDir, hostname, "logs", f.Name())
 
 			if permsInfos != nil {
@@ -329,6 +248,44 @@ func zipLogFiles(tempDir, hostname, logFilePath string, permsInfos permissionsIn
 	return err
 }
 
+// zipIPCAuditLog adds the IPC audit log when it's not already collected with
+// the other log files
+func zipIPCAuditLog(tempDir, hostname, logFilePath string, permsInfos permissionsInfos) error {
+	auditFile := audit.FilePath(logFilePath)
+	if filepath.Dir(auditFile) == filepath.Dir(logFilePath) {
+		return nil
+	}
+
+	for _, src := range []string{auditFile, auditFile + ".1"} {
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if permsInfos != nil {
+			permsInfos.add(src)
+		}
+		dst := filepath.Join(tempDir, hostname, "logs", filepath.Base(src))
+		if err := util.CopyFileAll(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zipCrashReports adds the crash reports of the agent processes
+func zipCrashReports(tempDir, hostname string) error {
+	reports, err := filepath.Glob(filepath.Join(crashreport.Dir(), "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, src := range reports {
+		dst := filepath.Join(tempDir, hostname, "crashes", filepath.Base(src))
+		if err := util.CopyFileAll(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func zipExpVar(tempDir, hostname string) error {
 	var variables = make(map[string]interface{})
 	expvar.Do(func(kv expvar.KeyValue) {
@@ -570,6 +527,122 @@ func zipHealth(tempDir, hostname string) error {
 	return err
 }
 
+// zipFeatures lists the optional subsystems enabled in the configuration
+func zipFeatures(tempDir, hostname string) error {
+	yamlValue, err := yaml.Marshal(config.GetFeatures())
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, "features.yaml")
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(yamlValue)
+	return err
+}
+
+// zipPolicyValidation reports the syntax errors and unknown fields of the
+// compliance suites and of the runtime security policy, when any is configured
+func zipPolicyValidation(tempDir, hostname string) error {
+	complianceDir := config.Datadog.GetString("compliance_config.dir")
+	fimPaths := config.Datadog.GetStringSlice("runtime_security_config.fim.paths")
+	if _, err := os.Stat(complianceDir); err != nil && len(fimPaths) == 0 {
+		return nil
+	}
+
+	f := filepath.Join(tempDir, hostname, "policy_validation.txt")
+	err := ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(policyValidationReport(complianceDir, fimPaths))
+	return err
+}
+
+// policyValidationReport validates each suite of the compliance directory and
+// the runtime file integrity monitoring paths
+func policyValidationReport(complianceDir string, fimPaths []string) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "==== compliance suites (%s) ====\n", complianceDir)
+	files, err := filepath.Glob(filepath.Join(complianceDir, "*.yaml"))
+	if err != nil {
+		fmt.Fprintf(&b, "  error: %s\n", err)
+	} else if len(files) == 0 {
+		fmt.Fprintln(&b, "  no suite found")
+	}
+	for _, file := range files {
+		writeValidationResult(&b, filepath.Base(file), compliance.ValidateSuite(file))
+	}
+
+	fmt.Fprintln(&b, "\n==== runtime security policy (runtime_security_config.fim.paths) ====")
+	var fimErrs []error
+	for _, pattern := range fimPaths {
+		if err := (fim.Policy{Paths: []string{pattern}}).Validate(); err != nil {
+			fimErrs = append(fimErrs, err)
+		}
+	}
+	writeValidationResult(&b, fmt.Sprintf("%d paths", len(fimPaths)), fimErrs)
+
+	return b.Bytes()
+}
+
+func writeValidationResult(b *bytes.Buffer, name string, errs []error) {
+	if len(errs) == 0 {
+		fmt.Fprintf(b, "  %s: OK\n", name)
+		return
+	}
+	fmt.Fprintf(b, "  %s: %d error(s)\n", name, len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(b, "    - %s\n", err)
+	}
+}
+
+// zipCheckState lists the keys persisted by the check instances, their values
+// may be sensitive and are left out
+func zipCheckState(tempDir, hostname string) error {
+	keys, err := persistentcache.StateKeys()
+	if err != nil {
+		return err
+	}
+
+	yamlValue, err := yaml.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, "check_state.yaml")
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(yamlValue)
+	return err
+}
+
 func zipInstallInfo(tempDir, hostname string) error {
 	originalPath := filepath.Join(config.FileUsedDir(), "install_info")
 	original, err := os.Open(originalPath)
@@ -720,6 +793,12 @@ func getFirstSuffix(s string) string {
 	return filepath.Ext(strings.TrimSuffix(s, filepath.Ext(s)))
 }
 
+// isCompressedLogRoll returns whether the file is a log file rotated and
+// compressed by the agent, e.g. agent.log.1.gz
+func isCompressedLogRoll(s string) bool {
+	return filepath.Ext(s) == ".gz" && getFirstSuffix(strings.TrimSuffix(s, ".gz")) == ".log"
+}
+
 func getArchivePath() string {
 	dir := os.TempDir()
 	t := time.Now()
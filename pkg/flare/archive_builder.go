@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/mholt/archiver"
+)
+
+// archiveSection is a part of the flare archive
+type archiveSection struct {
+	name string
+	zip  func(tempDir, hostname string, permsInfos permissionsInfos) error
+	// required sections abort the creation of the archive when they fail,
+	// the errors of the others are only logged
+	required bool
+}
+
+// section returns an optional section written by zip
+func section(name string, zip func(tempDir, hostname string) error) archiveSection {
+	return archiveSection{
+		name: name,
+		zip: func(tempDir, hostname string, _ permissionsInfos) error {
+			return zip(tempDir, hostname)
+		},
+	}
+}
+
+// requiredSection returns a section written by zip aborting the archive on error
+func requiredSection(name string, zip func(tempDir, hostname string) error) archiveSection {
+	s := section(name, zip)
+	s.required = true
+	return s
+}
+
+// permsSection returns an optional section also reporting the permissions of
+// the files it collects in permissions.log
+func permsSection(name string, zip func(tempDir, hostname string, permsInfos permissionsInfos) error) archiveSection {
+	return archiveSection{name: name, zip: zip}
+}
+
+// archiveBuilder creates the flare archives of all the agents, which only
+// differ by their lists of sections. The temporary directory, the hostname,
+// the local marker, permissions.log and the zip are shared.
+type archiveBuilder struct {
+	// localSections are written when the agent can't be reached,
+	// remoteSections when it can
+	localSections  []archiveSection
+	remoteSections []archiveSection
+	// sections are always written, after the local or remote ones
+	sections []archiveSection
+}
+
+func (b *archiveBuilder) build(zipFilePath string, local bool) (string, error) {
+	r := make([]byte, 10)
+	_, err := rand.Read(r)
+	if err != nil {
+		return "", err
+	}
+
+	dirName := hex.EncodeToString(r)
+	tempDir, err := ioutil.TempDir("", dirName)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	// Get hostname, if there's an error in getting the hostname,
+	// set the hostname to unknown
+	hostname, err := util.GetHostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	hostname = cleanDirectoryName(hostname)
+
+	permsInfos := make(permissionsInfos)
+
+	roleSections := b.remoteSections
+	if local {
+		err = writeLocalMarker(tempDir, hostname)
+		if err != nil {
+			return "", err
+		}
+		roleSections = b.localSections
+	}
+
+	for _, sections := range [][]archiveSection{roleSections, b.sections} {
+		for _, s := range sections {
+			err = s.zip(tempDir, hostname, permsInfos)
+			if err == nil {
+				continue
+			}
+			if s.required {
+				return "", fmt.Errorf("could not zip %s: %s", s.name, err)
+			}
+			log.Errorf("Could not zip %s: %s", s.name, err)
+		}
+	}
+
+	// gets files infos and write the permissions.log file
+	if err := permsInfos.commit(tempDir, hostname, os.ModePerm); err != nil {
+		log.Errorf("Could not write permissions.log file: %s", err)
+	}
+
+	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
+	if err != nil {
+		return "", err
+	}
+
+	return zipFilePath, nil
+}
+
+// writeLocalMarker writes the empty file telling the flare was created
+// without reaching the agent
+func writeLocalMarker(tempDir, hostname string) error {
+	f := filepath.Join(tempDir, hostname, "local")
+
+	err := ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write([]byte{})
+	return err
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveBuilderSections(t *testing.T) {
+	var written []string
+	record := func(name string, err error) func(tempDir, hostname string) error {
+		return func(tempDir, hostname string) error {
+			written = append(written, name)
+			return err
+		}
+	}
+
+	b := &archiveBuilder{
+		localSections:  []archiveSection{section("local", record("local", nil))},
+		remoteSections: []archiveSection{section("remote", record("remote", nil))},
+		sections: []archiveSection{
+			section("optional", record("optional", errors.New("unavailable"))),
+			section("common", record("common", nil)),
+		},
+	}
+
+	zipFilePath := getArchivePath()
+	defer os.Remove(zipFilePath)
+	_, err := b.build(zipFilePath, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remote", "optional", "common"}, written)
+
+	written = nil
+	_, err = b.build(zipFilePath, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"local", "optional", "common"}, written)
+
+	written = nil
+	b.sections = append([]archiveSection{requiredSection("required", record("required", errors.New("unavailable")))}, b.sections...)
+	_, err = b.build(zipFilePath, false)
+	assert.EqualError(t, err, "could not zip required: unavailable")
+	assert.Equal(t, []string{"remote", "required"}, written)
+}
@@ -8,21 +8,16 @@ package flare
 import (
 	"bufio"
 	"bytes"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
-	"github.com/mholt/archiver"
-
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/custommetrics"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/status"
-	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -38,114 +33,47 @@ func CreateDCAArchive(local bool, distPath, logFilePath string) (string, error)
 }
 
 func createDCAArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string) (string, error) {
-	b := make([]byte, 10)
-	_, err := rand.Read(b)
-	if err != nil {
-		return "", err
-	}
-
-	dirName := hex.EncodeToString(b)
-	tempDir, err := ioutil.TempDir("", dirName)
-	if err != nil {
-		return "", err
-	}
-
-	defer os.RemoveAll(tempDir)
-
-	// Get hostname, if there's an error in getting the hostname,
-	// set the hostname to unknown
-	hostname, err := util.GetHostname()
-	if err != nil {
-		hostname = "unknown"
-	}
-
-	// If the request against the API does not go through we don't collect the status log.
-	if local {
-		f := filepath.Join(tempDir, hostname, "local")
-		err = ensureParentDirsExist(f)
-		if err != nil {
-			return "", err
-		}
-
-		err = ioutil.WriteFile(f, []byte{}, os.ModePerm)
-		if err != nil {
-			return "", err
-		}
-	} else {
+	b := &archiveBuilder{
 		// The Status will be unavailable unless the agent is running.
 		// Only zip it up if the agent is running
-		err = zipDCAStatusFile(tempDir, hostname)
-		if err != nil {
-			log.Infof("Error getting the status of the DCA, %q", err)
-			return "", err
-		}
-	}
-
-	permsInfos := make(permissionsInfos)
-
-	err = zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
-	if err != nil {
-		return "", err
-	}
-
-	err = zipConfigFiles(tempDir, hostname, confSearchPaths, permsInfos)
-	if err != nil {
-		return "", err
-	}
-
-	err = zipClusterAgentConfigCheck(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip config check: %s", err)
-	}
-
-	err = zipExpVar(tempDir, hostname)
-	if err != nil {
-		return "", err
-	}
-
-	err = zipEnvvars(tempDir, hostname)
-	if err != nil {
-		return "", err
-	}
-
-	err = zipMetadataMap(tempDir, hostname)
-	if err != nil {
-		return "", err
-	}
-
-	err = zipClusterAgentClusterChecks(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip clustercheck status: %s", err)
+		remoteSections: []archiveSection{
+			requiredSection("status", zipDCAStatusFile),
+		},
+		sections: dcaSections(confSearchPaths, logFilePath),
 	}
+	return b.build(zipFilePath, local)
+}
 
-	err = zipClusterAgentDiagnose(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip diagnose: %s", err)
+// dcaSections lists the sections of the cluster agent flare
+func dcaSections(confSearchPaths SearchPaths, logFilePath string) []archiveSection {
+	sections := []archiveSection{
+		{
+			name: "logs",
+			zip: func(tempDir, hostname string, permsInfos permissionsInfos) error {
+				return zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
+			},
+			required: true,
+		},
+		{
+			name: "config",
+			zip: func(tempDir, hostname string, permsInfos permissionsInfos) error {
+				return zipConfigFiles(tempDir, hostname, confSearchPaths, permsInfos)
+			},
+			required: true,
+		},
+		section("config check", zipClusterAgentConfigCheck),
+		requiredSection("exp var", zipExpVar),
+		requiredSection("env vars", zipEnvvars),
+		requiredSection("metadata map", zipMetadataMap),
+		section("clustercheck status", zipClusterAgentClusterChecks),
+		section("diagnose", zipClusterAgentDiagnose),
 	}
 
 	if config.Datadog.GetBool("external_metrics_provider.enabled") {
-		err = zipHPAStatus(tempDir, hostname)
-		if err != nil {
-			return "", err
-		}
-	}
-
-	err = zipClusterAgentTelemetry(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip telemetry payload: %v", err)
-	}
-
-	err = permsInfos.commit(tempDir, hostname, os.ModePerm)
-	if err != nil {
-		log.Infof("Error while creating permissions.log infos file: %s", err)
-	}
-
-	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
-	if err != nil {
-		return "", err
+		sections = append(sections, requiredSection("HPA status", zipHPAStatus))
 	}
 
-	return zipFilePath, nil
+	return append(sections, section("telemetry payload", zipClusterAgentTelemetry))
 }
 
 func zipDCAStatusFile(tempDir, hostname string) error {
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package flare
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+// zipKubeletConnection adds how the kubelet endpoint was selected, if the
+// kubelet was ever queried
+func zipKubeletConnection(tempDir, hostname string) error {
+	report, err := kubelet.GetConnectionReport()
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	f := filepath.Join(tempDir, hostname, "kubelet_connection.json")
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(report)
+	return err
+}
@@ -0,0 +1,216 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package flare
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// POSIX ACL entry tags, from linux/posix_acl_xattr.h
+const (
+	aclUserObj  = 0x01
+	aclUser     = 0x02
+	aclGroupObj = 0x04
+	aclGroup    = 0x08
+	aclMask     = 0x10
+	aclOther    = 0x20
+)
+
+// File capabilities revisions and flags, from linux/capability.h
+const (
+	vfsCapRevisionMask   = 0xFF000000
+	vfsCapRevision1      = 0x01000000
+	vfsCapFlagsEffective = 0x000001
+)
+
+// capabilityNames is indexed by capability number
+var capabilityNames = []string{
+	"chown", "dac_override", "dac_read_search", "fowner", "fsetid", "kill",
+	"setgid", "setuid", "setpcap", "linux_immutable", "net_bind_service",
+	"net_broadcast", "net_admin", "net_raw", "ipc_lock", "ipc_owner",
+	"sys_module", "sys_rawio", "sys_chroot", "sys_ptrace", "sys_pacct",
+	"sys_admin", "sys_boot", "sys_nice", "sys_resource", "sys_time",
+	"sys_tty_config", "mknod", "lease", "audit_write", "audit_control",
+	"setfcap", "mac_override", "mac_admin", "syslog", "wake_alarm",
+	"block_suspend", "audit_read", "perfmon", "bpf", "checkpoint_restore",
+}
+
+// extendedPerms returns the extended ACL, the capabilities and the SELinux
+// context of a file, "-" when it has none
+func extendedPerms(filePath string) (acl, capabilities, secContext string) {
+	acl, capabilities, secContext = "-", "-", "-"
+
+	var entries []string
+	if value, err := getxattr(filePath, "system.posix_acl_access"); err == nil {
+		entries = append(entries, formatACL("", value)...)
+	}
+	if value, err := getxattr(filePath, "system.posix_acl_default"); err == nil {
+		entries = append(entries, formatACL("default:", value)...)
+	}
+	if len(entries) > 0 {
+		acl = strings.Join(entries, ",")
+	}
+
+	if value, err := getxattr(filePath, "security.capability"); err == nil {
+		if caps := formatCapabilities(value); caps != "" {
+			capabilities = caps
+		}
+	}
+
+	if value, err := getxattr(filePath, "security.selinux"); err == nil && len(value) > 0 {
+		secContext = strings.TrimRight(string(value), "\x00")
+	}
+
+	return acl, capabilities, secContext
+}
+
+// processSecurityContext returns the SELinux or AppArmor context the agent
+// runs with, AppArmor confining processes rather than labelling files
+func processSecurityContext() string {
+	value, err := ioutil.ReadFile("/proc/self/attr/current")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+}
+
+func getxattr(filePath, name string) ([]byte, error) {
+	size, err := syscall.Getxattr(filePath, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, size)
+	size, err = syscall.Getxattr(filePath, name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value[:size], nil
+}
+
+// formatACL decodes a POSIX ACL extended attribute in the short text form of
+// getfacl. Nothing is returned for the minimal ACLs equivalent to the mode.
+func formatACL(prefix string, value []byte) []string {
+	// 4 bytes of version followed by entries of tag, perm and id
+	if len(value) < 4 || (len(value)-4)%8 != 0 {
+		return nil
+	}
+
+	var entries []string
+	extended := false
+	for b := value[4:]; len(b) >= 8; b = b[8:] {
+		tag := binary.LittleEndian.Uint16(b[0:2])
+		perm := binary.LittleEndian.Uint16(b[2:4])
+		id := binary.LittleEndian.Uint32(b[4:8])
+
+		var qualifier string
+		switch tag {
+		case aclUserObj:
+			qualifier = "user::"
+		case aclUser:
+			qualifier = "user:" + lookupUser(id) + ":"
+			extended = true
+		case aclGroupObj:
+			qualifier = "group::"
+		case aclGroup:
+			qualifier = "group:" + lookupGroup(id) + ":"
+			extended = true
+		case aclMask:
+			qualifier = "mask::"
+		case aclOther:
+			qualifier = "other::"
+		default:
+			qualifier = fmt.Sprintf("unknown(%#x):", tag)
+		}
+		entries = append(entries, prefix+qualifier+formatACLPerm(perm))
+	}
+
+	// a default ACL is worth reporting even when minimal
+	if !extended && prefix == "" {
+		return nil
+	}
+	return entries
+}
+
+func formatACLPerm(perm uint16) string {
+	b := []byte("---")
+	if perm&4 != 0 {
+		b[0] = 'r'
+	}
+	if perm&2 != 0 {
+		b[1] = 'w'
+	}
+	if perm&1 != 0 {
+		b[2] = 'x'
+	}
+	return string(b)
+}
+
+func lookupUser(uid uint32) string {
+	if u, err := user.LookupId(strconv.Itoa(int(uid))); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(int(uid))
+}
+
+func lookupGroup(gid uint32) string {
+	if g, err := user.LookupGroupId(strconv.Itoa(int(gid))); err == nil {
+		return g.Name
+	}
+	return strconv.Itoa(int(gid))
+}
+
+// formatCapabilities decodes a security.capability extended attribute in the
+// text form of getcap, e.g. "cap_net_raw,cap_sys_ptrace=ep"
+func formatCapabilities(value []byte) string {
+	if len(value) < 12 {
+		return ""
+	}
+
+	magic := binary.LittleEndian.Uint32(value[0:4])
+	permitted := uint64(binary.LittleEndian.Uint32(value[4:8]))
+	inheritable := uint64(binary.LittleEndian.Uint32(value[8:12]))
+	// revisions 2 and 3 hold the upper 32 capabilities in a second pair
+	if magic&vfsCapRevisionMask != vfsCapRevision1 && len(value) >= 20 {
+		permitted |= uint64(binary.LittleEndian.Uint32(value[12:16])) << 32
+		inheritable |= uint64(binary.LittleEndian.Uint32(value[16:20])) << 32
+	}
+
+	var sets []string
+	if names := capabilitySet(permitted); names != "" {
+		flags := "p"
+		if magic&vfsCapFlagsEffective != 0 {
+			flags = "ep"
+		}
+		sets = append(sets, names+"="+flags)
+	}
+	if names := capabilitySet(inheritable); names != "" {
+		sets = append(sets, names+"=i")
+	}
+	return strings.Join(sets, " ")
+}
+
+func capabilitySet(mask uint64) string {
+	var names []string
+	for i := uint(0); i < 64; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if int(i) < len(capabilityNames) {
+			names = append(names, "cap_"+capabilityNames[i])
+		} else {
+			names = append(names, fmt.Sprintf("cap_%d", i))
+		}
+	}
+	return strings.Join(names, ",")
+}
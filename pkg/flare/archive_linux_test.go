@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package flare
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func aclXattr(entries ...[3]uint32) []byte {
+	b := make([]byte, 4+8*len(entries))
+	binary.LittleEndian.PutUint32(b, 2)
+	for i, e := range entries {
+		binary.LittleEndian.PutUint16(b[4+8*i:], uint16(e[0]))
+		binary.LittleEndian.PutUint16(b[6+8*i:], uint16(e[1]))
+		binary.LittleEndian.PutUint32(b[8+8*i:], e[2])
+	}
+	return b
+}
+
+func TestFormatACL(t *testing.T) {
+	minimal := aclXattr([3]uint32{aclUserObj, 6, 0}, [3]uint32{aclGroupObj, 4, 0}, [3]uint32{aclOther, 4, 0})
+	assert.Empty(t, formatACL("", minimal))
+	assert.Equal(t, []string{"default:user::rw-", "default:group::r--", "default:other::r--"}, formatACL("default:", minimal))
+
+	extended := aclXattr(
+		[3]uint32{aclUserObj, 6, 0},
+		[3]uint32{aclUser, 5, 4242},
+		[3]uint32{aclGroupObj, 4, 0},
+		[3]uint32{aclMask, 5, 0},
+		[3]uint32{aclOther, 0, 0},
+	)
+	assert.Equal(t, []string{"user::rw-", "user:4242:r-x", "group::r--", "mask::r-x", "other::---"}, formatACL("", extended))
+
+	assert.Empty(t, formatACL("", []byte{2, 0, 0}))
+}
+
+func TestFormatCapabilities(t *testing.T) {
+	caps := make([]byte, 20)
+	// revision 2, effective
+	binary.LittleEndian.PutUint32(caps[0:], 0x02000001)
+	// cap_net_raw and cap_sys_ptrace permitted
+	binary.LittleEndian.PutUint32(caps[4:], 1<<13|1<<19)
+	// cap_bpf inheritable, in the upper half
+	binary.LittleEndian.PutUint32(caps[16:], 1<<(39-32))
+	assert.Equal(t, "cap_net_raw,cap_sys_ptrace=ep cap_bpf=i", formatCapabilities(caps))
+
+	// revision 1 has no upper half
+	binary.LittleEndian.PutUint32(caps[0:], 0x01000000)
+	assert.Equal(t, "cap_net_raw,cap_sys_ptrace=p", formatCapabilities(caps[:12]))
+
+	assert.Empty(t, formatCapabilities([]byte{1, 2}))
+}
@@ -72,10 +72,15 @@ func (p permissionsInfos) statFiles() error {
 			uname = u.Username
 		}
 
+		acl, capabilities, secContext := extendedPerms(filePath)
+
 		p[filePath] = filePermsInfo{
-			mode:  fi.Mode(),
-			owner: uname,
-			group: g.Name,
+			mode:         fi.Mode(),
+			owner:        uname,
+			group:        g.Name,
+			acl:          acl,
+			capabilities: capabilities,
+			secContext:   secContext,
 		}
 	}
 	return nil
@@ -96,8 +101,16 @@ func (p permissionsInfos) write(tempDir, hostname string, mode os.FileMode) erro
 
 	defer f.Close()
 
+	// AppArmor confines processes rather than labelling files, report the
+	// context of the agent itself
+	if secContext := processSecurityContext(); secContext != "" {
+		if _, err = f.WriteString(fmt.Sprintf("Agent process security context: %s\n\n", secContext)); err != nil {
+			return err
+		}
+	}
+
 	// write headers
-	s := fmt.Sprintf("%-50s | %-5s | %-10s | %-10s\n", "File path", "mode", "owner", "group")
+	s := fmt.Sprintf("%-50s | %-5s | %-10s | %-10s | %-10s | %-10s | %s\n", "File path", "mode", "owner", "group", "ACL", "capabilities", "security context")
 	if _, err = f.Write([]byte(s)); err != nil {
 		return err
 	}
@@ -107,7 +120,7 @@ func (p permissionsInfos) write(tempDir, hostname string, mode os.FileMode) erro
 
 	// write each file permissions infos
 	for filePath, perms := range p {
-		_, err = f.WriteString(fmt.Sprintf("%-50s | %-5s | %-10s | %-10s\n", filePath, perms.mode.String(), perms.owner, perms.group))
+		_, err = f.WriteString(fmt.Sprintf("%-50s | %-5s | %-10s | %-10s | %-10s | %-10s | %s\n", filePath, perms.mode.String(), perms.owner, perms.group, perms.acl, perms.capabilities, perms.secContext))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux,!windows
+
+package flare
+
+// extendedPerms returns the extended ACL, the capabilities and the SELinux
+// context of a file, only collected on Linux
+func extendedPerms(filePath string) (acl, capabilities, secContext string) {
+	return "-", "-", "-"
+}
+
+// processSecurityContext returns the SELinux or AppArmor context the agent
+// runs with, only collected on Linux
+func processSecurityContext() string {
+	return ""
+}
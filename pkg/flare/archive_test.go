@@ -145,6 +145,39 @@ func TestZipConfigCheck(t *testing.T) {
 	assert.NotContains(t, string(content), "MySecurePass")
 }
 
+func TestZipPolicyValidation(t *testing.T) {
+	complianceDir, err := ioutil.TempDir("", "TestZipPolicyValidation")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(complianceDir)
+
+	ioutil.WriteFile(filepath.Join(complianceDir, "good.yaml"), []byte("name: Good\nrules:\n- id: good-1\n"), 0644)
+	ioutil.WriteFile(filepath.Join(complianceDir, "bad.yaml"), []byte("name: Bad\nrules:\n- id: bad-1\n  scop: {}\n"), 0644)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("compliance_config.dir", complianceDir)
+	mockConfig.Set("runtime_security_config.fim.paths", []string{"/etc/passwd", "etc/shadow"})
+
+	dir, err := ioutil.TempDir("", "TestZipPolicyValidation")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, zipPolicyValidation(dir, ""))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "policy_validation.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Contains(t, string(content), "good.yaml: OK")
+	assert.Contains(t, string(content), "bad.yaml: 1 error(s)")
+	assert.Contains(t, string(content), "field scop not found")
+	assert.Contains(t, string(content), "2 paths: 1 error(s)")
+	assert.Contains(t, string(content), `pattern "etc/shadow" is not an absolute path`)
+}
+
 func TestIncludeSystemProbeConfig(t *testing.T) {
 	assert := assert.New(t)
 	common.SetupConfig("./test/datadog-agent.yaml")
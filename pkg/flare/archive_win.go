@@ -9,10 +9,13 @@ package flare
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"unsafe"
 
 	"github.com/DataDog/datadog-agent/pkg/util/winutil"
@@ -82,7 +85,121 @@ func zipTypeperfData(tempDir, hostname string) error {
 	return nil
 }
 
-func (p permissionsInfos) add(filePath string) {}
+// Add puts the given filepath in the map
+// of files to process later during the commit phase.
+func (p permissionsInfos) add(filePath string) {
+	p[filePath] = filePermsInfo{}
+}
+
+// Commit resolves the DACL of every stacked files in the map
+// and then writes the permissions.log file on the filesystem.
 func (p permissionsInfos) commit(tempDir, hostname string, mode os.FileMode) error {
+	for filePath := range p {
+		acl, err := fileDACL(filePath)
+		if err != nil {
+			acl = fmt.Sprintf("can't retrieve the DACL: %s", err)
+		}
+		p[filePath] = filePermsInfo{acl: acl}
+	}
+
+	t := filepath.Join(tempDir, hostname, "permissions.log")
+	if err := ensureParentDirsExist(t); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(t, os.O_RDWR|os.O_CREATE|os.O_APPEND, mode)
+	if err != nil {
+		return fmt.Errorf("while opening: %s", err)
+	}
+	defer f.Close()
+
+	s := fmt.Sprintf("%-50s | %s\n", "File path", "DACL")
+	if _, err = f.WriteString(s + strings.Repeat("-", len(s)) + "\n"); err != nil {
+		return err
+	}
+	for filePath, perms := range p {
+		if _, err = f.WriteString(fmt.Sprintf("%-50s | %s\n", filePath, perms.acl)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// fileDACL lists the access control entries of a file as
+// "<ALLOW|DENY> <account> <rights>", separated by semicolons
+func fileDACL(filePath string) (string, error) {
+	var dacl *winutil.Acl
+	var secDesc windows.Handle
+	err := winutil.GetNamedSecurityInfo(filePath,
+		winutil.SE_FILE_OBJECT,
+		winutil.DACL_SECURITY_INFORMATION,
+		nil,
+		nil,
+		&dacl,
+		nil,
+		&secDesc)
+	if err != nil {
+		return "", err
+	}
+	defer windows.LocalFree(secDesc)
+
+	if dacl == nil {
+		return "NULL DACL, everyone has full access", nil
+	}
+
+	var aclSizeInfo winutil.AclSizeInformation
+	if err := winutil.GetAclInformation(dacl, &aclSizeInfo, winutil.AclSizeInformationEnum); err != nil {
+		return "", err
+	}
+
+	entries := make([]string, 0, aclSizeInfo.AceCount)
+	for i := uint32(0); i < aclSizeInfo.AceCount; i++ {
+		var ace *winutil.AccessAllowedAce
+		if err := winutil.GetAce(dacl, i, &ace); err != nil {
+			return "", err
+		}
+
+		aceType := fmt.Sprintf("TYPE(%d)", ace.AceType)
+		switch ace.AceType {
+		case winutil.ACCESS_ALLOWED_ACE_TYPE:
+			aceType = "ALLOW"
+		case winutil.ACCESS_DENIED_ACE_TYPE:
+			aceType = "DENY"
+		}
+
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		entries = append(entries, fmt.Sprintf("%s %s %s", aceType, accountName(sid), accessRights(ace.AccessMask)))
+	}
+	return strings.Join(entries, "; "), nil
+}
+
+// accountName returns DOMAIN\account, or the SID string of unknown accounts
+func accountName(sid *windows.SID) string {
+	if account, domain, _, err := sid.LookupAccount(""); err == nil {
+		if domain == "" {
+			return account
+		}
+		return domain + "\\" + account
+	}
+	if s, err := (*syscall.SID)(unsafe.Pointer(sid)).String(); err == nil {
+		return s
+	}
+	return "unknown SID"
+}
+
+// accessRights names the usual file access masks, as displayed by Explorer
+func accessRights(mask uint32) string {
+	switch mask {
+	case 0x1f01ff:
+		return "FullControl"
+	case 0x1301bf:
+		return "Modify"
+	case 0x1200a9:
+		return "ReadAndExecute"
+	case 0x120089:
+		return "Read"
+	case 0x100116:
+		return "Write"
+	}
+	return fmt.Sprintf("%#x", mask)
+}
@@ -9,13 +9,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	"github.com/fatih/color"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
@@ -28,30 +32,7 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 		color.NoColor = true
 	}
 
-	c := util.GetClient(false) // FIX: get certificates right then make this true
-
-	// Set session token
-	err := util.SetAuthToken()
-	if err != nil {
-		return err
-	}
-	ipcAddress, err := config.GetIPCAddress()
-	if err != nil {
-		return err
-	}
-	if configCheckURL == "" {
-		configCheckURL = fmt.Sprintf("https://%v:%v/agent/config-check", ipcAddress, config.Datadog.GetInt("cmd_port"))
-	}
-	r, err := util.DoGet(c, configCheckURL)
-	if err != nil {
-		if r != nil && string(r) != "" {
-			return fmt.Errorf("the agent ran into an error while checking config: %s", string(r))
-		}
-		return fmt.Errorf("failed to query the agent (running?): %s", err)
-	}
-
-	cr := response.ConfigCheckResponse{}
-	err = json.Unmarshal(r, &cr)
+	cr, err := getConfigCheckResponse()
 	if err != nil {
 		return err
 	}
@@ -87,11 +68,101 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 				}
 			}
 		}
+		if len(cr.ScheduleHistory) > 0 {
+			fmt.Fprintln(w, fmt.Sprintf("\n=== %s ===", color.YellowString("Scheduling history")))
+			PrintScheduleHistory(w, cr.ScheduleHistory)
+		}
+	}
+
+	return nil
+}
+
+// PrintScheduleHistory prints when and why each check instance was last
+// (re)scheduled, sorted by check name and instance ID
+func PrintScheduleHistory(w io.Writer, history map[string]integration.ScheduleRecord) {
+	IDs := make([]string, 0, len(history))
+	for ID := range history {
+		IDs = append(IDs, ID)
+	}
+	sort.Slice(IDs, func(i, j int) bool {
+		if history[IDs[i]].CheckName != history[IDs[j]].CheckName {
+			return history[IDs[i]].CheckName < history[IDs[j]].CheckName
+		}
+		return IDs[i] < IDs[j]
+	})
+
+	for _, ID := range IDs {
+		record := history[ID]
+		fmt.Fprintln(w, fmt.Sprintf("\n%s: %s", color.BlueString("Instance ID"), color.CyanString(ID)))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration provider"), record.Provider))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration source"), record.Source))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s (%s)", color.BlueString("Last scheduled"), record.LastScheduled.Format(time.RFC3339), record.Trigger))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %d", color.BlueString("Times scheduled"), record.ScheduleCount))
+		if !record.Unscheduled.IsZero() {
+			fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Unscheduled"), color.YellowString(record.Unscheduled.Format(time.RFC3339))))
+		}
+	}
+}
+
+// GetCheckConfig dumps the effective configuration of the instances of the
+// given check to the writer
+func GetCheckConfig(w io.Writer, checkName string) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	cr, err := getConfigCheckResponse()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, c := range cr.Configs {
+		if c.Name != checkName || c.IsTemplate() {
+			continue
+		}
+		found = true
+		if err := PrintEffectiveConfig(w, c); err != nil {
+			return err
+		}
 	}
 
+	if configErr, ok := cr.ConfigErrors[checkName]; ok {
+		fmt.Fprintln(w, fmt.Sprintf("\n%s: %s", color.RedString("Configuration error"), configErr))
+	} else if !found {
+		return fmt.Errorf("no configuration of the %s check is loaded", checkName)
+	}
 	return nil
 }
 
+func getConfigCheckResponse() (response.ConfigCheckResponse, error) {
+	cr := response.ConfigCheckResponse{}
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return cr, err
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return cr, err
+	}
+	if configCheckURL == "" {
+		configCheckURL = fmt.Sprintf("https://%v:%v/agent/config-check", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	}
+	r, err := util.DoGet(c, configCheckURL)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			return cr, fmt.Errorf("the agent ran into an error while checking config: %s", string(r))
+		}
+		return cr, fmt.Errorf("failed to query the agent (running?): %s", err)
+	}
+
+	err = json.Unmarshal(r, &cr)
+	return cr, err
+}
+
 // GetClusterAgentConfigCheck proxies GetConfigCheck overidding the URL
 func GetClusterAgentConfigCheck(w io.Writer, withDebug bool) error {
 	configCheckURL = fmt.Sprintf("https://localhost:%v/config-check", config.Datadog.GetInt("cluster_agent.cmd_port"))
@@ -146,3 +217,55 @@ func PrintConfig(w io.Writer, c integration.Config) {
 	}
 	fmt.Fprintln(w, "===")
 }
+
+// PrintEffectiveConfig prints the configuration of every instance of a check,
+// as resolved by autodiscovery and completed with the defaults the Agent
+// applies when running it
+func PrintEffectiveConfig(w io.Writer, c integration.Config) error {
+	fmt.Fprintln(w, fmt.Sprintf("\n=== %s check ===", color.GreenString(c.Name)))
+	fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration provider"), color.CyanString(c.Provider)))
+	fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration source"), color.CyanString(c.Source)))
+	for _, inst := range c.Instances {
+		ID := string(check.BuildID(c.Name, inst, c.InitConfig))
+		effective, err := effectiveInstance(inst, c.InitConfig)
+		if err != nil {
+			return fmt.Errorf("invalid instance %s: %s", ID, err)
+		}
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Instance ID"), color.CyanString(ID)))
+		fmt.Fprint(w, fmt.Sprintf("%s", effective))
+		fmt.Fprintln(w, "~")
+	}
+	if len(c.InitConfig) > 0 {
+		fmt.Fprintln(w, fmt.Sprintf("%s:", color.BlueString("Init Config")))
+		fmt.Fprintln(w, string(c.InitConfig))
+	}
+	fmt.Fprintln(w, "===")
+	return nil
+}
+
+// effectiveInstance returns the instance with the defaults of the reserved
+// fields applied, as they are when the check is configured
+func effectiveInstance(instance, initConfig integration.Data) (integration.Data, error) {
+	commonOptions := integration.CommonInstanceConfig{}
+	if err := yaml.Unmarshal(instance, &commonOptions); err != nil {
+		return nil, err
+	}
+	commonGlobalOptions := integration.CommonGlobalConfig{}
+	if err := yaml.Unmarshal(initConfig, &commonGlobalOptions); err != nil {
+		return nil, err
+	}
+
+	effective := make(integration.Data, len(instance))
+	copy(effective, instance)
+	if commonOptions.MinCollectionInterval <= 0 {
+		if err := effective.SetField("min_collection_interval", int(defaults.DefaultCheckInterval/time.Second)); err != nil {
+			return nil, err
+		}
+	}
+	if commonOptions.Service == "" && commonGlobalOptions.Service != "" {
+		if err := effective.SetField("service", commonGlobalOptions.Service); err != nil {
+			return nil, err
+		}
+	}
+	return effective, nil
+}
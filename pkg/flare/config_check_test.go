@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func TestEffectiveInstance(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		instance   string
+		initConfig string
+		expected   map[string]interface{}
+	}{
+		{
+			name:     "defaults",
+			instance: "host: localhost",
+			expected: map[string]interface{}{
+				"host":                    "localhost",
+				"min_collection_interval": 15,
+			},
+		},
+		{
+			name:       "service from init_config",
+			instance:   "host: localhost\nmin_collection_interval: 30",
+			initConfig: "service: redis",
+			expected: map[string]interface{}{
+				"host":                    "localhost",
+				"min_collection_interval": 30,
+				"service":                 "redis",
+			},
+		},
+		{
+			name:       "instance service overrides init_config",
+			instance:   "host: localhost\nservice: cache",
+			initConfig: "service: redis",
+			expected: map[string]interface{}{
+				"host":                    "localhost",
+				"min_collection_interval": 15,
+				"service":                 "cache",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := integration.Data(tc.instance)
+			effective, err := effectiveInstance(instance, integration.Data(tc.initConfig))
+			require.NoError(t, err)
+
+			actual := map[string]interface{}{}
+			require.NoError(t, yaml.Unmarshal(effective, &actual))
+			assert.Equal(t, tc.expected, actual)
+			// the loaded instance is left untouched
+			assert.Equal(t, tc.instance, string(instance))
+		})
+	}
+
+	_, err := effectiveInstance(integration.Data("- invalid"), nil)
+	assert.Error(t, err)
+}
+
+func TestPrintScheduleHistory(t *testing.T) {
+	scheduled := time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC)
+	history := map[string]integration.ScheduleRecord{
+		"redisdb:b2c4": {
+			CheckName:     "redisdb",
+			Provider:      "kubelet",
+			Source:        "kubelet:docker://abc",
+			Trigger:       "service docker://abc",
+			LastScheduled: scheduled,
+			Unscheduled:   scheduled.Add(time.Minute),
+			ScheduleCount: 3,
+		},
+		"cpu:a1b2": {
+			CheckName:     "cpu",
+			Provider:      "file",
+			Source:        "file:/etc/datadog-agent/conf.d/cpu.d/conf.yaml.default",
+			Trigger:       "initial load",
+			LastScheduled: scheduled,
+			ScheduleCount: 1,
+		},
+	}
+
+	var b bytes.Buffer
+	PrintScheduleHistory(&b, history)
+	out := b.String()
+
+	assert.Contains(t, out, "Last scheduled: 2020-05-04T12:00:00Z (service docker://abc)")
+	assert.Contains(t, out, "Times scheduled: 3")
+	assert.Contains(t, out, "Unscheduled: 2020-05-04T12:01:00Z")
+	assert.Contains(t, out, "Configuration provider: kubelet")
+	// sorted by check name
+	assert.True(t, bytes.Index(b.Bytes(), []byte("cpu:a1b2")) < bytes.Index(b.Bytes(), []byte("redisdb:b2c4")))
+	// only the unscheduled instance reports it
+	assert.Equal(t, 1, bytes.Count(b.Bytes(), []byte("Unscheduled")))
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/ecs"
+	"github.com/DataDog/datadog-agent/pkg/util/fargate"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// detection is the outcome of one step of the environment autodetection
+type detection struct {
+	Name   string `yaml:"name"`
+	Result bool   `yaml:"result"`
+	Reason string `yaml:"reason"`
+}
+
+// environmentDetection is the decision tree followed by the agent to detect
+// its environment and the features it enabled
+type environmentDetection struct {
+	Environment []detection `yaml:"environment"`
+	Features    []detection `yaml:"features"`
+}
+
+// zipEnvironmentDetection writes the environment detection decisions, so the
+// branches taken by the agent on startup are visible
+func zipEnvironmentDetection(tempDir, hostname string) error {
+	yamlValue, err := yaml.Marshal(detectEnvironment())
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, "environment_detection.yaml")
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(yamlValue)
+	return err
+}
+
+// detectEnvironment replays the autodetection with the same functions as the
+// agent, and explains each result with the inputs they rely on
+func detectEnvironment() environmentDetection {
+	var env environmentDetection
+
+	containerized := config.IsContainerized()
+	env.Environment = append(env.Environment, detection{
+		Name:   "containerized",
+		Result: containerized,
+		Reason: envVarReason("DOCKER_DD_AGENT"),
+	})
+
+	if containerized {
+		procfs := detection{Name: "host procfs", Result: pathExists("/host/proc")}
+		if procfs.Result {
+			procfs.Reason = "/host/proc is mounted, procfs_path defaults to /host/proc"
+		} else {
+			procfs.Reason = "/host/proc is not mounted, procfs_path defaults to /proc"
+		}
+		cgroups := detection{Name: "host cgroups", Result: pathExists("/host/sys/fs/cgroup/")}
+		if cgroups.Result {
+			cgroups.Reason = "/host/sys/fs/cgroup/ is mounted, container_cgroup_root defaults to it"
+		} else {
+			cgroups.Reason = "/host/sys/fs/cgroup/ is not mounted, container_cgroup_root defaults to /sys/fs/cgroup/"
+		}
+		env.Environment = append(env.Environment, procfs, cgroups)
+	}
+
+	env.Environment = append(env.Environment, detection{
+		Name:   "kubernetes",
+		Result: config.IsKubernetes(),
+		Reason: envVarReason("KUBERNETES_SERVICE_PORT") + ", " + envVarReason("KUBERNETES"),
+	})
+
+	env.Environment = append(env.Environment, detection{
+		Name:   "eks fargate",
+		Result: fargate.IsEKSFargateInstance(),
+		Reason: settingReason("eks_fargate"),
+	})
+
+	ecsInstance := detection{Name: "ecs", Result: ecs.IsECSInstance()}
+	ecsFargate := detection{Name: "ecs fargate", Result: ecs.IsFargateInstance()}
+	if !config.IsCloudProviderEnabled(ecs.CloudProviderName) {
+		reason := fmt.Sprintf("%s is not part of cloud_provider_metadata", ecs.CloudProviderName)
+		ecsInstance.Reason, ecsFargate.Reason = reason, reason
+	} else {
+		if ecsInstance.Result {
+			ecsInstance.Reason = "the ECS agent metadata API answered"
+		} else {
+			ecsInstance.Reason = "the ECS agent metadata API could not be reached"
+		}
+		ecsFargate.Reason = envVarReason("AWS_EXECUTION_ENV")
+		if ecsFargate.Result {
+			ecsFargate.Reason += ", the task metadata API answered"
+		}
+	}
+	env.Environment = append(env.Environment, ecsInstance, ecsFargate)
+
+	states := config.GetFeatures()
+	settings := config.GetFeatureSettings()
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env.Features = append(env.Features, detection{
+			Name:   name,
+			Result: states[name],
+			Reason: settingReason(settings[name]),
+		})
+	}
+
+	return env
+}
+
+// envVarReason describes whether an environment variable is set
+func envVarReason(name string) string {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return name + " is not set"
+	}
+	return fmt.Sprintf("%s is set to %q", name, value)
+}
+
+// settingReason describes the value of a setting and where it comes from
+func settingReason(key string) string {
+	reason := fmt.Sprintf("%s is %v", key, config.Datadog.Get(key))
+	envVar := "DD_" + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+	if _, found := os.LookupEnv(envVar); found {
+		return reason + ", from " + envVar
+	}
+	return reason + ", from the configuration file or the default value"
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("logs_enabled", true)
+	mockConfig.Set("cloud_provider_metadata", []string{})
+
+	os.Setenv("DOCKER_DD_AGENT", "true")
+	defer os.Unsetenv("DOCKER_DD_AGENT")
+	os.Setenv("DD_LOGS_ENABLED", "true")
+	defer os.Unsetenv("DD_LOGS_ENABLED")
+
+	env := detectEnvironment()
+
+	require.NotEmpty(t, env.Environment)
+	assert.Equal(t, detection{Name: "containerized", Result: true, Reason: `DOCKER_DD_AGENT is set to "true"`}, env.Environment[0])
+	for _, d := range env.Environment {
+		if d.Name == "ecs" {
+			assert.False(t, d.Result)
+			assert.Equal(t, "AWS is not part of cloud_provider_metadata", d.Reason)
+		}
+	}
+
+	require.Len(t, env.Features, len(config.GetFeatures()))
+	for _, d := range env.Features {
+		if d.Name == "logs" {
+			assert.True(t, d.Result)
+			assert.Equal(t, "logs_enabled is true, from DD_LOGS_ENABLED", d.Reason)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package flaretest helps testing the code writing sections of a flare: it
+// builds an in-memory archive from providers and asserts on its entries.
+package flaretest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hostname is the hostname the providers are called with
+const Hostname = "flaretest-host"
+
+// Provider writes files in a flare directory, under its hostname
+// subdirectory, like the zip functions of the flare package
+type Provider func(tempDir, hostname string) error
+
+// Archive holds the entries written by the providers, keyed by their slash
+// separated path relative to the hostname directory
+type Archive struct {
+	Entries map[string][]byte
+}
+
+// Build runs the providers against a temporary directory and loads the files
+// they wrote in memory. The directory is removed before returning.
+func Build(providers ...Provider) (*Archive, error) {
+	tempDir, err := ioutil.TempDir("", "flaretest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, p := range providers {
+		if err := p(tempDir, Hostname); err != nil {
+			return nil, err
+		}
+	}
+
+	root := filepath.Join(tempDir, Hostname)
+	a := &Archive{Entries: make(map[string][]byte)}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		a.Entries[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Names returns the sorted paths of the entries
+func (a *Archive) Names() []string {
+	names := make([]string, 0, len(a.Entries))
+	for name := range a.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AssertEntry checks that the archive has an entry containing each substring
+func AssertEntry(t *testing.T, a *Archive, name string, contains ...string) bool {
+	t.Helper()
+	content, ok := a.Entries[name]
+	if !assert.True(t, ok, "entry %s not found in %v", name, a.Names()) {
+		return false
+	}
+	for _, s := range contains {
+		if !assert.Contains(t, string(content), s, "entry %s", name) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertNoEntry checks that the archive has no entry at the given path
+func AssertNoEntry(t *testing.T, a *Archive, name string) bool {
+	t.Helper()
+	_, ok := a.Entries[name]
+	return assert.False(t, ok, "unexpected entry %s", name)
+}
+
+// AssertNotLeaked checks that no entry contains the given value, typically a
+// credential that should have been scrubbed
+func AssertNotLeaked(t *testing.T, a *Archive, value string) bool {
+	t.Helper()
+	ok := true
+	for _, name := range a.Names() {
+		if strings.Contains(string(a.Entries[name]), value) {
+			ok = assert.Fail(t, "value leaked", "entry %s contains %q", name, value)
+		}
+	}
+	return ok
+}
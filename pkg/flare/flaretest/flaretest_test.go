@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flaretest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeEntry(name, content string) Provider {
+	return func(tempDir, hostname string) error {
+		f := filepath.Join(tempDir, hostname, name)
+		if err := os.MkdirAll(filepath.Dir(f), os.ModePerm); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(f, []byte(content), 0644)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	a, err := Build(
+		writeEntry("status.log", "agent is running"),
+		writeEntry("etc/datadog.yaml", "api_key: ********"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"etc/datadog.yaml", "status.log"}, a.Names())
+	AssertEntry(t, a, "status.log", "agent", "running")
+	AssertEntry(t, a, "etc/datadog.yaml", "api_key")
+	AssertNoEntry(t, a, "missing.log")
+	AssertNotLeaked(t, a, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+}
+
+func TestBuildEmpty(t *testing.T) {
+	a, err := Build(func(tempDir, hostname string) error { return nil })
+	require.NoError(t, err)
+	assert.Empty(t, a.Names())
+}
+
+func TestBuildError(t *testing.T) {
+	_, err := Build(func(tempDir, hostname string) error { return errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+}
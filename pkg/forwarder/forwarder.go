@@ -17,6 +17,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -32,6 +33,7 @@ var (
 	transactionsSketchSeries      = expvar.Int{}
 	transactionsHostMetadata      = expvar.Int{}
 	transactionsMetadata          = expvar.Int{}
+	transactionsEventPlatform     = expvar.Int{}
 	transactionsTimeseriesV1      = expvar.Int{}
 	transactionsCheckRunsV1       = expvar.Int{}
 	transactionsIntakeV1          = expvar.Int{}
@@ -57,6 +59,7 @@ var (
 	sketchSeriesEndpoint  = endpoint{"/api/beta/sketches", "sketches_v2"}
 	hostMetadataEndpoint  = endpoint{"/api/v2/host_metadata", "host_metadata_v2"}
 	metadataEndpoint      = endpoint{"/api/v2/metadata", "metadata_v2"}
+	eventPlatformEndpoint = endpoint{"/api/v2/epevents/", "event_platform"}
 
 	processesEndpoint   = endpoint{"/api/v1/collector", "process"}
 	rtProcessesEndpoint = endpoint{"/api/v1/collector", "rtprocess"}
@@ -77,6 +80,7 @@ func init() {
 	transactionsExpvars.Set("SketchSeries", &transactionsSketchSeries)
 	transactionsExpvars.Set("HostMetadata", &transactionsHostMetadata)
 	transactionsExpvars.Set("Metadata", &transactionsMetadata)
+	transactionsExpvars.Set("EventPlatform", &transactionsEventPlatform)
 	transactionsExpvars.Set("TimeseriesV1", &transactionsTimeseriesV1)
 	transactionsExpvars.Set("CheckRunsV1", &transactionsCheckRunsV1)
 	transactionsExpvars.Set("IntakeV1", &transactionsIntakeV1)
@@ -144,6 +148,7 @@ type Forwarder interface {
 	SubmitSketchSeries(payload Payloads, extra http.Header) error
 	SubmitHostMetadata(payload Payloads, extra http.Header) error
 	SubmitMetadata(payload Payloads, extra http.Header) error
+	SubmitEventPlatformEvents(payload Payloads, extra http.Header, track string) error
 	SubmitProcessChecks(payload Payloads, extra http.Header) (chan Response, error)
 	SubmitRTProcessChecks(payload Payloads, extra http.Header) (chan Response, error)
 	SubmitContainerChecks(payload Payloads, extra http.Header) (chan Response, error)
@@ -269,6 +274,10 @@ func (f *DefaultForwarder) Stop() {
 	f.internalState = Stopped
 
 	purgeTimeout := config.Datadog.GetDuration("forwarder_stop_timeout") * time.Second
+	// leave the time to send the data drained from the aggregator
+	if drainTimeout := config.Datadog.GetDuration("shutdown_drain_timeout") * time.Second; drainTimeout > purgeTimeout {
+		purgeTimeout = drainTimeout
+	}
 	if purgeTimeout > 0 {
 		var wg sync.WaitGroup
 
@@ -316,6 +325,7 @@ func (f *DefaultForwarder) State() uint32 {
 func (f *DefaultForwarder) createHTTPTransactions(endpoint endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*HTTPTransaction {
 	transactions := make([]*HTTPTransaction, 0, len(payloads)*len(f.keysPerDomains))
 	for _, payload := range payloads {
+		payloadaudit.Record(endpoint.name, *payload)
 		for domain, apiKeys := range f.keysPerDomains {
 			for _, apiKey := range apiKeys {
 				transactionEndpoint := endpoint.route
@@ -397,6 +407,18 @@ func (f *DefaultForwarder) SubmitMetadata(payload Payloads, extra http.Header) e
 	return f.sendHTTPTransactions(transactions)
 }
 
+// SubmitEventPlatformEvents will send a batch of events of the given event
+// platform track to Datadog backend.
+func (f *DefaultForwarder) SubmitEventPlatformEvents(payload Payloads, extra http.Header, track string) error {
+	ep := endpoint{eventPlatformEndpoint.route + track, eventPlatformEndpoint.name}
+	transactions := f.createHTTPTransactions(ep, payload, false, extra)
+	for _, t := range transactions {
+		t.Headers.Set("Content-Type", "application/json")
+	}
+	transactionsEventPlatform.Add(1)
+	return f.sendHTTPTransactions(transactions)
+}
+
 // SubmitV1Series will send timeserie to v1 endpoint (this will be remove once
 // the backend handles v2 endpoints).
 func (f *DefaultForwarder) SubmitV1Series(payload Payloads, extra http.Header) error {
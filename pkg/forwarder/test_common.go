@@ -113,6 +113,11 @@ func (tf *MockedForwarder) SubmitMetadata(payload Payloads, extra http.Header) e
 	return tf.Called(payload, extra).Error(0)
 }
 
+// SubmitEventPlatformEvents updates the internal mock struct
+func (tf *MockedForwarder) SubmitEventPlatformEvents(payload Payloads, extra http.Header, track string) error {
+	return tf.Called(payload, extra, track).Error(0)
+}
+
 // SubmitProcessChecks mock
 func (tf *MockedForwarder) SubmitProcessChecks(payload Payloads, extra http.Header) (chan Response, error) {
 	return nil, tf.Called(payload, extra).Error(0)
@@ -15,6 +15,7 @@ import (
 	"net/http"
 	"net/http/httptrace"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -39,6 +40,10 @@ var (
 	transactionsHTTPErrors         = expvar.Int{}
 	transactionsHTTPErrorsByCode   = expvar.Map{}
 
+	// lastIntakeResponse is the unix time in nanoseconds of the last HTTP
+	// response received from an intake, whatever its status code
+	lastIntakeResponse int64
+
 	tlmConnectEvents = telemetry.NewCounter("forwarder", "connection_events",
 		[]string{"connection_event_type"}, "Count of new connection events grouped by type of event")
 	tlmTxRetryQueueSize = telemetry.NewGauge("transactions", "retry_queue_size",
@@ -153,6 +158,16 @@ type Transaction interface {
 	GetTarget() string
 }
 
+// LastIntakeResponse returns the time of the last response received from an
+// intake, or the zero time if none was received yet
+func LastIntakeResponse() time.Time {
+	nanos := atomic.LoadInt64(&lastIntakeResponse)
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
 // NewHTTPTransaction returns a new HTTPTransaction.
 func NewHTTPTransaction() *HTTPTransaction {
 	return &HTTPTransaction{
@@ -225,6 +240,7 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 		return 0, nil, fmt.Errorf("error while sending transaction, rescheduling it: %s", httputils.SanitizeURL(err.Error()))
 	}
 	defer func() { _ = resp.Body.Close() }()
+	atomic.StoreInt64(&lastIntakeResponse, time.Now().UnixNano())
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package hostprofile
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+// NewProfilerFromConfig returns a profiler configured with the host_profile
+// settings, sending the baseline through the serializer
+func NewProfilerFromConfig(s serializer.MetricSerializer) (*Profiler, error) {
+	hostname, err := util.GetHostname()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the hostname: %v", err)
+	}
+
+	sampleInterval := config.Datadog.GetDuration("host_profile.sample_interval")
+	flushInterval := config.Datadog.GetDuration("host_profile.flush_interval")
+	if sampleInterval <= 0 || flushInterval < sampleInterval {
+		return nil, fmt.Errorf("invalid host_profile intervals, the flush interval (%s) must be longer than the sample interval (%s)", flushInterval, sampleInterval)
+	}
+
+	return NewProfiler(hostname, sampleInterval, flushInterval, func(p *Payload) error {
+		return s.SendMetadata(p)
+	})
+}
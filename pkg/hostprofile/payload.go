@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package hostprofile
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+)
+
+// Sketch is the compact form of the distribution of a baseline: its summary
+// and the keys and counts of the bins of its DDSketch
+type Sketch struct {
+	Cnt int64    `json:"cnt"`
+	Min float64  `json:"min"`
+	Max float64  `json:"max"`
+	Sum float64  `json:"sum"`
+	Avg float64  `json:"avg"`
+	K   []int32  `json:"k"`
+	N   []uint32 `json:"n"`
+}
+
+func newSketch(s *quantile.Sketch) *Sketch {
+	k, n := s.Cols()
+	return &Sketch{
+		Cnt: s.Basic.Cnt,
+		Min: s.Basic.Min,
+		Max: s.Basic.Max,
+		Sum: s.Basic.Sum,
+		Avg: s.Basic.Avg,
+		K:   k,
+		N:   n,
+	}
+}
+
+// Payload handles the JSON unmarshalling of the host profile payload
+type Payload struct {
+	Hostname  string `json:"hostname"`
+	Timestamp int64  `json:"timestamp"`
+	// Start and End bound the period covered by the sketches, in seconds
+	Start          int64              `json:"start"`
+	End            int64              `json:"end"`
+	SampleInterval int64              `json:"sample_interval"`
+	KernelVersion  string             `json:"kernel_version,omitempty"`
+	Sketches       map[string]*Sketch `json:"host_profile"`
+}
+
+// MarshalJSON serialization a Payload to JSON
+func (p *Payload) MarshalJSON() ([]byte, error) {
+	type PayloadAlias Payload
+	return json.Marshal((*PayloadAlias)(p))
+}
+
+// Marshal not implemented
+func (p *Payload) Marshal() ([]byte, error) {
+	return nil, fmt.Errorf("Host profile Payload serialization is not implemented")
+}
+
+// SplitPayload breaks the payload into times number of pieces
+func (p *Payload) SplitPayload(times int) ([]marshaler.Marshaler, error) {
+	return nil, fmt.Errorf("Host profile Payload splitting is not implemented")
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package hostprofile collects a baseline of the host performance: the
+// distributions of the CPU steal time, of the IO latency and of the memory
+// pressure, sampled periodically into sketches. Comparing the baselines of a
+// host before and after a kernel or instance type change shows regressions.
+package hostprofile
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Names of the distributions of the baseline
+const (
+	// CPUSteal is the percentage of CPU time stolen by the hypervisor
+	CPUSteal = "cpu.steal"
+	// IOLatency is the average latency of the IOs of a disk, in milliseconds
+	IOLatency = "io.latency"
+	// MemoryPressure is the percentage of time some tasks were stalled on memory
+	MemoryPressure = "memory.pressure"
+)
+
+// sampler samples the host performance, by distribution name. A sample
+// covers the time elapsed since the previous one, so the first one may be
+// empty.
+type sampler interface {
+	sample(now time.Time) (map[string][]float64, error)
+	kernelVersion() string
+}
+
+// Profiler samples the host performance and sends the baseline periodically
+type Profiler struct {
+	sampler        sampler
+	send           func(*Payload) error
+	hostname       string
+	sampleInterval time.Duration
+	flushInterval  time.Duration
+
+	mu       sync.Mutex
+	sketches map[string]*quantile.Agent
+	start    time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewProfiler returns a profiler sampling the host every sampleInterval and
+// sending the baseline with send every flushInterval
+func NewProfiler(hostname string, sampleInterval, flushInterval time.Duration, send func(*Payload) error) (*Profiler, error) {
+	s, err := newSampler()
+	if err != nil {
+		return nil, err
+	}
+	return newProfiler(s, hostname, sampleInterval, flushInterval, send), nil
+}
+
+func newProfiler(s sampler, hostname string, sampleInterval, flushInterval time.Duration, send func(*Payload) error) *Profiler {
+	return &Profiler{
+		sampler:        s,
+		send:           send,
+		hostname:       hostname,
+		sampleInterval: sampleInterval,
+		flushInterval:  flushInterval,
+		sketches:       make(map[string]*quantile.Agent),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start starts sampling the host in the background
+func (p *Profiler) Start() {
+	p.start = time.Now()
+	p.collect(p.start)
+	go p.run()
+}
+
+// Stop stops sampling the host. The samples collected since the last flush
+// aren't sent.
+func (p *Profiler) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *Profiler) run() {
+	defer close(p.done)
+
+	sampleTicker := time.NewTicker(p.sampleInterval)
+	defer sampleTicker.Stop()
+	flushTicker := time.NewTicker(p.flushInterval)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case now := <-sampleTicker.C:
+			p.collect(now)
+		case now := <-flushTicker.C:
+			payload := p.flush(now)
+			if payload == nil {
+				continue
+			}
+			if err := p.send(payload); err != nil {
+				log.Warnf("Could not send the host profile: %v", err)
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// collect adds a sample of the host performance to the sketches
+func (p *Profiler) collect(now time.Time) {
+	samples, err := p.sampler.sample(now)
+	if err != nil {
+		log.Debugf("Could not sample the host performance: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, values := range samples {
+		sketch, found := p.sketches[name]
+		if !found {
+			sketch = &quantile.Agent{}
+			p.sketches[name] = sketch
+		}
+		for _, v := range values {
+			sketch.Insert(v, 1)
+		}
+	}
+}
+
+// flush returns the baseline since the previous flush and resets the
+// sketches, or nil when nothing was sampled
+func (p *Profiler) flush(now time.Time) *Payload {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payload := &Payload{
+		Hostname:       p.hostname,
+		Timestamp:      now.UnixNano(),
+		Start:          p.start.Unix(),
+		End:            now.Unix(),
+		SampleInterval: int64(p.sampleInterval / time.Second),
+		KernelVersion:  p.sampler.kernelVersion(),
+		Sketches:       make(map[string]*Sketch),
+	}
+	for name, agent := range p.sketches {
+		if s := agent.Finish(); s != nil {
+			payload.Sketches[name] = newSketch(s)
+		}
+		agent.Reset()
+	}
+	p.start = now
+
+	if len(payload.Sketches) == 0 {
+		return nil
+	}
+	return payload
+}
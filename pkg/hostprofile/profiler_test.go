@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package hostprofile
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSampler struct {
+	samples []map[string][]float64
+}
+
+func (s *fakeSampler) sample(now time.Time) (map[string][]float64, error) {
+	if len(s.samples) == 0 {
+		return nil, nil
+	}
+	sample := s.samples[0]
+	s.samples = s.samples[1:]
+	return sample, nil
+}
+
+func (s *fakeSampler) kernelVersion() string {
+	return "5.4.0-1029-aws"
+}
+
+func TestProfilerFlush(t *testing.T) {
+	s := &fakeSampler{samples: []map[string][]float64{
+		{},
+		{CPUSteal: {1}, IOLatency: {2, 4}},
+		{CPUSteal: {3}, IOLatency: {6}, MemoryPressure: {0.5}},
+	}}
+	p := newProfiler(s, "my-host", 15*time.Second, time.Hour, nil)
+	start := time.Unix(1600000000, 0)
+	p.start = start
+	for i := 0; i < 3; i++ {
+		p.collect(start.Add(time.Duration(i) * 15 * time.Second))
+	}
+
+	payload := p.flush(start.Add(time.Hour))
+	require.NotNil(t, payload)
+	assert.Equal(t, "my-host", payload.Hostname)
+	assert.Equal(t, int64(1600000000), payload.Start)
+	assert.Equal(t, int64(1600003600), payload.End)
+	assert.Equal(t, int64(15), payload.SampleInterval)
+	assert.Equal(t, "5.4.0-1029-aws", payload.KernelVersion)
+	require.Len(t, payload.Sketches, 3)
+	steal := payload.Sketches[CPUSteal]
+	assert.Equal(t, int64(2), steal.Cnt)
+	assert.Equal(t, 1.0, steal.Min)
+	assert.Equal(t, 3.0, steal.Max)
+	assert.Equal(t, 2.0, steal.Avg)
+	assert.Equal(t, int64(3), payload.Sketches[IOLatency].Cnt)
+	assert.NotEmpty(t, payload.Sketches[IOLatency].K)
+
+	raw, err := payload.MarshalJSON()
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Contains(t, decoded["host_profile"], MemoryPressure)
+
+	// the sketches are reset by the flush
+	assert.Nil(t, p.flush(start.Add(2*time.Hour)))
+}
@@ -0,0 +1,210 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package hostprofile
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// cpuTimes holds the counters of the cpu line of /proc/stat, in ticks
+type cpuTimes struct {
+	steal, total uint64
+}
+
+// diskCounters holds the counters of a disk from /proc/diskstats
+type diskCounters struct {
+	ios, ms uint64
+}
+
+// procfsSampler samples the host performance from procfs
+type procfsSampler struct {
+	procPath string
+
+	prevCPU      *cpuTimes
+	prevDisks    map[string]diskCounters
+	prevPressure uint64
+	prevTime     time.Time
+}
+
+func newSampler() (sampler, error) {
+	return &procfsSampler{procPath: config.Datadog.GetString("procfs_path")}, nil
+}
+
+func (s *procfsSampler) sample(now time.Time) (map[string][]float64, error) {
+	samples := make(map[string][]float64)
+	var errs []string
+
+	if cpu, err := s.readCPU(); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		if s.prevCPU != nil && cpu.total > s.prevCPU.total {
+			steal := float64(cpu.steal-s.prevCPU.steal) / float64(cpu.total-s.prevCPU.total) * 100
+			samples[CPUSteal] = []float64{steal}
+		}
+		s.prevCPU = &cpu
+	}
+
+	if disks, err := s.readDisks(); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		for name, disk := range disks {
+			prev, found := s.prevDisks[name]
+			if found && disk.ios > prev.ios && disk.ms >= prev.ms {
+				samples[IOLatency] = append(samples[IOLatency], float64(disk.ms-prev.ms)/float64(disk.ios-prev.ios))
+			}
+		}
+		s.prevDisks = disks
+	}
+
+	// the pressure stall information is only available from Linux 4.20
+	if stalled, err := s.readMemoryPressure(); err == nil {
+		if !s.prevTime.IsZero() && now.After(s.prevTime) && stalled >= s.prevPressure {
+			elapsed := now.Sub(s.prevTime).Microseconds()
+			samples[MemoryPressure] = []float64{float64(stalled-s.prevPressure) / float64(elapsed) * 100}
+		}
+		s.prevPressure = stalled
+	} else if !os.IsNotExist(err) {
+		errs = append(errs, err.Error())
+	}
+	s.prevTime = now
+
+	if len(errs) > 0 {
+		return samples, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return samples, nil
+}
+
+// readCPU reads the aggregated cpu line of /proc/stat
+func (s *procfsSampler) readCPU() (cpuTimes, error) {
+	f, err := os.Open(filepath.Join(s.procPath, "stat"))
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 || fields[0] != "cpu" {
+			continue
+		}
+		// user nice system idle iowait irq softirq steal, the guest times
+		// are already part of the user time
+		var times cpuTimes
+		for i, field := range fields[1:9] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("invalid cpu time %q: %v", field, err)
+			}
+			times.total += v
+			if i == 7 {
+				times.steal = v
+			}
+		}
+		return times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return cpuTimes{}, err
+	}
+	return cpuTimes{}, fmt.Errorf("no cpu line in %s", f.Name())
+}
+
+// readDisks reads the IO counters of the disks from /proc/diskstats. The
+// partitions, loop and ram devices are ignored.
+func (s *procfsSampler) readDisks() (map[string]diskCounters, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(s.procPath, "diskstats"))
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make(map[string]diskCounters)
+	var names []string
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 11 {
+			continue
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+		var values [4]uint64
+		valid := true
+		// reads completed, time reading, writes completed, time writing
+		for i, idx := range []int{3, 6, 7, 10} {
+			if values[i], err = strconv.ParseUint(fields[idx], 10, 64); err != nil {
+				valid = false
+				break
+			}
+		}
+		if !valid {
+			continue
+		}
+		disks[name] = diskCounters{ios: values[0] + values[2], ms: values[1] + values[3]}
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		for _, parent := range names {
+			if isPartition(name, parent) {
+				delete(disks, name)
+				break
+			}
+		}
+	}
+	return disks, nil
+}
+
+// isPartition returns whether name is a partition of the parent device, like
+// sda1 of sda or nvme0n1p1 of nvme0n1
+func isPartition(name, parent string) bool {
+	if name == parent || !strings.HasPrefix(name, parent) {
+		return false
+	}
+	suffix := strings.TrimPrefix(strings.TrimPrefix(name, parent), "p")
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// readMemoryPressure returns the total time in microseconds some tasks were
+// stalled on memory, from /proc/pressure/memory
+func (s *procfsSampler) readMemoryPressure() (uint64, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(s.procPath, "pressure", "memory"))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "some" {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "total=") {
+				return strconv.ParseUint(strings.TrimPrefix(field, "total="), 10, 64)
+			}
+		}
+	}
+	return 0, fmt.Errorf("no memory pressure total in %s", filepath.Join(s.procPath, "pressure", "memory"))
+}
+
+func (s *procfsSampler) kernelVersion() string {
+	raw, err := ioutil.ReadFile(filepath.Join(s.procPath, "sys", "kernel", "osrelease"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
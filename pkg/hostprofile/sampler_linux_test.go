@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package hostprofile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProcFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestProcfsSampler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hostprofile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &procfsSampler{procPath: dir}
+	now := time.Unix(1600000000, 0)
+	writeProcFiles(t, dir, map[string]string{
+		"stat":                 "cpu  100 0 100 700 0 0 0 100 0 0\ncpu0 100 0 100 700 0 0 0 100 0 0\n",
+		"diskstats":            "   8       0 sda 100 0 0 1000 100 0 0 1000 0 0 0\n   8       1 sda1 100 0 0 1000 100 0 0 1000 0 0 0\n   7       0 loop0 100 0 0 1000 0 0 0 0 0 0 0\n 259       0 nvme0n1 10 0 0 10 0 0 0 0 0 0 0\n",
+		"pressure/memory":      "some avg10=0.00 avg60=0.00 avg300=0.00 total=1000000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=500000\n",
+		"sys/kernel/osrelease": "5.4.0-1029-aws\n",
+	})
+	samples, err := s.sample(now)
+	require.NoError(t, err)
+	assert.Empty(t, samples, "the first sample has no previous counters")
+
+	writeProcFiles(t, dir, map[string]string{
+		"stat":            "cpu  150 0 150 780 0 0 0 120 0 0\n",
+		"diskstats":       "   8       0 sda 150 0 0 1500 150 0 0 2000 0 0 0\n   8       1 sda1 150 0 0 1500 150 0 0 2000 0 0 0\n   7       0 loop0 200 0 0 9000 0 0 0 0 0 0 0\n 259       0 nvme0n1 10 0 0 10 0 0 0 0 0 0 0\n",
+		"pressure/memory": "some avg10=0.00 avg60=0.00 avg300=0.00 total=2500000\n",
+	})
+	samples, err = s.sample(now.Add(15 * time.Second))
+	require.NoError(t, err)
+	// 20 ticks of steal out of 200
+	assert.Equal(t, []float64{10}, samples[CPUSteal])
+	// 1500ms for 100 IOs on sda, no IO on nvme0n1, partitions and loop devices ignored
+	assert.Equal(t, []float64{15}, samples[IOLatency])
+	// stalled 1.5s out of 15s
+	assert.Equal(t, []float64{10}, samples[MemoryPressure])
+	assert.Equal(t, "5.4.0-1029-aws", s.kernelVersion())
+}
+
+func TestProcfsSamplerWithoutPressure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hostprofile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeProcFiles(t, dir, map[string]string{
+		"stat":      "cpu  100 0 100 700 0 0 0 100 0 0\n",
+		"diskstats": "",
+	})
+	s := &procfsSampler{procPath: dir}
+	_, err = s.sample(time.Now())
+	assert.NoError(t, err, "the pressure stall information is optional")
+}
+
+func TestIsPartition(t *testing.T) {
+	assert.True(t, isPartition("sda1", "sda"))
+	assert.True(t, isPartition("nvme0n1p2", "nvme0n1"))
+	assert.False(t, isPartition("sda", "sda"))
+	assert.False(t, isPartition("sdab", "sda"))
+	assert.False(t, isPartition("nvme0n1", "nvme0"))
+}
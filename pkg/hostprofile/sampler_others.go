@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package hostprofile
+
+import "errors"
+
+func newSampler() (sampler, error) {
+	return nil, errors.New("the host profile is only supported on Linux")
+}
@@ -71,6 +71,7 @@ type JMXFetch struct {
 	Checks             []string
 	IPCPort            int
 	IPCHost            string
+	SessionToken       string
 	Output             func(...interface{})
 	cmd                *exec.Cmd
 	managed            bool
@@ -283,9 +284,13 @@ func (j *JMXFetch) Start(manage bool) error {
 	j.cmd = exec.Command(j.JavaBinPath, subprocessArgs...)
 
 	// set environment + token
+	sessionToken := j.SessionToken
+	if sessionToken == "" {
+		sessionToken = api.GetAuthToken()
+	}
 	j.cmd.Env = append(
 		os.Environ(),
-		fmt.Sprintf("SESSION_TOKEN=%s", api.GetAuthToken()),
+		fmt.Sprintf("SESSION_TOKEN=%s", sessionToken),
 	)
 
 	// forward the standard output to the Agent logger
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localalert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// actionTimeout bounds the time spent running a command or sending a trap
+const actionTimeout = 30 * time.Second
+
+// snmpTrapOIDOID is the OID of the variable holding the OID of a trap. The
+// sysUpTime variable preceding it is added by gosnmp.
+const snmpTrapOIDOID = "1.3.6.1.6.3.1.1.4.1.0"
+
+func runAction(action Action, alert Alert) error {
+	switch action.Type {
+	case ActionFile:
+		return writeAlert(action.Path, alert)
+	case ActionCommand:
+		return runCommand(action.Command, alert)
+	case ActionSNMPTrap:
+		return sendTrap(action, alert)
+	}
+	return fmt.Errorf("unsupported action type %q", action.Type)
+}
+
+// writeAlert appends the alert as a JSON line to a file
+func writeAlert(path string, alert Alert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runCommand runs a command with the alert as JSON on its stdin
+func runCommand(command []string, alert Alert) error {
+	input, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, output)
+	}
+	return nil
+}
+
+// sendTrap sends an SNMPv2c trap of the configured OID, with the alert as a
+// JSON string variable of the same OID
+func sendTrap(action Action, alert Alert) error {
+	message, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	host, portStr, err := net.SplitHostPort(action.Target)
+	if err != nil {
+		host, portStr = action.Target, "162"
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid trap target port %q: %v", portStr, err)
+	}
+	community := action.Community
+	if community == "" {
+		community = "public"
+	}
+
+	params := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      uint16(port),
+		Community: community,
+		Version:   gosnmp.Version2c,
+		Timeout:   actionTimeout,
+	}
+	if err := params.Connect(); err != nil {
+		return err
+	}
+	defer params.Conn.Close()
+
+	trap := gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: snmpTrapOIDOID, Type: gosnmp.ObjectIdentifier, Value: action.TrapOID},
+			{Name: action.TrapOID, Type: gosnmp.OctetString, Value: string(message)},
+		},
+	}
+	_, err = params.SendTrap(trap)
+	return err
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localalert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// NewEngineFromConfig returns an engine evaluating the local_alerting rules
+func NewEngineFromConfig(lastIntakeResponse func() time.Time) (*Engine, error) {
+	var rules []Rule
+	if err := config.Datadog.UnmarshalKey("local_alerting.rules", &rules); err != nil {
+		return nil, fmt.Errorf("unable to parse the local_alerting rules: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no local_alerting rules configured")
+	}
+	names := make(map[string]struct{}, len(rules))
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return nil, err
+		}
+		if _, found := names[rules[i].Name]; found {
+			return nil, fmt.Errorf("duplicate local_alerting rule %s", rules[i].Name)
+		}
+		names[rules[i].Name] = struct{}{}
+	}
+
+	offlineAfter := config.Datadog.GetDuration("local_alerting.offline_after")
+	if offlineAfter <= 0 {
+		return nil, fmt.Errorf("invalid local_alerting.offline_after %s, it must be positive", offlineAfter)
+	}
+	return NewEngine(rules, offlineAfter, lastIntakeResponse), nil
+}
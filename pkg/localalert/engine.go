@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localalert
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Statuses of an alert
+const (
+	StatusTriggered = "triggered"
+	StatusRecovered = "recovered"
+)
+
+// Alert is passed to the actions of a rule when it triggers or recovers
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Status    string    `json:"status"`
+	Metric    string    `json:"metric"`
+	Host      string    `json:"host"`
+	Tags      []string  `json:"tags"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Operator  string    `json:"operator"`
+	Time      time.Time `json:"time"`
+}
+
+// Engine evaluates the rules on the flushed series. The state of the rules is
+// always tracked, but their actions only run while the intake is unreachable:
+// otherwise the monitors of the Datadog application take over.
+type Engine struct {
+	rules        []Rule
+	offlineAfter time.Duration
+	// lastIntakeResponse returns the time of the last response of the
+	// intake, the zero time if none was received yet
+	lastIntakeResponse func() time.Time
+	run                func(Action, Alert) error
+
+	mu        sync.Mutex
+	start     time.Time
+	triggered map[string]bool // rule name -> triggered
+	stopped   bool
+}
+
+// NewEngine returns an engine considering the intake unreachable when it did
+// not respond for offlineAfter
+func NewEngine(rules []Rule, offlineAfter time.Duration, lastIntakeResponse func() time.Time) *Engine {
+	return &Engine{
+		rules:              rules,
+		offlineAfter:       offlineAfter,
+		lastIntakeResponse: lastIntakeResponse,
+		run:                runAction,
+		start:              time.Now(),
+		triggered:          make(map[string]bool),
+	}
+}
+
+// Stop stops the evaluation of the rules
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stopped = true
+}
+
+// offline returns whether the intake is considered unreachable
+func (e *Engine) offline(now time.Time) bool {
+	last := e.lastIntakeResponse()
+	if last.IsZero() {
+		last = e.start
+	}
+	return now.Sub(last) > e.offlineAfter
+}
+
+// Evaluate evaluates the rules on the series of a flush. The actions run in
+// the background not to block the flush.
+func (e *Engine) Evaluate(series metrics.Series) {
+	e.evaluate(series, time.Now())
+}
+
+func (e *Engine) evaluate(series metrics.Series, now time.Time) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopped {
+		return nil
+	}
+	offline := e.offline(now)
+
+	var alerts []Alert
+	for i := range e.rules {
+		rule := &e.rules[i]
+		alert, found := e.evaluateRule(rule, series, now)
+		if !found {
+			// no data, keep the previous state
+			continue
+		}
+		triggered := alert.Status == StatusTriggered
+		if triggered == e.triggered[rule.Name] {
+			continue
+		}
+		e.triggered[rule.Name] = triggered
+		log.Infof("Local alert rule %s %s: %s is %v", rule.Name, alert.Status, alert.Metric, alert.Value)
+		if !offline {
+			continue
+		}
+		alerts = append(alerts, alert)
+		for _, action := range rule.Actions {
+			go func(action Action, alert Alert) {
+				if err := e.run(action, alert); err != nil {
+					log.Errorf("Could not run the %s action of the local alert rule %s: %v", action.Type, alert.Rule, err)
+				}
+			}(action, alert)
+		}
+	}
+	return alerts
+}
+
+// evaluateRule returns the alert of a rule from the last points of the
+// matching series. The rule triggers if any series crosses the threshold.
+func (e *Engine) evaluateRule(rule *Rule, series metrics.Series, now time.Time) (Alert, bool) {
+	alert := Alert{
+		Rule:      rule.Name,
+		Status:    StatusRecovered,
+		Metric:    rule.Metric,
+		Threshold: rule.Threshold,
+		Operator:  rule.Operator,
+		Time:      now,
+	}
+	found := false
+	for _, serie := range series {
+		if len(serie.Points) == 0 || !rule.matches(serie) {
+			continue
+		}
+		value := serie.Points[len(serie.Points)-1].Value
+		if !found || (alert.Status != StatusTriggered && rule.crossed(value)) {
+			alert.Host = serie.Host
+			alert.Tags = serie.Tags
+			alert.Value = value
+		}
+		found = true
+		if rule.crossed(value) {
+			alert.Status = StatusTriggered
+		}
+	}
+	return alert, found
+}
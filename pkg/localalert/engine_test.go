@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package localalert
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newSerie(name string, value float64, tags ...string) *metrics.Serie {
+	return &metrics.Serie{
+		Name:   name,
+		Host:   "ship-1",
+		Tags:   tags,
+		Points: []metrics.Point{{Ts: 1, Value: value}},
+	}
+}
+
+type recordedActions struct {
+	sync.WaitGroup
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recordedActions) run(action Action, alert Alert) error {
+	defer r.Done()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestRuleValidate(t *testing.T) {
+	fileAction := Action{Type: ActionFile, Path: "/tmp/alerts.json"}
+	valid := Rule{Name: "disk", Metric: "system.disk.in_use", Operator: ">", Threshold: 0.9, Actions: []Action{fileAction}}
+	assert.NoError(t, valid.validate())
+
+	for name, rule := range map[string]Rule{
+		"no name":      {Metric: "m", Operator: ">", Actions: []Action{fileAction}},
+		"no metric":    {Name: "r", Operator: ">", Actions: []Action{fileAction}},
+		"bad operator": {Name: "r", Metric: "m", Operator: "==", Actions: []Action{fileAction}},
+		"no action":    {Name: "r", Metric: "m", Operator: ">"},
+		"bad action":   {Name: "r", Metric: "m", Operator: ">", Actions: []Action{{Type: "email"}}},
+		"no trap oid":  {Name: "r", Metric: "m", Operator: ">", Actions: []Action{{Type: ActionSNMPTrap, Target: "10.0.0.1"}}},
+	} {
+		assert.Error(t, rule.validate(), name)
+	}
+}
+
+func TestEngineTransitions(t *testing.T) {
+	now := time.Now()
+	lastResponse := now.Add(-time.Hour)
+	rules := []Rule{{
+		Name:      "engine-temperature",
+		Metric:    "engine.temperature",
+		Tags:      []string{"engine:main"},
+		Operator:  ">=",
+		Threshold: 90,
+		Actions:   []Action{{Type: ActionFile, Path: "unused"}},
+	}}
+	e := NewEngine(rules, 5*time.Minute, func() time.Time { return lastResponse })
+	recorded := &recordedActions{}
+	e.run = recorded.run
+
+	// below the threshold, nothing to recover from
+	assert.Len(t, e.evaluate(metrics.Series{newSerie("engine.temperature", 80, "engine:main")}, now), 0)
+
+	// the tags of the rule must match
+	assert.Len(t, e.evaluate(metrics.Series{newSerie("engine.temperature", 95, "engine:aux")}, now), 0)
+
+	recorded.Add(1)
+	alerts := e.evaluate(metrics.Series{
+		newSerie("engine.temperature", 80, "engine:main", "ship:1"),
+		newSerie("engine.temperature", 95, "engine:main", "ship:2"),
+	}, now)
+	recorded.Wait()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, StatusTriggered, alerts[0].Status)
+	assert.Equal(t, float64(95), alerts[0].Value)
+	assert.Equal(t, []string{"engine:main", "ship:2"}, alerts[0].Tags)
+	assert.Len(t, recorded.alerts, 1)
+
+	// still triggered, no new alert
+	assert.Len(t, e.evaluate(metrics.Series{newSerie("engine.temperature", 96, "engine:main")}, now), 0)
+	// no data keeps the state
+	assert.Len(t, e.evaluate(metrics.Series{}, now), 0)
+
+	recorded.Add(1)
+	alerts = e.evaluate(metrics.Series{newSerie("engine.temperature", 85, "engine:main")}, now)
+	recorded.Wait()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, StatusRecovered, alerts[0].Status)
+	assert.Len(t, recorded.alerts, 2)
+
+	// the intake is reachable: the state changes without running the actions
+	lastResponse = now
+	assert.Len(t, e.evaluate(metrics.Series{newSerie("engine.temperature", 99, "engine:main")}, now), 0)
+	assert.True(t, e.triggered["engine-temperature"])
+
+	e.Stop()
+	assert.Len(t, e.evaluate(metrics.Series{newSerie("engine.temperature", 10, "engine:main")}, now.Add(time.Hour)), 0)
+	assert.Len(t, recorded.alerts, 2)
+}
+
+func TestEngineOfflineSinceStart(t *testing.T) {
+	e := NewEngine(nil, time.Minute, func() time.Time { return time.Time{} })
+	assert.False(t, e.offline(e.start.Add(30*time.Second)))
+	assert.True(t, e.offline(e.start.Add(2*time.Minute)))
+}
+
+func TestFileAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localalert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alerts.json")
+
+	alert := Alert{Rule: "disk", Status: StatusTriggered, Metric: "system.disk.in_use", Value: 0.95}
+	require.NoError(t, runAction(Action{Type: ActionFile, Path: path}, alert))
+	alert.Status = StatusRecovered
+	require.NoError(t, runAction(Action{Type: ActionFile, Path: path}, alert))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	var written Alert
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &written))
+	assert.Equal(t, "disk", written.Rule)
+	assert.Equal(t, StatusRecovered, written.Status)
+}
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package localalert evaluates simple threshold rules on the series flushed by
+// the agent and runs local actions when they trigger while the intake is
+// unreachable, for hosts with an intermittent connectivity.
+package localalert
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// Types of actions
+const (
+	// ActionFile appends the alert as a JSON line to a file
+	ActionFile = "file"
+	// ActionCommand runs a command with the alert as JSON on its stdin
+	ActionCommand = "command"
+	// ActionSNMPTrap sends an SNMPv2c trap
+	ActionSNMPTrap = "snmp_trap"
+)
+
+// Rule triggers when a series matching its metric name and tags crosses the
+// threshold
+type Rule struct {
+	Name      string   `mapstructure:"name"`
+	Metric    string   `mapstructure:"metric"`
+	Tags      []string `mapstructure:"tags"`
+	Operator  string   `mapstructure:"operator"`
+	Threshold float64  `mapstructure:"threshold"`
+	Actions   []Action `mapstructure:"actions"`
+}
+
+// Action is run when a rule triggers or recovers
+type Action struct {
+	Type string `mapstructure:"type"`
+	// Path of the file action
+	Path string `mapstructure:"path"`
+	// Command and arguments of the command action
+	Command []string `mapstructure:"command"`
+	// Target host:port, community and trap OID of the snmp_trap action
+	Target    string `mapstructure:"target"`
+	Community string `mapstructure:"community"`
+	TrapOID   string `mapstructure:"trap_oid"`
+}
+
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("a rule has no name")
+	}
+	if r.Metric == "" {
+		return fmt.Errorf("rule %s has no metric", r.Name)
+	}
+	switch r.Operator {
+	case ">", ">=", "<", "<=":
+	default:
+		return fmt.Errorf("rule %s has an unsupported operator %q, expected one of >, >=, < or <=", r.Name, r.Operator)
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("rule %s has no action", r.Name)
+	}
+	for _, a := range r.Actions {
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("rule %s: %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a *Action) validate() error {
+	switch a.Type {
+	case ActionFile:
+		if a.Path == "" {
+			return fmt.Errorf("the file action has no path")
+		}
+	case ActionCommand:
+		if len(a.Command) == 0 {
+			return fmt.Errorf("the command action has no command")
+		}
+	case ActionSNMPTrap:
+		if a.Target == "" || a.TrapOID == "" {
+			return fmt.Errorf("the snmp_trap action needs a target and a trap_oid")
+		}
+	default:
+		return fmt.Errorf("unsupported action type %q", a.Type)
+	}
+	return nil
+}
+
+// matches returns whether the rule applies to a series: same metric name and
+// all the tags of the rule
+func (r *Rule) matches(serie *metrics.Serie) bool {
+	if serie.Name != r.Metric {
+		return false
+	}
+	for _, tag := range r.Tags {
+		found := false
+		for _, t := range serie.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// crossed returns whether a value crosses the threshold of the rule
+func (r *Rule) crossed(value float64) bool {
+	switch r.Operator {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	}
+	return false
+}
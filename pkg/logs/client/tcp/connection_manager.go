@@ -18,6 +18,7 @@ import (
 
 	"golang.org/x/net/proxy"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -107,9 +108,11 @@ func (cm *ConnectionManager) NewConnection(ctx context.Context) (net.Conn, error
 		log.Debugf("connected to %v", cm.address())
 
 		if cm.endpoint.UseSSL {
-			sslConn := tls.Client(conn, &tls.Config{
+			tlsConfig := &tls.Config{
 				ServerName: cm.endpoint.Host,
-			})
+			}
+			coreConfig.ConfigureFIPSTLS(tlsConfig)
+			sslConn := tls.Client(conn, tlsConfig)
 			err = cm.handshakeWithTimeout(sslConn, connectionTimeout)
 			if err != nil {
 				log.Warn(err)
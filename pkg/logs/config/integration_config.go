@@ -18,6 +18,7 @@ const (
 	DockerType       = "docker"
 	JournaldType     = "journald"
 	WindowsEventType = "windows_event"
+	UnifiedLogType   = "unified_log"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -43,6 +44,9 @@ type LogsConfig struct {
 	ChannelPath string `mapstructure:"channel_path" json:"channel_path"` // Windows Event
 	Query       string // Windows Event
 
+	Predicate string // Unified Log
+	Level     string // Unified Log
+
 	Service         string
 	Source          string
 	SourceCategory  string
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package diagnostic streams the processed log messages to troubleshooting
+// clients like the `agent stream-logs` command.
+package diagnostic
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Default is the broadcaster the logs processors send their messages to
+var Default = NewBroadcaster()
+
+// Filters selects the messages streamed to a subscriber, an empty filter
+// matching every message
+type Filters struct {
+	// Name is the name of the log source, e.g. the integration name
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Source  string `json:"source"`
+	Service string `json:"service"`
+	// Pattern is a regular expression the content must match
+	Pattern string `json:"pattern"`
+}
+
+// Message is a processed log message, after the processing rules were applied
+type Message struct {
+	Timestamp time.Time `json:"timestamp"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Service   string    `json:"service"`
+	Status    string    `json:"status"`
+	Tags      []string  `json:"tags"`
+	Content   string    `json:"content"`
+}
+
+type subscriber struct {
+	filters Filters
+	pattern *regexp.Regexp
+	ch      chan Message
+	dropped uint64
+}
+
+// Broadcaster sends the log messages to the subscribed clients. Messages are
+// dropped for the clients not reading fast enough so that they never slow
+// the logs pipeline down.
+type Broadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	active      int32
+}
+
+// NewBroadcaster returns a broadcaster without subscribers
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Subscribe returns a channel receiving the messages matching the filters,
+// and the function to call to unsubscribe, returning the number of messages
+// dropped because the channel was full
+func (b *Broadcaster) Subscribe(filters Filters, bufferSize int) (<-chan Message, func() uint64, error) {
+	s := &subscriber{
+		filters: filters,
+		ch:      make(chan Message, bufferSize),
+	}
+	if filters.Pattern != "" {
+		pattern, err := regexp.Compile(filters.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern: %v", err)
+		}
+		s.pattern = pattern
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	atomic.StoreInt32(&b.active, int32(len(b.subscribers)))
+	b.mu.Unlock()
+
+	unsubscribe := func() uint64 {
+		b.mu.Lock()
+		delete(b.subscribers, s)
+		atomic.StoreInt32(&b.active, int32(len(b.subscribers)))
+		b.mu.Unlock()
+		return atomic.LoadUint64(&s.dropped)
+	}
+	return s.ch, unsubscribe, nil
+}
+
+// Enabled returns whether a client is subscribed, so that the pipeline only
+// builds the messages when needed
+func (b *Broadcaster) Enabled() bool {
+	return atomic.LoadInt32(&b.active) > 0
+}
+
+// HandleMessage sends a processed message and its content, redacted by the
+// processing rules, to the matching subscribers
+func (b *Broadcaster) HandleMessage(msg *message.Message, redactedContent []byte) {
+	var m *Message
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subscribers {
+		if !s.match(msg, redactedContent) {
+			continue
+		}
+		if m == nil {
+			m = toMessage(msg, redactedContent)
+		}
+		select {
+		case s.ch <- *m:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+func (s *subscriber) match(msg *message.Message, content []byte) bool {
+	origin := msg.Origin
+	source := origin.LogSource
+	if s.filters.Name != "" && s.filters.Name != source.Name {
+		return false
+	}
+	if s.filters.Type != "" && s.filters.Type != source.Config.Type {
+		return false
+	}
+	if s.filters.Source != "" && s.filters.Source != origin.Source() {
+		return false
+	}
+	if s.filters.Service != "" && s.filters.Service != origin.Service() {
+		return false
+	}
+	if s.pattern != nil && !s.pattern.Match(content) {
+		return false
+	}
+	return true
+}
+
+func toMessage(msg *message.Message, content []byte) *Message {
+	origin := msg.Origin
+	return &Message{
+		Timestamp: time.Now(),
+		Name:      origin.LogSource.Name,
+		Type:      origin.LogSource.Config.Type,
+		Source:    origin.Source(),
+		Service:   origin.Service(),
+		Status:    msg.GetStatus(),
+		Tags:      origin.Tags(),
+		Content:   string(content),
+	}
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnostic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestMessage(content, name, source, service string) *message.Message {
+	logSource := config.NewLogSource(name, &config.LogsConfig{
+		Type:    config.FileType,
+		Source:  source,
+		Service: service,
+	})
+	return message.NewMessageWithSource([]byte(content), message.StatusInfo, logSource)
+}
+
+func TestBroadcasterFilters(t *testing.T) {
+	b := NewBroadcaster()
+	assert.False(t, b.Enabled())
+
+	all, unsubscribeAll, err := b.Subscribe(Filters{}, 10)
+	require.NoError(t, err)
+	nginx, unsubscribeNginx, err := b.Subscribe(Filters{Source: "nginx", Pattern: "GET"}, 10)
+	require.NoError(t, err)
+	assert.True(t, b.Enabled())
+
+	b.HandleMessage(newTestMessage("GET /", "web", "nginx", "front"), []byte("GET /"))
+	b.HandleMessage(newTestMessage("POST /", "web", "nginx", "front"), []byte("POST /"))
+	b.HandleMessage(newTestMessage("GET /", "api", "apache", "back"), []byte("GET /"))
+
+	assert.Len(t, all, 3)
+	require.Len(t, nginx, 1)
+	m := <-nginx
+	assert.Equal(t, "web", m.Name)
+	assert.Equal(t, config.FileType, m.Type)
+	assert.Equal(t, "nginx", m.Source)
+	assert.Equal(t, "front", m.Service)
+	assert.Equal(t, message.StatusInfo, m.Status)
+	assert.Equal(t, "GET /", m.Content)
+
+	assert.Equal(t, uint64(0), unsubscribeNginx())
+	assert.Equal(t, uint64(0), unsubscribeAll())
+	assert.False(t, b.Enabled())
+}
+
+func TestBroadcasterRedactedContent(t *testing.T) {
+	b := NewBroadcaster()
+	ch, unsubscribe, err := b.Subscribe(Filters{Pattern: "password"}, 10)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	// The pattern and the streamed content are the ones after the processing rules
+	b.HandleMessage(newTestMessage("password=secret", "app", "", ""), []byte("[REDACTED]"))
+	assert.Len(t, ch, 0)
+	b.HandleMessage(newTestMessage("password=secret", "app", "", ""), []byte("password=[REDACTED]"))
+	require.Len(t, ch, 1)
+	assert.Equal(t, "password=[REDACTED]", (<-ch).Content)
+}
+
+func TestBroadcasterDropsWhenFull(t *testing.T) {
+	b := NewBroadcaster()
+	_, unsubscribe, err := b.Subscribe(Filters{}, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		b.HandleMessage(newTestMessage("hello", "app", "", ""), []byte("hello"))
+	}
+	assert.Equal(t, uint64(2), unsubscribe())
+}
+
+func TestBroadcasterInvalidPattern(t *testing.T) {
+	b := NewBroadcaster()
+	_, _, err := b.Subscribe(Filters{Pattern: "("}, 1)
+	assert.Error(t, err)
+	assert.False(t, b.Enabled())
+}
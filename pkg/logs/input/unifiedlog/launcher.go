@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package unifiedlog
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// levels supported by `log stream --level`
+var levels = map[string]bool{
+	"default": true,
+	"info":    true,
+	"debug":   true,
+}
+
+const defaultLevel = "default"
+
+// Launcher is in charge of starting and stopping unified logging tailers
+type Launcher struct {
+	sources          chan *config.LogSource
+	pipelineProvider pipeline.Provider
+	tailers          map[string]*Tailer
+	stop             chan struct{}
+}
+
+// NewLauncher returns a new Launcher.
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		sources:          sources.GetAddedForType(config.UnifiedLogType),
+		pipelineProvider: pipelineProvider,
+		tailers:          make(map[string]*Tailer),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// run starts new tailers.
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.sources:
+			config := l.sanitizedConfig(source.Config)
+			identifier := Identifier(config.Predicate, config.Level)
+			if _, exists := l.tailers[identifier]; exists {
+				// tailer already setup
+				continue
+			}
+			tailer := NewTailer(source, config, l.pipelineProvider.NextPipelineChan())
+			tailer.Start()
+			l.tailers[identifier] = tailer
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop stops all active tailers
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for _, tailer := range l.tailers {
+		stopper.Add(tailer)
+		delete(l.tailers, tailer.Identifier())
+	}
+	stopper.Stop()
+}
+
+// sanitizedConfig sets default values for the config
+func (l *Launcher) sanitizedConfig(sourceConfig *config.LogsConfig) *Config {
+	config := &Config{Predicate: sourceConfig.Predicate, Level: sourceConfig.Level}
+	if config.Level == "" {
+		config.Level = defaultLevel
+	} else if !levels[config.Level] {
+		log.Warnf("Invalid unified logging level %q, using %q", config.Level, defaultLevel)
+		config.Level = defaultLevel
+	}
+	return config
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package unifiedlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestShouldSanitizeConfig(t *testing.T) {
+	launcher := NewLauncher(config.NewLogSources(), nil)
+	assert.Equal(t, "default", launcher.sanitizedConfig(&config.LogsConfig{Predicate: `subsystem == "com.apple.foo"`}).Level)
+	assert.Equal(t, "debug", launcher.sanitizedConfig(&config.LogsConfig{Level: "debug"}).Level)
+	assert.Equal(t, "default", launcher.sanitizedConfig(&config.LogsConfig{Level: "fault"}).Level)
+}
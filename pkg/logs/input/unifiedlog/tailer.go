@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package unifiedlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	messageField     = "eventMessage"
+	messageTypeField = "messageType"
+
+	// maxLineSize bounds the size of an entry printed by `log stream`
+	maxLineSize = 1024 * 1024
+)
+
+// Config is a unified logging tailer configuration
+type Config struct {
+	Predicate string
+	Level     string
+}
+
+// Tailer collects the logs of the macOS unified logging system, streamed
+// by the `log` command line tool.
+type Tailer struct {
+	source     *config.LogSource
+	config     *Config
+	outputChan chan *message.Message
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewTailer returns a new tailer.
+func NewTailer(source *config.LogSource, config *Config, outputChan chan *message.Message) *Tailer {
+	return &Tailer{
+		source:     source,
+		config:     config,
+		outputChan: outputChan,
+		stop:       make(chan struct{}, 1),
+		done:       make(chan struct{}, 1),
+	}
+}
+
+// Identifier returns a string that uniquely identifies a source
+func Identifier(predicate, level string) string {
+	return fmt.Sprintf("unified_log:%s;%s", predicate, level)
+}
+
+// Identifier returns a string that uniquely identifies a source
+func (t *Tailer) Identifier() string {
+	return Identifier(t.config.Predicate, t.config.Level)
+}
+
+// streamArgs returns the arguments of the `log` command streaming the entries
+func (t *Tailer) streamArgs() []string {
+	args := []string{"stream", "--style", "ndjson", "--level", t.config.Level}
+	if t.config.Predicate != "" {
+		args = append(args, "--predicate", t.config.Predicate)
+	}
+	return args
+}
+
+// readStream forwards the entries read from r, one JSON object per line,
+// until r is closed. The other lines, like the header printed by `log
+// stream`, are skipped.
+func (t *Tailer) readStream(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		msg, err := t.toMessage(line)
+		if err != nil {
+			log.Debugf("Could not parse unified logging entry: %s", err)
+			continue
+		}
+		t.outputChan <- msg
+	}
+	return scanner.Err()
+}
+
+// toMessage transforms an entry into a message, remapping "eventMessage"
+// into "message" and bundling all the other keys in a "unified_log" attribute.
+// ex:
+//
+//	entry:
+//	{
+//	  "eventMessage": "foo",
+//	  "subsystem": "com.apple.foo",
+//	  ...
+//	}
+//
+//	message-content:
+//	{
+//	  "message": "foo",
+//	  "unified_log": {
+//	    "subsystem": "com.apple.foo",
+//	    ...
+//	  }
+//	}
+func (t *Tailer) toMessage(entry []byte) (*message.Message, error) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return nil, err
+	}
+	content := make(map[string]interface{})
+	if msg, exists := fields[messageField]; exists {
+		content["message"] = msg
+		delete(fields, messageField)
+	}
+	content["unified_log"] = fields
+
+	status := message.StatusInfo
+	if messageType, ok := fields[messageTypeField].(string); ok {
+		status = toStatus(messageType)
+	}
+
+	jsonContent, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return message.NewMessageWithSource(jsonContent, status, t.source), nil
+}
+
+// toStatus maps the type of an entry to a message status
+func toStatus(messageType string) string {
+	switch messageType {
+	case "Fault":
+		return message.StatusCritical
+	case "Error":
+		return message.StatusError
+	case "Debug":
+		return message.StatusDebug
+	default:
+		// Default and Info
+		return message.StatusInfo
+	}
+}
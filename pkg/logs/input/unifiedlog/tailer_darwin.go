@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build darwin
+
+package unifiedlog
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const logCommand = "/usr/bin/log"
+
+// Start starts streaming the entries
+func (t *Tailer) Start() {
+	cmd := exec.Command(logCommand, t.streamArgs()...)
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		err = fmt.Errorf("could not stream the unified logging entries: %s", err)
+		t.source.Status.Error(err)
+		log.Error(err)
+		t.done <- struct{}{}
+		return
+	}
+
+	t.source.Status.Success()
+	t.source.AddInput(t.Identifier())
+	log.Info("Start tailing unified logging ", t.Identifier())
+
+	go func() {
+		<-t.stop
+		// makes readStream return
+		_ = cmd.Process.Kill()
+	}()
+	go func() {
+		defer func() {
+			t.done <- struct{}{}
+		}()
+		if err := t.readStream(stdout); err != nil {
+			log.Warnf("Error reading the unified logging entries: %s", err)
+		}
+		if err := cmd.Wait(); err != nil {
+			log.Debugf("log stream exited: %s", err)
+		}
+	}()
+}
+
+// Stop stops the tailer
+func (t *Tailer) Stop() {
+	log.Info("Stop tailing unified logging ", t.Identifier())
+	t.stop <- struct{}{}
+	t.source.RemoveInput(t.Identifier())
+	<-t.done
+}
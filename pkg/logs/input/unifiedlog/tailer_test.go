@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package unifiedlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestStreamArgs(t *testing.T) {
+	tailer := NewTailer(nil, &Config{Level: "info"}, nil)
+	assert.Equal(t, []string{"stream", "--style", "ndjson", "--level", "info"}, tailer.streamArgs())
+
+	tailer = NewTailer(nil, &Config{Predicate: `process == "foo"`, Level: "default"}, nil)
+	assert.Equal(t, []string{"stream", "--style", "ndjson", "--level", "default", "--predicate", `process == "foo"`}, tailer.streamArgs())
+}
+
+func TestToMessage(t *testing.T) {
+	tailer := NewTailer(nil, &Config{Level: "default"}, nil)
+	entry := `{"eventMessage":"foo","messageType":"Error","subsystem":"com.apple.foo"}`
+	msg, err := tailer.toMessage([]byte(entry))
+	require.NoError(t, err)
+	assert.Equal(t, `{"message":"foo","unified_log":{"messageType":"Error","subsystem":"com.apple.foo"}}`, string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+
+	_, err = tailer.toMessage([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestToStatus(t *testing.T) {
+	assert.Equal(t, message.StatusCritical, toStatus("Fault"))
+	assert.Equal(t, message.StatusError, toStatus("Error"))
+	assert.Equal(t, message.StatusDebug, toStatus("Debug"))
+	assert.Equal(t, message.StatusInfo, toStatus("Info"))
+	assert.Equal(t, message.StatusInfo, toStatus("Default"))
+}
+
+func TestReadStream(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	tailer := NewTailer(nil, &Config{Level: "default"}, outputChan)
+	stream := strings.Join([]string{
+		"Filtering the log data using \"process == \\\"foo\\\"\"",
+		`{"eventMessage":"first","messageType":"Default"}`,
+		"",
+		`{"eventMessage":"second","messageType":"Fault"}`,
+	}, "\n")
+
+	require.NoError(t, tailer.readStream(strings.NewReader(stream)))
+	require.Len(t, outputChan, 2)
+	assert.Equal(t, `{"message":"first","unified_log":{"messageType":"Default"}}`, string((<-outputChan).Content))
+	msg := <-outputChan
+	assert.Equal(t, `{"message":"second","unified_log":{"messageType":"Fault"}}`, string(msg.Content))
+	assert.Equal(t, message.StatusCritical, msg.GetStatus())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
@@ -58,6 +59,10 @@ func (p *Processor) run() {
 			metrics.LogsProcessed.Add(1)
 			metrics.TlmLogsProcessed.Inc()
 
+			if diagnostic.Default.Enabled() {
+				diagnostic.Default.HandleMessage(msg, redactedMsg)
+			}
+
 			// Encode the message to its final format
 			content, err := p.encoder.Encode(msg, redactedMsg)
 			if err != nil {
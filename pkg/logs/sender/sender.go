@@ -11,6 +11,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
 )
 
 // Strategy should contain all logic to send logs to a remote destination
@@ -62,6 +63,7 @@ func (s *Sender) run() {
 // it will forever retry for the main destination unless the error is not retryable
 // and only try once for additionnal destinations.
 func (s *Sender) send(payload []byte) error {
+	payloadaudit.Record(payloadaudit.KindLogs, payload)
 	for {
 		err := s.destinations.Main.Send(payload)
 		if err != nil {
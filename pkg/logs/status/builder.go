@@ -183,6 +183,9 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 	case config.WindowsEventType:
 		dictionary["ChannelPath"] = c.ChannelPath
 		dictionary["Query"] = c.Query
+	case config.UnifiedLogType:
+		dictionary["Predicate"] = c.Predicate
+		dictionary["Level"] = c.Level
 	}
 	for k, v := range dictionary {
 		if v == "" {
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/containerimages"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+// ContainerImagesCollector sends the inventory of the container images
+// present on the host
+type ContainerImagesCollector struct{}
+
+// Send collects the data needed and submits the payload
+func (c *ContainerImagesCollector) Send(s *serializer.Serializer) error {
+	if s == nil {
+		return nil
+	}
+
+	hostname, err := util.GetHostname()
+	if err != nil {
+		return fmt.Errorf("unable to submit container images metadata payload, no hostname: %s", err)
+	}
+
+	payload := containerimages.GetPayload(hostname)
+	if payload == nil {
+		// no container runtime on this host
+		return nil
+	}
+	if err := s.SendMetadata(payload); err != nil {
+		return fmt.Errorf("unable to submit container images payload, %s", err)
+	}
+	return nil
+}
+
+// SetupContainerImages registers the container images collector into the Scheduler and schedules it
+func SetupContainerImages(sc *Scheduler) error {
+	RegisterCollector("container_images", new(ContainerImagesCollector))
+
+	return sc.AddCollector("container_images", config.Datadog.GetDuration("container_images_interval")*time.Second)
+}
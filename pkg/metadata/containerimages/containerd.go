@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package containerimages
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+func listContainerdImages() ([]*Image, error) {
+	// don't keep retrying to connect to containerd on hosts not running it
+	if config.Datadog.GetString("cri_socket_path") == "" {
+		return nil, nil
+	}
+	cu, err := containerd.GetContainerdUtil()
+	if err != nil {
+		return nil, err
+	}
+	cImages, err := cu.Images()
+	if err != nil {
+		return nil, err
+	}
+
+	// containerd stores an image by name, images sharing the same manifest
+	// are merged
+	byDigest := make(map[string]*Image)
+	var images []*Image
+	for _, cImage := range cImages {
+		digest := cImage.Target().Digest.String()
+		image, found := byDigest[digest]
+		if !found {
+			image = &Image{
+				ID:      digest,
+				Runtime: "containerd",
+				Labels:  cImage.Labels(),
+			}
+			layers, err := cu.ImageLayers(cImage)
+			if err != nil {
+				log.Debugf("Unable to get the layers of the image %s: %s", cImage.Name(), err)
+			} else {
+				image.Layers = layers
+			}
+			byDigest[digest] = image
+			images = append(images, image)
+		}
+
+		// the name is either a reference, e.g. docker.io/library/redis:6, or
+		// a digest reference, e.g. docker.io/library/redis@sha256:...
+		if name := cImage.Name(); strings.Contains(name, "@") {
+			image.RepoDigests = append(image.RepoDigests, name)
+		} else if !strings.HasPrefix(name, "sha256:") {
+			image.RepoTags = append(image.RepoTags, name)
+		}
+	}
+	return images, nil
+}
+
+func init() {
+	listers["containerd"] = listContainerdImages
+}
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package containerimages
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// baseImageLabel is the OCI annotation, also set as a label by some build
+// tools, giving the name of the image an image was built from
+const baseImageLabel = "org.opencontainers.image.base.name"
+
+// imageLister lists the images of a container runtime
+type imageLister func() ([]*Image, error)
+
+// listers by container runtime, registered by the files built with the
+// runtime build tags
+var listers = map[string]imageLister{}
+
+// GetPayload returns the images present on the host for all the container
+// runtimes available, or nil if there is none.
+func GetPayload(hostname string) *Payload {
+	var images []*Image
+	runtimes := make([]string, 0, len(listers))
+	for runtime := range listers {
+		runtimes = append(runtimes, runtime)
+	}
+	sort.Strings(runtimes)
+	for _, runtime := range runtimes {
+		runtimeImages, err := listers[runtime]()
+		if err != nil {
+			// the runtime is most likely not running on this host
+			log.Debugf("Unable to list the %s images: %s", runtime, err)
+			continue
+		}
+		images = append(images, runtimeImages...)
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	setBaseImages(images)
+
+	return &Payload{
+		Hostname:  hostname,
+		Timestamp: time.Now().UnixNano(),
+		Images:    images,
+	}
+}
+
+// setBaseImages guesses the base image of each image. The base image
+// label is used if set, otherwise the base image is the image of the same
+// runtime whose layers are the longest strict prefix of the image layers.
+func setBaseImages(images []*Image) {
+	for _, image := range images {
+		if base, ok := image.Labels[baseImageLabel]; ok && base != "" {
+			image.BaseImage = base
+			continue
+		}
+
+		var base *Image
+		for _, candidate := range images {
+			if candidate == image || candidate.Runtime != image.Runtime {
+				continue
+			}
+			if !isLayersPrefix(candidate.Layers, image.Layers) {
+				continue
+			}
+			if base == nil || len(candidate.Layers) > len(base.Layers) {
+				base = candidate
+			}
+		}
+		if base != nil {
+			image.BaseImage = imageName(base)
+		}
+	}
+}
+
+// isLayersPrefix returns whether prefix is a strict prefix of layers
+func isLayersPrefix(prefix, layers []string) bool {
+	if len(prefix) == 0 || len(prefix) >= len(layers) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != layers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// imageName returns the name of an image, its first tag if it has any
+func imageName(image *Image) string {
+	if len(image.RepoTags) > 0 {
+		tags := append([]string{}, image.RepoTags...)
+		sort.Strings(tags)
+		return tags[0]
+	}
+	return image.ID
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package containerimages
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBaseImages(t *testing.T) {
+	debian := &Image{ID: "sha256:1", Runtime: "docker", RepoTags: []string{"debian:buster"}, Layers: []string{"a"}}
+	python := &Image{ID: "sha256:2", Runtime: "docker", RepoTags: []string{"python:3.8"}, Layers: []string{"a", "b"}}
+	app := &Image{ID: "sha256:3", Runtime: "docker", Layers: []string{"a", "b", "c"}}
+	labelled := &Image{ID: "sha256:4", Runtime: "docker", Layers: []string{"a", "d"}, Labels: map[string]string{baseImageLabel: "docker.io/library/debian:buster-slim"}}
+	otherRuntime := &Image{ID: "sha256:5", Runtime: "containerd", Layers: []string{"a", "b", "c", "e"}}
+
+	setBaseImages([]*Image{debian, python, app, labelled, otherRuntime})
+
+	assert.Equal(t, "", debian.BaseImage)
+	assert.Equal(t, "debian:buster", python.BaseImage)
+	assert.Equal(t, "python:3.8", app.BaseImage)
+	assert.Equal(t, "docker.io/library/debian:buster-slim", labelled.BaseImage)
+	assert.Equal(t, "", otherRuntime.BaseImage)
+}
+
+func TestGetPayload(t *testing.T) {
+	origListers := listers
+	defer func() { listers = origListers }()
+
+	listers = map[string]imageLister{
+		"docker": func() ([]*Image, error) {
+			return []*Image{{ID: "sha256:1", Runtime: "docker", RepoTags: []string{"redis:6"}}}, nil
+		},
+		"containerd": func() ([]*Image, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+	}
+
+	payload := GetPayload("foo")
+	require.NotNil(t, payload)
+	assert.Equal(t, "foo", payload.Hostname)
+	require.Len(t, payload.Images, 1)
+	assert.Equal(t, "sha256:1", payload.Images[0].ID)
+
+	listers = map[string]imageLister{}
+	assert.Nil(t, GetPayload("foo"))
+}
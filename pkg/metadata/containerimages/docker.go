@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package containerimages
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+func listDockerImages() ([]*Image, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := du.Images(false)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*Image, 0, len(summaries))
+	for _, summary := range summaries {
+		image := &Image{
+			ID:          summary.ID,
+			Runtime:     "docker",
+			RepoTags:    summary.RepoTags,
+			RepoDigests: summary.RepoDigests,
+			Labels:      summary.Labels,
+			Size:        summary.Size,
+		}
+		inspect, err := du.ImageInspect(summary.ID)
+		if err != nil {
+			// the image may have been removed since it was listed
+			log.Debugf("Unable to get the layers of the image %s: %s", summary.ID, err)
+		} else {
+			image.Layers = inspect.RootFS.Layers
+			image.OS = inspect.Os
+			image.Architecture = inspect.Architecture
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func init() {
+	listers["docker"] = listDockerImages
+}
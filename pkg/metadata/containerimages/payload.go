@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package containerimages
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+)
+
+// Image contains the metadata of a container image present on the host
+type Image struct {
+	ID           string            `json:"id"`
+	Runtime      string            `json:"runtime"`
+	RepoTags     []string          `json:"repo_tags,omitempty"`
+	RepoDigests  []string          `json:"repo_digests,omitempty"`
+	Layers       []string          `json:"layers,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	BaseImage    string            `json:"base_image,omitempty"`
+	Size         int64             `json:"size,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+}
+
+// Payload handles the JSON unmarshalling of the container images metadata payload
+type Payload struct {
+	Hostname  string   `json:"hostname"`
+	Timestamp int64    `json:"timestamp"`
+	Images    []*Image `json:"container_images"`
+}
+
+// MarshalJSON serialization a Payload to JSON
+func (p *Payload) MarshalJSON() ([]byte, error) {
+	type PayloadAlias Payload
+	return json.Marshal((*PayloadAlias)(p))
+}
+
+// Marshal not implemented
+func (p *Payload) Marshal() ([]byte, error) {
+	return nil, fmt.Errorf("Container images Payload serialization is not implemented")
+}
+
+// SplitPayload breaks the payload into times number of pieces
+func (p *Payload) SplitPayload(times int) ([]marshaler.Marshaler, error) {
+	return nil, fmt.Errorf("Container images Payload splitting is not implemented")
+}
@@ -26,7 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	kubelet "github.com/DataDog/datadog-agent/pkg/util/hostname/kubelet"
 
-	"github.com/DataDog/datadog-agent/pkg/logs"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
 
 	"io/ioutil"
 
@@ -234,7 +234,7 @@ func getContainerMeta(timeout time.Duration) map[string]string {
 }
 
 func getLogsMeta() *LogsMeta {
-	return &LogsMeta{Transport: string(logs.CurrentTransport)}
+	return &LogsMeta{Transport: string(logsStatus.CurrentTransport)}
 }
 
 func buildKey(key string) string {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/azure"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
@@ -53,6 +54,15 @@ func getHostTags() *tags {
 	hostTags := make([]string, 0, len(rawHostTags))
 	hostTags = appendToHostTags(hostTags, rawHostTags)
 
+	if tagsFile := config.Datadog.GetString("tags_file"); tagsFile != "" {
+		fileTags, err := getTagsFromFile(tagsFile)
+		if err != nil {
+			log.Warnf("Could not read the host tags file %s: %s", tagsFile, err)
+		} else {
+			hostTags = appendToHostTags(hostTags, fileTags)
+		}
+	}
+
 	env := config.Datadog.GetString("env")
 	if env != "" {
 		hostTags = appendToHostTags(hostTags, []string{"env:" + env})
@@ -67,6 +77,15 @@ func getHostTags() *tags {
 		}
 	}
 
+	if config.Datadog.GetBool("collect_azure_tags") {
+		azureTags, err := azure.GetTags()
+		if err != nil {
+			log.Debugf("No Azure host tags %v", err)
+		} else {
+			hostTags = appendToHostTags(hostTags, azureTags)
+		}
+	}
+
 	clusterName := clustername.GetClusterName()
 	if len(clusterName) != 0 {
 		clusterNameTags := []string{"kube_cluster_name:" + clusterName}
@@ -100,6 +119,14 @@ func getHostTags() *tags {
 			gceTags = appendToHostTags(gceTags, rawGceTags)
 		}
 	}
+	if config.Datadog.GetBool("collect_gce_labels") {
+		gceLabels, err := gce.GetLabels()
+		if err != nil {
+			log.Debugf("No GCE labels %v", err)
+		} else {
+			gceTags = appendToHostTags(gceTags, gceLabels)
+		}
+	}
 
 	return &tags{
 		System:              hostTags,
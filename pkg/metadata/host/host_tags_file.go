@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package host
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var tagsFileExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+type schedulerInterface interface {
+	TriggerAndResetCollectorTimer(name string, delay time.Duration)
+}
+
+// getTagsFromFile returns the host tags defined in the file at path, or in the
+// YAML and JSON files of the directory at path, in lexical order. A file holds
+// either a list of tags, e.g. `[role:database, team:storage]`, or a map of tag
+// names to a value or a list of values, e.g. `{role: database, team: [storage, infra]}`.
+func getTagsFromFile(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && tagsFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	var tags []string
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileTags, err := parseTagsFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse tags file %s: %s", file, err)
+		}
+		tags = append(tags, fileTags...)
+	}
+	return tags, nil
+}
+
+func parseTagsFile(content []byte) ([]string, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	switch v := raw.(type) {
+	case nil:
+	case []interface{}:
+		for _, tag := range v {
+			tags = append(tags, fmt.Sprint(tag))
+		}
+	case map[interface{}]interface{}:
+		names := make([]string, 0, len(v))
+		values := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			names = append(names, fmt.Sprint(name))
+			values[fmt.Sprint(name)] = value
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch value := values[name].(type) {
+			case nil:
+				tags = append(tags, name)
+			case []interface{}:
+				for _, elt := range value {
+					tags = append(tags, fmt.Sprintf("%s:%v", name, elt))
+				}
+			default:
+				tags = append(tags, fmt.Sprintf("%s:%v", name, value))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected a list or a map of tags")
+	}
+	return tags, nil
+}
+
+// StartTagsFileWatcher checks the tags file at the given interval and sends the
+// host metadata payload as soon as the host tags it defines change.
+func StartTagsFileWatcher(sc schedulerInterface, path string, interval time.Duration) {
+	go func() {
+		lastTags, _ := getTagsFromFile(path)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			tags, err := getTagsFromFile(path)
+			if err != nil {
+				// keep the last tags, the file may be in the middle of an update
+				log.Debugf("Could not read the host tags file %s: %s", path, err)
+				continue
+			}
+			if reflect.DeepEqual(tags, lastTags) {
+				continue
+			}
+			log.Infof("Host tags from %s changed, sending the host metadata", path)
+			lastTags = tags
+			sc.TriggerAndResetCollectorTimer("host", 0)
+		}
+	}()
+}
@@ -1,10 +1,15 @@
 package host
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetHostTags(t *testing.T) {
@@ -57,3 +62,81 @@ func TestGetHostTagsWithEnv(t *testing.T) {
 	assert.NotNil(t, hostTags.System)
 	assert.Equal(t, []string{"tag1:value1", "tag2", "tag3", "env:prod", "env:preprod"}, hostTags.System)
 }
+
+func writeTagsFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestGetTagsFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags.d")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTagsFiles(t, dir, map[string]string{
+		"a.yaml":    "- role:database\n- standalone\n",
+		"b.json":    `{"team": ["storage", "infra"], "tier": 1}`,
+		"c.yml":     "",
+		"README.md": "- ignored:tag\n",
+	})
+
+	tags, err := getTagsFromFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role:database", "standalone", "team:storage", "team:infra", "tier:1"}, tags)
+
+	tags, err = getTagsFromFile(filepath.Join(dir, "a.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role:database", "standalone"}, tags)
+
+	writeTagsFiles(t, dir, map[string]string{"d.yaml": "invalid"})
+	_, err = getTagsFromFile(dir)
+	assert.Error(t, err)
+}
+
+func TestGetHostTagsWithTagsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags.d")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTagsFiles(t, dir, map[string]string{"tags.yaml": "role: database\n"})
+
+	mockConfig := config.Mock()
+	mockConfig.Set("tags", []string{"tag1:value1"})
+	mockConfig.Set("tags_file", dir)
+	defer mockConfig.Set("tags", nil)
+	defer mockConfig.Set("tags_file", "")
+
+	hostTags := getHostTags()
+	assert.Equal(t, []string{"tag1:value1", "role:database"}, hostTags.System)
+}
+
+type triggerScheduler chan string
+
+func (s triggerScheduler) TriggerAndResetCollectorTimer(name string, delay time.Duration) {
+	s <- name
+}
+
+func TestStartTagsFileWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags.d")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTagsFiles(t, dir, map[string]string{"tags.yaml": "role: database\n"})
+
+	sc := make(triggerScheduler, 1)
+	StartTagsFileWatcher(sc, dir, 10*time.Millisecond)
+
+	// Unchanged tags don't trigger the collector
+	select {
+	case <-sc:
+		assert.FailNow(t, "host metadata sent while the tags are unchanged")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeTagsFiles(t, dir, map[string]string{"tags.yaml": "role: cache\n"})
+	select {
+	case name := <-sc:
+		assert.Equal(t, "host", name)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "host metadata not sent after the tags changed")
+	}
+}
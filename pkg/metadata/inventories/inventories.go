@@ -59,6 +59,12 @@ const (
 	CloudProviderMetatadaName = "cloud_provider"
 )
 
+// FeatureMetadataName returns the field name to use to set whether a feature
+// of the agent is enabled in the agent metadata.
+func FeatureMetadataName(feature string) string {
+	return "feature_" + feature + "_enabled"
+}
+
 // SetAgentMetadata updates the agent metadata value in the cache
 func SetAgentMetadata(name string, value interface{}) {
 	agentCacheMutex.Lock()
@@ -72,6 +72,10 @@ func SetupInventories(sc *Scheduler, ac inventories.AutoConfigInterface, coll in
 	}
 	RegisterCollector("inventories", ic)
 
+	for name, enabled := range config.GetFeatures() {
+		inventories.SetAgentMetadata(inventories.FeatureMetadataName(name), enabled)
+	}
+
 	if err := sc.AddCollector("inventories", config.Datadog.GetDuration("inventories_max_interval")*time.Second); err != nil {
 		return err
 	}
@@ -44,6 +44,10 @@ func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSamp
 			m[contextKey] = NewSet()
 		case CounterType:
 			m[contextKey] = NewCounter(interval)
+		case IntervalCountType:
+			m[contextKey] = NewIntervalMetric(APICountType)
+		case IntervalRateType:
+			m[contextKey] = NewIntervalMetric(APIRateType)
 		default:
 			err := fmt.Errorf("unknown sample metric type: %v", sample.Mtype)
 			log.Error(err)
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// MaxLogSnippetSize is the size in bytes above which the log snippet of a
+// service check is truncated
+const MaxLogSnippetSize = 4096
+
+// Correlation links a failing service check to the event and the log lines a
+// check emitted about the same failure
+type Correlation struct {
+	// ID is shared by the service check and the CorrelationID of the event
+	ID string
+	// LogSnippet holds the log lines related to the failure
+	LogSnippet string
+}
+
+// NewCorrelationID returns a random ID to correlate a service check and an event
+func NewCorrelationID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read only fails if the system has no source of randomness
+	rand.Read(b) //nolint:errcheck
+	return hex.EncodeToString(b)
+}
+
+// SetCorrelation attaches the correlation to the service check, its log
+// snippet truncated to MaxLogSnippetSize on a UTF-8 character boundary
+func (sc *ServiceCheck) SetCorrelation(c Correlation) {
+	sc.CorrelationID = c.ID
+	snippet := c.LogSnippet
+	if len(snippet) > MaxLogSnippetSize {
+		end := MaxLogSnippetSize
+		for end > 0 && !utf8.RuneStart(snippet[end]) {
+			end--
+		}
+		snippet = snippet[:end]
+	}
+	sc.LogSnippet = snippet
+}
+
+// correlationTags returns the tags with the correlation ID as a tag, for the
+// protobuf payloads which have no correlation field
+func correlationTags(tags []string, correlationID string) []string {
+	if correlationID == "" {
+		return tags
+	}
+	withID := make([]string, 0, len(tags)+1)
+	withID = append(withID, tags...)
+	return append(withID, "correlation_id:"+correlationID)
+}
@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCorrelationID(t *testing.T) {
+	id := NewCorrelationID()
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, id, NewCorrelationID())
+}
+
+func TestSetCorrelation(t *testing.T) {
+	sc := &ServiceCheck{}
+	sc.SetCorrelation(Correlation{ID: "abc", LogSnippet: "connection refused"})
+	assert.Equal(t, "abc", sc.CorrelationID)
+	assert.Equal(t, "connection refused", sc.LogSnippet)
+
+	// the snippet is truncated without splitting the 2 bytes of "é"
+	sc.SetCorrelation(Correlation{ID: "abc", LogSnippet: strings.Repeat("a", MaxLogSnippetSize-1) + "é"})
+	assert.Equal(t, strings.Repeat("a", MaxLogSnippetSize-1), sc.LogSnippet)
+}
@@ -92,6 +92,7 @@ type Event struct {
 	AggregationKey string         `json:"aggregation_key,omitempty"`
 	SourceTypeName string         `json:"source_type_name,omitempty"`
 	EventType      string         `json:"event_type,omitempty"`
+	CorrelationID  string         `json:"correlation_id,omitempty"`
 }
 
 // Return a JSON string or "" in case of error during the Marshaling
@@ -121,7 +122,7 @@ func (events Events) Marshal() ([]byte, error) {
 				Ts:             e.Ts,
 				Priority:       string(e.Priority),
 				Host:           e.Host,
-				Tags:           e.Tags,
+				Tags:           correlationTags(e.Tags, e.CorrelationID),
 				AlertType:      string(e.AlertType),
 				AggregationKey: e.AggregationKey,
 				SourceTypeName: e.SourceTypeName,
@@ -290,6 +291,7 @@ func writeEvent(event *Event, writer *utiljson.RawObjectWriter) error {
 	writer.AddStringField("aggregation_key", event.AggregationKey, utiljson.OmitEmpty)
 	writer.AddStringField("source_type_name", event.SourceTypeName, utiljson.OmitEmpty)
 	writer.AddStringField("event_type", event.EventType, utiljson.OmitEmpty)
+	writer.AddStringField("correlation_id", event.CorrelationID, utiljson.OmitEmpty)
 	if err := writer.FinishObject(); err != nil {
 		return err
 	}
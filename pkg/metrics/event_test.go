@@ -105,6 +105,25 @@ func TestMarshalJSONOmittedFields(t *testing.T) {
 	assert.Equal(t, payload, []byte("{\"apiKey\":\"\",\"events\":{\"api\":[{\"msg_title\":\"An event occurred\",\"msg_text\":\"event description\",\"timestamp\":12345,\"host\":\"my-hostname\"}]},\"internalHostname\":\"test-hostname\"}\n"))
 }
 
+func TestMarshalJSONWithCorrelation(t *testing.T) {
+	events := Events{{
+		Title:         "An event occurred",
+		Text:          "event description",
+		Ts:            12345,
+		Host:          "my-hostname",
+		CorrelationID: "abc",
+	}}
+
+	mockConfig := config.Mock()
+	oldName := mockConfig.GetString("hostname")
+	defer mockConfig.Set("hostname", oldName)
+	mockConfig.Set("hostname", "test-hostname")
+
+	payload, err := events.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"apiKey\":\"\",\"events\":{\"api\":[{\"msg_title\":\"An event occurred\",\"msg_text\":\"event description\",\"timestamp\":12345,\"host\":\"my-hostname\",\"correlation_id\":\"abc\"}]},\"internalHostname\":\"test-hostname\"}\n", string(payload))
+}
+
 func TestSplitEvents(t *testing.T) {
 	var events = Events{}
 	for i := 0; i < 2; i++ {
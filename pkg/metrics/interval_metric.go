@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import "sort"
+
+// IntervalMetric keeps the values a check already aggregated over their own
+// interval, like the counters of a cloud provider API. Each value is flushed
+// as a point with its timestamp, in a serie with its interval, instead of
+// being aggregated again over the check run.
+type IntervalMetric struct {
+	mType     APIMetricType
+	intervals []int64
+	points    map[int64][]Point
+}
+
+// NewIntervalMetric returns a new IntervalMetric flushed with the given type
+func NewIntervalMetric(mType APIMetricType) *IntervalMetric {
+	return &IntervalMetric{mType: mType, points: make(map[int64][]Point)}
+}
+
+func (m *IntervalMetric) addSample(sample *MetricSample, timestamp float64) {
+	if _, found := m.points[sample.Interval]; !found {
+		m.intervals = append(m.intervals, sample.Interval)
+	}
+	m.points[sample.Interval] = append(m.points[sample.Interval], Point{Ts: timestamp, Value: sample.Value})
+}
+
+func (m *IntervalMetric) flush(timestamp float64) ([]*Serie, error) {
+	intervals, points := m.intervals, m.points
+	m.intervals, m.points = nil, make(map[int64][]Point)
+
+	if len(intervals) == 0 {
+		return []*Serie{}, NoSerieError{}
+	}
+
+	// the intake only supports an interval per serie
+	series := make([]*Serie, 0, len(intervals))
+	for _, interval := range intervals {
+		intervalPoints := points[interval]
+		sort.SliceStable(intervalPoints, func(i, j int) bool { return intervalPoints[i].Ts < intervalPoints[j].Ts })
+		series = append(series, &Serie{
+			Points:   intervalPoints,
+			MType:    m.mType,
+			Interval: interval,
+		})
+	}
+	return series, nil
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalMetricFlush(t *testing.T) {
+	m := NewIntervalMetric(APICountType)
+
+	// nothing submitted
+	_, err := m.flush(50)
+	assert.Equal(t, NoSerieError{}, err)
+
+	m.addSample(&MetricSample{Value: 3, Interval: 300}, 1200)
+	m.addSample(&MetricSample{Value: 1, Interval: 60}, 600)
+	m.addSample(&MetricSample{Value: 5, Interval: 300}, 900)
+
+	series, err := m.flush(1500)
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	// one serie per interval, in the order they were first submitted, and the
+	// values are neither summed nor moved to the flush timestamp
+	assert.Equal(t, APICountType, series[0].MType)
+	assert.Equal(t, int64(300), series[0].Interval)
+	assert.Equal(t, []Point{{Ts: 900, Value: 5}, {Ts: 1200, Value: 3}}, series[0].Points)
+	assert.Equal(t, APICountType, series[1].MType)
+	assert.Equal(t, int64(60), series[1].Interval)
+	assert.Equal(t, []Point{{Ts: 600, Value: 1}}, series[1].Points)
+
+	_, err = m.flush(1600)
+	assert.Equal(t, NoSerieError{}, err)
+}
@@ -20,6 +20,8 @@ const (
 	SetType
 	// NOTE: DistributionType is in development and is NOT supported
 	DistributionType
+	IntervalCountType
+	IntervalRateType
 )
 
 // DistributionMetricTypes contains the MetricTypes that are used for percentiles
@@ -48,6 +50,10 @@ func (m MetricType) String() string {
 		return "Set"
 	case DistributionType:
 		return "Distribution"
+	case IntervalCountType:
+		return "IntervalCount"
+	case IntervalRateType:
+		return "IntervalRate"
 	default:
 		return ""
 	}
@@ -70,6 +76,11 @@ type MetricSample struct {
 	Host       string
 	SampleRate float64
 	Timestamp  float64
+	// OriginID is the tagger entity that emitted the sample, if known
+	OriginID string
+	// Interval is the interval in seconds a pre-aggregated sample covers,
+	// 0 for the other samples
+	Interval int64
 }
 
 // Implement the MetricSampleContext interface
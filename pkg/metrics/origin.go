@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"strings"
+)
+
+// OriginProduct is the kind of producer of a series
+type OriginProduct string
+
+// Producers of series
+const (
+	OriginProductAgent     OriginProduct = "agent"
+	OriginProductCheck     OriginProduct = "check"
+	OriginProductDogStatsD OriginProduct = "dogstatsd"
+	OriginProductJMX       OriginProduct = "jmx"
+	OriginProductOTLP      OriginProduct = "otlp"
+)
+
+// jmxDomainTagPrefix prefixes a tag JMXFetch adds to all its metrics, which
+// it sends through DogStatsD
+const jmxDomainTagPrefix = "jmx_domain:"
+
+// Origin identifies the producer of a series, so that the series can be
+// attributed to it. The series of a producer share the same Origin.
+type Origin struct {
+	Product OriginProduct `json:"product"`
+	// Service identifies the producer within the product, e.g. the check name
+	Service string `json:"service,omitempty"`
+}
+
+var (
+	// AgentOrigin is the origin of the series added by the agent itself
+	AgentOrigin = &Origin{Product: OriginProductAgent}
+	// DogStatsDOrigin is the origin of the series received by DogStatsD
+	DogStatsDOrigin = &Origin{Product: OriginProductDogStatsD}
+	// JMXOrigin is the origin of the series sent by JMXFetch to DogStatsD
+	JMXOrigin = &Origin{Product: OriginProductJMX}
+)
+
+// CheckOrigin returns the origin of the series of a check
+func CheckOrigin(checkName string) *Origin {
+	return &Origin{Product: OriginProductCheck, Service: checkName}
+}
+
+// DogStatsDOriginFromTags returns the origin of a series received by
+// DogStatsD, JMXFetch being identified by its jmx_domain tag
+func DogStatsDOriginFromTags(tags []string) *Origin {
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, jmxDomainTagPrefix) {
+			return JMXOrigin
+		}
+	}
+	return DogStatsDOrigin
+}
@@ -0,0 +1,22 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDogStatsDOriginFromTags(t *testing.T) {
+	assert.Equal(t, DogStatsDOrigin, DogStatsDOriginFromTags(nil))
+	assert.Equal(t, DogStatsDOrigin, DogStatsDOriginFromTags([]string{"env:prod", "jmx:domain"}))
+	assert.Equal(t, JMXOrigin, DogStatsDOriginFromTags([]string{"instance:tomcat", "jmx_domain:Catalina"}))
+}
+
+func TestCheckOrigin(t *testing.T) {
+	assert.Equal(t, &Origin{Product: OriginProductCheck, Service: "cpu"}, CheckOrigin("cpu"))
+}
@@ -52,6 +52,7 @@ type Serie struct {
 	MType          APIMetricType   `json:"type"`
 	Interval       int64           `json:"interval"`
 	SourceTypeName string          `json:"source_type_name,omitempty"`
+	Origin         *Origin         `json:"origin,omitempty"`
 	ContextKey     ckey.ContextKey `json:"-"`
 	NameSuffix     string          `json:"-"`
 }
@@ -317,6 +318,20 @@ func encodeSerie(serie *Serie, stream *jsoniter.Stream) {
 		stream.WriteString(serie.SourceTypeName)
 	}
 
+	if serie.Origin != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("origin")
+		stream.WriteObjectStart()
+		stream.WriteObjectField("product")
+		stream.WriteString(string(serie.Origin.Product))
+		if serie.Origin.Service != "" {
+			stream.WriteMore()
+			stream.WriteObjectField("service")
+			stream.WriteString(serie.Origin.Service)
+		}
+		stream.WriteObjectEnd()
+	}
+
 	stream.WriteObjectEnd()
 }
 
@@ -309,11 +309,20 @@ func TestStreamJSONMarshaler(t *testing.T) {
 			Host:     "localHost",
 			Tags:     []string{},
 		},
+		{
+			Points:   []Point{},
+			MType:    APIGaugeType,
+			Name:     "test.metrics",
+			Interval: 15,
+			Host:     "localHost",
+			Tags:     []string{},
+			Origin:   CheckOrigin("cpu"),
+		},
 	}
 
 	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 0)
 
-	assert.Equal(t, 3, series.Len())
+	assert.Equal(t, 4, series.Len())
 
 	series.WriteHeader(stream)
 	assert.Equal(t, []byte(`{"series":[`), stream.Buffer())
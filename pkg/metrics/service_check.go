@@ -73,12 +73,14 @@ func (s ServiceCheckStatus) String() string {
 
 // ServiceCheck holds a service check (w/ serialization to DD api format)
 type ServiceCheck struct {
-	CheckName string             `json:"check"`
-	Host      string             `json:"host_name"`
-	Ts        int64              `json:"timestamp"`
-	Status    ServiceCheckStatus `json:"status"`
-	Message   string             `json:"message"`
-	Tags      []string           `json:"tags"`
+	CheckName     string             `json:"check"`
+	Host          string             `json:"host_name"`
+	Ts            int64              `json:"timestamp"`
+	Status        ServiceCheckStatus `json:"status"`
+	Message       string             `json:"message"`
+	Tags          []string           `json:"tags"`
+	CorrelationID string             `json:"correlation_id,omitempty"`
+	LogSnippet    string             `json:"log_snippet,omitempty"`
 }
 
 // ServiceChecks represents a list of service checks ready to be serialize
@@ -99,7 +101,7 @@ func (sc ServiceChecks) Marshal() ([]byte, error) {
 				Ts:      c.Ts,
 				Status:  int32(c.Status),
 				Message: c.Message,
-				Tags:    c.Tags,
+				Tags:    correlationTags(c.Tags, c.CorrelationID),
 			})
 	}
 
@@ -204,6 +206,8 @@ func writeServiceCheck(sc *ServiceCheck, stream *jsoniter.Stream) error {
 	writer.AddInt64Field("timestamp", sc.Ts)
 	writer.AddInt64Field("status", int64(sc.Status))
 	writer.AddStringField("message", sc.Message, utiljson.AllowEmpty)
+	writer.AddStringField("correlation_id", sc.CorrelationID, utiljson.OmitEmpty)
+	writer.AddStringField("log_snippet", sc.LogSnippet, utiljson.OmitEmpty)
 
 	tagsField := "tags"
 
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -67,6 +68,39 @@ func TestMarshalJSONServiceChecks(t *testing.T) {
 	assert.Equal(t, payload, []byte("[{\"check\":\"my_service.can_connect\",\"host_name\":\"my-hostname\",\"timestamp\":12345,\"status\":0,\"message\":\"my_service is up\",\"tags\":[\"tag1\",\"tag2:yes\"]}]\n"))
 }
 
+func TestMarshalServiceChecksWithCorrelation(t *testing.T) {
+	serviceChecks := ServiceChecks{{
+		CheckName:     "my_service.can_connect",
+		Host:          "my-hostname",
+		Ts:            int64(12345),
+		Status:        ServiceCheckCritical,
+		Message:       "my_service is down",
+		Tags:          []string{"tag1"},
+		CorrelationID: "abc",
+		LogSnippet:    "connection refused",
+	}}
+	expected := `[{"check":"my_service.can_connect","host_name":"my-hostname","timestamp":12345,"status":2,"message":"my_service is down","tags":["tag1"],"correlation_id":"abc","log_snippet":"connection refused"}]`
+
+	payload, err := serviceChecks.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, expected+"\n", string(payload))
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 0)
+	serviceChecks.WriteHeader(stream)
+	require.NoError(t, serviceChecks.WriteItem(stream, 0))
+	serviceChecks.WriteFooter(stream)
+	assert.JSONEq(t, expected, string(stream.Buffer()))
+
+	// the protobuf payload has no correlation field, the ID is sent as a tag
+	payload, err = serviceChecks.Marshal()
+	require.NoError(t, err)
+	newPayload := &agentpayload.ServiceChecksPayload{}
+	require.NoError(t, proto.Unmarshal(payload, newPayload))
+	require.Len(t, newPayload.ServiceChecks, 1)
+	assert.Equal(t, []string{"tag1", "correlation_id:abc"}, newPayload.ServiceChecks[0].Tags)
+	assert.Equal(t, []string{"tag1"}, serviceChecks[0].Tags)
+}
+
 func TestSplitServiceChecks(t *testing.T) {
 	var serviceChecks = ServiceChecks{}
 	for i := 0; i < 2; i++ {
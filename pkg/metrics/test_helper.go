@@ -67,6 +67,10 @@ func AssertSerieEqual(t *testing.T, expected, actual *Serie) {
 		// Only test the contextKey if it's set in the expected Serie
 		assert.Equal(t, expected.ContextKey, actual.ContextKey)
 	}
+	if expected.Origin != nil {
+		// Only test the origin if it's set in the expected Serie
+		assert.Equal(t, expected.Origin, actual.Origin)
+	}
 	assert.Equal(t, expected.NameSuffix, actual.NameSuffix)
 	AssertPointsEqual(t, expected.Points, actual.Points)
 }
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/snmp"
+)
+
+// deviceLookup returns the inventory device of an exporter
+type deviceLookup func(ip string) (snmp.Device, bool)
+
+// aggregator sums the flows of the same key until they are flushed
+type aggregator struct {
+	mu       sync.Mutex
+	flows    map[flowKey]*Flow
+	maxFlows int
+	lookup   deviceLookup
+}
+
+func newAggregator(maxFlows int, lookup deviceLookup) *aggregator {
+	return &aggregator{
+		flows:    make(map[flowKey]*Flow),
+		maxFlows: maxFlows,
+		lookup:   lookup,
+	}
+}
+
+// add aggregates the flows, it returns the number of new flows dropped
+// because the aggregator is full
+func (a *aggregator) add(flows []*Flow) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dropped := 0
+	for _, f := range flows {
+		key := f.key()
+		if existing, found := a.flows[key]; found {
+			existing.merge(f)
+			continue
+		}
+		if a.maxFlows > 0 && len(a.flows) >= a.maxFlows {
+			dropped++
+			continue
+		}
+		a.flows[key] = f
+	}
+	return dropped
+}
+
+// flush returns the aggregated flows tagged with the inventory devices of
+// their exporters, and resets the aggregator
+func (a *aggregator) flush() []*Flow {
+	a.mu.Lock()
+	flows := a.flows
+	a.flows = make(map[flowKey]*Flow, len(flows))
+	a.mu.Unlock()
+
+	flushed := make([]*Flow, 0, len(flows))
+	for _, f := range flows {
+		if device, found := a.lookup(f.ExporterAddr); found {
+			f.Tags = device.Tags()
+		}
+		flushed = append(flushed, f)
+	}
+	return flushed
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/snmp"
+)
+
+func TestAggregator(t *testing.T) {
+	lookup := func(ip string) (snmp.Device, bool) {
+		if ip == "10.0.0.1" {
+			return snmp.Device{IP: ip, Network: "10.0.0.0/24", ADIdentifier: "snmp"}, true
+		}
+		return snmp.Device{}, false
+	}
+	a := newAggregator(2, lookup)
+
+	newFlow := func(exporter string, srcPort uint16, start int64) *Flow {
+		return &Flow{
+			FlowType:       FlowTypeNetFlow5,
+			ExporterAddr:   exporter,
+			SrcAddr:        "192.168.1.1",
+			DstAddr:        "192.168.1.2",
+			SrcPort:        srcPort,
+			DstPort:        443,
+			IPProtocol:     6,
+			TCPFlags:       0x02,
+			StartTimestamp: start,
+			EndTimestamp:   start + 10,
+			Bytes:          100,
+			Packets:        1,
+		}
+	}
+
+	assert.Equal(t, 0, a.add([]*Flow{newFlow("10.0.0.1", 1000, 100), newFlow("10.0.0.2", 1000, 100)}))
+	merged := newFlow("10.0.0.1", 1000, 90)
+	merged.TCPFlags = 0x10
+	assert.Equal(t, 0, a.add([]*Flow{merged}))
+	// a third key is dropped, the aggregator is full
+	assert.Equal(t, 1, a.add([]*Flow{newFlow("10.0.0.1", 2000, 100)}))
+
+	flows := a.flush()
+	require.Len(t, flows, 2)
+	for _, f := range flows {
+		if f.ExporterAddr != "10.0.0.1" {
+			assert.Empty(t, f.Tags)
+			continue
+		}
+		assert.Equal(t, uint64(200), f.Bytes)
+		assert.Equal(t, uint64(2), f.Packets)
+		assert.Equal(t, uint8(0x12), f.TCPFlags)
+		assert.Equal(t, int64(90), f.StartTimestamp)
+		assert.Equal(t, int64(110), f.EndTimestamp)
+		assert.Equal(t, []string{"snmp_device:10.0.0.1", "autodiscovery_subnet:10.0.0.0/24", "ad_identifier:snmp"}, f.Tags)
+	}
+
+	assert.Len(t, a.flush(), 0)
+}
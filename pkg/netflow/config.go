@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// The flow types, one per decoder
+const (
+	FlowTypeNetFlow5 = "netflow5"
+	FlowTypeNetFlow9 = "netflow9"
+	FlowTypeIPFIX    = "ipfix"
+	FlowTypeSFlow5   = "sflow5"
+)
+
+// defaultPorts are the ports assigned to the flow types
+var defaultPorts = map[string]uint16{
+	FlowTypeNetFlow5: 2055,
+	FlowTypeNetFlow9: 2055,
+	FlowTypeIPFIX:    4739,
+	FlowTypeSFlow5:   6343,
+}
+
+// ListenerConfig is a UDP listener of a flow type
+type ListenerConfig struct {
+	FlowType string `mapstructure:"flow_type"`
+	BindHost string `mapstructure:"bind_host"`
+	Port     uint16 `mapstructure:"port"`
+}
+
+// Addr returns the address the listener binds to
+func (c ListenerConfig) Addr() string {
+	return net.JoinHostPort(c.BindHost, fmt.Sprintf("%d", c.Port))
+}
+
+// Config holds the configuration of the flow collector
+type Config struct {
+	Listeners     []ListenerConfig
+	FlushInterval time.Duration
+	MaxFlows      int
+}
+
+// ReadConfig reads and validates the netflow configuration
+func ReadConfig() (*Config, error) {
+	c := Config{
+		FlushInterval: time.Duration(config.Datadog.GetInt("netflow.aggregator_flush_interval")) * time.Second,
+		MaxFlows:      config.Datadog.GetInt("netflow.aggregator_max_flows"),
+	}
+	if err := config.Datadog.UnmarshalKey("netflow.listeners", &c.Listeners); err != nil {
+		return nil, fmt.Errorf("unable to parse the netflow listeners: %v", err)
+	}
+	if len(c.Listeners) == 0 {
+		return nil, fmt.Errorf("netflow needs at least one listener")
+	}
+	if c.FlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid netflow.aggregator_flush_interval: %v", c.FlushInterval)
+	}
+
+	// Set the default values, we can't otherwise on an array
+	ports := make(map[string]string)
+	for i := range c.Listeners {
+		l := &c.Listeners[i]
+		defaultPort, ok := defaultPorts[l.FlowType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported netflow flow_type %q", l.FlowType)
+		}
+		if l.Port == 0 {
+			l.Port = defaultPort
+		}
+		if l.BindHost == "" {
+			l.BindHost = "0.0.0.0"
+		}
+		if other, found := ports[l.Addr()]; found {
+			return nil, fmt.Errorf("the netflow listeners %s and %s both listen on %s", other, l.FlowType, l.Addr())
+		}
+		ports[l.Addr()] = l.FlowType
+	}
+	return &c, nil
+}
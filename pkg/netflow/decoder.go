@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+var errTruncated = errors.New("truncated datagram")
+
+// decoder decodes the flows of the datagrams sent by an exporter
+type decoder interface {
+	decode(payload []byte, exporter net.IP, now time.Time) ([]*Flow, error)
+}
+
+func newDecoder(flowType string) (decoder, error) {
+	switch flowType {
+	case FlowTypeNetFlow5:
+		return &netflow5Decoder{}, nil
+	case FlowTypeNetFlow9:
+		return newTemplateDecoder(FlowTypeNetFlow9), nil
+	case FlowTypeIPFIX:
+		return newTemplateDecoder(FlowTypeIPFIX), nil
+	case FlowTypeSFlow5:
+		return &sflow5Decoder{}, nil
+	}
+	return nil, fmt.Errorf("unsupported flow type %q", flowType)
+}
+
+// reader reads big endian fields, the first out of bounds read sets err and
+// the following reads return zero values
+type reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.off+n > len(r.b) {
+		r.err = errTruncated
+		return nil
+	}
+	v := r.b[r.off : r.off+n]
+	r.off += n
+	return v
+}
+
+func (r *reader) uint8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) remaining() int {
+	return len(r.b) - r.off
+}
+
+// readUint reads an unsigned integer of up to 8 bytes
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
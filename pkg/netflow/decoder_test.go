@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exporter = net.ParseIP("10.0.0.1")
+
+// datagram builds a datagram of big endian fields
+func datagram(fields ...interface{}) []byte {
+	var buf bytes.Buffer
+	for _, f := range fields {
+		if ip, ok := f.(net.IP); ok {
+			f = []byte(ip.To4())
+		}
+		binary.Write(&buf, binary.BigEndian, f)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeNetFlow5(t *testing.T) {
+	payload := datagram(
+		// header: version, count, uptime, unix secs, unix nsecs, sequence, engine type and id, sampling
+		uint16(5), uint16(1), uint32(60000), uint32(1600000000), uint32(0), uint32(1), uint8(0), uint8(0), uint16(0x4000|100),
+		// record
+		net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2"), net.ParseIP("0.0.0.0"),
+		uint16(1), uint16(2), uint32(10), uint32(1500), uint32(50000), uint32(59000),
+		uint16(34567), uint16(443), uint8(0), uint8(0x18), uint8(6), uint8(0),
+		uint16(0), uint16(0), uint8(24), uint8(24), uint16(0),
+	)
+	d, err := newDecoder(FlowTypeNetFlow5)
+	require.NoError(t, err)
+	flows, err := d.decode(payload, exporter, time.Now())
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	assert.Equal(t, &Flow{
+		FlowType:        FlowTypeNetFlow5,
+		ExporterAddr:    "10.0.0.1",
+		SamplingRate:    100,
+		StartTimestamp:  1599999990,
+		EndTimestamp:    1599999999,
+		Bytes:           1500,
+		Packets:         10,
+		SrcAddr:         "192.168.1.1",
+		DstAddr:         "192.168.1.2",
+		SrcPort:         34567,
+		DstPort:         443,
+		IPProtocol:      6,
+		TCPFlags:        0x18,
+		InputInterface:  1,
+		OutputInterface: 2,
+	}, flows[0])
+
+	_, err = d.decode(payload[:len(payload)-1], exporter, time.Now())
+	assert.Error(t, err)
+}
+
+func TestDecodeNetFlow9(t *testing.T) {
+	header := datagram(uint16(9), uint16(2), uint32(60000), uint32(1600000000), uint32(1), uint32(7))
+	template := datagram(
+		uint16(0), uint16(4+4+6*4),
+		uint16(256), uint16(6),
+		uint16(fieldSrcAddrV4), uint16(4), uint16(fieldDstAddrV4), uint16(4),
+		uint16(fieldBytes), uint16(4), uint16(fieldProtocol), uint16(1),
+		uint16(fieldFirstSwitched), uint16(4), uint16(fieldLastSwitched), uint16(4),
+	)
+	data := datagram(
+		uint16(256), uint16(4+2*21+2),
+		net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2"), uint32(100), uint8(17), uint32(58000), uint32(60000),
+		net.ParseIP("192.168.1.3"), net.ParseIP("192.168.1.4"), uint32(200), uint8(6), uint32(59000), uint32(60000),
+		uint16(0), // padding
+	)
+
+	d, err := newDecoder(FlowTypeNetFlow9)
+	require.NoError(t, err)
+
+	// the data is dropped until the template is received
+	flows, err := d.decode(append(append([]byte{}, header...), data...), exporter, time.Now())
+	assert.Error(t, err)
+	assert.Len(t, flows, 0)
+
+	flows, err = d.decode(append(append(append([]byte{}, header...), template...), data...), exporter, time.Now())
+	require.NoError(t, err)
+	require.Len(t, flows, 2)
+	assert.Equal(t, "192.168.1.1", flows[0].SrcAddr)
+	assert.Equal(t, uint64(100), flows[0].Bytes)
+	assert.Equal(t, uint8(17), flows[0].IPProtocol)
+	assert.Equal(t, int64(1599999998), flows[0].StartTimestamp)
+	assert.Equal(t, int64(1600000000), flows[0].EndTimestamp)
+	assert.Equal(t, "192.168.1.4", flows[1].DstAddr)
+
+	// the templates are per exporter
+	_, err = d.decode(append(append([]byte{}, header...), data...), net.ParseIP("10.0.0.2"), time.Now())
+	assert.Error(t, err)
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	template := datagram(
+		uint16(2), uint16(4+4+4*4+4),
+		uint16(300), uint16(4),
+		uint16(fieldSrcAddrV4), uint16(4), uint16(fieldDstAddrV4), uint16(4),
+		uint16(0x8000|1000), uint16(variableLength), uint32(9), // enterprise specific
+		uint16(fieldFlowStartSeconds), uint16(4),
+	)
+	data := datagram(
+		uint16(300), uint16(4+4+4+1+3+4),
+		net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2"), uint8(3), []byte("abc"), uint32(1599999000),
+	)
+	length := 16 + len(template) + len(data)
+	payload := append(datagram(uint16(10), uint16(length), uint32(1600000000), uint32(1), uint32(7)), template...)
+	payload = append(payload, data...)
+
+	d, err := newDecoder(FlowTypeIPFIX)
+	require.NoError(t, err)
+	flows, err := d.decode(payload, exporter, time.Now())
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	assert.Equal(t, FlowTypeIPFIX, flows[0].FlowType)
+	assert.Equal(t, "192.168.1.2", flows[0].DstAddr)
+	assert.Equal(t, int64(1599999000), flows[0].StartTimestamp)
+	assert.Equal(t, int64(1600000000), flows[0].EndTimestamp)
+
+	_, err = d.decode(datagram(uint16(9), uint16(0)), exporter, time.Now())
+	assert.Error(t, err)
+}
+
+func TestDecodeSFlow5(t *testing.T) {
+	frame := datagram(
+		// ethernet
+		[]byte{1, 2, 3, 4, 5, 6}, []byte{6, 5, 4, 3, 2, 1}, uint16(etherTypeIPv4),
+		// IPv4
+		uint8(0x45), uint8(0), uint16(60), uint32(0), uint8(64), uint8(6), uint16(0),
+		net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2"),
+		// TCP
+		uint16(34567), uint16(22), uint32(0), uint32(0), uint8(0x50), uint8(0x02),
+	)
+	record := datagram(uint32(sflowEthernetProtocol), uint32(74), uint32(4), uint32(len(frame)), frame)
+	sample := datagram(
+		uint32(1), uint32(3), uint32(512), uint32(0), uint32(0), uint32(4), uint32(5), uint32(1),
+		uint32(sflowRawPacketHeader), uint32(len(record)), record,
+	)
+	counters := datagram(uint32(0))
+	payload := datagram(
+		uint32(5), uint32(1), net.ParseIP("10.0.0.1"), uint32(0), uint32(1), uint32(1000), uint32(2),
+		uint32(2), uint32(len(counters)), counters,
+		uint32(sflowFlowSample), uint32(len(sample)), sample,
+	)
+
+	d, err := newDecoder(FlowTypeSFlow5)
+	require.NoError(t, err)
+	now := time.Unix(1600000000, 0)
+	flows, err := d.decode(payload, exporter, now)
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	assert.Equal(t, &Flow{
+		FlowType:        FlowTypeSFlow5,
+		ExporterAddr:    "10.0.0.1",
+		SamplingRate:    512,
+		StartTimestamp:  1600000000,
+		EndTimestamp:    1600000000,
+		Bytes:           74,
+		Packets:         1,
+		SrcAddr:         "192.168.1.1",
+		DstAddr:         "192.168.1.2",
+		SrcPort:         34567,
+		DstPort:         22,
+		IPProtocol:      6,
+		TCPFlags:        0x02,
+		InputInterface:  4,
+		OutputInterface: 5,
+	}, flows[0])
+}
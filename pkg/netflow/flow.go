@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"net"
+)
+
+// Flow is a flow record decoded from a NetFlow, IPFIX or sFlow datagram
+type Flow struct {
+	FlowType     string `json:"type"`
+	ExporterAddr string `json:"exporter_ip"`
+	SamplingRate uint64 `json:"sampling_rate"`
+
+	// the timestamps are in seconds since the epoch
+	StartTimestamp int64 `json:"start"`
+	EndTimestamp   int64 `json:"end"`
+
+	Bytes   uint64 `json:"bytes"`
+	Packets uint64 `json:"packets"`
+
+	SrcAddr         string `json:"src_ip"`
+	DstAddr         string `json:"dst_ip"`
+	SrcPort         uint16 `json:"src_port"`
+	DstPort         uint16 `json:"dst_port"`
+	IPProtocol      uint8  `json:"ip_protocol"`
+	Tos             uint8  `json:"tos"`
+	TCPFlags        uint8  `json:"tcp_flags"`
+	InputInterface  uint32 `json:"input_interface"`
+	OutputInterface uint32 `json:"output_interface"`
+
+	Tags []string `json:"tags,omitempty"`
+}
+
+// flowKey identifies the flows aggregated together
+type flowKey struct {
+	flowType        string
+	exporterAddr    string
+	srcAddr         string
+	dstAddr         string
+	srcPort         uint16
+	dstPort         uint16
+	ipProtocol      uint8
+	tos             uint8
+	inputInterface  uint32
+	outputInterface uint32
+}
+
+func (f *Flow) key() flowKey {
+	return flowKey{
+		flowType:        f.FlowType,
+		exporterAddr:    f.ExporterAddr,
+		srcAddr:         f.SrcAddr,
+		dstAddr:         f.DstAddr,
+		srcPort:         f.SrcPort,
+		dstPort:         f.DstPort,
+		ipProtocol:      f.IPProtocol,
+		tos:             f.Tos,
+		inputInterface:  f.InputInterface,
+		outputInterface: f.OutputInterface,
+	}
+}
+
+// merge adds the counters of other to f, and extends its time range
+func (f *Flow) merge(other *Flow) {
+	f.Bytes += other.Bytes
+	f.Packets += other.Packets
+	f.TCPFlags |= other.TCPFlags
+	if other.StartTimestamp < f.StartTimestamp {
+		f.StartTimestamp = other.StartTimestamp
+	}
+	if other.EndTimestamp > f.EndTimestamp {
+		f.EndTimestamp = other.EndTimestamp
+	}
+}
+
+func ipString(b []byte) string {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return ""
+	}
+	return net.IP(b).String()
+}
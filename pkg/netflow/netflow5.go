@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const netflow5RecordLength = 48
+
+// netflow5Decoder decodes the fixed format NetFlow v5 datagrams
+type netflow5Decoder struct{}
+
+func (d *netflow5Decoder) decode(payload []byte, exporter net.IP, now time.Time) ([]*Flow, error) {
+	r := &reader{b: payload}
+	if version := r.uint16(); r.err == nil && version != 5 {
+		return nil, fmt.Errorf("unexpected NetFlow version %d", version)
+	}
+	count := int(r.uint16())
+	sysUptime := r.uint32()
+	unixSecs := r.uint32()
+	r.bytes(4 + 4 + 1 + 1) // unix_nsecs, flow_sequence, engine_type, engine_id
+	samplingRate := uint64(r.uint16() & 0x3fff)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if samplingRate == 0 {
+		samplingRate = 1
+	}
+	if r.remaining() < count*netflow5RecordLength {
+		return nil, errTruncated
+	}
+
+	// first and last are the system uptime in milliseconds when the first
+	// and last packets of the flow were switched
+	toTimestamp := func(uptime uint32) int64 {
+		return int64(unixSecs) - int64(sysUptime-uptime)/1000
+	}
+
+	flows := make([]*Flow, 0, count)
+	for i := 0; i < count; i++ {
+		f := &Flow{
+			FlowType:     FlowTypeNetFlow5,
+			ExporterAddr: exporter.String(),
+			SamplingRate: samplingRate,
+		}
+		f.SrcAddr = ipString(r.bytes(4))
+		f.DstAddr = ipString(r.bytes(4))
+		r.bytes(4) // nexthop
+		f.InputInterface = uint32(r.uint16())
+		f.OutputInterface = uint32(r.uint16())
+		f.Packets = uint64(r.uint32())
+		f.Bytes = uint64(r.uint32())
+		f.StartTimestamp = toTimestamp(r.uint32())
+		f.EndTimestamp = toTimestamp(r.uint32())
+		f.SrcPort = r.uint16()
+		f.DstPort = r.uint16()
+		r.uint8() // pad1
+		f.TCPFlags = r.uint8()
+		f.IPProtocol = r.uint8()
+		f.Tos = r.uint8()
+		r.bytes(2 + 2 + 1 + 1 + 2) // src_as, dst_as, src_mask, dst_mask, pad2
+		flows = append(flows, f)
+	}
+	return flows, r.err
+}
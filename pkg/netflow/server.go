@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"encoding/json"
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/snmp"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxDatagramSize is the largest UDP payload
+const maxDatagramSize = 65535
+
+var (
+	netflowExpvars = expvar.NewMap("netflow")
+	// datagramsReceived is the total number of received datagrams, per flow type
+	datagramsReceived = expvar.Map{}
+	// decodingErrors is the total number of datagrams that failed to be decoded, per flow type
+	decodingErrors = expvar.Map{}
+	// flowsDropped is the total number of flows dropped because the aggregator was full
+	flowsDropped = expvar.Int{}
+	// flowsSent is the total number of aggregated flows sent to the event platform
+	flowsSent = expvar.Int{}
+	// sendErrors is the total number of aggregated flows that failed to be sent
+	sendErrors = expvar.Int{}
+)
+
+func init() {
+	netflowExpvars.Set("DatagramsReceived", &datagramsReceived)
+	netflowExpvars.Set("DecodingErrors", &decodingErrors)
+	netflowExpvars.Set("FlowsDropped", &flowsDropped)
+	netflowExpvars.Set("FlowsSent", &flowsSent)
+	netflowExpvars.Set("SendErrors", &sendErrors)
+}
+
+// SendFunc forwards a JSON encoded flow
+type SendFunc func(rawFlow []byte) error
+
+// Server collects the flows of the configured listeners, and sends them
+// aggregated at every flush interval
+type Server struct {
+	config     *Config
+	aggregator *aggregator
+	send       SendFunc
+	conns      []*net.UDPConn
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewServer returns a flow collector sending the flows with send
+func NewServer(c *Config, send SendFunc) *Server {
+	return &Server{
+		config:     c,
+		aggregator: newAggregator(c.MaxFlows, snmp.GetDevice),
+		send:       send,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start starts the listeners, it fails if one of them cannot listen
+func (s *Server) Start() error {
+	for _, l := range s.config.Listeners {
+		dec, err := newDecoder(l.FlowType)
+		if err != nil {
+			s.closeConns()
+			return err
+		}
+		addr, err := net.ResolveUDPAddr("udp", l.Addr())
+		if err != nil {
+			s.closeConns()
+			return err
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			s.closeConns()
+			return err
+		}
+		s.conns = append(s.conns, conn)
+		log.Infof("Listening for %s flows on %s", l.FlowType, l.Addr())
+
+		s.wg.Add(1)
+		go s.listen(conn, l.FlowType, dec)
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+	return nil
+}
+
+// Stop closes the listeners and sends the flows aggregated so far
+func (s *Server) Stop() {
+	close(s.stop)
+	s.closeConns()
+	s.wg.Wait()
+}
+
+func (s *Server) closeConns() {
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) listen(conn *net.UDPConn, flowType string, dec decoder) {
+	defer s.wg.Done()
+	buffer := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			log.Warnf("Unable to read a %s datagram: %v", flowType, err)
+			continue
+		}
+		datagramsReceived.Add(flowType, 1)
+
+		flows, err := dec.decode(buffer[:n], addr.IP, time.Now())
+		if err != nil {
+			decodingErrors.Add(flowType, 1)
+			log.Debugf("Unable to decode a %s datagram from %s: %v", flowType, addr.IP, err)
+		}
+		if dropped := s.aggregator.add(flows); dropped > 0 {
+			flowsDropped.Add(int64(dropped))
+		}
+	}
+}
+
+func (s *Server) flushLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+func (s *Server) flush() {
+	for _, f := range s.aggregator.flush() {
+		raw, err := json.Marshal(f)
+		if err == nil {
+			err = s.send(raw)
+		}
+		if err != nil {
+			sendErrors.Add(1)
+			log.Debugf("Unable to send a flow from %s: %v", f.ExporterAddr, err)
+			continue
+		}
+		flowsSent.Add(1)
+	}
+}
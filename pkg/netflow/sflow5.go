@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// The sFlow v5 formats decoded into flows, the counter samples and other
+// flow records are skipped
+const (
+	sflowFlowSample         = 1
+	sflowExpandedFlowSample = 3
+	sflowRawPacketHeader    = 1
+	sflowEthernetProtocol   = 1
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+)
+
+// sflow5Decoder decodes the sampled packet headers of the sFlow v5 datagrams,
+// each sampled packet becomes a flow of one packet
+type sflow5Decoder struct{}
+
+func (d *sflow5Decoder) decode(payload []byte, exporter net.IP, now time.Time) ([]*Flow, error) {
+	r := &reader{b: payload}
+	if version := r.uint32(); r.err == nil && version != 5 {
+		return nil, fmt.Errorf("unexpected sFlow version %d", version)
+	}
+	switch addressType := r.uint32(); addressType {
+	case 1:
+		r.bytes(net.IPv4len)
+	case 2:
+		r.bytes(net.IPv6len)
+	default:
+		if r.err == nil {
+			return nil, fmt.Errorf("unexpected sFlow agent address type %d", addressType)
+		}
+	}
+	r.bytes(4 + 4 + 4) // sub agent id, sequence number, uptime
+	count := int(r.uint32())
+
+	var flows []*Flow
+	for i := 0; i < count && r.err == nil; i++ {
+		format := r.uint32() & 0xfff
+		sample := &reader{b: r.bytes(int(r.uint32()))}
+		if r.err != nil {
+			break
+		}
+		if format != sflowFlowSample && format != sflowExpandedFlowSample {
+			continue
+		}
+
+		f := &Flow{
+			FlowType:       FlowTypeSFlow5,
+			ExporterAddr:   exporter.String(),
+			StartTimestamp: now.Unix(),
+			EndTimestamp:   now.Unix(),
+			Packets:        1,
+		}
+		sample.uint32() // sequence number
+		if format == sflowExpandedFlowSample {
+			sample.bytes(4 + 4) // source id type and index
+		} else {
+			sample.uint32() // source id
+		}
+		f.SamplingRate = uint64(sample.uint32())
+		sample.bytes(4 + 4) // sample pool, drops
+		if format == sflowExpandedFlowSample {
+			sample.uint32() // input interface format
+			f.InputInterface = sample.uint32()
+			sample.uint32() // output interface format
+			f.OutputInterface = sample.uint32()
+		} else {
+			// the two high bits hold the format of the interface
+			f.InputInterface = sample.uint32() & 0x3fffffff
+			f.OutputInterface = sample.uint32() & 0x3fffffff
+		}
+
+		records := int(sample.uint32())
+		decoded := false
+		for j := 0; j < records && sample.err == nil; j++ {
+			recordFormat := sample.uint32() & 0xfff
+			record := &reader{b: sample.bytes(int(sample.uint32()))}
+			if sample.err != nil || recordFormat != sflowRawPacketHeader {
+				continue
+			}
+			protocol := record.uint32()
+			f.Bytes = uint64(record.uint32())
+			record.uint32() // stripped
+			header := record.bytes(int(record.uint32()))
+			if record.err == nil && protocol == sflowEthernetProtocol {
+				decoded = decodeEthernet(header, f)
+			}
+		}
+		if sample.err != nil {
+			return flows, sample.err
+		}
+		if decoded {
+			flows = append(flows, f)
+		}
+	}
+	return flows, r.err
+}
+
+// decodeEthernet fills the flow with the IP and transport headers of a
+// sampled ethernet frame, it returns false for non IP frames
+func decodeEthernet(frame []byte, f *Flow) bool {
+	if len(frame) < 14 {
+		return false
+	}
+	etherType := binary.BigEndian.Uint16(frame[12:14])
+	payload := frame[14:]
+	if etherType == etherTypeVLAN && len(payload) >= 4 {
+		etherType = binary.BigEndian.Uint16(payload[2:4])
+		payload = payload[4:]
+	}
+
+	var transport []byte
+	switch etherType {
+	case etherTypeIPv4:
+		if len(payload) < 20 {
+			return false
+		}
+		headerLength := int(payload[0]&0x0f) * 4
+		f.Tos = payload[1]
+		f.IPProtocol = payload[9]
+		f.SrcAddr = ipString(payload[12:16])
+		f.DstAddr = ipString(payload[16:20])
+		if headerLength <= len(payload) {
+			transport = payload[headerLength:]
+		}
+	case etherTypeIPv6:
+		if len(payload) < 40 {
+			return false
+		}
+		f.Tos = uint8(binary.BigEndian.Uint16(payload[0:2]) >> 4)
+		f.IPProtocol = payload[6]
+		f.SrcAddr = ipString(payload[8:24])
+		f.DstAddr = ipString(payload[24:40])
+		transport = payload[40:]
+	default:
+		return false
+	}
+
+	switch f.IPProtocol {
+	case 6: // TCP
+		if len(transport) >= 14 {
+			f.TCPFlags = transport[13]
+		}
+		fallthrough
+	case 17: // UDP
+		if len(transport) >= 4 {
+			f.SrcPort = binary.BigEndian.Uint16(transport[0:2])
+			f.DstPort = binary.BigEndian.Uint16(transport[2:4])
+		}
+	}
+	return true
+}
@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package netflow
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// The information elements decoded into the flows, they share the same
+// identifiers in NetFlow v9 and IPFIX
+const (
+	fieldBytes                = 1
+	fieldPackets              = 2
+	fieldProtocol             = 4
+	fieldTos                  = 5
+	fieldTCPFlags             = 6
+	fieldSrcPort              = 7
+	fieldSrcAddrV4            = 8
+	fieldInputInterface       = 10
+	fieldDstPort              = 11
+	fieldDstAddrV4            = 12
+	fieldOutputInterface      = 14
+	fieldLastSwitched         = 21
+	fieldFirstSwitched        = 22
+	fieldSrcAddrV6            = 27
+	fieldDstAddrV6            = 28
+	fieldSamplingInterval     = 34
+	fieldSystemInitTimeMillis = 160
+	fieldFlowStartSeconds     = 150
+	fieldFlowEndSeconds       = 151
+	fieldFlowStartMillis      = 152
+	fieldFlowEndMillis        = 153
+)
+
+// variableLength is the field length announcing an IPFIX variable length field
+const variableLength = 0xffff
+
+type templateField struct {
+	id         uint16
+	length     uint16
+	enterprise bool
+}
+
+// templateKey identifies the templates of an exporter, the source id of
+// NetFlow v9 is the observation domain id of IPFIX
+type templateKey struct {
+	exporter   string
+	sourceID   uint32
+	templateID uint16
+}
+
+// templateDecoder decodes the NetFlow v9 and IPFIX datagrams, whose data
+// records are described by the templates previously sent by the exporter
+type templateDecoder struct {
+	flowType  string
+	mu        sync.Mutex
+	templates map[templateKey][]templateField
+}
+
+func newTemplateDecoder(flowType string) *templateDecoder {
+	return &templateDecoder{
+		flowType:  flowType,
+		templates: make(map[templateKey][]templateField),
+	}
+}
+
+func (d *templateDecoder) decode(payload []byte, exporter net.IP, now time.Time) ([]*Flow, error) {
+	r := &reader{b: payload}
+	version := r.uint16()
+	var sysUptime, exportTime, sourceID uint32
+	switch {
+	case d.flowType == FlowTypeNetFlow9 && version == 9:
+		r.uint16() // count
+		sysUptime = r.uint32()
+		exportTime = r.uint32()
+		r.uint32() // sequence
+		sourceID = r.uint32()
+	case d.flowType == FlowTypeIPFIX && version == 10:
+		length := int(r.uint16())
+		exportTime = r.uint32()
+		r.uint32() // sequence
+		sourceID = r.uint32()
+		if r.err == nil && length <= len(payload) {
+			r.b = payload[:length]
+		}
+	default:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, fmt.Errorf("unexpected %s version %d", d.flowType, version)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var flows []*Flow
+	var err error
+	for r.err == nil && r.remaining() >= 4 {
+		setID := r.uint16()
+		setLength := int(r.uint16())
+		body := r.bytes(setLength - 4)
+		if r.err != nil {
+			break
+		}
+		set := &reader{b: body}
+		switch {
+		case d.flowType == FlowTypeNetFlow9 && setID == 0, d.flowType == FlowTypeIPFIX && setID == 2:
+			d.readTemplates(set, exporter.String(), sourceID)
+		case setID < 256:
+			// options templates and reserved sets are not decoded
+			continue
+		default:
+			key := templateKey{exporter: exporter.String(), sourceID: sourceID, templateID: setID}
+			fields, found := d.templates[key]
+			if !found {
+				// the exporters send their templates periodically
+				err = fmt.Errorf("no template %d from %s yet", setID, exporter)
+				continue
+			}
+			for set.err == nil && set.remaining() >= minRecordLength(fields) {
+				f := d.readRecord(set, fields, exporter, sysUptime, exportTime)
+				if set.err == nil {
+					flows = append(flows, f)
+				}
+			}
+		}
+		if set.err != nil {
+			return flows, set.err
+		}
+	}
+	if r.err != nil {
+		return flows, r.err
+	}
+	return flows, err
+}
+
+func (d *templateDecoder) readTemplates(r *reader, exporter string, sourceID uint32) {
+	// the set ends with a padding shorter than a template header
+	for r.err == nil && r.remaining() >= 4 {
+		templateID := r.uint16()
+		count := int(r.uint16())
+		fields := make([]templateField, 0, count)
+		for i := 0; i < count; i++ {
+			f := templateField{id: r.uint16(), length: r.uint16()}
+			if d.flowType == FlowTypeIPFIX && f.id&0x8000 != 0 {
+				f.id &= 0x7fff
+				f.enterprise = true
+				r.uint32() // enterprise number
+			}
+			fields = append(fields, f)
+		}
+		if r.err != nil {
+			return
+		}
+		d.templates[templateKey{exporter: exporter, sourceID: sourceID, templateID: templateID}] = fields
+	}
+}
+
+func minRecordLength(fields []templateField) int {
+	length := 0
+	for _, f := range fields {
+		if f.length == variableLength {
+			length++
+		} else {
+			length += int(f.length)
+		}
+	}
+	if length == 0 {
+		// an empty template would never consume the set
+		return 1
+	}
+	return length
+}
+
+func (d *templateDecoder) readRecord(r *reader, fields []templateField, exporter net.IP, sysUptime, exportTime uint32) *Flow {
+	f := &Flow{
+		FlowType:     d.flowType,
+		ExporterAddr: exporter.String(),
+		SamplingRate: 1,
+	}
+	var first, last, systemInit uint64
+	var hasUptimes bool
+	for _, field := range fields {
+		length := int(field.length)
+		if field.length == variableLength {
+			if length = int(r.uint8()); length == 0xff {
+				length = int(r.uint16())
+			}
+		}
+		value := r.bytes(length)
+		if r.err != nil || field.enterprise {
+			continue
+		}
+		switch field.id {
+		case fieldBytes:
+			f.Bytes = readUint(value)
+		case fieldPackets:
+			f.Packets = readUint(value)
+		case fieldProtocol:
+			f.IPProtocol = uint8(readUint(value))
+		case fieldTos:
+			f.Tos = uint8(readUint(value))
+		case fieldTCPFlags:
+			f.TCPFlags = uint8(readUint(value))
+		case fieldSrcPort:
+			f.SrcPort = uint16(readUint(value))
+		case fieldDstPort:
+			f.DstPort = uint16(readUint(value))
+		case fieldSrcAddrV4, fieldSrcAddrV6:
+			f.SrcAddr = ipString(value)
+		case fieldDstAddrV4, fieldDstAddrV6:
+			f.DstAddr = ipString(value)
+		case fieldInputInterface:
+			f.InputInterface = uint32(readUint(value))
+		case fieldOutputInterface:
+			f.OutputInterface = uint32(readUint(value))
+		case fieldSamplingInterval:
+			if rate := readUint(value); rate > 0 {
+				f.SamplingRate = rate
+			}
+		case fieldFirstSwitched:
+			first, hasUptimes = readUint(value), true
+		case fieldLastSwitched:
+			last, hasUptimes = readUint(value), true
+		case fieldSystemInitTimeMillis:
+			systemInit = readUint(value)
+		case fieldFlowStartSeconds:
+			f.StartTimestamp = int64(readUint(value))
+		case fieldFlowEndSeconds:
+			f.EndTimestamp = int64(readUint(value))
+		case fieldFlowStartMillis:
+			f.StartTimestamp = int64(readUint(value) / 1000)
+		case fieldFlowEndMillis:
+			f.EndTimestamp = int64(readUint(value) / 1000)
+		}
+	}
+
+	if hasUptimes {
+		switch {
+		case systemInit > 0:
+			// IPFIX uptimes are relative to the system init time
+			f.StartTimestamp = int64((systemInit + first) / 1000)
+			f.EndTimestamp = int64((systemInit + last) / 1000)
+		case sysUptime > 0:
+			f.StartTimestamp = int64(exportTime) - int64(sysUptime-uint32(first))/1000
+			f.EndTimestamp = int64(exportTime) - int64(sysUptime-uint32(last))/1000
+		}
+	}
+	if f.StartTimestamp == 0 {
+		f.StartTimestamp = int64(exportTime)
+	}
+	if f.EndTimestamp == 0 {
+		f.EndTimestamp = int64(exportTime)
+	}
+	return f
+}
@@ -0,0 +1,217 @@
+// +build linux
+
+package tracepath
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const protocolICMP = 1
+
+var errNoAnswer = errors.New("no answer")
+
+// icmpProber sends UDP datagrams or TCP SYNs with a limited TTL and listens
+// to the ICMP errors sent back by the routers, which requires CAP_NET_RAW
+type icmpProber struct {
+	protocol string
+	ip       net.IP
+	port     int
+	timeout  time.Duration
+	icmpConn *icmp.PacketConn
+}
+
+func newProber(protocol string, ip net.IP, port int, timeout time.Duration) (prober, error) {
+	if protocol != ProtocolUDP && protocol != ProtocolTCP {
+		return nil, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen to ICMP: %v", err)
+	}
+	return &icmpProber{protocol: protocol, ip: ip, port: port, timeout: timeout, icmpConn: conn}, nil
+}
+
+func (p *icmpProber) close() {
+	p.icmpConn.Close()
+}
+
+func (p *icmpProber) probe(ttl int) (Hop, error) {
+	if p.protocol == ProtocolTCP {
+		return p.probeTCP(ttl)
+	}
+	return p.probeUDP(ttl)
+}
+
+// probeUDP sends a datagram to an unlikely used port, the target answering
+// with a port unreachable error
+func (p *icmpProber) probeUDP(ttl int) (Hop, error) {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: p.ip, Port: p.port + ttl - 1})
+	if err != nil {
+		return Hop{}, err
+	}
+	defer conn.Close()
+	if err := ipv4.NewConn(conn).SetTTL(ttl); err != nil {
+		return Hop{}, err
+	}
+	localPort := conn.LocalAddr().(*net.UDPAddr).Port
+
+	start := time.Now()
+	if _, err := conn.Write([]byte("datadog-tracepath")); err != nil {
+		return Hop{}, err
+	}
+	hopIP, reached, err := p.waitICMP(localPort, start.Add(p.timeout), nil)
+	if err != nil {
+		return Hop{}, err
+	}
+	return Hop{TTL: ttl, IP: hopIP, RTT: time.Since(start), Reached: reached}, nil
+}
+
+// probeTCP opens a connection to the target port, the target answering with
+// a SYN-ACK or a RST and the routers with a time exceeded error
+func (p *icmpProber) probeTCP(ttl int) (Hop, error) {
+	var localPort int32
+	dialer := net.Dialer{
+		Timeout: p.timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				if sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl); sockErr != nil {
+					return
+				}
+				// Bind before connecting to know the port to match in ICMP errors
+				if sockErr = syscall.Bind(int(fd), &syscall.SockaddrInet4{}); sockErr != nil {
+					return
+				}
+				var sa syscall.Sockaddr
+				if sa, sockErr = syscall.Getsockname(int(fd)); sockErr == nil {
+					atomic.StoreInt32(&localPort, int32(sa.(*syscall.SockaddrInet4).Port))
+				}
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	type icmpResult struct {
+		ip  string
+		err error
+	}
+	icmpDone := make(chan icmpResult, 1)
+	icmpExited := make(chan struct{})
+	dialDone := make(chan error, 1)
+	var canceled int32
+	defer func() {
+		// Stop reading the ICMP socket before the next probe reads it
+		atomic.StoreInt32(&canceled, 1)
+		p.icmpConn.SetReadDeadline(time.Now()) //nolint:errcheck
+		<-icmpExited
+	}()
+	go func() {
+		conn, err := dialer.DialContext(ctx, "tcp4", net.JoinHostPort(p.ip.String(), fmt.Sprint(p.port)))
+		if err == nil {
+			conn.Close()
+		}
+		dialDone <- err
+	}()
+	go func() {
+		defer close(icmpExited)
+		// Wait for the dialer to bind the socket
+		for i := 0; atomic.LoadInt32(&localPort) == 0 && atomic.LoadInt32(&canceled) == 0 && i < 100; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		ip, _, err := p.waitICMP(int(atomic.LoadInt32(&localPort)), start.Add(p.timeout), &canceled)
+		icmpDone <- icmpResult{ip, err}
+	}()
+
+	select {
+	case err := <-dialDone:
+		rtt := time.Since(start)
+		if err == nil || errors.Is(err, syscall.ECONNREFUSED) {
+			return Hop{TTL: ttl, IP: p.ip.String(), RTT: rtt, Reached: true}, nil
+		}
+		// The connection failed, a router may have answered
+		res := <-icmpDone
+		if res.err != nil {
+			return Hop{}, res.err
+		}
+		return Hop{TTL: ttl, IP: res.ip, RTT: rtt}, nil
+	case res := <-icmpDone:
+		cancel()
+		if res.err != nil {
+			return Hop{}, res.err
+		}
+		return Hop{TTL: ttl, IP: res.ip, RTT: time.Since(start)}, nil
+	}
+}
+
+// waitICMP waits for an ICMP error about a packet sent from localPort toward
+// the target. It returns the address of the sender and whether it is the target.
+// The wait stops early once canceled is set and the read deadline reset.
+func (p *icmpProber) waitICMP(localPort int, deadline time.Time, canceled *int32) (string, bool, error) {
+	if err := p.icmpConn.SetReadDeadline(deadline); err != nil {
+		return "", false, err
+	}
+	// Checked after setting the deadline, which may have overridden the one
+	// reset by the cancellation
+	if canceled != nil && atomic.LoadInt32(canceled) == 1 {
+		return "", false, errNoAnswer
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := p.icmpConn.ReadFrom(buf)
+		if err != nil {
+			return "", false, errNoAnswer
+		}
+		msg, err := icmp.ParseMessage(protocolICMP, buf[:n])
+		if err != nil {
+			continue
+		}
+		var data []byte
+		switch body := msg.Body.(type) {
+		case *icmp.TimeExceeded:
+			data = body.Data
+		case *icmp.DstUnreach:
+			data = body.Data
+		default:
+			continue
+		}
+		if !matchesProbe(data, p.ip, localPort) {
+			continue
+		}
+		peerIP := peer.(*net.IPAddr).IP.String()
+		return peerIP, peerIP == p.ip.String(), nil
+	}
+}
+
+// matchesProbe checks whether the original datagram quoted in an ICMP error
+// was sent from localPort to the target
+func matchesProbe(data []byte, target net.IP, localPort int) bool {
+	if len(data) < ipv4.HeaderLen {
+		return false
+	}
+	ihl := int(data[0]&0x0f) * 4
+	if ihl < ipv4.HeaderLen || len(data) < ihl+4 {
+		return false
+	}
+	if !bytes.Equal(data[16:20], target.To4()) {
+		return false
+	}
+	return int(binary.BigEndian.Uint16(data[ihl:ihl+2])) == localPort
+}
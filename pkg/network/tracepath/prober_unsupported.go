@@ -0,0 +1,13 @@
+// +build !linux
+
+package tracepath
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+func newProber(protocol string, ip net.IP, port int, timeout time.Duration) (prober, error) {
+	return nil, errors.New("network path tracing is only supported on linux")
+}
@@ -0,0 +1,250 @@
+// Package tracepath traces the network path toward critical endpoints, recording
+// the latency of every hop, either on demand or periodically.
+package tracepath
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Probe protocols
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+)
+
+const (
+	defaultMaxHops = 30
+	defaultTimeout = time.Second
+	defaultUDPPort = 33434
+)
+
+// Target is an endpoint whose network path is traced
+type Target struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+func (t Target) String() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// ParseTarget parses a target with the host[:port] format
+func ParseTarget(raw string) (Target, error) {
+	host, rawPort, err := net.SplitHostPort(raw)
+	if err != nil {
+		// No port
+		return Target{Host: raw}, nil
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil || port <= 0 || port > 65535 {
+		return Target{}, fmt.Errorf("invalid port in target %q", raw)
+	}
+	return Target{Host: host, Port: port}, nil
+}
+
+// Config holds the settings of the path tracing
+type Config struct {
+	Targets  []Target
+	Protocol string
+	MaxHops  int
+	Timeout  time.Duration
+	// Interval between two traces of all the targets, 0 to only trace them on demand
+	Interval time.Duration
+}
+
+// Hop is a router on the path toward a target
+type Hop struct {
+	TTL int `json:"ttl"`
+	// IP is empty if the hop didn't answer before the timeout
+	IP      string        `json:"ip,omitempty"`
+	RTT     time.Duration `json:"rtt"`
+	Reached bool          `json:"reached,omitempty"`
+}
+
+// Path is the result of tracing the path toward a target
+type Path struct {
+	Target    Target    `json:"target"`
+	Protocol  string    `json:"protocol"`
+	IP        string    `json:"ip,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Hops      []Hop     `json:"hops"`
+	Reached   bool      `json:"reached"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// prober sends a probe with a given TTL and returns the hop answering it
+type prober interface {
+	probe(ttl int) (Hop, error)
+	close()
+}
+
+type proberFactory func(protocol string, ip net.IP, port int, timeout time.Duration) (prober, error)
+
+// Runner traces the paths toward the configured targets and keeps the last result for each of them
+type Runner struct {
+	cfg       Config
+	running   int32
+	newProber proberFactory
+	stop      chan struct{}
+
+	mu      sync.RWMutex
+	results map[string]Path
+}
+
+// NewRunner returns a Runner for a given configuration
+func NewRunner(cfg Config) *Runner {
+	if cfg.Protocol == "" {
+		cfg.Protocol = ProtocolUDP
+	}
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = defaultMaxHops
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Runner{
+		cfg:       cfg,
+		newProber: newProber,
+		stop:      make(chan struct{}),
+		results:   make(map[string]Path),
+	}
+}
+
+// Start traces the targets periodically if an interval is configured
+func (r *Runner) Start() {
+	if r.cfg.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		r.Trigger()
+		for {
+			select {
+			case <-ticker.C:
+				r.Trigger()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic traces
+func (r *Runner) Stop() {
+	close(r.stop)
+}
+
+// Trigger starts tracing all the targets in the background. It returns false
+// if a trace is already running.
+func (r *Runner) Trigger() bool {
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		return false
+	}
+	go func() {
+		defer atomic.StoreInt32(&r.running, 0)
+		r.traceAll()
+	}()
+	return true
+}
+
+// Running returns whether a trace is running
+func (r *Runner) Running() bool {
+	return atomic.LoadInt32(&r.running) == 1
+}
+
+// Results returns the last path traced toward each target
+func (r *Runner) Results() []Path {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	paths := make([]Path, 0, len(r.results))
+	for _, p := range r.results {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].Target.String() < paths[j].Target.String()
+	})
+	return paths
+}
+
+func (r *Runner) traceAll() {
+	for _, target := range r.cfg.Targets {
+		path := r.trace(target)
+		if path.Error != "" {
+			log.Debugf("Could not trace the path toward %s: %s", target, path.Error)
+		}
+		r.mu.Lock()
+		r.results[target.String()] = path
+		r.mu.Unlock()
+	}
+}
+
+// trace sends probes with an increasing TTL until the target answers or the
+// maximum number of hops is reached
+func (r *Runner) trace(target Target) Path {
+	path := Path{
+		Target:    target,
+		Protocol:  r.cfg.Protocol,
+		Timestamp: time.Now(),
+	}
+
+	ip, err := resolve(target.Host)
+	if err != nil {
+		path.Error = err.Error()
+		return path
+	}
+	path.IP = ip.String()
+
+	port := target.Port
+	if port == 0 {
+		port = defaultUDPPort
+	}
+	p, err := r.newProber(r.cfg.Protocol, ip, port, r.cfg.Timeout)
+	if err != nil {
+		path.Error = err.Error()
+		return path
+	}
+	defer p.close()
+
+	for ttl := 1; ttl <= r.cfg.MaxHops; ttl++ {
+		hop, err := p.probe(ttl)
+		if err != nil {
+			// The hop didn't answer, keep going as the next ones may
+			hop = Hop{TTL: ttl}
+		}
+		path.Hops = append(path.Hops, hop)
+		if hop.Reached {
+			path.Reached = true
+			break
+		}
+	}
+	return path
+}
+
+func resolve(host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("only IPv4 targets are supported")
+		}
+		return ip.To4(), nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if v4 := ip.To4(); v4 != nil {
+			return v4, nil
+		}
+	}
+	return nil, fmt.Errorf("no IPv4 address found for %s", host)
+}
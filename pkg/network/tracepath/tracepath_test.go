@@ -0,0 +1,90 @@
+package tracepath
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProber simulates a path whose routers answer according to hops, the
+// target being reached at the last one
+type fakeProber struct {
+	hops []string
+}
+
+func (f *fakeProber) probe(ttl int) (Hop, error) {
+	if ttl > len(f.hops) {
+		return Hop{}, errors.New("unexpected probe")
+	}
+	ip := f.hops[ttl-1]
+	if ip == "" {
+		return Hop{}, errors.New("timeout")
+	}
+	return Hop{TTL: ttl, IP: ip, RTT: time.Duration(ttl) * time.Millisecond, Reached: ttl == len(f.hops)}, nil
+}
+
+func (f *fakeProber) close() {}
+
+func newTestRunner(cfg Config, hops []string) *Runner {
+	r := NewRunner(cfg)
+	r.newProber = func(protocol string, ip net.IP, port int, timeout time.Duration) (prober, error) {
+		return &fakeProber{hops: hops}, nil
+	}
+	return r
+}
+
+func TestTrace(t *testing.T) {
+	r := newTestRunner(Config{}, []string{"10.0.0.1", "", "192.0.2.1", "198.51.100.7"})
+	path := r.trace(Target{Host: "198.51.100.7", Port: 443})
+
+	assert.Equal(t, ProtocolUDP, path.Protocol)
+	assert.Equal(t, "198.51.100.7", path.IP)
+	assert.True(t, path.Reached)
+	assert.Empty(t, path.Error)
+	assert.Equal(t, []Hop{
+		{TTL: 1, IP: "10.0.0.1", RTT: time.Millisecond},
+		{TTL: 2},
+		{TTL: 3, IP: "192.0.2.1", RTT: 3 * time.Millisecond},
+		{TTL: 4, IP: "198.51.100.7", RTT: 4 * time.Millisecond, Reached: true},
+	}, path.Hops)
+}
+
+func TestTraceMaxHops(t *testing.T) {
+	r := newTestRunner(Config{MaxHops: 2}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+	path := r.trace(Target{Host: "198.51.100.7"})
+	assert.False(t, path.Reached)
+	assert.Len(t, path.Hops, 2)
+
+	path = r.trace(Target{Host: "2001:db8::1"})
+	assert.NotEmpty(t, path.Error)
+	assert.Empty(t, path.Hops)
+}
+
+func TestRunnerTrigger(t *testing.T) {
+	r := newTestRunner(Config{Targets: []Target{{Host: "198.51.100.7", Port: 443}, {Host: "192.0.2.10"}}}, []string{"10.0.0.1", "198.51.100.7"})
+
+	require.True(t, r.Trigger())
+	require.Eventually(t, func() bool { return !r.Running() }, time.Second, time.Millisecond)
+
+	results := r.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, "192.0.2.10", results[0].Target.Host)
+	assert.Equal(t, "198.51.100.7", results[1].Target.Host)
+}
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("example.com:443")
+	require.NoError(t, err)
+	assert.Equal(t, Target{Host: "example.com", Port: 443}, target)
+
+	target, err = ParseTarget("10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, Target{Host: "10.0.0.1"}, target)
+
+	_, err = ParseTarget("example.com:http")
+	assert.Error(t, err)
+}
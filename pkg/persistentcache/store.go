@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2019-2020 Datadog, Inc.
+
+package persistentcache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	stateDir      = "check_state"
+	stateFileExt  = ".json"
+	stateFileMode = 0600
+
+	// defaultStateFile stores the state of the checks with a single instance,
+	// whose ID has no hash
+	defaultStateFile = "default"
+)
+
+// ErrQuotaExceeded is returned by Store.Set when the state of a check
+// instance would exceed check_state_max_size.
+var ErrQuotaExceeded = errors.New("check state quota exceeded")
+
+// Store is a small key-value store persisted on disk in the run directory,
+// allowing a check instance to keep its state (cursors, last event IDs...)
+// across agent restarts. Every change is written to disk before returning.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int
+	size    int
+	data    map[string]string
+}
+
+// OpenStore returns the store of the given check instance, loading the state
+// saved by a previous run, if any.
+func OpenStore(checkID string) (*Store, error) {
+	path, err := getStateFile(checkID)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		path:    path,
+		maxSize: config.Datadog.GetInt("check_state_max_size"),
+		data:    make(map[string]string),
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, err
+	}
+	for k, v := range s.data {
+		s.size += len(k) + len(v)
+	}
+	return s, nil
+}
+
+// Get returns the value stored for key, and whether it was found.
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Set stores value for key. It returns ErrQuotaExceeded, without storing
+// anything, when the keys and values of the store would exceed the quota.
+func (s *Store) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.data[key]
+	size := s.size + len(key) + len(value)
+	if existed {
+		size -= len(key) + len(old)
+	}
+	if s.maxSize > 0 && size > s.maxSize {
+		return ErrQuotaExceeded
+	}
+
+	s.data[key] = value
+	if err := s.save(); err != nil {
+		if existed {
+			s.data[key] = old
+		} else {
+			delete(s.data, key)
+		}
+		return err
+	}
+	s.size = size
+	return nil
+}
+
+// Delete removes key from the store.
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	delete(s.data, key)
+	if err := s.save(); err != nil {
+		s.data[key] = old
+		return err
+	}
+	s.size -= len(key) + len(old)
+	return nil
+}
+
+// Keys returns the sorted keys of the store.
+func (s *Store) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedKeys(s.data)
+}
+
+// save writes the state to a temporary file then renames it, so that a
+// crash never leaves a truncated state behind. s.mu must be held.
+func (s *Store) save() error {
+	content, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, stateFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// StateKeys returns the keys, not the values, stored by every check instance
+// indexed by check ID. It is used to describe the check states in a flare.
+func StateKeys() (map[string][]string, error) {
+	root := filepath.Join(config.Datadog.GetString("run_path"), stateDir)
+	keys := make(map[string][]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != stateFileExt {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data := make(map[string]string)
+		if err := json.Unmarshal(content, &data); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, strings.TrimSuffix(path, stateFileExt))
+		if err != nil {
+			return err
+		}
+		checkID := strings.TrimSuffix(strings.Replace(filepath.ToSlash(rel), "/", ":", 1), ":"+defaultStateFile)
+		keys[checkID] = sortedKeys(data)
+		return nil
+	})
+	return keys, err
+}
+
+// getStateFile returns the file storing the state of a check instance, using
+// the check name as directory like getFileForKey does.
+func getStateFile(checkID string) (string, error) {
+	parent := filepath.Join(config.Datadog.GetString("run_path"), stateDir)
+	paths := strings.SplitN(checkID, ":", 2)
+	dir := filepath.Join(parent, invalidChars.ReplaceAllString(paths[0], ""))
+	file := defaultStateFile
+	if len(paths) == 2 {
+		file = invalidChars.ReplaceAllString(paths[1], "")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, file+stateFileExt), nil
+}
+
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
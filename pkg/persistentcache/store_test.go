@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2019-2020 Datadog, Inc.
+
+package persistentcache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePersists(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "fake-datadog-run-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", testDir)
+
+	s, err := OpenStore("mycheck:1234abcd")
+	require.NoError(t, err)
+	_, found := s.Get("cursor")
+	assert.False(t, found)
+	require.NoError(t, s.Set("cursor", "42"))
+	require.NoError(t, s.Set("last_event", "abc"))
+	require.NoError(t, s.Delete("last_event"))
+
+	_, err = os.Stat(filepath.Join(testDir, "check_state", "mycheck", "1234abcd.json"))
+	require.NoError(t, err)
+
+	// another run of the same instance finds the state back
+	s, err = OpenStore("mycheck:1234abcd")
+	require.NoError(t, err)
+	value, found := s.Get("cursor")
+	assert.True(t, found)
+	assert.Equal(t, "42", value)
+	assert.Equal(t, []string{"cursor"}, s.Keys())
+
+	// other instances have their own state
+	s, err = OpenStore("mycheck:5678")
+	require.NoError(t, err)
+	assert.Empty(t, s.Keys())
+}
+
+func TestStoreQuota(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "fake-datadog-run-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", testDir)
+	mockConfig.Set("check_state_max_size", 10)
+
+	s, err := OpenStore("mycheck")
+	require.NoError(t, err)
+	require.NoError(t, s.Set("key", "value"))
+	assert.Equal(t, ErrQuotaExceeded, s.Set("other", "value"))
+	_, found := s.Get("other")
+	assert.False(t, found)
+
+	// replacing a value only counts the difference
+	require.NoError(t, s.Set("key", "1234567"))
+	assert.Equal(t, ErrQuotaExceeded, s.Set("key", "12345678"))
+	value, _ := s.Get("key")
+	assert.Equal(t, "1234567", value)
+
+	// the size is restored when the store is opened again
+	s, err = OpenStore("mycheck")
+	require.NoError(t, err)
+	assert.Equal(t, ErrQuotaExceeded, s.Set("k", "v"))
+}
+
+func TestStateKeys(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "fake-datadog-run-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", testDir)
+
+	keys, err := StateKeys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	s, err := OpenStore("mycheck:1234")
+	require.NoError(t, err)
+	require.NoError(t, s.Set("cursor", "secret"))
+	s, err = OpenStore("othercheck")
+	require.NoError(t, err)
+	require.NoError(t, s.Set("b", "1"))
+	require.NoError(t, s.Set("a", "2"))
+
+	keys, err = StateKeys()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"mycheck:1234": {"cursor"},
+		"othercheck":   {"a", "b"},
+	}, keys)
+}
@@ -0,0 +1,242 @@
+// +build linux
+
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/gopsutil/cpu"
+	"github.com/DataDog/gopsutil/host"
+	"github.com/DataDog/gopsutil/process"
+)
+
+// clockTicks is the number of clock ticks per second used by the times of /proc/<pid>/stat
+const clockTicks = 100
+
+func getAllProcesses(cfg *config.AgentConfig) (map[int32]*process.FilledProcess, error) {
+	if cfg.MaxIOStatsProcesses <= 0 {
+		return process.AllProcesses()
+	}
+	return allProcessesWithTopIO(cfg.MaxIOStatsProcesses)
+}
+
+// allProcessesWithTopIO collects the processes like process.AllProcesses, but
+// only reads the IO stats and open file descriptors count of the n processes
+// returned by topIOProcesses, as reading them for every process is expensive
+// on hosts running many processes.
+func allProcessesWithTopIO(n int) (map[int32]*process.FilledProcess, error) {
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+	bootTime, err := host.BootTime()
+	if err != nil {
+		return nil, err
+	}
+	pageSize := uint64(os.Getpagesize())
+
+	procs := make(map[int32]*process.FilledProcess, len(pids))
+	for _, pid := range pids {
+		fp, err := fillProcessWithoutIO(pid, bootTime, pageSize)
+		if err != nil {
+			// the process most likely exited since the pids were listed
+			log.Tracef("Cannot collect process %d: %s", pid, err)
+			continue
+		}
+		procs[pid] = fp
+	}
+
+	for pid := range topIOProcesses(procs, n) {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		if ioStat, err := p.IOCounters(); err == nil {
+			procs[pid].IOStat = ioStat
+		}
+		if fds, err := p.NumFDs(); err == nil {
+			procs[pid].OpenFdCount = fds
+		}
+	}
+	return procs, nil
+}
+
+// fillProcessWithoutIO collects everything process.AllProcesses does for a
+// process, except for its IO stats and open file descriptors count
+func fillProcessWithoutIO(pid int32, bootTime, pageSize uint64) (*process.FilledProcess, error) {
+	procDir := util.HostProc(strconv.Itoa(int(pid)))
+	fp := &process.FilledProcess{Pid: pid}
+
+	stat, err := ioutil.ReadFile(procDir + "/stat")
+	if err != nil {
+		return nil, err
+	}
+	if err := parseProcStat(fp, stat, bootTime); err != nil {
+		return nil, err
+	}
+
+	status, err := ioutil.ReadFile(procDir + "/status")
+	if err != nil {
+		return nil, err
+	}
+	parseProcStatus(fp, status)
+
+	statm, err := ioutil.ReadFile(procDir + "/statm")
+	if err != nil {
+		return nil, err
+	}
+	if err := parseProcStatm(fp, statm, pageSize); err != nil {
+		return nil, err
+	}
+
+	if cmdline, err := ioutil.ReadFile(procDir + "/cmdline"); err == nil {
+		fp.Cmdline = parseProcCmdline(cmdline)
+	}
+	// the links cannot be read without enough permissions, as with process.AllProcesses
+	fp.Cwd, _ = os.Readlink(procDir + "/cwd")
+	fp.Exe, _ = os.Readlink(procDir + "/exe")
+	return fp, nil
+}
+
+// parseProcStat reads the state, parent, CPU times, nice value, threads count
+// and start time of a process from /proc/<pid>/stat
+func parseProcStat(fp *process.FilledProcess, contents []byte, bootTime uint64) error {
+	// the command name can contain spaces and parentheses, the other fields follow the last ')'
+	end := bytes.LastIndexByte(contents, ')')
+	if end < 0 {
+		return fmt.Errorf("cannot parse the stat file of process %d", fp.Pid)
+	}
+	fields := strings.Fields(string(contents[end+1:]))
+	if len(fields) < 20 {
+		return fmt.Errorf("cannot parse the stat file of process %d: %d fields", fp.Pid, len(fields))
+	}
+
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return err
+	}
+	utime, err := strconv.ParseFloat(fields[11], 64)
+	if err != nil {
+		return err
+	}
+	stime, err := strconv.ParseFloat(fields[12], 64)
+	if err != nil {
+		return err
+	}
+	nice, err := strconv.ParseInt(fields[16], 10, 32)
+	if err != nil {
+		return err
+	}
+	numThreads, err := strconv.ParseInt(fields[17], 10, 32)
+	if err != nil {
+		return err
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	fp.Status = fields[0]
+	fp.Ppid = int32(ppid)
+	fp.CpuTime = cpu.TimesStat{
+		CPU:    "cpu",
+		User:   utime / clockTicks,
+		System: stime / clockTicks,
+	}
+	fp.Nice = int32(nice)
+	fp.NumThreads = int32(numThreads)
+	fp.CreateTime = int64((startTime/clockTicks + bootTime) * 1000)
+	return nil
+}
+
+// parseProcStatus reads the user and group ids, context switches and swap
+// usage of a process from /proc/<pid>/status
+func parseProcStatus(fp *process.FilledProcess, contents []byte) {
+	fp.CtxSwitches = &process.NumCtxSwitchesStat{}
+	fp.MemInfo = &process.MemoryInfoStat{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "Uid:":
+			fp.Uids = parseIDs(fields[1:])
+		case "Gid:":
+			fp.Gids = parseIDs(fields[1:])
+		case "voluntary_ctxt_switches:":
+			fp.CtxSwitches.Voluntary, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "nonvoluntary_ctxt_switches:":
+			fp.CtxSwitches.Involuntary, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "VmSwap:":
+			// in kB
+			swap, _ := strconv.ParseUint(fields[1], 10, 64)
+			fp.MemInfo.Swap = swap * 1024
+		}
+	}
+}
+
+func parseIDs(fields []string) []int32 {
+	ids := make([]int32, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.ParseInt(field, 10, 32)
+		if err != nil {
+			break
+		}
+		ids = append(ids, int32(id))
+	}
+	return ids
+}
+
+// parseProcStatm reads the memory usage of a process from /proc/<pid>/statm,
+// which is given in pages
+func parseProcStatm(fp *process.FilledProcess, contents []byte, pageSize uint64) error {
+	fields := strings.Fields(string(contents))
+	if len(fields) < 7 {
+		return fmt.Errorf("cannot parse the statm file of process %d: %d fields", fp.Pid, len(fields))
+	}
+	values := make([]uint64, 7)
+	for i := range values {
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return err
+		}
+		values[i] = v * pageSize
+	}
+
+	if fp.MemInfo == nil {
+		fp.MemInfo = &process.MemoryInfoStat{}
+	}
+	fp.MemInfo.VMS = values[0]
+	fp.MemInfo.RSS = values[1]
+	fp.MemInfoEx = &process.MemoryInfoExStat{
+		VMS:    values[0],
+		RSS:    values[1],
+		Shared: values[2],
+		Text:   values[3],
+		Lib:    values[4],
+		Data:   values[5],
+		Dirty:  values[6],
+	}
+	return nil
+}
+
+// parseProcCmdline splits the NUL separated arguments of /proc/<pid>/cmdline
+func parseProcCmdline(contents []byte) []string {
+	contents = bytes.TrimRight(contents, "\x00")
+	if len(contents) == 0 {
+		return nil
+	}
+	return strings.Split(string(contents), "\x00")
+}
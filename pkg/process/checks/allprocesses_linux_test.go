@@ -0,0 +1,46 @@
+// +build linux
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/gopsutil/process"
+)
+
+func TestParseProcFiles(t *testing.T) {
+	fp := &process.FilledProcess{Pid: 42}
+
+	stat := "42 (my (odd) proc) S 1 42 42 0 -1 4194560 1000 0 0 0 250 50 0 0 20 -5 3 0 6000 10485760 512 18446744073709551615"
+	require.NoError(t, parseProcStat(fp, []byte(stat), 1600000000))
+	assert.Equal(t, "S", fp.Status)
+	assert.Equal(t, int32(1), fp.Ppid)
+	assert.Equal(t, 2.5, fp.CpuTime.User)
+	assert.Equal(t, 0.5, fp.CpuTime.System)
+	assert.Equal(t, int32(-5), fp.Nice)
+	assert.Equal(t, int32(3), fp.NumThreads)
+	assert.Equal(t, int64(1600000060000), fp.CreateTime)
+
+	status := "Name:\tmy (odd) proc\nState:\tS (sleeping)\nUid:\t1000\t1000\t1000\t1000\nGid:\t100\t100\t100\t100\nVmSwap:\t       8 kB\nvoluntary_ctxt_switches:\t12\nnonvoluntary_ctxt_switches:\t3\n"
+	parseProcStatus(fp, []byte(status))
+	assert.Equal(t, []int32{1000, 1000, 1000, 1000}, fp.Uids)
+	assert.Equal(t, []int32{100, 100, 100, 100}, fp.Gids)
+	assert.Equal(t, &process.NumCtxSwitchesStat{Voluntary: 12, Involuntary: 3}, fp.CtxSwitches)
+	assert.Equal(t, uint64(8192), fp.MemInfo.Swap)
+
+	require.NoError(t, parseProcStatm(fp, []byte("2560 512 128 16 0 1024 0\n"), 4096))
+	assert.Equal(t, uint64(512*4096), fp.MemInfo.RSS)
+	assert.Equal(t, uint64(2560*4096), fp.MemInfo.VMS)
+	assert.Equal(t, uint64(8192), fp.MemInfo.Swap)
+	assert.Equal(t, uint64(128*4096), fp.MemInfoEx.Shared)
+	assert.Equal(t, uint64(1024*4096), fp.MemInfoEx.Data)
+
+	assert.Equal(t, []string{"/bin/proc", "", "--flag"}, parseProcCmdline([]byte("/bin/proc\x00\x00--flag\x00")))
+	assert.Nil(t, parseProcCmdline([]byte{}))
+
+	assert.Error(t, parseProcStat(fp, []byte("42 (truncated"), 0))
+	assert.Error(t, parseProcStatm(fp, []byte("1 2 3"), 4096))
+}
@@ -210,6 +210,7 @@ func fmtProcesses(
 	lastRun time.Time,
 ) map[string][]*model.Process {
 	ctrIDForPID := ctrIDForPID(ctrList)
+	topIO := topIOProcesses(procs, cfg.MaxIOStatsProcesses)
 
 	procsByCtr := make(map[string][]*model.Process)
 
@@ -235,6 +236,11 @@ func fmtProcesses(
 			InvoluntaryCtxSwitches: uint64(fp.CtxSwitches.Involuntary),
 			ContainerId:            ctrIDForPID[fp.Pid],
 		}
+		if !reportsIOStats(topIO, fp.Pid) {
+			proc.OpenFdCount = -1
+			ioStat := unavailableIOStat
+			proc.IoStat = &ioStat
+		}
 		_, ok := procsByCtr[proc.ContainerId]
 		if !ok {
 			procsByCtr[proc.ContainerId] = make([]*model.Process, 0)
@@ -263,6 +269,10 @@ func formatIO(fp *process.FilledProcess, lastIO *process.IOCountersStat, before
 	if fp.IOStat == nil {
 		return &model.IOStat{}
 	}
+	// Or for the last run, if the IO stats of the process were not read then
+	if lastIO == nil {
+		return &model.IOStat{}
+	}
 
 	diff := time.Now().Unix() - before.Unix()
 	if before.IsZero() || diff <= 0 {
@@ -261,3 +261,58 @@ func floatEquals(a, b float32) bool {
 	var e float32 = 0.00000001 // Difference less than some epsilon
 	return a-b < e && b-a < e
 }
+
+func TestTopIOProcesses(t *testing.T) {
+	withUsage := func(pid int32, cpuTime float64, rss uint64) *process.FilledProcess {
+		p := makeProcess(pid, "proc")
+		p.CpuTime = cpu.TimesStat{User: cpuTime / 2, System: cpuTime / 2}
+		p.MemInfo = &process.MemoryInfoStat{RSS: rss}
+		p.IOStat = &process.IOCountersStat{ReadBytes: 100, WriteBytes: 100}
+		p.OpenFdCount = 10
+		return p
+	}
+	last := procsToHash([]*process.FilledProcess{
+		withUsage(1, 5, 100),
+		withUsage(2, 20, 100),
+		withUsage(3, 1, 500),
+		withUsage(4, 1, 100),
+	})
+	procs := procsToHash([]*process.FilledProcess{
+		withUsage(1, 10, 100),
+		withUsage(2, 30, 100),
+		withUsage(3, 2, 500), // least CPU, most memory
+		withUsage(4, 2, 100),
+		withUsage(5, 0, 900), // new process
+	})
+
+	assert.Nil(t, topIOProcesses(procs, 0))
+	assert.Nil(t, topIOProcesses(procs, 5))
+
+	top := topIOProcesses(procs, 3)
+	assert.Equal(t, map[int32]struct{}{1: {}, 2: {}, 3: {}}, top)
+	assert.True(t, reportsIOStats(top, 2))
+	assert.False(t, reportsIOStats(top, 4))
+	assert.True(t, reportsIOStats(nil, 4))
+
+	cfg := config.NewDefaultAgentConfig(false)
+	cfg.MaxIOStatsProcesses = 3
+	syst1, syst2 := cpu.TimesStat{}, cpu.TimesStat{}
+	procsByCtr := fmtProcesses(cfg, procs, last, nil, syst2, syst1, time.Now().Add(-2*time.Second))
+	for _, proc := range procsByCtr[emptyCtrID] {
+		if proc.Pid == 4 {
+			assert.Equal(t, int32(-1), proc.OpenFdCount)
+			assert.Equal(t, float32(-1), proc.IoStat.ReadBytesRate)
+		} else {
+			assert.NotEqual(t, int32(-1), proc.OpenFdCount)
+		}
+	}
+
+	// The IO stats of a process that was not in the top processes on the last run are not read then
+	last[3].IOStat = nil
+	procsByCtr = fmtProcesses(cfg, procs, last, nil, syst2, syst1, time.Now().Add(-2*time.Second))
+	for _, proc := range procsByCtr[emptyCtrID] {
+		if proc.Pid == 3 {
+			assert.Equal(t, &model.IOStat{}, proc.IoStat)
+		}
+	}
+}
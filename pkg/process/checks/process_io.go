@@ -0,0 +1,67 @@
+package checks
+
+import (
+	"sort"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/gopsutil/process"
+)
+
+// unavailableIOStat is reported for the processes whose IO stats are not
+// collected, -1 being used for rates that couldn't be read
+var unavailableIOStat = model.IOStat{
+	ReadRate:       -1,
+	WriteRate:      -1,
+	ReadBytesRate:  -1,
+	WriteBytesRate: -1,
+}
+
+// topIOProcesses returns the pids of the n processes using the most CPU time,
+// then the most memory, which report their IO stats and open file descriptors
+// count. These are cheap to collect, unlike the IO stats, so that the IO stats
+// of the other processes don't need to be read. It returns nil when the stats
+// of all processes should be reported.
+func topIOProcesses(procs map[int32]*process.FilledProcess, n int) map[int32]struct{} {
+	if n <= 0 || len(procs) <= n {
+		return nil
+	}
+
+	type procUsage struct {
+		pid     int32
+		cpuTime float64
+		rss     uint64
+	}
+	ranked := make([]procUsage, 0, len(procs))
+	for pid, fp := range procs {
+		usage := procUsage{pid: pid, cpuTime: fp.CpuTime.User + fp.CpuTime.System}
+		if fp.MemInfo != nil {
+			usage.rss = fp.MemInfo.RSS
+		}
+		ranked = append(ranked, usage)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].cpuTime != ranked[j].cpuTime {
+			return ranked[i].cpuTime > ranked[j].cpuTime
+		}
+		if ranked[i].rss != ranked[j].rss {
+			return ranked[i].rss > ranked[j].rss
+		}
+		return ranked[i].pid < ranked[j].pid
+	})
+
+	top := make(map[int32]struct{}, n)
+	for _, p := range ranked[:n] {
+		top[p.pid] = struct{}{}
+	}
+	return top
+}
+
+// reportsIOStats returns whether the IO stats and open file descriptors count
+// of a process are reported
+func reportsIOStats(topIO map[int32]struct{}, pid int32) bool {
+	if topIO == nil {
+		return true
+	}
+	_, found := topIO[pid]
+	return found
+}
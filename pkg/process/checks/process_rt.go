@@ -108,6 +108,8 @@ func fmtProcessStats(
 		}
 	}
 
+	topIO := topIOProcesses(procs, cfg.MaxIOStatsProcesses)
+
 	chunked := make([][]*model.ProcessStat, 0)
 	chunk := make([]*model.ProcessStat, 0, cfg.MaxPerMessage)
 	for _, fp := range procs {
@@ -115,7 +117,7 @@ func fmtProcessStats(
 			continue
 		}
 
-		chunk = append(chunk, &model.ProcessStat{
+		stat := &model.ProcessStat{
 			Pid:                    fp.Pid,
 			CreateTime:             fp.CreateTime,
 			Memory:                 formatMemory(fp),
@@ -128,7 +130,13 @@ func fmtProcessStats(
 			VoluntaryCtxSwitches:   uint64(fp.CtxSwitches.Voluntary),
 			InvoluntaryCtxSwitches: uint64(fp.CtxSwitches.Involuntary),
 			ContainerId:            cidByPid[fp.Pid],
-		})
+		}
+		if !reportsIOStats(topIO, fp.Pid) {
+			stat.OpenFdCount = -1
+			ioStat := unavailableIOStat
+			stat.IoStat = &ioStat
+		}
+		chunk = append(chunk, stat)
 		if len(chunk) == cfg.MaxPerMessage {
 			chunked = append(chunked, chunk)
 			chunk = make([]*model.ProcessStat, 0, cfg.MaxPerMessage)
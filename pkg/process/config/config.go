@@ -16,6 +16,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/network/tracepath"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
@@ -61,6 +62,7 @@ type AgentConfig struct {
 	Scrubber              *DataScrubber
 	MaxPerMessage         int
 	MaxConnsPerMessage    int
+	MaxIOStatsProcesses   int // The number of processes using the most CPU and memory reporting IO stats and open fds, 0 for all
 	AllowRealTime         bool
 	Transport             *http.Transport `json:"-"`
 	DDAgentBin            string
@@ -85,6 +87,7 @@ type AgentConfig struct {
 	ExcludedSourceConnections      map[string][]string
 	ExcludedDestinationConnections map[string][]string
 	EnableConntrack                bool
+	ConntrackMode                  string
 	ConntrackMaxStateSize          int
 	ConntrackRateLimit             int
 	SystemProbeDebugPort           int
@@ -107,6 +110,9 @@ type AgentConfig struct {
 	// Internal store of a proxy used for generating the Transport
 	proxy proxyFunc
 
+	// Network path tracing configuration
+	NetworkPath tracepath.Config
+
 	// Windows-specific config
 	Windows WindowsConfig
 }
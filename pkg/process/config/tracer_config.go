@@ -56,6 +56,13 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 	tracerConfig.ProcRoot = util.GetProcRoot()
 	tracerConfig.BPFDebug = cfg.SysProbeBPFDebug
 	tracerConfig.EnableConntrack = cfg.EnableConntrack
+	switch cfg.ConntrackMode {
+	case "":
+	case ebpf.ConntrackModeNetlink, ebpf.ConntrackModeEBPF, ebpf.ConntrackModeAuto:
+		tracerConfig.ConntrackMode = cfg.ConntrackMode
+	default:
+		log.Warnf("unknown conntrack mode %q, using %s", cfg.ConntrackMode, tracerConfig.ConntrackMode)
+	}
 	tracerConfig.ConntrackMaxStateSize = cfg.ConntrackMaxStateSize
 	tracerConfig.DebugPort = cfg.SystemProbeDebugPort
 
@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/network/tracepath"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
@@ -73,6 +74,9 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 	if config.Datadog.IsSet(key(spNS, "enable_conntrack")) {
 		a.EnableConntrack = config.Datadog.GetBool(key(spNS, "enable_conntrack"))
 	}
+	if mode := config.Datadog.GetString(key(spNS, "conntrack_mode")); mode != "" {
+		a.ConntrackMode = mode
+	}
 	if s := config.Datadog.GetInt(key(spNS, "conntrack_max_state_size")); s > 0 {
 		a.ConntrackMaxStateSize = s
 	}
@@ -80,6 +84,20 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.ConntrackRateLimit = config.Datadog.GetInt(key(spNS, "conntrack_rate_limit"))
 	}
 
+	// Network path tracing toward critical endpoints
+	for _, raw := range config.Datadog.GetStringSlice(key(spNS, "network_path", "targets")) {
+		target, err := tracepath.ParseTarget(raw)
+		if err != nil {
+			log.Warnf("Ignoring network path target: %s", err)
+			continue
+		}
+		a.NetworkPath.Targets = append(a.NetworkPath.Targets, target)
+	}
+	a.NetworkPath.Protocol = config.Datadog.GetString(key(spNS, "network_path", "protocol"))
+	a.NetworkPath.MaxHops = config.Datadog.GetInt(key(spNS, "network_path", "max_hops"))
+	a.NetworkPath.Timeout = time.Duration(config.Datadog.GetInt(key(spNS, "network_path", "timeout_ms"))) * time.Millisecond
+	a.NetworkPath.Interval = time.Duration(config.Datadog.GetInt(key(spNS, "network_path", "interval"))) * time.Second
+
 	if logFile := config.Datadog.GetString(key(spNS, "log_file")); logFile != "" {
 		a.LogFile = logFile
 	}
@@ -281,6 +299,15 @@ func (a *AgentConfig) LoadProcessYamlConfig(path string) error {
 		}
 	}
 
+	// Limits the IO stats and open file descriptors count to the processes using the most CPU and memory.
+	if k := key(ns, "max_io_stats_processes"); config.Datadog.IsSet(k) {
+		if maxIOStatsProcesses := config.Datadog.GetInt(k); maxIOStatsProcesses < 0 {
+			log.Warn("Invalid number of processes reporting IO stats (< 0), ignoring...")
+		} else {
+			a.MaxIOStatsProcesses = maxIOStatsProcesses
+		}
+	}
+
 	// Overrides the path to the Agent bin used for getting the hostname. The default is usually fine.
 	a.DDAgentBin = defaultDDAgentBin
 	if k := key(ns, "dd_agent_bin"); config.Datadog.IsSet(k) {
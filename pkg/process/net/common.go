@@ -137,6 +137,44 @@ func (r *RemoteSysProbeUtil) GetStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// TriggerNetworkPath starts tracing the network paths toward the targets
+// configured in system-probe. It returns false if a trace is already running.
+func (r *RemoteSysProbeUtil) TriggerNetworkPath() (bool, error) {
+	resp, err := r.httpClient.Post(networkPathTriggerURL, "", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+	return false, fmt.Errorf("network path trigger failed: Path %s, url: %s, status code: %d", r.path, networkPathTriggerURL, resp.StatusCode)
+}
+
+// IsNetworkPathRunning returns whether system-probe is tracing the network paths
+func (r *RemoteSysProbeUtil) IsNetworkPathRunning() (bool, error) {
+	resp, err := r.httpClient.Get(networkPathURL)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("network path request failed: Path %s, url: %s, status code: %d", r.path, networkPathURL, resp.StatusCode)
+	}
+
+	var status struct {
+		Running bool `json:"running"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, err
+	}
+	return status.Running, nil
+}
+
 func newSystemProbe() *RemoteSysProbeUtil {
 	return &RemoteSysProbeUtil{
 		path: globalSocketPath,
@@ -8,10 +8,12 @@ import (
 )
 
 const (
-	statusURL      = "http://unix/status"
-	connectionsURL = "http://unix/connections"
-	statsURL       = "http://unix/debug/stats"
-	netType        = "unix"
+	statusURL             = "http://unix/status"
+	connectionsURL        = "http://unix/connections"
+	statsURL              = "http://unix/debug/stats"
+	networkPathURL        = "http://unix/network_path"
+	networkPathTriggerURL = "http://unix/network_path/trigger"
+	netType               = "unix"
 )
 
 // CheckPath is used in conjunction with calling the stats endpoint, since we are calling this
@@ -34,3 +34,13 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) (*model.Connections
 func (r *RemoteSysProbeUtil) GetStats() (map[string]interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
 }
+
+// TriggerNetworkPath is not supported
+func (r *RemoteSysProbeUtil) TriggerNetworkPath() (bool, error) {
+	return false, ebpf.ErrNotImplemented
+}
+
+// IsNetworkPathRunning is not supported
+func (r *RemoteSysProbeUtil) IsNetworkPathRunning() (bool, error) {
+	return false, ebpf.ErrNotImplemented
+}
@@ -5,10 +5,12 @@ package net
 import "fmt"
 
 const (
-	statusURL      = "http://localhost:3333/status"
-	connectionsURL = "http://localhost:3333/connections"
-	statsURL       = "http://localhost:3333/debug/stats"
-	netType        = "tcp"
+	statusURL             = "http://localhost:3333/status"
+	connectionsURL        = "http://localhost:3333/connections"
+	statsURL              = "http://localhost:3333/debug/stats"
+	networkPathURL        = "http://localhost:3333/network_path"
+	networkPathTriggerURL = "http://localhost:3333/network_path/trigger"
+	netType               = "tcp"
 )
 
 // CheckPath is used to make sure the globalSocketPath has been set before attempting to connect
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package sbom
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package types
+const (
+	PackageTypeDeb    = "deb"
+	PackageTypeApk    = "apk"
+	PackageTypePython = "python"
+)
+
+// Package is a package installed in an image
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+}
+
+// cataloger lists the packages of a kind installed in a filesystem
+type cataloger func(root string) ([]Package, error)
+
+var catalogers = []cataloger{
+	catalogDpkg,
+	catalogApk,
+	catalogPython,
+}
+
+// Catalog lists the packages installed in the filesystem mounted at root
+func Catalog(root string) ([]Package, error) {
+	var packages []Package
+	for _, c := range catalogers {
+		found, err := c(root)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, found...)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Type != packages[j].Type {
+			return packages[i].Type < packages[j].Type
+		}
+		return packages[i].Name < packages[j].Name
+	})
+	return packages, nil
+}
+
+// catalogDpkg parses the dpkg database, made of paragraphs of "Key: value"
+// lines separated by empty lines
+func catalogDpkg(root string) ([]Package, error) {
+	var packages []Package
+	err := readParagraphs(filepath.Join(root, "var/lib/dpkg/status"), ": ", func(fields map[string]string) {
+		// Removed packages whose configuration files are kept are listed too
+		if !strings.HasSuffix(fields["Status"], " installed") {
+			return
+		}
+		packages = append(packages, Package{Name: fields["Package"], Version: fields["Version"], Type: PackageTypeDeb})
+	})
+	return packages, err
+}
+
+// catalogApk parses the apk database, made of paragraphs of "K:value" lines
+func catalogApk(root string) ([]Package, error) {
+	var packages []Package
+	err := readParagraphs(filepath.Join(root, "lib/apk/db/installed"), ":", func(fields map[string]string) {
+		packages = append(packages, Package{Name: fields["P"], Version: fields["V"], Type: PackageTypeApk})
+	})
+	return packages, err
+}
+
+// catalogPython reads the metadata of the distributions installed in the
+// site-packages and dist-packages directories
+func catalogPython(root string) ([]Package, error) {
+	var packages []Package
+	for _, pattern := range []string{
+		"usr/lib/python*/*-packages/*.dist-info/METADATA",
+		"usr/local/lib/python*/*-packages/*.dist-info/METADATA",
+		"usr/lib/python*/*-packages/*.egg-info/PKG-INFO",
+		"usr/local/lib/python*/*-packages/*.egg-info/PKG-INFO",
+	} {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			// The headers end at the first empty line, followed by the description
+			var fields map[string]string
+			err := readParagraphs(path, ": ", func(f map[string]string) {
+				if fields == nil {
+					fields = f
+				}
+			})
+			if err != nil || fields["Name"] == "" {
+				continue
+			}
+			packages = append(packages, Package{Name: fields["Name"], Version: fields["Version"], Type: PackageTypePython})
+		}
+	}
+	return packages, nil
+}
+
+// readParagraphs calls fn with the fields of every paragraph of a file, a
+// missing file having no paragraph. Continuation lines are ignored.
+func readParagraphs(path string, separator string, fn func(map[string]string)) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	return parseParagraphs(f, separator, fn)
+}
+
+func parseParagraphs(r io.Reader, separator string, fn func(map[string]string)) error {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				fn(fields)
+				fields = make(map[string]string)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if idx := strings.Index(line, separator); idx > 0 {
+			fields[line[:idx]] = strings.TrimSpace(line[idx+len(separator):])
+		}
+	}
+	if len(fields) > 0 {
+		fn(fields)
+	}
+	return scanner.Err()
+}
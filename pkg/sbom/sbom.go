@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package sbom generates the software bill of materials of the images of the
+// running containers, listing the packages they contain so that they can be
+// matched against vulnerability databases
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// SBOM is the inventory of the packages of an image
+type SBOM struct {
+	ImageID     string    `json:"image_id"`
+	ImageName   string    `json:"image_name"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Packages    []Package `json:"packages"`
+}
+
+// Container is a running container whose image gets an SBOM
+type Container struct {
+	ID        string
+	ImageID   string
+	ImageName string
+	// Pid is a process of the container, whose root is the container filesystem
+	Pid int32
+}
+
+// Cache records the images whose SBOM was sent, to survive restarts
+type Cache interface {
+	Read(key string) (string, error)
+	Write(key, value string) error
+}
+
+// Sender sends an SBOM to the intake
+type Sender interface {
+	Send(sbom *SBOM) error
+}
+
+// Generator generates the SBOM of the images of the containers, once per
+// image digest and at a limited rate, the filesystem walk being expensive
+type Generator struct {
+	procRoot string
+	ttl      time.Duration
+	cache    Cache
+	sender   Sender
+	limiter  *rate.Limiter
+	sent     map[string]time.Time // maps the image ids to the time their SBOM was sent
+	now      func() time.Time
+}
+
+// NewGenerator returns a Generator sending at most maxPerMinute SBOMs per
+// minute, the SBOM of an image being sent again once ttl expired
+func NewGenerator(procRoot string, ttl time.Duration, maxPerMinute int, cache Cache, sender Sender) *Generator {
+	if maxPerMinute <= 0 {
+		maxPerMinute = 1
+	}
+	return &Generator{
+		procRoot: procRoot,
+		ttl:      ttl,
+		cache:    cache,
+		sender:   sender,
+		limiter:  rate.NewLimiter(rate.Every(time.Minute/time.Duration(maxPerMinute)), maxPerMinute),
+		sent:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Process generates and sends the SBOM of the images of the containers
+// unless they were sent recently. The images skipped by the rate limiter are
+// processed by a later call.
+func (g *Generator) Process(containers []Container) {
+	for _, c := range containers {
+		if c.ImageID == "" || c.Pid <= 0 || !g.needsSBOM(c.ImageID) {
+			continue
+		}
+		if !g.limiter.AllowN(g.now(), 1) {
+			log.Debugf("SBOM generation rate limit reached, postponing the remaining images")
+			return
+		}
+		if err := g.generate(c); err != nil {
+			log.Warnf("Could not generate the SBOM of image %s: %v", c.ImageName, err)
+		}
+	}
+}
+
+func (g *Generator) needsSBOM(imageID string) bool {
+	sentAt, found := g.sent[imageID]
+	if !found {
+		if value, err := g.cache.Read(cacheKey(imageID)); err == nil && value != "" {
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				sentAt, found = time.Unix(ts, 0), true
+				g.sent[imageID] = sentAt
+			}
+		}
+	}
+	return !found || g.now().Sub(sentAt) >= g.ttl
+}
+
+func (g *Generator) generate(c Container) error {
+	// The container may have exited since it was listed
+	root := filepath.Join(g.procRoot, strconv.Itoa(int(c.Pid)), "root")
+	if _, err := os.Stat(root); err != nil {
+		return err
+	}
+	packages, err := Catalog(root)
+	if err != nil {
+		return err
+	}
+
+	now := g.now()
+	err = g.sender.Send(&SBOM{
+		ImageID:     c.ImageID,
+		ImageName:   c.ImageName,
+		GeneratedAt: now,
+		Packages:    packages,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send SBOM: %v", err)
+	}
+
+	g.sent[c.ImageID] = now
+	if err := g.cache.Write(cacheKey(c.ImageID), strconv.FormatInt(now.Unix(), 10)); err != nil {
+		log.Debugf("Could not cache the SBOM of image %s: %v", c.ImageName, err)
+	}
+	return nil
+}
+
+// cacheKey returns the persistent cache key of an image, stored in the sbom
+// directory of the run path
+func cacheKey(imageID string) string {
+	return "sbom:" + imageID
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package sbom
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dpkgStatus = `Package: libc6
+Status: install ok installed
+Version: 2.31-0ubuntu9
+Description: GNU C Library
+ Contains the standard libraries.
+
+Package: openssl
+Status: deinstall ok config-files
+Version: 1.1.1f-1ubuntu2
+
+Package: bash
+Status: install ok installed
+Version: 5.0-6ubuntu1
+`
+
+const apkInstalled = `C:Q1abc=
+P:musl
+V:1.1.24-r9
+A:x86_64
+
+C:Q1def=
+P:busybox
+V:1.31.1-r19
+`
+
+const pythonMetadata = `Metadata-Version: 2.1
+Name: requests
+Version: 2.24.0
+
+Version: not a header
+`
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	root, err := ioutil.TempDir("", "sbom")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"var/lib/dpkg/status":  dpkgStatus,
+		"lib/apk/db/installed": apkInstalled,
+		"usr/lib/python3.8/site-packages/requests-2.24.0.dist-info/METADATA": pythonMetadata,
+	})
+
+	packages, err := Catalog(root)
+	require.NoError(t, err)
+	assert.Equal(t, []Package{
+		{Name: "busybox", Version: "1.31.1-r19", Type: PackageTypeApk},
+		{Name: "musl", Version: "1.1.24-r9", Type: PackageTypeApk},
+		{Name: "bash", Version: "5.0-6ubuntu1", Type: PackageTypeDeb},
+		{Name: "libc6", Version: "2.31-0ubuntu9", Type: PackageTypeDeb},
+		{Name: "requests", Version: "2.24.0", Type: PackageTypePython},
+	}, packages)
+
+	// An image without package database has no package
+	empty, err := ioutil.TempDir("", "sbom")
+	require.NoError(t, err)
+	defer os.RemoveAll(empty)
+	packages, err = Catalog(empty)
+	require.NoError(t, err)
+	assert.Empty(t, packages)
+}
+
+type mapCache map[string]string
+
+func (c mapCache) Read(key string) (string, error) { return c[key], nil }
+
+func (c mapCache) Write(key, value string) error {
+	c[key] = value
+	return nil
+}
+
+type recordingSender struct {
+	sboms []*SBOM
+}
+
+func (s *recordingSender) Send(sbom *SBOM) error {
+	s.sboms = append(s.sboms, sbom)
+	return nil
+}
+
+func TestGenerator(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "sbom")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeFiles(t, procRoot, map[string]string{
+		"10/root/var/lib/dpkg/status":  dpkgStatus,
+		"20/root/lib/apk/db/installed": apkInstalled,
+		"30/root/lib/apk/db/installed": apkInstalled,
+		"12/root/etc/os-release":       "ID=debian\n",
+	})
+
+	now := time.Unix(1600000000, 0)
+	cache := mapCache{"sbom:sha256:cached": "1599999000"}
+	sender := &recordingSender{}
+	g := NewGenerator(procRoot, time.Hour, 2, cache, sender)
+	g.now = func() time.Time { return now }
+
+	containers := []Container{
+		{ID: "a", ImageID: "sha256:ubuntu", ImageName: "ubuntu:20.04", Pid: 10},
+		{ID: "b", ImageID: "sha256:ubuntu", ImageName: "ubuntu:20.04", Pid: 11},
+		{ID: "c", ImageID: "sha256:cached", ImageName: "redis:6", Pid: 12},
+		{ID: "d", ImageID: "sha256:alpine", ImageName: "alpine:3.12", Pid: 20},
+		{ID: "e", ImageID: "sha256:nginx", ImageName: "nginx:1.19", Pid: 30},
+	}
+
+	// The image of the second container was already processed and the third
+	// one is in the cache, the last one being postponed by the rate limiter
+	g.Process(containers)
+	require.Len(t, sender.sboms, 2)
+	assert.Equal(t, "ubuntu:20.04", sender.sboms[0].ImageName)
+	assert.Len(t, sender.sboms[0].Packages, 2)
+	assert.Equal(t, "alpine:3.12", sender.sboms[1].ImageName)
+	assert.Equal(t, "1600000000", cache["sbom:sha256:alpine"])
+
+	now = now.Add(30 * time.Second)
+	g.Process(containers)
+	require.Len(t, sender.sboms, 3)
+	assert.Equal(t, "nginx:1.19", sender.sboms[2].ImageName)
+
+	// SBOMs are sent again once expired
+	now = now.Add(time.Hour)
+	g.Process(containers)
+	require.Len(t, sender.sboms, 5)
+	assert.Equal(t, "ubuntu:20.04", sender.sboms[3].ImageName)
+	assert.Equal(t, "redis:6", sender.sboms[4].ImageName)
+}
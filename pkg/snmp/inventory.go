@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package snmp
+
+import (
+	"sync"
+)
+
+// Device is an SNMP device discovered by the SNMP listener
+type Device struct {
+	IP           string
+	Network      string
+	ADIdentifier string
+}
+
+// Tags returns the tags describing the device
+func (d Device) Tags() []string {
+	return []string{
+		"snmp_device:" + d.IP,
+		"autodiscovery_subnet:" + d.Network,
+		"ad_identifier:" + d.ADIdentifier,
+	}
+}
+
+var (
+	inventory     = make(map[string]Device)
+	inventoryLock sync.RWMutex
+)
+
+// AddDevice adds a discovered device to the inventory
+func AddDevice(d Device) {
+	inventoryLock.Lock()
+	defer inventoryLock.Unlock()
+	inventory[d.IP] = d
+}
+
+// RemoveDevice removes a device from the inventory
+func RemoveDevice(ip string) {
+	inventoryLock.Lock()
+	defer inventoryLock.Unlock()
+	delete(inventory, ip)
+}
+
+// GetDevice returns the discovered device of an IP address
+func GetDevice(ip string) (Device, bool) {
+	inventoryLock.RLock()
+	defer inventoryLock.RUnlock()
+	d, found := inventory[ip]
+	return d, found
+}
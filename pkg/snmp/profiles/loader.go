@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// LoadedProfile is a profile read from a profile directory
+type LoadedProfile struct {
+	Profile
+	Name string
+	Path string
+	// Overrides is the path of the profile of the same name it replaces
+	Overrides string
+}
+
+// Abstract returns whether the profile is only meant to be extended, the
+// names of the base profiles start with an underscore
+func (p *LoadedProfile) Abstract() bool {
+	return strings.HasPrefix(p.Name, "_")
+}
+
+// Directories returns the profile directories in increasing precedence: the
+// profiles installed with the SNMP integration, then the user directories
+func Directories() []string {
+	dirs := []string{filepath.Join(config.Datadog.GetString("confd_path"), "snmp.d", "profiles")}
+	return append(dirs, config.Datadog.GetStringSlice("snmp_profiles.user_directories")...)
+}
+
+// Load reads the profiles of the directories, a profile replaces the profile
+// of the same name of a previous directory. The extends of the profiles are
+// not resolved.
+func Load(dirs []string) (map[string]*LoadedProfile, error) {
+	profiles := make(map[string]*LoadedProfile)
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			p := &LoadedProfile{
+				Name: strings.TrimSuffix(filepath.Base(path), ".yaml"),
+				Path: path,
+			}
+			if err := yaml.Unmarshal(content, &p.Profile); err != nil {
+				return nil, fmt.Errorf("unable to parse the profile %s: %v", path, err)
+			}
+			if previous, found := profiles[p.Name]; found {
+				p.Overrides = previous.Path
+			}
+			profiles[p.Name] = p
+		}
+	}
+	return profiles, nil
+}
+
+// Resolve returns the profile with the metrics and metric tags of the
+// profiles it extends, recursively. The metrics of the base profiles come
+// first, the sysObjectIDs are not inherited.
+func Resolve(profiles map[string]*LoadedProfile, name string) (*Profile, error) {
+	return resolve(profiles, name, nil)
+}
+
+func resolve(profiles map[string]*LoadedProfile, name string, stack []string) (*Profile, error) {
+	for _, visited := range stack {
+		if visited == name {
+			return nil, fmt.Errorf("cyclic extends: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+	p, found := profiles[name]
+	if !found {
+		if len(stack) == 0 {
+			return nil, fmt.Errorf("unknown profile %s", name)
+		}
+		return nil, fmt.Errorf("profile %s extends the unknown profile %s", stack[len(stack)-1], name)
+	}
+	stack = append(stack, name)
+
+	resolved := &Profile{SysObjectIDs: p.SysObjectIDs}
+	for _, base := range p.Extends {
+		baseProfile, err := resolve(profiles, strings.TrimSuffix(base, ".yaml"), stack)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Metrics = append(resolved.Metrics, baseProfile.Metrics...)
+		resolved.MetricTags = append(resolved.MetricTags, baseProfile.MetricTags...)
+	}
+	resolved.Metrics = append(resolved.Metrics, p.Metrics...)
+	resolved.MetricTags = append(resolved.MetricTags, p.MetricTags...)
+	return resolved, nil
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sysObjectIDPattern is a sysObjectID or a pattern with '*' components
+// matching any sequence of components
+type sysObjectIDPattern struct {
+	components []string
+}
+
+func parsePattern(pattern string) (sysObjectIDPattern, error) {
+	pattern = strings.TrimPrefix(pattern, ".")
+	if pattern == "" {
+		return sysObjectIDPattern{}, fmt.Errorf("empty sysobjectid")
+	}
+	components := strings.Split(pattern, ".")
+	for _, c := range components {
+		if c == "*" {
+			continue
+		}
+		if c == "" || strings.Trim(c, "0123456789") != "" {
+			return sysObjectIDPattern{}, fmt.Errorf("invalid sysobjectid %q", pattern)
+		}
+	}
+	return sysObjectIDPattern{components: components}, nil
+}
+
+func (p sysObjectIDPattern) String() string {
+	return strings.Join(p.components, ".")
+}
+
+// literals returns the number of non wildcard components
+func (p sysObjectIDPattern) literals() int {
+	n := 0
+	for _, c := range p.components {
+		if c != "*" {
+			n++
+		}
+	}
+	return n
+}
+
+func (p sysObjectIDPattern) exact() bool {
+	return p.literals() == len(p.components)
+}
+
+// precedence orders the patterns matching a sysObjectID: an exact
+// sysObjectID first, then the patterns with the most literal components
+func (p sysObjectIDPattern) precedence() int {
+	if p.exact() {
+		return 1 << 16
+	}
+	return p.literals()
+}
+
+func (p sysObjectIDPattern) match(sysObjectID string) bool {
+	return matchComponents(p.components, strings.Split(strings.TrimPrefix(sysObjectID, "."), "."))
+}
+
+func matchComponents(pattern, oid []string) bool {
+	if len(pattern) == 0 {
+		return len(oid) == 0
+	}
+	if pattern[0] == "*" {
+		// the wildcard matches at least one component
+		for i := 1; i <= len(oid); i++ {
+			if matchComponents(pattern[1:], oid[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(oid) > 0 && pattern[0] == oid[0] && matchComponents(pattern[1:], oid[1:])
+}
+
+// Match returns the name of the profile of a sysObjectID. The profile with
+// the matching pattern of highest precedence wins, it is an error when
+// several profiles match with the same precedence.
+func Match(profiles map[string]*LoadedProfile, sysObjectID string) (string, error) {
+	best := -1
+	matched := make(map[string]bool)
+	for name, p := range profiles {
+		if p.Abstract() {
+			continue
+		}
+		for _, raw := range p.SysObjectIDs {
+			pattern, err := parsePattern(raw)
+			if err != nil || !pattern.match(sysObjectID) {
+				continue
+			}
+			switch precedence := pattern.precedence(); {
+			case precedence > best:
+				best, matched = precedence, map[string]bool{name: true}
+			case precedence == best:
+				matched[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no profile matches the sysobjectid %s", sysObjectID)
+	case 1:
+		return names[0], nil
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("the profiles %s match the sysobjectid %s with the same precedence", strings.Join(names, ", "), sysObjectID)
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiles
+
+// Profile is an SNMP device profile, as read by the SNMP integration
+type Profile struct {
+	Extends      []string          `yaml:"extends"`
+	SysObjectIDs StringArray       `yaml:"sysobjectid"`
+	Metrics      []MetricsConfig   `yaml:"metrics"`
+	MetricTags   []MetricTagConfig `yaml:"metric_tags"`
+}
+
+// MetricsConfig is a scalar symbol or a table of symbols to collect
+type MetricsConfig struct {
+	MIB        string            `yaml:"MIB"`
+	Symbol     SymbolConfig      `yaml:"symbol"`
+	Table      SymbolConfig      `yaml:"table"`
+	Symbols    []SymbolConfig    `yaml:"symbols"`
+	MetricTags []MetricTagConfig `yaml:"metric_tags"`
+	ForcedType string            `yaml:"forced_type"`
+}
+
+// SymbolConfig is an OID and its name
+type SymbolConfig struct {
+	OID  string `yaml:"OID"`
+	Name string `yaml:"name"`
+}
+
+// MetricTagConfig is a tag of the metrics, read from a symbol, a table
+// column or a table index
+type MetricTagConfig struct {
+	Tag    string       `yaml:"tag"`
+	OID    string       `yaml:"OID"`
+	Symbol string       `yaml:"symbol"`
+	Column SymbolConfig `yaml:"column"`
+	Index  uint         `yaml:"index"`
+}
+
+// StringArray is a list of strings that can be written as a single string
+type StringArray []string
+
+// UnmarshalYAML unmarshals a string or a list of strings
+func (a *StringArray) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var multi []string
+	if err := unmarshal(&multi); err == nil {
+		*a = multi
+		return nil
+	}
+	var single string
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*a = StringArray{single}
+	return nil
+}
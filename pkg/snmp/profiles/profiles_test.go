@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProfiles(t *testing.T, dir string, profiles map[string]string) {
+	for name, content := range profiles {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0644))
+	}
+}
+
+func loadTestProfiles(t *testing.T, bundled, user map[string]string) map[string]*LoadedProfile {
+	root, err := ioutil.TempDir("", "profiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	bundledDir, userDir := filepath.Join(root, "bundled"), filepath.Join(root, "user")
+	require.NoError(t, os.Mkdir(bundledDir, 0755))
+	require.NoError(t, os.Mkdir(userDir, 0755))
+	writeProfiles(t, bundledDir, bundled)
+	writeProfiles(t, userDir, user)
+
+	profiles, err := Load([]string{bundledDir, userDir})
+	require.NoError(t, err)
+	return profiles
+}
+
+const baseProfile = `
+metrics:
+  - MIB: SNMPv2-MIB
+    symbol:
+      OID: 1.3.6.1.2.1.1.3.0
+      name: sysUpTimeInstance
+metric_tags:
+  - OID: 1.3.6.1.2.1.1.5.0
+    symbol: sysName
+    tag: snmp_host
+`
+
+func TestLoadAndResolve(t *testing.T) {
+	profiles := loadTestProfiles(t, map[string]string{
+		"_base": baseProfile,
+		"cisco": `
+extends:
+  - _base.yaml
+sysobjectid: 1.3.6.1.4.1.9.*
+metrics:
+  - MIB: CISCO-PROCESS-MIB
+    symbol:
+      OID: 1.3.6.1.4.1.9.9.109.1.1.1.1.12
+      name: cpmCPUMemoryUsed
+`,
+	}, map[string]string{
+		"cisco": `
+extends:
+  - _base.yaml
+sysobjectid:
+  - 1.3.6.1.4.1.9.*
+  - 1.3.6.1.4.1.9.1.1745
+metrics:
+  - MIB: IF-MIB
+    table:
+      OID: 1.3.6.1.2.1.2.2
+      name: ifTable
+    symbols:
+      - OID: 1.3.6.1.2.1.2.2.1.14
+        name: ifInErrors
+    metric_tags:
+      - tag: interface
+        column:
+          OID: 1.3.6.1.2.1.31.1.1.1.1
+          name: ifName
+`,
+	})
+
+	require.Len(t, profiles, 2)
+	assert.True(t, profiles["_base"].Abstract())
+	cisco := profiles["cisco"]
+	assert.Contains(t, cisco.Path, "user")
+	assert.Contains(t, cisco.Overrides, "bundled")
+	assert.Equal(t, StringArray{"1.3.6.1.4.1.9.*", "1.3.6.1.4.1.9.1.1745"}, cisco.SysObjectIDs)
+
+	resolved, err := Resolve(profiles, "cisco")
+	require.NoError(t, err)
+	require.Len(t, resolved.Metrics, 2)
+	assert.Equal(t, "sysUpTimeInstance", resolved.Metrics[0].Symbol.Name)
+	assert.Equal(t, "ifTable", resolved.Metrics[1].Table.Name)
+	require.Len(t, resolved.MetricTags, 1)
+	assert.Equal(t, "snmp_host", resolved.MetricTags[0].Tag)
+}
+
+func TestResolveErrors(t *testing.T) {
+	profiles := loadTestProfiles(t, map[string]string{
+		"a":       "extends: [b.yaml]",
+		"b":       "extends: [a.yaml]",
+		"missing": "extends: [_unknown.yaml]",
+	}, nil)
+
+	_, err := Resolve(profiles, "a")
+	assert.EqualError(t, err, "cyclic extends: a -> b -> a")
+	_, err = Resolve(profiles, "missing")
+	assert.EqualError(t, err, "profile missing extends the unknown profile _unknown")
+}
+
+func TestMatch(t *testing.T) {
+	profiles := map[string]*LoadedProfile{
+		"_base":         {Name: "_base", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.9.1.1745"}}},
+		"generic":       {Name: "generic", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.*"}}},
+		"cisco":         {Name: "cisco", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.9.*"}}},
+		"cisco-3850":    {Name: "cisco-3850", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.9.1.1745"}}},
+		"cisco-asa":     {Name: "cisco-asa", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.9.1.*.5"}}},
+		"cisco-asa-bis": {Name: "cisco-asa-bis", Profile: Profile{SysObjectIDs: StringArray{"1.3.6.1.4.1.9.*.2.5"}}},
+	}
+
+	for sysObjectID, expected := range map[string]string{
+		"1.3.6.1.4.1.9.1.1745":  "cisco-3850",
+		".1.3.6.1.4.1.9.1.1746": "cisco",
+		"1.3.6.1.4.1.2636.1":    "generic",
+		"1.3.6.1.4.1.9.1.3.5":   "cisco-asa",
+	} {
+		name, err := Match(profiles, sysObjectID)
+		require.NoError(t, err, sysObjectID)
+		assert.Equal(t, expected, name, sysObjectID)
+	}
+
+	_, err := Match(profiles, "1.3.6.1.2.1")
+	assert.Error(t, err)
+	_, err = Match(profiles, "1.3.6.1.4.1.9.1.2.5")
+	assert.EqualError(t, err, "the profiles cisco-asa, cisco-asa-bis match the sysobjectid 1.3.6.1.4.1.9.1.2.5 with the same precedence")
+}
+
+func TestValidate(t *testing.T) {
+	profiles := loadTestProfiles(t, map[string]string{
+		"_base": baseProfile,
+		"good": `
+extends: [_base.yaml]
+sysobjectid: 1.3.6.1.4.1.8072.3.2.10
+`,
+		"conflicting": `
+sysobjectid: 1.3.6.1.4.1.8072.3.2.10
+metric_tags:
+  - symbol: sysName
+    tag: snmp_host
+`,
+		"broken": `
+extends: [_missing.yaml]
+sysobjectid: 1.3.6.a
+`,
+		"incomplete": `
+sysobjectid: 1.3.6.1.4.1.2636.*
+metrics:
+  - MIB: IF-MIB
+    table:
+      name: ifTable
+    symbols:
+      - name: ifInErrors
+    metric_tags:
+      - tag: interface
+        column:
+          name: ifName
+  - MIB: IF-MIB
+    symbol:
+      name: ifNumber
+  - MIB: IF-MIB
+`,
+	}, nil)
+
+	var messages []string
+	for _, issue := range Validate(profiles) {
+		messages = append(messages, issue.Profile+": "+issue.Message)
+	}
+	assert.Equal(t, []string{
+		`broken: invalid sysobjectid "1.3.6.a"`,
+		"broken: profile broken extends the unknown profile _missing",
+		"conflicting: the metric tag snmp_host has no OID",
+		"incomplete: the table ifTable of IF-MIB has no OID",
+		"incomplete: the symbol ifInErrors of the table ifTable has no OID",
+		"incomplete: the metric tag interface of the table ifTable has no column OID nor index",
+		"incomplete: the symbol ifNumber of IF-MIB has no OID",
+		"incomplete: a metric of IF-MIB has no symbol nor table",
+		"conflicting: the sysobjectid 1.3.6.1.4.1.8072.3.2.10 conflicts with the profiles conflicting, good",
+		"good: the sysobjectid 1.3.6.1.4.1.8072.3.2.10 conflicts with the profiles conflicting, good",
+	}, messages)
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem of a profile found by Validate
+type Issue struct {
+	Profile string
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s (%s): %s", i.Profile, i.Path, i.Message)
+}
+
+// Validate reports the profiles whose extends cannot be resolved, the
+// invalid and conflicting sysObjectIDs, and the symbols and tags without OID
+func Validate(profiles map[string]*LoadedProfile) []Issue {
+	var issues []Issue
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the profiles owning each sysObjectID pattern
+	owners := make(map[string][]string)
+	for _, name := range names {
+		p := profiles[name]
+		report := func(format string, args ...interface{}) {
+			issues = append(issues, Issue{Profile: name, Path: p.Path, Message: fmt.Sprintf(format, args...)})
+		}
+
+		for _, raw := range p.SysObjectIDs {
+			pattern, err := parsePattern(raw)
+			if err != nil {
+				report("%v", err)
+				continue
+			}
+			if !p.Abstract() {
+				owners[pattern.String()] = append(owners[pattern.String()], name)
+			}
+		}
+
+		resolved, err := Resolve(profiles, name)
+		if err != nil {
+			report("%v", err)
+			continue
+		}
+		for _, m := range resolved.Metrics {
+			for _, message := range validateMetric(m) {
+				report("%s", message)
+			}
+		}
+		for _, tag := range resolved.MetricTags {
+			if tag.OID == "" {
+				report("the metric tag %s has no OID", tagName(tag))
+			}
+		}
+	}
+
+	patterns := make([]string, 0, len(owners))
+	for pattern := range owners {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if owned := owners[pattern]; len(owned) > 1 {
+			for _, name := range owned {
+				issues = append(issues, Issue{
+					Profile: name,
+					Path:    profiles[name].Path,
+					Message: fmt.Sprintf("the sysobjectid %s conflicts with the profiles %s", pattern, strings.Join(owned, ", ")),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func validateMetric(m MetricsConfig) []string {
+	var messages []string
+	switch {
+	case m.Table.OID != "" || m.Table.Name != "" || len(m.Symbols) > 0:
+		if m.Table.OID == "" {
+			messages = append(messages, fmt.Sprintf("the table %s of %s has no OID", m.Table.Name, m.MIB))
+		}
+		for _, s := range m.Symbols {
+			if s.OID == "" {
+				messages = append(messages, fmt.Sprintf("the symbol %s of the table %s has no OID", s.Name, m.Table.Name))
+			}
+		}
+		for _, tag := range m.MetricTags {
+			if tag.Column.OID == "" && tag.Index == 0 {
+				messages = append(messages, fmt.Sprintf("the metric tag %s of the table %s has no column OID nor index", tagName(tag), m.Table.Name))
+			}
+		}
+	case m.Symbol.OID != "" || m.Symbol.Name != "":
+		if m.Symbol.OID == "" {
+			messages = append(messages, fmt.Sprintf("the symbol %s of %s has no OID", m.Symbol.Name, m.MIB))
+		}
+	default:
+		messages = append(messages, fmt.Sprintf("a metric of %s has no symbol nor table", m.MIB))
+	}
+	return messages
+}
+
+func tagName(tag MetricTagConfig) string {
+	if tag.Tag != "" {
+		return tag.Tag
+	}
+	return tag.Symbol
+}
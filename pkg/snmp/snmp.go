@@ -146,6 +146,8 @@ func (c *Config) BuildSNMPParams() (*gosnmp.GoSNMP, error) {
 	lowerAuthProtocol := strings.ToLower(c.AuthProtocol)
 	if lowerAuthProtocol == "" {
 		authProtocol = gosnmp.NoAuth
+	} else if lowerAuthProtocol == "md5" && config.FIPSEnabled() {
+		return nil, errors.New("The md5 authentication protocol is not allowed in FIPS mode")
 	} else if lowerAuthProtocol == "md5" {
 		authProtocol = gosnmp.MD5
 	} else if lowerAuthProtocol == "sha" {
@@ -158,6 +160,8 @@ func (c *Config) BuildSNMPParams() (*gosnmp.GoSNMP, error) {
 	lowerPrivProtocol := strings.ToLower(c.PrivProtocol)
 	if lowerPrivProtocol == "" {
 		privProtocol = gosnmp.NoPriv
+	} else if lowerPrivProtocol == "des" && config.FIPSEnabled() {
+		return nil, errors.New("The des privacy protocol is not allowed in FIPS mode")
 	} else if lowerPrivProtocol == "des" {
 		privProtocol = gosnmp.DES
 	} else if lowerPrivProtocol == "aes" {
@@ -10,6 +10,8 @@ import (
 
 	"github.com/soniah/gosnmp"
 	"github.com/stretchr/testify/assert"
+
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 )
 
 func TestBuildSNMPParams(t *testing.T) {
@@ -58,3 +60,30 @@ func TestBuildSNMPParams(t *testing.T) {
 	_, err = config.BuildSNMPParams()
 	assert.Equal(t, "Unsupported privacy protocol: bar", err.Error())
 }
+
+func TestBuildSNMPParamsFIPS(t *testing.T) {
+	mockConfig := coreconfig.Mock()
+	mockConfig.Set("fips.enabled", true)
+	defer mockConfig.Set("fips.enabled", false)
+
+	config := Config{
+		Network:      "192.168.0.0/24",
+		User:         "admin",
+		AuthProtocol: "md5",
+	}
+	_, err := config.BuildSNMPParams()
+	assert.Equal(t, "The md5 authentication protocol is not allowed in FIPS mode", err.Error())
+
+	config = Config{
+		Network:      "192.168.0.0/24",
+		User:         "admin",
+		AuthProtocol: "sha",
+		PrivProtocol: "des",
+	}
+	_, err = config.BuildSNMPParams()
+	assert.Equal(t, "The des privacy protocol is not allowed in FIPS mode", err.Error())
+
+	config.PrivProtocol = "aes"
+	_, err = config.BuildSNMPParams()
+	assert.NoError(t, err)
+}
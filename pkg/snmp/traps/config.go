@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/soniah/gosnmp"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// UserV3 is an SNMPv3 user allowed to send traps
+type UserV3 struct {
+	Username     string `mapstructure:"user"`
+	AuthKey      string `mapstructure:"authentication_key"`
+	AuthProtocol string `mapstructure:"authentication_protocol"`
+	PrivKey      string `mapstructure:"privacy_key"`
+	PrivProtocol string `mapstructure:"privacy_protocol"`
+}
+
+// DeviceProfile tags the traps sent by the devices of a network
+type DeviceProfile struct {
+	Name    string   `mapstructure:"name"`
+	Network string   `mapstructure:"network"`
+	Tags    []string `mapstructure:"tags"`
+
+	network *net.IPNet
+}
+
+// Config holds the configuration of the traps listener
+type Config struct {
+	Port             uint16
+	BindHost         string
+	CommunityStrings []string
+	Users            []UserV3
+	StopTimeout      int
+	MIBFiles         []string
+	DeviceProfiles   []DeviceProfile
+}
+
+// ReadConfig reads and validates the snmp_traps configuration
+func ReadConfig() (*Config, error) {
+	// The settings are read one by one, unmarshalling the whole snmp_traps
+	// section would ignore the defaults of the unset settings
+	c := Config{
+		Port:             uint16(config.Datadog.GetInt("snmp_traps.port")),
+		BindHost:         config.Datadog.GetString("snmp_traps.bind_host"),
+		CommunityStrings: config.Datadog.GetStringSlice("snmp_traps.community_strings"),
+		StopTimeout:      config.Datadog.GetInt("snmp_traps.stop_timeout"),
+		MIBFiles:         config.Datadog.GetStringSlice("snmp_traps.mib_files"),
+	}
+	if err := config.Datadog.UnmarshalKey("snmp_traps.users", &c.Users); err != nil {
+		return nil, fmt.Errorf("unable to parse the snmp_traps users: %v", err)
+	}
+	if err := config.Datadog.UnmarshalKey("snmp_traps.device_profiles", &c.DeviceProfiles); err != nil {
+		return nil, fmt.Errorf("unable to parse the snmp_traps device profiles: %v", err)
+	}
+	if len(c.CommunityStrings) == 0 && len(c.Users) == 0 {
+		return nil, errors.New("snmp_traps needs community_strings or users to authenticate the traps")
+	}
+	// gosnmp v1.25 only authenticates a single SNMPv3 user per listener
+	if len(c.Users) > 1 {
+		return nil, errors.New("snmp_traps supports a single SNMPv3 user")
+	}
+	for i := range c.DeviceProfiles {
+		p := &c.DeviceProfiles[i]
+		_, network, err := net.ParseCIDR(p.Network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network of the snmp_traps device profile %s: %v", p.Name, err)
+		}
+		p.network = network
+	}
+	return &c, nil
+}
+
+// Addr returns the address the listener binds to
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.BindHost, fmt.Sprintf("%d", c.Port))
+}
+
+// BuildListenerParams returns the gosnmp parameters authenticating the traps
+func (c *Config) BuildListenerParams() (*gosnmp.GoSNMP, error) {
+	if len(c.Users) == 0 {
+		return &gosnmp.GoSNMP{
+			Port:      c.Port,
+			Transport: "udp",
+			Version:   gosnmp.Version2c,
+		}, nil
+	}
+
+	user := c.Users[0]
+	authProtocol, err := buildAuthProtocol(user.AuthProtocol)
+	if err != nil {
+		return nil, err
+	}
+	privProtocol, err := buildPrivProtocol(user.PrivProtocol)
+	if err != nil {
+		return nil, err
+	}
+	msgFlags := gosnmp.NoAuthNoPriv
+	if user.PrivKey != "" {
+		msgFlags = gosnmp.AuthPriv
+	} else if user.AuthKey != "" {
+		msgFlags = gosnmp.AuthNoPriv
+	}
+	return &gosnmp.GoSNMP{
+		Port:          c.Port,
+		Transport:     "udp",
+		Version:       gosnmp.Version3,
+		SecurityModel: gosnmp.UserSecurityModel,
+		MsgFlags:      msgFlags,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			UserName:                 user.Username,
+			AuthenticationProtocol:   authProtocol,
+			AuthenticationPassphrase: user.AuthKey,
+			PrivacyProtocol:          privProtocol,
+			PrivacyPassphrase:        user.PrivKey,
+		},
+	}, nil
+}
+
+func buildAuthProtocol(protocol string) (gosnmp.SnmpV3AuthProtocol, error) {
+	switch strings.ToLower(protocol) {
+	case "":
+		return gosnmp.NoAuth, nil
+	case "md5":
+		if config.FIPSEnabled() {
+			return gosnmp.NoAuth, errors.New("The md5 authentication protocol is not allowed in FIPS mode")
+		}
+		return gosnmp.MD5, nil
+	case "sha":
+		return gosnmp.SHA, nil
+	}
+	return gosnmp.NoAuth, fmt.Errorf("Unsupported authentication protocol: %s", protocol)
+}
+
+func buildPrivProtocol(protocol string) (gosnmp.SnmpV3PrivProtocol, error) {
+	switch strings.ToLower(protocol) {
+	case "":
+		return gosnmp.NoPriv, nil
+	case "des":
+		if config.FIPSEnabled() {
+			return gosnmp.NoPriv, errors.New("The des privacy protocol is not allowed in FIPS mode")
+		}
+		return gosnmp.DES, nil
+	case "aes":
+		return gosnmp.AES, nil
+	case "aes192":
+		return gosnmp.AES192, nil
+	case "aes192c":
+		return gosnmp.AES192C, nil
+	case "aes256":
+		return gosnmp.AES256, nil
+	case "aes256c":
+		return gosnmp.AES256C, nil
+	}
+	return gosnmp.NoPriv, fmt.Errorf("Unsupported privacy protocol: %s", protocol)
+}
+
+// stopTimeout returns the time to wait for the listener to stop
+func (c *Config) stopTimeout() time.Duration {
+	return time.Duration(c.StopTimeout) * time.Second
+}
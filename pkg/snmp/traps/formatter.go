@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/hex"
+	"net"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	sysUpTimeOID   = "1.3.6.1.2.1.1.3.0"
+	snmpTrapOIDOID = "1.3.6.1.6.3.1.1.4.1.0"
+)
+
+// Event is the normalized form of a trap sent to the event platform
+type Event struct {
+	Timestamp int64      `json:"timestamp"`
+	TrapOID   string     `json:"snmpTrapOID"`
+	TrapName  string     `json:"snmpTrapName,omitempty"`
+	TrapMIB   string     `json:"snmpTrapMIB,omitempty"`
+	Uptime    uint32     `json:"uptime"`
+	SourceIP  string     `json:"source_ip"`
+	Tags      []string   `json:"tags"`
+	Variables []Variable `json:"variables"`
+}
+
+// Variable is a resolved varbind of a trap
+type Variable struct {
+	OID   string      `json:"oid"`
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Formatter normalizes the traps into events
+type Formatter struct {
+	resolver *Resolver
+	profiles []DeviceProfile
+}
+
+// NewFormatter returns a formatter resolving the varbinds with the resolver and
+// tagging the events with the device profiles
+func NewFormatter(resolver *Resolver, profiles []DeviceProfile) *Formatter {
+	return &Formatter{resolver: resolver, profiles: profiles}
+}
+
+// Format normalizes a trap received from source
+func (f *Formatter) Format(packet *gosnmp.SnmpPacket, source net.IP, now time.Time) Event {
+	event := Event{
+		Timestamp: now.Unix(),
+		SourceIP:  source.String(),
+		Tags:      f.tags(source),
+		Variables: []Variable{},
+	}
+	for _, pdu := range packet.Variables {
+		oid := normalizeOID(pdu.Name)
+		switch oid {
+		case sysUpTimeOID:
+			if uptime, ok := pdu.Value.(uint32); ok {
+				event.Uptime = uptime
+			}
+			continue
+		case snmpTrapOIDOID:
+			if trapOID, ok := pdu.Value.(string); ok {
+				event.TrapOID = normalizeOID(trapOID)
+				if object, _, found := f.resolver.Resolve(trapOID); found {
+					event.TrapName, event.TrapMIB = object.Name, object.MIB
+				}
+			}
+			continue
+		}
+		event.Variables = append(event.Variables, f.variable(oid, pdu))
+	}
+	return event
+}
+
+// tags returns the tags of the device profiles matching source
+func (f *Formatter) tags(source net.IP) []string {
+	tags := []string{"snmp_device:" + source.String()}
+	for _, p := range f.profiles {
+		if p.network != nil && p.network.Contains(source) {
+			tags = append(tags, "snmp_profile:"+p.Name)
+			tags = append(tags, p.Tags...)
+		}
+	}
+	return tags
+}
+
+func (f *Formatter) variable(oid string, pdu gosnmp.SnmpPDU) Variable {
+	v := Variable{OID: oid, Type: typeName(pdu.Type)}
+	object, instance, found := f.resolver.Resolve(oid)
+	if found {
+		v.Name = object.Name
+		if instance != "" {
+			v.Name += "." + instance
+		}
+	}
+
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		if isPrintable(b) {
+			v.Value = string(b)
+		} else {
+			v.Value = hex.EncodeToString(b)
+		}
+	case gosnmp.ObjectIdentifier:
+		value, _ := pdu.Value.(string)
+		value = normalizeOID(value)
+		if o, instance, ok := f.resolver.Resolve(value); ok && instance == "" {
+			value = o.Name
+		}
+		v.Value = value
+	case gosnmp.Integer:
+		value := gosnmp.ToBigInt(pdu.Value).Int64()
+		if name, ok := object.Enums[int(value)]; found && ok {
+			v.Value = name
+		} else {
+			v.Value = value
+		}
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		v.Value = gosnmp.ToBigInt(pdu.Value).Uint64()
+	default:
+		v.Value = pdu.Value
+	}
+	return v
+}
+
+func typeName(t gosnmp.Asn1BER) string {
+	switch t {
+	case gosnmp.Integer:
+		return "integer"
+	case gosnmp.OctetString:
+		return "string"
+	case gosnmp.ObjectIdentifier:
+		return "oid"
+	case gosnmp.IPAddress:
+		return "ip-address"
+	case gosnmp.Counter32:
+		return "counter32"
+	case gosnmp.Gauge32:
+		return "gauge32"
+	case gosnmp.TimeTicks:
+		return "timeticks"
+	case gosnmp.Counter64:
+		return "counter64"
+	case gosnmp.Uinteger32:
+		return "uinteger32"
+	case gosnmp.Null:
+		return "null"
+	}
+	return "unknown"
+}
+
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	r, err := NewResolver(nil)
+	require.NoError(t, err)
+	_, network, err := net.ParseCIDR("10.0.0.0/24")
+	require.NoError(t, err)
+	profiles := []DeviceProfile{
+		{Name: "core-switches", Tags: []string{"role:core"}, network: network},
+		{Name: "other", Tags: []string{"role:other"}, network: &net.IPNet{IP: net.IPv4(192, 168, 0, 0), Mask: net.CIDRMask(16, 32)}},
+	}
+	f := NewFormatter(r, profiles)
+
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1234)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.12", Type: gosnmp.Integer, Value: 12},
+			{Name: ".1.3.6.1.2.1.2.2.1.8.12", Type: gosnmp.Integer, Value: 2},
+			{Name: ".1.3.6.1.2.1.2.2.1.2.12", Type: gosnmp.OctetString, Value: []byte("eth0")},
+			{Name: ".1.3.6.1.4.1.9.2.1.1", Type: gosnmp.OctetString, Value: []byte{0x00, 0x1b, 0xff}},
+			{Name: ".1.3.6.1.4.1.9.2.1.2", Type: gosnmp.Counter64, Value: uint64(42)},
+		},
+	}
+	now := time.Unix(1600000000, 0)
+	event := f.Format(packet, net.ParseIP("10.0.0.7"), now)
+
+	assert.Equal(t, int64(1600000000), event.Timestamp)
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", event.TrapOID)
+	assert.Equal(t, "linkDown", event.TrapName)
+	assert.Equal(t, "IF-MIB", event.TrapMIB)
+	assert.Equal(t, uint32(1234), event.Uptime)
+	assert.Equal(t, "10.0.0.7", event.SourceIP)
+	assert.Equal(t, []string{"snmp_device:10.0.0.7", "snmp_profile:core-switches", "role:core"}, event.Tags)
+	assert.Equal(t, []Variable{
+		{OID: "1.3.6.1.2.1.2.2.1.1.12", Name: "ifIndex.12", Type: "integer", Value: int64(12)},
+		{OID: "1.3.6.1.2.1.2.2.1.8.12", Name: "ifOperStatus.12", Type: "integer", Value: "down"},
+		{OID: "1.3.6.1.2.1.2.2.1.2.12", Name: "ifDescr.12", Type: "string", Value: "eth0"},
+		{OID: "1.3.6.1.4.1.9.2.1.1", Type: "string", Value: "001bff"},
+		{OID: "1.3.6.1.4.1.9.2.1.2", Type: "counter64", Value: uint64(42)},
+	}, event.Variables)
+
+	// no profile matches
+	event = f.Format(packet, net.ParseIP("172.16.0.1"), now)
+	assert.Equal(t, []string{"snmp_device:172.16.0.1"}, event.Tags)
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/soniah/gosnmp"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	trapsExpvars = expvar.NewMap("snmp_traps")
+	// trapsReceived is the total number of received traps
+	trapsReceived = expvar.Int{}
+	// trapsAuthFailures is the total number of traps dropped because of an unknown community
+	trapsAuthFailures = expvar.Int{}
+	// trapsForwarded is the total number of traps forwarded to the event platform
+	trapsForwarded = expvar.Int{}
+	// trapsErrors is the total number of traps that failed to be forwarded
+	trapsErrors = expvar.Int{}
+)
+
+func init() {
+	trapsExpvars.Set("Received", &trapsReceived)
+	trapsExpvars.Set("AuthFailures", &trapsAuthFailures)
+	trapsExpvars.Set("Forwarded", &trapsForwarded)
+	trapsExpvars.Set("Errors", &trapsErrors)
+}
+
+// SendFunc forwards a JSON encoded event
+type SendFunc func(rawEvent []byte) error
+
+// Server listens for SNMP traps and forwards them as events
+type Server struct {
+	config    *Config
+	listener  *gosnmp.TrapListener
+	formatter *Formatter
+	send      SendFunc
+}
+
+// NewServer returns a traps server forwarding the events with send
+func NewServer(c *Config, send SendFunc) (*Server, error) {
+	params, err := c.BuildListenerParams()
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := NewResolver(c.MIBFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config:    c,
+		listener:  gosnmp.NewTrapListener(),
+		formatter: NewFormatter(resolver, c.DeviceProfiles),
+		send:      send,
+	}
+	s.listener.Params = params
+	s.listener.OnNewTrap = s.handleTrap
+	return s, nil
+}
+
+// Start starts listening for traps, it returns once the listener is ready
+func (s *Server) Start() error {
+	errs := make(chan error, 1)
+	go func() {
+		// Listen blocks until the listener is closed
+		if err := s.listener.Listen(s.config.Addr()); err != nil {
+			errs <- err
+		}
+	}()
+
+	select {
+	case <-s.listener.Listening():
+		log.Infof("Listening for SNMP traps on %s", s.config.Addr())
+		return nil
+	case err := <-errs:
+		return fmt.Errorf("unable to listen for SNMP traps on %s: %v", s.config.Addr(), err)
+	}
+}
+
+// Stop stops listening for traps
+func (s *Server) Stop() {
+	stopped := make(chan struct{})
+	go func() {
+		s.listener.Close()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(s.config.stopTimeout()):
+		log.Warnf("The SNMP traps listener did not stop within %s", s.config.stopTimeout())
+	}
+}
+
+func (s *Server) handleTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	trapsReceived.Add(1)
+	if packet.Version != gosnmp.Version3 && !s.validCommunity(packet.Community) {
+		trapsAuthFailures.Add(1)
+		log.Debugf("Dropping an SNMP trap from %s with an unknown community", addr.IP)
+		return
+	}
+
+	event := s.formatter.Format(packet, addr.IP, time.Now())
+	raw, err := json.Marshal(event)
+	if err == nil {
+		err = s.send(raw)
+	}
+	if err != nil {
+		trapsErrors.Add(1)
+		log.Errorf("Unable to forward the SNMP trap %s from %s: %v", event.TrapOID, addr.IP, err)
+		return
+	}
+	trapsForwarded.Add(1)
+}
+
+func (s *Server) validCommunity(community string) bool {
+	for _, c := range s.config.CommunityStrings {
+		if c == community {
+			return true
+		}
+	}
+	return false
+}
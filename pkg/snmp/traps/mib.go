@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// MIBObject describes a MIB object or notification
+type MIBObject struct {
+	OID   string         `json:"oid"`
+	Name  string         `json:"name"`
+	MIB   string         `json:"mib"`
+	Enums map[int]string `json:"enums,omitempty"`
+}
+
+// bundledMIBObjects are the objects of the standard MIBs resolved out of the box
+var bundledMIBObjects = []MIBObject{
+	{OID: "1.3.6.1.2.1.1.1", Name: "sysDescr", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.2.1.1.3", Name: "sysUpTime", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.2.1.1.5", Name: "sysName", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.4.1", Name: "snmpTrapOID", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.4.3", Name: "snmpTrapEnterprise", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.5.1", Name: "coldStart", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.5.2", Name: "warmStart", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.5.3", Name: "linkDown", MIB: "IF-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.5.4", Name: "linkUp", MIB: "IF-MIB"},
+	{OID: "1.3.6.1.6.3.1.1.5.5", Name: "authenticationFailure", MIB: "SNMPv2-MIB"},
+	{OID: "1.3.6.1.2.1.2.2.1.1", Name: "ifIndex", MIB: "IF-MIB"},
+	{OID: "1.3.6.1.2.1.2.2.1.2", Name: "ifDescr", MIB: "IF-MIB"},
+	{OID: "1.3.6.1.2.1.2.2.1.7", Name: "ifAdminStatus", MIB: "IF-MIB", Enums: map[int]string{1: "up", 2: "down", 3: "testing"}},
+	{OID: "1.3.6.1.2.1.2.2.1.8", Name: "ifOperStatus", MIB: "IF-MIB", Enums: map[int]string{
+		1: "up", 2: "down", 3: "testing", 4: "unknown", 5: "dormant", 6: "notPresent", 7: "lowerLayerDown",
+	}},
+	{OID: "1.3.6.1.2.1.31.1.1.1.1", Name: "ifName", MIB: "IF-MIB"},
+}
+
+// Resolver resolves OIDs to the MIB objects they are instances of
+type Resolver struct {
+	objects map[string]MIBObject
+}
+
+// NewResolver returns a resolver of the bundled MIB objects and of the
+// objects defined in the given JSON files
+func NewResolver(mibFiles []string) (*Resolver, error) {
+	r := &Resolver{objects: make(map[string]MIBObject)}
+	r.add(bundledMIBObjects)
+	for _, path := range mibFiles {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var objects []MIBObject
+		if err := json.Unmarshal(content, &objects); err != nil {
+			return nil, fmt.Errorf("unable to parse the MIB file %s: %v", path, err)
+		}
+		r.add(objects)
+	}
+	return r, nil
+}
+
+func (r *Resolver) add(objects []MIBObject) {
+	for _, o := range objects {
+		o.OID = normalizeOID(o.OID)
+		r.objects[o.OID] = o
+	}
+}
+
+// Resolve returns the object of the longest known prefix of an OID, and the
+// instance suffix following it. ok is false when no prefix is known.
+func (r *Resolver) Resolve(oid string) (object MIBObject, instance string, ok bool) {
+	oid = normalizeOID(oid)
+	for prefix := oid; prefix != ""; {
+		if object, ok = r.objects[prefix]; ok {
+			return object, strings.TrimPrefix(oid[len(prefix):], "."), true
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return MIBObject{}, "", false
+}
+
+// normalizeOID removes the leading dot of the OIDs sent by gosnmp
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
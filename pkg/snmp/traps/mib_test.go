@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package traps
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	r, err := NewResolver(nil)
+	require.NoError(t, err)
+
+	object, instance, ok := r.Resolve(".1.3.6.1.6.3.1.1.5.3")
+	require.True(t, ok)
+	assert.Equal(t, "linkDown", object.Name)
+	assert.Equal(t, "IF-MIB", object.MIB)
+	assert.Equal(t, "", instance)
+
+	object, instance, ok = r.Resolve("1.3.6.1.2.1.2.2.1.8.12")
+	require.True(t, ok)
+	assert.Equal(t, "ifOperStatus", object.Name)
+	assert.Equal(t, "12", instance)
+
+	_, _, ok = r.Resolve("1.3.6.1.4.1.9.9.41.2.0.1")
+	assert.False(t, ok)
+}
+
+func TestResolveMIBFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "mib")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`[{"oid": ".1.3.6.1.4.1.9.9.41.2.0.1", "name": "clogMessageGenerated", "mib": "CISCO-SYSLOG-MIB"}]`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	r, err := NewResolver([]string{f.Name()})
+	require.NoError(t, err)
+	object, _, ok := r.Resolve("1.3.6.1.4.1.9.9.41.2.0.1")
+	require.True(t, ok)
+	assert.Equal(t, "clogMessageGenerated", object.Name)
+
+	_, err = NewResolver([]string{"/does/not/exist.json"})
+	assert.Error(t, err)
+}
@@ -31,17 +31,21 @@ func Deregister(handle *Handle) error {
 	return readinessOnlyCatalog.deregister(handle)
 }
 
-// GetLive returns health of all components registered for liveness
+// GetLive returns health of all components registered for liveness.
+// A component registered for liveness is live until it misses a ping, so that
+// a slow start doesn't get the agent restarted.
 func GetLive() Status {
-	return readinessAndLivenessCatalog.getStatus()
+	return readinessAndLivenessCatalog.getLivenessStatus()
 }
 
-// GetReady returns health of all components registered for both readiness and liveness
+// GetReady returns health of all components registered for both readiness and liveness.
+// A component is only ready once it has read its health channel.
 func GetReady() (ret Status) {
 	liveStatus := readinessAndLivenessCatalog.getStatus()
 	readyStatus := readinessOnlyCatalog.getStatus()
 	ret.Healthy = append(liveStatus.Healthy, readyStatus.Healthy...)
 	ret.Unhealthy = append(liveStatus.Unhealthy, readyStatus.Unhealthy...)
+	ret.Components = append(liveStatus.Components, readyStatus.Components...)
 	return
 }
 
@@ -28,6 +28,9 @@ type component struct {
 	name       string
 	healthChan chan struct{}
 	healthy    bool
+	// pinged is false until the component is pinged for the first time
+	pinged      bool
+	lastHealthy time.Time
 }
 
 type catalog struct {
@@ -95,9 +98,11 @@ func (c *catalog) pingComponents() bool {
 		select {
 		case component.healthChan <- struct{}{}:
 			component.healthy = true
+			component.lastHealthy = time.Now()
 		default:
 			component.healthy = false
 		}
+		component.pinged = true
 	}
 	c.latestRun = time.Now()
 	return len(c.components) == 0
@@ -118,30 +123,58 @@ func (c *catalog) deregister(handle *Handle) error {
 // Status represents the current status of registered components
 // it is built and returned by GetStatus()
 type Status struct {
-	Healthy   []string
-	Unhealthy []string
+	Healthy    []string
+	Unhealthy  []string
+	Components []ComponentStatus
 }
 
-// getStatus allows to query the health status of the agent
+// ComponentStatus details the status of a registered component
+type ComponentStatus struct {
+	Name    string
+	Healthy bool
+	// LastHealthy is the last time the component was seen healthy, if ever
+	LastHealthy *time.Time `json:",omitempty"`
+}
+
+func (s *Status) add(name string, healthy bool, lastHealthy time.Time) {
+	cs := ComponentStatus{Name: name, Healthy: healthy}
+	if !lastHealthy.IsZero() {
+		cs.LastHealthy = &lastHealthy
+	}
+	s.Components = append(s.Components, cs)
+
+	if healthy {
+		s.Healthy = append(s.Healthy, name)
+	} else {
+		s.Unhealthy = append(s.Unhealthy, name)
+	}
+}
+
+// getStatus returns the readiness of the registered components: they're
+// ready once they've read their health channel since the last ping
 func (c *catalog) getStatus() Status {
+	return c.getStatusFor(false)
+}
+
+// getLivenessStatus returns the liveness of the registered components: they're
+// live until they miss a ping, so that a component starting slowly isn't
+// considered dead before it had a chance to read its health channel
+func (c *catalog) getLivenessStatus() Status {
+	return c.getStatusFor(true)
+}
+
+func (c *catalog) getStatusFor(liveness bool) Status {
 	status := Status{}
 	c.RLock()
 	defer c.RUnlock()
 
 	// Test the checker itself
-	if time.Now().After(c.latestRun.Add(2 * pingFrequency)) {
-		status.Unhealthy = append(status.Unhealthy, "healthcheck")
-	} else {
-		status.Healthy = append(status.Healthy, "healthcheck")
-	}
+	status.add("healthcheck", !time.Now().After(c.latestRun.Add(2*pingFrequency)), c.latestRun)
 
 	// Check components
 	for _, component := range c.components {
-		if component.healthy {
-			status.Healthy = append(status.Healthy, component.name)
-		} else {
-			status.Unhealthy = append(status.Unhealthy, component.name)
-		}
+		healthy := component.healthy || (liveness && !component.pinged)
+		status.add(component.name, healthy, component.lastHealthy)
 	}
 	return status
 }
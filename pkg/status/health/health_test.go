@@ -108,3 +108,46 @@ func TestGetHealthy(t *testing.T) {
 	assert.Len(t, status.Healthy, 2)
 	assert.Len(t, status.Unhealthy, 0)
 }
+
+func TestLivenessGracePeriod(t *testing.T) {
+	cat := newCatalog()
+	token := cat.register("test1")
+
+	// Live but not ready until the first ping
+	assert.Contains(t, cat.getLivenessStatus().Healthy, "test1")
+	assert.Contains(t, cat.getStatus().Unhealthy, "test1")
+
+	// Missing the first ping makes it dead
+	cat.pingComponents()
+	assert.Contains(t, cat.getLivenessStatus().Unhealthy, "test1")
+	assert.Contains(t, cat.getStatus().Unhealthy, "test1")
+
+	// Reading the channel makes it live and ready
+	<-token.C
+	<-token.C
+	cat.pingComponents()
+	assert.Contains(t, cat.getLivenessStatus().Healthy, "test1")
+	assert.Contains(t, cat.getStatus().Healthy, "test1")
+}
+
+func TestComponentsDetail(t *testing.T) {
+	cat := newCatalog()
+	token := cat.register("test1")
+
+	status := cat.getStatus()
+	require.Len(t, status.Components, 2)
+	for _, c := range status.Components {
+		if c.Name == "test1" {
+			assert.False(t, c.Healthy)
+			assert.Nil(t, c.LastHealthy)
+		}
+	}
+
+	<-token.C
+	cat.pingComponents()
+	status = cat.getStatus()
+	for _, c := range status.Components {
+		assert.True(t, c.Healthy)
+		assert.NotNil(t, c.LastHealthy)
+	}
+}
@@ -28,6 +28,7 @@ type JMXStartupError struct {
 
 var (
 	lastJMXStatus            JMXStatus
+	lastJMXGroupStatus       = map[string]JMXStatus{}
 	lastJMXStatusMutex       sync.RWMutex
 	lastJMXStartupError      JMXStartupError
 	lastJMXStartupErrorMutex sync.RWMutex
@@ -41,12 +42,70 @@ func SetJMXStatus(s JMXStatus) {
 	lastJMXStatus = s
 }
 
-// GetJMXStatus retrieves latest JMX Status
+// SetJMXGroupStatus sets the last JMX Status of the JMXFetch process running
+// the checks of a process group, the empty group being the default one
+func SetJMXGroupStatus(group string, s JMXStatus) {
+	if group == "" {
+		SetJMXStatus(s)
+		return
+	}
+	lastJMXStatusMutex.Lock()
+	defer lastJMXStatusMutex.Unlock()
+
+	lastJMXGroupStatus[group] = s
+}
+
+// RemoveJMXGroupStatus removes the JMX Status of a process group whose
+// JMXFetch process was stopped
+func RemoveJMXGroupStatus(group string) {
+	lastJMXStatusMutex.Lock()
+	defer lastJMXStatusMutex.Unlock()
+
+	delete(lastJMXGroupStatus, group)
+}
+
+// GetJMXStatus retrieves latest JMX Status, merging the ones of all the
+// JMXFetch processes
 func GetJMXStatus() JMXStatus {
 	lastJMXStatusMutex.RLock()
 	defer lastJMXStatusMutex.RUnlock()
 
-	return lastJMXStatus
+	if len(lastJMXGroupStatus) == 0 {
+		return lastJMXStatus
+	}
+
+	merged := JMXStatus{
+		ChecksStatus: jmxCheckStatus{
+			InitializedChecks: map[string]interface{}{},
+			FailedChecks:      map[string]interface{}{},
+		},
+	}
+	mergeJMXStatus(&merged, lastJMXStatus)
+	for _, s := range lastJMXGroupStatus {
+		mergeJMXStatus(&merged, s)
+	}
+	return merged
+}
+
+func mergeJMXStatus(dst *JMXStatus, src JMXStatus) {
+	if src.Timestamp > dst.Timestamp {
+		dst.Timestamp = src.Timestamp
+	}
+	mergeJMXChecks(dst.ChecksStatus.InitializedChecks, src.ChecksStatus.InitializedChecks)
+	mergeJMXChecks(dst.ChecksStatus.FailedChecks, src.ChecksStatus.FailedChecks)
+}
+
+// mergeJMXChecks merges the lists of instances of the checks of src into dst
+func mergeJMXChecks(dst, src map[string]interface{}) {
+	for name, instances := range src {
+		existing, ok := dst[name].([]interface{})
+		added, isList := instances.([]interface{})
+		if ok && isList {
+			dst[name] = append(append([]interface{}{}, existing...), added...)
+		} else {
+			dst[name] = instances
+		}
+	}
 }
 
 // SetJMXStartupError sets the last JMX startup error
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJMXGroupStatus(t *testing.T) {
+	defer SetJMXStatus(JMXStatus{})
+
+	SetJMXStatus(JMXStatus{
+		ChecksStatus: jmxCheckStatus{
+			InitializedChecks: map[string]interface{}{"tomcat": []interface{}{"instance1"}},
+		},
+		Timestamp: 10,
+	})
+	assert.Equal(t, int64(10), GetJMXStatus().Timestamp)
+
+	SetJMXGroupStatus("kafka", JMXStatus{
+		ChecksStatus: jmxCheckStatus{
+			InitializedChecks: map[string]interface{}{"tomcat": []interface{}{"instance2"}},
+			FailedChecks:      map[string]interface{}{"kafka": []interface{}{"instance3"}},
+		},
+		Timestamp: 20,
+	})
+	s := GetJMXStatus()
+	assert.Equal(t, int64(20), s.Timestamp)
+	assert.Equal(t, []interface{}{"instance1", "instance2"}, s.ChecksStatus.InitializedChecks["tomcat"])
+	assert.Equal(t, []interface{}{"instance3"}, s.ChecksStatus.FailedChecks["kafka"])
+
+	RemoveJMXGroupStatus("kafka")
+	s = GetJMXStatus()
+	assert.Equal(t, int64(10), s.Timestamp)
+	assert.Nil(t, s.ChecksStatus.FailedChecks)
+}
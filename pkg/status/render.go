@@ -47,7 +47,7 @@ func FormatStatus(data []byte) (string, error) {
 	renderStatusTemplate(b, "/forwarder.tmpl", forwarderStats)
 	renderStatusTemplate(b, "/endpoints.tmpl", endpointsInfos)
 	renderStatusTemplate(b, "/logsagent.tmpl", logsStats)
-	if config.Datadog.GetBool("system_probe_config.enabled") {
+	if config.IsFeatureEnabled(config.NPMFeature) {
 		renderStatusTemplate(b, "/systemprobe.tmpl", systemProbeStats)
 	}
 	renderStatusTemplate(b, "/trace-agent.tmpl", stats["apmStats"])
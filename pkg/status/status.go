@@ -21,7 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
-	"github.com/DataDog/datadog-agent/pkg/logs"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
@@ -39,6 +39,8 @@ func GetStatus() (map[string]interface{}, error) {
 	}
 
 	stats["config"] = getPartialConfig()
+	stats["configDeprecations"] = config.GetDeprecationWarnings()
+	stats["features"] = config.GetFeatures()
 	metadata := stats["metadata"].(*host.Payload)
 	hostTags := make([]string, 0, len(metadata.HostTags.System)+len(metadata.HostTags.GoogleCloudPlatform))
 	hostTags = append(hostTags, metadata.HostTags.System...)
@@ -52,7 +54,7 @@ func GetStatus() (map[string]interface{}, error) {
 	stats["JMXStatus"] = GetJMXStatus()
 	stats["JMXStartupError"] = GetJMXStartupError()
 
-	stats["logsStats"] = logs.GetStatus()
+	stats["logsStats"] = logsStatus.Get()
 
 	endpointsInfos, err := getEndpointsInfos()
 	if endpointsInfos != nil && err == nil {
@@ -65,7 +67,7 @@ func GetStatus() (map[string]interface{}, error) {
 		stats["clusterAgentStatus"] = getDCAStatus()
 	}
 
-	if config.Datadog.GetBool("system_probe_config.enabled") {
+	if config.IsFeatureEnabled(config.NPMFeature) {
 		stats["systemProbeStats"] = GetSystemProbeStats(config.Datadog.GetString("system_probe_config.sysprobe_socket"))
 	}
 
@@ -247,6 +249,7 @@ func getCommonStatus() (map[string]interface{}, error) {
 	stats["go_version"] = runtime.Version()
 	stats["agent_start"] = startTime.Format(timeFormat)
 	stats["build_arch"] = runtime.GOARCH
+	stats["fips"] = config.GetFIPSStatus()
 	now := time.Now()
 	stats["time"] = now.Format(timeFormat)
 
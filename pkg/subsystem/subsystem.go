@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package subsystem keeps track of the optional subsystems of the agent, like
+// the logs-agent. A subsystem registers itself from a file only built with its
+// build tag, so a build without the tag doesn't link the subsystem at all.
+package subsystem
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Subsystem describes how to run an optional subsystem
+type Subsystem struct {
+	Name string
+	// Enabled reports whether the configuration enables the subsystem
+	Enabled func() bool
+	Start   func() error
+	Stop    func()
+	// Scheduler returns the scheduler of the subsystem, registered to
+	// autodiscovery once started. Optional.
+	Scheduler func() scheduler.Scheduler
+}
+
+var (
+	mu sync.Mutex
+	// catalog keeps track of the registered subsystems by name
+	catalog = make(map[string]Subsystem)
+	// started lists the started subsystems, in start order
+	started []string
+)
+
+// Register adds a subsystem to the catalog
+func Register(s Subsystem) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalog[s.Name] = s
+}
+
+// Names returns the sorted names of the registered subsystems
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRegistered returns whether a subsystem is part of the build
+func IsRegistered(name string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, found := catalog[name]
+	return found
+}
+
+// StartAll starts the enabled subsystems, by name. A subsystem failing to
+// start doesn't prevent the others from starting.
+func StartAll() {
+	for _, name := range Names() {
+		mu.Lock()
+		s := catalog[name]
+		mu.Unlock()
+
+		if !s.Enabled() {
+			log.Infof("%s disabled", name)
+			continue
+		}
+		if err := s.Start(); err != nil {
+			log.Errorf("Could not start %s: %v", name, err)
+			continue
+		}
+
+		mu.Lock()
+		started = append(started, name)
+		mu.Unlock()
+	}
+}
+
+// StopAll stops the started subsystems, in reverse start order
+func StopAll() {
+	mu.Lock()
+	toStop := started
+	started = nil
+	mu.Unlock()
+
+	for i := len(toStop) - 1; i >= 0; i-- {
+		mu.Lock()
+		s := catalog[toStop[i]]
+		mu.Unlock()
+		s.Stop()
+	}
+}
+
+// Schedulers returns the schedulers of the started subsystems, by name
+func Schedulers() map[string]scheduler.Scheduler {
+	mu.Lock()
+	defer mu.Unlock()
+	schedulers := make(map[string]scheduler.Scheduler)
+	for _, name := range started {
+		if s := catalog[name]; s.Scheduler != nil {
+			if sched := s.Scheduler(); sched != nil {
+				schedulers[name] = sched
+			}
+		}
+	}
+	return schedulers
+}
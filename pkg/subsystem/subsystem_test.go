@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package subsystem
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
+)
+
+type testScheduler struct{}
+
+func (s *testScheduler) Schedule([]integration.Config)   {}
+func (s *testScheduler) Unschedule([]integration.Config) {}
+func (s *testScheduler) Stop()                           {}
+
+func reset() {
+	catalog = make(map[string]Subsystem)
+	started = nil
+}
+
+func TestStartStopAll(t *testing.T) {
+	reset()
+	defer reset()
+
+	var events []string
+	register := func(name string, enabled bool, startErr error) {
+		Register(Subsystem{
+			Name:    name,
+			Enabled: func() bool { return enabled },
+			Start: func() error {
+				events = append(events, "start "+name)
+				return startErr
+			},
+			Stop:      func() { events = append(events, "stop "+name) },
+			Scheduler: func() scheduler.Scheduler { return &testScheduler{} },
+		})
+	}
+	register("b", true, nil)
+	register("a", true, nil)
+	register("disabled", false, nil)
+	register("failing", true, errors.New("failure"))
+
+	assert.Equal(t, []string{"a", "b", "disabled", "failing"}, Names())
+	assert.True(t, IsRegistered("disabled"))
+	assert.False(t, IsRegistered("logs"))
+
+	StartAll()
+	assert.Len(t, Schedulers(), 2)
+	assert.Contains(t, Schedulers(), "a")
+
+	StopAll()
+	assert.Equal(t, []string{"start a", "start b", "start failing", "stop b", "stop a"}, events)
+	assert.Empty(t, Schedulers())
+}
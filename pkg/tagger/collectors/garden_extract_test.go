@@ -88,6 +88,10 @@ func (fakeDCAClient) GetNodeLabels(nodeName string) (map[string]string, error) {
 	panic("implement me")
 }
 
+func (fakeDCAClient) GetNamespaceMetadata(ns string) (*apiv1.NamespaceMetadata, error) {
+	panic("implement me")
+}
+
 func (fakeDCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error) {
 	panic("implement me")
 }
@@ -9,10 +9,13 @@ package collectors
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
@@ -35,6 +38,12 @@ type KubeMetadataCollector struct {
 	updateFreq time.Duration
 
 	clusterAgentEnabled bool
+
+	namespaceLabelsAsTags      map[string]string
+	namespaceAnnotationsAsTags map[string]string
+	// namespaceMetadata caches the metadata of the namespaces until the next pull
+	namespaceMetadata   map[string]*apiv1.NamespaceMetadata
+	namespaceMetadataMu sync.Mutex
 }
 
 // Detect tries to connect to the kubelet and the API Server if the DCA is not used or the DCA.
@@ -77,6 +86,9 @@ func (c *KubeMetadataCollector) Detect(out chan<- []*TagInfo) (CollectionMode, e
 	}
 	c.infoOut = out
 	c.updateFreq = time.Duration(config.Datadog.GetInt("kubernetes_metadata_tag_update_freq")) * time.Second
+	c.namespaceLabelsAsTags = lowerCaseKeys(config.Datadog.GetStringMapString("kubernetes_namespace_labels_as_tags"))
+	c.namespaceAnnotationsAsTags = lowerCaseKeys(config.Datadog.GetStringMapString("kubernetes_namespace_annotations_as_tags"))
+	c.namespaceMetadata = make(map[string]*apiv1.NamespaceMetadata)
 	return PullCollection, nil
 }
 
@@ -93,6 +105,8 @@ func (c *KubeMetadataCollector) Pull() error {
 	if err != nil {
 		return err
 	}
+	// Forget the namespace metadata so that label changes are picked up
+	c.resetNamespaceMetadata()
 	if !c.isClusterAgentEnabled() {
 		// If the DCA is not used, each agent stores a local cache of the MetadataMap.
 		err = c.addToCacheMetadataMapping(pods)
@@ -148,6 +162,16 @@ func (c *KubeMetadataCollector) isClusterAgentEnabled() bool {
 	return false
 }
 
+// lowerCaseKeys returns the map with its keys lower-cased, the map keys
+// collected by viper being lower-cased as well
+func lowerCaseKeys(m map[string]string) map[string]string {
+	lowered := make(map[string]string, len(m))
+	for k, v := range m {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
 func kubernetesFactory() Collector {
 	return &KubeMetadataCollector{}
 }
@@ -68,6 +68,7 @@ func (c *KubeMetadataCollector) getTagInfos(pods []*kubelet.Pod) []*TagInfo {
 		if err != nil {
 			log.Errorf("Could not fetch tags, %v", err)
 		}
+		c.addNamespaceTags(tagList, po.Metadata.Namespace)
 		for _, tagDCA := range metadataNames {
 			log.Tracef("Tagging %s with %s", po.Metadata.Name, tagDCA)
 			tag = strings.Split(tagDCA, ":")
@@ -138,6 +139,67 @@ func (c *KubeMetadataCollector) getMetadaNames(getPodMetaDataFromAPIServerFunc f
 	return metadataNames, err
 }
 
+// addNamespaceTags adds to the tags of a pod the labels and annotations of its
+// namespace configured as tags
+func (c *KubeMetadataCollector) addNamespaceTags(tagList *utils.TagList, ns string) {
+	if len(c.namespaceLabelsAsTags) == 0 && len(c.namespaceAnnotationsAsTags) == 0 {
+		return
+	}
+	metadata, err := c.getNamespaceMetadata(ns)
+	if err != nil {
+		log.Debugf("Could not fetch the metadata of the namespace %s: %v", ns, err)
+		return
+	}
+	for name, value := range metadata.Labels {
+		if tagName, found := c.namespaceLabelsAsTags[strings.ToLower(name)]; found {
+			tagList.AddAuto(tagName, value)
+		}
+	}
+	for name, value := range metadata.Annotations {
+		if tagName, found := c.namespaceAnnotationsAsTags[strings.ToLower(name)]; found {
+			tagList.AddAuto(tagName, value)
+		}
+	}
+}
+
+// getNamespaceMetadata returns the metadata of a namespace, queried from the
+// DCA or the API server once per pull
+func (c *KubeMetadataCollector) getNamespaceMetadata(ns string) (*apiv1.NamespaceMetadata, error) {
+	c.namespaceMetadataMu.Lock()
+	defer c.namespaceMetadataMu.Unlock()
+
+	if metadata, found := c.namespaceMetadata[ns]; found {
+		return metadata, nil
+	}
+
+	var metadata *apiv1.NamespaceMetadata
+	var err error
+	switch {
+	case c.isClusterAgentEnabled():
+		metadata, err = c.dcaClient.GetNamespaceMetadata(ns)
+	case c.apiClient != nil:
+		metadata, err = c.apiClient.NamespaceMetadata(ns)
+	default:
+		err = fmt.Errorf("no client to query the namespace metadata")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = &apiv1.NamespaceMetadata{}
+	}
+	c.namespaceMetadata[ns] = metadata
+	return metadata, nil
+}
+
+// resetNamespaceMetadata forgets the cached namespace metadata
+func (c *KubeMetadataCollector) resetNamespaceMetadata() {
+	c.namespaceMetadataMu.Lock()
+	defer c.namespaceMetadataMu.Unlock()
+
+	c.namespaceMetadata = make(map[string]*apiv1.NamespaceMetadata)
+}
+
 // addToCacheMetadataMapping is acting like the DCA at the node level.
 func (c *KubeMetadataCollector) addToCacheMetadataMapping(kubeletPodList []*kubelet.Pod) error {
 	if len(kubeletPodList) == 0 {
@@ -15,11 +15,14 @@ import (
 
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -32,6 +35,9 @@ type FakeDCAClient struct {
 	NodeLabel    map[string]string
 	NodeLabelErr error
 
+	NamespaceMetadata    map[string]*apiv1.NamespaceMetadata
+	NamespaceMetadataErr error
+
 	PodMetadataForNode    apiv1.NamespacesPodsStringsSet
 	PodMetadataForNodeErr error
 
@@ -60,6 +66,9 @@ func (f *FakeDCAClient) GetVersion() (version.Version, error) {
 func (f *FakeDCAClient) GetNodeLabels(nodeName string) (map[string]string, error) {
 	return f.NodeLabel, f.NodeLabelErr
 }
+func (f *FakeDCAClient) GetNamespaceMetadata(ns string) (*apiv1.NamespaceMetadata, error) {
+	return f.NamespaceMetadata[ns], f.NamespaceMetadataErr
+}
 func (f *FakeDCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error) {
 	return f.PodMetadataForNode, f.PodMetadataForNodeErr
 }
@@ -362,3 +371,52 @@ func TestKubeMetadataCollector_getTagInfos(t *testing.T) {
 		})
 	}
 }
+
+func TestKubeMetadataCollector_addNamespaceTags(t *testing.T) {
+	dcaClient := &FakeDCAClient{
+		LocalVersion: version.Version{Major: 1, Minor: 7},
+		NamespaceMetadata: map[string]*apiv1.NamespaceMetadata{
+			"default": {
+				Labels:      map[string]string{"Team": "containers", "env": "prod"},
+				Annotations: map[string]string{"cost-center": "1234"},
+			},
+		},
+	}
+	c := &KubeMetadataCollector{
+		dcaClient:                  dcaClient,
+		clusterAgentEnabled:        true,
+		namespaceLabelsAsTags:      lowerCaseKeys(map[string]string{"team": "team", "ENV": "+env"}),
+		namespaceAnnotationsAsTags: map[string]string{"cost-center": "cost_center"},
+		namespaceMetadata:          make(map[string]*apiv1.NamespaceMetadata),
+	}
+
+	tagList := utils.NewTagList()
+	c.addNamespaceTags(tagList, "default")
+	low, _, high := tagList.Compute()
+	assert.ElementsMatch(t, []string{"team:containers", "cost_center:1234"}, low)
+	assert.ElementsMatch(t, []string{"env:prod"}, high)
+
+	// the metadata is cached until the next pull
+	dcaClient.NamespaceMetadata["default"].Labels = map[string]string{"team": "agent"}
+	tagList = utils.NewTagList()
+	c.addNamespaceTags(tagList, "default")
+	low, _, _ = tagList.Compute()
+	assert.Contains(t, low, "team:containers")
+
+	c.resetNamespaceMetadata()
+	tagList = utils.NewTagList()
+	c.addNamespaceTags(tagList, "default")
+	low, _, _ = tagList.Compute()
+	assert.Contains(t, low, "team:agent")
+
+	// unknown namespaces and errors don't add tags
+	tagList = utils.NewTagList()
+	c.addNamespaceTags(tagList, "kube-system")
+	low, _, high = tagList.Compute()
+	assert.Len(t, low, 0)
+	assert.Len(t, high, 0)
+
+	dcaClient.NamespaceMetadataErr = fmt.Errorf("unavailable")
+	_, err := c.getNamespaceMetadata("monitoring")
+	require.Error(t, err)
+}
@@ -35,12 +35,12 @@ func Init() {
 		checkCard := config.Datadog.GetString("checks_tag_cardinality")
 		dsdCard := config.Datadog.GetString("dogstatsd_tag_cardinality")
 
-		ChecksCardinality, err = stringToTagCardinality(checkCard)
+		ChecksCardinality, err = StringToTagCardinality(checkCard)
 		if err != nil {
 			log.Warnf("failed to parse check tag cardinality, defaulting to low. Error: %s", err)
 			ChecksCardinality = collectors.LowCardinality
 		}
-		DogstatsdCardinality, err = stringToTagCardinality(dsdCard)
+		DogstatsdCardinality, err = StringToTagCardinality(dsdCard)
 		if err != nil {
 			log.Warnf("failed to parse dogstatsd tag cardinality, defaulting to low. Error: %s", err)
 			DogstatsdCardinality = collectors.LowCardinality
@@ -62,6 +62,12 @@ func OrchestratorScopeTag() ([]string, error) {
 	return defaultTagger.Tag(collectors.OrchestratorScopeEntityID, collectors.OrchestratorCardinality)
 }
 
+// AddDeletionListener registers a listener notified of the entities deleted
+// from the defaultTagger
+func AddDeletionListener(listener DeletionListener) {
+	defaultTagger.AddDeletionListener(listener)
+}
+
 // Stop queues a stop signal to the defaultTagger
 func Stop() error {
 	return defaultTagger.Stop()
@@ -77,9 +83,9 @@ func GetEntityHash(entity string) string {
 	return defaultTagger.GetEntityHash(entity)
 }
 
-// stringToTagCardinality extracts a TagCardinality from a string.
+// StringToTagCardinality extracts a TagCardinality from a string.
 // In case of failure to parse, returns an error and defaults to Low.
-func stringToTagCardinality(c string) (collectors.TagCardinality, error) {
+func StringToTagCardinality(c string) (collectors.TagCardinality, error) {
 	switch strings.ToLower(c) {
 	case "high":
 		return collectors.HighCardinality, nil
@@ -36,8 +36,14 @@ type Tagger struct {
 	retryTicker *time.Ticker
 	stop        chan bool
 	health      *health.Handle
+
+	deletionListeners []DeletionListener
 }
 
+// DeletionListener is notified of the entities reported as deleted by the
+// collectors. It is called from the tagger loop and must not block.
+type DeletionListener func(entities []string)
+
 type collectorReply struct {
 	name     string
 	mode     collectors.CollectionMode
@@ -103,8 +109,15 @@ func (t *Tagger) run() error {
 			return nil
 		case <-t.health.C:
 		case msg := <-t.infoIn:
+			var deleted []string
 			for _, info := range msg {
 				t.tagStore.processTagInfo(info) //nolint:errcheck
+				if info != nil && info.DeleteEntity && info.Entity != "" {
+					deleted = append(deleted, info.Entity)
+				}
+			}
+			if len(deleted) > 0 {
+				t.notifyDeletion(deleted)
 			}
 		case <-t.retryTicker.C:
 			go t.startCollectors()
@@ -208,6 +221,23 @@ func (t *Tagger) pull() {
 	t.RUnlock()
 }
 
+// AddDeletionListener registers a listener notified of the entities reported
+// as deleted. Their tags are kept until the next prune, so that the data
+// emitted right before the deletion is still tagged.
+func (t *Tagger) AddDeletionListener(listener DeletionListener) {
+	t.Lock()
+	t.deletionListeners = append(t.deletionListeners, listener)
+	t.Unlock()
+}
+
+func (t *Tagger) notifyDeletion(entities []string) {
+	t.RLock()
+	for _, listener := range t.deletionListeners {
+		listener(entities)
+	}
+	t.RUnlock()
+}
+
 // Stop queues a shutdown of Tagger
 func (t *Tagger) Stop() error {
 	t.stop <- true
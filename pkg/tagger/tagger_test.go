@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -305,3 +306,35 @@ func TestSafeCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"low1", "low2", "low3"}, tags2)
 }
+
+func TestDeletionListener(t *testing.T) {
+	catalog := collectors.Catalog{"pull": NewDummyPuller}
+	tagger := newTagger()
+	tagger.Init(catalog)
+	defer tagger.Stop()
+
+	deleted := make(chan []string, 1)
+	tagger.AddDeletionListener(func(entities []string) {
+		deleted <- entities
+	})
+
+	tagger.infoIn <- []*collectors.TagInfo{
+		{
+			Entity:      "entity_name",
+			Source:      "pull",
+			LowCardTags: []string{"low1"},
+		},
+		{
+			Entity:       "deleted_entity",
+			Source:       "pull",
+			DeleteEntity: true,
+		},
+	}
+
+	select {
+	case entities := <-deleted:
+		assert.Equal(t, []string{"deleted_entity"}, entities)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "deletion listener was not called")
+	}
+}
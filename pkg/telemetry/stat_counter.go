@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package telemetry
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatCounter is an internal counter of the Agent, counted once and exposed
+// both on the telemetry endpoint and in the expvars read by the status page,
+// so that both always report the same values.
+type StatCounter struct {
+	desc *prometheus.Desc
+	tags []string
+
+	mu     sync.RWMutex
+	values map[string]*StatValue
+}
+
+// StatValue is the value of a StatCounter for given tags value. Keeping it
+// avoids looking the tags value up in hot paths.
+type StatValue struct {
+	value     int64
+	tagsValue []string
+}
+
+// NewStatCounter creates a StatCounter with default options.
+func NewStatCounter(subsystem, name string, tags []string, help string) *StatCounter {
+	return NewStatCounterWithOpts(subsystem, name, tags, help, DefaultOptions)
+}
+
+// NewStatCounterWithOpts creates a StatCounter with the given options.
+// See NewStatCounter()
+func NewStatCounterWithOpts(subsystem, name string, tags []string, help string, opts Options) *StatCounter {
+	// subsystem is optional
+	if subsystem != "" && !opts.NoDoubleUnderscoreSep {
+		// Prefix metrics with a _, prometheus will add a second _
+		// It will create metrics with a custom separator and
+		// will let us replace it to a dot later in the process.
+		name = fmt.Sprintf("_%s", name)
+	}
+
+	c := &StatCounter{
+		desc:   prometheus.NewDesc(prometheus.BuildFQName("", subsystem, name), help, tags, nil),
+		tags:   tags,
+		values: make(map[string]*StatValue),
+	}
+	telemetryRegistry.MustRegister(c)
+	return c
+}
+
+// WithValues returns the value of the counter for the given tags value,
+// which must match the tags of the counter.
+func (c *StatCounter) WithValues(tagsValue ...string) *StatValue {
+	if len(tagsValue) != len(c.tags) {
+		panic(fmt.Sprintf("expected %d tags value, got %d", len(c.tags), len(tagsValue)))
+	}
+	key := strings.Join(tagsValue, "\xff")
+
+	c.mu.RLock()
+	v, found := c.values[key]
+	c.mu.RUnlock()
+	if found {
+		return v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, found = c.values[key]; !found {
+		v = &StatValue{tagsValue: append([]string(nil), tagsValue...)}
+		c.values[key] = v
+	}
+	return v
+}
+
+// Inc increments the counter with the given tags value.
+func (c *StatCounter) Inc(tagsValue ...string) {
+	c.WithValues(tagsValue...).Add(1)
+}
+
+// Add adds the given value to the counter with the given tags value.
+func (c *StatCounter) Add(value int64, tagsValue ...string) {
+	c.WithValues(tagsValue...).Add(value)
+}
+
+// Get returns the value of the counter for the given tags value.
+func (c *StatCounter) Get(tagsValue ...string) int64 {
+	return c.WithValues(tagsValue...).Get()
+}
+
+// Total returns the sum of the values of the counter for all the tags value.
+func (c *StatCounter) Total() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var total int64
+	for _, v := range c.values {
+		total += v.Get()
+	}
+	return total
+}
+
+// Expvar returns an expvar.Var publishing the total of the counter.
+func (c *StatCounter) Expvar() expvar.Var {
+	return expvar.Func(func() interface{} { return c.Total() })
+}
+
+// Describe implements prometheus.Collector
+func (c *StatCounter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector
+func (c *StatCounter) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, v := range c.values {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(v.Get()), v.tagsValue...)
+	}
+}
+
+// Inc increments the value.
+func (v *StatValue) Inc() {
+	atomic.AddInt64(&v.value, 1)
+}
+
+// Add adds the given delta to the value.
+func (v *StatValue) Add(delta int64) {
+	atomic.AddInt64(&v.value, delta)
+}
+
+// Get returns the value.
+func (v *StatValue) Get() int64 {
+	return atomic.LoadInt64(&v.value)
+}
+
+// Expvar returns an expvar.Var publishing the value.
+func (v *StatValue) Expvar() expvar.Var {
+	return expvar.Func(func() interface{} { return v.Get() })
+}
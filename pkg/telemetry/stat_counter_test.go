@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatheredValues returns the values of a metric on the telemetry endpoint by joined label values
+func gatheredValues(t *testing.T, name string) map[string]float64 {
+	families, err := telemetryRegistry.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			key := ""
+			for _, label := range m.GetLabel() {
+				key += label.GetName() + ":" + label.GetValue() + ","
+			}
+			values[key] = m.GetCounter().GetValue()
+		}
+	}
+	return values
+}
+
+func TestStatCounter(t *testing.T) {
+	c := NewStatCounter("test", "stat_counter", []string{"type", "state"}, "Test counter")
+	ok := c.WithValues("metrics", "ok")
+	ok.Inc()
+	ok.Add(2)
+	c.Inc("metrics", "error")
+	c.Add(5, "events", "ok")
+
+	assert.Equal(t, int64(3), c.Get("metrics", "ok"))
+	assert.Equal(t, int64(1), c.Get("metrics", "error"))
+	assert.Equal(t, int64(9), c.Total())
+	assert.Same(t, ok, c.WithValues("metrics", "ok"))
+
+	// expvar and the telemetry endpoint share the values
+	assert.Equal(t, "9", c.Expvar().String())
+	assert.Equal(t, "3", ok.Expvar().String())
+	assert.Equal(t, map[string]float64{
+		"state:ok,type:metrics,":    3,
+		"state:error,type:metrics,": 1,
+		"state:ok,type:events,":     5,
+	}, gatheredValues(t, "test__stat_counter"))
+
+	assert.Panics(t, func() { c.Inc("metrics") })
+}
+
+func TestStatCounterNoTags(t *testing.T) {
+	c := NewStatCounter("test", "stat_counter_no_tags", nil, "Test counter")
+	c.Inc()
+	c.Inc()
+	assert.Equal(t, int64(2), c.Total())
+	assert.Equal(t, map[string]float64{"": 2}, gatheredValues(t, "test__stat_counter_no_tags"))
+}
@@ -8,6 +8,7 @@ package agent
 import (
 	"context"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -48,6 +49,9 @@ type Agent struct {
 	// tags based on their type.
 	obfuscator *obfuscate.Obfuscator
 
+	// obfuscationDiagnostics is nil unless apm_config.obfuscation_diagnostics_rate is set
+	obfuscationDiagnostics *obfuscationDiagnostics
+
 	In  chan *api.Trace
 	Out chan *writer.SampledSpans
 
@@ -56,6 +60,9 @@ type Agent struct {
 
 	// Used to synchronize on a clean exit
 	ctx context.Context
+
+	// wg waits for the workers processing the received traces
+	wg sync.WaitGroup
 }
 
 // NewAgent returns a new Agent object, ready to be started. It takes a context
@@ -66,23 +73,31 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 	out := make(chan *writer.SampledSpans, 1000)
 	statsChan := make(chan []stats.Bucket)
 
+	if conf.MaxResourceLen > 0 {
+		MaxResourceLen = conf.MaxResourceLen
+	}
+	var diagnostics *obfuscationDiagnostics
+	if conf.ObfuscationDiagnosticsRate > 0 {
+		diagnostics = newObfuscationDiagnostics()
+	}
 	return &Agent{
-		Receiver:           api.NewHTTPReceiver(conf, dynConf, in),
-		Concentrator:       stats.NewConcentrator(conf.ExtraAggregators, conf.BucketInterval.Nanoseconds(), statsChan),
-		Blacklister:        filters.NewBlacklister(conf.Ignore["resource"]),
-		Replacer:           filters.NewReplacer(conf.ReplaceTags),
-		ScoreSampler:       NewScoreSampler(conf),
-		ExceptionSampler:   sampler.NewExceptionSampler(),
-		ErrorsScoreSampler: NewErrorsSampler(conf),
-		PrioritySampler:    NewPrioritySampler(conf, dynConf),
-		EventProcessor:     newEventProcessor(conf),
-		TraceWriter:        writer.NewTraceWriter(conf, out),
-		StatsWriter:        writer.NewStatsWriter(conf, statsChan),
-		obfuscator:         obfuscate.NewObfuscator(conf.Obfuscation),
-		In:                 in,
-		Out:                out,
-		conf:               conf,
-		ctx:                ctx,
+		Receiver:               api.NewHTTPReceiver(conf, dynConf, in),
+		Concentrator:           stats.NewConcentrator(conf.ExtraAggregators, conf.BucketInterval.Nanoseconds(), statsChan),
+		Blacklister:            filters.NewBlacklister(conf.Ignore["resource"]),
+		Replacer:               filters.NewReplacer(conf.ReplaceTags),
+		ScoreSampler:           NewScoreSampler(conf),
+		ExceptionSampler:       sampler.NewExceptionSampler(&conf.RareSampler),
+		ErrorsScoreSampler:     NewErrorsSampler(conf),
+		PrioritySampler:        NewPrioritySampler(conf, dynConf),
+		EventProcessor:         newEventProcessor(conf),
+		TraceWriter:            writer.NewTraceWriter(conf, out),
+		StatsWriter:            writer.NewStatsWriter(conf, statsChan),
+		obfuscator:             obfuscate.NewObfuscator(conf.Obfuscation),
+		obfuscationDiagnostics: diagnostics,
+		In:                     in,
+		Out:                    out,
+		conf:                   conf,
+		ctx:                    ctx,
 	}
 }
 
@@ -103,6 +118,7 @@ func (a *Agent) Run() {
 	go a.StatsWriter.Run()
 
 	for i := 0; i < runtime.NumCPU(); i++ {
+		a.wg.Add(1)
 		go a.work()
 	}
 
@@ -110,6 +126,7 @@ func (a *Agent) Run() {
 }
 
 func (a *Agent) work() {
+	defer a.wg.Done()
 	for {
 		select {
 		case t, ok := <-a.In:
@@ -130,6 +147,10 @@ func (a *Agent) loop() {
 			if err := a.Receiver.Stop(); err != nil {
 				log.Error(err)
 			}
+			// let the workers process the traces left before stopping the
+			// concentrator; the receiver closes the input channel once its
+			// requests are done, which may not happen when its shutdown fails
+			a.waitWorkers(a.conf.ShutdownDrainTimeout)
 			a.Concentrator.Stop()
 			a.TraceWriter.Stop()
 			a.StatsWriter.Stop()
@@ -138,11 +159,28 @@ func (a *Agent) loop() {
 			a.ErrorsScoreSampler.Stop()
 			a.PrioritySampler.Stop()
 			a.EventProcessor.Stop()
+			if a.obfuscationDiagnostics != nil {
+				a.obfuscationDiagnostics.Stop()
+			}
 			return
 		}
 	}
 }
 
+// waitWorkers waits for the workers to exit, for at most the given timeout.
+func (a *Agent) waitWorkers(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warnf("Traces still being processed after %s, exiting.", timeout)
+	}
+}
+
 // Process is the default work unit that receives a trace, transforms it and
 // passes it downstream.
 func (a *Agent) Process(t *api.Trace) {
@@ -185,9 +223,14 @@ func (a *Agent) Process(t *api.Trace) {
 	}
 
 	// Extra sanitization steps of the trace.
+	diagnose := a.obfuscationDiagnostics != nil && sampler.SampleByRate(root.TraceID, a.conf.ObfuscationDiagnosticsRate)
 	for _, span := range t.Spans {
+		raw := span.Resource
 		a.obfuscator.Obfuscate(span)
 		Truncate(span)
+		if diagnose {
+			a.obfuscationDiagnostics.record(span, raw)
+		}
 	}
 	a.Replacer.Replace(t.Spans)
 
@@ -234,6 +277,10 @@ func (a *Agent) Process(t *api.Trace) {
 		a.sample(ts, pt)
 	}
 
+	if t.ClientComputedStats {
+		// the tracer already computed and sent the stats of this trace
+		return
+	}
 	a.Concentrator.In <- &stats.Input{
 		Trace:     pt.WeightedTrace,
 		Sublayers: pt.Sublayers,
@@ -281,8 +328,10 @@ func (a *Agent) samplePriorityTrace(pt ProcessedTrace) (sampled bool, rate float
 		sampledError, rateError := a.ErrorsScoreSampler.Add(pt)
 		return sampledError || sampledPriority, sampler.CombineRates(ratePriority, rateError)
 	}
-	if sampled := a.ExceptionSampler.Add(pt.Env, pt.Root, pt.Trace); sampled {
-		return sampled, 1
+	if a.conf.RareSampler.Enabled {
+		if sampled := a.ExceptionSampler.Add(pt.Env, pt.Root, pt.Trace); sampled {
+			return sampled, 1
+		}
 	}
 	return sampledPriority, ratePriority
 }
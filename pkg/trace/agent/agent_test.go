@@ -167,6 +167,30 @@ func TestProcess(t *testing.T) {
 		assert.Equal(t, "A:B,C", span.Meta[tagContainersTags])
 	})
 
+	t.Run("ClientComputedStats", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		for _, ccs := range []bool{true, false} {
+			agnt.Process(&api.Trace{
+				Spans: pb.Trace{&pb.Span{
+					Resource: "SELECT name FROM people WHERE age = 42",
+					Type:     "sql",
+					Start:    time.Now().Add(-time.Second).UnixNano(),
+					Duration: (500 * time.Millisecond).Nanoseconds(),
+				}},
+				Source:              &info.Tags{},
+				ClientComputedStats: ccs,
+			})
+		}
+
+		// only the trace without client computed stats reaches the concentrator
+		assert.Len(t, agnt.Concentrator.In, 1)
+	})
+
 	t.Run("Stats/Priority", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
@@ -217,6 +241,17 @@ func TestProcess(t *testing.T) {
 	})
 }
 
+func TestWaitWorkers(t *testing.T) {
+	var a Agent
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	// a worker still waiting for its input doesn't block the shutdown
+	start := time.Now()
+	a.waitWorkers(10 * time.Millisecond)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
+
 func TestSampling(t *testing.T) {
 	for name, tt := range map[string]struct {
 		// hasErrors will be true if the input trace should have errors
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// diagnosticsReportInterval specifies the frequency at which the obfuscation diagnostics are logged.
+	diagnosticsReportInterval = 5 * time.Minute
+	// diagnosticsTopOffenders is the number of offenders logged in each report.
+	diagnosticsTopOffenders = 10
+	// diagnosticsCardinalityLimit limits the number of groups, and of resources per group, tracked.
+	diagnosticsCardinalityLimit = 1000
+	// diagnosticsExampleLen is the maximum length of the example resource logged for an offender.
+	diagnosticsExampleLen = 200
+)
+
+// diagnosticsKey identifies a group of spans in the obfuscation diagnostics.
+type diagnosticsKey struct {
+	service string
+	name    string
+}
+
+// diagnosticsOffender is a group of spans whose obfuscated resources don't collapse.
+type diagnosticsOffender struct {
+	diagnosticsKey
+	raw         int    // number of distinct raw resources
+	obfuscated  int    // number of distinct obfuscated resources
+	uncollapsed int    // number of obfuscated resources matching a single raw resource
+	example     string // an obfuscated resource matching a single raw resource
+}
+
+// obfuscationDiagnostics computes, for a sample of the spans, how many distinct raw resources
+// collapse into each obfuscated resource, and periodically logs the groups of spans whose
+// obfuscated resources collapse the least. These are likely to be obfuscation gaps, causing
+// cardinality explosions.
+type obfuscationDiagnostics struct {
+	mu sync.Mutex
+	// groups maps the obfuscated resources of each group to the hashes of their raw resources
+	groups map[diagnosticsKey]map[string]map[uint64]struct{}
+
+	tickReport *time.Ticker
+}
+
+func newObfuscationDiagnostics() *obfuscationDiagnostics {
+	d := &obfuscationDiagnostics{
+		groups:     make(map[diagnosticsKey]map[string]map[uint64]struct{}),
+		tickReport: time.NewTicker(diagnosticsReportInterval),
+	}
+	go func() {
+		for range d.tickReport.C {
+			d.report()
+		}
+	}()
+	return d
+}
+
+// Stop stops reporting the diagnostics
+func (d *obfuscationDiagnostics) Stop() {
+	d.tickReport.Stop()
+}
+
+// record records the resource of the span, obfuscated from the raw one.
+func (d *obfuscationDiagnostics) record(span *pb.Span, raw string) {
+	h := fnv.New64a()
+	h.Write([]byte(raw)) //nolint:errcheck
+	key := diagnosticsKey{service: span.Service, name: span.Name}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	group, ok := d.groups[key]
+	if !ok {
+		if len(d.groups) >= diagnosticsCardinalityLimit {
+			return
+		}
+		group = make(map[string]map[uint64]struct{})
+		d.groups[key] = group
+	}
+	raws, ok := group[span.Resource]
+	if !ok {
+		if len(group) >= diagnosticsCardinalityLimit {
+			return
+		}
+		raws = make(map[uint64]struct{})
+		group[span.Resource] = raws
+	}
+	if len(raws) < diagnosticsCardinalityLimit {
+		raws[h.Sum64()] = struct{}{}
+	}
+}
+
+// offenders returns the groups recorded since the last call having obfuscated resources
+// matching a single raw resource, the ones having the most first.
+func (d *obfuscationDiagnostics) offenders() []diagnosticsOffender {
+	d.mu.Lock()
+	groups := d.groups
+	d.groups = make(map[diagnosticsKey]map[string]map[uint64]struct{})
+	d.mu.Unlock()
+
+	var offenders []diagnosticsOffender
+	for key, group := range groups {
+		o := diagnosticsOffender{diagnosticsKey: key, obfuscated: len(group)}
+		for resource, raws := range group {
+			o.raw += len(raws)
+			if len(raws) == 1 {
+				o.uncollapsed++
+				if o.example == "" || resource < o.example {
+					// keep the example stable across runs
+					o.example = resource
+				}
+			}
+		}
+		if o.uncollapsed > 1 {
+			offenders = append(offenders, o)
+		}
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].uncollapsed != offenders[j].uncollapsed {
+			return offenders[i].uncollapsed > offenders[j].uncollapsed
+		}
+		return offenders[i].service+offenders[i].name < offenders[j].service+offenders[j].name
+	})
+	return offenders
+}
+
+func (d *obfuscationDiagnostics) report() {
+	offenders := d.offenders()
+	if len(offenders) == 0 {
+		log.Debug("Obfuscation diagnostics: all the sampled resources were collapsed")
+		return
+	}
+	if len(offenders) > diagnosticsTopOffenders {
+		offenders = offenders[:diagnosticsTopOffenders]
+	}
+	for _, o := range offenders {
+		log.Infof("Obfuscation diagnostics: service %q, span %q: %d sampled raw resources obfuscated into %d resources, %d of which did not collapse, e.g. %q",
+			o.service, o.name, o.raw, o.obfuscated, o.uncollapsed, traceutil.TruncateUTF8(o.example, diagnosticsExampleLen))
+	}
+}
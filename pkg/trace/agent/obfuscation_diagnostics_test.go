@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscationDiagnostics(t *testing.T) {
+	d := newObfuscationDiagnostics()
+	defer d.Stop()
+
+	record := func(service, raw, resource string) {
+		d.record(&pb.Span{Service: service, Name: "query", Resource: resource}, raw)
+	}
+	// collapsed resources
+	record("db", "SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?")
+	record("db", "SELECT * FROM users WHERE id = 2", "SELECT * FROM users WHERE id = ?")
+	record("db", "SELECT * FROM users WHERE id = 2", "SELECT * FROM users WHERE id = ?")
+	// uncollapsed resources
+	record("cache", "GET user:1", "GET user:1")
+	record("cache", "GET user:2", "GET user:2")
+	record("cache", "GET user:3", "GET user:3")
+	record("db", "SELECT * FROM orders_2020", "SELECT * FROM orders_2020")
+	record("db", "SELECT * FROM orders_2021", "SELECT * FROM orders_2021")
+
+	assert.Equal(t, []diagnosticsOffender{
+		{
+			diagnosticsKey: diagnosticsKey{service: "cache", name: "query"},
+			raw:            3,
+			obfuscated:     3,
+			uncollapsed:    3,
+			example:        "GET user:1",
+		},
+		{
+			diagnosticsKey: diagnosticsKey{service: "db", name: "query"},
+			raw:            4,
+			obfuscated:     3,
+			uncollapsed:    2,
+			example:        "SELECT * FROM orders_2020",
+		},
+	}, d.offenders())
+
+	// the diagnostics are reset after each report
+	assert.Empty(t, d.offenders())
+}
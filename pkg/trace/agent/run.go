@@ -22,9 +22,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
+	"github.com/DataDog/datadog-agent/pkg/trace/obfuscate"
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
 )
 
 const messageAgentDisabled = `trace-agent not enabled. Set the environment variable
@@ -54,6 +56,15 @@ func Run(ctx context.Context) {
 		return
 	}
 
+	if flags.ExportObfuscation {
+		out, err := obfuscate.EffectiveConfig(cfg.Obfuscation).Export()
+		if err != nil {
+			osutil.Exitf("Failed to export the obfuscation configuration: %s", err)
+		}
+		os.Stdout.Write(out) //nolint:errcheck
+		return
+	}
+
 	if err := coreconfig.SetupLogger(
 		coreconfig.LoggerName("TRACE"),
 		cfg.LogLevel,
@@ -114,6 +125,11 @@ func Run(ctx context.Context) {
 	tagger.Init()
 	defer tagger.Stop()
 
+	if err := payloadaudit.StartFromConfig(); err != nil {
+		log.Errorf("Could not start the payload audit: %v", err)
+	}
+	defer payloadaudit.Stop()
+
 	agnt := NewAgent(ctx, cfg)
 	log.Infof("Trace agent running on host %s", cfg.Hostname)
 	agnt.Run()
@@ -45,15 +45,21 @@ func NewScoreSampler(conf *config.AgentConfig) *Sampler {
 // ScoreSampler except that its statistics are reported under a different name.
 func NewErrorsSampler(conf *config.AgentConfig) *Sampler {
 	return &Sampler{
-		engine: sampler.NewErrorsEngine(conf.ExtraSampleRate, conf.MaxTPS),
+		engine: sampler.NewErrorsEngine(conf.ExtraSampleRate, conf.ErrorTPS),
 		exit:   make(chan struct{}),
 	}
 }
 
 // NewPrioritySampler creates a new empty distributed sampler ready to be started
 func NewPrioritySampler(conf *config.AgentConfig, dynConf *sampler.DynamicConfig) *Sampler {
+	targetTPS := make(map[sampler.ServiceSignature]float64)
+	for service, envs := range conf.TargetTPSByService {
+		for env, tps := range envs {
+			targetTPS[sampler.ServiceSignature{Name: service, Env: env}] = tps
+		}
+	}
 	return &Sampler{
-		engine: sampler.NewPriorityEngine(conf.ExtraSampleRate, conf.MaxTPS, &dynConf.RateByService),
+		engine: sampler.NewPriorityEngine(conf.ExtraSampleRate, conf.MaxTPS, targetTPS, &dynConf.RateByService),
 		exit:   make(chan struct{}),
 	}
 }
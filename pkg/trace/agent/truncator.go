@@ -6,13 +6,18 @@
 package agent
 
 import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// MaxResourceLen the maximum length the resource can have
+// MaxResourceLen the maximum length the resource can have. It can be set with
+// apm_config.max_resource_length.
 var MaxResourceLen = 5000
 
 func init() {
@@ -35,7 +40,7 @@ const (
 func Truncate(s *pb.Span) {
 	// Resource
 	if len(s.Resource) > MaxResourceLen {
-		s.Resource = traceutil.TruncateUTF8(s.Resource, MaxResourceLen)
+		s.Resource = truncateResource(s.Resource, MaxResourceLen)
 		log.Debugf("span.truncate: truncated `Resource` (max %d chars): %s", MaxResourceLen, s.Resource)
 	}
 	// Error - Nothing to do
@@ -70,3 +75,20 @@ func Truncate(s *pb.Span) {
 		}
 	}
 }
+
+// truncateResource truncates the resource to the given length, at the last token
+// boundary when it doesn't discard most of the resource, and suffixes it with a hash
+// of the full resource so that resources sharing a long prefix remain distinct.
+func truncateResource(resource string, limit int) string {
+	h := fnv.New64a()
+	h.Write([]byte(resource)) //nolint:errcheck
+	suffix := fmt.Sprintf("... %016x", h.Sum64())
+	if limit <= len(suffix) {
+		return traceutil.TruncateUTF8(resource, limit)
+	}
+	truncated := traceutil.TruncateUTF8(resource, limit-len(suffix))
+	if i := strings.LastIndexByte(truncated, ' '); i > len(truncated)/2 {
+		truncated = truncated[:i]
+	}
+	return truncated + suffix
+}
@@ -49,6 +49,20 @@ func TestTruncateLongResource(t *testing.T) {
 	assert.Equal(t, 5000, len(s.Resource))
 }
 
+func TestTruncateResourceTokenBoundary(t *testing.T) {
+	resource := "SELECT " + strings.Repeat("column, ", 1000) + "other FROM table"
+	truncated := truncateResource(resource, 100)
+	assert.True(t, len(truncated) <= 100)
+	assert.True(t, strings.HasPrefix(truncated, "SELECT column, column,"))
+	assert.Regexp(t, `column,\.\.\. [0-9a-f]{16}$`, truncated)
+
+	// resources sharing the same prefix remain distinct
+	assert.NotEqual(t, truncated, truncateResource(resource+" WHERE id = ?", 100))
+	assert.Equal(t, truncated, truncateResource(resource, 100))
+
+	assert.Equal(t, "SELECT col", truncateResource(resource, 10))
+}
+
 func TestTruncateMetricsPassThru(t *testing.T) {
 	s := testSpan()
 	before := s.Metrics
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package api
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/coreos/go-systemd/activation"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// activatedListeners returns the listening sockets passed by systemd to the trace-agent
+// when it is socket activated, see sd_listen_fds(3), by network: "tcp" or "unix". It
+// returns nil when the trace-agent wasn't socket activated.
+func activatedListeners() (map[string]net.Listener, error) {
+	files := activation.Files(true)
+	if len(files) == 0 {
+		return nil, nil
+	}
+	lns := make(map[string]net.Listener, len(files))
+	for _, f := range files {
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %q is not a listening stream socket: %v", f.Name(), err)
+		}
+		network := ln.Addr().Network()
+		if _, ok := lns[network]; ok || (network != "tcp" && network != "unix") {
+			log.Warnf("Ignoring the %s socket %q passed by systemd.", network, f.Name())
+			ln.Close()
+			continue
+		}
+		log.Infof("Using the %s socket %q passed by systemd.", network, f.Name())
+		lns[network] = ln
+	}
+	return lns, nil
+}
@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package api
+
+import "net"
+
+// activatedListeners returns nil: socket activation is only supported with systemd, on Linux.
+func activatedListeners() (map[string]net.Listener, error) {
+	return nil, nil
+}
@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"os/user"
 	"runtime"
 	"sort"
 	"strconv"
@@ -64,12 +65,21 @@ const (
 	// Traces: msgpack/JSON (Content-Type) slice of traces + returns service sampling ratios
 	// Services: deprecated
 	v04 Version = "v0.4"
+	// v07
+	// Traces: msgpack slice of traces, decoded one trace at a time + returns service sampling ratios
+	// Services: not supported
+	v07 Version = "v0.7"
 )
 
+// maxTracerClients is the maximum number of tracer clients whose stats are
+// reported by the /debug/clients endpoint
+const maxTracerClients = 100
+
 // HTTPReceiver is a collector that uses HTTP protocol and just holds
 // a chan where the spans received are sent one by one
 type HTTPReceiver struct {
 	Stats       *info.ReceiverStats
+	Clients     *info.ClientsStats
 	RateLimiter *rateLimiter
 
 	out     chan *Trace
@@ -92,6 +102,7 @@ func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, o
 	}
 	return &HTTPReceiver{
 		Stats:       info.NewReceiverStats(),
+		Clients:     info.NewClientsStats(maxTracerClients),
 		RateLimiter: newRateLimiter(),
 		out:         out,
 
@@ -121,6 +132,8 @@ func (r *HTTPReceiver) Start() {
 	mux.HandleFunc("/v0.3/services", r.handleWithVersion(v03, r.handleServices))
 	mux.HandleFunc("/v0.4/traces", r.handleWithVersion(v04, r.handleTraces))
 	mux.HandleFunc("/v0.4/services", r.handleWithVersion(v04, r.handleServices))
+	mux.HandleFunc("/v0.7/traces", r.handleWithVersion(v07, r.handleTraces))
+	mux.HandleFunc("/info", r.handleInfo)
 	mux.Handle("/profiling/v1/input", r.profileProxyHandler())
 
 	timeout := 5 * time.Second
@@ -133,10 +146,24 @@ func (r *HTTPReceiver) Start() {
 		WriteTimeout: timeout,
 		ErrorLog:     stdlog.New(httpLogger, "http.Server: ", 0),
 		Handler:      mux,
+		ConnContext:  connContext,
+	}
+
+	// with socket activation, systemd listens on behalf of the trace-agent and passes
+	// it the sockets, which take precedence over the configured ones.
+	activated, err := activatedListeners()
+	if err != nil {
+		killProcess("Error reading the sockets passed by systemd: %v", err)
 	}
 
 	addr := fmt.Sprintf("%s:%d", r.conf.ReceiverHost, r.conf.ReceiverPort)
-	ln, err := r.listenTCP(addr)
+	var ln net.Listener
+	if tcpln, ok := activated["tcp"]; ok {
+		addr = tcpln.Addr().String()
+		ln, err = r.limitConnections(tcpln)
+	} else {
+		ln, err = r.listenTCP(addr)
+	}
 	if err != nil {
 		killProcess("Error creating tcp listener: %v", err)
 	}
@@ -146,16 +173,18 @@ func (r *HTTPReceiver) Start() {
 	}()
 	log.Infof("Listening for traces at http://%s", addr)
 
-	if path := r.conf.ReceiverSocket; path != "" {
-		ln, err := r.listenUnix(path)
-		if err != nil {
+	uds, ok := activated["unix"]
+	if path := r.conf.ReceiverSocket; !ok && path != "" {
+		if uds, err = r.listenUnix(path); err != nil {
 			killProcess("Error creating UDS listener: %v", err)
 		}
+	}
+	if uds != nil {
 		go func() {
 			defer watchdog.LogOnPanic()
-			r.server.Serve(ln)
+			r.server.Serve(uds)
 		}()
-		log.Infof("Listening for traces at unix://%s", path)
+		log.Infof("Listening for traces at unix://%s", uds.Addr())
 	}
 
 	go r.RateLimiter.Run()
@@ -201,10 +230,53 @@ func (r *HTTPReceiver) attachDebugHandlers(mux *http.ServeMux) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:"+mainconfig.Datadog.GetString("GUI_port"))
 		expvar.Handler().ServeHTTP(w, req)
 	}))
+
+	mux.HandleFunc("/debug/clients", r.handleClients)
+}
+
+// traceEndpoints are the trace endpoints supported by the receiver, the most
+// recent last, reported to the tracers by the /info endpoint
+var traceEndpoints = []string{
+	"/v0.3/traces",
+	"/v0.4/traces",
+	"/v0.7/traces",
 }
 
-// listenUnix returns a net.Listener listening on the given "unix" socket path.
+// handleInfo lets the tracers negotiate the protocol version, listing the
+// supported trace endpoints and the payload limits
+func (r *HTTPReceiver) handleInfo(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(struct {
+		Version         string   `json:"version"`
+		Endpoints       []string `json:"endpoints"`
+		MaxRequestBytes int64    `json:"max_request_bytes"`
+		MaxPayloadSpans int      `json:"max_payload_spans"`
+	}{
+		Version:         info.Version,
+		Endpoints:       traceEndpoints,
+		MaxRequestBytes: r.conf.MaxRequestBytes,
+		MaxPayloadSpans: r.conf.MaxPayloadSpans,
+	})
+	if err != nil {
+		log.Errorf("Error encoding the receiver info: %v", err)
+	}
+}
+
+// handleClients reports the payloads received from each tracer client and
+// why their traces were dropped
+func (r *HTTPReceiver) handleClients(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Clients.Reports()); err != nil {
+		log.Errorf("Error encoding the tracer clients stats: %v", err)
+	}
+}
+
+// listenUnix returns a net.Listener listening on the given "unix" socket path. Paths starting
+// with '@' address sockets in the abstract namespace of Linux, which have no file.
 func (r *HTTPReceiver) listenUnix(path string) (net.Listener, error) {
+	if strings.HasPrefix(path, "@") {
+		return net.Listen("unix", path)
+	}
 	fi, err := os.Stat(path)
 	if err == nil {
 		// already exists
@@ -219,18 +291,64 @@ func (r *HTTPReceiver) listenUnix(path string) (net.Listener, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := os.Chmod(path, 0722); err != nil {
+	mode := r.conf.ReceiverSocketMode
+	if mode == 0 {
+		mode = 0722
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
 		return nil, fmt.Errorf("error setting socket permissions: %v", err)
 	}
+	if err := chownSocket(path, r.conf.ReceiverSocketUser, r.conf.ReceiverSocketGroup); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("error setting socket ownership: %v", err)
+	}
 	return ln, err
 }
 
+// chownSocket changes the owners of the socket file to the given user and group, names or
+// IDs, leaving them unchanged when empty.
+func chownSocket(path, username, group string) error {
+	if username == "" && group == "" {
+		return nil
+	}
+	uid, gid := -1, -1
+	if username != "" {
+		u, err := user.Lookup(username)
+		if err != nil {
+			if u, err = user.LookupId(username); err != nil {
+				return fmt.Errorf("unknown user %q", username)
+			}
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return fmt.Errorf("unsupported user ID %q", u.Uid)
+		}
+	}
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			if g, err = user.LookupGroupId(group); err != nil {
+				return fmt.Errorf("unknown group %q", group)
+			}
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return fmt.Errorf("unsupported group ID %q", g.Gid)
+		}
+	}
+	return os.Chown(path, uid, gid)
+}
+
 // listenTCP creates a new net.Listener on the provided TCP address.
 func (r *HTTPReceiver) listenTCP(addr string) (net.Listener, error) {
 	tcpln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
+	return r.limitConnections(tcpln)
+}
+
+// limitConnections wraps the TCP listener to rate limit its connections.
+func (r *HTTPReceiver) limitConnections(tcpln net.Listener) (net.Listener, error) {
 	ln, err := newRateLimitedListener(tcpln, r.conf.ConnectionLimit)
 	go func() {
 		defer watchdog.LogOnPanic()
@@ -263,6 +381,10 @@ func (r *HTTPReceiver) handleWithVersion(v Version, f func(Version, http.Respons
 			// msgpack is only supported for versions >= v0.3
 			httpFormatError(w, v, fmt.Errorf("unsupported media type: %q", mediaType))
 			return
+		} else if mediaType != "application/msgpack" && v == v07 {
+			// only msgpack can be decoded one trace at a time
+			httpFormatError(w, v, fmt.Errorf("unsupported media type: %q", mediaType))
+			return
 		}
 
 		req.Body = NewLimitedReader(req.Body, r.conf.MaxRequestBytes)
@@ -295,6 +417,15 @@ const (
 	// container where the request originated.
 	headerContainerID = "Datadog-Container-ID"
 
+	// headerEntityID specifies the name of the header which contains the ID of the
+	// entity where the request originated, used when headerContainerID is not set.
+	// Container IDs are prefixed with "cid-".
+	headerEntityID = "Datadog-Entity-ID"
+
+	// headerClientComputedStats specifies the name of the header which is set when the
+	// client computed the stats of the traces of the payload itself.
+	headerClientComputedStats = "Datadog-Client-Computed-Stats"
+
 	// headerLang specifies the name of the header which contains the language from
 	// which the traces originate.
 	headerLang = "Datadog-Meta-Lang"
@@ -326,6 +457,10 @@ func (r *HTTPReceiver) tagStats(req *http.Request) *info.TagStats {
 	})
 }
 
+func (r *HTTPReceiver) clientStats(req *http.Request, tags info.Tags) *info.ClientStats {
+	return r.Clients.Get(info.Client{Tags: tags, PID: clientPID(req)})
+}
+
 func (r *HTTPReceiver) decodeTraces(v Version, req *http.Request) (pb.Traces, error) {
 	if v == v01 {
 		var spans []pb.Span
@@ -345,7 +480,7 @@ func (r *HTTPReceiver) replyOK(v Version, w http.ResponseWriter) {
 	switch v {
 	case v01, v02, v03:
 		httpOK(w)
-	case v04:
+	case v04, v07:
 		httpRateByService(w, r.dynConf)
 	}
 }
@@ -353,6 +488,7 @@ func (r *HTTPReceiver) replyOK(v Version, w http.ResponseWriter) {
 // handleTraces knows how to handle a bunch of traces
 func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.Request) {
 	ts := r.tagStats(req)
+	cs := r.clientStats(req, ts.Tags)
 	traceCount, err := traceCount(req)
 	if err != nil {
 		log.Warnf("Error getting trace count: %q. Functionality may be limited.", err)
@@ -364,6 +500,12 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 		w.WriteHeader(r.rateLimiterResponse)
 		r.replyOK(v, w)
 		atomic.AddInt64(&ts.PayloadRefused, 1)
+		atomic.AddInt64(&cs.PayloadRefused, 1)
+		return
+	}
+
+	if v == v07 {
+		r.handleTraceStream(ts, cs, w, req)
 		return
 	}
 
@@ -372,9 +514,12 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 		httpDecodingError(err, []string{"handler:traces", fmt.Sprintf("v:%s", v)}, w)
 		if err == ErrLimitedReaderLimitReached {
 			atomic.AddInt64(&ts.TracesDropped.PayloadTooLarge, traceCount)
+			atomic.AddInt64(&cs.TracesDropped.PayloadTooLarge, traceCount)
 		} else {
 			atomic.AddInt64(&ts.TracesDropped.DecodingError, traceCount)
+			atomic.AddInt64(&cs.TracesDropped.DecodingError, traceCount)
 		}
+		atomic.AddInt64(&cs.PayloadErrors, 1)
 		log.Errorf("Cannot decode %s traces payload: %v", v, err)
 		return
 	}
@@ -383,6 +528,9 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	atomic.AddInt64(&ts.TracesReceived, int64(len(traces)))
 	atomic.AddInt64(&ts.TracesBytes, req.Body.(*LimitedReader).Count)
 	atomic.AddInt64(&ts.PayloadAccepted, 1)
+	atomic.AddInt64(&cs.TracesReceived, int64(len(traces)))
+	atomic.AddInt64(&cs.TracesBytes, req.Body.(*LimitedReader).Count)
+	atomic.AddInt64(&cs.PayloadAccepted, 1)
 
 	r.wg.Add(1)
 	go func() {
@@ -390,8 +538,7 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 			r.wg.Done()
 			watchdog.LogOnPanic()
 		}()
-		containerID := req.Header.Get(headerContainerID)
-		r.processTraces(ts, containerID, traces)
+		r.processTraces(ts, cs, newPayloadContext(req), traces)
 	}()
 }
 
@@ -405,31 +552,120 @@ type Trace struct {
 	// trace (e.g. K8S pod, Docker image, ECS, etc). They are of the type "k1:v1,k2:v2".
 	ContainerTags string
 
+	// ClientComputedStats reports whether the client computed the stats of this trace,
+	// in which case the agent doesn't compute them again.
+	ClientComputedStats bool
+
 	// Spans holds the spans of this trace.
 	Spans pb.Trace
 }
 
-func (r *HTTPReceiver) processTraces(ts *info.TagStats, containerID string, traces pb.Traces) {
+func (r *HTTPReceiver) processTraces(ts *info.TagStats, cs *info.ClientStats, pc *payloadContext, traces pb.Traces) {
 	defer timing.Since("datadog.trace_agent.internal.normalize_ms", time.Now())
 
-	containerTags := getContainerTags(containerID)
+	// The payload stats are accumulated into the receiver and client stats
+	// once all the traces are normalized
+	ps := newPayloadStats(ts.Tags)
+	defer func() {
+		ts.Acc(ps)
+		cs.Acc(ps)
+	}()
+
 	for _, trace := range traces {
-		spans := len(trace)
+		r.processTrace(ts, ps, pc, trace)
+	}
+}
+
+// processTrace normalizes a trace and sends it to the agent, its stats being
+// counted in the payload stats ps
+func (r *HTTPReceiver) processTrace(ts, ps *info.TagStats, pc *payloadContext, trace pb.Trace) {
+	spans := len(trace)
+
+	atomic.AddInt64(&ps.SpansReceived, int64(spans))
+
+	if pc.traceContext != nil {
+		pc.traceContext.enrich(trace)
+	}
+	err := normalizeTrace(ps, trace)
+	if err != nil {
+		log.Debug("Dropping invalid trace: %s", err)
+		atomic.AddInt64(&ps.SpansDropped, int64(spans))
+		return
+	}
+
+	r.out <- &Trace{
+		Source:              &ts.Tags,
+		ContainerTags:       pc.containerTags,
+		ClientComputedStats: pc.clientComputedStats,
+		Spans:               trace,
+	}
+}
+
+// handleTraceStream decodes and processes the traces of a v0.7 payload one at
+// a time, so that the payload is never held in memory as a whole. When the
+// payload exceeds the byte or span limits, the traces decoded so far are kept
+// and the others are dropped.
+func (r *HTTPReceiver) handleTraceStream(ts *info.TagStats, cs *info.ClientStats, w http.ResponseWriter, req *http.Request) {
+	defer timing.Since("datadog.trace_agent.internal.normalize_ms", time.Now())
+
+	r.wg.Add(1)
+	defer r.wg.Done()
 
-		atomic.AddInt64(&ts.SpansReceived, int64(spans))
+	tags := []string{"handler:traces", fmt.Sprintf("v:%s", v07)}
+	dec, err := pb.NewStreamDecoder(req.Body, r.conf.MaxPayloadSpans)
+	if err != nil {
+		httpDecodingError(err, tags, w)
+		atomic.AddInt64(&cs.PayloadErrors, 1)
+		log.Errorf("Cannot decode %s traces payload: %v", v07, err)
+		return
+	}
 
-		err := normalizeTrace(ts, trace)
+	ps := newPayloadStats(ts.Tags)
+	pc := newPayloadContext(req)
+	for {
+		var trace pb.Trace
+		trace, err = dec.Next()
 		if err != nil {
-			log.Debug("Dropping invalid trace: %s", err)
-			atomic.AddInt64(&ts.SpansDropped, int64(spans))
-			continue
+			break
 		}
+		atomic.AddInt64(&ps.TracesReceived, 1)
+		r.processTrace(ts, ps, pc, trace)
+	}
+
+	var decodingErr error
+	switch err {
+	case io.EOF:
+		atomic.AddInt64(&ps.PayloadAccepted, 1)
+	case pb.ErrSpanLimitReached, ErrLimitedReaderLimitReached:
+		dropped := dec.Remaining()
+		atomic.AddInt64(&ps.TracesDropped.PayloadTooLarge, int64(dropped))
+		atomic.AddInt64(&ps.PayloadAccepted, 1)
+		log.Warnf("Dropping %d traces of a %s payload exceeding the limits: %v", dropped, v07, err)
+		metrics.Count(receiverErrorKey, 1, append(tags, "error:payload-too-large"), 1)
+		io.Copy(ioutil.Discard, req.Body) //nolint:errcheck
+	default:
+		decodingErr = err
+		atomic.AddInt64(&ps.TracesDropped.DecodingError, int64(dec.Remaining()))
+		atomic.AddInt64(&cs.PayloadErrors, 1)
+	}
+	atomic.AddInt64(&ps.TracesBytes, req.Body.(*LimitedReader).Count)
 
-		r.out <- &Trace{
-			Source:        &ts.Tags,
-			ContainerTags: containerTags,
-			Spans:         trace,
-		}
+	// accumulate the stats before replying, so that they are up to date for the client
+	ts.Acc(ps)
+	cs.Acc(ps)
+
+	if decodingErr != nil {
+		httpDecodingError(decodingErr, tags, w)
+		log.Errorf("Cannot decode %s traces payload: %v", v07, decodingErr)
+		return
+	}
+	r.replyOK(v07, w)
+}
+
+func newPayloadStats(tags info.Tags) *info.TagStats {
+	return &info.TagStats{
+		Tags:  tags,
+		Stats: info.Stats{TracesDropped: &info.TracesDropped{}, SpansMalformed: &info.SpansMalformed{}},
 	}
 }
 
@@ -468,6 +704,7 @@ func (r *HTTPReceiver) loop() {
 
 			// Publish the stats accumulated during the last flush
 			r.Stats.Publish()
+			info.UpdateClientsStats(r.Clients)
 
 			// We reset the stats accumulated during the last 10s.
 			r.Stats.Reset()
@@ -590,6 +827,15 @@ func tracesFromSpans(spans []pb.Span) pb.Traces {
 	return traces
 }
 
+// containerID returns the ID of the container where the request originated, read from
+// headerContainerID or else from a "cid-" prefixed headerEntityID.
+func containerID(req *http.Request) string {
+	if id := req.Header.Get(headerContainerID); id != "" {
+		return id
+	}
+	return strings.TrimPrefix(req.Header.Get(headerEntityID), "cid-")
+}
+
 // getContainerTag returns container and orchestrator tags belonging to containerID. If containerID
 // is empty or no tags are found, an empty string is returned.
 func getContainerTags(containerID string) string {
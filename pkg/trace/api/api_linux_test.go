@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
+)
+
+func TestAbstractUDS(t *testing.T) {
+	sockPath := "@test-trace-abstract.sock"
+	payload := msgpTraces(t, pb.Traces{testutil.RandomTrace(10, 20)})
+	client := http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", sockPath)
+			},
+		},
+	}
+
+	conf := config.New()
+	conf.Endpoints[0].APIKey = "apikey_2"
+	conf.ReceiverSocket = sockPath
+
+	r := newTestReceiverFromConfig(conf)
+	r.Start()
+	defer r.Stop()
+
+	resp, err := client.Post("http://localhost:8126/v0.4/traces", "application/msgpack", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected http.StatusOK, got response: %#v", resp)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"os"
+	"os/user"
 	"testing"
 	"time"
 
@@ -68,3 +70,35 @@ func TestUDS(t *testing.T) {
 		}
 	})
 }
+
+func TestUDSPermissions(t *testing.T) {
+	sockPath := "/tmp/test-trace-perms.sock"
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.New()
+	conf.Endpoints[0].APIKey = "apikey_2"
+	conf.ReceiverSocket = sockPath
+	conf.ReceiverSocketMode = 0760
+	conf.ReceiverSocketUser = u.Username
+	conf.ReceiverSocketGroup = u.Gid
+
+	r := newTestReceiverFromConfig(conf)
+	ln, err := r.listenUnix(sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	fi, err := os.Stat(sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0760 {
+		t.Fatalf("expected permissions 0760, got %#o", perm)
+	}
+
+	if err := chownSocket(sockPath, "no-such-user-for-sure", ""); err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+}
@@ -327,6 +327,50 @@ func TestReceiverMsgpackDecoder(t *testing.T) {
 	}
 }
 
+func TestReceiverTraceStream(t *testing.T) {
+	assert := assert.New(t)
+	conf := newTestReceiverConfig()
+	conf.MaxPayloadSpans = 2
+	r := newTestReceiverFromConfig(conf)
+	server := httptest.NewServer(http.HandlerFunc(r.handleWithVersion(v07, r.handleTraces)))
+	defer server.Close()
+
+	post := func(contentType string, traces pb.Traces) *http.Response {
+		var buf bytes.Buffer
+		assert.NoError(msgp.Encode(&buf, traces))
+		req, err := http.NewRequest("POST", server.URL, &buf)
+		assert.NoError(err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("json", func(t *testing.T) {
+		resp := post("application/json", testutil.GetTestTraces(1, 1, false))
+		assert.Equal(415, resp.StatusCode)
+	})
+
+	t.Run("span-limit", func(t *testing.T) {
+		resp := post("application/msgpack", testutil.GetTestTraces(3, 1, false))
+		assert.Equal(200, resp.StatusCode)
+
+		// the traces decoded before reaching the limit are kept
+		for i := 0; i < 2; i++ {
+			select {
+			case rt := <-r.out:
+				assert.Len(rt.Spans, 1)
+			case <-time.After(time.Second):
+				t.Fatalf("no data received")
+			}
+		}
+		ts := r.Stats.GetTagStats(info.Tags{})
+		assert.EqualValues(2, ts.TracesReceived)
+		assert.EqualValues(1, ts.TracesDropped.PayloadTooLarge)
+	})
+}
+
 func TestReceiverDecodingError(t *testing.T) {
 	assert := assert.New(t)
 	conf := newTestReceiverConfig()
@@ -362,6 +406,38 @@ func TestReceiverDecodingError(t *testing.T) {
 	})
 }
 
+func TestReceiverClients(t *testing.T) {
+	assert := assert.New(t)
+	conf := newTestReceiverConfig()
+	r := newTestReceiverFromConfig(conf)
+	server := httptest.NewServer(http.HandlerFunc(r.handleWithVersion(v04, r.handleTraces)))
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL, bytes.NewBuffer([]byte("} invalid json")))
+	assert.NoError(err)
+	req.Header.Set(headerTraceCount, "3")
+	req.Header.Set(headerLang, "python")
+	req.Header.Set(headerTracerVersion, "0.38.0")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(400, resp.StatusCode)
+
+	rec := httptest.NewRecorder()
+	r.handleClients(rec, httptest.NewRequest("GET", "/debug/clients", nil))
+	var reports []info.ClientReport
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &reports))
+	if assert.Len(reports, 1) {
+		assert.Equal("python", reports[0].Lang)
+		assert.Equal("0.38.0", reports[0].TracerVersion)
+		assert.EqualValues(1, reports[0].PayloadsErrors)
+		assert.EqualValues(1, reports[0].ErrorRate)
+		assert.EqualValues(3, reports[0].TracesDropped)
+		assert.Equal(map[string]int64{"decoding_error": 3}, reports[0].TracesDroppedReasons)
+	}
+}
+
 func TestTraceCount(t *testing.T) {
 	req, err := http.NewRequest("GET", "/", nil)
 	assert.NoError(t, err)
@@ -177,6 +177,9 @@ func normalizeTrace(ts *info.TagStats, t pb.Trace) error {
 
 	spanIDs := make(map[uint64]struct{})
 	firstSpan := t[0]
+	// traceIDHigh holds the high 64 bits of a 128-bit trace ID, which tracers usually
+	// only set on the first span of a trace chunk
+	var traceIDHigh uint64
 
 	for _, span := range t {
 		if span.TraceID != firstSpan.TraceID {
@@ -186,6 +189,19 @@ func normalizeTrace(ts *info.TagStats, t pb.Trace) error {
 		if err := normalize(ts, span); err != nil {
 			return err
 		}
+		high, err := traceutil.TraceIDHigh(span)
+		if err != nil {
+			atomic.AddInt64(&ts.SpansMalformed.InvalidTraceIDHigh, 1)
+			log.Debugf("Fixing malformed trace. Trace ID high bits are invalid (reason:invalid_trace_id_high), dropping them: %v: %s", err, span)
+			traceutil.DeleteTraceIDHigh(span)
+		}
+		if high != 0 {
+			if traceIDHigh != 0 && high != traceIDHigh {
+				atomic.AddInt64(&ts.TracesDropped.ForeignSpan, 1)
+				return fmt.Errorf("trace has foreign span (reason:foreign_span): %s", span)
+			}
+			traceIDHigh = high
+		}
 		if _, ok := spanIDs[span.SpanID]; ok {
 			atomic.AddInt64(&ts.SpansMalformed.DuplicateSpanID, 1)
 			log.Debugf("Found malformed trace with duplicate span ID (reason:duplicate_span_id): %s", span)
@@ -193,6 +209,14 @@ func normalizeTrace(ts *info.TagStats, t pb.Trace) error {
 		spanIDs[span.SpanID] = struct{}{}
 	}
 
+	if traceIDHigh != 0 {
+		// carry the high bits on every span, and propagate them in the tag of the first one
+		for _, span := range t {
+			span.TraceIDHigh = traceIDHigh
+		}
+		traceutil.SetTraceIDHigh(firstSpan, traceIDHigh)
+	}
+
 	return nil
 }
 
@@ -403,6 +403,43 @@ func TestNormalizeTraceDuplicateSpanID(t *testing.T) {
 	assert.Equal(t, tsMalformed(&info.SpansMalformed{DuplicateSpanID: 1}), ts)
 }
 
+func TestNormalizeTraceIDHigh(t *testing.T) {
+	t.Run("propagated", func(t *testing.T) {
+		ts := newTagStats()
+		span1, span2 := newTestSpan(), newTestSpan()
+		span2.SpanID++
+		span2.Meta["_dd.p.tid"] = "5af7183fb1d4cf5f"
+
+		assert.NoError(t, normalizeTrace(ts, pb.Trace{span1, span2}))
+		assert.Equal(t, newTagStats(), ts)
+		assert.Equal(t, uint64(0x5af7183fb1d4cf5f), span1.TraceIDHigh)
+		assert.Equal(t, uint64(0x5af7183fb1d4cf5f), span2.TraceIDHigh)
+		assert.Equal(t, "5af7183fb1d4cf5f", span1.Meta["_dd.p.tid"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ts := newTagStats()
+		span := newTestSpan()
+		span.Meta["_dd.p.tid"] = "invalid"
+
+		assert.NoError(t, normalizeTrace(ts, pb.Trace{span}))
+		assert.Equal(t, tsMalformed(&info.SpansMalformed{InvalidTraceIDHigh: 1}), ts)
+		assert.Equal(t, uint64(0), span.TraceIDHigh)
+		assert.NotContains(t, span.Meta, "_dd.p.tid")
+	})
+
+	t.Run("foreign", func(t *testing.T) {
+		ts := newTagStats()
+		span1, span2 := newTestSpan(), newTestSpan()
+		span2.SpanID++
+		span1.TraceIDHigh = 1
+		span2.TraceIDHigh = 2
+
+		assert.Error(t, normalizeTrace(ts, pb.Trace{span1, span2}))
+		assert.Equal(t, tsDropped(&info.TracesDropped{ForeignSpan: 1}), ts)
+	})
+}
+
 func TestNormalizeTrace(t *testing.T) {
 	ts := newTagStats()
 	span1, span2 := newTestSpan(), newTestSpan()
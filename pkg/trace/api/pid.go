@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+type pidContextKey struct{}
+
+// connContext stores the PID of the peer of the connection in the context of
+// its requests, when it's known.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	if pid := peerPID(c); pid > 0 {
+		return context.WithValue(ctx, pidContextKey{}, pid)
+	}
+	return ctx
+}
+
+// clientPID returns the PID of the client which sent the request, 0 if unknown.
+func clientPID(req *http.Request) int32 {
+	pid, _ := req.Context().Value(pidContextKey{}).(int32)
+	return pid
+}
@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package api
+
+import "net"
+
+// peerPID returns 0: the PID of the clients is only known on Linux.
+func peerPID(c net.Conn) int32 {
+	return 0
+}
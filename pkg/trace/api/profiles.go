@@ -62,8 +62,7 @@ func newProfileProxy(target *url.URL, apiKey, tags string) *httputil.ReverseProx
 			req.Header.Set("User-Agent", "")
 
 		}
-		containerID := req.Header.Get(headerContainerID)
-		if ctags := getContainerTags(containerID); ctags != "" {
+		if ctags := getContainerTags(containerID(req)); ctags != "" {
 			req.Header.Set("X-Datadog-Container-Tags", ctags)
 		}
 		req.Header.Set("X-Datadog-Additional-Tags", tags)
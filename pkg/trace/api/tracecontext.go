@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// headerTraceparent and headerTracestate are the W3C trace context headers,
+	// see https://www.w3.org/TR/trace-context/
+	headerTraceparent = "traceparent"
+	headerTracestate  = "tracestate"
+
+	// tracestateVendor is the key of the Datadog member of the tracestate header.
+	tracestateVendor = "dd"
+)
+
+var errInvalidTraceparent = errors.New("invalid traceparent header")
+
+// payloadContext holds the information read from the headers of a traces payload
+// which applies to its traces.
+type payloadContext struct {
+	// containerTags holds the tags of the container where the payload originated.
+	containerTags string
+
+	// clientComputedStats is set when the client computed the stats of the traces.
+	clientComputedStats bool
+
+	// traceContext holds the W3C trace context of the request, nil if there is none.
+	traceContext *traceContext
+}
+
+// newPayloadContext returns the payload context of req.
+func newPayloadContext(req *http.Request) *payloadContext {
+	ccs, _ := strconv.ParseBool(req.Header.Get(headerClientComputedStats))
+	return &payloadContext{
+		containerTags:       getContainerTags(containerID(req)),
+		clientComputedStats: ccs,
+		traceContext:        newTraceContext(req),
+	}
+}
+
+// traceContext holds the W3C trace context of a request.
+type traceContext struct {
+	traceIDHigh uint64
+	traceID     uint64
+	sampled     bool
+
+	// priority is the sampling priority of the "s" key of the Datadog tracestate member,
+	// PriorityNone when unset.
+	priority sampler.SamplingPriority
+}
+
+// newTraceContext returns the trace context of the request, or nil if it has no valid
+// traceparent header.
+func newTraceContext(req *http.Request) *traceContext {
+	tp := req.Header.Get(headerTraceparent)
+	if tp == "" {
+		return nil
+	}
+	tc, err := parseTraceparent(tp)
+	if err != nil {
+		return nil
+	}
+	tc.parseTracestate(req.Header.Get(headerTracestate))
+	return tc
+}
+
+// parseTraceparent parses a traceparent header, of the form
+// "<version>-<trace-id>-<parent-id>-<trace-flags>" in hexadecimal.
+func parseTraceparent(v string) (*traceContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 {
+		return nil, errInvalidTraceparent
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return nil, errInvalidTraceparent
+	}
+	if len(traceID) != 32 || len(parentID) != 16 || len(flags) != 2 {
+		return nil, errInvalidTraceparent
+	}
+	if _, err := strconv.ParseUint(version, 16, 8); err != nil {
+		return nil, errInvalidTraceparent
+	}
+	if _, err := strconv.ParseUint(parentID, 16, 64); err != nil {
+		return nil, errInvalidTraceparent
+	}
+	high, err := strconv.ParseUint(traceID[:16], 16, 64)
+	if err != nil {
+		return nil, errInvalidTraceparent
+	}
+	low, err := strconv.ParseUint(traceID[16:], 16, 64)
+	if err != nil || high == 0 && low == 0 {
+		return nil, errInvalidTraceparent
+	}
+	f, err := strconv.ParseUint(flags, 16, 8)
+	if err != nil {
+		return nil, errInvalidTraceparent
+	}
+	return &traceContext{
+		traceIDHigh: high,
+		traceID:     low,
+		sampled:     f&1 == 1,
+		priority:    sampler.PriorityNone,
+	}, nil
+}
+
+// parseTracestate reads the Datadog member of a tracestate header, such as
+// "dd=s:2;o:rum,othervendor=value". Invalid values are ignored.
+func (tc *traceContext) parseTracestate(v string) {
+	for _, member := range strings.Split(v, ",") {
+		kv := strings.SplitN(strings.TrimSpace(member), "=", 2)
+		if len(kv) != 2 || kv[0] != tracestateVendor {
+			continue
+		}
+		for _, field := range strings.Split(kv[1], ";") {
+			fkv := strings.SplitN(field, ":", 2)
+			if len(fkv) != 2 || fkv[0] != "s" {
+				continue
+			}
+			if p, err := strconv.ParseInt(fkv[1], 10, 8); err == nil {
+				tc.priority = sampler.SamplingPriority(p)
+			}
+		}
+		return
+	}
+}
+
+// enrich completes the trace with its trace context if they share the same trace ID:
+// the high bits of its 128-bit trace ID and its sampling priority are set when the
+// tracer didn't set them.
+func (tc *traceContext) enrich(t pb.Trace) {
+	if len(t) == 0 || t[0].TraceID != tc.traceID {
+		return
+	}
+	if high, err := traceutil.TraceIDHigh(t[0]); err == nil && high == 0 && tc.traceIDHigh != 0 {
+		traceutil.SetTraceIDHigh(t[0], tc.traceIDHigh)
+	}
+	root := traceutil.GetRoot(t)
+	if _, ok := sampler.GetSamplingPriority(root); ok {
+		return
+	}
+	switch {
+	case tc.priority != sampler.PriorityNone:
+		sampler.SetSamplingPriority(root, tc.priority)
+	case tc.sampled:
+		sampler.SetSamplingPriority(root, sampler.PriorityAutoKeep)
+	default:
+		sampler.SetSamplingPriority(root, sampler.PriorityAutoDrop)
+	}
+}
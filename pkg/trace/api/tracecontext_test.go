@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want *traceContext
+	}{
+		{
+			in: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			want: &traceContext{
+				traceIDHigh: 0x0af7651916cd43dd,
+				traceID:     0x8448eb211c80319c,
+				sampled:     true,
+				priority:    sampler.PriorityNone,
+			},
+		},
+		{
+			in: "00-00000000000000000000000000000001-b7ad6b7169203331-00",
+			want: &traceContext{
+				traceID:  1,
+				priority: sampler.PriorityNone,
+			},
+		},
+		{
+			// future versions may append fields
+			in: "01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-03-extra",
+			want: &traceContext{
+				traceIDHigh: 0x0af7651916cd43dd,
+				traceID:     0x8448eb211c80319c,
+				sampled:     true,
+				priority:    sampler.PriorityNone,
+			},
+		},
+		{in: ""},
+		{in: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra"},
+		{in: "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		{in: "00-00000000000000000000000000000000-b7ad6b7169203331-01"},
+		{in: "00-0af7651916cd43dd8448eb211c80319-b7ad6b7169203331-01"},
+		{in: "00-0af7651916cd43dd8448eb211c80319z-b7ad6b7169203331-01"},
+		{in: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333z-01"},
+		{in: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-0z"},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			tc, err := parseTraceparent(tt.in)
+			if tt.want == nil {
+				assert.Equal(t, errInvalidTraceparent, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tc)
+		})
+	}
+}
+
+func TestParseTracestate(t *testing.T) {
+	for in, want := range map[string]sampler.SamplingPriority{
+		"":                              sampler.PriorityNone,
+		"dd=s:2":                        sampler.PriorityUserKeep,
+		"dd=o:rum;s:-1":                 sampler.PriorityUserDrop,
+		"rojo=00f067aa0ba902b7, dd=s:1": sampler.PriorityAutoKeep,
+		"dd=s:a":                        sampler.PriorityNone,
+		"congo=s:2":                     sampler.PriorityNone,
+	} {
+		tc := traceContext{priority: sampler.PriorityNone}
+		tc.parseTracestate(in)
+		assert.Equal(t, want, tc.priority, in)
+	}
+}
+
+func TestTraceContextEnrich(t *testing.T) {
+	newTrace := func() pb.Trace {
+		return pb.Trace{
+			{TraceID: 42, SpanID: 1, Metrics: map[string]float64{}},
+			{TraceID: 42, SpanID: 2, ParentID: 1, Metrics: map[string]float64{}},
+		}
+	}
+	tc := &traceContext{traceIDHigh: 7, traceID: 42, sampled: true, priority: sampler.PriorityNone}
+
+	t.Run("missing", func(t *testing.T) {
+		assert := assert.New(t)
+		trace := newTrace()
+		tc.enrich(trace)
+		high, err := traceutil.TraceIDHigh(trace[0])
+		assert.NoError(err)
+		assert.EqualValues(7, high)
+		p, ok := sampler.GetSamplingPriority(trace[0])
+		assert.True(ok)
+		assert.Equal(sampler.PriorityAutoKeep, p)
+	})
+
+	t.Run("tracestate", func(t *testing.T) {
+		trace := newTrace()
+		tc := *tc
+		tc.priority = sampler.PriorityUserDrop
+		tc.enrich(trace)
+		p, _ := sampler.GetSamplingPriority(trace[0])
+		assert.Equal(t, sampler.PriorityUserDrop, p)
+	})
+
+	t.Run("not-sampled", func(t *testing.T) {
+		trace := newTrace()
+		tc := *tc
+		tc.sampled = false
+		tc.enrich(trace)
+		p, _ := sampler.GetSamplingPriority(trace[0])
+		assert.Equal(t, sampler.PriorityAutoDrop, p)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		assert := assert.New(t)
+		trace := newTrace()
+		traceutil.SetTraceIDHigh(trace[0], 3)
+		sampler.SetSamplingPriority(trace[0], sampler.PriorityUserKeep)
+		tc.enrich(trace)
+		high, _ := traceutil.TraceIDHigh(trace[0])
+		assert.EqualValues(3, high)
+		p, _ := sampler.GetSamplingPriority(trace[0])
+		assert.Equal(sampler.PriorityUserKeep, p)
+	})
+
+	t.Run("other-trace", func(t *testing.T) {
+		assert := assert.New(t)
+		trace := newTrace()
+		trace[0].TraceID, trace[1].TraceID = 43, 43
+		tc.enrich(trace)
+		high, _ := traceutil.TraceIDHigh(trace[0])
+		assert.EqualValues(0, high)
+		_, ok := sampler.GetSamplingPriority(trace[0])
+		assert.False(ok)
+	})
+}
+
+func TestContainerID(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", nil)
+	assert.Equal(t, "", containerID(req))
+	req.Header.Set(headerEntityID, "cid-abc")
+	assert.Equal(t, "abc", containerID(req))
+	req.Header.Set(headerContainerID, "def")
+	assert.Equal(t, "def", containerID(req))
+}
+
+func TestReceiverTraceContext(t *testing.T) {
+	assert := assert.New(t)
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(http.HandlerFunc(r.handleWithVersion(v04, r.handleTraces)))
+	defer server.Close()
+
+	traces := pb.Traces{{{
+		Service:  "svc",
+		Name:     "op",
+		Resource: "res",
+		TraceID:  0x8448eb211c80319c,
+		SpanID:   1,
+		Start:    time.Now().UnixNano(),
+		Duration: 1,
+	}}}
+	var buf bytes.Buffer
+	assert.NoError(msgp.Encode(&buf, traces))
+	req, err := http.NewRequest("POST", server.URL, &buf)
+	assert.NoError(err)
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set(headerTraceparent, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	req.Header.Set(headerTracestate, "dd=s:2")
+	req.Header.Set(headerClientComputedStats, "true")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(200, resp.StatusCode)
+
+	select {
+	case rt := <-r.out:
+		assert.True(rt.ClientComputedStats)
+		high, err := traceutil.TraceIDHigh(rt.Spans[0])
+		assert.NoError(err)
+		assert.EqualValues(0x0af7651916cd43dd, high)
+		p, ok := sampler.GetSamplingPriority(rt.Spans[0])
+		assert.True(ok)
+		assert.Equal(sampler.PriorityUserKeep, p)
+	case <-time.After(time.Second):
+		t.Fatalf("no data received")
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,50 +28,108 @@ const apiEndpointPrefix = "https://trace.agent."
 // for various span types.
 type ObfuscationConfig struct {
 	// ES holds the obfuscation configuration for ElasticSearch bodies.
-	ES JSONObfuscationConfig `mapstructure:"elasticsearch"`
+	ES JSONObfuscationConfig `mapstructure:"elasticsearch" yaml:"elasticsearch"`
 
 	// Mongo holds the obfuscation configuration for MongoDB queries.
-	Mongo JSONObfuscationConfig `mapstructure:"mongodb"`
+	Mongo JSONObfuscationConfig `mapstructure:"mongodb" yaml:"mongodb"`
 
 	// HTTP holds the obfuscation settings for HTTP URLs.
-	HTTP HTTPObfuscationConfig `mapstructure:"http"`
+	HTTP HTTPObfuscationConfig `mapstructure:"http" yaml:"http"`
+
+	// SQL holds the obfuscation settings for SQL queries.
+	SQL SQLObfuscationConfig `mapstructure:"sql" yaml:"sql"`
 
 	// RemoveStackTraces specifies whether stack traces should be removed.
 	// More specifically "error.stack" tag values will be cleared.
-	RemoveStackTraces bool `mapstructure:"remove_stack_traces"`
+	RemoveStackTraces bool `mapstructure:"remove_stack_traces" yaml:"remove_stack_traces"`
 
 	// Redis holds the configuration for obfuscating the "redis.raw_command" tag
 	// for spans of type "redis".
-	Redis Enablable `mapstructure:"redis"`
+	Redis Enablable `mapstructure:"redis" yaml:"redis"`
 
 	// Memcached holds the configuration for obfuscating the "memcached.command" tag
 	// for spans of type "memcached".
-	Memcached Enablable `mapstructure:"memcached"`
+	Memcached Enablable `mapstructure:"memcached" yaml:"memcached"`
+
+	// ReplacementToken specifies the token replacing the obfuscated literals of SQL queries,
+	// Redis commands and JSON documents, such as "%s" or ":param". Defaults to "?" when empty.
+	ReplacementToken string `mapstructure:"replacement_token" yaml:"replacement_token"`
 }
 
 // HTTPObfuscationConfig holds the configuration settings for HTTP obfuscation.
 type HTTPObfuscationConfig struct {
 	// RemoveQueryStrings determines query strings to be removed from HTTP URLs.
-	RemoveQueryString bool `mapstructure:"remove_query_string"`
+	RemoveQueryString bool `mapstructure:"remove_query_string" yaml:"remove_query_string"`
 
 	// RemovePathDigits determines digits in path segments to be obfuscated.
-	RemovePathDigits bool `mapstructure:"remove_paths_with_digits"`
+	RemovePathDigits bool `mapstructure:"remove_paths_with_digits" yaml:"remove_paths_with_digits"`
+}
+
+// SQLObfuscationConfig holds the configuration settings for SQL obfuscation.
+type SQLObfuscationConfig struct {
+	// NormalizeQuotedIdentifiers determines identifiers quoted with backticks or double
+	// quotes to be lowercased, so that queries differing only by their quoting share
+	// the same resource.
+	NormalizeQuotedIdentifiers bool `mapstructure:"normalize_quoted_identifiers" yaml:"normalize_quoted_identifiers"`
+
+	// MaxBytes specifies the maximum size of a query to obfuscate. Larger queries are
+	// replaced by a placeholder. Defaults to 256KiB when zero.
+	MaxBytes int `mapstructure:"max_bytes" yaml:"max_bytes"`
+
+	// MaxDurationMs specifies the maximum time spent obfuscating a query, in milliseconds.
+	// Queries taking longer are replaced by a placeholder. Defaults to 100ms when zero.
+	MaxDurationMs int `mapstructure:"max_duration_ms" yaml:"max_duration_ms"`
+
+	// CacheSize specifies the number of obfuscated queries kept in an LRU cache keyed by
+	// the raw query, so repeated queries aren't obfuscated again. Disabled when zero.
+	CacheSize int `mapstructure:"cache_size" yaml:"cache_size"`
+
+	// Dialect specifies the SQL engine of the queries, so that they are tokenized with its
+	// quoting, escaping and comment rules: "postgres", "mysql", "mssql", "oracle" or
+	// "snowflake". When empty, generic rules are used and the treatment of backslashes
+	// in strings is learnt from the queries.
+	Dialect string `mapstructure:"dialect" yaml:"dialect"`
+
+	// ExtractComments determines the key-value pairs of sqlcommenter comments, such as
+	// /*traceparent='00-...'*/, to be set as "sql.comment.<key>" tags on the spans before
+	// the comments are removed from the queries.
+	ExtractComments bool `mapstructure:"extract_comments" yaml:"extract_comments"`
+
+	// TableNames determines the tables that the queries address, such as the targets of FROM,
+	// JOIN, UPDATE and INSERT INTO, to be set as a comma-separated list in the "sql.tables" tag.
+	TableNames bool `mapstructure:"table_names" yaml:"table_names"`
+
+	// ObfuscateDBStatement determines the "db.statement" tag of the spans without type to be
+	// obfuscated when it looks like a SQL query, as some tracers don't set the type of SQL spans.
+	ObfuscateDBStatement bool `mapstructure:"obfuscate_db_statement" yaml:"obfuscate_db_statement"`
+
+	// KeepValuesTuples determines the repeated groups of values, such as the tuples of bulk
+	// INSERT ... VALUES statements, to be kept in the resources. By default, they are collapsed
+	// into a single ( ? ) and their number is set in the "sql.values_count" tag.
+	KeepValuesTuples bool `mapstructure:"keep_values_tuples" yaml:"keep_values_tuples"`
 }
 
 // Enablable can represent any option that has an "enabled" boolean sub-field.
 type Enablable struct {
-	Enabled bool `mapstructure:"enabled"`
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
 }
 
 // JSONObfuscationConfig holds the obfuscation configuration for sensitive
 // data found in JSON objects.
 type JSONObfuscationConfig struct {
 	// Enabled will specify whether obfuscation should be enabled.
-	Enabled bool `mapstructure:"enabled"`
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
 
 	// KeepValues will specify a set of keys for which their values will
 	// not be obfuscated.
-	KeepValues []string `mapstructure:"keep_values"`
+	KeepValues []string `mapstructure:"keep_values" yaml:"keep_values,omitempty"`
+
+	// UseDefaults will specify whether the default profile of the obfuscator
+	// should be added to this configuration. The ElasticSearch profile keeps
+	// the values describing the structure of the queries and obfuscates the
+	// terms of query strings. The MongoDB one keeps the collection names and
+	// the values of the pipeline stages without literals.
+	UseDefaults bool `mapstructure:"use_defaults" yaml:"use_defaults"`
 }
 
 // ReplaceRule specifies a replace rule.
@@ -105,6 +165,90 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+// RareSamplerConfig specifies the configuration of the sampler which catches the traces
+// with rare spans that the priority sampler didn't keep.
+type RareSamplerConfig struct {
+	// Enabled specifies whether the sampler runs.
+	Enabled bool
+
+	// TPS specifies the maximum number of traces per second to sample, and Burst
+	// the number of traces which can be sampled at once.
+	TPS   float64
+	Burst int
+
+	// Cooldown specifies for how long a sampled span is not sampled again, and
+	// PriorityCooldown for how long after it was seen in a trace kept by the
+	// priority sampler.
+	Cooldown         time.Duration
+	PriorityCooldown time.Duration
+
+	// CardinalityLimit specifies the maximum number of distinct spans tracked for
+	// each env and service.
+	CardinalityLimit int
+
+	// Tags lists the span tags whose values make spans distinct, in addition to their
+	// env, service, name, resource, error type and HTTP status code.
+	Tags []string
+}
+
+// parseFileMode parses file permissions, given either as an octal string such as "0722", or
+// as a number, which YAML reads from octal literals.
+func parseFileMode(v interface{}) (os.FileMode, error) {
+	var mode uint64
+	switch v := v.(type) {
+	case int:
+		mode = uint64(v)
+	case string:
+		m, err := strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an octal number", v)
+		}
+		mode = m
+	default:
+		return 0, fmt.Errorf("unexpected value %v", v)
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("%#o is not a valid permission", mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// parseAdditionalEndpoint parses the value of an 'additional_endpoints' entry, which is either
+// the list of its API keys or an object holding them in "api_keys", along with the optional
+// "sample_rate" of the traces sent to the endpoint.
+func parseAdditionalEndpoint(v interface{}) (keys []string, rate float64, err error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		v = m["api_keys"]
+		if r, ok := m["sample_rate"]; ok {
+			switch r := r.(type) {
+			case float64:
+				rate = r
+			case int:
+				rate = float64(r)
+			default:
+				return nil, 0, fmt.Errorf("sample_rate must be a number, got %v", r)
+			}
+			if rate <= 0 || rate > 1 {
+				return nil, 0, fmt.Errorf("sample_rate must be greater than 0 and at most 1, got %v", rate)
+			}
+		}
+	}
+	switch v := v.(type) {
+	case []string:
+		keys = v
+	case []interface{}:
+		for _, k := range v {
+			if k, ok := k.(string); ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, 0, errors.New("entries must have at least one API key present")
+	}
+	return keys, rate, nil
+}
+
 func (c *AgentConfig) applyDatadogConfig() error {
 	if len(c.Endpoints) == 0 {
 		c.Endpoints = []*Endpoint{{}}
@@ -133,14 +277,15 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		}
 	}
 	if config.Datadog.IsSet("apm_config.additional_endpoints") {
-		for url, keys := range config.Datadog.GetStringMapStringSlice("apm_config.additional_endpoints") {
-			if len(keys) == 0 {
-				log.Errorf("'additional_endpoints' entries must have at least one API key present")
+		for url, v := range config.Datadog.GetStringMap("apm_config.additional_endpoints") {
+			keys, rate, err := parseAdditionalEndpoint(v)
+			if err != nil {
+				log.Errorf("Ignoring 'additional_endpoints' entry %q: %v", url, err)
 				continue
 			}
 			for _, key := range keys {
 				key = config.SanitizeAPIKey(key)
-				c.Endpoints = append(c.Endpoints, &Endpoint{Host: url, APIKey: key})
+				c.Endpoints = append(c.Endpoints, &Endpoint{Host: url, APIKey: key, SampleRate: rate})
 			}
 		}
 	}
@@ -195,6 +340,19 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.receiver_socket") {
 		c.ReceiverSocket = config.Datadog.GetString("apm_config.receiver_socket")
 	}
+	if k := "apm_config.receiver_socket_mode"; config.Datadog.IsSet(k) {
+		mode, err := parseFileMode(config.Datadog.Get(k))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", k, err)
+		}
+		c.ReceiverSocketMode = mode
+	}
+	if k := "apm_config.receiver_socket_user"; config.Datadog.IsSet(k) {
+		c.ReceiverSocketUser = config.Datadog.GetString(k)
+	}
+	if k := "apm_config.receiver_socket_group"; config.Datadog.IsSet(k) {
+		c.ReceiverSocketGroup = config.Datadog.GetString(k)
+	}
 	if config.Datadog.IsSet("apm_config.connection_limit") {
 		c.ConnectionLimit = config.Datadog.GetInt("apm_config.connection_limit")
 	}
@@ -207,12 +365,65 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.max_traces_per_second") {
 		c.MaxTPS = config.Datadog.GetFloat64("apm_config.max_traces_per_second")
 	}
+	// the errors sampler follows max_traces_per_second unless it has its own limit
+	c.ErrorTPS = c.MaxTPS
+	if k := "apm_config.errors_sampler.max_traces_per_second"; config.Datadog.IsSet(k) {
+		c.ErrorTPS = config.Datadog.GetFloat64(k)
+	}
+	if k := "apm_config.rare_sampler.enabled"; config.Datadog.IsSet(k) {
+		c.RareSampler.Enabled = config.Datadog.GetBool(k)
+	}
+	if k := "apm_config.rare_sampler.max_traces_per_second"; config.Datadog.IsSet(k) {
+		c.RareSampler.TPS = config.Datadog.GetFloat64(k)
+	}
+	if k := "apm_config.rare_sampler.burst"; config.Datadog.IsSet(k) {
+		c.RareSampler.Burst = config.Datadog.GetInt(k)
+	}
+	if k := "apm_config.rare_sampler.cooldown"; config.Datadog.IsSet(k) {
+		c.RareSampler.Cooldown = time.Duration(config.Datadog.GetInt(k)) * time.Second
+	}
+	if k := "apm_config.rare_sampler.priority_cooldown"; config.Datadog.IsSet(k) {
+		c.RareSampler.PriorityCooldown = time.Duration(config.Datadog.GetInt(k)) * time.Second
+	}
+	if k := "apm_config.rare_sampler.cardinality_limit"; config.Datadog.IsSet(k) {
+		c.RareSampler.CardinalityLimit = config.Datadog.GetInt(k)
+	}
+	if k := "apm_config.rare_sampler.tags"; config.Datadog.IsSet(k) {
+		c.RareSampler.Tags = config.Datadog.GetStringSlice(k)
+	}
+	if k := "apm_config.target_traces_per_second_by_service"; config.Datadog.IsSet(k) {
+		tpsByService := make(map[string]float64)
+		if err := config.Datadog.UnmarshalKey(k, &tpsByService); err != nil {
+			return err
+		}
+		for key, tps := range tpsByService {
+			service, env, err := parseServiceAndEnv(key)
+			if err != nil {
+				log.Errorf("Error parsing target TPS: %v", err)
+				continue
+			}
+			if tps <= 0 {
+				log.Errorf("Invalid target TPS %f for %q: it must be positive", tps, key)
+				continue
+			}
+			if _, ok := c.TargetTPSByService[service]; !ok {
+				c.TargetTPSByService[service] = make(map[string]float64)
+			}
+			c.TargetTPSByService[service][env] = tps
+		}
+	}
 	if config.Datadog.IsSet("apm_config.ignore_resources") {
 		c.Ignore["resource"] = config.Datadog.GetStringSlice("apm_config.ignore_resources")
 	}
+	if k := "apm_config.max_resource_length"; config.Datadog.IsSet(k) {
+		c.MaxResourceLen = config.Datadog.GetInt(k)
+	}
 	if k := "apm_config.max_payload_size"; config.Datadog.IsSet(k) {
 		c.MaxRequestBytes = config.Datadog.GetInt64(k)
 	}
+	if k := "apm_config.max_payload_spans"; config.Datadog.IsSet(k) {
+		c.MaxPayloadSpans = config.Datadog.GetInt(k)
+	}
 
 	if config.Datadog.IsSet("apm_config.replace_tags") {
 		rt := make([]*ReplaceRule, 0)
@@ -242,9 +453,32 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		err := config.Datadog.UnmarshalKey("apm_config.obfuscation", &o)
 		if err == nil {
 			c.Obfuscation = &o
-			if c.Obfuscation.RemoveStackTraces {
-				c.addReplaceRule("error.stack", `(?s).*`, "?")
-			}
+		}
+	}
+	if k := "apm_config.obfuscation_file"; config.Datadog.IsSet(k) {
+		// the obfuscation file takes precedence over apm_config.obfuscation
+		o, err := LoadObfuscationConfig(config.Datadog.GetString(k))
+		if err != nil {
+			log.Errorf("Error reading obfuscation file: %v", err)
+		} else {
+			c.Obfuscation = o
+		}
+	}
+	if k := "apm_config.obfuscation_diagnostics_rate"; config.Datadog.IsSet(k) {
+		c.ObfuscationDiagnosticsRate = config.Datadog.GetFloat64(k)
+	}
+	if c.Obfuscation != nil && c.Obfuscation.RemoveStackTraces {
+		c.addReplaceRule("error.stack", `(?s).*`, "?")
+	}
+	if k := "apm_config.otlp_stats_endpoint"; config.Datadog.GetString(k) != "" {
+		u, err := url.Parse(config.Datadog.GetString(k))
+		switch {
+		case err != nil:
+			log.Errorf("Error parsing %s, stats won't be exported to OTLP: %v", k, err)
+		case u.Scheme != "http" && u.Scheme != "https":
+			log.Errorf("Invalid %s %q, stats won't be exported to OTLP: the URL must start with http:// or https://", k, u)
+		default:
+			c.OTLPStatsURL = u
 		}
 	}
 
@@ -268,6 +502,9 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.connection_reset_interval") {
 		c.ConnectionResetInterval = getDuration(config.Datadog.GetInt("apm_config.connection_reset_interval"))
 	}
+	if d := getDuration(config.Datadog.GetInt("shutdown_drain_timeout")); d > c.ShutdownDrainTimeout {
+		c.ShutdownDrainTimeout = d
+	}
 
 	// undocumented deprecated
 	if config.Datadog.IsSet("apm_config.analyzed_rate_by_service") {
@@ -400,6 +637,14 @@ func parseServiceAndOp(name string) (string, string, error) {
 	return splits[0], splits[1], nil
 }
 
+func parseServiceAndEnv(name string) (string, string, error) {
+	splits := strings.Split(name, "|")
+	if len(splits) != 2 || splits[0] == "" || splits[1] == "" {
+		return "", "", fmt.Errorf("Bad format for service name and env in: %s, it should have format: service_name|env", name)
+	}
+	return splits[0], splits[1], nil
+}
+
 func splitString(s string, sep rune) ([]string, error) {
 	r := csv.NewReader(strings.NewReader(s))
 	r.TrimLeadingSpace = true
@@ -40,6 +40,11 @@ type Endpoint struct {
 	// NoProxy will be set to true when the proxy setting for the trace API endpoint
 	// needs to be ignored (e.g. it is part of the "no_proxy" list in the yaml settings).
 	NoProxy bool
+
+	// SampleRate specifies the rate of the traces sent to the endpoint, chosen by trace ID
+	// so that all the agents send the same traces. All traces are sent when it is zero, as
+	// to the main endpoint. Stats are always sent in full.
+	SampleRate float64
 }
 
 // AgentConfig handles the interpretation of the configuration (with default
@@ -70,19 +75,45 @@ type AgentConfig struct {
 	MaxTPS          float64
 	MaxEPS          float64
 
+	// TargetTPSByService maps services and their envs to the number of traces per second
+	// to sample for them, out of MaxTPS.
+	TargetTPSByService map[string]map[string]float64
+
+	// ErrorTPS is the maximum number of traces with errors per second to sample.
+	ErrorTPS float64
+
+	// RareSampler configures the sampler of the traces with rare spans.
+	RareSampler RareSamplerConfig
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
-	ReceiverSocket  string // if not empty, UDS will be enabled on unix://<receiver_socket>
+	ReceiverSocket  string // if not empty, UDS will be enabled on unix://<receiver_socket>, abstract if it starts with '@'
 	ConnectionLimit int    // for rate-limiting, how many unique connections to allow in a lease period (30s)
 	ReceiverTimeout int
 	MaxRequestBytes int64 // specifies the maximum allowed request size for incoming trace payloads
+	MaxPayloadSpans int   // specifies the maximum number of spans in a v0.7 trace payload, 0 for no limit
+	MaxResourceLen  int   // specifies the maximum length of span resources after obfuscation, 0 for the default
+
+	// ReceiverSocketMode specifies the permissions of the receiver socket file, and
+	// ReceiverSocketUser and ReceiverSocketGroup the names or IDs of its owners when
+	// not empty. They don't apply to abstract sockets and the sockets passed by systemd.
+	ReceiverSocketMode  os.FileMode
+	ReceiverSocketUser  string
+	ReceiverSocketGroup string
 
 	// Writers
 	StatsWriter             *WriterConfig
 	TraceWriter             *WriterConfig
 	ConnectionResetInterval time.Duration // frequency at which outgoing connections are reset. 0 means no reset is performed
 
+	// ShutdownDrainTimeout is the time given to the workers to process the received traces on shutdown.
+	ShutdownDrainTimeout time.Duration
+
+	// OTLPStatsURL is the URL of an OTLP/HTTP metrics endpoint, such as http://localhost:4318/v1/metrics,
+	// where the computed stats are exported too. Nil if disabled.
+	OTLPStatsURL *url.URL
+
 	// internal telemetry
 	StatsdHost string
 	StatsdPort int
@@ -117,6 +148,10 @@ type AgentConfig struct {
 
 	// Obfuscation holds sensitive data obufscator's configuration.
 	Obfuscation *ObfuscationConfig
+
+	// ObfuscationDiagnosticsRate specifies the rate of the traces whose resources are used to
+	// diagnose the obfuscation, 0 to disable the diagnostics.
+	ObfuscationDiagnosticsRate float64
 }
 
 // New returns a configuration with the default values.
@@ -132,15 +167,28 @@ func New() *AgentConfig {
 		ExtraSampleRate: 1.0,
 		MaxTPS:          10,
 		MaxEPS:          200,
+		ErrorTPS:        10,
+		RareSampler: RareSamplerConfig{
+			Enabled:          true,
+			TPS:              5,
+			Burst:            50,
+			Cooldown:         2 * time.Minute,
+			PriorityCooldown: 10 * time.Minute,
+			CardinalityLimit: 1000,
+		},
 
 		ReceiverHost:    "localhost",
 		ReceiverPort:    8126,
 		ConnectionLimit: 2000,
 		MaxRequestBytes: 50 * 1024 * 1024, // 50MB
+		MaxPayloadSpans: 500000,
+
+		ReceiverSocketMode: 0722,
 
 		StatsWriter:             new(WriterConfig),
 		TraceWriter:             new(WriterConfig),
 		ConnectionResetInterval: 0, // disabled
+		ShutdownDrainTimeout:    5 * time.Second,
 
 		StatsdHost: "localhost",
 		StatsdPort: 8125,
@@ -156,6 +204,7 @@ func New() *AgentConfig {
 		Ignore:                      make(map[string][]string),
 		AnalyzedRateByServiceLegacy: make(map[string]float64),
 		AnalyzedSpansByService:      make(map[string]map[string]float64),
+		TargetTPSByService:          make(map[string]map[string]float64),
 
 		DDAgentBin: defaultDDAgentBin,
 	}
@@ -211,8 +260,10 @@ func (c *AgentConfig) acquireHostname() error {
 // HTTPClient returns a new http.Client to be used for outgoing connections to the
 // Datadog API.
 func (c *AgentConfig) HTTPClient() *http.Client {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.SkipSSLValidation}
+	coreconfig.ConfigureFIPSTLS(tlsConfig)
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: c.SkipSSLValidation},
+		TLSClientConfig: tlsConfig,
 		// below field values are from http.DefaultTransport (go1.12)
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
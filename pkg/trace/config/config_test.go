@@ -108,6 +108,7 @@ func TestDefaultConfig(t *testing.T) {
 	// assert that some sane defaults are set
 	assert.Equal("localhost", c.ReceiverHost)
 	assert.Equal(8126, c.ReceiverPort)
+	assert.Equal(os.FileMode(0722), c.ReceiverSocketMode)
 
 	assert.Equal("localhost", c.StatsdHost)
 	assert.Equal(8125, c.StatsdPort)
@@ -157,9 +158,13 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.Equal("test", c.DefaultEnv)
 	assert.Equal(123, c.ConnectionLimit)
 	assert.Equal(18126, c.ReceiverPort)
+	assert.Equal(os.FileMode(0750), c.ReceiverSocketMode)
 	assert.Equal(0.5, c.ExtraSampleRate)
 	assert.Equal(5.0, c.MaxTPS)
+	assert.Equal(5.0, c.ErrorTPS)
 	assert.Equal(50.0, c.MaxEPS)
+	assert.Equal(10000, c.MaxResourceLen)
+	assert.Equal(0.01, c.ObfuscationDiagnosticsRate)
 	assert.Equal(0.5, c.MaxCPU)
 	assert.EqualValues(123.4, c.MaxMemory)
 	assert.Equal("0.0.0.0", c.ReceiverHost)
@@ -180,6 +185,7 @@ func TestFullYamlConfig(t *testing.T) {
 		{Host: "https://my2.endpoint.eu", APIKey: "apikey3", NoProxy: noProxy},
 		{Host: "https://my2.endpoint.eu", APIKey: "apikey4", NoProxy: noProxy},
 		{Host: "https://my2.endpoint.eu", APIKey: "apikey5", NoProxy: noProxy},
+		{Host: "https://my3.endpoint.com", APIKey: "apikey6", SampleRate: 0.25},
 	}, c.Endpoints)
 
 	assert.ElementsMatch([]*ReplaceRule{
@@ -209,13 +215,78 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.NotNil(o)
 	assert.True(o.ES.Enabled)
 	assert.EqualValues([]string{"user_id", "category_id"}, o.ES.KeepValues)
+	assert.True(o.ES.UseDefaults)
 	assert.True(o.Mongo.Enabled)
 	assert.EqualValues([]string{"uid", "cat_id"}, o.Mongo.KeepValues)
+	assert.True(o.Mongo.UseDefaults)
 	assert.True(o.HTTP.RemoveQueryString)
 	assert.True(o.HTTP.RemovePathDigits)
+	assert.True(o.SQL.NormalizeQuotedIdentifiers)
+	assert.Equal(65536, o.SQL.MaxBytes)
+	assert.Equal(50, o.SQL.MaxDurationMs)
+	assert.Equal(1000, o.SQL.CacheSize)
+	assert.Equal("postgres", o.SQL.Dialect)
+	assert.True(o.SQL.ExtractComments)
+	assert.True(o.SQL.KeepValuesTuples)
+	assert.True(o.SQL.TableNames)
+	assert.True(o.SQL.ObfuscateDBStatement)
 	assert.True(o.RemoveStackTraces)
 	assert.True(c.Obfuscation.Redis.Enabled)
 	assert.True(c.Obfuscation.Memcached.Enabled)
+	assert.Equal("%s", c.Obfuscation.ReplacementToken)
+}
+
+func TestParseFileMode(t *testing.T) {
+	for _, tt := range []struct {
+		in   interface{}
+		mode os.FileMode
+		err  bool
+	}{
+		{in: "0722", mode: 0722},
+		{in: "660", mode: 0660},
+		{in: 0770, mode: 0770},
+		{in: "0799", err: true},
+		{in: "01777", err: true},
+		{in: 1.5, err: true},
+	} {
+		mode, err := parseFileMode(tt.in)
+		if tt.err {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.mode, mode)
+	}
+}
+
+func TestParseAdditionalEndpoint(t *testing.T) {
+	for _, tt := range []struct {
+		in   interface{}
+		keys []string
+		rate float64
+		err  bool
+	}{
+		{in: []interface{}{"key1", "key2"}, keys: []string{"key1", "key2"}},
+		{in: []string{"key1"}, keys: []string{"key1"}},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}}, keys: []string{"key1"}},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}, "sample_rate": 0.5}, keys: []string{"key1"}, rate: 0.5},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}, "sample_rate": 1}, keys: []string{"key1"}, rate: 1},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}, "sample_rate": 0}, err: true},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}, "sample_rate": 1.5}, err: true},
+		{in: map[string]interface{}{"api_keys": []interface{}{"key1"}, "sample_rate": "half"}, err: true},
+		{in: map[string]interface{}{"sample_rate": 0.5}, err: true},
+		{in: []interface{}{}, err: true},
+		{in: "key1", err: true},
+	} {
+		keys, rate, err := parseAdditionalEndpoint(tt.in)
+		if tt.err {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.keys, keys)
+		assert.Equal(t, tt.rate, rate)
+	}
 }
 
 func TestUndocumentedYamlConfig(t *testing.T) {
@@ -237,6 +308,20 @@ func TestUndocumentedYamlConfig(t *testing.T) {
 	assert.Equal(0.33, c.ExtraSampleRate)
 	assert.Equal(100.0, c.MaxTPS)
 	assert.Equal(1000.0, c.MaxEPS)
+	assert.Equal(map[string]map[string]float64{
+		"web": {"prod": 5, "staging": 1.5},
+		"db":  {"prod": 2},
+	}, c.TargetTPSByService)
+	assert.Equal(20.0, c.ErrorTPS)
+	assert.Equal(RareSamplerConfig{
+		Enabled:          false,
+		TPS:              2,
+		Burst:            20,
+		Cooldown:         time.Minute,
+		PriorityCooldown: 5 * time.Minute,
+		CardinalityLimit: 500,
+		Tags:             []string{"version", "region"},
+	}, c.RareSampler)
 	assert.Equal(25, c.ReceiverPort)
 	assert.Equal(120*time.Second, c.ConnectionResetInterval)
 	// watchdog
@@ -45,9 +45,16 @@ func loadEnv() {
 		{"DD_APM_MAX_EPS", "apm_config.max_events_per_second"},
 		{"DD_MAX_TPS", "apm_config.max_traces_per_second"}, // deprecated
 		{"DD_APM_MAX_TPS", "apm_config.max_traces_per_second"},
+		{"DD_APM_ERROR_TPS", "apm_config.errors_sampler.max_traces_per_second"},
+		{"DD_APM_ENABLE_RARE_SAMPLER", "apm_config.rare_sampler.enabled"},
 		{"DD_APM_MAX_MEMORY", "apm_config.max_memory"},
 		{"DD_APM_MAX_CPU_PERCENT", "apm_config.max_cpu_percent"},
+		{"DD_APM_MAX_RESOURCE_LENGTH", "apm_config.max_resource_length"},
 		{"DD_APM_RECEIVER_SOCKET", "apm_config.receiver_socket"},
+		{"DD_APM_RECEIVER_SOCKET_MODE", "apm_config.receiver_socket_mode"},
+		{"DD_APM_RECEIVER_SOCKET_USER", "apm_config.receiver_socket_user"},
+		{"DD_APM_RECEIVER_SOCKET_GROUP", "apm_config.receiver_socket_group"},
+		{"DD_APM_OTLP_STATS_ENDPOINT", "apm_config.otlp_stats_endpoint"},
 	} {
 		if v := os.Getenv(override.env); v != "" {
 			config.Datadog.Set(override.key, v)
@@ -82,7 +89,7 @@ func loadEnv() {
 		}
 	}
 	if v := os.Getenv("DD_APM_ADDITIONAL_ENDPOINTS"); v != "" {
-		ap := make(map[string][]string)
+		ap := make(map[string]interface{})
 		if err := json.Unmarshal([]byte(v), &ap); err != nil {
 			log.Errorf(`Could not parse DD_APM_ADDITIONAL_ENDPOINTS: %v. It must be of the form '{"https://trace.agent.datadoghq.com": ["apikey1", ...], ...}'.`, err)
 		} else {
@@ -264,9 +264,30 @@ func TestLoadEnv(t *testing.T) {
 			cfg, err := Load("./testdata/full.yaml")
 			assert.NoError(err)
 			assert.Equal(6., cfg.MaxTPS)
+			assert.Equal(6., cfg.ErrorTPS)
 		})
 	}
 
+	t.Run("DD_APM_ERROR_TPS", func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv("DD_APM_ERROR_TPS", "3")
+		assert.NoError(err)
+		defer os.Unsetenv("DD_APM_ERROR_TPS")
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(3., cfg.ErrorTPS)
+	})
+
+	t.Run("DD_APM_ENABLE_RARE_SAMPLER", func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv("DD_APM_ENABLE_RARE_SAMPLER", "false")
+		assert.NoError(err)
+		defer os.Unsetenv("DD_APM_ENABLE_RARE_SAMPLER")
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.False(cfg.RareSampler.Enabled)
+	})
+
 	for _, envKey := range []string{
 		"DD_MAX_EPS", // deprecated
 		"DD_APM_MAX_EPS",
@@ -282,6 +303,34 @@ func TestLoadEnv(t *testing.T) {
 		})
 	}
 
+	t.Run("DD_APM_RECEIVER_SOCKET_MODE", func(t *testing.T) {
+		assert := assert.New(t)
+		for k, v := range map[string]string{
+			"DD_APM_RECEIVER_SOCKET_MODE":  "0770",
+			"DD_APM_RECEIVER_SOCKET_USER":  "dd-agent",
+			"DD_APM_RECEIVER_SOCKET_GROUP": "1001",
+		} {
+			assert.NoError(os.Setenv(k, v))
+			defer os.Unsetenv(k)
+		}
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0770), cfg.ReceiverSocketMode)
+		assert.Equal("dd-agent", cfg.ReceiverSocketUser)
+		assert.Equal("1001", cfg.ReceiverSocketGroup)
+	})
+
+	env = "DD_APM_OTLP_STATS_ENDPOINT"
+	t.Run(env, func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv(env, "http://localhost:4318/v1/metrics")
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal("http://localhost:4318/v1/metrics", cfg.OTLPStatsURL.String())
+	})
+
 	env = "DD_APM_ADDITIONAL_ENDPOINTS"
 	t.Run(env, func(t *testing.T) {
 		assert := assert.New(t)
@@ -294,4 +343,14 @@ func TestLoadEnv(t *testing.T) {
 		assert.Contains(cfg.Endpoints, &Endpoint{APIKey: "key2", Host: "url1"})
 		assert.Contains(cfg.Endpoints, &Endpoint{APIKey: "key3", Host: "url2"})
 	})
+
+	t.Run(env+"/sample_rate", func(t *testing.T) {
+		assert := assert.New(t)
+		err := os.Setenv(env, `{"url1": {"api_keys": ["key1"], "sample_rate": 0.1}}`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Contains(cfg.Endpoints, &Endpoint{APIKey: "key1", Host: "url1", SampleRate: 0.1})
+	})
 }
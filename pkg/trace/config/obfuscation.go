@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LoadObfuscationConfig reads an obfuscation configuration from a YAML document, such
+// as the one written by Export. Its schema is the one of apm_config.obfuscation.
+func LoadObfuscationConfig(path string) (*ObfuscationConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var o ObfuscationConfig
+	if err := yaml.UnmarshalStrict(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Export returns the obfuscation configuration as a YAML document, which can be reviewed,
+// versioned and loaded back with apm_config.obfuscation_file.
+func (o *ObfuscationConfig) Export() ([]byte, error) {
+	return yaml.Marshal(o)
+}
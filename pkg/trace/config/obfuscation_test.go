@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObfuscationConfigExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "obfuscation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	in := &ObfuscationConfig{
+		ES:                JSONObfuscationConfig{Enabled: true, KeepValues: []string{"user_id"}, UseDefaults: true},
+		HTTP:              HTTPObfuscationConfig{RemoveQueryString: true},
+		SQL:               SQLObfuscationConfig{NormalizeQuotedIdentifiers: true, MaxBytes: 1024, MaxDurationMs: 10},
+		RemoveStackTraces: true,
+		Redis:             Enablable{Enabled: true},
+	}
+	out, err := in.Export()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "normalize_quoted_identifiers: true")
+
+	path := filepath.Join(dir, "obfuscation.yaml")
+	require.NoError(t, ioutil.WriteFile(path, out, 0600))
+	loaded, err := LoadObfuscationConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, in, loaded)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("sql:\n  unknown: true\n"), 0600))
+	_, err = LoadObfuscationConfig(path)
+	assert.Error(t, err)
+}
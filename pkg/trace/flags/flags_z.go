@@ -23,6 +23,10 @@ var (
 	// Info will display information about a running agent.
 	Info bool
 
+	// ExportObfuscation will cause the agent to print its effective obfuscation
+	// configuration as YAML.
+	ExportObfuscation bool
+
 	// CPUProfile specifies the path to output CPU profiling information to.
 	// When empty, CPU profiling is disabled.
 	CPUProfile string
@@ -46,6 +50,7 @@ func init() {
 	flag.StringVar(&PIDFilePath, "pid", "", "Path to set pidfile for process")
 	flag.BoolVar(&Version, "version", false, "Show version information and exit")
 	flag.BoolVar(&Info, "info", false, "Show info about running trace agent process and exit")
+	flag.BoolVar(&ExportObfuscation, "export-obfuscation", false, "Print the effective obfuscation configuration as YAML and exit")
 
 	// profiling
 	flag.StringVar(&CPUProfile, "cpuprofile", "", "Write cpu profile to file")
@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package info
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client identifies a tracer client sending payloads to the receiver.
+type Client struct {
+	Tags
+	// PID is the process ID of the client. It is only known for the clients
+	// connected through the receiver socket on Linux, 0 otherwise.
+	PID int32
+}
+
+// ClientStats holds the stats of a tracer client since it was first seen.
+type ClientStats struct {
+	Client
+	Stats
+	// PayloadErrors is the number of payloads which could not be decoded.
+	PayloadErrors int64
+
+	firstSeen time.Time
+	lastSeen  int64 // unix nanoseconds, accessed atomically
+}
+
+// Acc accumulates the stats of a payload of the client.
+func (s *ClientStats) Acc(recent *TagStats) {
+	s.update(&recent.Stats)
+}
+
+// ClientsStats holds the stats of the tracer clients sending payloads to
+// the receiver. The number of clients is bounded, the least recently seen
+// ones being forgotten first.
+type ClientsStats struct {
+	mu         sync.Mutex
+	clients    map[Client]*ClientStats
+	maxClients int
+}
+
+// NewClientsStats returns the stats of at most maxClients clients.
+func NewClientsStats(maxClients int) *ClientsStats {
+	return &ClientsStats{
+		clients:    make(map[Client]*ClientStats),
+		maxClients: maxClients,
+	}
+}
+
+// Get returns the stats of the given client, marking it as seen.
+func (cs *ClientsStats) Get(c Client) *ClientStats {
+	now := time.Now()
+
+	cs.mu.Lock()
+	s, ok := cs.clients[c]
+	if !ok {
+		if len(cs.clients) >= cs.maxClients {
+			cs.evictOldest()
+		}
+		s = &ClientStats{
+			Client:    c,
+			Stats:     Stats{TracesDropped: &TracesDropped{}, SpansMalformed: &SpansMalformed{}},
+			firstSeen: now,
+		}
+		cs.clients[c] = s
+	}
+	cs.mu.Unlock()
+
+	atomic.StoreInt64(&s.lastSeen, now.UnixNano())
+	return s
+}
+
+// evictOldest forgets the least recently seen client. cs.mu must be held.
+func (cs *ClientsStats) evictOldest() {
+	var (
+		oldest   Client
+		lastSeen int64
+		found    bool
+	)
+	for c, s := range cs.clients {
+		if seen := atomic.LoadInt64(&s.lastSeen); !found || seen < lastSeen {
+			oldest, lastSeen, found = c, seen, true
+		}
+	}
+	if found {
+		delete(cs.clients, oldest)
+	}
+}
+
+// ClientReport describes the payloads received from a tracer client and
+// why its traces were dropped.
+type ClientReport struct {
+	Lang          string    `json:"lang"`
+	LangVersion   string    `json:"lang_version"`
+	Interpreter   string    `json:"interpreter"`
+	TracerVersion string    `json:"tracer_version"`
+	PID           int32     `json:"pid,omitempty"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+
+	PayloadsAccepted int64 `json:"payloads_accepted"`
+	PayloadsRefused  int64 `json:"payloads_refused"`
+	PayloadsErrors   int64 `json:"payloads_errors"`
+	// ErrorRate is the ratio of the payloads refused by the rate limiter or
+	// which could not be decoded.
+	ErrorRate float64 `json:"error_rate"`
+
+	TracesReceived int64 `json:"traces_received"`
+	TracesDropped  int64 `json:"traces_dropped"`
+	// TracesDroppedReasons counts the dropped traces per reason.
+	TracesDroppedReasons map[string]int64 `json:"traces_dropped_reasons,omitempty"`
+	SpansReceived        int64            `json:"spans_received"`
+	SpansDropped         int64            `json:"spans_dropped"`
+	// SpansMalformedReasons counts the spans fixed by the agent per reason.
+	SpansMalformedReasons map[string]int64 `json:"spans_malformed_reasons,omitempty"`
+}
+
+// Reports returns the reports of the clients, the most recently seen first.
+func (cs *ClientsStats) Reports() []ClientReport {
+	cs.mu.Lock()
+	reports := make([]ClientReport, 0, len(cs.clients))
+	for _, s := range cs.clients {
+		reports = append(reports, s.report())
+	}
+	cs.mu.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].LastSeen.After(reports[j].LastSeen)
+	})
+	return reports
+}
+
+func (s *ClientStats) report() ClientReport {
+	r := ClientReport{
+		Lang:          s.Lang,
+		LangVersion:   s.LangVersion,
+		Interpreter:   s.Interpreter,
+		TracerVersion: s.TracerVersion,
+		PID:           s.PID,
+		FirstSeen:     s.firstSeen,
+		LastSeen:      time.Unix(0, atomic.LoadInt64(&s.lastSeen)),
+
+		PayloadsAccepted: atomic.LoadInt64(&s.PayloadAccepted),
+		PayloadsRefused:  atomic.LoadInt64(&s.PayloadRefused),
+		PayloadsErrors:   atomic.LoadInt64(&s.PayloadErrors),
+		TracesReceived:   atomic.LoadInt64(&s.TracesReceived),
+		SpansReceived:    atomic.LoadInt64(&s.SpansReceived),
+		SpansDropped:     atomic.LoadInt64(&s.SpansDropped),
+	}
+	if total := r.PayloadsAccepted + r.PayloadsRefused + r.PayloadsErrors; total > 0 {
+		r.ErrorRate = float64(r.PayloadsRefused+r.PayloadsErrors) / float64(total)
+	}
+	r.TracesDroppedReasons, r.TracesDropped = nonZero(s.TracesDropped.tagValues())
+	r.SpansMalformedReasons, _ = nonZero(s.SpansMalformed.tagValues())
+	return r
+}
+
+// nonZero returns the non zero values of m, nil if there are none, and their sum.
+func nonZero(m map[string]int64) (map[string]int64, int64) {
+	var (
+		values map[string]int64
+		total  int64
+	)
+	for k, v := range m {
+		if v == 0 {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]int64)
+		}
+		values[k] = v
+		total += v
+	}
+	return values, total
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package info
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientsStats(t *testing.T) {
+	assert := assert.New(t)
+	cs := NewClientsStats(2)
+
+	python := Client{Tags: Tags{Lang: "python", TracerVersion: "0.38.0"}, PID: 10}
+	s := cs.Get(python)
+	atomic.AddInt64(&s.PayloadAccepted, 3)
+	atomic.AddInt64(&s.PayloadRefused, 1)
+	s.Acc(&TagStats{Stats: Stats{
+		SpansReceived:  5,
+		TracesDropped:  &TracesDropped{EmptyTrace: 2},
+		SpansMalformed: &SpansMalformed{ServiceEmpty: 1},
+	}})
+	assert.Equal(s, cs.Get(python))
+
+	reports := cs.Reports()
+	if assert.Len(reports, 1) {
+		r := reports[0]
+		assert.Equal("python", r.Lang)
+		assert.EqualValues(10, r.PID)
+		assert.EqualValues(3, r.PayloadsAccepted)
+		assert.Equal(0.25, r.ErrorRate)
+		assert.EqualValues(5, r.SpansReceived)
+		assert.EqualValues(2, r.TracesDropped)
+		assert.Equal(map[string]int64{"empty_trace": 2}, r.TracesDroppedReasons)
+		assert.Equal(map[string]int64{"service_empty": 1}, r.SpansMalformedReasons)
+	}
+}
+
+func TestClientsStatsEviction(t *testing.T) {
+	assert := assert.New(t)
+	cs := NewClientsStats(2)
+
+	for i, lang := range []string{"python", "go", "java"} {
+		s := cs.Get(Client{Tags: Tags{Lang: lang}})
+		// make the clients seen in order regardless of the clock resolution
+		atomic.StoreInt64(&s.lastSeen, int64(i+1))
+	}
+
+	reports := cs.Reports()
+	if assert.Len(reports, 2) {
+		assert.Equal("java", reports[0].Lang)
+		assert.Equal("go", reports[1].Lang)
+	}
+}
@@ -28,6 +28,7 @@ var (
 	infoMu        sync.RWMutex
 	receiverStats []TagStats // only for the last minute
 	languages     []string
+	clients       []ClientReport
 
 	// TODO: move from package globals to a clean single struct
 
@@ -60,7 +61,7 @@ const (
   Receiver: {{.Status.Config.ReceiverHost}}:{{.Status.Config.ReceiverPort}}
   Endpoints:
     {{ range $i, $e := .Status.Config.Endpoints}}
-    {{ $e.Host }}
+    {{ $e.Host }}{{ if $e.SampleRate }} (traces sample rate: {{ $e.SampleRate }}){{ end }}
     {{end}}
 
   --- Receiver stats (1 min) ---
@@ -76,7 +77,9 @@ const (
   {{end}}
   {{ range $key, $value := .Status.RateByService }}
   Priority sampling rate for '{{ $key }}': {{percent $value}} %
-  {{ end }}
+  {{ end }}{{ range $service, $envs := .Status.Config.TargetTPSByService }}{{ range $env, $tps := $envs }}
+  Target traces per second for 'service:{{ $service }},env:{{ $env }}': {{ $tps }}
+  {{ end }}{{ end }}
   {{if lt .Status.RateLimiter.TargetRate 1.0}}
   WARNING: Rate-limiter keep percentage: {{percent .Status.RateLimiter.TargetRate}} %
   {{end}}
@@ -133,6 +136,21 @@ func Languages() []string {
 	return languages
 }
 
+// UpdateClientsStats updates internal stats about the tracer clients.
+func UpdateClientsStats(cs *ClientsStats) {
+	reports := cs.Reports()
+
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	clients = reports
+}
+
+func publishClientsStats() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return clients
+}
+
 func publishReceiverStats() interface{} {
 	infoMu.RLock()
 	defer infoMu.RUnlock()
@@ -259,6 +277,7 @@ func InitInfo(conf *config.AgentConfig) error {
 		expvar.Publish("uptime", expvar.Func(publishUptime))
 		expvar.Publish("version", expvar.Func(publishVersion))
 		expvar.Publish("receiver", expvar.Func(publishReceiverStats))
+		expvar.Publish("clients", expvar.Func(publishClientsStats))
 		expvar.Publish("sampler", expvar.Func(publishSamplerInfo))
 		expvar.Publish("trace_writer", expvar.Func(publishTraceWriterInfo))
 		expvar.Publish("stats_writer", expvar.Func(publishStatsWriterInfo))
@@ -272,7 +291,7 @@ func InitInfo(conf *config.AgentConfig) error {
 		c := *conf
 		c.Endpoints = make([]*config.Endpoint, len(conf.Endpoints))
 		for i, e := range conf.Endpoints {
-			c.Endpoints[i] = &config.Endpoint{Host: e.Host, NoProxy: e.NoProxy}
+			c.Endpoints[i] = &config.Endpoint{Host: e.Host, NoProxy: e.NoProxy, SampleRate: e.SampleRate}
 		}
 
 		var buf []byte
@@ -124,6 +124,11 @@ func newTagStats(tags Tags) *TagStats {
 	return &TagStats{tags, Stats{TracesDropped: &TracesDropped{}, SpansMalformed: &SpansMalformed{}}}
 }
 
+// Acc accumulates the stats from another TagStats struct.
+func (ts *TagStats) Acc(recent *TagStats) {
+	ts.update(&recent.Stats)
+}
+
 func (ts *TagStats) publish() {
 	// Atomically load the stats from ts
 	tracesReceived := atomic.LoadInt64(&ts.TracesReceived)
@@ -248,6 +253,8 @@ type SpansMalformed struct {
 	InvalidDuration int64
 	// InvalidHTTPStatusCode is when a span's metadata contains an invalid http status code
 	InvalidHTTPStatusCode int64
+	// InvalidTraceIDHigh is when a span's metadata contains invalid high bits of a 128-bit trace ID
+	InvalidTraceIDHigh int64
 }
 
 // tagValues converts SpansMalformed into a map representation with keys matching standardized names for all reasons
@@ -265,6 +272,7 @@ func (s *SpansMalformed) tagValues() map[string]int64 {
 		"invalid_start_date":       atomic.LoadInt64(&s.InvalidStartDate),
 		"invalid_duration":         atomic.LoadInt64(&s.InvalidDuration),
 		"invalid_http_status_code": atomic.LoadInt64(&s.InvalidHTTPStatusCode),
+		"invalid_trace_id_high":    atomic.LoadInt64(&s.InvalidTraceIDHigh),
 	}
 }
 
@@ -314,6 +322,7 @@ type Stats struct {
 func (s *Stats) update(recent *Stats) {
 	atomic.AddInt64(&s.TracesReceived, atomic.LoadInt64(&recent.TracesReceived))
 
+	atomic.AddInt64(&s.TracesDropped.PayloadTooLarge, atomic.LoadInt64(&recent.TracesDropped.PayloadTooLarge))
 	atomic.AddInt64(&s.TracesDropped.DecodingError, atomic.LoadInt64(&recent.TracesDropped.DecodingError))
 	atomic.AddInt64(&s.TracesDropped.EmptyTrace, atomic.LoadInt64(&recent.TracesDropped.EmptyTrace))
 	atomic.AddInt64(&s.TracesDropped.TraceIDZero, atomic.LoadInt64(&recent.TracesDropped.TraceIDZero))
@@ -331,6 +340,7 @@ func (s *Stats) update(recent *Stats) {
 	atomic.AddInt64(&s.SpansMalformed.InvalidStartDate, atomic.LoadInt64(&recent.SpansMalformed.InvalidStartDate))
 	atomic.AddInt64(&s.SpansMalformed.InvalidDuration, atomic.LoadInt64(&recent.SpansMalformed.InvalidDuration))
 	atomic.AddInt64(&s.SpansMalformed.InvalidHTTPStatusCode, atomic.LoadInt64(&recent.SpansMalformed.InvalidHTTPStatusCode))
+	atomic.AddInt64(&s.SpansMalformed.InvalidTraceIDHigh, atomic.LoadInt64(&recent.SpansMalformed.InvalidTraceIDHigh))
 
 	atomic.AddInt64(&s.TracesFiltered, atomic.LoadInt64(&recent.TracesFiltered))
 	atomic.AddInt64(&s.TracesPriorityNone, atomic.LoadInt64(&recent.TracesPriorityNone))
@@ -368,6 +378,7 @@ func (s *Stats) reset() {
 	atomic.StoreInt64(&s.SpansMalformed.InvalidStartDate, 0)
 	atomic.StoreInt64(&s.SpansMalformed.InvalidDuration, 0)
 	atomic.StoreInt64(&s.SpansMalformed.InvalidHTTPStatusCode, 0)
+	atomic.StoreInt64(&s.SpansMalformed.InvalidTraceIDHigh, 0)
 	atomic.StoreInt64(&s.TracesFiltered, 0)
 	atomic.StoreInt64(&s.TracesPriorityNone, 0)
 	atomic.StoreInt64(&s.TracesPriorityNeg, 0)
@@ -51,6 +51,7 @@ func TestSpansMalformed(t *testing.T) {
 			"service_truncate":         0,
 			"invalid_start_date":       0,
 			"invalid_http_status_code": 0,
+			"invalid_trace_id_high":    0,
 			"invalid_duration":         0,
 			"duplicate_span_id":        0,
 			"service_empty":            1,
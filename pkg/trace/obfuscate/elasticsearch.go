@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// esDefaultProfile is the default obfuscation profile of ElasticSearch bodies. It keeps
+// the values describing the structure of the queries and aggregations, and obfuscates the
+// terms of query strings, keeping their fields and operators. Keys, such as the names of
+// the aggregations, are never obfuscated.
+var esDefaultProfile = jsonProfile{
+	keepValues: []string{
+		"_source",
+		"analyzer",
+		"boost",
+		"calendar_interval",
+		"default_field",
+		"default_operator",
+		"field",
+		"fields",
+		"fixed_interval",
+		"format",
+		"from",
+		"interval",
+		"minimum_should_match",
+		"operator",
+		"order",
+		"size",
+		"sort",
+		"time_zone",
+		"type",
+	},
+	transformValues: map[string]func(string) string{
+		"query": obfuscateQueryString,
+	},
+}
+
+// queryStringOperators are the characters having a meaning in the Lucene query string syntax.
+const queryStringOperators = `()[]{}:!^~/&|<>=`
+
+// queryStringKeywords are the boolean and range operators of the Lucene query string syntax.
+var queryStringKeywords = map[string]bool{
+	"AND": true,
+	"OR":  true,
+	"NOT": true,
+	"TO":  true,
+}
+
+// obfuscateQueryString obfuscates the terms and phrases of a Lucene query string, such as
+// `title:(quick OR "brown fox") AND price:>100`, keeping its fields and operators, which
+// results in `title:(? OR ?) AND price:>?`.
+func obfuscateQueryString(q string) string {
+	var out strings.Builder
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			for i < len(rs) && unicode.IsSpace(rs[i]) {
+				i++
+			}
+			out.WriteByte(' ')
+		case r == '"':
+			// phrase
+			for i++; i < len(rs) && rs[i] != '"'; i++ {
+				if rs[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			out.WriteByte('?')
+		case strings.ContainsRune(queryStringOperators, r):
+			out.WriteRune(r)
+			i++
+		default:
+			start := i
+			for i < len(rs) && !unicode.IsSpace(rs[i]) && rs[i] != '"' && !strings.ContainsRune(queryStringOperators, rs[i]) {
+				if rs[i] == '\\' {
+					// escaped character
+					i++
+				}
+				i++
+			}
+			if i > len(rs) {
+				i = len(rs)
+			}
+			word := string(rs[start:i])
+			if i < len(rs) && rs[i] == ':' || queryStringKeywords[word] {
+				// field or keyword
+				out.WriteString(word)
+			} else {
+				out.WriteByte('?')
+			}
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
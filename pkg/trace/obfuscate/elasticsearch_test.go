@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateQueryString(t *testing.T) {
+	for in, out := range map[string]string{
+		`quick brown fox`: `? ? ?`,
+		`title:(quick OR "brown fox") AND price:>100`:   `title:(? OR ?) AND price:>?`,
+		`status:active AND -user.name:"john \"j\" doe"`: `status:? AND -user.name:?`,
+		`date:[2020-01-01 TO 2020-12-31} NOT qu?ck*~2`:  `date:[? TO ?} NOT ?~?`,
+		`  path:\/var\/log `:                            `path:?`,
+		``:                                              ``,
+	} {
+		assert.Equal(t, out, obfuscateQueryString(in), in)
+	}
+}
+
+func TestElasticsearchDefaultProfile(t *testing.T) {
+	body := `{"query":{"bool":{"must":[{"query_string":{"query":"name:john AND age:>30","default_field":"name"}},` +
+		`{"match":{"city":{"query":"New York","operator":"and"}}}]}},` +
+		`"aggs":{"by_country":{"terms":{"field":"country","size":10}}},"size":20,"from":0,"sort":[{"date":"desc"}]}`
+
+	t.Run("on", func(t *testing.T) {
+		o := NewObfuscator(&config.ObfuscationConfig{
+			ES: config.JSONObfuscationConfig{Enabled: true, UseDefaults: true, KeepValues: []string{"city"}},
+		})
+		out, err := o.es.obfuscate([]byte(body))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"bool":{"must":[{"query_string":{"query":"name:? AND age:>?","default_field":"name"}},`+
+			`{"match":{"city":{"query":"New York","operator":"and"}}}]}},`+
+			`"aggs":{"by_country":{"terms":{"field":"country","size":10}}},"size":20,"from":0,"sort":[{"date":"desc"}]}`, out)
+	})
+
+	t.Run("off", func(t *testing.T) {
+		o := NewObfuscator(&config.ObfuscationConfig{
+			ES: config.JSONObfuscationConfig{Enabled: true},
+		})
+		out, err := o.es.obfuscate([]byte(body))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"bool":{"must":[{"query_string":{"query":"?","default_field":"?"}},`+
+			`{"match":{"city":{"query":"?","operator":"?"}}}]}},`+
+			`"aggs":{"by_country":{"terms":{"field":"?","size":"?"}}},"size":"?","from":"?","sort":[{"date":"?"}]}`, out)
+	})
+
+	t.Run("non-string", func(t *testing.T) {
+		o := NewObfuscator(&config.ObfuscationConfig{
+			ES: config.JSONObfuscationConfig{Enabled: true, UseDefaults: true},
+		})
+		out, err := o.es.obfuscate([]byte(`{"a":{"query":42},"b":{"query":{"term":{"user":"kimchy"}}},"c":{"query":"x<y"}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":{"query":"?"},"b":{"query":{"term":{"user":"?"}}},"c":{"query":"?<?"}}`, out)
+	})
+}
@@ -6,9 +6,13 @@
 package obfuscate
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 )
 
@@ -19,49 +23,137 @@ func (o *Obfuscator) obfuscateJSON(span *pb.Span, tag string, obfuscator *jsonOb
 		// obfuscator is disabled or tag is not present
 		return
 	}
-	span.Meta[tag], _ = obfuscator.obfuscate([]byte(span.Meta[tag]))
+	var err error
+	span.Meta[tag], err = obfuscator.obfuscate([]byte(span.Meta[tag]))
 	// we should accept whatever the obfuscator returns, even if it's an error: a parsing
 	// error simply means that the JSON was invalid, meaning that we've only obfuscated
 	// as much of it as we could. It is safe to accept the output, even if partial.
+	tags := []string{"type:" + span.Type, "outcome:success"}
+	if err != nil {
+		tags[1] = "outcome:error"
+		obfuscationFailures.Add(span.Type+".error", 1)
+	}
+	metrics.Count("datadog.trace_agent.obfuscations", 1, tags, 1)
 }
 
+// jsonObfuscator obfuscates JSON documents. It is safe for concurrent use: its settings
+// are read-only once built and the state of each obfuscation comes from a pool.
 type jsonObfuscator struct {
-	keepers map[string]bool // these keys will not be obfuscated
+	keepers      map[string]bool                // these keys will not be obfuscated
+	topKeepers   map[string]bool                // these keys will not be obfuscated at the top level of the document
+	transformers map[string]func(string) string // the string values of these keys are transformed
+	replacement  string                         // JSON string replacing the obfuscated values, e.g. "?"
+
+	states sync.Pool // *jsonObfuscation
+}
+
+// jsonObfuscation holds the state of the obfuscation of a document.
+type jsonObfuscation struct {
+	*jsonObfuscator
 
 	scan     *scanner // scanner
 	closures []bool   // closure stack, true if object (e.g. {[{ => []bool{true, false, true})
 	key      bool     // true if scanning a key
 
-	wiped     bool // true if obfuscation string (`"?"`) was already written for current value
+	wiped     bool // true if the replacement string (e.g. `"?"`) was already written for current value
 	keeping   bool // true if not obfuscating
 	keepDepth int  // the depth at which we've stopped obfuscating
+
+	transform    func(string) string // transformer of the current value, nil if none
+	transformBuf []byte              // recording the value to transform
 }
 
-func newJSONObfuscator(cfg *config.JSONObfuscationConfig) *jsonObfuscator {
+// newJSONObfuscator returns a JSON obfuscator with the given configuration, replacing the
+// obfuscated values with the given token.
+func newJSONObfuscator(cfg *config.JSONObfuscationConfig, token string) *jsonObfuscator {
 	keepValue := make(map[string]bool, len(cfg.KeepValues))
 	for _, v := range cfg.KeepValues {
 		keepValue[v] = true
 	}
-	return &jsonObfuscator{
-		closures: []bool{},
-		keepers:  keepValue,
-		scan:     &scanner{},
+	replacement, _ := json.Marshal(token) // strings can always be marshalled
+	o := &jsonObfuscator{
+		keepers:      keepValue,
+		topKeepers:   map[string]bool{},
+		transformers: map[string]func(string) string{},
+		replacement:  string(replacement),
+	}
+	o.states.New = func() interface{} {
+		return &jsonObfuscation{
+			jsonObfuscator: o,
+			closures:       []bool{},
+			scan:           &scanner{},
+		}
 	}
+	return o
+}
+
+// jsonProfile is a set of default obfuscation settings for the JSON bodies of a given type.
+type jsonProfile struct {
+	keepValues         []string                       // keys whose values are not obfuscated
+	keepTopLevelValues []string                       // keys of the root object whose values are not obfuscated
+	transformValues    map[string]func(string) string // keys whose string values are transformed
+}
+
+// useProfile adds the settings of the profile to the ones of the obfuscator.
+func (p *jsonObfuscator) useProfile(profile jsonProfile) {
+	for _, k := range profile.keepValues {
+		p.keepers[k] = true
+	}
+	for _, k := range profile.keepTopLevelValues {
+		p.topKeepers[k] = true
+	}
+	for k, fn := range profile.transformValues {
+		p.transformers[k] = fn
+	}
+}
+
+// flushTransform writes the transformed value being recorded, if any. Values which are
+// not strings are obfuscated.
+func (p *jsonObfuscation) flushTransform(out *strings.Builder) {
+	if p.transform == nil {
+		return
+	}
+	var s string
+	if err := json.Unmarshal(p.transformBuf, &s); err != nil {
+		out.WriteString(p.replacement)
+	} else {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(p.transform(s)); err != nil {
+			out.WriteString(p.replacement)
+		} else {
+			out.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+		}
+	}
+	p.transform = nil
+	p.transformBuf = p.transformBuf[:0]
 }
 
 // setKey verifies if we are currently scanning a key based on the current state
 // and updates the state accordingly. It must be called only after a closure or a
 // value scan has ended.
-func (p *jsonObfuscator) setKey() {
+func (p *jsonObfuscation) setKey() {
 	n := len(p.closures)
 	p.key = n == 0 || p.closures[n-1] // true if we are at top level or in an object
 	p.wiped = false
 }
 
+// obfuscate obfuscates the JSON document in data.
 func (p *jsonObfuscator) obfuscate(data []byte) (string, error) {
+	state := p.states.Get().(*jsonObfuscation)
+	defer p.states.Put(state)
+	return state.obfuscate(data)
+}
+
+func (p *jsonObfuscation) obfuscate(data []byte) (string, error) {
 	var out strings.Builder
 	buf := make([]byte, 0, 10) // recording key token
 	p.scan.reset()
+	p.closures = p.closures[:0]
+	p.keeping = false
+	p.transform = nil
+	p.transformBuf = p.transformBuf[:0]
 	for _, c := range data {
 		p.scan.bytes++
 		op := p.scan.step(p.scan, c)
@@ -71,11 +163,13 @@ func (p *jsonObfuscator) obfuscate(data []byte) (string, error) {
 			// object begins: {
 			p.closures = append(p.closures, true)
 			p.setKey()
+			p.transform = nil // only string values are transformed
 
 		case scanBeginArray:
 			// array begins: [
 			p.closures = append(p.closures, false)
 			p.setKey()
+			p.transform = nil
 
 		case scanEndArray, scanEndObject:
 			// array or object closing
@@ -86,6 +180,7 @@ func (p *jsonObfuscator) obfuscate(data []byte) (string, error) {
 
 		case scanObjectValue, scanArrayValue:
 			// done scanning value
+			p.flushTransform(&out)
 			p.setKey()
 			if p.keeping && depth < p.keepDepth {
 				p.keeping = false
@@ -96,10 +191,14 @@ func (p *jsonObfuscator) obfuscate(data []byte) (string, error) {
 			if p.key {
 				// it's a key
 				buf = append(buf, c)
+			} else if p.transform != nil {
+				// it's a value we're transforming once complete
+				p.transformBuf = append(p.transformBuf, c)
+				continue
 			} else if !p.keeping {
 				// it's a value we're not keeping
 				if !p.wiped {
-					out.Write([]byte(`"?"`))
+					out.WriteString(p.replacement)
 					p.wiped = true
 				}
 				continue
@@ -108,10 +207,14 @@ func (p *jsonObfuscator) obfuscate(data []byte) (string, error) {
 		case scanObjectKey:
 			// done scanning key
 			k := strings.Trim(string(buf), `"`)
-			if !p.keeping && p.keepers[k] {
+			if !p.keeping && (p.keepers[k] || depth == 1 && p.topKeepers[k]) {
 				// we should not obfuscate values of this key
 				p.keeping = true
 				p.keepDepth = depth + 1
+			} else if !p.keeping && p.transformers[k] != nil {
+				// we should transform the value of this key
+				p.transform = p.transformers[k]
+				p.transformBuf = p.transformBuf[:0]
 			}
 			buf = buf[:0]
 			p.key = false
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
@@ -81,7 +82,7 @@ func TestObfuscateJSON(t *testing.T) {
 		return func(t *testing.T) {
 			assert := assert.New(t)
 			cfg := &config.JSONObfuscationConfig{KeepValues: s.KeepValues}
-			out, err := newJSONObfuscator(cfg).obfuscate([]byte(s.In))
+			out, err := newJSONObfuscator(cfg, "?").obfuscate([]byte(s.In))
 			if !s.DontNormalize {
 				assert.NoError(err)
 			}
@@ -98,6 +99,28 @@ func TestObfuscateJSON(t *testing.T) {
 	}
 }
 
+func TestObfuscateJSONConcurrent(t *testing.T) {
+	for i, s := range jsonSuite {
+		s := s
+		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
+			// a single obfuscator is shared by all the goroutines
+			o := newJSONObfuscator(&config.JSONObfuscationConfig{KeepValues: s.KeepValues}, "?")
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for n := 0; n < 10; n++ {
+						out, _ := o.obfuscate([]byte(s.In))
+						assert.Equal(t, s.Out, out)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
 func BenchmarkObfuscateJSON(b *testing.B) {
 	cfg := &config.JSONObfuscationConfig{KeepValues: []string{"highlight"}}
 	if len(jsonSuite) == 0 {
@@ -114,7 +137,7 @@ func BenchmarkObfuscateJSON(b *testing.B) {
 		b.Run(strconv.Itoa(len(test.In)), func(b *testing.B) {
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				_, err := newJSONObfuscator(cfg).obfuscate([]byte(test.In))
+				_, err := newJSONObfuscator(cfg, "?").obfuscate([]byte(test.In))
 				if !test.DontNormalize && err != nil {
 					b.Fatal(err)
 				}
@@ -122,3 +145,17 @@ func BenchmarkObfuscateJSON(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkObfuscateJSONParallel(b *testing.B) {
+	if len(jsonSuite) == 0 {
+		b.Fatal("no test suite loaded")
+	}
+	test := jsonSuite[len(jsonSuite)-1]
+	o := newJSONObfuscator(&config.JSONObfuscationConfig{KeepValues: []string{"highlight"}}, "?")
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			o.obfuscate([]byte(test.In))
+		}
+	})
+}
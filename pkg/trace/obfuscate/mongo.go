@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+// mongoDefaultProfile is the default obfuscation profile of MongoDB queries. Keys, such as
+// the $-prefixed operators and the field names, are never obfuscated. It keeps the names of
+// the collections, set at the top level of the commands, and the values of the pipeline
+// stages which can't hold literals. Everything else, $regex patterns included, is obfuscated.
+var mongoDefaultProfile = jsonProfile{
+	keepValues: []string{
+		"$count",
+		"$limit",
+		"$skip",
+		"$sort",
+		"$unwind",
+	},
+	keepTopLevelValues: []string{
+		"aggregate",
+		"collection",
+		"count",
+		"create",
+		"delete",
+		"distinct",
+		"drop",
+		"find",
+		"findAndModify",
+		"insert",
+		"mapReduce",
+		"update",
+	},
+}
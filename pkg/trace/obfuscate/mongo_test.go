@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMongoDefaultProfile(t *testing.T) {
+	o := NewObfuscator(&config.ObfuscationConfig{
+		Mongo: config.JSONObfuscationConfig{Enabled: true, UseDefaults: true},
+	})
+	for in, out := range map[string]string{
+		`{"find":"users","filter":{"name":{"$regex":"^jo","$options":"i"},"age":{"$gt":30}},"sort":{"age":1}}`:                    `{"find":"users","filter":{"name":{"$regex":"?","$options":"?"},"age":{"$gt":"?"}},"sort":{"age":"?"}}`,
+		`{"insert":"users","documents":[{"find":"secret","count":3}]}`:                                                            `{"insert":"users","documents":[{"find":"?","count":"?"}]}`,
+		`{"aggregate":"orders","pipeline":[{"$match":{"status":"A"}},{"$sort":{"total":-1}},{"$limit":10},{"$unwind":"$items"}]}`: `{"aggregate":"orders","pipeline":[{"$match":{"status":"?"}},{"$sort":{"total":-1}},{"$limit":10},{"$unwind":"$items"}]}`,
+		`{"status":"A","qty":{"$lt":30}}`: `{"status":"?","qty":{"$lt":"?"}}`,
+	} {
+		// run twice to verify that the state of the obfuscator is reset
+		for i := 0; i < 2; i++ {
+			res, err := o.mongo.obfuscate([]byte(in))
+			assert.NoError(t, err)
+			assert.Equal(t, out, res)
+		}
+	}
+}
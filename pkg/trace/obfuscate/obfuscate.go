@@ -9,23 +9,46 @@ package obfuscate
 
 import (
 	"bytes"
+	"expvar"
 	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// Obfuscator quantizes and obfuscates spans. The obfuscator is not safe for
-// concurrent use.
+// obfuscationFailures counts the spans whose obfuscation failed by span type and outcome, such
+// as "sql.error" for the queries replaced by "Non-parsable SQL query". It is published with expvar.
+var obfuscationFailures = expvar.NewMap("obfuscation_failures")
+
+// Obfuscator quantizes and obfuscates spans. The obfuscator is safe for concurrent
+// use: a single instance can be shared by all the goroutines processing spans.
 type Obfuscator struct {
+	// sqlCacheHits and sqlCacheMisses count the lookups in sqlCache. They are accessed
+	// atomically and kept first for their 64-bit alignment on 32-bit platforms.
+	sqlCacheHits   uint64
+	sqlCacheMisses uint64
+
 	opts  *config.ObfuscationConfig
 	es    *jsonObfuscator // nil if disabled
 	mongo *jsonObfuscator // nil if disabled
+	// replacement is the token replacing the obfuscated literals.
+	replacement string
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
 	// A non-zero value means 'yes'. Different SQL engines behave in different ways and the tokenizer needs
 	// to be generic.
-	// Not safe for concurrent use.
+	// It is accessed atomically and learnt from the queries, each query gets its own tokenizer.
+	// It is not used when sqlDialect defines how to treat escape characters.
 	sqlLiteralEscapes int32
+	// sqlDialect holds the lexical rules of the configured SQL engine.
+	sqlDialect sqlDialect
+	// sqlBudget limits the resources spent obfuscating a single SQL query.
+	sqlBudget sqlBudget
+	// sqlCache holds the obfuscated SQL queries by raw query, nil if disabled.
+	sqlCache *lru.Cache
 }
 
 // SetSQLLiteralEscapes sets whether or not escape characters should be treated literally by the SQL obfuscator.
@@ -47,16 +70,61 @@ func NewObfuscator(cfg *config.ObfuscationConfig) *Obfuscator {
 	if cfg == nil {
 		cfg = new(config.ObfuscationConfig)
 	}
-	o := Obfuscator{opts: cfg}
+	o := Obfuscator{
+		opts:        cfg,
+		replacement: replacementToken(cfg),
+		sqlBudget:   newSQLBudget(&cfg.SQL),
+		sqlDialect:  genericSQLDialect,
+	}
+	if d, ok := sqlDialects[cfg.SQL.Dialect]; ok {
+		o.sqlDialect = d
+	} else {
+		log.Warnf("Unknown SQL obfuscation dialect %q, falling back to the generic one.", cfg.SQL.Dialect)
+	}
+	if cfg.SQL.CacheSize > 0 {
+		// lru.New only fails on a non-positive size
+		o.sqlCache, _ = lru.New(cfg.SQL.CacheSize)
+	}
 	if cfg.ES.Enabled {
-		o.es = newJSONObfuscator(&cfg.ES)
+		o.es = newJSONObfuscator(&cfg.ES, o.replacement)
+		if cfg.ES.UseDefaults {
+			o.es.useProfile(esDefaultProfile)
+		}
 	}
 	if cfg.Mongo.Enabled {
-		o.mongo = newJSONObfuscator(&cfg.Mongo)
+		o.mongo = newJSONObfuscator(&cfg.Mongo, o.replacement)
+		if cfg.Mongo.UseDefaults {
+			o.mongo.useProfile(mongoDefaultProfile)
+		}
 	}
 	return &o
 }
 
+// EffectiveConfig returns a copy of the configuration, completed with the defaults the
+// obfuscator uses for the settings left unset.
+func EffectiveConfig(cfg *config.ObfuscationConfig) *config.ObfuscationConfig {
+	var o config.ObfuscationConfig
+	if cfg != nil {
+		o = *cfg
+	}
+	budget := newSQLBudget(&o.SQL)
+	o.SQL.MaxBytes = budget.maxBytes
+	o.SQL.MaxDurationMs = int(budget.maxDuration / time.Millisecond)
+	o.ReplacementToken = replacementToken(&o)
+	return &o
+}
+
+// defaultReplacementToken replaces the obfuscated literals when no other token is configured.
+const defaultReplacementToken = "?"
+
+// replacementToken returns the token replacing the obfuscated literals with the given configuration.
+func replacementToken(cfg *config.ObfuscationConfig) string {
+	if cfg.ReplacementToken == "" {
+		return defaultReplacementToken
+	}
+	return cfg.ReplacementToken
+}
+
 // Obfuscate may obfuscate span's properties based on its type and on the Obfuscator's
 // configuration.
 func (o *Obfuscator) Obfuscate(span *pb.Span) {
@@ -78,6 +146,10 @@ func (o *Obfuscator) Obfuscate(span *pb.Span) {
 		o.obfuscateJSON(span, "mongodb.query", o.mongo)
 	case "elasticsearch":
 		o.obfuscateJSON(span, "elasticsearch.body", o.es)
+	case "":
+		if o.opts.SQL.ObfuscateDBStatement {
+			o.obfuscateDBStatement(span)
+		}
 	}
 }
 
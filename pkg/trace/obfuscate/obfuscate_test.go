@@ -6,9 +6,12 @@
 package obfuscate
 
 import (
+	"expvar"
 	"flag"
 	"log"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
@@ -238,9 +241,125 @@ func TestLiteralEscapes(t *testing.T) {
 	})
 }
 
+func TestObfuscatorConcurrent(t *testing.T) {
+	o := NewObfuscator(&config.ObfuscationConfig{
+		ES:    config.JSONObfuscationConfig{Enabled: true, UseDefaults: true},
+		Mongo: config.JSONObfuscationConfig{Enabled: true, UseDefaults: true},
+		Redis: config.Enablable{Enabled: true},
+	})
+	spans := func() []*pb.Span {
+		return []*pb.Span{
+			{Type: "sql", Resource: "SELECT * FROM users WHERE id = 42"},
+			{Type: "redis", Resource: "SET k v", Meta: map[string]string{"redis.raw_command": "SET k v"}},
+			{Type: "elasticsearch", Meta: map[string]string{"elasticsearch.body": `{"query": {"match": {"name": "Jane"}}}`}},
+			{Type: "mongodb", Meta: map[string]string{"mongodb.query": `{"find": "users", "filter": {"name": "Jane"}}`}},
+		}
+	}
+	want := spans()
+	for _, span := range want {
+		o.Obfuscate(span)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				got := spans()
+				for _, span := range got {
+					o.Obfuscate(span)
+				}
+				assert.Equal(t, want, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func BenchmarkCompactWhitespaces(b *testing.B) {
 	str := "a b       cde     fg       hi                     j  jk   lk lkjfdsalfd     afsd sfdafsd f"
 	for i := 0; i < b.N; i++ {
 		compactWhitespaces(str)
 	}
 }
+
+func TestEffectiveConfig(t *testing.T) {
+	cfg := EffectiveConfig(nil)
+	assert.Equal(t, defaultSQLMaxBytes, cfg.SQL.MaxBytes)
+	assert.Equal(t, 100, cfg.SQL.MaxDurationMs)
+	assert.Equal(t, "?", cfg.ReplacementToken)
+
+	in := &config.ObfuscationConfig{
+		ES:  config.JSONObfuscationConfig{Enabled: true, UseDefaults: true},
+		SQL: config.SQLObfuscationConfig{MaxBytes: 1024},
+	}
+	cfg = EffectiveConfig(in)
+	assert.True(t, cfg.ES.Enabled)
+	assert.Equal(t, 1024, cfg.SQL.MaxBytes)
+	assert.Equal(t, 100, cfg.SQL.MaxDurationMs)
+	assert.Equal(t, 0, in.SQL.MaxDurationMs, "the configuration should not be modified")
+}
+
+func TestReplacementToken(t *testing.T) {
+	o := NewObfuscator(&config.ObfuscationConfig{
+		Mongo:            config.JSONObfuscationConfig{Enabled: true},
+		Redis:            config.Enablable{Enabled: true},
+		ReplacementToken: "%s",
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		span := &pb.Span{Type: "sql", Resource: "SELECT * FROM users WHERE id = 42 AND name IN ('a', 'b')"}
+		o.Obfuscate(span)
+		assert.Equal(t, "SELECT * FROM users WHERE id = %s AND name IN ( %s )", span.Resource)
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		span := &pb.Span{Type: "redis", Meta: map[string]string{redisRawCommand: "SET key value\nAUTH password"}}
+		o.Obfuscate(span)
+		assert.Equal(t, "SET key %s\nAUTH %s", span.Meta[redisRawCommand])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		span := &pb.Span{Type: "mongodb", Meta: map[string]string{"mongodb.query": `{"name": "john", "age": {"$gt": 30}}`}}
+		o.Obfuscate(span)
+		assert.Equal(t, `{"name":"%s","age":{"$gt":"%s"}}`, span.Meta["mongodb.query"])
+	})
+
+	t.Run("json-escaped", func(t *testing.T) {
+		o := NewObfuscator(&config.ObfuscationConfig{
+			Mongo:            config.JSONObfuscationConfig{Enabled: true},
+			ReplacementToken: `"?"`,
+		})
+		span := &pb.Span{Type: "mongodb", Meta: map[string]string{"mongodb.query": `{"name": "john"}`}}
+		o.Obfuscate(span)
+		assert.Equal(t, `{"name":"\"?\""}`, span.Meta["mongodb.query"])
+	})
+}
+
+func TestObfuscationFailures(t *testing.T) {
+	failures := func(key string) int64 {
+		if v, ok := obfuscationFailures.Get(key).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	o := NewObfuscator(&config.ObfuscationConfig{
+		ES:  config.JSONObfuscationConfig{Enabled: true},
+		SQL: config.SQLObfuscationConfig{MaxBytes: 100},
+	})
+	sqlErrors, sqlBudget, esErrors := failures("sql.error"), failures("sql.budget-exceeded"), failures("elasticsearch.error")
+
+	o.Obfuscate(&pb.Span{Type: "sql", Resource: "SELECT * FROM users WHERE id = 1"})
+	o.Obfuscate(&pb.Span{Type: "elasticsearch", Meta: map[string]string{"elasticsearch.body": `{"query": "x"}`}})
+	assert.Equal(t, sqlErrors, failures("sql.error"))
+	assert.Equal(t, sqlBudget, failures("sql.budget-exceeded"))
+	assert.Equal(t, esErrors, failures("elasticsearch.error"))
+
+	o.Obfuscate(&pb.Span{Type: "sql", Resource: "SELECT * FROM users WHERE name = 'unterminated"})
+	o.Obfuscate(&pb.Span{Type: "sql", Resource: "SELECT * FROM users WHERE id IN (" + strings.Repeat("1, ", 100) + "1)"})
+	o.Obfuscate(&pb.Span{Type: "elasticsearch", Meta: map[string]string{"elasticsearch.body": `{"query": "trunc`}})
+	assert.Equal(t, sqlErrors+1, failures("sql.error"))
+	assert.Equal(t, sqlBudget+1, failures("sql.budget-exceeded"))
+	assert.Equal(t, esErrors+1, failures("elasticsearch.error"))
+}
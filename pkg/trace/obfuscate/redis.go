@@ -88,7 +88,7 @@ const redisRawCommand = "redis.raw_command"
 
 // obfuscateRedis obfuscates arguments inside the given span's "redis.raw_command" tag, if it exists
 // and is non-empty.
-func (*Obfuscator) obfuscateRedis(span *pb.Span) {
+func (o *Obfuscator) obfuscateRedis(span *pb.Span) {
 	if span.Meta == nil || span.Meta[redisRawCommand] == "" {
 		// nothing to do
 		return
@@ -106,7 +106,7 @@ func (*Obfuscator) obfuscateRedis(span *pb.Span) {
 			// new command starting
 			if cmd != "" {
 				// a previous command was buffered, obfuscate it
-				obfuscateRedisCmd(&str, cmd, args...)
+				obfuscateRedisCmd(&str, o.replacement, cmd, args...)
 				str.WriteByte('\n')
 			}
 			cmd = tok
@@ -116,14 +116,15 @@ func (*Obfuscator) obfuscateRedis(span *pb.Span) {
 		}
 		if done {
 			// last command
-			obfuscateRedisCmd(&str, cmd, args...)
+			obfuscateRedisCmd(&str, o.replacement, cmd, args...)
 			break
 		}
 	}
 	span.Meta[redisRawCommand] = str.String()
 }
 
-func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
+// obfuscateRedisCmd writes the command to out, replacing the obfuscated arguments with token.
+func obfuscateRedisCmd(out *strings.Builder, token, cmd string, args ...string) {
 	out.WriteString(cmd)
 	if len(args) == 0 {
 		return
@@ -135,7 +136,7 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 		// Obfuscate everything after command
 		// • AUTH password
 		if len(args) > 0 {
-			args[0] = "?"
+			args[0] = token
 			args = args[:1]
 		}
 
@@ -153,7 +154,7 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 		// • ZRANK key member
 		// • ZREVRANK key member
 		// • ZSCORE key member
-		obfuscateRedisArgN(args, 1)
+		obfuscateRedisArgN(args, token, 1)
 
 	case "HSET", "HSETNX", "LREM", "LSET", "SETBIT", "SETEX", "PSETEX",
 		"SETRANGE", "ZINCRBY", "SMOVE", "RESTORE":
@@ -169,12 +170,12 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 		// • ZINCRBY key increment member
 		// • SMOVE source destination member
 		// • RESTORE key ttl serialized-value [REPLACE]
-		obfuscateRedisArgN(args, 2)
+		obfuscateRedisArgN(args, token, 2)
 
 	case "LINSERT":
 		// Obfuscate 4th argument:
 		// • LINSERT key BEFORE|AFTER pivot value
-		obfuscateRedisArgN(args, 3)
+		obfuscateRedisArgN(args, token, 3)
 
 	case "GEOHASH", "GEOPOS", "GEODIST", "LPUSH", "RPUSH", "SREM",
 		"ZREM", "SADD":
@@ -188,31 +189,31 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 		// • ZREM key member [member ...]
 		// • SADD key member [member ...]
 		if len(args) > 1 {
-			args[1] = "?"
+			args[1] = token
 			args = args[:2]
 		}
 
 	case "GEOADD":
 		// Obfuscating every 3rd argument starting from first
 		// • GEOADD key longitude latitude member [longitude latitude member ...]
-		obfuscateRedisArgsStep(args, 1, 3)
+		obfuscateRedisArgsStep(args, token, 1, 3)
 
 	case "HMSET":
 		// Every 2nd argument starting from first.
 		// • HMSET key field value [field value ...]
-		obfuscateRedisArgsStep(args, 1, 2)
+		obfuscateRedisArgsStep(args, token, 1, 2)
 
 	case "MSET", "MSETNX":
 		// Every 2nd argument starting from command.
 		// • MSET key value [key value ...]
 		// • MSETNX key value [key value ...]
-		obfuscateRedisArgsStep(args, 0, 2)
+		obfuscateRedisArgsStep(args, token, 0, 2)
 
 	case "CONFIG":
 		// Obfuscate 2nd argument to SET sub-command.
 		// • CONFIG SET parameter value
 		if strings.ToUpper(args[0]) == "SET" {
-			obfuscateRedisArgN(args, 2)
+			obfuscateRedisArgN(args, token, 2)
 		}
 
 	case "BITFIELD":
@@ -224,7 +225,7 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 				n = i
 			}
 			if n > 0 && i-n == 3 {
-				args[i] = "?"
+				args[i] = token
 				break
 			}
 		}
@@ -245,7 +246,7 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 				break loop
 			}
 		}
-		obfuscateRedisArgsStep(args, i, 2)
+		obfuscateRedisArgsStep(args, token, i, 2)
 
 	default:
 		// Obfuscate nothing.
@@ -253,18 +254,18 @@ func obfuscateRedisCmd(out *strings.Builder, cmd string, args ...string) {
 	out.WriteString(strings.Join(args, " "))
 }
 
-func obfuscateRedisArgN(args []string, n int) {
+func obfuscateRedisArgN(args []string, token string, n int) {
 	if len(args) > n {
-		args[n] = "?"
+		args[n] = token
 	}
 }
 
-func obfuscateRedisArgsStep(args []string, start, step int) {
+func obfuscateRedisArgsStep(args []string, token string, start, step int) {
 	if start+step-1 >= len(args) {
 		// can't reach target
 		return
 	}
 	for i := start + step - 1; i < len(args); i += step {
-		args[i] = "?"
+		args[i] = token
 	}
 }
@@ -9,7 +9,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -21,8 +25,50 @@ import (
 )
 
 const sqlQueryTag = "sql.query"
+
+// dbStatementTag holds the query of the database spans in the OpenTelemetry conventions.
+const dbStatementTag = "db.statement"
+
+// sqlValuesCountTag holds the number of tuples collapsed in the VALUES clauses of a query.
+const sqlValuesCountTag = "sql.values_count"
 const nonParsableResource = "Non-parsable SQL query"
 
+// budgetExceededResource replaces the queries exceeding the obfuscation budget.
+const budgetExceededResource = "Truncated SQL query"
+
+const (
+	// defaultSQLMaxBytes is the default maximum size of a query to obfuscate.
+	defaultSQLMaxBytes = 256 * 1024
+	// defaultSQLMaxDuration is the default maximum time spent obfuscating a query.
+	defaultSQLMaxDuration = 100 * time.Millisecond
+	// budgetCheckInterval is the number of tokens between two checks of the time budget.
+	budgetCheckInterval = 256
+)
+
+// errBudgetExceeded is returned when obfuscating a query exceeds the budget.
+var errBudgetExceeded = errors.New("obfuscation budget exceeded")
+
+// sqlBudget limits the resources spent obfuscating a single SQL query, protecting the
+// pipeline from pathological inputs, such as megabyte-long generated queries.
+type sqlBudget struct {
+	maxBytes    int
+	maxDuration time.Duration
+}
+
+func newSQLBudget(cfg *config.SQLObfuscationConfig) sqlBudget {
+	b := sqlBudget{
+		maxBytes:    cfg.MaxBytes,
+		maxDuration: time.Duration(cfg.MaxDurationMs) * time.Millisecond,
+	}
+	if b.maxBytes <= 0 {
+		b.maxBytes = defaultSQLMaxBytes
+	}
+	if b.maxDuration <= 0 {
+		b.maxDuration = defaultSQLMaxDuration
+	}
+	return b
+}
+
 // tokenFilter is a generic interface that a sqlObfuscator expects. It defines
 // the Filter() function used to filter or replace given tokens.
 // A filter can be stateful and keep an internal state to apply the filter later;
@@ -68,6 +114,23 @@ func (f *discardFilter) Filter(token, lastToken TokenKind, buffer []byte) (Token
 			// closing bracket counter-part. See GitHub issue DataDog/datadog-trace-agent#475.
 			return FilteredBracketedIdentifier, nil, nil
 		}
+		if lastToken == As {
+			if token == '(' {
+				// the parenthesis starts the query of a CTE or the definition of
+				// a named window, e.g. WITH [name] AS ( [query] ), it must be kept
+				// to preserve the groups of the query.
+				return token, buffer, nil
+			}
+			if token == ID && isMaterialization(buffer) {
+				// WITH [name] AS [NOT] MATERIALIZED ( [query] )
+				return As, nil, nil
+			}
+			if token == DollarQuotedString {
+				// the body of a PostgreSQL function, e.g. CREATE FUNCTION ... AS $$ [body] $$,
+				// it is not an alias but a literal to obfuscate.
+				return token, buffer, nil
+			}
+		}
 		return Filtered, nil, nil
 	}
 
@@ -86,25 +149,33 @@ func (f *discardFilter) Filter(token, lastToken TokenKind, buffer []byte) (Token
 // Reset implements tokenFilter.
 func (f *discardFilter) Reset() {}
 
+// isMaterialization returns whether the identifier is one of the keywords
+// following AS in the definition of a PostgreSQL CTE.
+func isMaterialization(ident []byte) bool {
+	return bytes.EqualFold(ident, []byte("MATERIALIZED")) || bytes.EqualFold(ident, []byte("NOT"))
+}
+
 // replaceFilter is a token filter which obfuscates strings and numbers in queries by replacing them
-// with the "?" character.
-type replaceFilter struct{}
+// with the replacement token, "?" by default.
+type replaceFilter struct {
+	token []byte
+}
 
 // Filter the given token so that it will be replaced if in the token replacement list
 func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (tokenType TokenKind, tokenBytes []byte, err error) {
 	switch lastToken {
 	case Savepoint:
-		return FilteredGroupable, []byte("?"), nil
+		return FilteredGroupable, f.token, nil
 	case '=':
 		switch token {
 		case DoubleQuotedString:
 			// double-quoted strings after assignments are eligible for obfuscation
-			return FilteredGroupable, []byte("?"), nil
+			return FilteredGroupable, f.token, nil
 		}
 	}
 	switch token {
-	case String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence:
-		return FilteredGroupable, []byte("?"), nil
+	case String, DollarQuotedString, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence:
+		return FilteredGroupable, f.token, nil
 	default:
 		return token, buffer, nil
 	}
@@ -118,6 +189,10 @@ func (f *replaceFilter) Reset() {}
 type groupingFilter struct {
 	groupFilter int
 	groupMulti  int
+	groupDepth  int
+	// keepTuples is true when repeated groups such as '( ? ), ( ? )' must not be
+	// collapsed into a single '( ? )'
+	keepTuples bool
 }
 
 // Filter the given token so that it will be discarded if a grouping pattern
@@ -127,7 +202,7 @@ type groupingFilter struct {
 func (f *groupingFilter) Filter(token, lastToken TokenKind, buffer []byte) (tokenType TokenKind, tokenBytes []byte, err error) {
 	// increasing the number of groups means that we're filtering an entire group
 	// because it can be represented with a single '( ? )'
-	if (lastToken == '(' && token == FilteredGroupable) || (token == '(' && f.groupMulti > 0) {
+	if !f.keepTuples && ((lastToken == '(' && token == FilteredGroupable) || (token == '(' && f.groupMulti > 0)) {
 		f.groupMulti++
 	}
 
@@ -146,8 +221,21 @@ func (f *groupingFilter) Filter(token, lastToken TokenKind, buffer []byte) (toke
 		return FilteredGroupable, nil, nil
 	case f.groupMulti > 1:
 		// drop all tokens since we're in a counting group
-		// and they're duplicated
-		return FilteredGroupable, nil, nil
+		// and they're duplicated, until the last group is closed
+		// e.g. in 'VALUES ( ? ), ( ? ) ON CONFLICT ...'
+		switch token {
+		case '(':
+			f.groupDepth++
+		case ')':
+			f.groupDepth--
+		}
+		if f.groupDepth >= 0 && (f.groupDepth > 0 || token == ',' || token == '(' || token == ')') {
+			return FilteredGroupable, nil, nil
+		}
+		f.Reset()
+	case f.keepTuples && token == ')':
+		// the group is closed and the next one must be kept, e.g. in 'VALUES ( ? ), ( ? )'
+		f.Reset()
 	case token != ',' && token != '(' && token != ')' && token != FilteredGroupable:
 		// when we're out of a group reset the filter state
 		f.Reset()
@@ -160,20 +248,126 @@ func (f *groupingFilter) Filter(token, lastToken TokenKind, buffer []byte) (toke
 func (f *groupingFilter) Reset() {
 	f.groupFilter = 0
 	f.groupMulti = 0
+	f.groupDepth = 0
+}
+
+// valuesCounterFilter is a token filter which counts the tuples of the VALUES clauses, such as
+// the rows of a bulk INSERT ... VALUES ( ? ), ( ? ). It must run before the groupingFilter.
+type valuesCounterFilter struct {
+	// tuples is the number of tuples found
+	tuples int
+	// values is true in a VALUES clause, until a token follows one of its tuples
+	values bool
+	// depth is the number of parentheses opened in the VALUES clause
+	depth int
+}
+
+// Filter implements tokenFilter.
+func (f *valuesCounterFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
+	switch {
+	case token == ID && bytes.EqualFold(buffer, []byte("VALUES")):
+		// but not the function of ON DUPLICATE KEY UPDATE [column] = VALUES ( [column] )
+		f.values = lastToken != '='
+		f.depth = 0
+	case !f.values:
+	case token == '(':
+		if f.depth == 0 {
+			f.tuples++
+		}
+		f.depth++
+	case token == ')':
+		// a negative depth closes an enclosing group, e.g. USING ( VALUES ( ? ) )
+		f.depth--
+		f.values = f.depth >= 0
+	case f.depth == 0 && token != ',':
+		f.values = false
+	}
+	return token, buffer, nil
+}
+
+// Reset implements tokenFilter.
+func (f *valuesCounterFilter) Reset() {
+	f.tuples = 0
+	f.values = false
+	f.depth = 0
+}
+
+var (
+	defaultObfuscator     *Obfuscator
+	defaultObfuscatorOnce sync.Once
+)
+
+// ObfuscateSQLString obfuscates the given SQL query string with the default obfuscation
+// settings, outside of the trace pipeline. The result holds the obfuscated query along
+// with the tables it addresses and the comments it contained.
+func ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
+	defaultObfuscatorOnce.Do(func() {
+		defaultObfuscator = NewObfuscator(nil)
+	})
+	return defaultObfuscator.ObfuscateSQLString(in)
+}
+
+// cachedSQL is the outcome of the obfuscation of a query kept in the SQL cache.
+type cachedSQL struct {
+	oq  ObfuscatedQuery
+	err error
 }
 
 // ObfuscateSQLString quantizes and obfuscates the given input SQL query string. Quantization removes
 // some elements such as comments and aliases and obfuscation attempts to hide sensitive information
-// in strings and numbers by redacting them.
+// in strings and numbers by redacting them. When the SQL cache is enabled, repeated queries are
+// served from it without being tokenized again.
 func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
+	if o.sqlCache == nil {
+		return o.obfuscateSQLString(in)
+	}
+	if v, ok := o.sqlCache.Get(in); ok {
+		atomic.AddUint64(&o.sqlCacheHits, 1)
+		metrics.Count("datadog.trace_agent.obfuscation.sql_cache.hits", 1, nil, 1)
+		cached := v.(*cachedSQL)
+		if cached.err != nil {
+			return nil, cached.err
+		}
+		oq := cached.oq
+		return &oq, nil
+	}
+	atomic.AddUint64(&o.sqlCacheMisses, 1)
+	metrics.Count("datadog.trace_agent.obfuscation.sql_cache.misses", 1, nil, 1)
+
+	oq, err := o.obfuscateSQLString(in)
+	switch {
+	case err == errBudgetExceeded:
+		// the budget depends on the load, the query may be obfuscated next time
+	case err != nil:
+		o.sqlCache.Add(in, &cachedSQL{err: err})
+	default:
+		o.sqlCache.Add(in, &cachedSQL{oq: *oq})
+	}
+	return oq, err
+}
+
+// SQLCacheStats returns the number of hits and misses of the SQL cache.
+func (o *Obfuscator) SQLCacheStats() (hits, misses uint64) {
+	return atomic.LoadUint64(&o.sqlCacheHits), atomic.LoadUint64(&o.sqlCacheMisses)
+}
+
+// obfuscateSQLString obfuscates the query, without the SQL cache.
+func (o *Obfuscator) obfuscateSQLString(in string) (*ObfuscatedQuery, error) {
+	if len(in) > o.sqlBudget.maxBytes {
+		return nil, errBudgetExceeded
+	}
+	deadline := time.Now().Add(o.sqlBudget.maxDuration)
+	if o.sqlDialect.knownEscapes {
+		return attemptObfuscation(o.newSQLTokenizer(in, o.sqlDialect.literalEscapes), deadline)
+	}
 	lesc := o.SQLLiteralEscapes()
-	tok := NewSQLTokenizer(in, lesc)
-	out, err := attemptObfuscation(tok)
+	tok := o.newSQLTokenizer(in, lesc)
+	out, err := attemptObfuscation(tok, deadline)
 	if err != nil && tok.SeenEscape() {
 		// If the tokenizer failed, but saw an escape character in the process,
 		// try again treating escapes differently
-		tok = NewSQLTokenizer(in, !lesc)
-		if out, err2 := attemptObfuscation(tok); err2 == nil {
+		tok = o.newSQLTokenizer(in, !lesc)
+		if out, err2 := attemptObfuscation(tok, deadline); err2 == nil {
 			// If the second attempt succeeded, change the default behavior so that
 			// on the next run we get it right in the first run.
 			o.SetSQLLiteralEscapes(!lesc)
@@ -183,33 +377,74 @@ func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
 	return out, err
 }
 
+// newSQLTokenizer returns a tokenizer for the query, configured with the obfuscation settings.
+func (o *Obfuscator) newSQLTokenizer(in string, literalEscapes bool) *SQLTokenizer {
+	tok := NewSQLTokenizer(in, literalEscapes)
+	tok.normalizeQuotedIdentifiers = o.opts.SQL.NormalizeQuotedIdentifiers
+	tok.dialect = o.sqlDialect
+	tok.replacementToken = replacementToken(o.opts)
+	tok.keepValuesTuples = o.opts.SQL.KeepValuesTuples
+	tok.tableNames = o.opts.SQL.TableNames
+	return tok
+}
+
 // tableFinderFilter is a filter which attempts to identify the table name as it goes through each
 // token in a query.
 type tableFinderFilter struct {
 	// seen keeps track of unique table names encountered by the filter.
 	seen map[string]struct{}
+	// names lists the table names in the order they were found
+	names []string
 	// csv specifies a comma-separated list of tables
 	csv strings.Builder
+	// merge is true after a MERGE statement, until its source is found
+	merge bool
+	// key is true after a KEY identifier, as in ON DUPLICATE KEY UPDATE
+	key bool
+	// upsert is true after the UPDATE of an ON DUPLICATE KEY UPDATE clause
+	upsert bool
 }
 
 // Filter implements tokenFilter.
 func (f *tableFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
 	switch lastToken {
+	case Merge:
+		f.merge = true
+	case Using:
+		if !f.merge {
+			// ... JOIN [tableName] USING ( [column] )
+			break
+		}
+		f.merge = false
+		fallthrough
 	case From:
 		// SELECT ... FROM [tableName]
 		// DELETE FROM [tableName]
+		// MERGE INTO [tableName] USING [tableName]
 		if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) {
 			// first character in buffer is not a letter; we might have a nested
 			// query like SELECT * FROM (SELECT ...)
 			break
 		}
 		fallthrough
-	case Update, Into, Join:
-		// UPDATE [tableName]
+	case Into, Join:
 		// INSERT INTO [tableName]
 		// ... JOIN [tableName]
 		f.storeName(string(buffer))
+	case Update:
+		// UPDATE [tableName]
+		// but not the UPDATE of MERGE and upsert statements:
+		// ... WHEN MATCHED THEN UPDATE SET ...
+		// ... ON CONFLICT ( ... ) DO UPDATE SET ...
+		// ... ON DUPLICATE KEY UPDATE [column] = ...
+		if !f.upsert && !bytes.EqualFold(buffer, []byte("SET")) {
+			f.storeName(string(buffer))
+		}
+	}
+	if token == Update {
+		f.upsert = f.key
 	}
+	f.key = token == ID && bytes.EqualFold(buffer, []byte("KEY"))
 	return token, buffer, nil
 }
 
@@ -222,6 +457,7 @@ func (f *tableFinderFilter) storeName(name string) {
 		f.seen = make(map[string]struct{}, 1)
 	}
 	f.seen[name] = struct{}{}
+	f.names = append(f.names, name)
 	if f.csv.Len() > 0 {
 		f.csv.WriteByte(',')
 	}
@@ -236,31 +472,106 @@ func (f *tableFinderFilter) Reset() {
 	for k := range f.seen {
 		delete(f.seen, k)
 	}
+	f.names = nil
 	f.csv.Reset()
+	f.merge = false
+	f.key = false
+	f.upsert = false
+}
+
+// procedureFinderFilter is a filter which identifies the names of the stored procedures
+// called by statements such as EXEC [name] @a = ?, CALL [name] ( ? ) or the ODBC and JDBC
+// escape {call [name] ( ? )}. Their arguments are obfuscated by the other filters.
+type procedureFinderFilter struct {
+	// names lists the procedure names in the order they were found
+	names []string
+	// call is true after the EXEC, EXECUTE or CALL keyword starting a statement
+	call bool
+	// returnVar is true after the variable receiving the return status of the
+	// procedure, as in EXEC @ret = [name]
+	returnVar bool
+}
+
+// Filter implements tokenFilter.
+func (f *procedureFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
+	switch {
+	case f.returnVar:
+		f.returnVar = false
+		f.call = token == '='
+	case f.call:
+		f.call = false
+		if token != ID {
+			// e.g. EXEC ( [query] )
+			break
+		}
+		if buffer[0] == '@' {
+			f.returnVar = true
+			break
+		}
+		f.names = append(f.names, string(buffer))
+	case token == ID && isProcedureCall(buffer):
+		// the keyword must start the statement, unlike in GRANT EXECUTE ON ...
+		f.call = lastToken == 0 || lastToken == FilteredGroupable || lastToken == '='
+	}
+	return token, buffer, nil
+}
+
+// isProcedureCall returns whether the identifier is one of the keywords calling a
+// stored procedure.
+func isProcedureCall(ident []byte) bool {
+	return bytes.EqualFold(ident, []byte("EXEC")) ||
+		bytes.EqualFold(ident, []byte("EXECUTE")) ||
+		bytes.EqualFold(ident, []byte("CALL"))
+}
+
+// Reset implements tokenFilter.
+func (f *procedureFinderFilter) Reset() {
+	f.names = nil
+	f.call = false
+	f.returnVar = false
 }
 
 // ObfuscatedQuery specifies information about an obfuscated SQL query.
 type ObfuscatedQuery struct {
-	Query     string // the obfuscated SQL query
-	TablesCSV string // comma-separated list of tables that the query addresses
+	Query     string      // the obfuscated SQL query
+	TablesCSV string      // comma-separated list of tables that the query addresses, only set with the "table_names" option or feature
+	Metadata  SQLMetadata // information collected while obfuscating the query
+}
+
+// SQLMetadata holds the information collected while obfuscating a SQL query.
+type SQLMetadata struct {
+	// Tables lists the tables that the query addresses, in the order they appear.
+	Tables []string
+	// Comments lists the comments removed from the query, including their delimiters.
+	Comments []string
+	// Procedures lists the stored procedures that the query calls, in the order they appear.
+	Procedures []string
+	// ValuesTuples is the number of tuples in the VALUES clauses of the query, such as the
+	// rows of a bulk insert.
+	ValuesTuples int
 }
 
 // attemptObfuscation attempts to obfuscate the SQL query loaded into the tokenizer, using the
-// given set of filters.
-func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
+// given set of filters, configured by the tokenizer. It fails with errBudgetExceeded when still
+// running after the deadline.
+func attemptObfuscation(tokenizer *SQLTokenizer, deadline time.Time) (*ObfuscatedQuery, error) {
+	tableFinder := &tableFinderFilter{}
+	procedureFinder := &procedureFinderFilter{}
+	valuesCounter := &valuesCounterFilter{}
 	filters := []tokenFilter{
 		&discardFilter{},
-		&replaceFilter{},
-		&groupingFilter{},
-	}
-	tableFinder := &tableFinderFilter{}
-	if config.HasFeature("table_names") {
-		filters = append(filters, tableFinder)
+		&replaceFilter{token: []byte(tokenizer.replacementToken)},
+		valuesCounter,
+		&groupingFilter{keepTuples: tokenizer.keepValuesTuples},
+		tableFinder,
+		procedureFinder,
 	}
 	var (
 		out       bytes.Buffer
 		err       error
 		lastToken TokenKind
+		count     int
+		comments  []string
 	)
 	// call Scan() function until tokens are available or if a LEX_ERROR is raised. After
 	// retrieving a token, send it to the tokenFilter chains so that the token is discarded
@@ -273,6 +584,13 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 		if token == LexError {
 			return nil, fmt.Errorf("%v", tokenizer.Err())
 		}
+		if count++; count%budgetCheckInterval == 0 && time.Now().After(deadline) {
+			return nil, errBudgetExceeded
+		}
+		if token == Comment {
+			// single line comments end with their line break
+			comments = append(comments, strings.TrimSpace(string(buff)))
+		}
 		for _, f := range filters {
 			if token, buff, err = f.Filter(token, lastToken, buff); err != nil {
 				return nil, err
@@ -300,10 +618,19 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 	if out.Len() == 0 {
 		return nil, errors.New("result is empty")
 	}
-	return &ObfuscatedQuery{
-		Query:     out.String(),
-		TablesCSV: tableFinder.CSV(),
-	}, nil
+	oq := &ObfuscatedQuery{
+		Query: out.String(),
+		Metadata: SQLMetadata{
+			Tables:       tableFinder.names,
+			Comments:     comments,
+			Procedures:   procedureFinder.names,
+			ValuesTuples: valuesCounter.tuples,
+		},
+	}
+	if tokenizer.tableNames || config.HasFeature("table_names") {
+		oq.TablesCSV = tableFinder.CSV()
+	}
+	return oq, nil
 }
 
 func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
@@ -316,6 +643,13 @@ func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
 		return
 	}
 	oq, err := o.ObfuscateSQLString(span.Resource)
+	if err == errBudgetExceeded {
+		log.Debugf("Error obfuscating SQL query: %v. Resource length: %d", err, len(span.Resource))
+		span.Resource = budgetExceededResource
+		tags = append(tags, "outcome:budget-exceeded")
+		obfuscationFailures.Add("sql.budget-exceeded", 1)
+		return
+	}
 	if err != nil {
 		// we have an error, discard the SQL to avoid polluting user resources.
 		log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
@@ -327,6 +661,7 @@ func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
 		}
 		span.Resource = nonParsableResource
 		tags = append(tags, "outcome:error")
+		obfuscationFailures.Add("sql.error", 1)
 		return
 	}
 
@@ -336,9 +671,76 @@ func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
 	if len(oq.TablesCSV) > 0 {
 		traceutil.SetMeta(span, "sql.tables", oq.TablesCSV)
 	}
+	if o.opts.SQL.ExtractComments {
+		setSQLCommentTags(span, oq.Metadata.Comments)
+	}
+	if !o.opts.SQL.KeepValuesTuples && oq.Metadata.ValuesTuples > 1 {
+		// the tuples were collapsed into a single '( ? )'
+		traceutil.SetMeta(span, sqlValuesCountTag, strconv.Itoa(oq.Metadata.ValuesTuples))
+	}
 	if span.Meta != nil && span.Meta[sqlQueryTag] != "" {
 		// "sql.query" tag already set by user, do not change it.
 		return
 	}
 	traceutil.SetMeta(span, sqlQueryTag, oq.Query)
 }
+
+// sqlStatementKeywords are the keywords starting the statements recognized as SQL in the
+// "db.statement" tag of the spans without type.
+var sqlStatementKeywords = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "UPSERT": true, "REPLACE": true,
+	"MERGE": true, "WITH": true, "CALL": true, "EXEC": true, "EXECUTE": true, "CREATE": true,
+	"ALTER": true, "DROP": true, "TRUNCATE": true, "GRANT": true, "REVOKE": true,
+}
+
+// looksLikeSQL returns whether the statement starts with a SQL keyword followed by a space or a
+// parenthesis, after the leading whitespace, parentheses and comments.
+func looksLikeSQL(s string) bool {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(s, "/*"):
+			i := strings.Index(s, "*/")
+			if i < 0 {
+				return false
+			}
+			s = s[i+2:]
+		case strings.HasPrefix(s, "--"):
+			i := strings.IndexByte(s, '\n')
+			if i < 0 {
+				return false
+			}
+			s = s[i+1:]
+		default:
+			i := strings.IndexAny(s, " \t\r\n(")
+			return i > 0 && sqlStatementKeywords[strings.ToUpper(s[:i])]
+		}
+	}
+}
+
+// obfuscateDBStatement obfuscates the "db.statement" tag of a span without type when it looks
+// like a SQL query. The statements which can't be obfuscated are replaced, not to leak them.
+func (o *Obfuscator) obfuscateDBStatement(span *pb.Span) {
+	if span.Meta == nil || !looksLikeSQL(span.Meta[dbStatementTag]) {
+		return
+	}
+	tags := []string{"type:" + dbStatementTag}
+	defer func() {
+		metrics.Count("datadog.trace_agent.obfuscations", 1, tags, 1)
+	}()
+	oq, err := o.ObfuscateSQLString(span.Meta[dbStatementTag])
+	switch {
+	case err == errBudgetExceeded:
+		span.Meta[dbStatementTag] = budgetExceededResource
+		tags = append(tags, "outcome:budget-exceeded")
+		obfuscationFailures.Add(dbStatementTag+".budget-exceeded", 1)
+	case err != nil:
+		log.Debugf("Error parsing SQL query in %s: %v", dbStatementTag, err)
+		span.Meta[dbStatementTag] = nonParsableResource
+		tags = append(tags, "outcome:error")
+		obfuscationFailures.Add(dbStatementTag+".error", 1)
+	default:
+		span.Meta[dbStatementTag] = oq.Query
+		tags = append(tags, "outcome:success")
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/stretchr/testify/assert"
 )
@@ -66,6 +69,16 @@ func TestSQLTableNames(t *testing.T) {
 
 	})
 
+	t.Run("option", func(t *testing.T) {
+		span := &pb.Span{
+			Resource: "SELECT * FROM users u JOIN orders o ON u.id = o.user_id WHERE u.id = 42",
+			Type:     "sql",
+		}
+		cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{TableNames: true}}
+		NewObfuscator(cfg).Obfuscate(span)
+		assert.Equal(t, "users,orders", span.Meta["sql.tables"])
+	})
+
 	t.Run("off", func(t *testing.T) {
 		span := &pb.Span{
 			Resource: "SELECT * FROM users WHERE id = 42",
@@ -76,6 +89,245 @@ func TestSQLTableNames(t *testing.T) {
 	})
 }
 
+func TestSQLNormalizeQuotedIdentifiers(t *testing.T) {
+	os.Setenv("DD_APM_FEATURES", "table_names")
+	defer os.Unsetenv("DD_APM_FEATURES")
+
+	for _, tt := range []struct {
+		normalize bool
+		query     string
+		expected  string
+		tables    string
+	}{
+		{true, `SELECT * FROM "Users" WHERE id = 42`, "SELECT * FROM users WHERE id = ?", "users"},
+		{true, "SELECT * FROM `Users` WHERE id = 42", "SELECT * FROM users WHERE id = ?", "users"},
+		{true, "SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?", "users"},
+		{true, `SELECT "Name" FROM "Users" WHERE "Name" = "John"`, "SELECT name FROM users WHERE name = ?", "users"},
+		{false, `SELECT * FROM "Users" WHERE id = 42`, "SELECT * FROM Users WHERE id = ?", "Users"},
+		{false, "SELECT * FROM `Users` WHERE id = 42", "SELECT * FROM Users WHERE id = ?", "Users"},
+	} {
+		t.Run("", func(t *testing.T) {
+			cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{NormalizeQuotedIdentifiers: tt.normalize}}
+			span := &pb.Span{Resource: tt.query, Type: "sql"}
+			NewObfuscator(cfg).Obfuscate(span)
+			assert.Equal(t, tt.expected, span.Resource)
+			assert.Equal(t, tt.tables, span.Meta["sql.tables"])
+		})
+	}
+}
+
+func TestSQLDialect(t *testing.T) {
+	for _, tt := range []struct {
+		dialect  string
+		query    string
+		expected string
+	}{
+		{"", `SELECT * FROM users WHERE name = 'O\'Reilly'`, "SELECT * FROM users WHERE name = ?"},
+		{"", `SELECT * FROM users WHERE path = 'C:\' AND id = 1`, "SELECT * FROM users WHERE path = ? AND id = ?"},
+		{"", "SELECT * FROM users # comment", "SELECT * FROM users"},
+		{"", "SELECT * FROM users WHERE name = N'Zoë' AND id = 1", "SELECT * FROM users WHERE name = ? AND id = ?"},
+		{"postgres", `SELECT * FROM users WHERE path = 'C:\' AND id = 1`, "SELECT * FROM users WHERE path = ? AND id = ?"},
+		{"postgres", `SELECT * FROM users WHERE name = E'O\'Reilly' AND id = 1`, "SELECT * FROM users WHERE name = ? AND id = ?"},
+		{"postgres", "SELECT * FROM users WHERE flags # 4 = 0", "SELECT * FROM users WHERE flags # ? = ?"},
+		{"postgres", "SELECT * /* outer /* inner */ still a comment */ FROM users", "SELECT * FROM users"},
+		{"postgres", "SELECT /*! STRAIGHT_JOIN */ * FROM users", "SELECT * FROM users"},
+		{"mysql", `SELECT * FROM users WHERE name = "O\"Reilly" AND id = 1`, "SELECT * FROM users WHERE name = ? AND id = ?"},
+		{"mysql", `SELECT * FROM users WHERE name = 'O\'Reilly'`, "SELECT * FROM users WHERE name = ?"},
+		{"mysql", "SELECT /*! STRAIGHT_JOIN */ * FROM users # comment", "SELECT STRAIGHT_JOIN * FROM users"},
+		{"mssql", "SELECT * FROM #temp JOIN ##global ON #temp.id = ##global.id", "SELECT * FROM #temp JOIN ##global ON #temp.id = ##global.id"},
+		{"mssql", `SELECT * FROM users WHERE path = 'C:\' AND id = 1`, "SELECT * FROM users WHERE path = ? AND id = ?"},
+		{"mssql", "SELECT [u].[Name] FROM [dbo].[My Table] AS [u] WHERE [u].[Order Date] > N'2020-01-01'", "SELECT [u].[Name] FROM [dbo].[My Table] WHERE [u].[Order Date] > ?"},
+		{"mssql", "SELECT * FROM dbo.[Users] JOIN [dbo].Orders ON [Users].[Id]] Ref] = Orders.UserId", "SELECT * FROM dbo.[Users] JOIN [dbo].Orders ON [Users].[Id]] Ref] = Orders.UserId"},
+		{"mssql", "INSERT INTO [Users] ([Name], [2nd-Email]) VALUES (N'Zoë', n'zoe@example.com')", "INSERT INTO [Users] ( [Name], [2nd-Email] ) VALUES ( ? )"},
+		{"oracle", `SELECT * FROM users WHERE path = 'C:\' AND id = 1`, "SELECT * FROM users WHERE path = ? AND id = ?"},
+		{"snowflake", `SELECT * FROM users WHERE name = 'O\'Reilly' AND id = 1`, "SELECT * FROM users WHERE name = ? AND id = ?"},
+		{"unknown", "SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?"},
+	} {
+		t.Run(tt.dialect, func(t *testing.T) {
+			cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{Dialect: tt.dialect}}
+			oq, err := NewObfuscator(cfg).ObfuscateSQLString(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, oq.Query)
+		})
+	}
+}
+
+func TestSQLProcedureCalls(t *testing.T) {
+	for _, tt := range []struct {
+		dialect   string
+		query     string
+		expected  string
+		procedure string
+	}{
+		{"", "EXEC my_proc @a=1, @b='x'", "EXEC my_proc @a = ? @b = ?", "my_proc"},
+		{"", "EXEC my_proc 1, 'x'", "EXEC my_proc ?", "my_proc"},
+		{"", "EXECUTE dbo.my_proc @a = 1, @b = N'x', @out = @result OUTPUT", "EXECUTE dbo.my_proc @a = ? @b = ? @out = @result OUTPUT", "dbo.my_proc"},
+		{"", "EXEC @ret = my_proc @a = 1", "EXEC @ret = my_proc @a = ?", "my_proc"},
+		{"", "EXEC sp_executesql N'SELECT * FROM users WHERE id = @id', N'@id int', @id = 1", "EXEC sp_executesql ? @id = ?", "sp_executesql"},
+		{"", "CALL my_proc(1,'x')", "CALL my_proc ( ? )", "my_proc"},
+		{"", "call app.my_proc(1, 'x', @out)", "call app.my_proc ( ? @out )", "app.my_proc"},
+		{"", "{call my_proc(?, ?)}", "call my_proc ( ?, ? )", "my_proc"},
+		{"", "{ ? = CALL my_proc(42) }", "? = CALL my_proc ( ? )", "my_proc"},
+		{"", "{call my_proc('x')} ", "call my_proc ( ? )", "my_proc"},
+		{"", "EXEC('SELECT * FROM users')", "EXEC ( ? )", ""},
+		{"", "GRANT EXECUTE ON my_proc TO reporting", "GRANT EXECUTE ON my_proc TO reporting", ""},
+		{"", "SELECT {fn UCASE(name)} FROM users", "SELECT ? FROM users", ""},
+		{"mssql", "EXEC [dbo].[my_proc] @a=1, @b=N'x'", "EXEC [dbo].[my_proc] @a = ? @b = ?", "[dbo].[my_proc]"},
+	} {
+		t.Run("", func(t *testing.T) {
+			cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{Dialect: tt.dialect}}
+			oq, err := NewObfuscator(cfg).ObfuscateSQLString(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, oq.Query)
+			if tt.procedure == "" {
+				assert.Empty(t, oq.Metadata.Procedures)
+			} else {
+				assert.Equal(t, []string{tt.procedure}, oq.Metadata.Procedures)
+			}
+		})
+	}
+
+	_, err := ObfuscateSQLString("{call my_proc(1)")
+	assert.Error(t, err)
+}
+
+func TestSQLValuesTuples(t *testing.T) {
+	for _, tt := range []struct {
+		query     string
+		collapsed string
+		kept      string
+		tuples    int
+	}{
+		{
+			"INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c')",
+			"INSERT INTO users ( id, name ) VALUES ( ? )",
+			"INSERT INTO users ( id, name ) VALUES ( ? ), ( ? ), ( ? )",
+			3,
+		},
+		{
+			"INSERT INTO users (id) VALUES (1)",
+			"INSERT INTO users ( id ) VALUES ( ? )",
+			"INSERT INTO users ( id ) VALUES ( ? )",
+			1,
+		},
+		{
+			"INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b') ON DUPLICATE KEY UPDATE name = VALUES(name)",
+			"INSERT INTO users ( id, name ) VALUES ( ? ) ON DUPLICATE KEY UPDATE name = VALUES ( name )",
+			"INSERT INTO users ( id, name ) VALUES ( ? ), ( ? ) ON DUPLICATE KEY UPDATE name = VALUES ( name )",
+			2,
+		},
+		{
+			"SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t (id, name)",
+			"SELECT * FROM ( VALUES ( ? ) ) ( id, name )",
+			"SELECT * FROM ( VALUES ( ? ), ( ? ) ) ( id, name )",
+			2,
+		},
+		{
+			"SELECT * FROM users WHERE id IN (1, 2, 3)",
+			"SELECT * FROM users WHERE id IN ( ? )",
+			"SELECT * FROM users WHERE id IN ( ? )",
+			0,
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			oq, err := NewObfuscator(nil).ObfuscateSQLString(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.collapsed, oq.Query)
+			assert.Equal(t, tt.tuples, oq.Metadata.ValuesTuples)
+
+			cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{KeepValuesTuples: true}}
+			oq, err = NewObfuscator(cfg).ObfuscateSQLString(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.kept, oq.Query)
+			assert.Equal(t, tt.tuples, oq.Metadata.ValuesTuples)
+		})
+	}
+
+	t.Run("tag", func(t *testing.T) {
+		query := "INSERT INTO users (id) VALUES " + strings.TrimSuffix(strings.Repeat("(1), ", 1000), ", ")
+		span := &pb.Span{Resource: query, Type: "sql"}
+		NewObfuscator(nil).Obfuscate(span)
+		assert.Equal(t, "INSERT INTO users ( id ) VALUES ( ? )", span.Resource)
+		assert.Equal(t, "1000", span.Meta[sqlValuesCountTag])
+
+		span = &pb.Span{Resource: "INSERT INTO users (id) VALUES (1)", Type: "sql"}
+		NewObfuscator(nil).Obfuscate(span)
+		assert.NotContains(t, span.Meta, sqlValuesCountTag)
+
+		span = &pb.Span{Resource: "INSERT INTO users (id) VALUES (1), (2)", Type: "sql"}
+		cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{KeepValuesTuples: true}}
+		NewObfuscator(cfg).Obfuscate(span)
+		assert.Equal(t, "INSERT INTO users ( id ) VALUES ( ? ), ( ? )", span.Resource)
+		assert.NotContains(t, span.Meta, sqlValuesCountTag)
+	})
+}
+
+func TestLooksLikeSQL(t *testing.T) {
+	for in, ok := range map[string]bool{
+		"SELECT * FROM users":                        true,
+		"  select id from users":                     true,
+		"(SELECT 1) UNION (SELECT 2)":                true,
+		"/* app=web */ UPDATE users SET name = 'x'":  true,
+		"-- comment\nDELETE FROM users WHERE id = 1": true,
+		"WITH t AS (SELECT 1) SELECT * FROM t":       true,
+		"EXEC(my_proc)":                              true,
+		"":                                           false,
+		"SELECT":                                     false,
+		"GET /users/1":                               false,
+		"selection of users":                         false,
+		"/* unterminated SELECT 1":                   false,
+		`{"find": "users"}`:                          false,
+	} {
+		assert.Equal(t, ok, looksLikeSQL(in), in)
+	}
+}
+
+func TestObfuscateDBStatement(t *testing.T) {
+	cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{ObfuscateDBStatement: true}}
+	for _, tt := range []struct {
+		typ, in, out string
+		cfg          *config.ObfuscationConfig
+	}{
+		{"", "SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?", cfg},
+		{"", "SELECT * FROM users WHERE name = 'unterminated", nonParsableResource, cfg},
+		{"", "GET /users/42", "GET /users/42", cfg},
+		{"", "SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = 42", nil},
+		{"custom", "SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = 42", cfg},
+	} {
+		t.Run("", func(t *testing.T) {
+			span := &pb.Span{Type: tt.typ, Resource: "query", Meta: map[string]string{dbStatementTag: tt.in}}
+			NewObfuscator(tt.cfg).Obfuscate(span)
+			assert.Equal(t, tt.out, span.Meta[dbStatementTag])
+			assert.Equal(t, "query", span.Resource)
+		})
+	}
+
+	NewObfuscator(cfg).Obfuscate(&pb.Span{Resource: "query"}) // no meta
+}
+
+func TestSQLBudget(t *testing.T) {
+	query := "SELECT * FROM users WHERE id IN (" + strings.Repeat("1, ", 1000) + "1)"
+
+	t.Run("bytes", func(t *testing.T) {
+		span := &pb.Span{Resource: query, Type: "sql"}
+		NewObfuscator(&config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{MaxBytes: 1000}}).Obfuscate(span)
+		assert.Equal(t, budgetExceededResource, span.Resource)
+		assert.Empty(t, span.Meta[sqlQueryTag])
+
+		span = &pb.Span{Resource: query, Type: "sql"}
+		NewObfuscator(nil).Obfuscate(span)
+		assert.Equal(t, "SELECT * FROM users WHERE id IN ( ? )", span.Resource)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		_, err := attemptObfuscation(NewSQLTokenizer(query, false), time.Now().Add(-time.Second))
+		assert.Equal(t, errBudgetExceeded, err)
+
+		_, err = attemptObfuscation(NewSQLTokenizer("SELECT 1", false), time.Now().Add(-time.Second))
+		assert.NoError(t, err)
+	})
+}
+
 func TestSQLResourceWithoutQuery(t *testing.T) {
 	assert := assert.New(t)
 	span := &pb.Span{
@@ -210,6 +462,26 @@ func TestSQLTableFinder(t *testing.T) {
 				"DELETE FROM table WHERE table.a=1",
 				"table",
 			},
+			{
+				"MERGE INTO target t USING source s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.name = 'foo' WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)",
+				"target,source",
+			},
+			{
+				"MERGE INTO target USING (SELECT id FROM source) s ON target.id = s.id WHEN MATCHED THEN DELETE",
+				"target,source",
+			},
+			{
+				"INSERT INTO users (id, name) VALUES (1, 'john') ON CONFLICT (id) DO UPDATE SET name = 'john'",
+				"users",
+			},
+			{
+				"INSERT INTO users (id, name) VALUES (1, 'john') ON DUPLICATE KEY UPDATE name = 'john'",
+				"users",
+			},
+			{
+				"SELECT * FROM a JOIN b USING (id) WHERE a.x = 1",
+				"a,b",
+			},
 		} {
 			t.Run("", func(t *testing.T) {
 				assert := assert.New(t)
@@ -227,6 +499,54 @@ func TestSQLTableFinder(t *testing.T) {
 	})
 }
 
+func TestObfuscateSQLStringMetadata(t *testing.T) {
+	for _, tt := range []struct {
+		query      string
+		out        string
+		tables     []string
+		comments   []string
+		procedures []string
+	}{
+		{
+			query:  "SELECT * FROM users JOIN orders ON users.id = orders.user_id WHERE users.id = 42",
+			out:    "SELECT * FROM users JOIN orders ON users.id = orders.user_id WHERE users.id = ?",
+			tables: []string{"users", "orders"},
+		},
+		{
+			query:    "-- get the user\nSELECT name /* columns */ FROM users WHERE id = 42",
+			out:      "SELECT name FROM users WHERE id = ?",
+			tables:   []string{"users"},
+			comments: []string{"-- get the user", "/* columns */"},
+		},
+		{
+			query:      "/* sync */ EXEC dbo.sync_user @id = 42; CALL audit(42, 'sync')",
+			out:        "EXEC dbo.sync_user @id = ? CALL audit ( ? )",
+			comments:   []string{"/* sync */"},
+			procedures: []string{"dbo.sync_user", "audit"},
+		},
+		{
+			query: "SELECT 1",
+			out:   "SELECT ?",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			oq, err := ObfuscateSQLString(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, oq.Query)
+			assert.Equal(t, tt.tables, oq.Metadata.Tables)
+			assert.Equal(t, tt.comments, oq.Metadata.Comments)
+			assert.Equal(t, tt.procedures, oq.Metadata.Procedures)
+			// the tables are only listed in TablesCSV with the "table_names" feature
+			assert.Empty(t, oq.TablesCSV)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := ObfuscateSQLString("SELECT * FROM users WHERE name = 'unterminated")
+		assert.Error(t, err)
+	})
+}
+
 func TestSQLQuantizer(t *testing.T) {
 	cases := []sqlTestCase{
 		{
@@ -418,7 +738,23 @@ func TestSQLQuantizer(t *testing.T) {
 		},
 		{
 			"SELECT /*! STRAIGHT_JOIN */ col1 FROM table1",
-			"SELECT col1 FROM table1",
+			"SELECT STRAIGHT_JOIN col1 FROM table1",
+		},
+		{
+			"/*!40101 SET NAMES utf8 */",
+			"SET NAMES utf8",
+		},
+		{
+			"SELECT /*M!100101 SQL_NO_CACHE */ col1 FROM table1 /*!50100 WHERE id = 42*/",
+			"SELECT SQL_NO_CACHE col1 FROM table1 WHERE id = ?",
+		},
+		{
+			"SELECT * FROM users WHERE id = ?1 AND name = ?23",
+			"SELECT * FROM users WHERE id = ? AND name = ?",
+		},
+		{
+			"SELECT * FROM users WHERE id IN (?1, ?2) AND org = :org AND team = @team",
+			"SELECT * FROM users WHERE id IN ( ?, ? ) AND org = :org AND team = @team",
 		},
 		{
 			`DELETE FROM t1
@@ -538,6 +874,118 @@ ORDER BY [b].[Name]`,
 			`SELECT * FROM foo LEFT JOIN bar ON 'embedded \'quote\' in string' = foo.b WHERE foo.name = 'String'`,
 			"SELECT * FROM foo LEFT JOIN bar ON ? = foo.b WHERE foo.name = ?",
 		},
+		{
+			"MERGE INTO target t USING source s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.name = 'foo', t.count = 3 WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, 'bar')",
+			"MERGE INTO target t USING source s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.name = ? t.count = ? WHEN NOT MATCHED THEN INSERT ( id, name ) VALUES ( s.id, ? )",
+		},
+		{
+			"MERGE INTO target t USING (SELECT 1 AS id, 'x' AS name) s ON (t.id = s.id) WHEN MATCHED AND t.count > 10 THEN DELETE WHEN NOT MATCHED THEN INSERT VALUES (1, 'foo')",
+			"MERGE INTO target t USING ( SELECT ?, ? ) s ON ( t.id = s.id ) WHEN MATCHED AND t.count > ? THEN DELETE WHEN NOT MATCHED THEN INSERT VALUES ( ? )",
+		},
+		{
+			"MERGE INTO target USING (VALUES (1, 'a'), (2, 'b')) AS s (id, name) ON target.id = s.id WHEN MATCHED THEN UPDATE SET name = 'c'",
+			"MERGE INTO target USING ( VALUES ( ? ) ) ( id, name ) ON target.id = s.id WHEN MATCHED THEN UPDATE SET name = ?",
+		},
+		{
+			"INSERT INTO users (id, name) VALUES (1, 'john') ON CONFLICT (id) DO UPDATE SET name = 'john', visits = users.visits + 1",
+			"INSERT INTO users ( id, name ) VALUES ( ? ) ON CONFLICT ( id ) DO UPDATE SET name = ? visits = users.visits + ?",
+		},
+		{
+			"INSERT INTO users (id, name) VALUES (1, 'john'), (2, 'jane') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name WHERE users.age > 30",
+			"INSERT INTO users ( id, name ) VALUES ( ? ) ON CONFLICT ( id ) DO UPDATE SET name = EXCLUDED.name WHERE users.age > ?",
+		},
+		{
+			"INSERT INTO users (id, name) VALUES (1, 'john'), (2, 'jane') ON DUPLICATE KEY UPDATE name = 'john', visits = visits + 1",
+			"INSERT INTO users ( id, name ) VALUES ( ? ) ON DUPLICATE KEY UPDATE name = ? visits = visits + ?",
+		},
+		{
+			"UPSERT INTO users (id, name) VALUES (1, 'john'), (2, 'jane')",
+			"UPSERT INTO users ( id, name ) VALUES ( ? )",
+		},
+		{
+			"SELECT * FROM a WHERE (x, y) IN ((1, 2), (3, 4)) AND z = 'c'",
+			"SELECT * FROM a WHERE ( x, y ) IN ( ( ? ) ) AND z = ?",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			s := SQLSpan(c.query)
+			NewObfuscator(nil).Obfuscate(s)
+			assert.Equal(t, c.expected, s.Resource)
+		})
+	}
+}
+
+// TestSQLAnalyticalQueries is a regression corpus of real-world analytical
+// queries, using CTEs and window functions.
+func TestSQLAnalyticalQueries(t *testing.T) {
+	cases := []sqlTestCase{
+		{
+			`WITH sales AS (SELECT region, SUM(amount) AS total FROM orders WHERE created_at > '2020-01-01' GROUP BY region), top AS (SELECT region FROM sales WHERE total > 1000) SELECT * FROM top`,
+			`WITH sales ( SELECT region, SUM ( amount ) FROM orders WHERE created_at > ? GROUP BY region ), top ( SELECT region FROM sales WHERE total > ? ) SELECT * FROM top`,
+		},
+		{
+			`WITH RECURSIVE tree (id, parent_id, depth) AS (SELECT id, parent_id, 0 FROM nodes WHERE parent_id IS NULL UNION ALL SELECT n.id, n.parent_id, t.depth + 1 FROM nodes n JOIN tree t ON n.parent_id = t.id) SELECT * FROM tree`,
+			`WITH RECURSIVE tree ( id, parent_id, depth ) ( SELECT id, parent_id, ? FROM nodes WHERE parent_id IS ? UNION ALL SELECT n.id, n.parent_id, t.depth + ? FROM nodes n JOIN tree t ON n.parent_id = t.id ) SELECT * FROM tree`,
+		},
+		{
+			`WITH a AS MATERIALIZED (SELECT 1), b AS NOT MATERIALIZED (SELECT * FROM a) SELECT * FROM b`,
+			`WITH a ( SELECT ? ) b ( SELECT * FROM a ) SELECT * FROM b`,
+		},
+		{
+			`SELECT x FROM t WHERE y IN (WITH z AS (SELECT 1) SELECT * FROM z)`,
+			`SELECT x FROM t WHERE y IN ( WITH z ( SELECT ? ) SELECT * FROM z )`,
+		},
+		{
+			`SELECT user_id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY ts DESC) AS rn FROM events WHERE kind = 'click'`,
+			`SELECT user_id, ROW_NUMBER ( ) OVER ( PARTITION BY user_id ORDER BY ts DESC ) FROM events WHERE kind = ?`,
+		},
+		{
+			`SELECT SUM(amount) OVER (ORDER BY ts ROWS BETWEEN 6 PRECEDING AND CURRENT ROW) FROM payments`,
+			`SELECT SUM ( amount ) OVER ( ORDER BY ts ROWS BETWEEN ? PRECEDING AND CURRENT ROW ) FROM payments`,
+		},
+		{
+			`SELECT x, LEAD(x) OVER win, LAG(x) OVER win FROM t WINDOW win AS (PARTITION BY y ORDER BY ts)`,
+			`SELECT x, LEAD ( x ) OVER win, LAG ( x ) OVER win FROM t WINDOW win ( PARTITION BY y ORDER BY ts )`,
+		},
+		{
+			`SELECT SUM(amount) FILTER (WHERE kind = 'refund') AS refunds FROM payments`,
+			`SELECT SUM ( amount ) FILTER ( WHERE kind = ? ) FROM payments`,
+		},
+		{
+			`SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY latency) FROM requests`,
+			`SELECT PERCENTILE_CONT ( ? ) WITHIN GROUP ( ORDER BY latency ) FROM requests`,
+		},
+		{
+			`SELECT name FROM users WHERE email !~* '@example\.com$' AND name !~ '^test'`,
+			`SELECT name FROM users WHERE email !~* ? AND name !~ ?`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			s := SQLSpan(c.query)
+			NewObfuscator(nil).Obfuscate(s)
+			assert.Equal(t, c.expected, s.Resource)
+		})
+	}
+}
+
+func TestSQLDollarQuotedStrings(t *testing.T) {
+	cases := []sqlTestCase{
+		{
+			`SELECT $$it's a string$$, $tag$another $$ string$tag$ FROM t WHERE id = $1`,
+			`SELECT ? FROM t WHERE id = ?`,
+		},
+		{
+			`CREATE FUNCTION add(a integer, b integer) RETURNS integer AS $$ SELECT a + b; $$ LANGUAGE SQL`,
+			`CREATE FUNCTION add ( a integer, b integer ) RETURNS integer ? LANGUAGE SQL`,
+		},
+		{
+			`DO $body$ BEGIN RAISE NOTICE 'it''s %', $$quoted$$; END $body$`,
+			`DO ?`,
+		},
 	}
 
 	for _, c := range cases {
@@ -676,6 +1124,30 @@ in the middle'`,
 	}
 }
 
+func TestSQLTokenizerDollarQuotedString(t *testing.T) {
+	cases := []sqlTokenizerTestCase{
+		{`$$it's a string$$`, "it's a string", DollarQuotedString},
+		{`$tag$another $$ string$tag$`, "another $$ string", DollarQuotedString},
+		{`$_t1$multi
+line$_t1$`, "multi\nline", DollarQuotedString},
+		{`$$backslash at end \$$`, `backslash at end \`, DollarQuotedString},
+		{`$$$$`, "", DollarQuotedString},
+		{`$tag$missing closing tag$$`, "missing closing tag$$", LexError},
+		{`$name`, "", LexError},
+		{`$name FROM t`, "", LexError},
+		{`$1`, "$1", PreparedStatement},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("tokenize_%s", c.str), func(t *testing.T) {
+			tokenizer := NewSQLTokenizer(c.str, false)
+			kind, buffer := tokenizer.Scan()
+			assert.Equal(t, c.expectedKind, kind)
+			assert.Equal(t, c.expected, string(buffer))
+		})
+	}
+}
+
 func TestSQLTokenizerIgnoreEscapeTrue(t *testing.T) {
 	cases := []sqlTokenizerTestCase{
 		{
@@ -921,6 +1393,16 @@ func TestSQLErrors(t *testing.T) {
 			`at position 7: unexpected EOF in comment`,
 		},
 
+		{
+			"SELECT /*! STRAIGHT_JOIN",
+			`at position 25: unexpected EOF in comment`,
+		},
+
+		{
+			"SELECT $tag$unterminated$tag",
+			`at position 28: unexpected EOF in dollar-quoted string`,
+		},
+
 		// using mixed cases of backslash escaping the single quote
 		{
 			"SELECT age FROM profile WHERE name='John\\' and place='John\\'s House'",
@@ -1068,3 +1550,58 @@ func TestCassQuantizer(t *testing.T) {
 		assert.Equal(testCase.expected, s.Resource)
 	}
 }
+
+func TestSQLCache(t *testing.T) {
+	o := NewObfuscator(&config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{CacheSize: 2}})
+
+	for i := 0; i < 3; i++ {
+		oq, err := o.ObfuscateSQLString("SELECT * FROM users WHERE id = 42")
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users WHERE id = ?", oq.Query)
+		// the cached result can't be altered by the callers
+		oq.Query = "altered"
+	}
+	hits, misses := o.SQLCacheStats()
+	assert.Equal(t, uint64(2), hits)
+	assert.Equal(t, uint64(1), misses)
+
+	// the errors are cached too
+	for i := 0; i < 2; i++ {
+		_, err := o.ObfuscateSQLString("SELECT * FROM users WHERE name = 'unterminated")
+		assert.Error(t, err)
+	}
+	hits, misses = o.SQLCacheStats()
+	assert.Equal(t, uint64(3), hits)
+	assert.Equal(t, uint64(2), misses)
+
+	// the least recently used query is evicted
+	o.ObfuscateSQLString("SELECT 1")
+	o.ObfuscateSQLString("SELECT * FROM users WHERE id = 42")
+	hits, misses = o.SQLCacheStats()
+	assert.Equal(t, uint64(3), hits)
+	assert.Equal(t, uint64(4), misses)
+
+	t.Run("disabled", func(t *testing.T) {
+		o := NewObfuscator(nil)
+		o.ObfuscateSQLString("SELECT 1")
+		o.ObfuscateSQLString("SELECT 1")
+		hits, misses := o.SQLCacheStats()
+		assert.Zero(t, hits)
+		assert.Zero(t, misses)
+	})
+}
+
+func BenchmarkObfuscateSQLStringCache(b *testing.B) {
+	query := "SELECT h.id, h.org_id, h.name, ha.name as alias, h.created FROM vs?.host h JOIN vs?.host_alias ha on ha.host_id = h.id WHERE ha.org_id = 1 AND ha.name = ANY('{\"foo\", \"bar\"}')"
+	for _, size := range []int{0, 1000} {
+		o := NewObfuscator(&config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{CacheSize: size}})
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := o.ObfuscateSQLString(query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
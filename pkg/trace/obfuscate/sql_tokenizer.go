@@ -39,6 +39,7 @@ const (
 	Null
 	String
 	DoubleQuotedString
+	DollarQuotedString
 	Number
 	BooleanLiteral
 	ValueArg
@@ -58,6 +59,8 @@ const (
 	Insert
 	Into
 	Join
+	Merge
+	Using
 
 	// FilteredGroupable specifies that the given token has been discarded by one of the
 	// token filters and that it is groupable together with consecutive FilteredGroupable
@@ -76,6 +79,72 @@ const (
 
 const escapeCharacter = '\\'
 
+// sqlDialect holds the lexical rules which differ between SQL engines.
+type sqlDialect struct {
+	// knownEscapes is true when the engine defines whether backslashes are escape characters
+	// in strings, as given by literalEscapes. Otherwise, it is learnt from the queries.
+	knownEscapes   bool
+	literalEscapes bool
+
+	// escapeStrings is true when strings prefixed with E, as in E'\n', contain escape
+	// characters regardless of literalEscapes.
+	escapeStrings bool
+
+	// doubleQuotedStrings is true when double quotes enclose strings rather than identifiers.
+	doubleQuotedStrings bool
+
+	// hashComments is true when '#' starts a comment rather than an identifier or an operator.
+	hashComments bool
+
+	// nestedComments is true when /* ... */ comments can be nested.
+	nestedComments bool
+
+	// executableComments is true when /*! ... */ comments are scanned as part of the query.
+	executableComments bool
+
+	// bracketedIdentifiers is true when brackets enclose identifiers, as in [dbo].[My Table],
+	// rather than array subscripts.
+	bracketedIdentifiers bool
+}
+
+// genericSQLDialect accepts the syntax of most engines, learning how to treat the escape
+// characters from the queries.
+var genericSQLDialect = sqlDialect{
+	hashComments:       true,
+	executableComments: true,
+}
+
+// sqlDialects lists the dialects which can be set in the obfuscation config, by name.
+var sqlDialects = map[string]sqlDialect{
+	"": genericSQLDialect,
+	"postgres": {
+		// standard_conforming_strings is on by default since PostgreSQL 9.1
+		knownEscapes:   true,
+		literalEscapes: true,
+		escapeStrings:  true,
+		nestedComments: true,
+	},
+	"mysql": {
+		knownEscapes:        true,
+		doubleQuotedStrings: true,
+		hashComments:        true,
+		executableComments:  true,
+	},
+	"mssql": {
+		knownEscapes:         true,
+		literalEscapes:       true,
+		nestedComments:       true,
+		bracketedIdentifiers: true,
+	},
+	"oracle": {
+		knownEscapes:   true,
+		literalEscapes: true,
+	},
+	"snowflake": {
+		knownEscapes: true,
+	},
+}
+
 // SQLTokenizer is the struct used to generate SQL
 // tokens for the parser.
 type SQLTokenizer struct {
@@ -84,16 +153,38 @@ type SQLTokenizer struct {
 	lastChar rune            // last read rune
 	err      error           // any error occurred while reading
 
-	literalEscapes bool // indicates we should not treat backslashes as escape characters
-	seenEscape     bool // indicates whether this tokenizer has seen an escape character within a string
+	literalEscapes bool       // indicates we should not treat backslashes as escape characters
+	seenEscape     bool       // indicates whether this tokenizer has seen an escape character within a string
+	dialect        sqlDialect // the lexical rules of the SQL engine
+
+	// executableComment indicates we are within a MySQL or MariaDB executable comment, such
+	// as /*! STRAIGHT_JOIN */, whose content is scanned as part of the query.
+	executableComment bool
+
+	// callEscape indicates we are within an ODBC or JDBC call escape sequence, such as
+	// {call proc(?, ?)}, whose content is scanned as part of the query.
+	callEscape bool
+
+	// normalizeQuotedIdentifiers indicates that identifiers quoted with backticks or double quotes
+	// should be lowercased, so that they match their unquoted form.
+	normalizeQuotedIdentifiers bool
+
+	// replacementToken replaces the literals, keepValuesTuples indicates that the tuples of VALUES
+	// clauses should not be collapsed and tableNames that the tables should be reported as CSV. They
+	// configure the filters applied to the tokens by attemptObfuscation.
+	replacementToken string
+	keepValuesTuples bool
+	tableNames       bool
 }
 
 // NewSQLTokenizer creates a new SQLTokenizer for the given SQL string. The literalEscapes argument specifies
 // whether escape characters should be treated literally or as such.
 func NewSQLTokenizer(sql string, literalEscapes bool) *SQLTokenizer {
 	return &SQLTokenizer{
-		rd:             strings.NewReader(sql),
-		literalEscapes: literalEscapes,
+		rd:               strings.NewReader(sql),
+		literalEscapes:   literalEscapes,
+		dialect:          genericSQLDialect,
+		replacementToken: defaultReplacementToken,
 	}
 }
 
@@ -103,6 +194,8 @@ func (tkn *SQLTokenizer) Reset(in string) {
 	tkn.pos = 0
 	tkn.lastChar = 0
 	tkn.err = nil
+	tkn.executableComment = false
+	tkn.callEscape = false
 }
 
 // keywords used to recognize string tokens
@@ -118,6 +211,8 @@ var keywords = map[string]TokenKind{
 	"INSERT":    Insert,
 	"INTO":      Into,
 	"JOIN":      Join,
+	"MERGE":     Merge,
+	"USING":     Using,
 }
 
 // Err returns the last error that the tokenizer encountered, or nil.
@@ -139,6 +234,16 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	tkn.skipBlank()
 
 	switch ch := tkn.lastChar; {
+	case tkn.dialect.escapeStrings && (ch == 'E' || ch == 'e') && tkn.peek() == '\'':
+		// string with escape characters, e.g. E'foo\'s'
+		tkn.next()
+		tkn.next()
+		return tkn.scanStringEscapes('\'', String, false)
+	case (ch == 'N' || ch == 'n') && tkn.peek() == '\'':
+		// national character string, e.g. N'foo'
+		tkn.next()
+		tkn.next()
+		return tkn.scanString('\'', String)
 	case isLeadingLetter(ch):
 		return tkn.scanIdentifier()
 	case isDigit(ch):
@@ -147,13 +252,40 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 		tkn.next()
 		switch ch {
 		case EOFChar:
+			if tkn.executableComment {
+				tkn.setErr("unexpected EOF in comment")
+				return LexError, nil
+			}
+			if tkn.callEscape {
+				tkn.setErr("unexpected EOF in escape sequence")
+				return LexError, nil
+			}
 			return EOFChar, nil
 		case ':':
 			if tkn.lastChar != '=' {
 				return tkn.scanBindVar()
 			}
 			fallthrough
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '~', '[', ']', '?':
+		case '*':
+			if tkn.executableComment && tkn.lastChar == '/' {
+				// end of the executable comment, e.g. /*! STRAIGHT_JOIN */
+				tkn.next()
+				tkn.executableComment = false
+				return tkn.Scan()
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '?':
+			// SQLite numbered parameters, e.g. ?1, are normalized to a single ?
+			for isDigit(tkn.lastChar) {
+				tkn.next()
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '[':
+			if tkn.dialect.bracketedIdentifiers {
+				return tkn.scanBracketedIdentifier()
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '=', ',', ';', '(', ')', '+', '&', '|', '^', '~', ']':
 			return TokenKind(ch), runeBytes(ch)
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -167,6 +299,9 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				return tkn.scanCommentType1("//")
 			case '*':
 				tkn.next()
+				if tkn.dialect.executableComments && (tkn.lastChar == '!' || tkn.lastChar == 'M' && tkn.peek() == '!') {
+					return tkn.scanExecutableComment()
+				}
 				return tkn.scanCommentType2()
 			default:
 				return TokenKind(ch), runeBytes(ch)
@@ -178,6 +313,15 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			}
 			return TokenKind(ch), runeBytes(ch)
 		case '#':
+			if !tkn.dialect.hashComments {
+				if isLetter(tkn.lastChar) {
+					// SQL Server temporary table, e.g. #temp or ##temp
+					kind, buf := tkn.scanIdentifier()
+					return kind, append(runeBytes(ch), buf...)
+				}
+				// PostgreSQL bitwise XOR operator
+				return TokenKind(ch), runeBytes(ch)
+			}
 			tkn.next()
 			return tkn.scanCommentType1("#")
 		case '<':
@@ -208,11 +352,23 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				tkn.next()
 				return NE, []byte("!=")
 			}
+			if tkn.lastChar == '~' {
+				// PostgreSQL regular expression operators !~ and !~*
+				tkn.next()
+				if tkn.lastChar == '*' {
+					tkn.next()
+					return TokenKind(ch), []byte("!~*")
+				}
+				return TokenKind(ch), []byte("!~")
+			}
 			tkn.setErr(`expected "=" after "!", got "%c" (%d)`, tkn.lastChar, tkn.lastChar)
 			return LexError, []byte("!")
 		case '\'':
 			return tkn.scanString(ch, String)
 		case '"':
+			if tkn.dialect.doubleQuotedStrings {
+				return tkn.scanString(ch, String)
+			}
 			return tkn.scanString(ch, DoubleQuotedString)
 		case '`':
 			return tkn.scanLiteralIdentifier('`')
@@ -227,9 +383,24 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			// modulo operator (e.g. 'id % 8')
 			return TokenKind(ch), runeBytes(ch)
 		case '$':
+			if tkn.isDollarQuoteTag() {
+				return tkn.scanDollarQuotedString()
+			}
 			return tkn.scanPreparedStatement('$')
 		case '{':
+			if tkn.isCallEscape() {
+				// start of a procedure call escape, e.g. {call proc(?)} or {? = call proc(?)}
+				tkn.callEscape = true
+				return tkn.Scan()
+			}
 			return tkn.scanEscapeSequence('{')
+		case '}':
+			if tkn.callEscape {
+				tkn.callEscape = false
+				return tkn.Scan()
+			}
+			tkn.setErr(`unexpected byte %d`, ch)
+			return LexError, runeBytes(ch)
 		default:
 			tkn.setErr(`unexpected byte %d`, ch)
 			return LexError, runeBytes(ch)
@@ -252,6 +423,12 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 		buffer.WriteRune(tkn.lastChar)
 		tkn.next()
 	}
+	if tkn.dialect.bracketedIdentifiers && tkn.lastChar == '[' && bytes.HasSuffix(buffer.Bytes(), []byte(".")) {
+		// multi-part identifier with a bracketed part, e.g. dbo.[My Table]
+		tkn.next()
+		kind, buf := tkn.scanBracketedIdentifier()
+		return kind, append(buffer.Bytes(), buf...)
+	}
 	upper := bytes.ToUpper(buffer.Bytes())
 	if keywordID, found := keywords[string(upper)]; found {
 		return keywordID, buffer.Bytes()
@@ -275,6 +452,51 @@ func (tkn *SQLTokenizer) scanLiteralIdentifier(quote rune) (TokenKind, []byte) {
 		return LexError, buffer.Bytes()
 	}
 	tkn.next()
+	if tkn.normalizeQuotedIdentifiers {
+		return ID, bytes.ToLower(buffer.Bytes())
+	}
+	return ID, buffer.Bytes()
+}
+
+// scanBracketedIdentifier scans an SQL Server identifier enclosed in brackets, along with
+// the parts following it in a multi-part identifier, e.g. [dbo].[My Table] or [dbo].users.
+// Within brackets, "]]" stands for a closing bracket.
+func (tkn *SQLTokenizer) scanBracketedIdentifier() (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	for {
+		// the opening bracket was consumed
+		buffer.WriteRune('[')
+		for {
+			ch := tkn.lastChar
+			if ch == EOFChar {
+				tkn.setErr("unexpected EOF in bracketed identifier")
+				return LexError, buffer.Bytes()
+			}
+			buffer.WriteRune(ch)
+			tkn.next()
+			if ch == ']' {
+				if tkn.lastChar != ']' {
+					break
+				}
+				buffer.WriteRune(tkn.lastChar)
+				tkn.next()
+			}
+		}
+		if tkn.lastChar != '.' {
+			break
+		}
+		for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
+			buffer.WriteRune(tkn.lastChar)
+			tkn.next()
+		}
+		if tkn.lastChar != '[' || !bytes.HasSuffix(buffer.Bytes(), []byte(".")) {
+			break
+		}
+		tkn.next()
+	}
+	if tkn.normalizeQuotedIdentifiers {
+		return ID, bytes.ToLower(buffer.Bytes())
+	}
 	return ID, buffer.Bytes()
 }
 
@@ -328,6 +550,53 @@ func (tkn *SQLTokenizer) scanPreparedStatement(prefix rune) (TokenKind, []byte)
 	return PreparedStatement, buffer.Bytes()
 }
 
+// isDollarQuoteTag reports whether the '$' being consumed opens a dollar-quoted string, that
+// is whether it is followed by an optional tag and another '$', as in $$ or $tag$. The
+// position of the tokenizer is left unchanged.
+func (tkn *SQLTokenizer) isDollarQuoteTag() bool {
+	if tkn.lastChar == '$' {
+		return true
+	}
+	if !isLeadingLetter(tkn.lastChar) {
+		return false
+	}
+	rd := *tkn.rd
+	for {
+		ch, _, err := rd.ReadRune()
+		switch {
+		case err != nil:
+			return false
+		case ch == '$':
+			return true
+		case !isLeadingLetter(ch) && !isDigit(ch):
+			return false
+		}
+	}
+}
+
+// scanDollarQuotedString scans a PostgreSQL dollar-quoted string, such as
+// $$text$$ or $tag$text$tag$, the leading '$' being already consumed.
+func (tkn *SQLTokenizer) scanDollarQuotedString() (TokenKind, []byte) {
+	delim := bytes.NewBufferString("$")
+	for tkn.lastChar != '$' {
+		delim.WriteRune(tkn.lastChar)
+		tkn.next()
+	}
+	delim.WriteRune('$')
+	tkn.next()
+
+	buffer := &bytes.Buffer{}
+	for !bytes.HasSuffix(buffer.Bytes(), delim.Bytes()) {
+		if tkn.lastChar == EOFChar {
+			tkn.setErr("unexpected EOF in dollar-quoted string")
+			return LexError, buffer.Bytes()
+		}
+		buffer.WriteRune(tkn.lastChar)
+		tkn.next()
+	}
+	return DollarQuotedString, buffer.Bytes()[:buffer.Len()-delim.Len()]
+}
+
 func (tkn *SQLTokenizer) scanEscapeSequence(braces rune) (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
 	buffer.WriteRune(braces)
@@ -349,6 +618,43 @@ func (tkn *SQLTokenizer) scanEscapeSequence(braces rune) (TokenKind, []byte) {
 	return EscapeSequence, buffer.Bytes()
 }
 
+// isCallEscape reports whether the escape sequence starting at the current position, the
+// opening brace being already consumed, is a procedure call such as {call proc(?, ?)} or
+// {? = call proc(?)}. The position of the tokenizer is left unchanged.
+func (tkn *SQLTokenizer) isCallEscape() bool {
+	rd := *tkn.rd
+	ch := tkn.lastChar
+	next := func() {
+		var err error
+		if ch, _, err = rd.ReadRune(); err != nil {
+			ch = EOFChar
+		}
+	}
+	skipBlank := func() {
+		for unicode.IsSpace(ch) {
+			next()
+		}
+	}
+	skipBlank()
+	if ch == '?' {
+		// the procedure returns a value, e.g. {? = call proc(?)}
+		next()
+		skipBlank()
+		if ch != '=' {
+			return false
+		}
+		next()
+		skipBlank()
+	}
+	for _, want := range "call" {
+		if unicode.ToLower(ch) != want {
+			return false
+		}
+		next()
+	}
+	return !isLetter(ch) && !isDigit(ch)
+}
+
 func (tkn *SQLTokenizer) scanBindVar() (TokenKind, []byte) {
 	buffer := bytes.NewBufferString(":")
 	token := ValueArg
@@ -433,6 +739,12 @@ exit:
 }
 
 func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []byte) {
+	return tkn.scanStringEscapes(delim, kind, tkn.literalEscapes)
+}
+
+// scanStringEscapes scans a string, literalEscapes specifying whether backslashes are escape
+// characters in it.
+func (tkn *SQLTokenizer) scanStringEscapes(delim rune, kind TokenKind, literalEscapes bool) (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
 	for {
 		ch := tkn.lastChar
@@ -448,7 +760,7 @@ func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []by
 		} else if ch == escapeCharacter {
 			tkn.seenEscape = true
 
-			if !tkn.literalEscapes {
+			if !literalEscapes {
 				// treat as an escape character
 				ch = tkn.lastChar
 				tkn.next()
@@ -468,6 +780,9 @@ func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []by
 		// See: https://github.com/DataDog/datadog-trace-agent/issues/316
 		return kind, append(runeBytes(delim), runeBytes(delim)...)
 	}
+	if kind == DoubleQuotedString && tkn.normalizeQuotedIdentifiers {
+		return kind, bytes.ToLower(buf)
+	}
 	return kind, buf
 }
 
@@ -487,12 +802,23 @@ func (tkn *SQLTokenizer) scanCommentType1(prefix string) (TokenKind, []byte) {
 func (tkn *SQLTokenizer) scanCommentType2() (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
 	buffer.WriteString("/*")
+	depth := 1
 	for {
 		if tkn.lastChar == '*' {
 			tkn.consumeNext(buffer)
 			if tkn.lastChar == '/' {
 				tkn.consumeNext(buffer)
-				break
+				if depth--; depth == 0 {
+					break
+				}
+			}
+			continue
+		}
+		if tkn.dialect.nestedComments && tkn.lastChar == '/' {
+			tkn.consumeNext(buffer)
+			if tkn.lastChar == '*' {
+				tkn.consumeNext(buffer)
+				depth++
 			}
 			continue
 		}
@@ -505,6 +831,22 @@ func (tkn *SQLTokenizer) scanCommentType2() (TokenKind, []byte) {
 	return Comment, buffer.Bytes()
 }
 
+// scanExecutableComment scans the start of a MySQL executable comment, such as /*!40101 or
+// MariaDB's /*M!100101, the leading "/*" being already consumed. The content of the comment
+// is then scanned as part of the query, as the server runs it.
+func (tkn *SQLTokenizer) scanExecutableComment() (TokenKind, []byte) {
+	if tkn.lastChar == 'M' {
+		tkn.next()
+	}
+	tkn.next()
+	// skip the minimum server version
+	for isDigit(tkn.lastChar) {
+		tkn.next()
+	}
+	tkn.executableComment = true
+	return tkn.Scan()
+}
+
 func (tkn *SQLTokenizer) consumeNext(buffer *bytes.Buffer) {
 	if tkn.lastChar == EOFChar {
 		// This should never happen.
@@ -514,6 +856,16 @@ func (tkn *SQLTokenizer) consumeNext(buffer *bytes.Buffer) {
 	tkn.next()
 }
 
+// peek returns the rune following lastChar, without consuming it.
+func (tkn *SQLTokenizer) peek() rune {
+	ch, _, err := tkn.rd.ReadRune()
+	if err != nil {
+		return EOFChar
+	}
+	tkn.rd.UnreadRune() //nolint:errcheck
+	return ch
+}
+
 func (tkn *SQLTokenizer) next() {
 	ch, _, err := tkn.rd.ReadRune()
 	if tkn.lastChar != 0 || tkn.pos > 0 {
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// sqlCommentTagPrefix prefixes the tags holding the values of sqlcommenter comments.
+const sqlCommentTagPrefix = "sql.comment."
+
+// setSQLCommentTags sets the key-value pairs found in the sqlcommenter comments of a query
+// as tags of the span, e.g. /*traceparent='00-...'*/ sets "sql.comment.traceparent".
+// Other comments are ignored.
+func setSQLCommentTags(span *pb.Span, comments []string) {
+	for _, c := range comments {
+		for _, kv := range parseSQLCommenter(c) {
+			traceutil.SetMeta(span, sqlCommentTagPrefix+kv[0], kv[1])
+		}
+	}
+}
+
+// parseSQLCommenter returns the key-value pairs of a sqlcommenter comment, of the form
+// /*key1='value1',key2='value2'*/ with URL-encoded keys and values, see
+// https://google.github.io/sqlcommenter/spec/. It returns nil if c isn't such a comment.
+func parseSQLCommenter(c string) [][2]string {
+	if !strings.HasPrefix(c, "/*") || !strings.HasSuffix(c, "*/") || len(c) < 4 {
+		return nil
+	}
+	c = strings.TrimSpace(c[2 : len(c)-2])
+	if c == "" {
+		return nil
+	}
+	var pairs [][2]string
+	for _, field := range strings.Split(c, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil
+		}
+		key, err := url.PathUnescape(strings.TrimSpace(kv[0]))
+		if err != nil || key == "" || strings.ContainsAny(key, " \t\n") {
+			return nil
+		}
+		v := strings.TrimSpace(kv[1])
+		if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+			v = strings.Replace(v[1:len(v)-1], `\'`, `'`, -1)
+		}
+		if v, err = url.PathUnescape(v); err != nil {
+			return nil
+		}
+		pairs = append(pairs, [2]string{key, v})
+	}
+	return pairs
+}
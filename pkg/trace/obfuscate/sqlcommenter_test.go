@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSQLCommenter(t *testing.T) {
+	for in, want := range map[string][][2]string{
+		"/*traceparent='00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01'*/": {
+			{"traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		},
+		"/* action='%2Fparam*d',controller='index',framework='spring' */": {
+			{"action", "/param*d"},
+			{"controller", "index"},
+			{"framework", "spring"},
+		},
+		`/*db_driver='it\'s',route=users*/`: {
+			{"db_driver", "it's"},
+			{"route", "users"},
+		},
+		"/* fetch users */":      nil,
+		"/* a=1, fetch users */": nil,
+		"-- traceparent='00'":    nil,
+		"/**/":                   nil,
+		"/*key='%zz'*/":          nil,
+	} {
+		assert.Equal(t, want, parseSQLCommenter(in), in)
+	}
+}
+
+func TestSQLExtractComments(t *testing.T) {
+	query := "SELECT * FROM users WHERE id = 42 /*traceparent='00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01',route='%2Fusers'*/ /* other */"
+
+	t.Run("enabled", func(t *testing.T) {
+		assert := assert.New(t)
+		cfg := &config.ObfuscationConfig{SQL: config.SQLObfuscationConfig{ExtractComments: true}}
+		span := &pb.Span{Resource: query, Type: "sql"}
+		NewObfuscator(cfg).Obfuscate(span)
+		assert.Equal("SELECT * FROM users WHERE id = ?", span.Resource)
+		assert.Equal("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", span.Meta["sql.comment.traceparent"])
+		assert.Equal("/users", span.Meta["sql.comment.route"])
+		assert.Len(span.Meta, 3)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert := assert.New(t)
+		span := &pb.Span{Resource: query, Type: "sql"}
+		NewObfuscator(&config.ObfuscationConfig{}).Obfuscate(span)
+		assert.Equal("SELECT * FROM users WHERE id = ?", span.Resource)
+		assert.NotContains(span.Meta, "sql.comment.traceparent")
+	})
+}
@@ -42,18 +42,19 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
 // Span specifies the common Datadog API and trace agent span.
 type Span struct {
-	Service  string             `protobuf:"bytes,1,opt,name=service,proto3" json:"service" msg:"service"`
-	Name     string             `protobuf:"bytes,2,opt,name=name,proto3" json:"name" msg:"name"`
-	Resource string             `protobuf:"bytes,3,opt,name=resource,proto3" json:"resource" msg:"resource"`
-	TraceID  uint64             `protobuf:"varint,4,opt,name=traceID,proto3" json:"trace_id" msg:"trace_id"`
-	SpanID   uint64             `protobuf:"varint,5,opt,name=spanID,proto3" json:"span_id" msg:"span_id"`
-	ParentID uint64             `protobuf:"varint,6,opt,name=parentID,proto3" json:"parent_id" msg:"parent_id"`
-	Start    int64              `protobuf:"varint,7,opt,name=start,proto3" json:"start" msg:"start"`
-	Duration int64              `protobuf:"varint,8,opt,name=duration,proto3" json:"duration" msg:"duration"`
-	Error    int32              `protobuf:"varint,9,opt,name=error,proto3" json:"error" msg:"error"`
-	Meta     map[string]string  `protobuf:"bytes,10,rep,name=meta" json:"meta" msg:"meta" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	Metrics  map[string]float64 `protobuf:"bytes,11,rep,name=metrics" json:"metrics" msg:"metrics" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
-	Type     string             `protobuf:"bytes,12,opt,name=type,proto3" json:"type" msg:"type"`
+	Service     string             `protobuf:"bytes,1,opt,name=service,proto3" json:"service" msg:"service"`
+	Name        string             `protobuf:"bytes,2,opt,name=name,proto3" json:"name" msg:"name"`
+	Resource    string             `protobuf:"bytes,3,opt,name=resource,proto3" json:"resource" msg:"resource"`
+	TraceID     uint64             `protobuf:"varint,4,opt,name=traceID,proto3" json:"trace_id" msg:"trace_id"`
+	SpanID      uint64             `protobuf:"varint,5,opt,name=spanID,proto3" json:"span_id" msg:"span_id"`
+	ParentID    uint64             `protobuf:"varint,6,opt,name=parentID,proto3" json:"parent_id" msg:"parent_id"`
+	Start       int64              `protobuf:"varint,7,opt,name=start,proto3" json:"start" msg:"start"`
+	Duration    int64              `protobuf:"varint,8,opt,name=duration,proto3" json:"duration" msg:"duration"`
+	Error       int32              `protobuf:"varint,9,opt,name=error,proto3" json:"error" msg:"error"`
+	Meta        map[string]string  `protobuf:"bytes,10,rep,name=meta" json:"meta" msg:"meta" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Metrics     map[string]float64 `protobuf:"bytes,11,rep,name=metrics" json:"metrics" msg:"metrics" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	Type        string             `protobuf:"bytes,12,opt,name=type,proto3" json:"type" msg:"type"`
+	TraceIDHigh uint64             `protobuf:"varint,13,opt,name=traceIDHigh,proto3" json:"trace_id_high" msg:"trace_id_high"`
 }
 
 func (m *Span) Reset()                    { *m = Span{} }
@@ -180,6 +181,11 @@ func (m *Span) MarshalTo(data []byte) (int, error) {
 		i = encodeVarintSpan(data, i, uint64(len(m.Type)))
 		i += copy(data[i:], m.Type)
 	}
+	if m.TraceIDHigh != 0 {
+		data[i] = 0x68
+		i++
+		i = encodeVarintSpan(data, i, uint64(m.TraceIDHigh))
+	}
 	return i, nil
 }
 
@@ -263,6 +269,9 @@ func (m *Span) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovSpan(uint64(l))
 	}
+	if m.TraceIDHigh != 0 {
+		n += 1 + sovSpan(uint64(m.TraceIDHigh))
+	}
 	return n
 }
 
@@ -758,6 +767,25 @@ func (m *Span) Unmarshal(data []byte) error {
 			}
 			m.Type = string(data[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceIDHigh", wireType)
+			}
+			m.TraceIDHigh = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSpan
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.TraceIDHigh |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSpan(data[iNdEx:])
@@ -887,36 +915,37 @@ var (
 func init() { proto.RegisterFile("span.proto", fileDescriptorSpan) }
 
 var fileDescriptorSpan = []byte{
-	// 493 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x93, 0xcd, 0x8e, 0xd3, 0x30,
-	0x10, 0xc7, 0xf1, 0x36, 0xe9, 0x87, 0xbb, 0xc0, 0xca, 0x02, 0x64, 0x55, 0x28, 0x89, 0x7c, 0x8a,
-	0x90, 0xc8, 0x4a, 0x80, 0x60, 0x55, 0x71, 0xa1, 0x2a, 0x87, 0x1e, 0x90, 0x90, 0x79, 0x00, 0xe4,
-	0xa6, 0xa6, 0x44, 0x6c, 0x3e, 0xe4, 0x38, 0x2b, 0xf5, 0x2d, 0x78, 0x0a, 0x9e, 0x85, 0x23, 0x4f,
-	0x10, 0xa1, 0x72, 0xcb, 0xb1, 0x4f, 0x80, 0x3c, 0x4e, 0xcc, 0x8a, 0xcb, 0xde, 0xf2, 0xff, 0xcd,
-	0xfc, 0x3d, 0x9e, 0xf1, 0x04, 0xe3, 0xba, 0x12, 0x45, 0x52, 0xa9, 0x52, 0x97, 0xc4, 0xcf, 0xcb,
-	0x9d, 0xbc, 0x5e, 0x3c, 0xdf, 0x67, 0xfa, 0x6b, 0xb3, 0x4d, 0xd2, 0x32, 0xbf, 0xdc, 0x97, 0xfb,
-	0xf2, 0x12, 0xa2, 0xdb, 0xe6, 0x0b, 0x28, 0x10, 0xf0, 0x65, 0x5d, 0xec, 0xc7, 0x18, 0x7b, 0x9f,
-	0x2a, 0x51, 0x90, 0xd7, 0x78, 0x52, 0x4b, 0x75, 0x93, 0xa5, 0x92, 0xa2, 0x08, 0xc5, 0xb3, 0xd5,
-	0xd3, 0xae, 0x0d, 0x07, 0x74, 0x6a, 0xc3, 0xfb, 0x79, 0xbd, 0x5f, 0xb2, 0x5e, 0x33, 0x3e, 0x44,
-	0xc8, 0x33, 0xec, 0x15, 0x22, 0x97, 0xf4, 0x0c, 0x4c, 0x4f, 0xba, 0x36, 0x04, 0x7d, 0x6a, 0x43,
-	0x0c, 0x0e, 0x23, 0x18, 0x07, 0x46, 0x96, 0x78, 0xaa, 0x64, 0x5d, 0x36, 0x2a, 0x95, 0x74, 0x04,
-	0xf9, 0x41, 0xd7, 0x86, 0x8e, 0x9d, 0xda, 0xf0, 0x01, 0x78, 0x06, 0xc0, 0xb8, 0x8b, 0x91, 0x2b,
-	0x3c, 0xd1, 0x4a, 0xa4, 0x72, 0xb3, 0xa6, 0x5e, 0x84, 0x62, 0xcf, 0x5a, 0x01, 0x7d, 0xce, 0x76,
-	0xce, 0x3a, 0x00, 0xc6, 0x87, 0x74, 0xf2, 0x0a, 0x8f, 0xcd, 0x98, 0x36, 0x6b, 0xea, 0x83, 0xd1,
-	0x36, 0x56, 0x89, 0xc2, 0xfa, 0xfa, 0xc6, 0xac, 0x66, 0xbc, 0xcf, 0x25, 0x6f, 0xf1, 0xb4, 0x12,
-	0x4a, 0x16, 0x7a, 0xb3, 0xa6, 0x63, 0xf0, 0x45, 0x5d, 0x1b, 0xce, 0x2c, 0xb3, 0xce, 0x87, 0xe0,
-	0x74, 0x84, 0x71, 0xe7, 0x20, 0x09, 0xf6, 0x6b, 0x2d, 0x94, 0xa6, 0x93, 0x08, 0xc5, 0xa3, 0x15,
-	0xed, 0xda, 0xd0, 0x82, 0x53, 0x1b, 0xce, 0x6d, 0x41, 0xa3, 0x18, 0xb7, 0xd4, 0x4c, 0x66, 0xd7,
-	0x28, 0xa1, 0xb3, 0xb2, 0xa0, 0x53, 0xb0, 0x40, 0x7b, 0x03, 0x73, 0xed, 0x0d, 0x80, 0x71, 0x17,
-	0x33, 0xb5, 0xa4, 0x52, 0xa5, 0xa2, 0xb3, 0x08, 0xc5, 0xbe, 0xad, 0x05, 0xc0, 0xd5, 0x02, 0xc5,
-	0xb8, 0xa5, 0xe4, 0x1d, 0xf6, 0x72, 0xa9, 0x05, 0xc5, 0xd1, 0x28, 0x9e, 0xbf, 0x78, 0x9c, 0xc0,
-	0xde, 0x24, 0x66, 0x09, 0x92, 0x0f, 0x52, 0x8b, 0xf7, 0x85, 0x56, 0x07, 0xfb, 0x90, 0x26, 0xcd,
-	0x3d, 0xa4, 0x11, 0x8c, 0x03, 0x23, 0x1f, 0xf1, 0x24, 0x97, 0x5a, 0x65, 0x69, 0x4d, 0xe7, 0x70,
-	0x0a, 0xfd, 0xef, 0x14, 0x13, 0xb2, 0x07, 0xc1, 0xb4, 0xfb, 0x64, 0x37, 0xed, 0x5e, 0x33, 0x3e,
-	0x44, 0xcc, 0x1a, 0xe9, 0x43, 0x25, 0xe9, 0xf9, 0xbf, 0x35, 0x32, 0xda, 0x55, 0x37, 0x82, 0x71,
-	0x60, 0x8b, 0x37, 0x78, 0xe6, 0x2e, 0x4a, 0x2e, 0xf0, 0xe8, 0x9b, 0x3c, 0xd8, 0x9d, 0xe5, 0xe6,
-	0x93, 0x3c, 0xc2, 0xfe, 0x8d, 0xb8, 0x6e, 0xfa, 0x95, 0xe4, 0x56, 0x2c, 0xcf, 0xae, 0xd0, 0x62,
-	0x89, 0xcf, 0x6f, 0xdf, 0xed, 0x2e, 0x2f, 0xba, 0xe5, 0x5d, 0x5d, 0xfc, 0x3c, 0x06, 0xe8, 0xd7,
-	0x31, 0x40, 0xbf, 0x8f, 0x01, 0xfa, 0xfe, 0x27, 0xb8, 0xb7, 0x1d, 0xc3, 0x1f, 0xf4, 0xf2, 0x6f,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0x94, 0x9b, 0x26, 0x0f, 0x85, 0x03, 0x00, 0x00,
+	// 508 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x85, 0x93, 0xcd, 0x8e, 0xd3, 0x30,
+	0x10, 0xc7, 0xc9, 0x36, 0xe9, 0xc7, 0x74, 0x0b, 0x2b, 0xf3, 0x21, 0xab, 0x42, 0xdd, 0x2a, 0xa7,
+	0x0a, 0x89, 0xac, 0x04, 0x2b, 0x58, 0x55, 0x5c, 0xa8, 0x40, 0x02, 0x24, 0x24, 0x14, 0x1e, 0x60,
+	0x95, 0xb6, 0x26, 0x8d, 0x76, 0x13, 0x57, 0x8e, 0xb3, 0x52, 0xdf, 0x62, 0x1f, 0x8b, 0x23, 0x4f,
+	0xb0, 0x42, 0x70, 0xe3, 0xb8, 0x4f, 0xc0, 0x78, 0xec, 0x98, 0xc2, 0x85, 0x43, 0x65, 0xff, 0xff,
+	0x9e, 0x9f, 0x27, 0xe3, 0x99, 0x02, 0xd4, 0xdb, 0xac, 0x4a, 0xb6, 0x4a, 0x6a, 0xc9, 0xa2, 0x52,
+	0xae, 0xc5, 0xe5, 0xf8, 0x69, 0x5e, 0xe8, 0x4d, 0xb3, 0x4c, 0x56, 0xb2, 0x3c, 0xc9, 0x65, 0x2e,
+	0x4f, 0xe8, 0x74, 0xd9, 0x7c, 0x21, 0x45, 0x82, 0x76, 0x96, 0x8a, 0x6f, 0xbb, 0x10, 0x7e, 0xc6,
+	0x4b, 0xd8, 0x0b, 0xe8, 0xd5, 0x42, 0x5d, 0x15, 0x2b, 0xc1, 0x83, 0x69, 0x30, 0x1b, 0x2c, 0x1e,
+	0xff, 0xba, 0x39, 0x6e, 0xad, 0xdb, 0x9b, 0xe3, 0x51, 0x59, 0xe7, 0xf3, 0xd8, 0xe9, 0x38, 0x6d,
+	0x4f, 0xd8, 0x13, 0x08, 0xab, 0xac, 0x14, 0xfc, 0x80, 0xa0, 0x47, 0x08, 0x91, 0x46, 0x02, 0x88,
+	0x30, 0x22, 0x4e, 0xc9, 0x63, 0x73, 0xe8, 0x2b, 0x51, 0xcb, 0x46, 0x61, 0x92, 0x0e, 0xc5, 0x4f,
+	0x30, 0xde, 0x7b, 0xc8, 0xdc, 0x25, 0xa6, 0x35, 0xe2, 0xd4, 0x9f, 0xb1, 0x33, 0xe8, 0x69, 0x95,
+	0xad, 0xc4, 0xfb, 0x37, 0x3c, 0x44, 0x34, 0xb4, 0x28, 0x59, 0xe7, 0xc5, 0xda, 0xa3, 0xad, 0x81,
+	0x5f, 0xe8, 0xc2, 0xd9, 0x29, 0x74, 0xcd, 0x33, 0x21, 0x18, 0x11, 0x68, 0x0b, 0x43, 0xc7, 0x72,
+	0xae, 0x30, 0xab, 0xe3, 0xd4, 0xc5, 0xb2, 0x57, 0xd0, 0xdf, 0x66, 0x4a, 0x54, 0x1a, 0xb9, 0x2e,
+	0x71, 0x53, 0xe4, 0x06, 0xd6, 0xb3, 0xe4, 0x3d, 0x22, 0xbd, 0x83, 0x5f, 0xdb, 0x12, 0x2c, 0x81,
+	0xa8, 0xd6, 0x99, 0xd2, 0xbc, 0x87, 0x68, 0x67, 0xc1, 0x11, 0xb5, 0x06, 0x62, 0x43, 0x9b, 0xd0,
+	0xa8, 0x38, 0xb5, 0xae, 0x79, 0x99, 0x75, 0xa3, 0x32, 0x5d, 0xc8, 0x8a, 0xf7, 0x09, 0xa1, 0xf2,
+	0x5a, 0xcf, 0x97, 0xd7, 0x1a, 0x98, 0xab, 0xdd, 0x9a, 0x5c, 0x42, 0x29, 0xa9, 0xf8, 0x00, 0xc1,
+	0xc8, 0xe6, 0x22, 0xc3, 0xe7, 0x22, 0x85, 0xb9, 0x68, 0x65, 0xaf, 0x21, 0x2c, 0x85, 0xce, 0x38,
+	0x4c, 0x3b, 0xb3, 0xe1, 0xb3, 0x87, 0x09, 0xcd, 0x4d, 0x62, 0x86, 0x20, 0xf9, 0x88, 0xfe, 0xdb,
+	0x4a, 0xab, 0x9d, 0x6d, 0xa4, 0x09, 0xf3, 0x8d, 0x34, 0x02, 0x1b, 0x69, 0x16, 0xf6, 0x09, 0x7a,
+	0xb8, 0xaa, 0x62, 0x55, 0xf3, 0x21, 0xdd, 0xc2, 0xff, 0xb9, 0xc5, 0x1c, 0xd9, 0x8b, 0xe8, 0xb5,
+	0x5d, 0xb0, 0x7f, 0x6d, 0xa7, 0xb1, 0x49, 0x6e, 0x67, 0xc6, 0x48, 0xef, 0xb6, 0x82, 0x1f, 0xfe,
+	0x19, 0x23, 0xa3, 0x7d, 0x76, 0x23, 0x30, 0xbb, 0x59, 0xd8, 0x07, 0x18, 0xba, 0xde, 0xbe, 0x2b,
+	0xf2, 0x0d, 0x1f, 0x51, 0x77, 0x66, 0x88, 0x8c, 0xda, 0xee, 0x9f, 0x6f, 0xf0, 0x00, 0xd9, 0xfb,
+	0x7f, 0xcd, 0x04, 0xb9, 0x71, 0xba, 0x0f, 0x8f, 0x5f, 0xc2, 0xc0, 0x17, 0xcd, 0x8e, 0xa0, 0x73,
+	0x21, 0x76, 0x76, 0xfe, 0x53, 0xb3, 0x65, 0x0f, 0x20, 0xba, 0xca, 0x2e, 0x1b, 0x37, 0xde, 0xa9,
+	0x15, 0xf3, 0x83, 0xb3, 0x60, 0x3c, 0x87, 0xc3, 0xfd, 0x3a, 0xff, 0xc7, 0x06, 0x7b, 0xec, 0xe2,
+	0xe8, 0xeb, 0x8f, 0x49, 0xf0, 0x0d, 0x7f, 0xdf, 0xf1, 0x77, 0xfd, 0x73, 0x72, 0x67, 0xd9, 0xa5,
+	0x7f, 0xe3, 0xf3, 0xdf, 0xff, 0x48, 0x96, 0x29, 0xd1, 0x03, 0x00, 0x00,
 }
@@ -192,6 +192,16 @@ func (z *Span) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "trace_id_high":
+			if dc.IsNil() {
+				z.TraceIDHigh, err = 0, dc.ReadNil()
+				break
+			}
+
+			z.TraceIDHigh, err = parseUint64(dc)
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -204,9 +214,9 @@ func (z *Span) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Span) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 12
+	// map header, size 13
 	// write "service"
-	err = en.Append(0x8c, 0xa7, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
+	err = en.Append(0x8d, 0xa7, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
 	if err != nil {
 		return err
 	}
@@ -333,6 +343,15 @@ func (z *Span) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "trace_id_high"
+	err = en.Append(0xad, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x5f, 0x68, 0x69, 0x67, 0x68)
+	if err != nil {
+		return err
+	}
+	err = en.WriteUint64(z.TraceIDHigh)
+	if err != nil {
+		return
+	}
 	return
 }
 
@@ -352,6 +371,6 @@ func (z *Span) Msgsize() (s int) {
 			s += msgp.StringPrefixSize + len(zbai) + msgp.Float64Size
 		}
 	}
-	s += 10 + msgp.Uint64Size + 5 + msgp.StringPrefixSize + len(z.Type)
+	s += 10 + msgp.Uint64Size + 5 + msgp.StringPrefixSize + len(z.Type) + 14 + msgp.Uint64Size
 	return
 }
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package pb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestSpanTraceIDHigh(t *testing.T) {
+	span := Span{
+		Service:     "django",
+		Name:        "django.controller",
+		TraceID:     0xe457b5a2e4d86bd1,
+		SpanID:      42,
+		TraceIDHigh: 0x5af7183fb1d4cf5f,
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		b, err := span.Marshal()
+		require.NoError(t, err)
+		assert.Len(t, b, span.Size())
+
+		var got Span
+		require.NoError(t, got.Unmarshal(b))
+		assert.Equal(t, span, got)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, msgp.Encode(&buf, &span))
+		assert.True(t, buf.Len() <= span.Msgsize())
+
+		var got Span
+		require.NoError(t, msgp.Decode(&buf, &got))
+		assert.Equal(t, span, got)
+	})
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package pb
+
+import (
+	"errors"
+	"io"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// ErrSpanLimitReached is returned by StreamDecoder.Next when a payload holds
+// more spans than allowed.
+var ErrSpanLimitReached = errors.New("span limit reached")
+
+// maxPreallocatedSpans bounds the memory allocated for a trace before its
+// spans are actually decoded.
+const maxPreallocatedSpans = 1024
+
+// StreamDecoder decodes a msgpack encoded list of traces one trace at a time,
+// so that a payload never has to be held in memory as a whole. The sizes
+// announced by the payload are checked against the span limit before anything
+// is allocated.
+type StreamDecoder struct {
+	dc        *msgp.Reader
+	remaining uint32
+	maxSpans  int
+	spans     int
+}
+
+// NewStreamDecoder reads the header of the list of traces from r and returns
+// a decoder allowing at most maxSpans spans in the payload, 0 meaning no limit.
+func NewStreamDecoder(r io.Reader, maxSpans int) (*StreamDecoder, error) {
+	dc := msgp.NewReader(r)
+	n, err := dc.ReadArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+	return &StreamDecoder{
+		dc:        dc,
+		remaining: n,
+		maxSpans:  maxSpans,
+	}, nil
+}
+
+// Remaining returns the number of traces left in the payload, including the
+// one which failed to be decoded after an error.
+func (d *StreamDecoder) Remaining() int {
+	return int(d.remaining)
+}
+
+// Next decodes the next trace of the payload. It returns io.EOF once all the
+// traces were decoded, and ErrSpanLimitReached when the trace would exceed
+// the span limit of the payload. The decoder must not be used after an error.
+func (d *StreamDecoder) Next() (Trace, error) {
+	if d.remaining == 0 {
+		return nil, io.EOF
+	}
+	n, err := d.dc.ReadArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+	if d.maxSpans > 0 && int(n) > d.maxSpans-d.spans {
+		return nil, ErrSpanLimitReached
+	}
+	d.spans += int(n)
+
+	// the announced size can't be trusted when there is no span limit
+	size := n
+	if size > maxPreallocatedSpans {
+		size = maxPreallocatedSpans
+	}
+	trace := make(Trace, 0, size)
+	for i := uint32(0); i < n; i++ {
+		if d.dc.IsNil() {
+			if err := d.dc.ReadNil(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		span := new(Span)
+		if err := span.DecodeMsg(d.dc); err != nil {
+			return nil, err
+		}
+		trace = append(trace, span)
+	}
+	d.remaining--
+	return trace, nil
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package pb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func encodeTraces(t *testing.T, traces Traces) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, msgp.Encode(&buf, traces))
+	return buf.Bytes()
+}
+
+func TestStreamDecoder(t *testing.T) {
+	assert := assert.New(t)
+	traces := Traces{
+		{{TraceID: 1, SpanID: 1, Service: "a"}, {TraceID: 1, SpanID: 2, Service: "a"}},
+		{{TraceID: 2, SpanID: 3, Service: "b"}},
+	}
+
+	dec, err := NewStreamDecoder(bytes.NewReader(encodeTraces(t, traces)), 0)
+	require.NoError(t, err)
+	assert.Equal(2, dec.Remaining())
+
+	var decoded Traces
+	for {
+		trace, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		decoded = append(decoded, trace)
+	}
+	assert.Equal(traces, decoded)
+	assert.Equal(0, dec.Remaining())
+}
+
+func TestStreamDecoderSpanLimit(t *testing.T) {
+	assert := assert.New(t)
+	traces := Traces{
+		{{TraceID: 1, SpanID: 1}, {TraceID: 1, SpanID: 2}},
+		{{TraceID: 2, SpanID: 3}, {TraceID: 2, SpanID: 4}},
+		{{TraceID: 3, SpanID: 5}},
+	}
+
+	dec, err := NewStreamDecoder(bytes.NewReader(encodeTraces(t, traces)), 3)
+	require.NoError(t, err)
+
+	trace, err := dec.Next()
+	assert.NoError(err)
+	assert.Equal(traces[0], trace)
+
+	// the second trace would exceed the limit: it and the following ones are dropped
+	_, err = dec.Next()
+	assert.Equal(ErrSpanLimitReached, err)
+	assert.Equal(2, dec.Remaining())
+}
+
+func TestStreamDecoderOversizedHeader(t *testing.T) {
+	// a single trace announcing 2^32-1 spans, without the spans
+	data := []byte{0x91, 0xdd, 0xff, 0xff, 0xff, 0xff}
+
+	dec, err := NewStreamDecoder(bytes.NewReader(data), 1000)
+	require.NoError(t, err)
+	_, err = dec.Next()
+	assert.Equal(t, ErrSpanLimitReached, err)
+
+	// without limit, the spans are not preallocated and decoding fails
+	dec, err = NewStreamDecoder(bytes.NewReader(data), 0)
+	require.NoError(t, err)
+	_, err = dec.Next()
+	assert.Error(t, err)
+	assert.Equal(t, 1, dec.Remaining())
+}
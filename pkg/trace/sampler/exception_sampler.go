@@ -1,16 +1,20 @@
 package sampler
 
 import (
+	"encoding/binary"
+	"hash/fnv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"golang.org/x/time/rate"
 )
 
+// The defaults of the settings left unset in the configuration, see config.RareSamplerConfig.
 const (
 	// cardinalityLimit limits the number of spans considered per combination of (env, service).
 	cardinalityLimit = 1000
@@ -43,15 +47,44 @@ type ExceptionSampler struct {
 	tickStats *time.Ticker
 	limiter   *rate.Limiter
 	seen      map[Signature]*seenSpans
+
+	ttl              time.Duration
+	priorityTTL      time.Duration
+	cardinalityLimit int
+	tags             []string
 }
 
 // NewExceptionSampler returns a NewExceptionSampler that ensures that we sample combinations
-// of env, service, name, resource, http-status, error type for each top level or measured spans
-func NewExceptionSampler() *ExceptionSampler {
+// of env, service, name, resource, http-status, error type and the configured tags for each
+// top level or measured spans. A nil conf or its zero values stand for the defaults.
+func NewExceptionSampler(conf *config.RareSamplerConfig) *ExceptionSampler {
+	var c config.RareSamplerConfig
+	if conf != nil {
+		c = *conf
+	}
+	if c.TPS <= 0 {
+		c.TPS = exceptionSamplerTPS
+	}
+	if c.Burst <= 0 {
+		c.Burst = exceptionSamplerBurst
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = defaultTTL
+	}
+	if c.PriorityCooldown <= 0 {
+		c.PriorityCooldown = priorityTTL
+	}
+	if c.CardinalityLimit <= 0 {
+		c.CardinalityLimit = cardinalityLimit
+	}
 	e := &ExceptionSampler{
-		limiter:   rate.NewLimiter(exceptionSamplerTPS, exceptionSamplerBurst),
-		seen:      make(map[Signature]*seenSpans),
-		tickStats: time.NewTicker(10 * time.Second),
+		limiter:          rate.NewLimiter(rate.Limit(c.TPS), c.Burst),
+		seen:             make(map[Signature]*seenSpans),
+		tickStats:        time.NewTicker(10 * time.Second),
+		ttl:              c.Cooldown,
+		priorityTTL:      c.PriorityCooldown,
+		cardinalityLimit: c.CardinalityLimit,
+		tags:             c.Tags,
 	}
 	go func() {
 		for range e.tickStats.C {
@@ -80,7 +113,7 @@ func (e *ExceptionSampler) Stop() {
 }
 
 func (e *ExceptionSampler) handlePriorityTrace(now time.Time, env string, t pb.Trace) {
-	expire := now.Add(priorityTTL)
+	expire := now.Add(e.priorityTTL)
 	for _, s := range t {
 		if !traceutil.HasTopLevel(s) && !traceutil.IsMeasured(s) {
 			continue
@@ -91,7 +124,7 @@ func (e *ExceptionSampler) handlePriorityTrace(now time.Time, env string, t pb.T
 
 func (e *ExceptionSampler) handleTrace(now time.Time, env string, t pb.Trace) bool {
 	var sampled bool
-	expire := now.Add(defaultTTL)
+	expire := now.Add(e.ttl)
 	for _, s := range t {
 		if !traceutil.HasTopLevel(s) && !traceutil.IsMeasured(s) {
 			continue
@@ -123,7 +156,7 @@ func (e *ExceptionSampler) sampleSpan(now time.Time, env string, s *pb.Span) boo
 	if now.After(expire) || !ok {
 		sampled = e.limiter.Allow()
 		if sampled {
-			ss.add(now.Add(defaultTTL), s)
+			ss.add(now.Add(e.ttl), s)
 			atomic.AddInt64(&e.hits, 1)
 			traceutil.SetMetric(s, exceptionKey, 1)
 		} else {
@@ -140,7 +173,12 @@ func (e *ExceptionSampler) loadSeenSpans(shardSig Signature) *seenSpans {
 	if ok {
 		return s
 	}
-	s = &seenSpans{expires: make(map[spanHash]time.Time), totalSamplerShrinks: &e.shrinks}
+	s = &seenSpans{
+		expires:             make(map[spanHash]time.Time),
+		cardinalityLimit:    e.cardinalityLimit,
+		tags:                e.tags,
+		totalSamplerShrinks: &e.shrinks,
+	}
 	e.mu.Lock()
 	e.seen[shardSig] = s
 	e.mu.Unlock()
@@ -160,6 +198,10 @@ type seenSpans struct {
 	expires map[spanHash]time.Time
 	// shrunk caracterize seenSpans when it's limited in size by capacityLimit.
 	shrunk bool
+	// cardinalityLimit is the maximum number of spans recorded.
+	cardinalityLimit int
+	// tags lists the span tags whose values are part of the span signatures.
+	tags []string
 	// totalSamplerShrinks is the reference to the total number of shrinks reported by ExceptionSampler.
 	totalSamplerShrinks *int64
 }
@@ -176,7 +218,7 @@ func (ss *seenSpans) add(expire time.Time, s *pb.Span) {
 
 	// if cardinality limit reached, shrink
 	size := len(ss.expires)
-	if size > cardinalityLimit {
+	if size > ss.cardinalityLimit {
 		ss.shrink()
 	}
 	ss.mu.Unlock()
@@ -187,9 +229,9 @@ func (ss *seenSpans) add(expire time.Time, s *pb.Span) {
 // all sampling tokens. The cardinality limit matches a backend limit.
 // This function is not thread safe and should be called between locks
 func (ss *seenSpans) shrink() {
-	newExpires := make(map[spanHash]time.Time, cardinalityLimit)
+	newExpires := make(map[spanHash]time.Time, ss.cardinalityLimit)
 	for h, expire := range ss.expires {
-		newExpires[h%spanHash(cardinalityLimit)] = expire
+		newExpires[h%spanHash(ss.cardinalityLimit)] = expire
 	}
 	ss.expires = newExpires
 	ss.shrunk = true
@@ -205,8 +247,28 @@ func (ss *seenSpans) getExpire(h spanHash) (time.Time, bool) {
 
 func (ss *seenSpans) sign(s *pb.Span) spanHash {
 	h := computeSpanHash(s, "", true)
+	if len(ss.tags) > 0 {
+		h = hashTags(h, s, ss.tags)
+	}
 	if ss.shrunk {
-		h = h % spanHash(cardinalityLimit)
+		h = h % spanHash(ss.cardinalityLimit)
 	}
 	return h
 }
+
+// hashTags returns the span hash h combined with the values of the given tags of s.
+func hashTags(h spanHash, s *pb.Span, tags []string) spanHash {
+	f := fnv.New32a()
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(h))
+	f.Write(buf[:])
+	for _, tag := range tags {
+		if v, ok := traceutil.GetMeta(s, tag); ok {
+			f.Write([]byte(tag))
+			f.Write([]byte{'='})
+			f.Write([]byte(v))
+		}
+		f.Write([]byte{0})
+	}
+	return spanHash(f.Sum32())
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,7 +28,7 @@ func TestSpanSeenTTLExpiration(t *testing.T) {
 		{"p0-ttl-expired", true, testTime.Add(priorityTTL + defaultTTL + 2*time.Nanosecond), map[string]float64{"_dd.measured": 1}},
 	}
 
-	e := NewExceptionSampler()
+	e := NewExceptionSampler(nil)
 	e.Stop()
 
 	for _, tc := range testCases {
@@ -56,7 +57,7 @@ func TestConsideredSpans(t *testing.T) {
 		{"p0-non-top-non-measured-blocked", false, "s4", nil},
 	}
 
-	e := NewExceptionSampler()
+	e := NewExceptionSampler(nil)
 	e.Stop()
 
 	for _, tc := range testCases {
@@ -71,7 +72,7 @@ func TestConsideredSpans(t *testing.T) {
 }
 
 func TestExceptionSamplerRace(t *testing.T) {
-	e := NewExceptionSampler()
+	e := NewExceptionSampler(nil)
 	e.Stop()
 	for i := 0; i < 2; i++ {
 		go func() {
@@ -87,7 +88,7 @@ func TestExceptionSamplerRace(t *testing.T) {
 
 func TestCardinalityLimit(t *testing.T) {
 	assert := assert.New(t)
-	e := NewExceptionSampler()
+	e := NewExceptionSampler(nil)
 	e.Stop()
 	for j := 1; j <= cardinalityLimit; j++ {
 		tr := pb.Trace{
@@ -108,3 +109,57 @@ func TestCardinalityLimit(t *testing.T) {
 		assert.True(len(set.expires) <= cardinalityLimit)
 	}
 }
+
+func TestExceptionSamplerConfig(t *testing.T) {
+	assert := assert.New(t)
+	e := NewExceptionSampler(&config.RareSamplerConfig{
+		Cooldown:         time.Minute,
+		PriorityCooldown: 2 * time.Minute,
+		CardinalityLimit: 10,
+	})
+	e.Stop()
+	assert.Equal(time.Minute, e.ttl)
+	assert.Equal(2*time.Minute, e.priorityTTL)
+	assert.Equal(10, e.cardinalityLimit)
+
+	testTime := time.Unix(13829192398, 0)
+	tr := pb.Trace{
+		&pb.Span{Service: "s1", Resource: "r1", Metrics: map[string]float64{"_top_level": 1}},
+	}
+	assert.True(e.add(testTime, "", tr[0], tr))
+	assert.False(e.add(testTime.Add(time.Minute), "", tr[0], tr))
+	assert.True(e.add(testTime.Add(time.Minute+time.Nanosecond), "", tr[0], tr))
+}
+
+func TestExceptionSamplerTags(t *testing.T) {
+	testTime := time.Unix(13829192398, 0)
+	newTrace := func(version string) pb.Trace {
+		return pb.Trace{
+			&pb.Span{
+				Service:  "s1",
+				Resource: "r1",
+				Meta:     map[string]string{"version": version},
+				Metrics:  map[string]float64{"_top_level": 1},
+			},
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assert := assert.New(t)
+		e := NewExceptionSampler(nil)
+		e.Stop()
+		tr1, tr2 := newTrace("v1"), newTrace("v2")
+		assert.True(e.add(testTime, "", tr1[0], tr1))
+		assert.False(e.add(testTime, "", tr2[0], tr2))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		assert := assert.New(t)
+		e := NewExceptionSampler(&config.RareSamplerConfig{Tags: []string{"version"}})
+		e.Stop()
+		tr1, tr2 := newTrace("v1"), newTrace("v2")
+		assert.True(e.add(testTime, "", tr1[0], tr1))
+		assert.True(e.add(testTime, "", tr2[0], tr2))
+		assert.False(e.add(testTime, "", tr1[0], tr1))
+	})
+}
@@ -23,6 +23,8 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
@@ -40,15 +42,37 @@ type PriorityEngine struct {
 	// Sampler is the underlying sampler used by this engine, sharing logic among various engines.
 	Sampler *Sampler
 
+	// targetTPS maps the signatures of the services given an explicit target TPS to
+	// their target, the other services sharing what remains of the max TPS.
+	targetTPS map[Signature]float64
+	// targetBackend counts the traces of the services with a target TPS.
+	targetBackend Backend
+
 	rateByService *RateByService
 	catalog       *serviceKeyCatalog
 	exit          chan struct{}
 }
 
-// NewPriorityEngine returns an initialized Sampler
-func NewPriorityEngine(extraRate float64, maxTPS float64, rateByService *RateByService) *PriorityEngine {
+// NewPriorityEngine returns an initialized Sampler. The services listed in targetTPS are
+// sampled at their own target TPS, the other ones sharing the remainder of maxTPS.
+func NewPriorityEngine(extraRate float64, maxTPS float64, targetTPS map[ServiceSignature]float64, rateByService *RateByService) *PriorityEngine {
+	targets := make(map[Signature]float64, len(targetTPS))
+	var total float64
+	for svcSig, tps := range targetTPS {
+		targets[svcSig.Hash()] = tps
+		total += tps
+	}
+	if maxTPS > 0 && total > 0 {
+		maxTPS -= total
+		if maxTPS < minSignatureScoreOffset {
+			log.Warnf("The target TPS of the services add up to more than the max TPS, the other services will be sampled at the lowest rate")
+			maxTPS = minSignatureScoreOffset
+		}
+	}
 	s := &PriorityEngine{
 		Sampler:       newSampler(extraRate, maxTPS),
+		targetTPS:     targets,
+		targetBackend: NewMemoryBackend(defaultDecayPeriod, defaultDecayFactor),
 		rateByService: rateByService,
 		catalog:       newServiceLookup(),
 		exit:          make(chan struct{}),
@@ -61,13 +85,19 @@ func NewPriorityEngine(extraRate float64, maxTPS float64, rateByService *RateByS
 // Run runs and block on the Sampler main loop
 func (s *PriorityEngine) Run() {
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(3)
 
 	go func() {
 		s.Sampler.Run()
 		wg.Done()
 	}()
 
+	go func() {
+		defer watchdog.LogOnPanic()
+		s.targetBackend.Run()
+		wg.Done()
+	}()
+
 	go func() {
 		t := time.NewTicker(syncPeriod)
 		defer t.Stop()
@@ -89,6 +119,7 @@ func (s *PriorityEngine) Run() {
 // Stop stops the main Run loop
 func (s *PriorityEngine) Stop() {
 	s.Sampler.Stop()
+	s.targetBackend.Stop()
 	close(s.exit)
 }
 
@@ -118,6 +149,17 @@ func (s *PriorityEngine) Sample(trace pb.Trace, root *pb.Span, env string) (samp
 
 	signature := s.catalog.register(ServiceSignature{root.Service, env})
 
+	if target, ok := s.targetTPS[signature]; ok {
+		// the service has its own target, it is kept out of the feedback loop of the max TPS
+		s.targetBackend.CountSignature(signature)
+		rate, ok = root.Metrics[SamplingPriorityRateKey]
+		if !ok || rate > prioritySamplingRateThresholdTo1 {
+			rate = targetRate(target, s.targetBackend.GetSignatureScore(signature))
+			root.Metrics[SamplingPriorityRateKey] = rate
+		}
+		return sampled, rate
+	}
+
 	// Update sampler state by counting this trace
 	s.Sampler.Backend.CountSignature(signature)
 
@@ -146,7 +188,20 @@ func (s *PriorityEngine) GetState() interface{} {
 // ratesByService returns all rates by service, this information is useful for
 // agents to pick the right service rate.
 func (s *PriorityEngine) ratesByService() map[ServiceSignature]float64 {
-	return s.catalog.ratesByService(s.Sampler.GetAllSignatureSampleRates(), s.Sampler.GetDefaultSampleRate())
+	rates := s.Sampler.GetAllSignatureSampleRates()
+	for signature, tps := range s.targetBackend.GetSignatureScores() {
+		rates[signature] = targetRate(s.targetTPS[signature], tps)
+	}
+	return s.catalog.ratesByService(rates, s.Sampler.GetDefaultSampleRate())
+}
+
+// targetRate returns the sample rate to apply to a service receiving tps traces per
+// second to keep target traces per second.
+func targetRate(target, tps float64) float64 {
+	if tps <= target {
+		return 1
+	}
+	return target / tps
 }
 
 // GetType return the type of the sampler engine
@@ -30,7 +30,7 @@ func getTestPriorityEngine() *PriorityEngine {
 	maxTPS := 0.0
 
 	rateByService := RateByService{}
-	return NewPriorityEngine(extraRate, maxTPS, &rateByService)
+	return NewPriorityEngine(extraRate, maxTPS, nil, &rateByService)
 }
 
 func getTestTraceWithService(t *testing.T, service string, s *PriorityEngine) (pb.Trace, *pb.Span) {
@@ -241,3 +241,57 @@ func TestMaxTPSByService(t *testing.T) {
 
 // Ensure PriorityEngine implements engine.
 var testPriorityEngine Engine = &PriorityEngine{}
+
+func TestTargetTPSByService(t *testing.T) {
+	rand.Seed(1)
+	assert := assert.New(t)
+	seelog.UseLogger(seelog.Disabled)
+
+	target := ServiceSignature{testServiceA, defaultEnv}
+	s := NewPriorityEngine(1, 10, map[ServiceSignature]float64{target: 2}, &RateByService{})
+	// the other services share the remainder of the max TPS
+	assert.Equal(8.0, s.Sampler.maxTPS)
+
+	const (
+		tps         = 20.0
+		initPeriods = 50
+		periods     = 500
+	)
+	periodSeconds := defaultDecayPeriod.Seconds()
+	sampledCount := 0
+	handledCount := 0
+	for period := 0; period < initPeriods+periods; period++ {
+		s.targetBackend.(*MemoryBackend).decayScore()
+		for i := 0; i < int(tps*periodSeconds); i++ {
+			trace, root := getTestTraceWithService(t, testServiceA, s)
+			sampled, _ := s.Sample(trace, root, defaultEnv)
+			if period > initPeriods {
+				handledCount++
+				if sampled {
+					sampledCount++
+				}
+			}
+		}
+	}
+
+	// the traces of the service are kept out of the max TPS feedback loop
+	assert.Equal(0.0, s.Sampler.Backend.GetTotalScore())
+	assert.InEpsilon(2.0, float64(sampledCount)/(float64(periods)*periodSeconds), 0.1+defaultDecayFactor-1)
+	assert.InEpsilon(2.0/tps, s.ratesByService()[target], 0.1+defaultDecayFactor-1)
+
+	t.Run("over-max", func(t *testing.T) {
+		s := NewPriorityEngine(1, 10, map[ServiceSignature]float64{target: 20}, &RateByService{})
+		assert.Equal(minSignatureScoreOffset, s.Sampler.maxTPS)
+	})
+
+	t.Run("no-max", func(t *testing.T) {
+		s := NewPriorityEngine(1, 0, map[ServiceSignature]float64{target: 20}, &RateByService{})
+		assert.Equal(0.0, s.Sampler.maxTPS)
+	})
+}
+
+func TestTargetRate(t *testing.T) {
+	assert.Equal(t, 1.0, targetRate(10, 5))
+	assert.Equal(t, 1.0, targetRate(10, 10))
+	assert.Equal(t, 0.25, targetRate(10, 40))
+}
@@ -18,7 +18,9 @@ const (
 )
 
 // SampleByRate tells if a trace (from its ID) with a given rate should be sampled
-// Use Knuth multiplicative hashing to leverage imbalanced traceID generators
+// Use Knuth multiplicative hashing to leverage imbalanced traceID generators.
+// Like the tracers, only the low 64 bits of 128-bit trace IDs are used, so that the
+// decisions agree.
 func SampleByRate(traceID uint64, rate float64) bool {
 	if rate < 1 {
 		return traceID*samplerHasher < uint64(rate*maxTraceIDFloat)
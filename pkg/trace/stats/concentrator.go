@@ -6,6 +6,7 @@
 package stats
 
 import (
+	"math"
 	"runtime"
 	"sort"
 	"sync"
@@ -90,12 +91,17 @@ func (c *Concentrator) Run() {
 
 	log.Debug("Starting concentrator")
 
+	var inWG sync.WaitGroup
 	for i := 0; i < runtime.NumCPU(); i++ {
+		inWG.Add(1)
 		go func() {
+			defer inWG.Done()
 			for {
 				select {
 				case i := <-c.In:
 					c.addNow(i, time.Now().UnixNano())
+				case <-c.exit:
+					return
 				}
 			}
 		}()
@@ -106,13 +112,28 @@ func (c *Concentrator) Run() {
 			c.Out <- c.Flush()
 		case <-c.exit:
 			log.Info("Exiting concentrator, computing remaining stats")
-			c.Out <- c.Flush()
+			inWG.Wait()
+			c.drain()
+			c.Out <- c.flushNow(math.MaxInt64)
+			return
+		}
+	}
+}
+
+// drain adds the inputs still queued, once the senders are stopped.
+func (c *Concentrator) drain() {
+	for {
+		select {
+		case i := <-c.In:
+			c.addNow(i, time.Now().UnixNano())
+		default:
 			return
 		}
 	}
 }
 
-// Stop stops the main Run loop.
+// Stop stops the main Run loop, flushing all the buckets, the current ones
+// included.
 func (c *Concentrator) Stop() {
 	close(c.exit)
 	c.exitWG.Wait()
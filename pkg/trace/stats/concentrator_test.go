@@ -295,6 +295,34 @@ func TestConcentratorStatsTotals(t *testing.T) {
 	assert.Equal(errors, float64(0), "Wrong value for total errors %d", errors)
 }
 
+// TestConcentratorStop tests that the queued inputs and the current buckets
+// are flushed when the concentrator stops.
+func TestConcentratorStop(t *testing.T) {
+	assert := assert.New(t)
+	statsChan := make(chan []Bucket)
+	c := NewConcentrator([]string{}, time.Hour.Nanoseconds(), statsChan)
+
+	trace := pb.Trace{testSpan(1, 0, 50, 0, "A1", "resource1", 0)}
+	traceutil.ComputeTopLevel(trace)
+	c.In <- &Input{
+		Env:   "none",
+		Trace: NewWeightedTrace(trace, traceutil.GetRoot(trace)),
+	}
+
+	c.Start()
+	go c.Stop()
+
+	var hits float64
+	for _, b := range <-statsChan {
+		for key, count := range b.Counts {
+			if key == "query|hits|env:none,resource:resource1,service:A1" {
+				hits += count.Value
+			}
+		}
+	}
+	assert.Equal(float64(1), hits)
+}
+
 // TestConcentratorStatsCounts tests exhaustively each stats bucket, over multiple time buckets.
 func TestConcentratorStatsCounts(t *testing.T) {
 	assert := assert.New(t)
@@ -5,7 +5,12 @@
 
 package traceutil
 
-import "github.com/DataDog/datadog-agent/pkg/trace/pb"
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
 
 const (
 	// This is a special metric, it's 1 if the span is top-level, 0 if not.
@@ -13,6 +18,10 @@ const (
 
 	// measuredKey is a special metric flag that marks a span for trace metrics calculation.
 	measuredKey = "_dd.measured"
+
+	// traceIDHighKey is the tag propagating the high 64 bits of a 128-bit trace ID,
+	// as 16 lowercase hexadecimal digits.
+	traceIDHighKey = "_dd.p.tid"
 )
 
 // HasTopLevel returns true if span is top-level.
@@ -63,3 +72,38 @@ func GetMeta(s *pb.Span, key string) (string, bool) {
 	val, ok := s.Meta[key]
 	return val, ok
 }
+
+// TraceIDHigh returns the high 64 bits of the 128-bit trace ID of the span s, 0 for a
+// 64-bit trace ID. They are read from its TraceIDHigh field or, when unset, from its
+// "_dd.p.tid" tag, which returns an error if it isn't valid.
+func TraceIDHigh(s *pb.Span) (uint64, error) {
+	if s.TraceIDHigh != 0 {
+		return s.TraceIDHigh, nil
+	}
+	v, ok := GetMeta(s, traceIDHighKey)
+	if !ok {
+		return 0, nil
+	}
+	if len(v) != 16 {
+		return 0, fmt.Errorf("%s should have 16 hexadecimal digits: %q", traceIDHighKey, v)
+	}
+	return strconv.ParseUint(v, 16, 64)
+}
+
+// SetTraceIDHigh sets the high 64 bits of the 128-bit trace ID of the span s, in its
+// TraceIDHigh field and its "_dd.p.tid" tag.
+func SetTraceIDHigh(s *pb.Span, high uint64) {
+	s.TraceIDHigh = high
+	SetMeta(s, traceIDHighKey, fmt.Sprintf("%016x", high))
+}
+
+// DeleteTraceIDHigh removes the "_dd.p.tid" tag of the span s.
+func DeleteTraceIDHigh(s *pb.Span) {
+	delete(s.Meta, traceIDHighKey)
+}
+
+// TraceID128 returns the 128-bit trace ID of the span s as 32 lowercase hexadecimal
+// digits, the format of the W3C trace context.
+func TraceID128(s *pb.Span) string {
+	return fmt.Sprintf("%016x%016x", s.TraceIDHigh, s.TraceID)
+}
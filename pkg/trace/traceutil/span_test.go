@@ -183,3 +183,37 @@ func TestIsMeasured(t *testing.T) {
 	span.Metrics = map[string]float64{"_dd.measured": 0}
 	assert.False(IsMeasured(span), "the measured key is present but the value != 1, the span should not be measured")
 }
+
+func TestTraceIDHigh(t *testing.T) {
+	assert := assert.New(t)
+
+	span := &pb.Span{TraceID: 0xe457b5a2e4d86bd1}
+	high, err := TraceIDHigh(span)
+	assert.NoError(err)
+	assert.Equal(uint64(0), high)
+	assert.Equal("0000000000000000e457b5a2e4d86bd1", TraceID128(span))
+
+	span.Meta = map[string]string{"_dd.p.tid": "5af7183fb1d4cf5f"}
+	high, err = TraceIDHigh(span)
+	assert.NoError(err)
+	assert.Equal(uint64(0x5af7183fb1d4cf5f), high)
+
+	for _, tid := range []string{"5af7183fb1d4cf5", "5af7183fb1d4cf5g", "0x5af7183fb1d4cf"} {
+		span.Meta["_dd.p.tid"] = tid
+		_, err = TraceIDHigh(span)
+		assert.Error(err, tid)
+	}
+	DeleteTraceIDHigh(span)
+	assert.NotContains(span.Meta, "_dd.p.tid")
+
+	SetTraceIDHigh(span, 0x0af7183fb1d4cf5f)
+	assert.Equal(uint64(0x0af7183fb1d4cf5f), span.TraceIDHigh)
+	assert.Equal("0af7183fb1d4cf5f", span.Meta["_dd.p.tid"])
+	assert.Equal("0af7183fb1d4cf5fe457b5a2e4d86bd1", TraceID128(span))
+
+	// the field takes precedence over the tag
+	span.Meta["_dd.p.tid"] = "invalid"
+	high, err = TraceIDHigh(span)
+	assert.NoError(err)
+	assert.Equal(uint64(0x0af7183fb1d4cf5f), high)
+}
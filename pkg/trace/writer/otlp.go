@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package writer
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/logutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The types below are the subset of the OTLP metrics export request used to export the stats,
+// in the JSON encoding of OTLP/HTTP. See:
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope     `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Unit    string       `json:"unit,omitempty"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+// otlpTemporalityDelta is the aggregation temporality of the stats: each bucket holds the
+// values of its own time window.
+const otlpTemporalityDelta = 1
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64         `json:"startTimeUnixNano,string"`
+	TimeUnixNano      uint64         `json:"timeUnixNano,string"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpKeyValue      `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64              `json:"startTimeUnixNano,string"`
+	TimeUnixNano      uint64              `json:"timeUnixNano,string"`
+	Count             uint64              `json:"count,string"`
+	Sum               float64             `json:"sum"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpQuantiles are the quantiles of the span durations exported to OTLP.
+var otlpQuantiles = []float64{0.5, 0.75, 0.95, 0.99, 1}
+
+// newOTLPMetricsRequest converts the stats payload into an OTLP metrics export request. The counts
+// are exported as delta sums named trace.<span name>.<measure>, e.g. trace.http.request.hits, and
+// the duration distributions as summaries named trace.<span name>.duration.quantiles, with the tags
+// of their tag sets as attributes.
+func newOTLPMetricsRequest(p *stats.Payload) *otlpMetricsRequest {
+	metrics := make(map[string]*otlpMetric)
+	metric := func(name, unit string, summary bool) *otlpMetric {
+		m, ok := metrics[name]
+		if !ok {
+			m = &otlpMetric{Name: name, Unit: unit}
+			if summary {
+				m.Summary = &otlpSummary{}
+			} else {
+				m.Sum = &otlpSum{AggregationTemporality: otlpTemporalityDelta, IsMonotonic: true}
+			}
+			metrics[name] = m
+		}
+		return m
+	}
+	for _, b := range p.Stats {
+		start, end := uint64(b.Start), uint64(b.Start+b.Duration)
+		for _, c := range b.Counts {
+			unit := "1"
+			if c.Measure == stats.DURATION {
+				unit = "ns"
+			}
+			m := metric("trace."+c.Name+"."+c.Measure, unit, false)
+			m.Sum.DataPoints = append(m.Sum.DataPoints, otlpNumberDataPoint{
+				Attributes:        otlpAttributes(c.TagSet),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				AsDouble:          c.Value,
+			})
+		}
+		for k, d := range b.Distributions {
+			qv := make([]otlpQuantileValue, len(otlpQuantiles))
+			for i, q := range otlpQuantiles {
+				qv[i] = otlpQuantileValue{Quantile: q, Value: d.Summary.Quantile(q)}
+			}
+			m := metric("trace."+d.Name+"."+d.Measure+".quantiles", "ns", true)
+			m.Summary.DataPoints = append(m.Summary.DataPoints, otlpSummaryDataPoint{
+				Attributes:        otlpAttributes(d.TagSet),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				Count:             uint64(d.Summary.N),
+				Sum:               b.Counts[k].Value, // the count of the distribution's measure
+				QuantileValues:    qv,
+			})
+		}
+	}
+	sm := otlpScopeMetrics{
+		Scope:   otlpScope{Name: "datadog-trace-agent", Version: info.Version},
+		Metrics: make([]*otlpMetric, 0, len(metrics)),
+	}
+	for _, m := range metrics {
+		sm.Metrics = append(sm.Metrics, m)
+	}
+	sort.Slice(sm.Metrics, func(i, j int) bool { return sm.Metrics[i].Name < sm.Metrics[j].Name })
+	var res otlpResource
+	if p.HostName != "" {
+		res.Attributes = append(res.Attributes, otlpKeyValue{"host.name", otlpAnyValue{p.HostName}})
+	}
+	if p.Env != "" {
+		res.Attributes = append(res.Attributes, otlpKeyValue{"deployment.environment", otlpAnyValue{p.Env}})
+	}
+	return &otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{Resource: res, ScopeMetrics: []otlpScopeMetrics{sm}}},
+	}
+}
+
+// otlpAttributes returns the tags of the tag set as OTLP attributes.
+func otlpAttributes(tags stats.TagSet) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(tags))
+	for _, t := range tags {
+		attrs = append(attrs, otlpKeyValue{t.Name, otlpAnyValue{t.Value}})
+	}
+	return attrs
+}
+
+// newOTLPSender returns a sender writing to the OTLP metrics endpoint of the configuration. It
+// doesn't use the proxy of the Datadog intake, nor send the API key.
+func newOTLPSender(cfg *config.AgentConfig, qsize int) *sender {
+	client := httputils.NewResetClient(0, func() *http.Client {
+		return &http.Client{Timeout: 10 * time.Second}
+	})
+	return newSender(&senderConfig{
+		client:    client,
+		maxConns:  1,
+		maxQueued: qsize,
+		url:       cfg.OTLPStatsURL,
+		recorder:  &otlpRecorder{easylog: logutil.NewThrottled(5, 10*time.Second)},
+	})
+}
+
+// otlpRecorder records the events of the OTLP sender.
+type otlpRecorder struct {
+	easylog *logutil.ThrottledLogger
+}
+
+var _ eventRecorder = (*otlpRecorder)(nil)
+
+// recordEvent implements eventRecorder.
+func (r *otlpRecorder) recordEvent(t eventType, data *eventData) {
+	switch t {
+	case eventTypeRetry:
+		log.Debugf("Retrying to export stats to OTLP (error: %q)", data.err)
+	case eventTypeSent:
+		log.Debugf("Exported stats to OTLP; time: %s, bytes: %d", data.duration, data.bytes)
+	case eventTypeRejected:
+		r.easylog.Warn("OTLP stats payload rejected: %v", data.err)
+	case eventTypeDropped:
+		r.easylog.Warn("OTLP stats queue full. Payload dropped (%.2fKB).", float64(data.bytes)/1024)
+	}
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package writer
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOTLPMetricsRequest(t *testing.T) {
+	assert := assert.New(t)
+	b := testutil.TestBucket()
+	req := newOTLPMetricsRequest(&stats.Payload{HostName: testHostname, Env: testEnv, Stats: []stats.Bucket{b}})
+
+	assert.Len(req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	assert.Equal([]otlpKeyValue{
+		{"host.name", otlpAnyValue{testHostname}},
+		{"deployment.environment", otlpAnyValue{testEnv}},
+	}, rm.Resource.Attributes)
+	assert.Len(rm.ScopeMetrics, 1)
+	metrics := make(map[string]*otlpMetric)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	for _, c := range b.Counts {
+		m, ok := metrics["trace."+c.Name+"."+c.Measure]
+		if !assert.True(ok, c.Key) || !assert.NotNil(m.Sum) {
+			continue
+		}
+		assert.Equal(otlpTemporalityDelta, m.Sum.AggregationTemporality)
+		assert.True(m.Sum.IsMonotonic)
+		assert.Contains(m.Sum.DataPoints, otlpNumberDataPoint{
+			Attributes:        otlpAttributes(c.TagSet),
+			StartTimeUnixNano: uint64(b.Start),
+			TimeUnixNano:      uint64(b.Start + b.Duration),
+			AsDouble:          c.Value,
+		})
+	}
+	assert.NotEmpty(b.Distributions)
+	for k, d := range b.Distributions {
+		m, ok := metrics["trace."+d.Name+".duration.quantiles"]
+		if !assert.True(ok, k) || !assert.NotNil(m.Summary) {
+			continue
+		}
+		assert.Equal("ns", m.Unit)
+		var found bool
+		for _, dp := range m.Summary.DataPoints {
+			if reflect.DeepEqual(otlpAttributes(d.TagSet), dp.Attributes) {
+				found = true
+				assert.Equal(uint64(d.Summary.N), dp.Count)
+				assert.Equal(b.Counts[k].Value, dp.Sum)
+				assert.Len(dp.QuantileValues, len(otlpQuantiles))
+				assert.Equal(d.Summary.Quantile(1), dp.QuantileValues[len(otlpQuantiles)-1].Value)
+			}
+		}
+		assert.True(found, k)
+	}
+
+	// 64-bit integers are encoded as strings in OTLP JSON
+	js, err := json.Marshal(otlpNumberDataPoint{StartTimeUnixNano: 1, TimeUnixNano: 2, AsDouble: 3})
+	assert.NoError(err)
+	assert.JSONEq(`{"startTimeUnixNano":"1","timeUnixNano":"2","asDouble":3}`, string(js))
+}
+
+func TestStatsWriterOTLP(t *testing.T) {
+	assert := assert.New(t)
+	srv := newTestServer()
+	defer srv.Close()
+	otlpSrv := newTestServer()
+	defer otlpSrv.Close()
+	u, err := url.Parse(otlpSrv.URL + "/v1/metrics")
+	assert.NoError(err)
+	in := make(chan []stats.Bucket)
+	sw := NewStatsWriter(&config.AgentConfig{
+		Hostname:     testHostname,
+		DefaultEnv:   testEnv,
+		Endpoints:    []*config.Endpoint{{Host: srv.URL, APIKey: "123"}},
+		StatsWriter:  &config.WriterConfig{ConnectionLimit: 20, QueueSize: 20},
+		OTLPStatsURL: u,
+	}, in)
+	go sw.Run()
+
+	in <- []stats.Bucket{testutil.TestBucket()}
+	sw.Stop()
+
+	assert.Len(srv.Payloads(), 1)
+	payloads := otlpSrv.Payloads()
+	if !assert.Len(payloads, 1) {
+		return
+	}
+	assert.Equal("application/json", payloads[0].headers["Content-Type"])
+	assert.NotContains(payloads[0].headers, "Dd-Api-Key")
+	var req otlpMetricsRequest
+	assert.NoError(json.Unmarshal(payloads[0].body.Bytes(), &req))
+	assert.Len(req.ResourceMetrics, 1)
+	assert.NotEmpty(req.ResourceMetrics[0].ScopeMetrics[0].Metrics)
+}
@@ -115,7 +115,7 @@ type senderConfig struct {
 	client *httputils.ResetClient
 	// url specifies the URL to send requests too.
 	url *url.URL
-	// apiKey specifies the Datadog API key to use.
+	// apiKey specifies the Datadog API key to use, if any.
 	apiKey string
 	// maxConns specifies the maximum number of allowed concurrent ougoing
 	// connections.
@@ -307,7 +307,9 @@ const (
 )
 
 func (s *sender) do(req *http.Request) error {
-	req.Header.Set(headerAPIKey, s.cfg.apiKey)
+	if s.cfg.apiKey != "" {
+		req.Header.Set(headerAPIKey, s.cfg.apiKey)
+	}
 	req.Header.Set(headerUserAgent, userAgent)
 	resp, err := s.cfg.client.Do(req)
 	if err != nil {
@@ -6,6 +6,7 @@
 package writer
 
 import (
+	"encoding/json"
 	"math"
 	"strings"
 	"sync/atomic"
@@ -40,6 +41,7 @@ type StatsWriter struct {
 	hostname string
 	env      string
 	senders  []*sender
+	otlp     *sender // nil unless the stats are exported to OTLP
 	stop     chan struct{}
 	stats    *info.StatsWriterInfo
 
@@ -74,6 +76,10 @@ func NewStatsWriter(cfg *config.AgentConfig, in <-chan []stats.Bucket) *StatsWri
 	}
 	log.Debugf("Stats writer initialized (climit=%d qsize=%d)", climit, qsize)
 	sw.senders = newSenders(cfg, sw, pathStats, climit, qsize)
+	if cfg.OTLPStatsURL != nil {
+		log.Infof("Exporting stats to OTLP endpoint %s", cfg.OTLPStatsURL)
+		sw.otlp = newOTLPSender(cfg, qsize)
+	}
 	return sw
 }
 
@@ -99,6 +105,9 @@ func (w *StatsWriter) Stop() {
 	w.stop <- struct{}{}
 	<-w.stop
 	stopSenders(w.senders)
+	if w.otlp != nil {
+		w.otlp.Stop()
+	}
 }
 
 func (w *StatsWriter) addStats(s []stats.Bucket) {
@@ -114,6 +123,10 @@ func (w *StatsWriter) addStats(s []stats.Bucket) {
 	atomic.AddInt64(&w.stats.StatsBuckets, int64(bucketCount))
 	log.Debugf("Flushing %d entries (buckets=%d payloads=%v)", entryCount, bucketCount, len(payloads))
 
+	if w.otlp != nil {
+		w.exportOTLP(s)
+	}
+
 	for _, p := range payloads {
 		req := newPayload(map[string]string{
 			headerLanguages:    strings.Join(info.Languages(), "|"),
@@ -130,6 +143,18 @@ func (w *StatsWriter) addStats(s []stats.Bucket) {
 	}
 }
 
+// exportOTLP sends the stats buckets to the OTLP metrics endpoint, as JSON.
+func (w *StatsWriter) exportOTLP(s []stats.Bucket) {
+	req := newPayload(map[string]string{"Content-Type": "application/json"})
+	p := &stats.Payload{HostName: w.hostname, Env: w.env, Stats: s}
+	if err := json.NewEncoder(req.body).Encode(newOTLPMetricsRequest(p)); err != nil {
+		log.Errorf("OTLP stats encoding error: %v", err)
+		ppool.Put(req)
+		return
+	}
+	w.otlp.Push(req)
+}
+
 // buildPayloads returns a set of payload to send out, each paylods guaranteed
 // to have the number of stats buckets under the given maximum.
 func (w *StatsWriter) buildPayloads(s []stats.Bucket, maxEntriesPerPayloads int) ([]*stats.Payload, int, int) {
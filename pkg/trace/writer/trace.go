@@ -7,6 +7,7 @@ package writer
 
 import (
 	"compress/gzip"
+	"encoding/json"
 	"math"
 	"strings"
 	"sync"
@@ -19,8 +20,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/payloadaudit"
 
 	"github.com/gogo/protobuf/proto"
 )
@@ -59,6 +62,7 @@ type TraceWriter struct {
 	hostname string
 	env      string
 	senders  []*sender
+	groups   []senderGroup // senders grouped by sample rate
 	stop     chan struct{}
 	stats    *info.TraceWriterInfo
 	wg       sync.WaitGroup // waits for gzippers
@@ -103,9 +107,37 @@ func NewTraceWriter(cfg *config.AgentConfig, in <-chan *SampledSpans) *TraceWrit
 	}
 	log.Debugf("Trace writer initialized (climit=%d qsize=%d)", climit, qsize)
 	tw.senders = newSenders(cfg, tw, pathTraces, climit, qsize)
+	tw.groups = groupSenders(cfg.Endpoints, tw.senders)
 	return tw
 }
 
+// senderGroup is a group of senders which are sent the same sample of the traces.
+type senderGroup struct {
+	rate    float64 // sample rate of the traces, 1 for all of them
+	senders []*sender
+}
+
+// groupSenders groups the senders of the given endpoints by the sample rate of their traces,
+// senders[i] sending to endpoints[i]. The group of the main endpoint comes first.
+func groupSenders(endpoints []*config.Endpoint, senders []*sender) []senderGroup {
+	var groups []senderGroup
+outer:
+	for i, e := range endpoints {
+		rate := e.SampleRate
+		if rate <= 0 || rate > 1 {
+			rate = 1
+		}
+		for j := range groups {
+			if groups[j].rate == rate {
+				groups[j].senders = append(groups[j].senders, senders[i])
+				continue outer
+			}
+		}
+		groups = append(groups, senderGroup{rate: rate, senders: []*sender{senders[i]}})
+	}
+	return groups
+}
+
 // Stop stops the TraceWriter and attempts to flush whatever is left in the senders buffers.
 func (w *TraceWriter) Stop() {
 	log.Debug("Exiting trace writer. Trying to flush whatever is left...")
@@ -187,20 +219,61 @@ func (w *TraceWriter) flush() {
 	defer w.resetBuffer()
 
 	log.Debugf("Serializing %d traces and %d APM events.", len(w.traces), len(w.events))
-	tracePayload := pb.TracePayload{
+	tracePayload := &pb.TracePayload{
 		HostName:     w.hostname,
 		Env:          w.env,
 		Traces:       w.traces,
 		Transactions: w.events,
 	}
-	b, err := proto.Marshal(&tracePayload)
+	if payloadaudit.Enabled() {
+		// the protobuf payload isn't readable, record its JSON equivalent instead
+		if jb, err := json.Marshal(tracePayload); err == nil {
+			payloadaudit.Record(payloadaudit.KindTraces, jb)
+		}
+	}
+	atomic.AddInt64(&w.stats.BytesEstimated, int64(w.bufferedSize))
+
+	for _, g := range w.groups {
+		p := tracePayload
+		if g.rate < 1 {
+			p = samplePayload(tracePayload, g.rate)
+			if len(p.Traces) == 0 && len(p.Transactions) == 0 {
+				continue
+			}
+		}
+		w.send(p, g.senders)
+	}
+}
+
+// samplePayload returns a payload holding the traces and APM events of p whose trace ID
+// is sampled at the given rate.
+func samplePayload(p *pb.TracePayload, rate float64) *pb.TracePayload {
+	sp := &pb.TracePayload{
+		HostName: p.HostName,
+		Env:      p.Env,
+	}
+	for _, t := range p.Traces {
+		if sampler.SampleByRate(t.TraceID, rate) {
+			sp.Traces = append(sp.Traces, t)
+		}
+	}
+	for _, e := range p.Transactions {
+		if sampler.SampleByRate(e.TraceID, rate) {
+			sp.Transactions = append(sp.Transactions, e)
+		}
+	}
+	return sp
+}
+
+// send serializes and compresses the trace payload, sending it to the given senders.
+func (w *TraceWriter) send(tracePayload *pb.TracePayload, senders []*sender) {
+	b, err := proto.Marshal(tracePayload)
 	if err != nil {
 		log.Errorf("Failed to serialize payload, data dropped: %v", err)
 		return
 	}
 
 	atomic.AddInt64(&w.stats.BytesUncompressed, int64(len(b)))
-	atomic.AddInt64(&w.stats.BytesEstimated, int64(w.bufferedSize))
 
 	w.wg.Add(1)
 	go func() {
@@ -225,7 +298,7 @@ func (w *TraceWriter) flush() {
 			log.Errorf("Error closing gzip stream when writing trace payload: %v", err)
 		}
 
-		sendPayloads(w.senders, p)
+		sendPayloads(senders, p)
 	}()
 }
 
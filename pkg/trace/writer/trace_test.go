@@ -9,11 +9,13 @@ import (
 	"compress/gzip"
 	"io/ioutil"
 	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
@@ -103,6 +105,64 @@ func TestTraceWriterMultipleEndpointsConcurrent(t *testing.T) {
 	payloadsContain(t, srv.Payloads(), testSpans)
 }
 
+func TestTraceWriterSampleRate(t *testing.T) {
+	assert := assert.New(t)
+	main, secondary := newTestServer(), newTestServer()
+	cfg := &config.AgentConfig{
+		Hostname:   testHostname,
+		DefaultEnv: testEnv,
+		Endpoints: []*config.Endpoint{
+			{APIKey: "123", Host: main.URL},
+			{APIKey: "456", Host: secondary.URL, SampleRate: 0.5},
+		},
+		TraceWriter: &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+	}
+	var testSpans, sampledSpans []*SampledSpans
+	for i := 0; i < 50; i++ {
+		ss := randomSampledSpans(2, 1)
+		testSpans = append(testSpans, ss)
+		if sampler.SampleByRate(ss.Trace[0].TraceID, 0.5) {
+			sampledSpans = append(sampledSpans, ss)
+		}
+	}
+	assert.NotEmpty(sampledSpans)
+	assert.True(len(sampledSpans) < len(testSpans))
+
+	in := make(chan *SampledSpans)
+	tw := NewTraceWriter(cfg, in)
+	assert.Len(tw.groups, 2)
+	go tw.Run()
+	for _, ss := range testSpans {
+		in <- ss
+	}
+	tw.Stop()
+
+	payloadsContain(t, main.Payloads(), testSpans)
+	sampled := mergePayloads(t, secondary.Payloads())
+	assert.Len(sampled.Traces, len(sampledSpans))
+	assert.Len(sampled.Transactions, len(sampledSpans))
+	for i, ss := range sampledSpans {
+		assert.Equal(([]*pb.Span)(ss.Trace), sampled.Traces[i].Spans)
+	}
+}
+
+func TestGroupSenders(t *testing.T) {
+	senders := make([]*sender, 4)
+	for i := range senders {
+		senders[i] = &sender{cfg: &senderConfig{apiKey: strconv.Itoa(i)}}
+	}
+	groups := groupSenders([]*config.Endpoint{
+		{Host: "main"},
+		{Host: "a", SampleRate: 0.1},
+		{Host: "b"},
+		{Host: "c", SampleRate: 0.1},
+	}, senders)
+	assert.Equal(t, []senderGroup{
+		{rate: 1, senders: []*sender{senders[0], senders[2]}},
+		{rate: 0.1, senders: []*sender{senders[1], senders[3]}},
+	}, groups)
+}
+
 // useFlushThreshold sets n as the number of bytes to be used as the flush threshold
 // and returns a function to restore it.
 func useFlushThreshold(n int) func() {
@@ -124,21 +184,7 @@ func randomSampledSpans(spans, events int) *SampledSpans {
 // payloadsContain checks that the given payloads contain the given set of sampled spans.
 func payloadsContain(t *testing.T, payloads []*payload, sampledSpans []*SampledSpans) {
 	t.Helper()
-	var all pb.TracePayload
-	for _, p := range payloads {
-		assert := assert.New(t)
-		gzipr, err := gzip.NewReader(p.body)
-		assert.NoError(err)
-		slurp, err := ioutil.ReadAll(gzipr)
-		assert.NoError(err)
-		var payload pb.TracePayload
-		err = proto.Unmarshal(slurp, &payload)
-		assert.NoError(err)
-		assert.Equal(payload.HostName, testHostname)
-		assert.Equal(payload.Env, testEnv)
-		all.Traces = append(all.Traces, payload.Traces...)
-		all.Transactions = append(all.Transactions, payload.Transactions...)
-	}
+	all := mergePayloads(t, payloads)
 	for _, ss := range sampledSpans {
 		var found bool
 		for _, trace := range all.Traces {
@@ -155,3 +201,24 @@ func payloadsContain(t *testing.T, payloads []*payload, sampledSpans []*SampledS
 		}
 	}
 }
+
+// mergePayloads decodes the given payloads, returning the concatenation of their traces and events.
+func mergePayloads(t *testing.T, payloads []*payload) *pb.TracePayload {
+	t.Helper()
+	var all pb.TracePayload
+	for _, p := range payloads {
+		assert := assert.New(t)
+		gzipr, err := gzip.NewReader(p.body)
+		assert.NoError(err)
+		slurp, err := ioutil.ReadAll(gzipr)
+		assert.NoError(err)
+		var payload pb.TracePayload
+		err = proto.Unmarshal(slurp, &payload)
+		assert.NoError(err)
+		assert.Equal(payload.HostName, testHostname)
+		assert.Equal(payload.Env, testEnv)
+		all.Traces = append(all.Traces, payload.Traces...)
+		all.Transactions = append(all.Transactions, payload.Transactions...)
+	}
+	return &all
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+// NewUpdaterFromConfig returns an updater configured with the updater
+// settings, or nil when it's disabled
+func NewUpdaterFromConfig() (*Updater, error) {
+	if !config.Datadog.GetBool("updater.enabled") {
+		return nil, nil
+	}
+
+	return NewUpdater(Options{
+		StagingDir:         filepath.Join(config.Datadog.GetString("run_path"), "updater"),
+		MaintenanceWindow:  config.Datadog.GetString("updater.maintenance_window"),
+		HealthCheckTimeout: config.Datadog.GetDuration("updater.health_check_timeout"),
+		HTTPClient: &http.Client{
+			Timeout:   10 * time.Minute,
+			Transport: httputils.CreateHTTPTransport(),
+		},
+	})
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package updater
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const (
+	dpkgInfoFile = "/var/lib/dpkg/info/datadog-agent.list"
+	// the installation runs in its own unit, so it isn't stopped along with
+	// the agent when the package restarts the service
+	installUnit = "datadog-agent-install"
+)
+
+// systemdInstaller installs the package with the package manager that
+// installed the agent, in a transient systemd unit. The scripts of the
+// package restart the agent.
+type systemdInstaller struct {
+	deb bool
+}
+
+func newInstaller() (installer, error) {
+	if _, err := exec.LookPath("systemd-run"); err != nil {
+		return nil, fmt.Errorf("the updater requires systemd: %v", err)
+	}
+	_, err := os.Stat(dpkgInfoFile)
+	return &systemdInstaller{deb: err == nil}, nil
+}
+
+func (i *systemdInstaller) kind() string {
+	if i.deb {
+		return "deb"
+	}
+	return "rpm"
+}
+
+func (i *systemdInstaller) install(path string) error {
+	args := []string{"--unit", installUnit, "--collect", "--no-block", "--"}
+	if i.deb {
+		args = append(args, "dpkg", "-i", path)
+	} else {
+		// --oldpackage allows the rollbacks
+		args = append(args, "rpm", "-U", "--oldpackage", path)
+	}
+	if out, err := exec.Command("systemd-run", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not start the installation: %v: %s", err, out)
+	}
+	return nil
+}
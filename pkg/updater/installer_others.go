@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux,!windows
+
+package updater
+
+import (
+	"errors"
+)
+
+func newInstaller() (installer, error) {
+	return nil, errors.New("the updater isn't supported on this platform")
+}
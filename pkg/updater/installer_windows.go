@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build windows
+
+package updater
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// msiInstaller starts an unattended installation of the package, which stops
+// and restarts the agent service
+type msiInstaller struct{}
+
+func newInstaller() (installer, error) {
+	return &msiInstaller{}, nil
+}
+
+func (i *msiInstaller) kind() string {
+	return "msi"
+}
+
+func (i *msiInstaller) install(path string) error {
+	cmd := exec.Command("msiexec", "/i", path, "/qn", "/norestart")
+	// msiexec must outlive the agent service it stops
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
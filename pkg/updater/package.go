@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var versionRx = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.~+-]*$`)
+
+// catalog is distributed by the remote configuration. It lists the packages
+// of the agent versions, including the one to upgrade to and the one running,
+// which is kept to roll back.
+type catalog struct {
+	Target string `json:"target"`
+	// MaintenanceWindow overrides updater.maintenance_window
+	MaintenanceWindow string                        `json:"maintenance_window,omitempty"`
+	Releases          map[string]map[string]pkgInfo `json:"releases"`
+}
+
+// pkgInfo describes the package of a version for a package kind
+type pkgInfo struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// stagedPackage is a package downloaded and verified, ready to be installed
+type stagedPackage struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// packageFor returns the package of a version for a package kind
+func (c *catalog) packageFor(version, kind string) (pkgInfo, error) {
+	packages, found := c.Releases[version]
+	if !found {
+		return pkgInfo{}, fmt.Errorf("version %s is not in the catalog", version)
+	}
+	info, found := packages[kind]
+	if !found {
+		return pkgInfo{}, fmt.Errorf("version %s has no %s package", version, kind)
+	}
+	return info, nil
+}
+
+// downloadPackage downloads a package in dir and verifies its size and hash.
+// Already downloaded packages are only verified.
+func downloadPackage(client *http.Client, dir, version, kind string, info pkgInfo) (*stagedPackage, error) {
+	if !versionRx.MatchString(version) {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	staged := &stagedPackage{
+		Version: version,
+		Path:    filepath.Join(dir, fmt.Sprintf("datadog-agent_%s.%s", version, kind)),
+	}
+	if err := verifyPackage(staged.Path, info); err == nil {
+		return staged, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(info.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s downloading %s", resp.Status, info.URL)
+	}
+
+	partial := staged.Path + ".part"
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// read one more byte than expected to detect larger packages
+	_, err = io.Copy(f, io.LimitReader(resp.Body, info.Size+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = verifyPackage(partial, info)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+	return staged, os.Rename(partial, staged.Path)
+}
+
+// verifyPackage checks the size and the sha256 hash of a package
+func verifyPackage(path string, info pkgInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if n != info.Size {
+		return fmt.Errorf("package %s has a size of %d, %d expected", path, n, info.Size)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != info.SHA256 {
+		return fmt.Errorf("package %s doesn't match its sha256 hash", path)
+	}
+	return nil
+}
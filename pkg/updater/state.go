@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFileName = "state.json"
+
+// state is stored in the staging directory, it survives the restart of the
+// agent by the installation of a package
+type state struct {
+	// Pending is the installation handed over to the service manager, checked
+	// by the next agent
+	Pending *pendingInstall `json:"pending,omitempty"`
+	// FailedVersions aren't installed again
+	FailedVersions []string `json:"failed_versions,omitempty"`
+}
+
+// pendingInstall is an upgrade, or the rollback of a failed upgrade
+type pendingInstall struct {
+	Package stagedPackage `json:"package"`
+	// Rollback is the package of the version running before the upgrade,
+	// nil when this installation is a rollback
+	Rollback *stagedPackage `json:"rollback,omitempty"`
+	Started  time.Time      `json:"started"`
+}
+
+func (s *state) failed(version string) bool {
+	for _, v := range s.FailedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *state) markFailed(version string) {
+	if !s.failed(version) {
+		s.FailedVersions = append(s.FailedVersions, version)
+	}
+}
+
+func loadState(dir string) (state, error) {
+	var s state
+	content, err := ioutil.ReadFile(filepath.Join(dir, stateFileName))
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(content, &s)
+}
+
+func saveState(dir string, s state) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// write then rename, the state must never be partially written
+	tmp := filepath.Join(dir, stateFileName+".tmp")
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, stateFileName))
+}
@@ -0,0 +1,356 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package updater upgrades the agent to the version requested through the
+// remote configuration. The package of the new version is downloaded and
+// verified ahead of time, then handed over to the service manager during the
+// maintenance window. The agent restarted by the installation checks its
+// health, and installs the package of the previous version if it stays
+// unhealthy.
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	defaultCheckInterval       = time.Minute
+	defaultHealthCheckTimeout  = 5 * time.Minute
+	defaultHealthCheckInterval = 5 * time.Second
+)
+
+// installer hands a package over to the service manager, which installs it
+// and restarts the agent
+type installer interface {
+	// kind is the kind of package to install, e.g. "deb"
+	kind() string
+	install(path string) error
+}
+
+// Options configures an Updater
+type Options struct {
+	// StagingDir holds the downloaded packages and the state of the updater
+	StagingDir string
+	// MaintenanceWindow is when packages are installed, e.g. "Sat,Sun 02:00-04:00".
+	// Nothing is installed when neither it nor the catalog define a window.
+	MaintenanceWindow string
+	// HealthCheckTimeout is how long the upgraded agent has to become
+	// healthy before it's rolled back
+	HealthCheckTimeout time.Duration
+	CheckInterval      time.Duration
+	HTTPClient         *http.Client
+}
+
+// Updater upgrades the agent to the target version of the catalog
+type Updater struct {
+	opts                Options
+	installer           installer
+	currentVersion      string
+	now                 func() time.Time
+	ready               func() (health.Status, error)
+	healthCheckInterval time.Duration
+
+	mu      sync.Mutex
+	catalog *catalog
+	// staged packages of the current catalog, by version
+	staged map[string]*stagedPackage
+	state  state
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewUpdater returns an updater using the service manager of the platform
+func NewUpdater(opts Options) (*Updater, error) {
+	i, err := newInstaller()
+	if err != nil {
+		return nil, err
+	}
+	return newUpdater(opts, i)
+}
+
+func newUpdater(opts Options, i installer) (*Updater, error) {
+	if opts.MaintenanceWindow != "" {
+		if _, err := parseMaintenanceWindow(opts.MaintenanceWindow); err != nil {
+			return nil, err
+		}
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = defaultCheckInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Minute}
+	}
+
+	s, err := loadState(opts.StagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the updater state: %v", err)
+	}
+
+	return &Updater{
+		opts:                opts,
+		installer:           i,
+		currentVersion:      version.AgentVersion,
+		now:                 time.Now,
+		ready:               health.GetReadyNonBlocking,
+		healthCheckInterval: defaultHealthCheckInterval,
+		staged:              make(map[string]*stagedPackage),
+		state:               s,
+		stop:                make(chan struct{}),
+	}, nil
+}
+
+// OnCatalog receives the catalog from the remote configuration
+func (u *Updater) OnCatalog(files map[string][]byte) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var c *catalog
+	for _, path := range paths {
+		var parsed catalog
+		if err := json.Unmarshal(files[path], &parsed); err != nil {
+			log.Errorf("Ignoring the invalid agent update catalog %s: %v", path, err)
+			continue
+		}
+		if c != nil {
+			log.Warnf("Ignoring the agent update catalog %s, only one catalog is supported", path)
+			continue
+		}
+		c = &parsed
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.catalog = c
+	u.staged = make(map[string]*stagedPackage)
+}
+
+// Start checks the installation that restarted the agent, if any, then
+// upgrades the agent when the catalog requires it
+func (u *Updater) Start() {
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		u.checkPending()
+
+		ticker := time.NewTicker(u.opts.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := u.upgrade(); err != nil {
+					log.Errorf("Could not upgrade the agent: %v", err)
+				}
+			case <-u.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the updater
+func (u *Updater) Stop() {
+	close(u.stop)
+	u.wg.Wait()
+}
+
+// upgrade stages the packages of the target and of the current version, then
+// installs the target during the maintenance window
+func (u *Updater) upgrade() error {
+	u.mu.Lock()
+	c, pending := u.catalog, u.state.Pending
+	if pending != nil && u.now().Sub(pending.Started) > u.opts.HealthCheckTimeout {
+		// the service manager didn't restart the agent
+		log.Errorf("Agent %s wasn't installed in time, it won't be installed again", pending.Package.Version)
+		u.state.markFailed(pending.Package.Version)
+		u.state.Pending = nil
+		u.saveState()
+		pending = nil
+	}
+	u.mu.Unlock()
+
+	if c == nil || c.Target == "" || c.Target == u.currentVersion || pending != nil {
+		return nil
+	}
+	if u.failed(c.Target) {
+		log.Debugf("Agent %s failed to install, not upgrading", c.Target)
+		return nil
+	}
+
+	window := c.MaintenanceWindow
+	if window == "" {
+		window = u.opts.MaintenanceWindow
+	}
+	if window == "" {
+		log.Debugf("No maintenance window defined, not upgrading to agent %s", c.Target)
+		return nil
+	}
+	w, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return err
+	}
+
+	// the packages are staged ahead of the maintenance window
+	target, err := u.stage(c, c.Target)
+	if err != nil {
+		return fmt.Errorf("could not stage agent %s: %v", c.Target, err)
+	}
+	rollback, err := u.stage(c, u.currentVersion)
+	if err != nil {
+		return fmt.Errorf("could not stage the package of the running agent %s, required to roll back: %v", u.currentVersion, err)
+	}
+	if !w.contains(u.now()) {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.install(&pendingInstall{Package: *target, Rollback: rollback, Started: u.now()})
+}
+
+func (u *Updater) failed(version string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.state.failed(version)
+}
+
+// stage downloads and verifies the package of a version, once per catalog
+func (u *Updater) stage(c *catalog, version string) (*stagedPackage, error) {
+	u.mu.Lock()
+	staged, found := u.staged[version]
+	u.mu.Unlock()
+	if found {
+		return staged, nil
+	}
+
+	info, err := c.packageFor(version, u.installer.kind())
+	if err != nil {
+		return nil, err
+	}
+	staged, err = downloadPackage(u.opts.HTTPClient, u.opts.StagingDir, version, u.installer.kind(), info)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.catalog == c {
+		u.staged[version] = staged
+	}
+	return staged, nil
+}
+
+// install saves the pending installation then hands the package over to the
+// service manager, the lock must be held
+func (u *Updater) install(p *pendingInstall) error {
+	u.state.Pending = p
+	if err := saveState(u.opts.StagingDir, u.state); err != nil {
+		u.state.Pending = nil
+		return fmt.Errorf("could not save the updater state: %v", err)
+	}
+
+	log.Infof("Installing agent %s", p.Package.Version)
+	if err := u.installer.install(p.Package.Path); err != nil {
+		u.state.Pending = nil
+		u.saveState()
+		return err
+	}
+	return nil
+}
+
+// saveState logs the errors, the lock must be held
+func (u *Updater) saveState() {
+	if err := saveState(u.opts.StagingDir, u.state); err != nil {
+		log.Errorf("Could not save the updater state: %v", err)
+	}
+}
+
+// checkPending checks the installation handed over before the agent
+// restarted. An upgraded agent that doesn't become healthy is rolled back.
+func (u *Updater) checkPending() {
+	u.mu.Lock()
+	pending := u.state.Pending
+	u.mu.Unlock()
+	if pending == nil {
+		return
+	}
+
+	healthy := false
+	if u.currentVersion == pending.Package.Version {
+		var stopped bool
+		if healthy, stopped = u.waitHealthy(); stopped {
+			// checked again by the next agent
+			return
+		}
+	} else {
+		log.Errorf("Agent %s wasn't installed, agent %s is running", pending.Package.Version, u.currentVersion)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.state.Pending = nil
+
+	switch {
+	case healthy:
+		log.Infof("Agent %s installed successfully", pending.Package.Version)
+		os.Remove(pending.Package.Path)
+		if pending.Rollback != nil {
+			os.Remove(pending.Rollback.Path)
+		}
+	case pending.Rollback == nil:
+		log.Errorf("Agent %s is unhealthy after a rollback", u.currentVersion)
+	default:
+		u.state.markFailed(pending.Package.Version)
+		if u.currentVersion != pending.Package.Version {
+			break
+		}
+		log.Errorf("Agent %s is unhealthy, rolling back to agent %s", u.currentVersion, pending.Rollback.Version)
+		if err := u.install(&pendingInstall{Package: *pending.Rollback, Started: u.now()}); err != nil {
+			log.Errorf("Could not roll back to agent %s: %v", pending.Rollback.Version, err)
+		}
+		return
+	}
+	u.saveState()
+}
+
+// waitHealthy waits until the agent is healthy or the health check timeout
+func (u *Updater) waitHealthy() (healthy bool, stopped bool) {
+	deadline := u.now().Add(u.opts.HealthCheckTimeout)
+	for {
+		status, err := u.ready()
+		if err == nil && len(status.Healthy) > 0 && len(status.Unhealthy) == 0 {
+			return true, false
+		}
+		if !u.now().Before(deadline) {
+			if err != nil {
+				log.Errorf("Could not get the health of the agent: %v", err)
+			} else {
+				log.Errorf("Unhealthy agent components: %v", status.Unhealthy)
+			}
+			return false, false
+		}
+		select {
+		case <-time.After(u.healthCheckInterval):
+		case <-u.stop:
+			return false, true
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+)
+
+type fakeInstaller struct {
+	installed []string
+}
+
+func (i *fakeInstaller) kind() string {
+	return "deb"
+}
+
+func (i *fakeInstaller) install(path string) error {
+	i.installed = append(i.installed, filepath.Base(path))
+	return nil
+}
+
+// packageServer serves a package per version
+func packageServer(t *testing.T, versions ...string) (*httptest.Server, *catalog) {
+	c := &catalog{Releases: map[string]map[string]pkgInfo{}}
+	content := map[string][]byte{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw, found := content[req.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(raw)
+	}))
+	for _, v := range versions {
+		raw := []byte("package " + v)
+		sum := sha256.Sum256(raw)
+		content["/"+v] = raw
+		c.Releases[v] = map[string]pkgInfo{
+			"deb": {URL: ts.URL + "/" + v, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(raw))},
+		}
+	}
+	return ts, c
+}
+
+func newTestUpdater(t *testing.T, dir string, i installer, currentVersion string) *Updater {
+	u, err := newUpdater(Options{StagingDir: dir, MaintenanceWindow: "02:00-04:00"}, i)
+	require.NoError(t, err)
+	u.currentVersion = currentVersion
+	u.now = func() time.Time { return time.Date(2020, 11, 7, 3, 0, 0, 0, time.Local) }
+	u.healthCheckInterval = time.Millisecond
+	return u
+}
+
+func (u *Updater) setCatalog(t *testing.T, c *catalog) {
+	raw, err := json.Marshal(c)
+	require.NoError(t, err)
+	u.OnCatalog(map[string][]byte{"AGENT_UPDATES/default/catalog": raw})
+}
+
+func TestDownloadPackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ts, c := packageServer(t, "7.25.0")
+	defer ts.Close()
+
+	info := c.Releases["7.25.0"]["deb"]
+	staged, err := downloadPackage(ts.Client(), dir, "7.25.0", "deb", info)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "datadog-agent_7.25.0.deb"), staged.Path)
+
+	tampered := info
+	tampered.SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+	_, err = downloadPackage(ts.Client(), dir, "7.25.1", "deb", tampered)
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "datadog-agent_7.25.1.deb.part"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = downloadPackage(ts.Client(), dir, "../7.25.0", "deb", info)
+	assert.EqualError(t, err, `invalid version "../7.25.0"`)
+}
+
+func TestUpgradeAndRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ts, c := packageServer(t, "7.24.0", "7.25.0")
+	defer ts.Close()
+	c.Target = "7.25.0"
+
+	i := &fakeInstaller{}
+	u := newTestUpdater(t, dir, i, "7.24.0")
+	u.setCatalog(t, c)
+	require.NoError(t, u.upgrade())
+	assert.Equal(t, []string{"datadog-agent_7.25.0.deb"}, i.installed)
+
+	// the installation is pending, nothing is installed again
+	require.NoError(t, u.upgrade())
+	assert.Len(t, i.installed, 1)
+
+	// the upgraded agent stays unhealthy, the previous version is installed again
+	u = newTestUpdater(t, dir, i, "7.25.0")
+	u.opts.HealthCheckTimeout = 0
+	u.ready = func() (health.Status, error) { return health.Status{Unhealthy: []string{"forwarder"}}, nil }
+	u.checkPending()
+	assert.Equal(t, []string{"datadog-agent_7.25.0.deb", "datadog-agent_7.24.0.deb"}, i.installed)
+
+	// the rolled back agent is healthy, the failed version isn't installed again
+	u = newTestUpdater(t, dir, i, "7.24.0")
+	u.ready = func() (health.Status, error) { return health.Status{Healthy: []string{"forwarder"}}, nil }
+	u.checkPending()
+	assert.Nil(t, u.state.Pending)
+	assert.Equal(t, []string{"7.25.0"}, u.state.FailedVersions)
+	u.setCatalog(t, c)
+	require.NoError(t, u.upgrade())
+	assert.Len(t, i.installed, 2)
+}
+
+func TestUpgradeSuccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ts, c := packageServer(t, "7.24.0", "7.25.0")
+	defer ts.Close()
+	c.Target = "7.25.0"
+
+	i := &fakeInstaller{}
+	u := newTestUpdater(t, dir, i, "7.24.0")
+	u.setCatalog(t, c)
+	require.NoError(t, u.upgrade())
+
+	u = newTestUpdater(t, dir, i, "7.25.0")
+	u.ready = func() (health.Status, error) { return health.Status{Healthy: []string{"forwarder"}}, nil }
+	u.checkPending()
+	assert.Nil(t, u.state.Pending)
+	assert.Empty(t, u.state.FailedVersions)
+	assert.Len(t, i.installed, 1)
+	// the staged packages are removed
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, stateFileName, files[0].Name())
+}
+
+func TestUpgradeRequirements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ts, c := packageServer(t, "7.25.0")
+	defer ts.Close()
+	c.Target = "7.25.0"
+
+	// the package of the running version is required to roll back
+	i := &fakeInstaller{}
+	u := newTestUpdater(t, dir, i, "7.24.0")
+	u.setCatalog(t, c)
+	assert.Error(t, u.upgrade())
+	assert.Empty(t, i.installed)
+
+	// nothing is installed out of the maintenance window
+	ts, c = packageServer(t, "7.24.0", "7.25.0")
+	defer ts.Close()
+	c.Target = "7.25.0"
+	c.MaintenanceWindow = "Sun 02:00-04:00"
+	u.setCatalog(t, c)
+	require.NoError(t, u.upgrade())
+	assert.Empty(t, i.installed)
+	_, err = os.Stat(filepath.Join(dir, "datadog-agent_7.25.0.deb"))
+	assert.NoError(t, err, "the package is staged ahead of the window")
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// maintenanceWindow is a daily range of local time, optionally restricted to
+// some weekdays, e.g. "Sat,Sun 02:00-04:00". A range ending before it starts
+// ends on the next day.
+type maintenanceWindow struct {
+	// days the window starts on, every day when empty
+	days  map[time.Weekday]bool
+	start time.Duration
+	end   time.Duration
+}
+
+func parseMaintenanceWindow(s string) (*maintenanceWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid maintenance window %q, expected \"[days] HH:MM-HH:MM\"", s)
+	}
+
+	w := &maintenanceWindow{days: make(map[time.Weekday]bool)}
+	if len(fields) == 2 {
+		for _, day := range strings.Split(fields[0], ",") {
+			weekday, found := weekdays[strings.ToLower(day)]
+			if !found {
+				return nil, fmt.Errorf("invalid day %q in maintenance window %q", day, s)
+			}
+			w.days[weekday] = true
+		}
+	}
+
+	bounds := strings.Split(fields[len(fields)-1], "-")
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("invalid time range in maintenance window %q", s)
+	}
+	var err error
+	if w.start, err = parseTimeOfDay(bounds[0]); err != nil {
+		return nil, err
+	}
+	if w.end, err = parseTimeOfDay(bounds[1]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// parseTimeOfDay returns the duration since midnight of a HH:MM time
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns whether t is within the window
+func (w *maintenanceWindow) contains(t time.Time) bool {
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	// a window started the day before may not be over yet
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if len(w.days) > 0 && !w.days[day.Weekday()] {
+			continue
+		}
+		start, end := day.Add(w.start), day.Add(w.end)
+		if w.end <= w.start {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return true
+		}
+	}
+	return false
+}
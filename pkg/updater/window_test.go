@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package updater
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaintenanceWindowErrors(t *testing.T) {
+	for _, s := range []string{"", "02:00", "Mon 02:00-03:00 extra", "Foo 02:00-03:00", "02:00-25:00", "2-3"} {
+		_, err := parseMaintenanceWindow(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2020-11-07 is a Saturday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 11, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		window   string
+		t        time.Time
+		expected bool
+	}{
+		{"02:00-04:00", at(4, 2, 0), true},
+		{"02:00-04:00", at(4, 3, 59), true},
+		{"02:00-04:00", at(4, 4, 0), false},
+		{"02:00-04:00", at(4, 1, 59), false},
+		{"Sat,sun 02:00-04:00", at(7, 3, 0), true},
+		{"Sat,sun 02:00-04:00", at(6, 3, 0), false},
+		// overnight windows end on the next day
+		{"22:00-02:00", at(4, 23, 0), true},
+		{"22:00-02:00", at(4, 1, 0), true},
+		{"22:00-02:00", at(4, 12, 0), false},
+		{"Sun 22:00-02:00", at(9, 1, 0), true},
+		{"Sun 22:00-02:00", at(8, 1, 0), false},
+	}
+	for _, test := range tests {
+		w, err := parseMaintenanceWindow(test.window)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, w.contains(test.t), "%s at %s", test.window, test.t)
+	}
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package autotune adjusts the Go runtime and the buffer sizes of the Agent to
+// the CPU and memory limits of its cgroup, mostly set on containerized Agents.
+package autotune
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const mib = 1 << 20
+
+// Limits are the resource limits of the cgroup of the Agent, zero when unlimited
+type Limits struct {
+	CPUs   float64
+	Memory uint64
+}
+
+// Tuning holds the values derived from the limits
+type Tuning struct {
+	GOMAXPROCS int
+	Ballast    uint64
+	// Settings are the configuration settings to override
+	Settings map[string]int
+}
+
+// tunedSetting scales a setting to the memory limit, it is only lowered
+type tunedSetting struct {
+	key string
+	// defaultValue is the default of the setting in pkg/config, the setting
+	// is not tuned when it is set to another value
+	defaultValue int
+	// bytesPerUnit is the memory budget of one unit of the setting
+	bytesPerUnit uint64
+	min          int
+}
+
+// tunedSettings are the buffers holding most of the memory of the Agent,
+// they are given a quarter of the memory limit
+var tunedSettings = []tunedSetting{
+	// a queue item holds dogstatsd_packet_buffer_size packets of dogstatsd_buffer_size bytes
+	{key: "dogstatsd_queue_size", defaultValue: 1024, bytesPerUnit: 32 * 8 * 1024, min: 64},
+	// a retried request takes up to 2 MiB
+	{key: "forwarder_retry_queue_max_size", defaultValue: 30, bytesPerUnit: 2 * mib, min: 5},
+}
+
+// ballast keeps the memory ballast allocated
+var ballast []byte
+
+// Compute returns the tuning of the limits. current holds the values of the
+// settings that can be tuned.
+func Compute(limits Limits, current map[string]int, ballastRatio float64) Tuning {
+	t := Tuning{Settings: make(map[string]int)}
+	if limits.CPUs > 0 {
+		// a fraction of CPU still needs a thread
+		t.GOMAXPROCS = int(limits.CPUs)
+		if t.GOMAXPROCS < 1 {
+			t.GOMAXPROCS = 1
+		}
+	}
+	if limits.Memory == 0 {
+		return t
+	}
+
+	if ballastRatio > 0 {
+		t.Ballast = uint64(float64(limits.Memory) * ballastRatio)
+	}
+	budget := limits.Memory / 4
+	for _, s := range tunedSettings {
+		value := int(budget / s.bytesPerUnit)
+		if value < s.min {
+			value = s.min
+		}
+		if value < current[s.key] {
+			t.Settings[s.key] = value
+		}
+	}
+	return t
+}
+
+// ReadLimits returns the CPU and memory limits of the cgroup of the Agent
+func ReadLimits() (Limits, error) {
+	return readLimits()
+}
+
+// Apply reads the limits of the cgroup of the Agent and tunes the runtime and
+// the settings left to their default values. It must run before the
+// components reading these settings start.
+func Apply() {
+	if !config.Datadog.GetBool("autotune.enabled") {
+		return
+	}
+	limits, err := readLimits()
+	if err != nil {
+		log.Debugf("Not tuning the Agent to its cgroup limits: %v", err)
+		return
+	}
+	if limits.CPUs == 0 && limits.Memory == 0 {
+		return
+	}
+
+	// only the settings left to their defaults are tuned
+	current := make(map[string]int)
+	for _, s := range tunedSettings {
+		if value := config.Datadog.GetInt(s.key); value == s.defaultValue {
+			current[s.key] = value
+		}
+	}
+
+	t := Compute(limits, current, config.Datadog.GetFloat64("autotune.memory_ballast_ratio"))
+	if t.GOMAXPROCS > 0 && t.GOMAXPROCS < runtime.GOMAXPROCS(0) {
+		if _, set := os.LookupEnv("GOMAXPROCS"); set {
+			log.Infof("Not tuning GOMAXPROCS to the CPU limit of %.2f, it is set by the environment", limits.CPUs)
+		} else {
+			runtime.GOMAXPROCS(t.GOMAXPROCS)
+			log.Infof("GOMAXPROCS set to %d for the CPU limit of %.2f", t.GOMAXPROCS, limits.CPUs)
+		}
+	}
+	if t.Ballast > 0 {
+		// the ballast is never written to, it raises the heap size triggering
+		// the garbage collections without using resident memory
+		ballast = make([]byte, t.Ballast)
+		log.Infof("Allocated a memory ballast of %d MiB for the memory limit of %d MiB", t.Ballast/mib, limits.Memory/mib)
+	}
+	for key, value := range t.Settings {
+		config.Datadog.Set(key, value)
+		log.Infof("%s set to %d for the memory limit of %d MiB", key, value, limits.Memory/mib)
+	}
+}
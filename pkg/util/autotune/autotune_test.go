@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autotune
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func defaults() map[string]int {
+	current := make(map[string]int)
+	for _, s := range tunedSettings {
+		current[s.key] = s.defaultValue
+	}
+	return current
+}
+
+func TestComputeUnlimited(t *testing.T) {
+	tuning := Compute(Limits{}, defaults(), 0.1)
+	assert.Equal(t, 0, tuning.GOMAXPROCS)
+	assert.Equal(t, uint64(0), tuning.Ballast)
+	assert.Empty(t, tuning.Settings)
+}
+
+func TestComputeCPU(t *testing.T) {
+	assert.Equal(t, 1, Compute(Limits{CPUs: 0.5}, defaults(), 0.1).GOMAXPROCS)
+	assert.Equal(t, 2, Compute(Limits{CPUs: 2.7}, defaults(), 0.1).GOMAXPROCS)
+}
+
+func TestComputeMemory(t *testing.T) {
+	tuning := Compute(Limits{Memory: 40 * mib}, defaults(), 0.1)
+	assert.Equal(t, uint64(4*mib), tuning.Ballast)
+	// 10MiB budget, both settings are raised to their minimum
+	assert.Equal(t, map[string]int{
+		"dogstatsd_queue_size":           64,
+		"forwarder_retry_queue_max_size": 5,
+	}, tuning.Settings)
+
+	// large limits never raise the settings
+	tuning = Compute(Limits{Memory: 64 * 1024 * mib}, defaults(), 0)
+	assert.Equal(t, uint64(0), tuning.Ballast)
+	assert.Empty(t, tuning.Settings)
+
+	// settings set by the user are not tuned
+	tuning = Compute(Limits{Memory: 16 * mib}, map[string]int{}, 0.1)
+	assert.Empty(t, tuning.Settings)
+}
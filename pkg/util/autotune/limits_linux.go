@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package autotune
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// unlimitedMemory is the lowest memory.limit_in_bytes meaning no limit on
+// cgroup v1, the kernel reports a page aligned maximum int64
+const unlimitedMemory = 1 << 62
+
+// declare these as vars not const to ease testing
+var (
+	procSelfPath = "/proc/self"
+	// fsRoot prefixes the mount points
+	fsRoot = ""
+)
+
+type cgroupMount struct {
+	root       string
+	mountPoint string
+	fsType     string
+	options    []string
+}
+
+func readLimits() (Limits, error) {
+	paths, err := parseCgroupFile(filepath.Join(procSelfPath, "cgroup"))
+	if err != nil {
+		return Limits{}, err
+	}
+	mounts, err := parseMountInfo(filepath.Join(procSelfPath, "mountinfo"))
+	if err != nil {
+		return Limits{}, err
+	}
+
+	var limits Limits
+	for _, m := range mounts {
+		switch {
+		case m.fsType == "cgroup2":
+			dir, found := m.dir(paths[""])
+			if !found {
+				continue
+			}
+			limits.CPUs = readCPUMax(filepath.Join(dir, "cpu.max"))
+			limits.Memory = readMemory(filepath.Join(dir, "memory.max"))
+			return limits, nil
+		case m.hasOption("cpu"):
+			if dir, found := m.dir(paths["cpu"]); found {
+				limits.CPUs = readCFSQuota(dir)
+			}
+		case m.hasOption("memory"):
+			if dir, found := m.dir(paths["memory"]); found {
+				limits.Memory = readMemory(filepath.Join(dir, "memory.limit_in_bytes"))
+			}
+		}
+	}
+	return limits, nil
+}
+
+// parseCgroupFile returns the cgroup path of every controller of a
+// /proc/<pid>/cgroup file, the cgroup v2 path has the empty controller
+func parseCgroupFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	paths := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// parseMountInfo returns the cgroup mounts of a /proc/<pid>/mountinfo file
+func parseMountInfo(path string) ([]cgroupMount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []cgroupMount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /root /mount/point rw,noatime master:1 - cgroup cgroup rw,cpu,cpuacct
+		fields := strings.Fields(scanner.Text())
+		separator := -1
+		for i, field := range fields {
+			if field == "-" {
+				separator = i
+				break
+			}
+		}
+		if separator < 5 || len(fields) < separator+4 {
+			continue
+		}
+		fsType := fields[separator+1]
+		if fsType != "cgroup" && fsType != "cgroup2" {
+			continue
+		}
+		mounts = append(mounts, cgroupMount{
+			root:       fields[3],
+			mountPoint: fields[4],
+			fsType:     fsType,
+			options:    strings.Split(fields[separator+3], ","),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+func (m cgroupMount) hasOption(option string) bool {
+	for _, o := range m.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// dir returns the directory of a cgroup under the mount. The mount root is
+// the cgroup of the container when the cgroup namespace is not private.
+func (m cgroupMount) dir(cgroupPath string) (string, bool) {
+	if cgroupPath == "" {
+		return "", false
+	}
+	relative := cgroupPath
+	if m.root != "/" {
+		if !strings.HasPrefix(cgroupPath, m.root) {
+			return filepath.Join(fsRoot, m.mountPoint), true
+		}
+		relative = strings.TrimPrefix(cgroupPath, m.root)
+	}
+	return filepath.Join(fsRoot, m.mountPoint, relative), true
+}
+
+func readUint(path string) (uint64, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	return value, err == nil
+}
+
+// readCFSQuota returns the CPU limit of the cgroup v1 CFS quota
+func readCFSQuota(dir string) float64 {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0
+	}
+	quota, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil || quota <= 0 {
+		return 0
+	}
+	period, ok := readUint(filepath.Join(dir, "cpu.cfs_period_us"))
+	if !ok || period == 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+// readCPUMax returns the CPU limit of a cgroup v2 cpu.max file
+func readCPUMax(path string) float64 {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	// $MAX $PERIOD, $MAX is "max" when unlimited
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseUint(fields[0], 10, 64)
+	period, err2 := strconv.ParseUint(fields[1], 10, 64)
+	if err1 != nil || err2 != nil || period == 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+// readMemory returns the memory limit of a memory.limit_in_bytes or
+// memory.max file
+func readMemory(path string) uint64 {
+	limit, ok := readUint(path)
+	if !ok || limit >= unlimitedMemory {
+		// memory.max holds "max" when unlimited
+		return 0
+	}
+	return limit
+}
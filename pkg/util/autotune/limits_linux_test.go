@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package autotune
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func withFakeFS(t *testing.T, files map[string]string) func() {
+	root, err := ioutil.TempDir("", "autotune")
+	require.NoError(t, err)
+	writeFiles(t, root, files)
+
+	oldProc, oldRoot := procSelfPath, fsRoot
+	procSelfPath, fsRoot = filepath.Join(root, "proc/self"), root
+	return func() {
+		procSelfPath, fsRoot = oldProc, oldRoot
+		os.RemoveAll(root)
+	}
+}
+
+func TestReadLimitsV1(t *testing.T) {
+	defer withFakeFS(t, map[string]string{
+		"proc/self/cgroup": `12:memory:/docker/abc
+11:cpu,cpuacct:/docker/abc
+1:name=systemd:/docker/abc
+`,
+		"proc/self/mountinfo": `100 99 0:50 / / rw,relatime - overlay overlay rw
+110 109 0:28 /docker/abc /sys/fs/cgroup/cpu,cpuacct ro,nosuid master:12 - cgroup cgroup rw,cpu,cpuacct
+111 109 0:29 /docker/abc /sys/fs/cgroup/memory ro,nosuid master:13 - cgroup cgroup rw,memory
+`,
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "150000\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":  "536870912\n",
+	})()
+
+	limits, err := readLimits()
+	require.NoError(t, err)
+	assert.Equal(t, Limits{CPUs: 1.5, Memory: 512 * mib}, limits)
+}
+
+func TestReadLimitsV1Unlimited(t *testing.T) {
+	defer withFakeFS(t, map[string]string{
+		"proc/self/cgroup": `12:memory:/
+11:cpu,cpuacct:/
+`,
+		"proc/self/mountinfo": `110 109 0:28 / /sys/fs/cgroup/cpu,cpuacct rw - cgroup cgroup rw,cpu,cpuacct
+111 109 0:29 / /sys/fs/cgroup/memory rw - cgroup cgroup rw,memory
+`,
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "-1\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":  "9223372036854771712\n",
+	})()
+
+	limits, err := readLimits()
+	require.NoError(t, err)
+	assert.Equal(t, Limits{}, limits)
+}
+
+func TestReadLimitsV2(t *testing.T) {
+	defer withFakeFS(t, map[string]string{
+		"proc/self/cgroup":         "0::/\n",
+		"proc/self/mountinfo":      "120 119 0:26 / /sys/fs/cgroup ro,nosuid - cgroup2 cgroup2 rw,nsdelegate\n",
+		"sys/fs/cgroup/cpu.max":    "50000 100000\n",
+		"sys/fs/cgroup/memory.max": "max\n",
+	})()
+
+	limits, err := readLimits()
+	require.NoError(t, err)
+	assert.Equal(t, Limits{CPUs: 0.5}, limits)
+}
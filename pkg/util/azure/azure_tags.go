@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tagsCacheKey     = cache.BuildAgentKey("azure", "GetTags")
+	lastTagsCacheKey = cache.BuildAgentKey("azure", "GetTags", "last")
+)
+
+type azureTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GetTags returns the tags of the VM resource from the Azure Metadata api,
+// which requires no permission. They are refreshed every
+// cloud_provider_tags_refresh_interval, the last known tags are returned when
+// the api is unreachable.
+func GetTags() ([]string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return nil, fmt.Errorf("cloud provider is disabled by configuration")
+	}
+	if tags, found := cache.Cache.Get(tagsCacheKey); found {
+		return tags.([]string), nil
+	}
+
+	tags, err := fetchTags()
+	if err != nil {
+		if tags, found := cache.Cache.Get(lastTagsCacheKey); found {
+			log.Infof("unable to get tags from azure, returning the last known tags: %s", err)
+			return tags.([]string), nil
+		}
+		return nil, log.Warnf("unable to get tags from azure: %s", err)
+	}
+
+	refresh := time.Duration(config.Datadog.GetInt("cloud_provider_tags_refresh_interval")) * time.Second
+	cache.Cache.Set(tagsCacheKey, tags, refresh)
+	cache.Cache.Set(lastTagsCacheKey, tags, cache.NoExpiration)
+	return tags, nil
+}
+
+func fetchTags() ([]string, error) {
+	// tagsList is available from the 2019-06-04 api version
+	res, err := getResponse(metadataURL + "/metadata/instance/compute/tagsList?api-version=2019-06-04&format=json")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query metadata endpoint: %s", err)
+	}
+	azureTags := []azureTag{}
+	if err := json.Unmarshal([]byte(res), &azureTags); err != nil {
+		return nil, fmt.Errorf("unable to parse the tags: %s", err)
+	}
+
+	tags := make([]string, 0, len(azureTags))
+	for _, t := range azureTags {
+		if t.Value == "" {
+			tags = append(tags, t.Name)
+		} else {
+			tags = append(tags, fmt.Sprintf("%s:%s", t.Name, t.Value))
+		}
+	}
+	return tags, nil
+}
@@ -12,6 +12,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 )
 
 func TestGetHostname(t *testing.T) {
@@ -76,3 +79,32 @@ func TestGetClusterName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTags(t *testing.T) {
+	var lastRequest *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[{"name":"env","value":"prod"},{"name":"team","value":"fleet"},{"name":"standalone","value":""}]`)
+		lastRequest = r
+	}))
+	metadataURL = ts.URL
+	defer cache.Cache.Delete(tagsCacheKey)
+	defer cache.Cache.Delete(lastTagsCacheKey)
+
+	tags, err := GetTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env:prod", "team:fleet", "standalone"}, tags)
+	assert.Equal(t, "/metadata/instance/compute/tagsList", lastRequest.URL.Path)
+	assert.Equal(t, "true", lastRequest.Header.Get("Metadata"))
+
+	// the last known tags are returned once the refreshed tags expire and the api is unreachable
+	ts.Close()
+	cache.Cache.Delete(tagsCacheKey)
+	tags, err = GetTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env:prod", "team:fleet", "standalone"}, tags)
+
+	cache.Cache.Delete(lastTagsCacheKey)
+	_, err = GetTags()
+	assert.Error(t, err)
+}
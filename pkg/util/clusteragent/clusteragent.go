@@ -48,6 +48,7 @@ type DCAClientInterface interface {
 
 	GetVersion() (version.Version, error)
 	GetNodeLabels(nodeName string) (map[string]string, error)
+	GetNamespaceMetadata(ns string) (*apiv1.NamespaceMetadata, error)
 	GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error)
 	GetKubernetesMetadataNames(nodeName, ns, podName string) ([]string, error)
 	GetCFAppsMetadataForNode(nodename string) (map[string][]string, error)
@@ -102,18 +103,38 @@ func (c *DCAClient) init() error {
 		return err
 	}
 
-	authToken, err := security.GetClusterAgentAuthToken()
+	mtlsMode, err := security.GetClusterAgentMTLSMode()
 	if err != nil {
 		return err
 	}
 
 	c.clusterAgentAPIRequestHeaders = http.Header{}
-	c.clusterAgentAPIRequestHeaders.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
+	authToken, err := security.GetClusterAgentAuthToken()
+	if err == nil {
+		c.clusterAgentAPIRequestHeaders.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
+	} else if mtlsMode != security.MTLSRequired {
+		// The auth token is optional once the client certificate is required
+		return err
+	}
 	podIP := config.Datadog.GetString("clc_runner_host")
 	c.clusterAgentAPIRequestHeaders.Set(RealIPHeader, podIP)
 
-	// TODO remove insecure
-	c.clusterAgentAPIClient = util.GetClient(false)
+	if mtlsMode == security.MTLSDisabled {
+		// TODO remove insecure
+		c.clusterAgentAPIClient = util.GetClient(false)
+	} else {
+		certReloader, err := security.NewClusterAgentCertReloader()
+		if err != nil {
+			return err
+		}
+		endpoint, err := url.Parse(c.clusterAgentAPIEndpoint)
+		if err != nil {
+			return err
+		}
+		c.clusterAgentAPIClient = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: certReloader.ClientTLSConfig(endpoint.Hostname())},
+		}
+	}
 	c.clusterAgentAPIClient.Timeout = 2 * time.Second
 
 	// Validate the cluster-agent client by checking the version
@@ -270,6 +291,39 @@ func (c *DCAClient) GetNodeLabels(nodeName string) (map[string]string, error) {
 	return labels, err
 }
 
+// GetNamespaceMetadata returns the labels and annotations of a namespace from the Cluster Agent.
+func (c *DCAClient) GetNamespaceMetadata(ns string) (*apiv1.NamespaceMetadata, error) {
+	const dcaNamespaceMeta = "api/v1/tags/namespace"
+	var err error
+	metadata := &apiv1.NamespaceMetadata{}
+
+	// https://host:port/api/v1/tags/namespace/{ns}
+	rawURL := fmt.Sprintf("%s/%s/%s", c.clusterAgentAPIEndpoint, dcaNamespaceMeta, ns)
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.clusterAgentAPIRequestHeaders
+
+	resp, err := c.clusterAgentAPIClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(body, metadata)
+	return metadata, err
+}
+
 // GetCFAppsMetadataForNode returns the CF application tags from the Cluster Agent.
 func (c *DCAClient) GetCFAppsMetadataForNode(nodename string) (map[string][]string, error) {
 	const dcaCFAppsMeta = "api/v1/tags/cf/apps"
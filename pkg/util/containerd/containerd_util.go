@@ -38,6 +38,8 @@ type ContainerdItf interface {
 	GetEvents() containerd.EventService
 	Info(ctn containerd.Container) (containers.Container, error)
 	ImageSize(ctn containerd.Container) (int64, error)
+	Images() ([]containerd.Image, error)
+	ImageLayers(img containerd.Image) ([]string, error)
 	Metadata() (containerd.Version, error)
 	Namespace() string
 	TaskMetrics(ctn containerd.Container) (*types.Metric, error)
@@ -153,6 +155,32 @@ func (c *ContainerdUtil) ImageSize(ctn containerd.Container) (int64, error) {
 	return img.Size(ctxNamespace)
 }
 
+// Images interfaces with the containerd api to get the list of images.
+func (c *ContainerdUtil) Images() ([]containerd.Image, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+	return c.cl.ListImages(ctxNamespace)
+}
+
+// ImageLayers interfaces with the containerd api to get the digests of the
+// uncompressed layers of an image, from the base layer to the top one.
+func (c *ContainerdUtil) ImageLayers(img containerd.Image) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+
+	diffIDs, err := img.RootFS(ctxNamespace)
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]string, 0, len(diffIDs))
+	for _, diffID := range diffIDs {
+		layers = append(layers, diffID.String())
+	}
+	return layers, nil
+}
+
 // Info interfaces with the containerd api to get Container info
 func (c *ContainerdUtil) Info(ctn containerd.Container) (containers.Container, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
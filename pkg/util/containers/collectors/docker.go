@@ -10,6 +10,7 @@ package collectors
 import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	ecsutil "github.com/DataDog/datadog-agent/pkg/util/ecs"
 )
 
 const (
@@ -40,7 +41,11 @@ func (c *DockerCollector) Detect() error {
 
 // List gets all running containers
 func (c *DockerCollector) List() ([]*containers.Container, error) {
-	return c.dockerUtil.ListContainers(c.listConfig)
+	cList, err := c.dockerUtil.ListContainers(c.listConfig)
+	if err == nil && ecsutil.IsECSInstance() {
+		ecsutil.ApplyEC2TaskLimits(cList)
+	}
+	return cList, err
 }
 
 // UpdateMetrics updates metrics on an existing list of containers
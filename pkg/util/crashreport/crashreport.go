@@ -0,0 +1,348 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package crashreport persists the crashes of the agent processes to a local
+// spool, so that they can be collected in the next flare and optionally
+// reported as telemetry.
+package crashreport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// Kinds of crashes
+const (
+	// KindPanic is a panic recovered by Recover, with its stack trace
+	KindPanic = "panic"
+	// KindUncleanExit is a process that exited without being stopped,
+	// detected when it starts again: a fatal error, a panic in a goroutine
+	// not recovered by Recover, or the process being killed
+	KindUncleanExit = "unclean_exit"
+)
+
+const (
+	reportExt  = ".json"
+	markerExt  = ".running"
+	timeLayout = "20060102T150405.000000000"
+
+	crashMetric = "datadog.agent.crash"
+)
+
+// Report is a crash of an agent process
+type Report struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Process    string    `json:"process"`
+	Version    string    `json:"version"`
+	ConfigHash string    `json:"config_hash"`
+	Kind       string    `json:"kind"`
+	Error      string    `json:"error,omitempty"`
+	Stack      string    `json:"stack,omitempty"`
+	Submitted  bool      `json:"submitted"`
+}
+
+// runMarker is written when a process starts and removed when it's stopped
+type runMarker struct {
+	PID        int       `json:"pid"`
+	Started    time.Time `json:"started"`
+	Version    string    `json:"version"`
+	ConfigHash string    `json:"config_hash"`
+}
+
+// Sender is the part of the aggregator sender used to submit crash telemetry
+type Sender interface {
+	Count(metric string, value float64, hostname string, tags []string)
+	Commit()
+}
+
+// Reporter writes the crash reports of a process to the spool
+type Reporter struct {
+	dir        string
+	process    string
+	version    string
+	configHash string
+	maxReports int
+}
+
+var reporter *Reporter
+
+// Dir returns the spool directory of the crash reports, crash_reporting.spool_dir
+// or a crashes folder in the run path
+func Dir() string {
+	if dir := config.Datadog.GetString("crash_reporting.spool_dir"); dir != "" {
+		return dir
+	}
+	return filepath.Join(config.Datadog.GetString("run_path"), "crashes")
+}
+
+// Init starts reporting the crashes of the process when enabled, and reports
+// the previous run of the process if it didn't stop cleanly
+func Init(process string) error {
+	if !config.Datadog.GetBool("crash_reporting.enabled") {
+		return nil
+	}
+	r, err := NewReporter(Dir(), process, version.AgentVersion, configHash(), config.Datadog.GetInt("crash_reporting.max_reports"))
+	if err != nil {
+		return err
+	}
+	reporter = r
+	return nil
+}
+
+// Stop records that the process stopped cleanly
+func Stop() {
+	if reporter != nil {
+		reporter.Stop()
+	}
+}
+
+// Recover persists a panic before propagating it, it must be deferred at the
+// top of the goroutines whose panics should be reported
+func Recover() {
+	if r := recover(); r != nil {
+		if reporter != nil {
+			if err := reporter.ReportPanic(r, debug.Stack()); err != nil {
+				log.Errorf("Could not write the crash report: %v", err)
+			}
+		}
+		log.Errorf("Unexpected panic: %v", r)
+		log.Flush()
+		panic(r)
+	}
+}
+
+// SubmitTelemetry sends a count per crash not submitted yet, tagged with the
+// process, version and kind of crash, when crash_reporting.submit_telemetry
+// is enabled
+func SubmitTelemetry(sender Sender) {
+	if reporter == nil || !config.Datadog.GetBool("crash_reporting.submit_telemetry") {
+		return
+	}
+	if err := reporter.SubmitTelemetry(sender); err != nil {
+		log.Warnf("Could not submit the crash telemetry: %v", err)
+	}
+}
+
+// configHash identifies the configuration of the process without revealing it
+func configHash() string {
+	settings, err := yaml.Marshal(config.Datadog.AllSettings())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(settings)
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewReporter creates the spool directory, reports the previous run of the
+// process if it didn't stop cleanly and marks the process as running
+func NewReporter(dir, process, version, configHash string, maxReports int) (*Reporter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	r := &Reporter{
+		dir:        dir,
+		process:    process,
+		version:    version,
+		configHash: configHash,
+		maxReports: maxReports,
+	}
+
+	if previous, err := r.readMarker(); err == nil {
+		report := Report{
+			Timestamp:  time.Now(),
+			Process:    process,
+			Version:    previous.Version,
+			ConfigHash: previous.ConfigHash,
+			Kind:       KindUncleanExit,
+			Error:      fmt.Sprintf("process %d started at %s did not stop cleanly", previous.PID, previous.Started.Format(time.RFC3339)),
+		}
+		if err := r.write(report); err != nil {
+			log.Warnf("Could not write the crash report of the previous run: %v", err)
+		} else {
+			log.Warnf("The previous %s process did not stop cleanly, a crash report was written to %s", process, dir)
+		}
+	}
+
+	marker, err := json.Marshal(runMarker{
+		PID:        os.Getpid(),
+		Started:    time.Now(),
+		Version:    version,
+		ConfigHash: configHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(r.markerPath(), marker, 0600); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Stop records that the process stopped cleanly
+func (r *Reporter) Stop() {
+	os.Remove(r.markerPath()) //nolint:errcheck
+}
+
+// ReportPanic persists a panic and its stack trace
+func (r *Reporter) ReportPanic(recovered interface{}, stack []byte) error {
+	return r.write(Report{
+		Timestamp:  time.Now(),
+		Process:    r.process,
+		Version:    r.version,
+		ConfigHash: r.configHash,
+		Kind:       KindPanic,
+		Error:      fmt.Sprintf("%v", recovered),
+		Stack:      string(stack),
+	})
+}
+
+// Reports returns the reports of the process in the spool, the oldest first
+func (r *Reporter) Reports() ([]Report, error) {
+	paths, err := r.reportPaths()
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(paths))
+	for _, path := range paths {
+		report, err := readReport(path)
+		if err != nil {
+			log.Debugf("Skipping invalid crash report %s: %v", path, err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// SubmitTelemetry sends a count per crash not submitted yet and marks them
+// as submitted
+func (r *Reporter) SubmitTelemetry(sender Sender) error {
+	paths, err := r.reportPaths()
+	if err != nil {
+		return err
+	}
+	submitted := 0
+	var writeErr error
+	for _, path := range paths {
+		report, err := readReport(path)
+		if err != nil || report.Submitted {
+			continue
+		}
+		// Mark the report first so that it's never counted twice
+		report.Submitted = true
+		if writeErr = writeReport(path, report); writeErr != nil {
+			break
+		}
+		sender.Count(crashMetric, 1, "", []string{
+			"process:" + report.Process,
+			"version:" + report.Version,
+			"kind:" + report.Kind,
+		})
+		submitted++
+	}
+	if submitted > 0 {
+		sender.Commit()
+	}
+	return writeErr
+}
+
+func (r *Reporter) markerPath() string {
+	return filepath.Join(r.dir, r.process+markerExt)
+}
+
+func (r *Reporter) readMarker() (runMarker, error) {
+	var marker runMarker
+	content, err := ioutil.ReadFile(r.markerPath())
+	if err != nil {
+		return marker, err
+	}
+	err = json.Unmarshal(content, &marker)
+	return marker, err
+}
+
+// reportPaths returns the paths of the reports of the process, the oldest first
+func (r *Reporter) reportPaths() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(r.dir, r.process+"-*"+reportExt))
+	if err != nil {
+		return nil, err
+	}
+	// The timestamp in the names sorts them chronologically
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// write persists a report and removes the oldest ones above the maximum
+func (r *Reporter) write(report Report) error {
+	report.Error = scrub(report.Error)
+	report.Stack = scrub(report.Stack)
+
+	name := r.process + "-" + report.Timestamp.UTC().Format(timeLayout) + "-" + strconv.Itoa(os.Getpid()) + reportExt
+	if err := writeReport(filepath.Join(r.dir, name), report); err != nil {
+		return err
+	}
+
+	if r.maxReports <= 0 {
+		return nil
+	}
+	paths, err := r.reportPaths()
+	if err != nil {
+		return err
+	}
+	for len(paths) > r.maxReports {
+		os.Remove(paths[0]) //nolint:errcheck
+		paths = paths[1:]
+	}
+	return nil
+}
+
+func readReport(path string) (Report, error) {
+	var report Report
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	err = json.Unmarshal(content, &report)
+	return report, err
+}
+
+func writeReport(path string, report Report) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write the report atomically so that a flare never collects a partial one
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// scrub removes the credentials a panic message or stack trace could contain
+func scrub(s string) string {
+	if s == "" {
+		return s
+	}
+	scrubbed, err := log.CredentialsCleanerBytes([]byte(s))
+	if err != nil {
+		return "[REDACTED] - failure to clean the crash report"
+	}
+	return string(scrubbed)
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package crashreport
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countCall struct {
+	metric string
+	tags   []string
+}
+
+type mockSender struct {
+	counts  []countCall
+	commits int
+}
+
+func (s *mockSender) Count(metric string, value float64, hostname string, tags []string) {
+	s.counts = append(s.counts, countCall{metric: metric, tags: tags})
+}
+
+func (s *mockSender) Commit() {
+	s.commits++
+}
+
+func TestUncleanExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r, err := NewReporter(dir, "agent", "7.0.0", "abc", 10)
+	require.NoError(t, err)
+	reports, err := r.Reports()
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	// A clean stop doesn't report anything on the next start
+	r.Stop()
+	r, err = NewReporter(dir, "agent", "7.0.1", "def", 10)
+	require.NoError(t, err)
+	reports, err = r.Reports()
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	// The process wasn't stopped: the next start reports the previous run
+	r, err = NewReporter(dir, "agent", "7.0.2", "ghi", 10)
+	require.NoError(t, err)
+	reports, err = r.Reports()
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, KindUncleanExit, reports[0].Kind)
+	assert.Equal(t, "agent", reports[0].Process)
+	assert.Equal(t, "7.0.1", reports[0].Version)
+	assert.Equal(t, "def", reports[0].ConfigHash)
+}
+
+func TestReportPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r, err := NewReporter(dir, "agent", "7.0.0", "abc", 2)
+	require.NoError(t, err)
+	for _, msg := range []string{"first", "second", "api_key: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"} {
+		require.NoError(t, r.ReportPanic(msg, []byte("goroutine 1 [running]:")))
+	}
+
+	// The oldest report is removed and the credentials are scrubbed
+	reports, err := r.Reports()
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, KindPanic, reports[0].Kind)
+	assert.Equal(t, "second", reports[0].Error)
+	assert.Equal(t, "goroutine 1 [running]:", reports[0].Stack)
+	assert.NotContains(t, reports[1].Error, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+}
+
+func TestSubmitTelemetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r, err := NewReporter(dir, "agent", "7.0.0", "abc", 10)
+	require.NoError(t, err)
+	require.NoError(t, r.ReportPanic("boom", nil))
+
+	sender := &mockSender{}
+	require.NoError(t, r.SubmitTelemetry(sender))
+	assert.Equal(t, []countCall{{
+		metric: "datadog.agent.crash",
+		tags:   []string{"process:agent", "version:7.0.0", "kind:panic"},
+	}}, sender.counts)
+	assert.Equal(t, 1, sender.commits)
+
+	// Each crash is only submitted once
+	require.NoError(t, r.SubmitTelemetry(sender))
+	assert.Len(t, sender.counts, 1)
+	assert.Equal(t, 1, sender.commits)
+}
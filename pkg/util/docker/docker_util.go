@@ -112,6 +112,17 @@ func (d *DockerUtil) Images(includeIntermediate bool) ([]types.ImageSummary, err
 	return images, nil
 }
 
+// ImageInspect returns a docker inspect object for a given image ID.
+func (d *DockerUtil) ImageInspect(imageID string) (types.ImageInspect, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.queryTimeout)
+	defer cancel()
+	image, _, err := d.cli.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return image, fmt.Errorf("unable to inspect docker image %s: %s", imageID, err)
+	}
+	return image, nil
+}
+
 // CountVolumes returns the number of attached and dangling volumes.
 func (d *DockerUtil) CountVolumes() (int, int, error) {
 	attachedFilter, _ := buildDockerFilter("dangling", "false")
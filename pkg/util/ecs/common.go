@@ -9,14 +9,23 @@ package ecs
 
 import (
 	"net"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/ecs/metadata"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
+	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
+)
+
+const (
+	ec2TaskCacheKeyPrefix  = "ecs_ec2_task:"
+	ec2TaskCacheExpiration = 30 * time.Minute
 )
 
 // ListContainersInCurrentTask returns internal container representations (with
@@ -30,8 +39,11 @@ func ListContainersInCurrentTask() ([]*containers.Container, error) {
 		log.Error("Unable to get the container list from ecs")
 		return cList, err
 	}
+	taskLimits := parseTaskLimits(task.Limits)
 	for _, c := range task.Containers {
-		cList = append(cList, convertMetaV2Container(c))
+		container := convertMetaV2Container(c)
+		applyTaskLimits(container, c.Limits, taskLimits)
+		cList = append(cList, container)
 	}
 
 	err = UpdateContainerMetrics(cList)
@@ -59,6 +71,7 @@ func UpdateContainerMetrics(cList []*containers.Container) error {
 		if ctr.MemLimit == 0 {
 			ctr.MemLimit = memLimit
 		}
+		ctr.Network = convertMetaV2NetStats(stats)
 	}
 	return nil
 }
@@ -123,6 +136,155 @@ func convertMetaV2ContainerStats(s *v2.ContainerStats) (metrics.ContainerMetrics
 	}, s.Memory.Limit
 }
 
+// convertMetaV2NetStats returns internal network metrics representations from
+// an ECS metadata v2 container stats object. Per-interface stats are reported
+// for awsvpc tasks, otherwise the aggregated stats are used.
+func convertMetaV2NetStats(s *v2.ContainerStats) metrics.ContainerNetStats {
+	var netStats metrics.ContainerNetStats
+	if len(s.Networks) > 0 {
+		for name, ifStats := range s.Networks {
+			netStats = append(netStats, &metrics.InterfaceNetStats{
+				NetworkName: name,
+				BytesSent:   ifStats.TxBytes,
+				BytesRcvd:   ifStats.RxBytes,
+				PacketsSent: ifStats.TxPackets,
+				PacketsRcvd: ifStats.RxPackets,
+			})
+		}
+		sort.Slice(netStats, func(i, j int) bool {
+			return netStats[i].NetworkName < netStats[j].NetworkName
+		})
+		return netStats
+	}
+	if s.Network == (v2.NetStats{}) {
+		return nil
+	}
+	return metrics.ContainerNetStats{
+		&metrics.InterfaceNetStats{
+			NetworkName: containers.AwsvpcNetworkMode,
+			BytesSent:   s.Network.TxBytes,
+			BytesRcvd:   s.Network.RxBytes,
+			PacketsSent: s.Network.TxPackets,
+			PacketsRcvd: s.Network.RxPackets,
+		},
+	}
+}
+
+// taskLimits holds the task-level resources limits, which apply to every
+// container of the task that doesn't set its own limits.
+type taskLimits struct {
+	// cpu is expressed as a percentage of one core, like container.CPULimit
+	cpu float64
+	// memory is expressed in bytes
+	memory uint64
+}
+
+// parseTaskLimits converts the task-level limits reported by the ECS metadata
+// v2 API, expressed in vCPUs and MiB, into a taskLimits.
+func parseTaskLimits(limits map[string]float64) taskLimits {
+	var tl taskLimits
+	if l, found := limits["CPU"]; found && l > 0 {
+		tl.cpu = l * 100
+	}
+	if l, found := limits["Memory"]; found && l > 0 {
+		tl.memory = uint64(l) * 1024 * 1024
+	}
+	return tl
+}
+
+// applyTaskLimits sets the task-level limits on a container that doesn't
+// define its own in the task definition. Its own limits are read from the
+// metadata API, zero meaning unset: the container limits can't tell, the CPU
+// limit defaulting to one full core.
+func applyTaskLimits(container *containers.Container, ownLimits map[string]uint64, tl taskLimits) {
+	if tl.cpu > 0 && getLimit(ownLimits, "cpu") == 0 {
+		container.CPULimit = tl.cpu
+	}
+	if tl.memory > 0 && getLimit(ownLimits, "memory") == 0 && container.MemLimit == 0 {
+		container.MemLimit = tl.memory
+	}
+}
+
+// getLimit returns a limit of a container, the metadata API versions not
+// agreeing on the case of the names
+func getLimit(limits map[string]uint64, name string) uint64 {
+	for k, v := range limits {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return 0
+}
+
+// ApplyEC2TaskLimits sets the task-level limits on the containers of the ECS
+// tasks running on this EC2 instance that don't define their own.
+func ApplyEC2TaskLimits(cList []*containers.Container) {
+	metaV1, err := metadata.V1()
+	if err != nil {
+		log.Debugf("Unable to get the ECS tasks: %s", err)
+		return
+	}
+	tasks, err := metaV1.GetTasks()
+	if err != nil {
+		log.Debugf("Unable to get the ECS tasks: %s", err)
+		return
+	}
+
+	byID := make(map[string]*containers.Container, len(cList))
+	for _, ctr := range cList {
+		byID[ctr.ID] = ctr
+	}
+
+	for _, t := range tasks {
+		var taskContainers []*containers.Container
+		for _, c := range t.Containers {
+			if ctr, found := byID[c.DockerID]; found {
+				taskContainers = append(taskContainers, ctr)
+			}
+		}
+		if len(taskContainers) == 0 {
+			continue
+		}
+
+		task, err := getEC2Task(t.Arn, taskContainers[0].ID)
+		if err != nil {
+			log.Debugf("Unable to get the limits of the ECS task %s: %s", t.Arn, err)
+			continue
+		}
+		tl := parseTaskLimits(task.Limits)
+		ownLimits := make(map[string]map[string]uint64, len(task.Containers))
+		for _, c := range task.Containers {
+			ownLimits[c.DockerID] = c.Limits
+		}
+		for _, ctr := range taskContainers {
+			applyTaskLimits(ctr, ownLimits[ctr.ID], tl)
+		}
+	}
+}
+
+// getEC2Task returns the metadata v3 of a task from the endpoint of one of its
+// containers. The limits of a task don't change, it is cached.
+func getEC2Task(taskARN, containerID string) (*v3.Task, error) {
+	cacheKey := ec2TaskCacheKeyPrefix + taskARN
+	if cached, found := cache.Cache.Get(cacheKey); found {
+		if task, ok := cached.(*v3.Task); ok {
+			return task, nil
+		}
+		log.Errorf("Invalid cache format for key %q: forcing a cache miss", cacheKey)
+	}
+
+	client, err := metadata.V3(containerID)
+	if err != nil {
+		return nil, err
+	}
+	task, err := client.GetTask()
+	if err != nil {
+		return nil, err
+	}
+	cache.Cache.Set(cacheKey, task, ec2TaskCacheExpiration)
+	return task, nil
+}
+
 // parseContainerNetworkAddresses converts ECS container ports
 // and networks into a list of NetworkAddress
 func parseContainerNetworkAddresses(ports []v2.Port, networks []v2.Network, container string) []containers.NetworkAddress {
@@ -207,3 +207,58 @@ func TestParseContainerNetworkAddresses(t *testing.T) {
 	result := parseContainerNetworkAddresses(ports, networks, "mycontainer")
 	assert.Equal(t, expectedOutput, result)
 }
+
+func TestConvertMetaV2NetStats(t *testing.T) {
+	stats := &v2.ContainerStats{
+		Networks: map[string]v2.NetStats{
+			"eth1": {RxBytes: 4096, RxPackets: 40, TxBytes: 2048, TxPackets: 20},
+			"eth0": {RxBytes: 1024, RxPackets: 10, TxBytes: 512, TxPackets: 5},
+		},
+	}
+	expected := metrics.ContainerNetStats{
+		{NetworkName: "eth0", BytesRcvd: 1024, PacketsRcvd: 10, BytesSent: 512, PacketsSent: 5},
+		{NetworkName: "eth1", BytesRcvd: 4096, PacketsRcvd: 40, BytesSent: 2048, PacketsSent: 20},
+	}
+	assert.Equal(t, expected, convertMetaV2NetStats(stats))
+
+	stats = &v2.ContainerStats{
+		Network: v2.NetStats{RxBytes: 1024, RxPackets: 10, TxBytes: 512, TxPackets: 5},
+	}
+	expected = metrics.ContainerNetStats{
+		{NetworkName: "awsvpc", BytesRcvd: 1024, PacketsRcvd: 10, BytesSent: 512, PacketsSent: 5},
+	}
+	assert.Equal(t, expected, convertMetaV2NetStats(stats))
+
+	assert.Nil(t, convertMetaV2NetStats(&v2.ContainerStats{}))
+}
+
+func TestApplyTaskLimits(t *testing.T) {
+	tl := parseTaskLimits(map[string]float64{"CPU": 0.25, "Memory": 512})
+	assert.Equal(t, taskLimits{cpu: 25, memory: 512 * 1024 * 1024}, tl)
+
+	container := &containers.Container{}
+	container.CPULimit = 100
+	applyTaskLimits(container, map[string]uint64{"CPU": 0, "Memory": 0}, tl)
+	assert.Equal(t, float64(25), container.CPULimit)
+	assert.Equal(t, uint64(512*1024*1024), container.MemLimit)
+
+	container = &containers.Container{}
+	container.CPULimit = 10
+	container.MemLimit = 128 * 1024 * 1024
+	applyTaskLimits(container, map[string]uint64{"cpu": 10, "memory": 128 * 1024 * 1024}, tl)
+	assert.Equal(t, float64(10), container.CPULimit)
+	assert.Equal(t, uint64(128*1024*1024), container.MemLimit)
+
+	// A limit of one full core set in the task definition is kept
+	container = &containers.Container{}
+	container.CPULimit = 100
+	applyTaskLimits(container, map[string]uint64{"CPU": 1024}, tl)
+	assert.Equal(t, float64(100), container.CPULimit)
+	assert.Equal(t, uint64(512*1024*1024), container.MemLimit)
+
+	container = &containers.Container{}
+	container.CPULimit = 100
+	applyTaskLimits(container, nil, parseTaskLimits(nil))
+	assert.Equal(t, float64(100), container.CPULimit)
+	assert.Equal(t, uint64(0), container.MemLimit)
+}
@@ -57,6 +57,9 @@ type ContainerStats struct {
 	Memory  MemStats `json:"memory_stats"`
 	IO      IOStats  `json:"blkio_stats"`
 	Network NetStats `json:"network"`
+	// Networks is only reported for tasks using the awsvpc network mode
+	// (Fargate platform version 1.4.0 and later), keyed by interface name.
+	Networks map[string]NetStats `json:"networks,omitempty"`
 	//Pids    []int32  `json:"pids_stats"` // seems to be always empty
 }
 
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build gce
+
+package gce
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// declare these as vars not const to ease testing
+var (
+	computeAPIURL      = "https://compute.googleapis.com/compute/v1"
+	labelsCacheKey     = cache.BuildAgentKey("gce", "GetLabels")
+	lastLabelsCacheKey = cache.BuildAgentKey("gce", "GetLabels", "last")
+)
+
+type gceToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+type gceInstanceLabels struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// GetLabels returns the labels of the instance as tags. The labels are not
+// exposed by the metadata server, they are read from the Compute Engine API
+// with the token of the service account of the instance. They are refreshed
+// every cloud_provider_tags_refresh_interval, the last known labels are
+// returned when the API is unreachable.
+func GetLabels() ([]string, error) {
+	if !config.IsCloudProviderEnabled(CloudProviderName) {
+		return nil, fmt.Errorf("cloud provider is disabled by configuration")
+	}
+	if labels, found := cache.Cache.Get(labelsCacheKey); found {
+		return labels.([]string), nil
+	}
+
+	labels, err := fetchLabels()
+	if err != nil {
+		if labels, found := cache.Cache.Get(lastLabelsCacheKey); found {
+			log.Infof("unable to get labels from gce, returning the last known labels: %s", err)
+			return labels.([]string), nil
+		}
+		return nil, log.Warnf("unable to get labels from gce: %s", err)
+	}
+
+	refresh := time.Duration(config.Datadog.GetInt("cloud_provider_tags_refresh_interval")) * time.Second
+	cache.Cache.Set(labelsCacheKey, labels, refresh)
+	cache.Cache.Set(lastLabelsCacheKey, labels, cache.NoExpiration)
+	return labels, nil
+}
+
+func fetchLabels() ([]string, error) {
+	project, err := getResponse(metadataURL + "/project/project-id")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the project: %s", err)
+	}
+	zone, err := getResponse(metadataURL + "/instance/zone")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the zone: %s", err)
+	}
+	name, err := getResponse(metadataURL + "/instance/name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the instance name: %s", err)
+	}
+	rawToken, err := getResponse(metadataURL + "/instance/service-accounts/default/token")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get a token of the default service account, the instance needs a service account to collect its labels: %s", err)
+	}
+	token := gceToken{}
+	if err := json.Unmarshal([]byte(rawToken), &token); err != nil {
+		return nil, fmt.Errorf("unable to parse the service account token: %s", err)
+	}
+
+	// the zone is returned as projects/<numeric project id>/zones/<zone>
+	url := fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s?fields=labels", computeAPIURL, project, path.Base(zone), name)
+	client := http.Client{
+		Timeout: time.Duration(config.Datadog.GetInt("gce_metadata_timeout")) * time.Millisecond,
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("status code %d trying to GET %s: the service account of the instance needs the compute.instances.get permission, granted by the roles/compute.viewer role, and the instance needs the compute-ro or cloud-platform access scope", res.StatusCode, url)
+	default:
+		return nil, fmt.Errorf("status code %d trying to GET %s", res.StatusCode, url)
+	}
+
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the instance labels: %s", err)
+	}
+	instance := gceInstanceLabels{}
+	if err := json.Unmarshal(all, &instance); err != nil {
+		return nil, fmt.Errorf("unable to parse the instance labels: %s", err)
+	}
+
+	labels := make([]string, 0, len(instance.Labels))
+	for k, v := range instance.Labels {
+		labels = append(labels, fmt.Sprintf("%s:%s", k, v))
+	}
+	return labels, nil
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build gce
+
+package gce
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+func mockLabelsRequests(t *testing.T, computeStatus int) (*httptest.Server, *httptest.Server) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		switch r.URL.Path {
+		case "/project/project-id":
+			io.WriteString(w, "test-project")
+		case "/instance/zone":
+			io.WriteString(w, "projects/111111111111/zones/us-east1-b")
+		case "/instance/name":
+			io.WriteString(w, "dd-test")
+		case "/instance/service-accounts/default/token":
+			io.WriteString(w, `{"access_token":"secret-token","expires_in":3599,"token_type":"Bearer"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	compute := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/test-project/zones/us-east1-b/instances/dd-test", r.URL.Path)
+		assert.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+		if computeStatus != http.StatusOK {
+			http.Error(w, "denied", computeStatus)
+			return
+		}
+		io.WriteString(w, `{"labels":{"env":"prod","team":"fleet"}}`)
+	}))
+	metadataURL = metadata.URL
+	computeAPIURL = compute.URL
+	return metadata, compute
+}
+
+func TestGetLabels(t *testing.T) {
+	metadata, compute := mockLabelsRequests(t, http.StatusOK)
+	defer cache.Cache.Delete(labelsCacheKey)
+	defer cache.Cache.Delete(lastLabelsCacheKey)
+
+	labels, err := GetLabels()
+	require.NoError(t, err)
+	sort.Strings(labels)
+	assert.Equal(t, []string{"env:prod", "team:fleet"}, labels)
+
+	// the last known labels are returned once the refreshed labels expire and the api is unreachable
+	metadata.Close()
+	compute.Close()
+	cache.Cache.Delete(labelsCacheKey)
+	labels, err = GetLabels()
+	require.NoError(t, err)
+	assert.Len(t, labels, 2)
+}
+
+func TestGetLabelsPermissionDenied(t *testing.T) {
+	metadata, compute := mockLabelsRequests(t, http.StatusForbidden)
+	defer metadata.Close()
+	defer compute.Close()
+
+	_, err := GetLabels()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "compute.instances.get")
+}
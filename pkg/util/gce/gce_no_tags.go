@@ -13,3 +13,8 @@ func GetTags() ([]string, error) {
 
 	return tags, nil
 }
+
+// GetLabels returns the labels of the instance as tags
+func GetLabels() ([]string, error) {
+	return []string{}, nil
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -26,6 +27,7 @@ func CreateHTTPTransport() *http.Transport {
 	if config.Datadog.GetBool("force_tls_12") {
 		tlsConfig.MinVersion = tls.VersionTLS12
 	}
+	config.ConfigureFIPSTLS(tlsConfig)
 
 	// Most of the following timeouts are a copy of Golang http.DefaultTransport
 	// They are mostly used to act as safeguards in case we forget to add a general
@@ -58,31 +60,12 @@ func CreateHTTPTransport() *http.Transport {
 // would return the right proxy depending on the configuration.
 func GetProxyTransportFunc(p *config.Proxy) func(*http.Request) (*url.URL, error) {
 	return func(r *http.Request) (*url.URL, error) {
-		// check no_proxy list first
-		for _, host := range p.NoProxy {
-			if r.URL.Host == host {
-				log.Debugf("URL match no_proxy list item '%s': not using any proxy", host)
-				return nil, nil
-			}
-		}
-
-		// check proxy by scheme
-		confProxy := ""
-		if r.URL.Scheme == "http" {
-			confProxy = p.HTTP
-		} else if r.URL.Scheme == "https" {
-			confProxy = p.HTTPS
-		} else {
-			log.Warnf("Proxy configuration do not support scheme '%s'", r.URL.Scheme)
+		proxyURL, err := GetProxyForURL(p, r.URL)
+		if err != nil {
+			log.Error(err.Error())
+			return nil, err
 		}
-
-		if confProxy != "" {
-			proxyURL, err := url.Parse(confProxy)
-			if err != nil {
-				err := fmt.Errorf("Could not parse the proxy URL for scheme %s from configuration: %s", r.URL.Scheme, err)
-				log.Error(err.Error())
-				return nil, err
-			}
+		if proxyURL != nil {
 			userInfo := ""
 			if proxyURL.User != nil {
 				if _, isSet := proxyURL.User.Password(); isSet {
@@ -93,10 +76,87 @@ func GetProxyTransportFunc(p *config.Proxy) func(*http.Request) (*url.URL, error
 			}
 
 			log.Debugf("Using proxy %s://%s%s for URL '%s'", proxyURL.Scheme, userInfo, proxyURL.Host, SanitizeURL(r.URL.String()))
-			return proxyURL, nil
 		}
+		return proxyURL, nil
+	}
+}
+
+// GetProxyForURL returns the proxy to use for a URL, nil if the URL must be
+// reached directly
+func GetProxyForURL(p *config.Proxy, u *url.URL) (*url.URL, error) {
+	confProxy := ""
+	if endpoint, found := matchProxyEndpoint(p.Endpoints, u); found {
+		log.Debugf("URL '%s' matches the proxy endpoint '%s'", SanitizeURL(u.String()), endpoint.Host)
+		confProxy = endpoint.URL
+	} else {
+		// check no_proxy list first
+		for _, pattern := range p.NoProxy {
+			if matchNoProxy(pattern, u) {
+				log.Debugf("URL match no_proxy list item '%s': not using any proxy", pattern)
+				return nil, nil
+			}
+		}
+
+		// check proxy by scheme
+		if u.Scheme == "http" {
+			confProxy = p.HTTP
+		} else if u.Scheme == "https" {
+			confProxy = p.HTTPS
+		} else {
+			log.Warnf("Proxy configuration do not support scheme '%s'", u.Scheme)
+		}
+	}
 
+	if confProxy == "" {
 		// no proxy set for this request
 		return nil, nil
 	}
+	proxyURL, err := url.Parse(confProxy)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse the proxy URL for scheme %s from configuration: %s", u.Scheme, err)
+	}
+	return proxyURL, nil
+}
+
+func matchProxyEndpoint(endpoints []config.ProxyEndpoint, u *url.URL) (config.ProxyEndpoint, bool) {
+	for _, endpoint := range endpoints {
+		if matchNoProxy(endpoint.Host, u) {
+			return endpoint, true
+		}
+	}
+	return config.ProxyEndpoint{}, false
+}
+
+// matchNoProxy returns whether a URL matches a no_proxy pattern, one of:
+//   - "*", matching every host
+//   - a host, optionally with a port, matching exactly
+//   - a domain suffix starting with "." or "*.", matching its subdomains
+//   - an IP address or a CIDR range, matching the IP hosts in the range
+func matchNoProxy(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	if pattern == strings.ToLower(u.Host) || pattern == host {
+		return true
+	}
+
+	if _, cidr, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && cidr.Contains(ip)
+	}
+
+	pattern = strings.TrimPrefix(pattern, "*")
+	if strings.HasPrefix(pattern, ".") {
+		// A port in the pattern must match the port of the URL
+		if patternHost, port, err := net.SplitHostPort(pattern); err == nil {
+			return port == u.Port() && strings.HasSuffix(host, patternHost)
+		}
+		return strings.HasSuffix(host, pattern)
+	}
+	return false
 }
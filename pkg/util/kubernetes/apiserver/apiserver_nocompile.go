@@ -54,3 +54,9 @@ func GetNodeLabels(nodeName string) (map[string]string, error) {
 	log.Errorf("GetNodeLabels not implemented %s", ErrNotCompiled.Error())
 	return nil, nil
 }
+
+// GetNamespaceMetadata retrieves the labels and annotations of the queried namespace.
+func GetNamespaceMetadata(name string) (*apiv1.NamespaceMetadata, error) {
+	log.Errorf("GetNamespaceMetadata not implemented %s", ErrNotCompiled.Error())
+	return nil, nil
+}
@@ -52,6 +52,10 @@ var controllerCatalog = map[controllerName]controllerFuncs{
 		func() bool { return config.Datadog.GetBool("cluster_checks.enabled") },
 		startEndpointsInformer,
 	},
+	namespacesController: {
+		func() bool { return config.Datadog.GetBool("kubernetes_collect_metadata_tags") },
+		startNamespaceMetadataController,
+	},
 }
 
 type ControllerContext struct {
@@ -118,6 +122,13 @@ func startMetadataController(ctx ControllerContext, c chan error) {
 	go metaController.Run(ctx.StopCh)
 }
 
+// startNamespaceMetadataController starts the informer needed to serve the
+// namespace metadata. The synchronization of the informer is handled by the controller.
+func startNamespaceMetadataController(ctx ControllerContext, c chan error) {
+	nsController := NewNamespaceMetadataController(ctx.InformerFactory.Core().V1().Namespaces())
+	go nsController.Run(ctx.StopCh)
+}
+
 // startAutoscalersController starts the informers needed for autoscaling.
 // The synchronization of the informers is handled by the controller.
 func startAutoscalersController(ctx ControllerContext, c chan error) {
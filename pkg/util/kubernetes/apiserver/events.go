@@ -17,6 +17,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -150,3 +152,15 @@ func (c *APIClient) listForEventResync(eventReadTimeout int64, eventCardinalityL
 	}
 	return added, evList.ResourceVersion, time.Now(), nil
 }
+
+// NewEventInformer returns an informer on the events of all the namespaces
+// matching the given field selector. The caller is responsible for running it.
+func (c *APIClient) NewEventInformer(fieldSelector string) (cache.SharedIndexInformer, error) {
+	factory, err := getInformerFactoryWithOption(informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fieldSelector
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return factory.Core().V1().Events().Informer(), nil
+}
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	agentcache "github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	gocache "github.com/patrickmn/go-cache"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const namespaceMetadataCachePrefix = "KubernetesNamespaceMetadata"
+
+// globalNamespaceMetadataController is the controller serving the namespace
+// metadata, set once its informer is synced
+var (
+	globalNamespaceMetadataController   *NamespaceMetadataController
+	globalNamespaceMetadataControllerMu sync.RWMutex
+)
+
+// NamespaceMetadataController caches the labels and annotations of the
+// namespaces for the node agents. A cached entry is invalidated as soon as the
+// labels or the annotations of its namespace change.
+type NamespaceMetadataController struct {
+	namespaceLister       corelisters.NamespaceLister
+	namespaceListerSynced cache.InformerSynced
+
+	// mu prevents a stale entry to be cached while it is invalidated
+	mu    sync.Mutex
+	cache *gocache.Cache
+}
+
+// NewNamespaceMetadataController returns a controller watching the namespaces
+// through the given informer
+func NewNamespaceMetadataController(namespaceInformer coreinformers.NamespaceInformer) *NamespaceMetadataController {
+	m := &NamespaceMetadataController{
+		cache: agentcache.Cache,
+	}
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: m.updateNamespace,
+		DeleteFunc: m.deleteNamespace,
+	})
+	m.namespaceLister = namespaceInformer.Lister()
+	m.namespaceListerSynced = namespaceInformer.Informer().HasSynced
+
+	return m
+}
+
+// Run waits for the informer to be synced and serves the namespace metadata
+// until stopCh is closed
+func (m *NamespaceMetadataController) Run(stopCh <-chan struct{}) {
+	log.Infof("Starting namespace metadata controller")
+	defer log.Infof("Stopping namespace metadata controller")
+
+	if !cache.WaitForCacheSync(stopCh, m.namespaceListerSynced) {
+		return
+	}
+
+	globalNamespaceMetadataControllerMu.Lock()
+	globalNamespaceMetadataController = m
+	globalNamespaceMetadataControllerMu.Unlock()
+	<-stopCh
+}
+
+// get returns the metadata of a namespace from the cache, or from the informer
+// on a cache miss
+func (m *NamespaceMetadataController) get(name string) (*apiv1.NamespaceMetadata, error) {
+	cacheKey := agentcache.BuildAgentKey(namespaceMetadataCachePrefix, name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if v, found := m.cache.Get(cacheKey); found {
+		if metadata, ok := v.(*apiv1.NamespaceMetadata); ok {
+			return metadata, nil
+		}
+		log.Errorf("invalid cache format for the cacheKey: %s", cacheKey)
+	}
+
+	ns, err := m.namespaceLister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &apiv1.NamespaceMetadata{
+		Labels:      ns.Labels,
+		Annotations: ns.Annotations,
+	}
+	m.cache.Set(cacheKey, metadata, gocache.NoExpiration)
+	return metadata, nil
+}
+
+func (m *NamespaceMetadataController) updateNamespace(old, cur interface{}) {
+	oldNamespace, ok := old.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	newNamespace, ok := cur.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	if reflect.DeepEqual(oldNamespace.Labels, newNamespace.Labels) && reflect.DeepEqual(oldNamespace.Annotations, newNamespace.Annotations) {
+		return
+	}
+	log.Debugf("Metadata of namespace %s changed, invalidating its cache entry", newNamespace.Name)
+	m.invalidate(newNamespace.Name)
+}
+
+func (m *NamespaceMetadataController) deleteNamespace(obj interface{}) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			log.Debugf("Couldn't get object from tombstone %#v", obj)
+			return
+		}
+		ns, ok = tombstone.Obj.(*corev1.Namespace)
+		if !ok {
+			log.Debugf("Tombstone contained object that is not a namespace %#v", obj)
+			return
+		}
+	}
+	log.Debugf("Forgot namespace %s", ns.Name)
+	m.invalidate(ns.Name)
+}
+
+func (m *NamespaceMetadataController) invalidate(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cache.Delete(agentcache.BuildAgentKey(namespaceMetadataCachePrefix, name))
+}
+
+// GetNamespaceMetadata is used when the API endpoint of the DCA to get the
+// metadata of a namespace is hit.
+func GetNamespaceMetadata(name string) (*apiv1.NamespaceMetadata, error) {
+	if !config.Datadog.GetBool("kubernetes_collect_metadata_tags") {
+		return nil, log.Errorf("Metadata collection is disabled on the Cluster Agent")
+	}
+	globalNamespaceMetadataControllerMu.RLock()
+	m := globalNamespaceMetadataController
+	globalNamespaceMetadataControllerMu.RUnlock()
+	if m == nil {
+		return nil, fmt.Errorf("the namespace metadata controller is not started yet")
+	}
+	return m.get(name)
+}
+
+// NamespaceMetadata is used by the node agents to query the metadata of a
+// namespace when the DCA is not used.
+func (c *APIClient) NamespaceMetadata(name string) (*apiv1.NamespaceMetadata, error) {
+	ns, err := c.Cl.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.NamespaceMetadata{
+		Labels:      ns.Labels,
+		Annotations: ns.Annotations,
+	}, nil
+}
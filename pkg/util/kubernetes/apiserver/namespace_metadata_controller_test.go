@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+func newFakeNamespace(name string, labels, annotations map[string]string) *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestNamespaceMetadataController(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 1*time.Second)
+	namespaceInformer := informerFactory.Core().V1().Namespaces()
+	m := NewNamespaceMetadataController(namespaceInformer)
+	m.cache = gocache.New(gocache.NoExpiration, gocache.NoExpiration)
+
+	ns := newFakeNamespace("default", map[string]string{"team": "containers"}, map[string]string{"cost-center": "1234"})
+	store := namespaceInformer.Informer().GetStore()
+	require.NoError(t, store.Add(ns))
+
+	metadata, err := m.get("default")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "containers"}, metadata.Labels)
+	assert.Equal(t, map[string]string{"cost-center": "1234"}, metadata.Annotations)
+
+	_, err = m.get("unknown")
+	assert.Error(t, err)
+
+	// the cached entry is served until the metadata change
+	updated := newFakeNamespace("default", map[string]string{"team": "agent"}, map[string]string{"cost-center": "1234"})
+	require.NoError(t, store.Update(updated))
+	metadata, err = m.get("default")
+	require.NoError(t, err)
+	assert.Equal(t, "containers", metadata.Labels["team"])
+
+	// an update without metadata change keeps the cached entry
+	m.updateNamespace(ns, ns.DeepCopy())
+	metadata, err = m.get("default")
+	require.NoError(t, err)
+	assert.Equal(t, "containers", metadata.Labels["team"])
+
+	m.updateNamespace(ns, updated)
+	metadata, err = m.get("default")
+	require.NoError(t, err)
+	assert.Equal(t, "agent", metadata.Labels["team"])
+
+	// deletions are also handled from tombstones
+	require.NoError(t, store.Delete(updated))
+	m.deleteNamespace(cache.DeletedFinalStateUnknown{Key: "default", Obj: updated})
+	_, err = m.get("default")
+	assert.Error(t, err)
+}
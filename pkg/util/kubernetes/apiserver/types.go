@@ -19,6 +19,7 @@ const (
 	autoscalersController controllerName = "autoscalers"
 	servicesController    controllerName = "services"
 	endpointsController   controllerName = "endpoints"
+	namespacesController  controllerName = "namespaces"
 )
 
 // InformerName represents the kubernetes informer names
@@ -23,5 +23,8 @@ func diagnose() error {
 	if err != nil {
 		log.Error(err)
 	}
+	if report := getConnectionReport(); report != nil {
+		log.Info(report.String())
+	}
 	return err
 }
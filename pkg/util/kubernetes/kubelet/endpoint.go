@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// kubeletEndpointCacheKey is the persistent cache key used to remember
+	// the last working kubelet endpoint across agent restarts
+	kubeletEndpointCacheKey = "kubelet:endpoint"
+
+	hostTypeIP       = "ip"
+	hostTypeHostname = "hostname"
+
+	// Scores of a reachable endpoint: secure connections are always preferred
+	// over the read-only port, and ips are preferred over hostnames.
+	scoreHTTPS  = 20
+	scoreHTTP   = 10
+	scoreHostIP = 1
+)
+
+// endpointHealth holds the result of probing a kubelet endpoint candidate
+type endpointHealth struct {
+	Scheme   string        `json:"scheme"`
+	Host     string        `json:"host"`
+	Port     int           `json:"port"`
+	HostType string        `json:"host_type"`
+	Score    int           `json:"score"`
+	Latency  time.Duration `json:"latency"`
+	Probed   bool          `json:"probed"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// String returns the endpoint URL of the candidate
+func (e *endpointHealth) String() string {
+	return fmt.Sprintf("%s://%s:%d", e.Scheme, e.Host, e.Port)
+}
+
+// connectionReport keeps track of how the kubelet endpoint was selected. The
+// candidates are probed in order of preference until one works, the next ones
+// are reported unprobed.
+type connectionReport struct {
+	Time       time.Time        `json:"time"`
+	FromCache  bool             `json:"from_cache"`
+	Selected   *endpointHealth  `json:"selected,omitempty"`
+	Candidates []endpointHealth `json:"candidates"`
+}
+
+// GetConnectionReport returns how the kubelet endpoint was selected by the
+// global KubeUtil as JSON, or nil if it is not initialized
+func GetConnectionReport() ([]byte, error) {
+	report := getConnectionReport()
+	if report == nil {
+		return nil, nil
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// getConnectionReport returns how the kubelet endpoint was selected by the
+// global KubeUtil, if any
+func getConnectionReport() *connectionReport {
+	globalKubeUtilMutex.Lock()
+	ku := globalKubeUtil
+	globalKubeUtilMutex.Unlock()
+	if ku == nil {
+		return nil
+	}
+	ku.RLock()
+	defer ku.RUnlock()
+	return ku.connectionReport
+}
+
+// String returns a human readable summary of the report, used by diagnose
+func (r *connectionReport) String() string {
+	var b strings.Builder
+	if r.Selected != nil {
+		fmt.Fprintf(&b, "Selected kubelet endpoint %s (score %d, cached: %t)\n", r.Selected, r.Selected.Score, r.FromCache)
+	} else {
+		b.WriteString("No kubelet endpoint could be selected\n")
+	}
+	for _, c := range r.Candidates {
+		if !c.Probed {
+			fmt.Fprintf(&b, "  - %s (%s): not probed\n", c.String(), c.HostType)
+			continue
+		}
+		fmt.Fprintf(&b, "  - %s (%s): score %d, latency %s", c.String(), c.HostType, c.Score, c.Latency)
+		if c.Error != "" {
+			fmt.Fprintf(&b, ", error: %s", c.Error)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// buildEndpointCandidates returns every scheme/host/port combination that
+// should be probed, in order of preference, which is the order of their
+// scores
+func buildEndpointCandidates(hosts *connectionInfo, httpsPort, httpPort int) []endpointHealth {
+	var candidates []endpointHealth
+	add := func(scheme string, port int) {
+		for _, ip := range hosts.ips {
+			candidates = append(candidates, endpointHealth{Scheme: scheme, Host: ip, Port: port, HostType: hostTypeIP})
+		}
+		for _, hostname := range hosts.hostnames {
+			candidates = append(candidates, endpointHealth{Scheme: scheme, Host: hostname, Port: port, HostType: hostTypeHostname})
+		}
+	}
+	if httpsPort > 0 {
+		add("https", httpsPort)
+	}
+	if httpPort > 0 {
+		add("http", httpPort)
+	}
+	return candidates
+}
+
+// probeEndpoint checks the connection to a candidate and scores it, a score
+// of zero meaning the candidate cannot be used. The candidate is probed with
+// its own connection state, ku is only set up with the selected endpoint.
+func (ku *KubeUtil) probeEndpoint(candidate *endpointHealth) {
+	start := time.Now()
+	var err error
+	switch candidate.Scheme {
+	case "https":
+		probe := &KubeUtil{
+			kubeletHost:              candidate.Host,
+			kubeletAPIClient:         &http.Client{Timeout: ku.kubeletAPIClient.Timeout},
+			kubeletAPIRequestHeaders: &http.Header{},
+			rawConnectionInfo:        make(map[string]string),
+		}
+		if err = probe.setupkubeletAPIClient(); err == nil {
+			err = checkKubeletHTTPSConnection(probe, candidate.Port)
+		}
+	default:
+		err = checkKubeletHTTPConnection(candidate.Host, candidate.Port)
+	}
+	candidate.Latency = time.Since(start)
+	candidate.Probed = true
+
+	if err != nil {
+		log.Debugf("Cannot connect to kubelet using %s: %v", candidate, err)
+		candidate.Error = err.Error()
+		candidate.Score = 0
+		return
+	}
+
+	candidate.Error = ""
+	candidate.Score = scoreHTTP
+	if candidate.Scheme == "https" {
+		candidate.Score = scoreHTTPS
+	}
+	if candidate.HostType == hostTypeIP {
+		candidate.Score += scoreHostIP
+	}
+	log.Debugf("Can connect to kubelet using %s, score %d", candidate, candidate.Score)
+}
+
+// selectBestEndpoint returns the candidate with the highest score, the first
+// one winning ties. It returns nil if no candidate is usable.
+func selectBestEndpoint(candidates []endpointHealth) *endpointHealth {
+	var best *endpointHealth
+	for i := range candidates {
+		if candidates[i].Score <= 0 {
+			continue
+		}
+		if best == nil || candidates[i].Score > best.Score {
+			best = &candidates[i]
+		}
+	}
+	return best
+}
+
+// readCachedEndpoint returns the last working endpoint, if any
+func readCachedEndpoint() *endpointHealth {
+	raw, err := persistentcache.Read(kubeletEndpointCacheKey)
+	if err != nil {
+		log.Debugf("Unable to read the cached kubelet endpoint: %s", err)
+		return nil
+	}
+	if raw == "" {
+		return nil
+	}
+	var cached endpointHealth
+	if err := json.Unmarshal([]byte(raw), &cached); err != nil {
+		log.Debugf("Unable to parse the cached kubelet endpoint: %s", err)
+		return nil
+	}
+	if cached.Host == "" || cached.Port <= 0 {
+		return nil
+	}
+	return &cached
+}
+
+// writeCachedEndpoint stores the selected endpoint to speed up the next start
+func writeCachedEndpoint(selected *endpointHealth) {
+	raw, err := json.Marshal(endpointHealth{
+		Scheme:   selected.Scheme,
+		Host:     selected.Host,
+		Port:     selected.Port,
+		HostType: selected.HostType,
+	})
+	if err != nil {
+		log.Debugf("Unable to serialize the kubelet endpoint: %s", err)
+		return
+	}
+	if err := persistentcache.Write(kubeletEndpointCacheKey, string(raw)); err != nil {
+		log.Debugf("Unable to cache the kubelet endpoint: %s", err)
+	}
+}
+
+// isCandidate returns whether the cached endpoint is still part of the
+// potential endpoints, so that a configuration change invalidates the cache
+func isCandidate(cached *endpointHealth, candidates []endpointHealth) bool {
+	for _, c := range candidates {
+		if c.Scheme == cached.Scheme && c.Host == cached.Host && c.Port == cached.Port {
+			return true
+		}
+	}
+	return false
+}
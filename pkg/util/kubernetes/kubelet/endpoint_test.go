@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestBuildEndpointCandidates(t *testing.T) {
+	hosts := &connectionInfo{
+		ips:       []string{"10.0.0.1"},
+		hostnames: []string{"node-1"},
+	}
+
+	candidates := buildEndpointCandidates(hosts, 10250, 10255)
+	assert.Equal(t, []endpointHealth{
+		{Scheme: "https", Host: "10.0.0.1", Port: 10250, HostType: hostTypeIP},
+		{Scheme: "https", Host: "node-1", Port: 10250, HostType: hostTypeHostname},
+		{Scheme: "http", Host: "10.0.0.1", Port: 10255, HostType: hostTypeIP},
+		{Scheme: "http", Host: "node-1", Port: 10255, HostType: hostTypeHostname},
+	}, candidates)
+
+	candidates = buildEndpointCandidates(hosts, 10250, -1)
+	assert.Len(t, candidates, 2)
+}
+
+func TestSelectBestEndpoint(t *testing.T) {
+	assert.Nil(t, selectBestEndpoint(nil))
+	assert.Nil(t, selectBestEndpoint([]endpointHealth{
+		{Scheme: "https", Host: "10.0.0.1", Error: "connection refused"},
+	}))
+
+	candidates := []endpointHealth{
+		{Scheme: "https", Host: "10.0.0.1", Error: "connection refused"},
+		{Scheme: "https", Host: "node-1", Score: scoreHTTPS},
+		{Scheme: "http", Host: "10.0.0.1", Score: scoreHTTP + scoreHostIP},
+		{Scheme: "http", Host: "node-1", Score: scoreHTTP},
+	}
+	best := selectBestEndpoint(candidates)
+	require.NotNil(t, best)
+	assert.Equal(t, "https://node-1:0", best.String())
+
+	// ties are won by the first candidate
+	candidates = []endpointHealth{
+		{Scheme: "http", Host: "10.0.0.1", Port: 10255, Score: scoreHTTP + scoreHostIP},
+		{Scheme: "http", Host: "10.0.0.2", Port: 10255, Score: scoreHTTP + scoreHostIP},
+	}
+	best = selectBestEndpoint(candidates)
+	require.NotNil(t, best)
+	assert.Equal(t, "10.0.0.1", best.Host)
+}
+
+func TestCachedEndpoint(t *testing.T) {
+	runPath, err := ioutil.TempDir("", "kubelet-endpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(runPath)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", runPath)
+
+	assert.Nil(t, readCachedEndpoint())
+
+	writeCachedEndpoint(&endpointHealth{Scheme: "https", Host: "10.0.0.1", Port: 10250, HostType: hostTypeIP, Score: scoreHTTPS + scoreHostIP})
+	cached := readCachedEndpoint()
+	require.NotNil(t, cached)
+	assert.Equal(t, endpointHealth{Scheme: "https", Host: "10.0.0.1", Port: 10250, HostType: hostTypeIP}, *cached)
+
+	hosts := &connectionInfo{ips: []string{"10.0.0.1"}}
+	assert.True(t, isCandidate(cached, buildEndpointCandidates(hosts, 10250, 10255)))
+	assert.False(t, isCandidate(cached, buildEndpointCandidates(hosts, 443, 10255)))
+}
+
+func TestProbeEndpointKeepsConnectionState(t *testing.T) {
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	ku := newKubeUtil()
+	ku.kubeletHost = "10.0.0.1"
+	ku.kubeletAPIEndpoint = "https://10.0.0.1:10250"
+
+	candidate := endpointHealth{Scheme: "https", Host: "127.0.0.1", Port: port, HostType: hostTypeIP}
+	ku.probeEndpoint(&candidate)
+	assert.True(t, candidate.Probed)
+	assert.Equal(t, 0, candidate.Score)
+	assert.NotEmpty(t, candidate.Error)
+
+	assert.Equal(t, "10.0.0.1", ku.kubeletHost)
+	assert.Equal(t, "https://10.0.0.1:10250", ku.kubeletAPIEndpoint)
+	assert.Empty(t, ku.kubeletAPIRequestHeaders.Get(authorizationHeaderKey))
+}
+
+func TestSetKubeletHostStopsAtFirstUsableEndpoint(t *testing.T) {
+	runPath, err := ioutil.TempDir("", "kubelet-endpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(runPath)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", runPath)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	port, err := strconv.Atoi(server.URL[len("http://127.0.0.1:"):])
+	require.NoError(t, err)
+
+	ku := newKubeUtil()
+	hosts := &connectionInfo{ips: []string{"127.0.0.1"}, hostnames: []string{"localhost"}}
+	require.NoError(t, ku.setKubeletHost(hosts, -1, port))
+	assert.Equal(t, "127.0.0.1", ku.kubeletHost)
+
+	report := ku.connectionReport
+	require.NotNil(t, report)
+	require.NotNil(t, report.Selected)
+	assert.Equal(t, "127.0.0.1", report.Selected.Host)
+	require.Len(t, report.Candidates, 2)
+	assert.True(t, report.Candidates[0].Probed)
+	assert.False(t, report.Candidates[1].Probed)
+}
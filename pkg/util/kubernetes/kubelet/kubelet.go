@@ -64,6 +64,7 @@ type KubeUtil struct {
 	filter                   *containers.Filter
 	waitOnMissingContainer   time.Duration
 	podUnmarshaller          *podUnmarshaller
+	connectionReport         *connectionReport // how the kubelet endpoint was selected
 }
 
 // ResetGlobalKubeUtil is a helper to remove the current KubeUtil global
@@ -695,9 +696,10 @@ func dedupeConnectionInfo(hosts *connectionInfo) {
 }
 
 // setKubeletHost select a kubelet host from potential kubelet hosts
-// the method check HTTPS connections first and prioritize ips over hostnames
+// the candidates are probed and scored in order of preference, HTTPS connections
+// are preferred and ips are prioritized over hostnames. The last working endpoint
+// is cached across restarts and tried first.
 func (ku *KubeUtil) setKubeletHost(hosts *connectionInfo, httpsPort, httpPort int) error {
-	var connectionErrors []error
 	log.Debugf("Trying several connection methods to locate the kubelet...")
 	if ku.kubeletProxyEnabled && config.Datadog.Get("kubernetes_kubelet_nodename") != "" {
 		ku.kubeletAPIEndpoint = fmt.Sprintf("https://%s:%s/api/v1/nodes/%s/proxy/", os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"), config.Datadog.Get("kubernetes_kubelet_nodename"))
@@ -705,85 +707,49 @@ func (ku *KubeUtil) setKubeletHost(hosts *connectionInfo, httpsPort, httpPort in
 		return nil
 	}
 
-	var kubeletHost string
-	var errors []error
-
-	if httpsPort > 0 {
-		kubeletHost, errors = selectFromPotentialHostsHTTPS(ku, hosts.ips, httpsPort)
-		if kubeletHost != "" && errors == nil {
-			log.Infof("Connection to the kubelet succeeded! %s is set as kubelet host", kubeletHost)
-			return nil
-		}
-		connectionErrors = append(connectionErrors, errors...)
-
-		kubeletHost, errors = selectFromPotentialHostsHTTPS(ku, hosts.hostnames, httpsPort)
-		if kubeletHost != "" && errors == nil {
-			log.Infof("Connection to the kubelet succeeded! %s is set as kubelet host", kubeletHost)
+	candidates := buildEndpointCandidates(hosts, httpsPort, httpPort)
+	report := &connectionReport{Time: time.Now()}
+	defer func() {
+		ku.Lock()
+		ku.connectionReport = report
+		ku.Unlock()
+	}()
+
+	if cached := readCachedEndpoint(); cached != nil && isCandidate(cached, candidates) {
+		ku.probeEndpoint(cached)
+		if cached.Score > 0 {
+			report.FromCache = true
+			report.Selected = cached
+			report.Candidates = []endpointHealth{*cached}
+			ku.kubeletHost = cached.Host
+			log.Infof("Connection to the kubelet succeeded using the cached endpoint! %s is set as kubelet host", cached.Host)
 			return nil
 		}
-		connectionErrors = append(connectionErrors, errors...)
+		log.Debugf("Cached kubelet endpoint %s is not usable anymore, probing all candidates", cached)
 	}
 
-	if httpPort > 0 {
-		kubeletHost, errors = selectFromPotentialHostsHTTP(hosts.ips, httpPort)
-		if kubeletHost != "" && errors == nil {
-			ku.kubeletHost = kubeletHost
-			log.Infof("Connection to the kubelet succeeded! %s is set as kubelet host", kubeletHost)
-			return nil
-		}
-		connectionErrors = append(connectionErrors, errors...)
-
-		kubeletHost, errors = selectFromPotentialHostsHTTP(hosts.hostnames, httpPort)
-		if kubeletHost != "" && errors == nil {
-			ku.kubeletHost = kubeletHost
-			log.Infof("Connection to the kubelet succeeded! %s is set as kubelet host", kubeletHost)
-			return nil
+	// The candidates are sorted by score, the first usable one is selected
+	var connectionErrors []string
+	for i := range candidates {
+		ku.probeEndpoint(&candidates[i])
+		if candidates[i].Score > 0 {
+			break
 		}
-		connectionErrors = append(connectionErrors, errors...)
+		connectionErrors = append(connectionErrors, candidates[i].Error)
 	}
+	report.Candidates = candidates
 
-	log.Debug("All connection attempts to the Kubelet failed.")
-	return fmt.Errorf("cannot set a valid kubelet host: cannot connect to kubelet using any of the given hosts: %v %v, Errors: %v", hosts.ips, hosts.hostnames, connectionErrors)
-}
-
-func selectFromPotentialHostsHTTPS(ku *KubeUtil, hosts []string, httpsPort int) (string, []error) {
-	var connectionErrors []error
-	for _, host := range hosts {
-		log.Debugf("Trying to use host %s with HTTPS", host)
-		ku.kubeletHost = host
-		err := ku.setupkubeletAPIClient()
-		if err != nil {
-			log.Debugf("Cannot setup https kubelet api client for %s: %v", host, err)
-			connectionErrors = append(connectionErrors, err)
-			continue
-		}
-
-		err = checkKubeletHTTPSConnection(ku, httpsPort)
-		if err == nil {
-			log.Debugf("Can connect to kubelet using %s and HTTPS", host)
-			return host, nil
-		}
-		log.Debugf("Cannot connect to kubelet using %s and https: %v", host, err)
-		connectionErrors = append(connectionErrors, err)
+	selected := selectBestEndpoint(candidates)
+	if selected == nil {
+		log.Debug("All connection attempts to the Kubelet failed.")
+		return fmt.Errorf("cannot set a valid kubelet host: cannot connect to kubelet using any of the given hosts: %v %v, Errors: %v", hosts.ips, hosts.hostnames, connectionErrors)
 	}
 
-	return "", connectionErrors
-}
-
-func selectFromPotentialHostsHTTP(hosts []string, httpPort int) (string, []error) {
-	var connectionErrors []error
-	for _, host := range hosts {
-		log.Debugf("Trying to use host %s with HTTP", host)
-		err := checkKubeletHTTPConnection(host, httpPort)
-		if err == nil {
-			log.Debugf("Can connect to kubelet using %s and HTTP", host)
-			return host, nil
-		}
-		log.Debugf("Cannot connect to kubelet using %s and http: %v", host, err)
-		connectionErrors = append(connectionErrors, err)
-	}
-
-	return "", connectionErrors
+	report.Selected = selected
+	ku.kubeletHost = selected.Host
+	writeCachedEndpoint(selected)
+	log.Infof("Connection to the kubelet succeeded! %s is set as kubelet host", selected.Host)
+	return nil
 }
 
 func checkKubeletHTTPSConnection(ku *KubeUtil, httpsPort int) error {
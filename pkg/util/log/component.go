@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+// componentsByFile caches the component of the files logging messages
+var componentsByFile sync.Map
+
+// ComponentFromPath returns the component of the agent a source file belongs
+// to: its package path relative to the pkg or cmd folder of the repository,
+// e.g. "forwarder" for pkg/forwarder/forwarder.go or "collector/corechecks/system"
+// for pkg/collector/corechecks/system/cpu.go
+func ComponentFromPath(fullPath string) string {
+	// Trim the path of the project, ie DataDog/datadog-agent/
+	slices := strings.Split(path.Clean(strings.Replace(fullPath, "\\", "/", -1)), "-agent/")
+	short := slices[len(slices)-1]
+
+	for i := 0; i < len(short); i++ {
+		if i > 0 && short[i-1] != '/' {
+			continue
+		}
+		if strings.HasPrefix(short[i:], "pkg/") || strings.HasPrefix(short[i:], "cmd/") {
+			short = short[i+len("pkg/"):]
+			break
+		}
+	}
+	return path.Dir(short)
+}
+
+// callerComponent returns the component of the function logging a message,
+// skipping the given number of frames
+func callerComponent(skip int) string {
+	_, file, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	if component, found := componentsByFile.Load(file); found {
+		return component.(string)
+	}
+	component := ComponentFromPath(file)
+	componentsByFile.Store(file, component)
+	return component
+}
+
+// componentLevel returns the log level of a component: the level of its most
+// specific override, e.g. "collector/corechecks" before "collector", or the
+// global log level
+func componentLevel(component string, level seelog.LogLevel, overrides map[string]seelog.LogLevel) seelog.LogLevel {
+	for {
+		if lvl, found := overrides[component]; found {
+			return lvl
+		}
+		i := strings.LastIndex(component, "/")
+		if i < 0 {
+			return level
+		}
+		component = component[:i]
+	}
+}
+
+// parseLogLevelOverrides validates the log levels of the components
+func parseLogLevelOverrides(overrides map[string]string) (map[string]seelog.LogLevel, error) {
+	levels := make(map[string]seelog.LogLevel, len(overrides))
+	for component, level := range overrides {
+		lvl, ok := seelog.LogLevelFromString(strings.ToLower(level))
+		if !ok {
+			return nil, fmt.Errorf("bad log level %q for component %s", level, component)
+		}
+		levels[strings.Trim(component, "/")] = lvl
+	}
+	return levels, nil
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentFromPath(t *testing.T) {
+	// omnibus path
+	assert.Equal(t, "collector", ComponentFromPath("/go/src/github.com/DataDog/datadog-agent/.omnibus/src/datadog-agent/src/github.com/DataDog/datadog-agent/pkg/collector/scheduler.go"))
+	// dev env path
+	assert.Equal(t, "agent/app", ComponentFromPath("/home/vagrant/go/src/github.com/DataDog/datadog-agent/cmd/agent/app/start.go"))
+	// nested package
+	assert.Equal(t, "collector/corechecks/system", ComponentFromPath("/src/datadog-agent/pkg/collector/corechecks/system/cpu.go"))
+	// windows path
+	assert.Equal(t, "forwarder", ComponentFromPath(`C:\src\datadog-agent\pkg\forwarder\forwarder.go`))
+	// checkout not named after the project
+	assert.Equal(t, "util/log", ComponentFromPath("/root/module/pkg/util/log/log.go"))
+	// relative path
+	assert.Equal(t, "forwarder", ComponentFromPath("pkg/forwarder/forwarder.go"))
+}
+
+func TestComponentLevel(t *testing.T) {
+	overrides := map[string]seelog.LogLevel{
+		"collector":            seelog.DebugLvl,
+		"collector/corechecks": seelog.WarnLvl,
+	}
+	assert.Equal(t, seelog.DebugLvl, componentLevel("collector", seelog.InfoLvl, overrides))
+	assert.Equal(t, seelog.DebugLvl, componentLevel("collector/py", seelog.InfoLvl, overrides))
+	assert.Equal(t, seelog.WarnLvl, componentLevel("collector/corechecks/system", seelog.InfoLvl, overrides))
+	assert.Equal(t, seelog.InfoLvl, componentLevel("forwarder", seelog.InfoLvl, overrides))
+	// A prefix of the name isn't a parent component
+	assert.Equal(t, seelog.InfoLvl, componentLevel("collectors", seelog.InfoLvl, overrides))
+}
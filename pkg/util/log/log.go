@@ -12,6 +12,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/cihub/seelog"
 )
@@ -29,14 +30,21 @@ var (
 	bufferLogsBeforeInit = true
 	bufferMutex          sync.Mutex
 	defaultStackDepth    = 2
+
+	// shedLevel is the level under which the logs are dropped to relieve
+	// the memory pressure, see pkg/util/memorypressure
+	shedLevel uint32
+	// shedCount is the number of logs dropped because of shedLevel
+	shedCount uint64
 )
 
 // DatadogLogger wrapper structure for seelog
 type DatadogLogger struct {
-	inner seelog.LoggerInterface
-	level seelog.LogLevel
-	extra map[string]seelog.LoggerInterface
-	l     sync.RWMutex
+	inner     seelog.LoggerInterface
+	level     seelog.LogLevel
+	overrides map[string]seelog.LogLevel
+	extra     map[string]seelog.LoggerInterface
+	l         sync.RWMutex
 }
 
 // SetupDatadogLogger configure logger singleton with seelog interface
@@ -100,12 +108,29 @@ func (sw *DatadogLogger) changeLogLevel(level string) error {
 	return nil
 }
 
+func (sw *DatadogLogger) changeLogLevelOverrides(overrides map[string]seelog.LogLevel) {
+	sw.l.Lock()
+	defer sw.l.Unlock()
+
+	sw.overrides = overrides
+}
+
 func (sw *DatadogLogger) shouldLog(level seelog.LogLevel) bool {
 	sw.l.RLock()
-	shouldLog := level >= sw.level
-	sw.l.RUnlock()
+	defer sw.l.RUnlock()
 
-	return shouldLog
+	var allowed bool
+	if len(sw.overrides) == 0 {
+		allowed = level >= sw.level
+	} else {
+		// Skip shouldLog and the exported function to get the component of the caller
+		allowed = level >= componentLevel(callerComponent(2), sw.level, sw.overrides)
+	}
+	if allowed && level < seelog.LogLevel(atomic.LoadUint32(&shedLevel)) {
+		atomic.AddUint64(&shedCount, 1)
+		return false
+	}
+	return allowed
 }
 
 func (sw *DatadogLogger) registerAdditionalLogger(n string, l seelog.LoggerInterface) error {
@@ -337,6 +362,18 @@ func (sw *DatadogLogger) getLogLevel() seelog.LogLevel {
 	return sw.level
 }
 
+// getLogLevelOverrides returns the current log levels of the components
+func (sw *DatadogLogger) getLogLevelOverrides() map[string]string {
+	sw.l.RLock()
+	defer sw.l.RUnlock()
+
+	overrides := make(map[string]string, len(sw.overrides))
+	for component, level := range sw.overrides {
+		overrides[component] = level.String()
+	}
+	return overrides
+}
+
 func buildLogEntry(v ...interface{}) string {
 	var fmtBuffer bytes.Buffer
 
@@ -557,6 +594,42 @@ func GetLogLevel() (seelog.LogLevel, error) {
 	return seelog.InfoLvl, errors.New("cannot get loglevel: logger not initialized")
 }
 
+// SetShedLevel drops the logs under the given level, whatever the configured
+// log level, until it's set back to seelog.TraceLvl
+func SetShedLevel(level seelog.LogLevel) {
+	atomic.StoreUint32(&shedLevel, uint32(level))
+}
+
+// ShedCount returns the number of logs dropped because of the shed level
+func ShedCount() uint64 {
+	return atomic.LoadUint64(&shedCount)
+}
+
+// GetLogLevelOverrides returns the log levels overriding the global one for
+// some components, by component
+func GetLogLevelOverrides() (map[string]string, error) {
+	if logger != nil && logger.inner != nil {
+		return logger.getLogLevelOverrides(), nil
+	}
+	return nil, errors.New("cannot get loglevel overrides: logger not initialized")
+}
+
+// SetLogLevelOverrides sets the log levels overriding the global one for some
+// components, by component. A component is a package path relative to the pkg
+// or cmd folder, and its level applies to its sub-packages too. The inner
+// seelog logger must accept the lowest of these levels.
+func SetLogLevelOverrides(overrides map[string]string) error {
+	levels, err := parseLogLevelOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	if logger != nil && logger.inner != nil {
+		logger.changeLogLevelOverrides(levels)
+		return nil
+	}
+	return errors.New("cannot set loglevel overrides: logger not initialized")
+}
+
 // ChangeLogLevel changes the current log level, valide levels are trace, debug,
 // info, warn, error, critical and off, it requires a new seelog logger because
 // an existing one cannot be updated
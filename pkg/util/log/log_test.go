@@ -238,3 +238,69 @@ func TestCriticalfNotNil(t *testing.T) {
 
 	assert.NotNil(t, Criticalf("test"))
 }
+
+func TestLogLevelOverrides(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	l, err := seelog.LoggerFromWriterWithMinLevelAndFormat(w, seelog.TraceLvl, "[%LEVEL] %FuncShort: %Msg")
+	assert.Nil(t, err)
+
+	SetupDatadogLogger(l, "info")
+	assert.NotNil(t, logger)
+
+	// This file belongs to the util/log component
+	assert.Nil(t, SetLogLevelOverrides(map[string]string{"util": "debug", "forwarder": "error"}))
+	Debugf("%s", "foo")
+	Tracef("%s", "foo")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "foo"))
+
+	// The most specific component wins
+	assert.Nil(t, SetLogLevelOverrides(map[string]string{"util": "debug", "util/log": "warn"}))
+	Infof("%s", "bar")
+	Warnf("%s", "bar")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "bar"))
+
+	overrides, err := GetLogLevelOverrides()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"util": "debug", "util/log": "warn"}, overrides)
+
+	assert.NotNil(t, SetLogLevelOverrides(map[string]string{"util": "verbose"}))
+
+	// Without overrides the global level applies
+	assert.Nil(t, SetLogLevelOverrides(nil))
+	Debugf("%s", "baz")
+	Infof("%s", "baz")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "baz"))
+}
+
+func TestShedLevel(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	l, err := seelog.LoggerFromWriterWithMinLevelAndFormat(w, seelog.TraceLvl, "[%LEVEL] %FuncShort: %Msg")
+	assert.Nil(t, err)
+
+	SetupDatadogLogger(l, "debug")
+	defer SetShedLevel(seelog.TraceLvl)
+
+	shed := ShedCount()
+	SetShedLevel(seelog.InfoLvl)
+	Tracef("%s", "foo")
+	Debugf("%s", "foo")
+	Infof("%s", "foo")
+	Warnf("%s", "foo")
+	w.Flush()
+	assert.Equal(t, 2, strings.Count(b.String(), "foo"))
+	// the trace log is below the log level, it isn't counted as shed
+	assert.Equal(t, shed+1, ShedCount())
+
+	SetShedLevel(seelog.TraceLvl)
+	Debugf("%s", "bar")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "bar"))
+	assert.Equal(t, shed+1, ShedCount())
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const compressedExt = ".gz"
+
+// RotatingFile is a log file rotated once it reaches its maximum size. The
+// rotated files are named after the log file with a .1, .2... suffix, .1
+// being the most recent one, and are optionally compressed with gzip.
+// Rotated files are removed when there are more than the maximum number of
+// rolls, or when they are older than the maximum age.
+type RotatingFile struct {
+	path     string
+	maxSize  int64
+	maxRolls int
+	maxAge   time.Duration
+	compress bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	now  func() time.Time
+}
+
+// NewRotatingFile opens a log file, creating it if needed. A maxSize of 0
+// disables the rotation, maxRolls and maxAge of 0 disable the retention
+// based on the number and age of the rotated files.
+func NewRotatingFile(path string, maxSize int64, maxRolls int, maxAge time.Duration, compress bool) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxRolls: maxRolls,
+		maxAge:   maxAge,
+		compress: compress,
+		now:      time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		// Never rotate devices like /dev/null or /dev/stdout
+		f.maxSize = 0
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write writes to the log file, rotating it first if the write would make it
+// exceed its maximum size
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			// Keep logging to the current file rather than losing the messages
+			fmt.Fprintf(os.Stderr, "Unable to rotate the log file %s: %v\n", f.path, err)
+			if f.file == nil {
+				if err := f.open(); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// rotate renames the log file to .1, shifting the previous rotated files,
+// and opens a new log file
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	rolls := f.rolls()
+	// Shift the oldest ones first to not overwrite any of them
+	for i := len(rolls) - 1; i >= 0; i-- {
+		r := rolls[i]
+		if err := os.Rename(r.path, f.rollPath(r.index+1, r.compressed)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.rollPath(1, false)); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if f.compress {
+		if err := compressFile(f.rollPath(1, false)); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to compress the rotated log file: %v\n", err)
+		}
+	}
+	f.removeExpiredRolls()
+	return nil
+}
+
+func (f *RotatingFile) rollPath(index int, compressed bool) string {
+	path := f.path + "." + strconv.Itoa(index)
+	if compressed {
+		path += compressedExt
+	}
+	return path
+}
+
+type roll struct {
+	path       string
+	index      int
+	compressed bool
+}
+
+// rolls returns the rotated files, the most recent first
+func (f *RotatingFile) rolls() []roll {
+	matches, _ := filepath.Glob(f.path + ".*")
+	var rolls []roll
+	for _, path := range matches {
+		suffix := strings.TrimPrefix(path, f.path+".")
+		compressed := strings.HasSuffix(suffix, compressedExt)
+		index, err := strconv.Atoi(strings.TrimSuffix(suffix, compressedExt))
+		if err != nil || index <= 0 {
+			continue
+		}
+		rolls = append(rolls, roll{path: path, index: index, compressed: compressed})
+	}
+	sort.Slice(rolls, func(i, j int) bool { return rolls[i].index < rolls[j].index })
+	return rolls
+}
+
+// removeExpiredRolls applies the retention policy to the rotated files
+func (f *RotatingFile) removeExpiredRolls() {
+	for i, r := range f.rolls() {
+		expired := f.maxRolls > 0 && i >= f.maxRolls
+		if !expired && f.maxAge > 0 {
+			if info, err := os.Stat(r.path); err == nil && f.now().Sub(info.ModTime()) > f.maxAge {
+				expired = true
+			}
+		}
+		if expired {
+			os.Remove(r.path) //nolint:errcheck
+		}
+	}
+}
+
+// compressFile replaces a file with its gzip compressed version, keeping its
+// modification time for the age based retention
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstPath := path + compressedExt
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dstPath) //nolint:errcheck
+		return err
+	}
+	os.Chtimes(dstPath, info.ModTime(), info.ModTime()) //nolint:errcheck
+	return os.Remove(path)
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLogFile(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	if filepath.Ext(path) != compressedExt {
+		content, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		return string(content)
+	}
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.log")
+
+	f, err := NewRotatingFile(path, 10, 2, 0, false)
+	require.NoError(t, err)
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, "fourth\n", readLogFile(t, path))
+	assert.Equal(t, "third\n", readLogFile(t, path+".1"))
+	assert.Equal(t, "second\n", readLogFile(t, path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	// Reopening the file keeps its size
+	f, err = NewRotatingFile(path, 10, 2, 0, false)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("fifth\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "fifth\n", readLogFile(t, path))
+	assert.Equal(t, "fourth\n", readLogFile(t, path+".1"))
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.log")
+
+	f, err := NewRotatingFile(path, 10, 0, 0, true)
+	require.NoError(t, err)
+	defer f.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "third\n", readLogFile(t, path))
+	assert.Equal(t, "second\n", readLogFile(t, path+".1.gz"))
+	assert.Equal(t, "first\n", readLogFile(t, path+".2.gz"))
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.log")
+
+	f, err := NewRotatingFile(path, 10, 0, 24*time.Hour, false)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.FileExists(t, path+".1")
+
+	// The roll is removed once it's older than the maximum age
+	f.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	_, err = f.Write([]byte("third\n"))
+	require.NoError(t, err)
+	assert.NoFileExists(t, path+".2")
+	assert.NoFileExists(t, path+".1")
+}
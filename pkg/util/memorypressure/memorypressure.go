@@ -0,0 +1,250 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package memorypressure monitors the resident memory of the Agent process and
+// instructs its pipelines to shed load when it approaches a limit.
+package memorypressure
+
+import (
+	"expvar"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/cihub/seelog"
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/autotune"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Level is how much load the pipelines shed
+type Level int32
+
+const (
+	// None means nothing is shed
+	None Level = iota
+	// Soft means the debug and trace logs are dropped
+	Soft
+	// Hard means, on top of the Soft level, the dogstatsd queue is reduced
+	// and the process payloads are paused
+	Hard
+)
+
+// String returns the name of the level
+func (l Level) String() string {
+	switch l {
+	case Soft:
+		return "soft"
+	case Hard:
+		return "hard"
+	}
+	return "none"
+}
+
+// The actions reported to Shed
+const (
+	ActionDebugLogs        = "debug_logs"
+	ActionDogstatsdPackets = "dogstatsd_packets"
+	ActionProcessPayloads  = "process_payloads"
+)
+
+// recoveryRatio is the share of a threshold the RSS must go under to leave its
+// level, so the level doesn't flap around the threshold
+const recoveryRatio = 0.95
+
+var (
+	// current is the current Level
+	current int32
+
+	pressureExpvars = expvar.NewMap("memory_pressure")
+	levelExpvar     = expvar.String{}
+	rssExpvar       = expvar.Int{}
+	shedExpvars     = map[string]*expvar.Int{
+		ActionDebugLogs:        {},
+		ActionDogstatsdPackets: {},
+		ActionProcessPayloads:  {},
+	}
+
+	tlmLevel = telemetry.NewGauge("memory_pressure", "level",
+		nil, "Shedding level of the memory pressure, 0 for none, 1 for soft and 2 for hard")
+	tlmShed = telemetry.NewCounter("memory_pressure", "shed",
+		[]string{"action"}, "Count of items shed because of the memory pressure, by action")
+)
+
+func init() {
+	levelExpvar.Set(None.String())
+	pressureExpvars.Set("Level", &levelExpvar)
+	pressureExpvars.Set("RSS", &rssExpvar)
+	pressureExpvars.Set("DebugLogsShed", shedExpvars[ActionDebugLogs])
+	pressureExpvars.Set("DogstatsdPacketsShed", shedExpvars[ActionDogstatsdPackets])
+	pressureExpvars.Set("ProcessPayloadsShed", shedExpvars[ActionProcessPayloads])
+}
+
+// CurrentLevel returns the shedding level the pipelines must apply
+func CurrentLevel() Level {
+	return Level(atomic.LoadInt32(&current))
+}
+
+// Shed records that a pipeline shed n items with the given action
+func Shed(action string, n int) {
+	if v, ok := shedExpvars[action]; ok {
+		v.Add(int64(n))
+	}
+	tlmShed.Add(float64(n), action)
+}
+
+// Monitor probes the resident memory of the process and sets the shedding level
+type Monitor struct {
+	limit         uint64
+	softThreshold float64
+	hardThreshold float64
+	interval      time.Duration
+	readRSS       func() (uint64, error)
+
+	// logsShed is the count of logs dropped by the logger at the last probe
+	logsShed uint64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewMonitorFromConfig returns the monitor configured with the memory_pressure
+// settings, or nil when it's disabled or no limit is known
+func NewMonitorFromConfig() *Monitor {
+	if !config.Datadog.GetBool("memory_pressure.enabled") {
+		return nil
+	}
+	limit := uint64(config.Datadog.GetInt64("memory_pressure.rss_limit"))
+	if limit == 0 {
+		limits, err := autotune.ReadLimits()
+		if err != nil || limits.Memory == 0 {
+			log.Warnf("The memory pressure monitor is disabled: memory_pressure.rss_limit is not set and the Agent has no cgroup memory limit")
+			return nil
+		}
+		limit = limits.Memory
+	}
+	return newMonitor(
+		limit,
+		config.Datadog.GetFloat64("memory_pressure.soft_threshold"),
+		config.Datadog.GetFloat64("memory_pressure.hard_threshold"),
+		time.Duration(config.Datadog.GetInt("memory_pressure.check_interval"))*time.Second,
+		readProcessRSS,
+	)
+}
+
+func newMonitor(limit uint64, softThreshold, hardThreshold float64, interval time.Duration, readRSS func() (uint64, error)) *Monitor {
+	return &Monitor{
+		limit:         limit,
+		softThreshold: softThreshold,
+		hardThreshold: hardThreshold,
+		interval:      interval,
+		readRSS:       readRSS,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start probes the memory every interval until Stop is called
+func (m *Monitor) Start() {
+	log.Infof("Shedding load when the RSS reaches %.0f%% and %.0f%% of %d MiB", m.softThreshold*100, m.hardThreshold*100, m.limit>>20)
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.probe()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the monitor and stops shedding load
+func (m *Monitor) Stop() {
+	close(m.stop)
+	<-m.done
+	atomic.StoreInt32(&current, int32(None))
+	levelExpvar.Set(None.String())
+	tlmLevel.Set(float64(None))
+	log.SetShedLevel(seelog.TraceLvl)
+}
+
+// probe reads the RSS and updates the shedding level
+func (m *Monitor) probe() {
+	rss, err := m.readRSS()
+	if err != nil {
+		log.Debugf("Could not read the RSS of the process: %v", err)
+		return
+	}
+	rssExpvar.Set(int64(rss))
+
+	// the logger counts the logs it drops, report the new ones
+	shed := log.ShedCount()
+	if shed > m.logsShed {
+		Shed(ActionDebugLogs, int(shed-m.logsShed))
+	}
+	m.logsShed = shed
+
+	m.setLevel(m.levelFor(CurrentLevel(), rss), rss)
+}
+
+// levelFor returns the level for the RSS given the current level
+func (m *Monitor) levelFor(level Level, rss uint64) Level {
+	ratio := float64(rss) / float64(m.limit)
+	switch {
+	case ratio >= m.hardThreshold:
+		return Hard
+	case level == Hard && ratio >= m.hardThreshold*recoveryRatio:
+		return Hard
+	case ratio >= m.softThreshold:
+		return Soft
+	case level >= Soft && ratio >= m.softThreshold*recoveryRatio:
+		return Soft
+	}
+	return None
+}
+
+func (m *Monitor) setLevel(level Level, rss uint64) {
+	previous := Level(atomic.SwapInt32(&current, int32(level)))
+	if previous == level {
+		return
+	}
+	levelExpvar.Set(level.String())
+	tlmLevel.Set(float64(level))
+
+	if level >= Soft {
+		log.SetShedLevel(seelog.InfoLvl)
+	} else {
+		log.SetShedLevel(seelog.TraceLvl)
+	}
+
+	switch {
+	case level == Hard:
+		log.Warnf("The RSS of %d MiB exceeds %.0f%% of the %d MiB limit: dropping the debug logs, reducing the dogstatsd queue and pausing the process payloads", rss>>20, m.hardThreshold*100, m.limit>>20)
+	case level == Soft && previous < Soft:
+		log.Warnf("The RSS of %d MiB exceeds %.0f%% of the %d MiB limit: dropping the debug logs", rss>>20, m.softThreshold*100, m.limit>>20)
+	case level == Soft:
+		log.Infof("The RSS of %d MiB is back under %.0f%% of the %d MiB limit: resuming the dogstatsd queue and the process payloads", rss>>20, m.hardThreshold*100, m.limit>>20)
+	default:
+		log.Infof("The RSS of %d MiB is back under %.0f%% of the %d MiB limit: not shedding load anymore", rss>>20, m.softThreshold*100, m.limit>>20)
+	}
+}
+
+func readProcessRSS() (uint64, error) {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	info, err := p.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.RSS, nil
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package memorypressure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mib = 1 << 20
+
+func TestLevelFor(t *testing.T) {
+	m := newMonitor(100*mib, 0.8, 0.9, time.Second, nil)
+
+	for _, tt := range []struct {
+		current Level
+		rss     uint64
+		want    Level
+	}{
+		{None, 50 * mib, None},
+		{None, 80 * mib, Soft},
+		{None, 95 * mib, Hard},
+		// the levels are left under 95% of their threshold
+		{Soft, 77 * mib, Soft},
+		{Soft, 75 * mib, None},
+		{Hard, 86 * mib, Hard},
+		{Hard, 85 * mib, Soft},
+		{Hard, 10 * mib, None},
+	} {
+		assert.Equal(t, tt.want, m.levelFor(tt.current, tt.rss))
+	}
+}
+
+func TestProbe(t *testing.T) {
+	rss := uint64(50 * mib)
+	m := newMonitor(100*mib, 0.8, 0.9, time.Second, func() (uint64, error) { return rss, nil })
+	defer m.setLevel(None, 0)
+
+	m.probe()
+	assert.Equal(t, None, CurrentLevel())
+	assert.Equal(t, int64(50*mib), rssExpvar.Value())
+
+	rss = 92 * mib
+	m.probe()
+	assert.Equal(t, Hard, CurrentLevel())
+	assert.Equal(t, "hard", levelExpvar.Value())
+
+	rss = 82 * mib
+	m.probe()
+	assert.Equal(t, Soft, CurrentLevel())
+
+	rss = 10 * mib
+	m.probe()
+	assert.Equal(t, None, CurrentLevel())
+	assert.Equal(t, "none", levelExpvar.Value())
+}
+
+func TestShed(t *testing.T) {
+	before := shedExpvars[ActionDogstatsdPackets].Value()
+	Shed(ActionDogstatsdPackets, 32)
+	assert.Equal(t, before+32, shedExpvars[ActionDogstatsdPackets].Value())
+}
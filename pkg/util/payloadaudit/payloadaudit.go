@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package payloadaudit tees scrubbed copies of the payloads the Agent sends to
+// a local directory, for a bounded duration, so users can inspect exactly what
+// leaves the host.
+package payloadaudit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The kinds of payloads recorded outside of the forwarder, which records its
+// payloads under the name of their endpoint
+const (
+	KindLogs   = "logs"
+	KindTraces = "traces"
+)
+
+var (
+	enabled uint32
+
+	mu      sync.Mutex
+	current *auditor
+
+	unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+type auditor struct {
+	dir      string
+	maxBytes int64
+	written  int64
+	seq      uint64
+	timer    *time.Timer
+}
+
+// Enabled returns whether the payloads are being recorded
+func Enabled() bool {
+	return atomic.LoadUint32(&enabled) == 1
+}
+
+// StartFromConfig starts recording the payloads if `payload_audit.enabled` is set
+func StartFromConfig() error {
+	if !config.Datadog.GetBool("payload_audit.enabled") {
+		return nil
+	}
+	return StartWithConfig()
+}
+
+// StartWithConfig starts recording the payloads with the `payload_audit` settings,
+// regardless of `payload_audit.enabled`
+func StartWithConfig() error {
+	dir := config.Datadog.GetString("payload_audit.directory")
+	if dir == "" {
+		dir = filepath.Join(config.Datadog.GetString("run_path"), "payload_audit")
+	}
+	duration := time.Duration(config.Datadog.GetInt("payload_audit.duration")) * time.Second
+	return Start(dir, duration, config.Datadog.GetInt64("payload_audit.max_size"))
+}
+
+// Start records the payloads to dir until duration elapses or maxBytes have
+// been written, whichever comes first. A running audit is replaced.
+func Start(dir string, duration time.Duration, maxBytes int64) error {
+	if duration <= 0 || maxBytes <= 0 {
+		return fmt.Errorf("the payload audit duration and max size must be positive")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create the payload audit directory: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	stopLocked()
+
+	a := &auditor{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+	a.timer = time.AfterFunc(duration, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if current == a {
+			log.Infof("Payload audit: %s elapsed, stopping", duration)
+			stopLocked()
+		}
+	})
+	current = a
+	atomic.StoreUint32(&enabled, 1)
+	log.Warnf("Payload audit: recording scrubbed copies of the outgoing payloads to %s for %s", dir, duration)
+	return nil
+}
+
+// Stop stops recording the payloads
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	stopLocked()
+}
+
+func stopLocked() {
+	if current == nil {
+		return
+	}
+	current.timer.Stop()
+	log.Infof("Payload audit: stopped after writing %d payloads (%d bytes) to %s", current.seq, current.written, current.dir)
+	current = nil
+	atomic.StoreUint32(&enabled, 0)
+}
+
+// Record writes a scrubbed copy of payload to the audit directory. The payload
+// is decompressed first if it is zlib or gzip compressed, and indented if it is
+// JSON. It is a no-op when the audit is not running.
+func Record(kind string, payload []byte) {
+	if !Enabled() {
+		return
+	}
+
+	content, err := scrub(payload)
+	if err != nil {
+		log.Warnf("Payload audit: unable to scrub a %s payload, it is not recorded: %v", kind, err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	a := current
+	if a == nil {
+		return
+	}
+	if a.written+int64(len(content)) > a.maxBytes {
+		log.Infof("Payload audit: the %d bytes budget is exhausted, stopping", a.maxBytes)
+		stopLocked()
+		return
+	}
+
+	a.seq++
+	name := fmt.Sprintf("%s-%s-%06d", unsafeChars.ReplaceAllString(kind, "_"), time.Now().UTC().Format("20060102T150405"), a.seq)
+	if err := ioutil.WriteFile(filepath.Join(a.dir, name), content, 0600); err != nil {
+		log.Warnf("Payload audit: unable to write %s: %v", name, err)
+		return
+	}
+	a.written += int64(len(content))
+}
+
+// scrub returns the decompressed, indented and scrubbed content of payload
+func scrub(payload []byte) ([]byte, error) {
+	content := decompress(payload)
+	if json.Valid(content) {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, content, "", "  "); err == nil {
+			content = indented.Bytes()
+		}
+	}
+	return log.CredentialsCleanerBytes(content)
+}
+
+// decompress returns the decompressed payload, or the payload itself if it isn't
+// zlib or gzip compressed
+func decompress(payload []byte) []byte {
+	if len(payload) < 2 {
+		return payload
+	}
+
+	var (
+		r   io.Reader
+		err error
+	)
+	switch {
+	case payload[0] == 0x1f && payload[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(payload))
+	case payload[0] == 0x78 && (uint16(payload[0])<<8|uint16(payload[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload
+	}
+	if err != nil {
+		return payload
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return payload
+	}
+	return content
+}
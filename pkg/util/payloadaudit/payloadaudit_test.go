@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package payloadaudit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const payload = `{"series":[{"metric":"foo","tags":["api_key:aaaaaaaaaaaaaaaaaaaaaaaaaaabbbbb"]}]}`
+
+func readAudit(t *testing.T, dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var contents []string
+	for _, f := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		require.NoError(t, err)
+		contents = append(contents, string(b))
+	}
+	return contents
+}
+
+func TestRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloadaudit")
+	require.NoError(t, err)
+
+	// not started yet
+	Record("series_v1", []byte(payload))
+
+	require.NoError(t, Start(dir, time.Minute, 1<<20))
+	defer Stop()
+	assert.True(t, Enabled())
+
+	var zipped bytes.Buffer
+	zw := zlib.NewWriter(&zipped)
+	zw.Write([]byte(payload))
+	zw.Close()
+	Record("series_v1", zipped.Bytes())
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte("plain text"))
+	gw.Close()
+	Record(KindLogs, gzipped.Bytes())
+
+	files, err := filepath.Glob(filepath.Join(dir, "series_v1-*"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	contents := readAudit(t, dir)
+	require.Len(t, contents, 2)
+	assert.Contains(t, contents, "plain text")
+	for _, c := range contents {
+		assert.NotContains(t, c, "aaaaaaaaaaaaaaaaaaaaaaaaaaabbbbb")
+	}
+	assert.Contains(t, contents[1], "\n  \"series\": [")
+	assert.Contains(t, contents[1], "***************************bbbbb")
+
+	Stop()
+	assert.False(t, Enabled())
+	Record(KindLogs, []byte("after stop"))
+	assert.Len(t, readAudit(t, dir), 2)
+}
+
+func TestRecordBudget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloadaudit")
+	require.NoError(t, err)
+
+	require.NoError(t, Start(dir, time.Minute, 15))
+	defer Stop()
+
+	Record(KindTraces, []byte("0123456789"))
+	Record(KindTraces, []byte("0123456789"))
+	assert.False(t, Enabled())
+	assert.Len(t, readAudit(t, dir), 1)
+}
+
+func TestRecordDuration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloadaudit")
+	require.NoError(t, err)
+
+	require.NoError(t, Start(dir, 10*time.Millisecond, 1<<20))
+	defer Stop()
+
+	assert.Eventually(t, func() bool { return !Enabled() }, time.Second, 5*time.Millisecond)
+}
+
+func TestStartInvalid(t *testing.T) {
+	assert.Error(t, Start("", time.Minute, 0))
+	assert.Error(t, Start("", 0, 1))
+	assert.False(t, Enabled())
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package pdhutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Metric types a counter can be submitted as
+const (
+	MetricTypeGauge          = "gauge"
+	MetricTypeRate           = "rate"
+	MetricTypeCount          = "count"
+	MetricTypeMonotonicCount = "monotonic_count"
+)
+
+// CounterConfig declares a performance counter to collect, as found in the
+// YAML configuration of a check.
+type CounterConfig struct {
+	// Class is the english name of the counter class, e.g. "Processor"
+	Class string `yaml:"class"`
+	// Counter is the english name of the counter, e.g. "% Processor Time"
+	Counter string `yaml:"counter"`
+	// Instances lists the instances to collect for a multi-instance class.
+	// Shell wildcards are supported, "*" collecting all the instances. Leave
+	// empty for a single-instance class.
+	Instances []string `yaml:"instances"`
+	// ExcludeInstances lists the instances not to collect, with wildcards
+	ExcludeInstances []string `yaml:"exclude_instances"`
+	// Metric is the name of the metric to submit
+	Metric string `yaml:"metric"`
+	// Type is the metric type: gauge (default), rate, count or monotonic_count
+	Type string `yaml:"type"`
+	// InstanceTag is the tag holding the instance name, "instance" by default
+	InstanceTag string `yaml:"instance_tag"`
+	// Scale multiplies the collected values, e.g. 1024 to convert KB to bytes
+	Scale float64 `yaml:"scale"`
+	// Inverse submits Inverse-value instead of the value, e.g. 100 to turn an
+	// "% Idle Time" into a busy percentage
+	Inverse float64 `yaml:"inverse"`
+}
+
+// Validate checks the configuration and sets the defaults.
+func (c *CounterConfig) Validate() error {
+	if c.Class == "" || c.Counter == "" {
+		return fmt.Errorf("class and counter are required")
+	}
+	if c.Metric == "" {
+		return fmt.Errorf("metric is required for counter %s\\%s", c.Class, c.Counter)
+	}
+	switch c.Type {
+	case "":
+		c.Type = MetricTypeGauge
+	case MetricTypeGauge, MetricTypeRate, MetricTypeCount, MetricTypeMonotonicCount:
+	default:
+		return fmt.Errorf("unknown type %q for metric %s", c.Type, c.Metric)
+	}
+	for _, pattern := range append(c.Instances, c.ExcludeInstances...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid instance pattern %q for metric %s: %v", pattern, c.Metric, err)
+		}
+	}
+	if c.InstanceTag == "" {
+		c.InstanceTag = "instance"
+	}
+	if c.Scale == 0 {
+		c.Scale = 1
+	}
+	return nil
+}
+
+// matchInstance returns whether the instance is requested and not excluded.
+// Instance names are matched case-insensitively, as PDH does.
+func (c *CounterConfig) matchInstance(instance string) bool {
+	return matchAny(c.Instances, instance) && !matchAny(c.ExcludeInstances, instance)
+}
+
+func matchAny(patterns []string, instance string) bool {
+	instance = strings.ToLower(instance)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), instance); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// transform applies the configured transformations to a collected value
+func (c *CounterConfig) transform(val float64) float64 {
+	val *= c.Scale
+	if c.Inverse != 0 {
+		val = c.Inverse - val
+	}
+	return val
+}
+
+// ConfiguredCounter collects a performance counter declared by a CounterConfig
+type ConfiguredCounter struct {
+	Config CounterConfig
+	single *PdhSingleInstanceCounterSet
+	multi  *PdhMultiInstanceCounterSet
+}
+
+// NewConfiguredCounter validates the configuration and opens the counter.
+// Instances appearing after the creation are collected too.
+func NewConfiguredCounter(config CounterConfig) (*ConfiguredCounter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	c := &ConfiguredCounter{Config: config}
+
+	var err error
+	if len(config.Instances) == 0 {
+		c.single, err = GetSingleInstanceCounter(config.Class, config.Counter)
+	} else {
+		c.multi, err = GetMultiInstanceCounter(config.Class, config.Counter, nil, c.Config.matchInstance)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open counter %s\\%s: %v", config.Class, config.Counter, err)
+	}
+	return c, nil
+}
+
+// GetValues returns the transformed values of the counter by instance name,
+// the only key being the empty string for a single-instance counter.
+func (c *ConfiguredCounter) GetValues() (map[string]float64, error) {
+	if c.single != nil {
+		val, err := c.single.GetValue()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]float64{"": c.Config.transform(val)}, nil
+	}
+
+	values, err := c.multi.GetAllValues()
+	if err != nil {
+		return nil, err
+	}
+	for inst, val := range values {
+		values[inst] = c.Config.transform(val)
+	}
+	return values, nil
+}
+
+// Close frees the counter resources.
+func (c *ConfiguredCounter) Close() {
+	if c.single != nil {
+		c.single.Close()
+	} else if c.multi != nil {
+		c.multi.Close()
+	}
+}
@@ -100,6 +100,8 @@ typedef void (*cb_submit_service_check_t)(char *, char *, int, char **, char *,
 typedef void (*cb_submit_event_t)(char *, event_t *);
 // (id, metric_name, value, lower_bound, upper_bound, monotonic, hostname, tags)
 typedef void (*cb_submit_histogram_bucket_t)(char *, char *, long long, float, float, int, char *, char **);
+// (id, raw_event, track)
+typedef void (*cb_submit_event_platform_event_t)(char *, char *, char *);
 
 // datadog_agent
 //
@@ -406,3 +406,29 @@ func TestSubmitHistogramBucket(t *testing.T) {
 	// Check for leaks
 	helpers.AssertMemoryUsage(t)
 }
+
+func TestSubmitEventPlatformEvent(t *testing.T) {
+	// Reset memory counters
+	helpers.ResetMemoryStats()
+
+	out, err := run(`aggregator.submit_event_platform_event(None, 'id', '{"foo": "bar"}', 'dbm-samples')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != "" {
+		t.Errorf("Unexpected printed value: '%s'", out)
+	}
+	if checkID != "id" {
+		t.Fatalf("Unexpected id value: %s", checkID)
+	}
+	if rawEvent != `{"foo": "bar"}` {
+		t.Fatalf("Unexpected raw event value: %s", rawEvent)
+	}
+	if track != "dbm-samples" {
+		t.Fatalf("Unexpected track value: %s", track)
+	}
+
+	// Check for leaks
+	helpers.AssertMemoryUsage(t)
+}
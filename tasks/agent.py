@@ -64,6 +64,7 @@ AGENT_CORECHECKS = [
     "systemd",
     "tcp_queue_length",
     "uptime",
+    "windows_counters",
     "winproc",
 ]
 
@@ -84,15 +85,23 @@ IOT_AGENT_CORECHECKS = [
 def build(ctx, rebuild=False, race=False, build_include=None, build_exclude=None,
           iot=False, development=True, precompile_only=False, skip_assets=False,
           embedded_path=None, rtloader_root=None, python_home_2=None, python_home_3=None,
-          major_version='7', python_runtimes='3', arch='x64', exclude_rtloader=False, go_mod="vendor"):
+          major_version='7', python_runtimes='3', arch='x64', exclude_rtloader=False, go_mod="vendor",
+          edge=False):
     """
     Build the agent. If the bits to include in the build are not specified,
     the values from `invoke.yaml` will be used.
 
+    The edge profile (--edge) is an IoT Agent without the optional subsystems,
+    like the logs-agent, to reduce the size and the memory usage on constrained
+    devices.
+
     Example invokation:
         inv agent.build --build-exclude=systemd
     """
 
+    # the edge profile is an IoT Agent build
+    iot = iot or edge
+
     if not exclude_rtloader and not iot:
         rtloader_make(ctx, python_runtimes=python_runtimes)
         rtloader_install(ctx)
@@ -149,7 +158,7 @@ def build(ctx, rebuild=False, race=False, build_include=None, build_exclude=None
 
     if iot:
         # Iot mode overrides whatever passed through `--build-exclude` and `--build-include`
-        build_tags = get_default_build_tags(iot=True)
+        build_tags = get_default_build_tags(iot=True, edge=edge)
     else:
         build_tags = get_build_tags(build_include, build_exclude)
 
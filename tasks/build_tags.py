@@ -15,10 +15,12 @@ ALL_TAGS = set([
     "ec2",
     "etcd",
     "fargateprocess",
+    "fips", # Enforce the FIPS mode, requires a Go toolchain with BoringCrypto
     "gce",
     "jmx",
     "kubeapiserver",
     "kubelet",
+    "log",
     "netcgo", # Force the use of the CGO resolver. This will also have the effect of making the binary non-static
     "orchestrator",
     "process",
@@ -31,15 +33,28 @@ ALL_TAGS = set([
 
 # IOT_AGENT_TAGS lists the tags needed when building the IOT Agent
 IOT_AGENT_TAGS = [
+    "log",
     "zlib",
     "systemd",
 ]
 
+# EDGE_AGENT_TAGS lists the tags needed when building the edge profile of the
+# IOT Agent, which compiles out the optional subsystems for constrained devices
+EDGE_AGENT_TAGS = [
+    "zlib",
+]
+
 ANDROID_TAGS = [
+    "log",
     "zlib",
     "android",
 ]
 
+# OPT_IN_TAGS lists the tags only enabled when explicitly included
+OPT_IN_TAGS = [
+    "fips",
+]
+
 PROCESS_ONLY_TAGS = [
     "fargateprocess",
     "orchestrator",
@@ -59,7 +74,7 @@ WINDOWS_32BIT_EXCLUDE_TAGS = [
 ]
 
 
-def get_default_build_tags(iot=False, process=False, arch="x64", android=False):
+def get_default_build_tags(iot=False, process=False, arch="x64", android=False, edge=False):
     """
     Build the default list of tags based on the current platform.
 
@@ -69,6 +84,8 @@ def get_default_build_tags(iot=False, process=False, arch="x64", android=False):
     include = ["all"]
     if iot:
         include = IOT_AGENT_TAGS
+    if edge:
+        include = EDGE_AGENT_TAGS
 
     # android has its own set of tags
     if android:
@@ -92,7 +109,7 @@ def get_build_tags(include, exclude):
     """
     # special case, include == all
     if "all" in include:
-        return list(ALL_TAGS - set(exclude))
+        return list(ALL_TAGS - set(OPT_IN_TAGS) - set(exclude))
 
     # filter out unrecognised tags
     include = ALL_TAGS.intersection(set(include))
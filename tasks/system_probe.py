@@ -305,7 +305,7 @@ def build_object_files(ctx, install=True):
         # Now update the assets stored in the go code
         commands.append("go get -u github.com/jteeuwen/go-bindata/...")
 
-        assets_cmd = os.environ["GOPATH"]+"/bin/go-bindata -pkg bytecode -prefix '{c_dir}' -modtime 1 -o '{go_file}' '{obj_file}' '{debug_obj_file}' '{tcp_queue_length_kern_c_file}' '{tcp_queue_length_kern_user_h_file}'"
+        assets_cmd = os.environ["GOPATH"]+"/bin/go-bindata -pkg bytecode -prefix '{c_dir}' -modtime 1 -o '{go_file}' '{obj_file}' '{debug_obj_file}' '{tcp_queue_length_kern_c_file}' '{tcp_queue_length_kern_user_h_file}' '{conntrack_kern_c_file}' '{conntrack_kern_user_h_file}' '{file_integrity_kern_c_file}' '{file_integrity_kern_user_h_file}'"
         go_file = os.path.join(bpf_dir, "bytecode", "tracer-ebpf.go")
         commands.append(assets_cmd.format(
             c_dir=c_dir,
@@ -314,6 +314,10 @@ def build_object_files(ctx, install=True):
             debug_obj_file=debug_obj_file,
             tcp_queue_length_kern_c_file=os.path.join(c_dir, "tcp-queue-length-kern.c"),
             tcp_queue_length_kern_user_h_file=os.path.join(c_dir, "tcp-queue-length-kern-user.h"),
+            conntrack_kern_c_file=os.path.join(c_dir, "conntrack-kern.c"),
+            conntrack_kern_user_h_file=os.path.join(c_dir, "conntrack-kern-user.h"),
+            file_integrity_kern_c_file=os.path.join(c_dir, "file-integrity-kern.c"),
+            file_integrity_kern_user_h_file=os.path.join(c_dir, "file-integrity-kern-user.h"),
         ))
 
         commands.append("gofmt -w -s {go_file}".format(go_file=go_file))
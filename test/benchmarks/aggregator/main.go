@@ -105,6 +105,10 @@ func (f *forwarderBenchStub) SubmitMetadata(payload forwarder.Payloads, extraHea
 	return nil
 }
 
+func (f *forwarderBenchStub) SubmitEventPlatformEvents(payload forwarder.Payloads, extraHeaders http.Header, track string) error {
+	return nil
+}
+
 type aggregatorStats struct {
 	Flush map[string]aggregator.Stats
 }
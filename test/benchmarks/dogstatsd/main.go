@@ -113,6 +113,11 @@ func (f *forwarderBenchStub) SubmitMetadata(payloads forwarder.Payloads, extraHe
 	return nil
 }
 
+func (f *forwarderBenchStub) SubmitEventPlatformEvents(payloads forwarder.Payloads, extraHeaders http.Header, track string) error {
+	f.computeStats(payloads)
+	return nil
+}
+
 // NewStatsdGenerator returns a generator server
 // We could use datadog-go, but I want as little overhead as possible.
 func NewStatsdGenerator(uri string) (*net.UDPConn, error) {